@@ -0,0 +1,288 @@
+package bacalhau
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/auth"
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"github.com/filecoin-project/bacalhau/pkg/requester/publicapi"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/util/templates"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	authLong = templates.LongDesc(i18n.T(`
+		Manage the client keys and tokens a requester node accepts.
+
+		Requester nodes started with --auth-registry only accept requests signed with a
+		registered client key, or carrying a token. Keys and tokens are managed through
+		the node's API, which needs the admin scope, or directly in a registry file with
+		--registry (e.g. to register the first admin key).
+`))
+
+	//nolint:lll // Documentation
+	authExample = templates.Examples(i18n.T(`
+		# Show this client's public key, to register it with a node
+		bacalhau auth key show
+
+		# Register the first admin key in a node's registry file
+		bacalhau auth key add --registry /etc/bacalhau/auth.json --scope admin <public-key>
+
+		# Create a token that can submit jobs and read its client's jobs, for a week
+		bacalhau auth token create --client-id <client-id> --scope submit,list-own --ttl 168h
+
+		# Use a token for all requests from this client
+		bacalhau auth login <token>
+`))
+)
+
+// AuthOptions is a struct to support the auth commands
+type AuthOptions struct {
+	Registry    string        // Registry file to manage instead of the node's API.
+	Scopes      []string      // Scopes of added keys and created tokens.
+	Description string        // Description of an added key.
+	ClientID    string        // Client a token is created for.
+	TTL         time.Duration // How long a created token is valid for.
+	Save        bool          // Whether to store a created token in the config.
+}
+
+func NewAuthOptions() *AuthOptions {
+	return &AuthOptions{
+		Scopes: []string{string(handlerwrapper.ScopeSubmit), string(handlerwrapper.ScopeListOwn)},
+	}
+}
+
+func newAuthCmd() *cobra.Command {
+	OA := NewAuthOptions()
+
+	authCmd := &cobra.Command{
+		Use:     "auth",
+		Short:   "Manage client keys and tokens for requester API authentication",
+		Long:    authLong,
+		Example: authExample,
+	}
+
+	keyCmd := &cobra.Command{
+		Use:   "key",
+		Short: "Manage registered client keys",
+	}
+	keyCmd.PersistentFlags().StringVar(&OA.Registry, "registry", OA.Registry,
+		`Registry file to manage, instead of the requester node's API.`)
+	keyCmd.AddCommand(
+		&cobra.Command{
+			Use:   "show",
+			Short: "Show this client's ID and public key",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				return printYAML(cmd, auth.ClientKey{
+					ClientID:  system.GetClientID(),
+					PublicKey: system.GetClientPublicKey(),
+				})
+			},
+		},
+		newAuthKeyAddCmd(OA),
+		&cobra.Command{
+			Use:   "list",
+			Short: "List registered client keys",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				manager, err := getAuthManager(OA)
+				if err != nil {
+					return err
+				}
+				keys, err := manager.ListKeys(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return printYAML(cmd, keys)
+			},
+		},
+		&cobra.Command{
+			Use:   "remove <client-id>",
+			Short: "Remove a client's registered key",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				manager, err := getAuthManager(OA)
+				if err != nil {
+					return err
+				}
+				return manager.RemoveKey(cmd.Context(), args[0])
+			},
+		},
+	)
+
+	tokenCmd := &cobra.Command{
+		Use:   "token",
+		Short: "Manage client tokens",
+	}
+	tokenCmd.PersistentFlags().StringVar(&OA.Registry, "registry", OA.Registry,
+		`Registry file to manage, instead of the requester node's API.`)
+	tokenCmd.AddCommand(
+		newAuthTokenCreateCmd(OA),
+		&cobra.Command{
+			Use:   "list",
+			Short: "List issued tokens",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				manager, err := getAuthManager(OA)
+				if err != nil {
+					return err
+				}
+				tokens, err := manager.ListTokens(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return printYAML(cmd, tokens)
+			},
+		},
+		&cobra.Command{
+			Use:   "revoke <token-id>",
+			Short: "Revoke a token",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				manager, err := getAuthManager(OA)
+				if err != nil {
+					return err
+				}
+				return manager.RevokeToken(cmd.Context(), args[0])
+			},
+		},
+	)
+
+	authCmd.AddCommand(
+		keyCmd,
+		tokenCmd,
+		&cobra.Command{
+			Use:   "login <token>",
+			Short: "Store a token to send with all requests from this client",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return system.SetAPIToken(args[0])
+			},
+		},
+		&cobra.Command{
+			Use:   "logout",
+			Short: "Remove the stored token",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				return system.SetAPIToken("")
+			},
+		},
+		&cobra.Command{
+			Use:   "whoami",
+			Short: "Show who the requester node authenticates this client as",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				res, err := GetAPIClient().Whoami(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return printYAML(cmd, res)
+			},
+		},
+	)
+
+	return authCmd
+}
+
+func newAuthKeyAddCmd(OA *AuthOptions) *cobra.Command {
+	addCmd := &cobra.Command{
+		Use:   "add <public-key>",
+		Short: "Register a client's public key (see 'bacalhau auth key show')",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scopes, err := parseScopes(OA.Scopes)
+			if err != nil {
+				return err
+			}
+			manager, err := getAuthManager(OA)
+			if err != nil {
+				return err
+			}
+			key, err := manager.AddKey(cmd.Context(), args[0], scopes, OA.Description)
+			if err != nil {
+				return err
+			}
+			return printYAML(cmd, key)
+		},
+	}
+	addCmd.Flags().StringSliceVar(&OA.Scopes, "scope", OA.Scopes,
+		fmt.Sprintf(`Scopes of the key, any of %v.`, handlerwrapper.Scopes()))
+	addCmd.Flags().StringVar(&OA.Description, "description", OA.Description,
+		`Who or what the key belongs to.`)
+	return addCmd
+}
+
+func newAuthTokenCreateCmd(OA *AuthOptions) *cobra.Command {
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a token for a client",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			scopes, err := parseScopes(OA.Scopes)
+			if err != nil {
+				return err
+			}
+			clientID := OA.ClientID
+			if clientID == "" {
+				clientID = system.GetClientID()
+			}
+			manager, err := getAuthManager(OA)
+			if err != nil {
+				return err
+			}
+			secret, token, err := manager.CreateToken(cmd.Context(), clientID, scopes, OA.TTL)
+			if err != nil {
+				return err
+			}
+			if OA.Save {
+				if err = system.SetAPIToken(secret); err != nil {
+					return err
+				}
+			}
+			cmd.Printf("Created token %s for client %s. It is only shown once:\n%s\n", token.ID, token.ClientID, secret)
+			return nil
+		},
+	}
+	createCmd.Flags().StringVar(&OA.ClientID, "client-id", OA.ClientID,
+		`Client the token is for. Defaults to this client.`)
+	createCmd.Flags().StringSliceVar(&OA.Scopes, "scope", OA.Scopes,
+		fmt.Sprintf(`Scopes of the token, any of %v.`, handlerwrapper.Scopes()))
+	createCmd.Flags().DurationVar(&OA.TTL, "ttl", OA.TTL,
+		`How long the token is valid for. Never expires when 0.`)
+	createCmd.Flags().BoolVar(&OA.Save, "save", OA.Save,
+		`Store the token to send with all requests from this client.`)
+	return createCmd
+}
+
+func getAuthManager(OA *AuthOptions) (auth.Manager, error) {
+	if OA.Registry != "" {
+		return auth.NewRegistry(auth.RegistryParams{Path: OA.Registry})
+	}
+	return publicapi.NewAuthAPIClient(GetAPIClient()), nil
+}
+
+func parseScopes(values []string) ([]handlerwrapper.Scope, error) {
+	scopes := make([]handlerwrapper.Scope, 0, len(values))
+	for _, value := range values {
+		scope, err := handlerwrapper.ParseScope(value)
+		if err != nil {
+			return nil, err
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+func printYAML(cmd *cobra.Command, v interface{}) error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	cmd.Print(string(b))
+	return nil
+}
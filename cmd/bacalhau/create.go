@@ -179,6 +179,13 @@ func create(cmd *cobra.Command, cmdArgs []string, OC *CreateOptions) error { //n
 		return err
 	}
 
+	// Jobs written with the V1beta2 API are rewritten in the form jobs are read in below
+	byteResult, err = convertV1beta2JobDocument(byteResult)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("%s: %s", userstrings.JobSpecBad, err), 1)
+		return err
+	}
+
 	// Turns out the yaml parser supports both yaml & json (because json is a subset of yaml)
 	// so we can just use that
 	err = model.YAMLUnmarshalWithMax(byteResult, &j)
@@ -274,3 +281,21 @@ func create(cmd *cobra.Command, cmdArgs []string, OC *CreateOptions) error { //n
 
 	return nil
 }
+
+// convertV1beta2JobDocument returns a job written with the V1beta2 API as a document of the form model.Job is read
+// from, or the document as it is if it is written with an older API.
+func convertV1beta2JobDocument(byteResult []byte) ([]byte, error) {
+	jsonData, err := yaml.YAMLToJSON(byteResult)
+	if err != nil {
+		return nil, err
+	}
+	version := jobDocumentAPIVersion(jsonData)
+	if version != model.V1beta2 {
+		return byteResult, nil
+	}
+	job, err := model.APIVersionParseJob(version.String(), string(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	return model.YAMLMarshalWithMax(job)
+}
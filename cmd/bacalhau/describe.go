@@ -2,12 +2,14 @@ package bacalhau
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/filecoin-project/bacalhau/pkg/bacerrors"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/filecoin-project/bacalhau/pkg/util/templates"
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
 	"k8s.io/kubectl/pkg/util/i18n"
 	"sigs.k8s.io/yaml"
 )
@@ -27,6 +29,9 @@ var (
 
 		# Describe a job and include all server and local events
 		bacalhau describe --include-events b6ad164a 
+
+		# Describe a job and show how its results were verified
+		bacalhau describe --verification b6ad164a
 `))
 )
 
@@ -34,6 +39,105 @@ type DescribeOptions struct {
 	Filename      string // Filename for job (can be .json or .yaml)
 	IncludeEvents bool   // Include events in the description
 	OutputSpec    bool   // Print Just the jobspec to stdout
+	Verification  bool   // Include per shard verification results and diffs
+}
+
+// jobDescription is a job, and for broadcast jobs how it went on each node.
+type jobDescription struct {
+	model.Job
+	NodeResults  []nodeResult        `json:"NodeResults,omitempty"`
+	Verification []shardVerification `json:"Verification,omitempty"`
+}
+
+// shardVerification is how the results of each node were verified for one shard.
+type shardVerification struct {
+	ShardIndex int                `json:"ShardIndex"`
+	Nodes      []nodeVerification `json:"Nodes"`
+}
+
+type nodeVerification struct {
+	NodeID   string             `json:"NodeID"`
+	State    model.JobStateType `json:"State"`
+	Complete bool               `json:"Complete"`
+	Verified bool               `json:"Verified"`
+	Reason   string             `json:"Reason,omitempty"`
+	Diff     *model.ResultsDiff `json:"Diff,omitempty"`
+}
+
+// shardVerifications groups a job's verification results by shard, ordered by
+// shard index and node ID.
+func shardVerifications(jobState model.JobState) []shardVerification {
+	byShard := map[int][]nodeVerification{}
+	for nodeID, nodeState := range jobState.Nodes { //nolint:gocritic
+		for _, shardState := range nodeState.Shards { //nolint:gocritic
+			verification := nodeVerification{
+				NodeID:   nodeID,
+				State:    shardState.State,
+				Complete: shardState.VerificationResult.Complete,
+				Verified: shardState.VerificationResult.Result,
+				Diff:     shardState.VerificationResult.Diff,
+			}
+			if verification.Complete && !verification.Verified {
+				verification.Reason = shardState.Status
+			}
+			byShard[shardState.ShardIndex] = append(byShard[shardState.ShardIndex], verification)
+		}
+	}
+
+	shardIndexes := maps.Keys(byShard)
+	sort.Ints(shardIndexes)
+	results := make([]shardVerification, 0, len(shardIndexes))
+	for _, shardIndex := range shardIndexes {
+		nodes := byShard[shardIndex]
+		sort.Slice(nodes, func(i, j int) bool {
+			return nodes[i].NodeID < nodes[j].NodeID
+		})
+		results = append(results, shardVerification{ShardIndex: shardIndex, Nodes: nodes})
+	}
+	return results
+}
+
+// nodeResult is how a broadcast job went on one node.
+type nodeResult struct {
+	NodeID string        `json:"NodeID"`
+	Shards []shardResult `json:"Shards"`
+}
+
+type shardResult struct {
+	ShardIndex int                `json:"ShardIndex"`
+	State      model.JobStateType `json:"State"`
+	Status     string             `json:"Status,omitempty"`
+	ExitCode   *int               `json:"ExitCode,omitempty"`
+	ResultsCID string             `json:"ResultsCID,omitempty"`
+}
+
+// nodeResults groups a job's shard states by node, ordered by node ID and
+// shard index.
+func nodeResults(jobState model.JobState) []nodeResult {
+	nodeIDs := maps.Keys(jobState.Nodes)
+	sort.Strings(nodeIDs)
+	results := make([]nodeResult, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		result := nodeResult{NodeID: nodeID}
+		for _, shardState := range jobState.Nodes[nodeID].Shards { //nolint:gocritic
+			shard := shardResult{
+				ShardIndex: shardState.ShardIndex,
+				State:      shardState.State,
+				Status:     shardState.Status,
+				ResultsCID: shardState.PublishedResult.CID,
+			}
+			if shardState.RunOutput != nil {
+				exitCode := shardState.RunOutput.ExitCode
+				shard.ExitCode = &exitCode
+			}
+			result.Shards = append(result.Shards, shard)
+		}
+		sort.Slice(result.Shards, func(i, j int) bool {
+			return result.Shards[i].ShardIndex < result.Shards[j].ShardIndex
+		})
+		results = append(results, result)
+	}
+	return results
 }
 
 func NewDescribeOptions() *DescribeOptions {
@@ -66,6 +170,10 @@ func newDescribeCmd() *cobra.Command {
 		&OD.IncludeEvents, "include-events", OD.IncludeEvents,
 		`Include events in the description (could be noisy)`,
 	)
+	describeCmd.PersistentFlags().BoolVar(
+		&OD.Verification, "verification", OD.Verification,
+		`Include how the results of each shard were verified, and how they differed`,
+	)
 
 	return describeCmd
 }
@@ -123,7 +231,7 @@ func describe(cmd *cobra.Command, cmdArgs []string, OD *DescribeOptions) error {
 		Fatal(cmd, fmt.Sprintf("Failure retrieving job events '%s': %s\n", j.Metadata.ID, err), 1)
 	}
 
-	jobDesc := j
+	jobDesc := jobDescription{Job: *j}
 	jobDesc.Status.State = shardStates
 
 	if OD.IncludeEvents {
@@ -131,6 +239,15 @@ func describe(cmd *cobra.Command, cmdArgs []string, OD *DescribeOptions) error {
 		jobDesc.Status.LocalEvents = localEvents
 	}
 
+	// each node runs a broadcast job independently, so show how it went on each of them
+	if j.Spec.Deal.Broadcast {
+		jobDesc.NodeResults = nodeResults(shardStates)
+	}
+
+	if OD.Verification {
+		jobDesc.Verification = shardVerifications(shardStates)
+	}
+
 	b, err := model.JSONMarshalWithMax(jobDesc)
 	if err != nil {
 		Fatal(cmd, fmt.Sprintf("Failure marshaling job description '%s': %s\n", j.Metadata.ID, err), 1)
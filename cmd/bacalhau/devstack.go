@@ -31,6 +31,9 @@ var (
 
 		# Create a devstack cluster with a single hybrid (requester and compute) nodes
 		bacalhau devstack  --requester-nodes 0 --compute-nodes 0 --hybrid-nodes 1 
+
+		# Create a devstack cluster with the node groups declared in a topology file
+		bacalhau devstack  --topology cluster.yaml
 `))
 )
 
@@ -52,6 +55,7 @@ func newDevStackCmd() *cobra.Command {
 	ODs := newDevStackOptions()
 	OS := NewServeOptions()
 	IsNoop := false
+	TopologyPath := ""
 
 	devstackCmd := &cobra.Command{
 		Use:     "devstack",
@@ -59,7 +63,7 @@ func newDevStackCmd() *cobra.Command {
 		Long:    devStackLong,
 		Example: devstackExample,
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return runDevstack(cmd, ODs, OS, IsNoop)
+			return runDevstack(cmd, ODs, OS, IsNoop, TopologyPath)
 		},
 	}
 
@@ -107,6 +111,10 @@ func newDevStackCmd() *cobra.Command {
 		&ODs.PublicIPFSMode, "public-ipfs", ODs.PublicIPFSMode,
 		`Connect devstack to public IPFS`,
 	)
+	devstackCmd.PersistentFlags().StringVar(
+		&TopologyPath, "topology", TopologyPath,
+		`Path to a YAML file declaring the node groups of the cluster. Replaces the node and bad actor counts.`,
+	)
 
 	setupJobSelectionCLIFlags(devstackCmd, OS)
 	setupCapacityManagerCLIFlags(devstackCmd, OS)
@@ -114,7 +122,7 @@ func newDevStackCmd() *cobra.Command {
 	return devstackCmd
 }
 
-func runDevstack(cmd *cobra.Command, ODs *devstack.DevStackOptions, OS *ServeOptions, IsNoop bool) error {
+func runDevstack(cmd *cobra.Command, ODs *devstack.DevStackOptions, OS *ServeOptions, IsNoop bool, TopologyPath string) error {
 	cm := system.NewCleanupManager()
 	defer cm.Cleanup()
 	ctx := cmd.Context()
@@ -126,13 +134,21 @@ func runDevstack(cmd *cobra.Command, ODs *devstack.DevStackOptions, OS *ServeOpt
 
 	config.DevstackSetShouldPrintInfo()
 
+	if TopologyPath != "" {
+		topology, err := devstack.LoadTopology(TopologyPath)
+		if err != nil {
+			Fatal(cmd, err.Error(), 1)
+		}
+		ODs.Topology = topology
+	}
+
 	totalComputeNodes := ODs.NumberOfComputeOnlyNodes + ODs.NumberOfHybridNodes
 	totalRequesterNodes := ODs.NumberOfRequesterOnlyNodes + ODs.NumberOfHybridNodes
-	if ODs.NumberOfBadComputeActors > totalComputeNodes {
+	if ODs.Topology == nil && ODs.NumberOfBadComputeActors > totalComputeNodes {
 		Fatal(cmd, fmt.Sprintf("You cannot have more bad compute actors (%d) than there are nodes (%d).",
 			ODs.NumberOfBadComputeActors, totalComputeNodes), 1)
 	}
-	if ODs.NumberOfBadRequesterActors > totalRequesterNodes {
+	if ODs.Topology == nil && ODs.NumberOfBadRequesterActors > totalRequesterNodes {
 		Fatal(cmd, fmt.Sprintf("You cannot have more bad requester actors (%d) than there are nodes (%d).",
 			ODs.NumberOfBadRequesterActors, totalRequesterNodes), 1)
 	}
@@ -155,7 +171,10 @@ func runDevstack(cmd *cobra.Command, ODs *devstack.DevStackOptions, OS *ServeOpt
 		}
 	}
 
-	computeConfig := getComputeConfig(OS)
+	computeConfig, err := getComputeConfig(OS)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Invalid compute node config: %s", err), 1)
+	}
 	if ODs.LocalNetworkLotus {
 		cmd.Println("Note that starting up the Lotus node can take many minutes!")
 	}
@@ -3,9 +3,12 @@ package bacalhau
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
+	"github.com/docker/docker/api/types"
 	"github.com/filecoin-project/bacalhau/pkg/bacerrors"
+	"github.com/filecoin-project/bacalhau/pkg/docker"
 	"github.com/filecoin-project/bacalhau/pkg/downloader/util"
 	jobutils "github.com/filecoin-project/bacalhau/pkg/job"
 	"github.com/filecoin-project/bacalhau/pkg/model"
@@ -13,6 +16,7 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/util/templates"
 	"github.com/filecoin-project/bacalhau/pkg/version"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"k8s.io/kubectl/pkg/util/i18n"
 	"sigs.k8s.io/yaml"
@@ -42,19 +46,26 @@ var (
 
 // DockerRunOptions declares the arguments accepted by the `docker run` command
 type DockerRunOptions struct {
-	Engine           string   // Executor - executor.Executor
-	Verifier         string   // Verifier - verifier.Verifier
-	Publisher        string   // Publisher - publisher.Publisher
-	Inputs           []string // Array of input CIDs
-	InputUrls        []string // Array of input URLs (will be copied to IPFS)
-	InputVolumes     []string // Array of input volumes in 'CID:mount point' form
-	OutputVolumes    []string // Array of output volumes in 'name:mount point' form
-	Env              []string // Array of environment variables
-	IDOnly           bool     // Only print the job ID
-	Concurrency      int      // Number of concurrent jobs to run
-	Confidence       int      // Minimum number of nodes that must agree on a verification result
-	MinBids          int      // Minimum number of bids before they will be accepted (at random)
-	Timeout          float64  // Job execution timeout in seconds
+	Engine           string              // Executor - executor.Executor
+	Verifier         string              // Verifier - verifier.Verifier
+	Publisher        string              // Publisher - publisher.Publisher
+	Inputs           []string            // Array of input CIDs
+	InputUrls        []string            // Array of input URLs (will be copied to IPFS)
+	InputVolumes     []string            // Array of input volumes in 'CID:mount point' form
+	InputGit         []model.StorageSpec // Array of git repositories to check out as inputs
+	OutputVolumes    []string            // Array of output volumes in 'name:mount point' form
+	Env              []string            // Array of environment variables
+	IDOnly           bool                // Only print the job ID
+	Concurrency      int                 // Number of concurrent jobs to run
+	Confidence       int                 // Minimum number of nodes that must agree on a verification result
+	MinBids          int                 // Minimum number of bids before they will be accepted (at random)
+	Broadcast        bool                // Run the job on every matching node
+	LateJoiners      bool                // Also run a broadcast job on nodes that join while it runs
+	MaxBudget        float64             // The most to pay for each shard, summed over the accepted bids
+	SampleRate       float64             // Fraction of shards the optimistic verifier runs twice
+	VerifyIgnore     []string            // Result files the verifier does not compare
+	MaxDiffFiles     int                 // Files that may differ between results the optimistic verifier considers the same
+	Timeout          float64             // Job execution timeout in seconds
 	CPU              string
 	Memory           string
 	GPU              string
@@ -64,8 +75,16 @@ type DockerRunOptions struct {
 	Labels           []string // Labels for the job on the Bacalhau network (for searching)
 	NodeSelector     string   // Selector (label query) to filter nodes on which this job can be executed
 
-	Image      string   // Image to execute
-	Entrypoint []string // Entrypoint to the docker image
+	Image         string   // Image to execute
+	Entrypoint    []string // Entrypoint to the docker image
+	RegistryToken string   // Credentials for pulling the image from a private registry
+	PinImage      bool     // Resolve the image tag to a digest before submitting
+
+	Security model.JobSpecDockerSecurity // Relaxations of the compute node's container security policy
+
+	NotifyURLs   []string // Webhooks to notify as the job progresses
+	NotifyEvents []string // Events to notify the webhooks of
+	NotifySecret string   // Secret to sign notifications with
 
 	SkipSyntaxChecking bool // Verify the syntax using shellcheck
 
@@ -90,6 +109,7 @@ func NewDockerRunOptions() *DockerRunOptions {
 		Inputs:             []string{},
 		InputUrls:          []string{},
 		InputVolumes:       []string{},
+		InputGit:           []model.StorageSpec{},
 		OutputVolumes:      []string{},
 		Env:                []string{},
 		Concurrency:        1,
@@ -105,6 +125,12 @@ func NewDockerRunOptions() *DockerRunOptions {
 		WorkingDirectory:   "",
 		Labels:             []string{},
 		NodeSelector:       "",
+		RegistryToken:      "",
+		PinImage:           false,
+		Security:           model.JobSpecDockerSecurity{},
+		NotifyURLs:         []string{},
+		NotifyEvents:       []string{},
+		NotifySecret:       "",
 		DownloadFlags:      *util.NewDownloadSettings(),
 		RunTimeSettings:    *NewRunTimeSettings(),
 
@@ -160,6 +186,18 @@ func newDockerRunCmd() *cobra.Command { //nolint:funlen
 		&ODR.Verifier, "verifier", ODR.Verifier,
 		`What verification engine to use to run the job`,
 	)
+	dockerRunCmd.PersistentFlags().Float64Var(
+		&ODR.SampleRate, "verification-sample-rate", ODR.SampleRate,
+		`With the optimistic verifier, the fraction of shards (between 0 and 1) that also run on a second node to check the results`,
+	)
+	dockerRunCmd.PersistentFlags().StringSliceVar(
+		&ODR.VerifyIgnore, "verification-ignore", ODR.VerifyIgnore,
+		`Glob patterns of result files the verifier does not compare, e.g. logs with timestamps (e.g. "*.log")`,
+	)
+	dockerRunCmd.PersistentFlags().IntVar(
+		&ODR.MaxDiffFiles, "verification-max-differing-files", ODR.MaxDiffFiles,
+		`With the optimistic verifier, how many files may differ between results that are still considered the same`,
+	)
 	dockerRunCmd.PersistentFlags().StringVar(
 		&ODR.Publisher, "publisher", ODR.Publisher,
 		`What publisher engine to use to publish the job results`,
@@ -180,6 +218,11 @@ func newDockerRunCmd() *cobra.Command { //nolint:funlen
 		&ODR.InputVolumes, "input-volumes", "v", ODR.InputVolumes,
 		`CID:path of the input data volumes, if you need to set the path of the mounted data.`,
 	)
+	dockerRunCmd.PersistentFlags().Var(
+		NewGitStorageSpecArrayFlag(&ODR.InputGit), "input-git",
+		`url@ref:path of a git repository to check out as an input, e.g. 'https://github.com/org/repo@main:/code'.
+		The ref can be a branch, tag or commit and defaults to the remote HEAD. The path defaults to '/inputs'.`,
+	)
 	dockerRunCmd.PersistentFlags().StringSliceVarP(
 		&ODR.OutputVolumes, "output-volumes", "o", ODR.OutputVolumes,
 		`name:path of the output data volumes. 'outputs:/outputs' is always added.`,
@@ -200,6 +243,18 @@ func newDockerRunCmd() *cobra.Command { //nolint:funlen
 		&ODR.MinBids, "min-bids", ODR.MinBids,
 		`Minimum number of bids that must be received before concurrency-many bids will be accepted (at random)`,
 	)
+	dockerRunCmd.PersistentFlags().BoolVar(
+		&ODR.Broadcast, "broadcast", ODR.Broadcast,
+		`Run the job on every node that matches the node selectors, instead of on concurrency-many nodes`,
+	)
+	dockerRunCmd.PersistentFlags().BoolVar(
+		&ODR.LateJoiners, "include-late-joiners", ODR.LateJoiners,
+		`With --broadcast, also run the job on matching nodes that join the network while it is running`,
+	)
+	dockerRunCmd.PersistentFlags().Float64Var(
+		&ODR.MaxBudget, "max-budget", ODR.MaxBudget,
+		`The most to pay for each shard, summed over the prices of the accepted bids. The cheapest bids are accepted first.`,
+	)
 	dockerRunCmd.PersistentFlags().Float64Var(
 		&ODR.Timeout, "timeout", ODR.Timeout,
 		`Job execution timeout in seconds (e.g. 300 for 5 minutes and 0.1 for 100ms)`,
@@ -239,6 +294,44 @@ func newDockerRunCmd() *cobra.Command { //nolint:funlen
 		`Working directory inside the container. Overrides the working directory shipped with the image (e.g. via WORKDIR in Dockerfile).`,
 	)
 
+	dockerRunCmd.PersistentFlags().StringVar(
+		&ODR.RegistryToken, "registry-token", ODR.RegistryToken,
+		`Token (or username:password) for pulling the image from a private registry. Defaults to $BACALHAU_REGISTRY_TOKEN. `+
+			`It is encrypted so only the node running the job can read it.`,
+	)
+
+	dockerRunCmd.PersistentFlags().BoolVar(
+		&ODR.PinImage, "pin-image", ODR.PinImage,
+		`Resolve the image tag to its current digest before submitting, so every node runs the same image. `+
+			`Needs a local docker daemon to query the registry.`,
+	)
+
+	dockerRunCmd.PersistentFlags().BoolVar(
+		&ODR.Security.RunAsRoot, "run-as-root", ODR.Security.RunAsRoot,
+		`Run as the image's own user (often root). Only nodes whose security policy allows it will run the job.`,
+	)
+	dockerRunCmd.PersistentFlags().BoolVar(
+		&ODR.Security.WritableRootFilesystem, "writable-rootfs", ODR.Security.WritableRootFilesystem,
+		`Allow writes to the container's root filesystem. Only nodes whose security policy allows it will run the job.`,
+	)
+	dockerRunCmd.PersistentFlags().StringSliceVar(
+		&ODR.Security.AddCapabilities, "cap-add", ODR.Security.AddCapabilities,
+		`Linux capabilities to add to the container (e.g. NET_ADMIN). Only nodes whose security policy allows them will run the job.`,
+	)
+
+	dockerRunCmd.PersistentFlags().StringArrayVar(
+		&ODR.NotifyURLs, "notify-url", ODR.NotifyURLs,
+		`URL the requester node POSTs JSON notifications to as the job progresses. Can be given more than once.`,
+	)
+	dockerRunCmd.PersistentFlags().StringSliceVar(
+		&ODR.NotifyEvents, "notify-event", ODR.NotifyEvents,
+		fmt.Sprintf(`Events to send notifications for %v (default all)`, model.NotificationEvents()),
+	)
+	dockerRunCmd.PersistentFlags().StringVar(
+		&ODR.NotifySecret, "notify-secret", ODR.NotifySecret,
+		`Secret to sign notifications with (HMAC-SHA256, in the X-Bacalhau-Signature header). Defaults to $BACALHAU_NOTIFY_SECRET.`,
+	)
+
 	dockerRunCmd.PersistentFlags().StringSliceVarP(
 		&ODR.Labels, "labels", "l", ODR.Labels,
 		`List of labels for the job. Enter multiple in the format '-l a -l 2'. All characters not matching /a-zA-Z0-9_:|-/ and all emojis will be stripped.`, //nolint:lll // Documentation, ok if long.
@@ -299,6 +392,13 @@ func dockerRun(cmd *cobra.Command, cmdArgs []string, ODR *DockerRunOptions) erro
 			return nil
 		}
 	}
+	if ODR.PinImage {
+		err = pinImage(ctx, j)
+		if err != nil {
+			Fatal(cmd, fmt.Sprintf("Error pinning image: %s", err), 1)
+			return nil
+		}
+	}
 	if ODR.DryRun {
 		// Converting job to yaml
 		var yamlBytes []byte
@@ -320,6 +420,56 @@ func dockerRun(cmd *cobra.Command, cmdArgs []string, ODR *DockerRunOptions) erro
 	)
 }
 
+// pinImage resolves the job's image tag to the digest it currently refers to, so that every node runs exactly the same
+// image. Resolving needs a local docker daemon, which is why it is only done when asked for.
+func pinImage(ctx context.Context, j *model.Job) error {
+	image := j.Spec.Docker.Image
+	if docker.IsPinned(image) {
+		return nil
+	}
+
+	pinned, err := resolveImageDigest(ctx, j.Spec.Docker)
+	if err != nil {
+		return fmt.Errorf("could not resolve image %s to a digest: %w", image, err)
+	}
+	log.Ctx(ctx).Debug().Msgf("Pinned image %s to %s", image, pinned)
+	j.Spec.Docker.Image = pinned
+	return nil
+}
+
+func resolveImageDigest(ctx context.Context, spec model.JobSpecDocker) (string, error) {
+	dockerClient, err := docker.NewDockerClient()
+	if err != nil {
+		return "", err
+	}
+	if !docker.IsInstalled(ctx, dockerClient) {
+		return "", fmt.Errorf("docker is not available")
+	}
+
+	registry, err := docker.RegistryForImage(spec.Image)
+	if err != nil {
+		return "", err
+	}
+	var authConfig types.AuthConfig
+	ok := len(spec.RegistryAuth) > 0
+	if ok {
+		authConfig = docker.ParseRegistryToken(registry, string(spec.RegistryAuth))
+	} else {
+		authConfig, ok, err = docker.NewRegistryCredentials("").Get(ctx, registry)
+		if err != nil {
+			return "", err
+		}
+	}
+	var registryAuth string
+	if ok {
+		registryAuth, err = docker.EncodeRegistryAuth(authConfig)
+		if err != nil {
+			return "", err
+		}
+	}
+	return docker.ResolveImageDigest(ctx, dockerClient, spec.Image, registryAuth)
+}
+
 func CreateJob(ctx context.Context,
 	cmdArgs []string,
 	odr *DockerRunOptions) (*model.Job, error) {
@@ -376,7 +526,7 @@ func CreateJob(ctx context.Context,
 	}
 
 	j, err := jobutils.ConstructDockerJob(
-		model.APIVersionLatest(),
+		model.APIVersionModel(),
 		engineType,
 		verifierType,
 		publisherType,
@@ -407,5 +557,63 @@ func CreateJob(ctx context.Context,
 		return &model.Job{}, errors.Wrap(err, "CreateJobSpecAndDeal")
 	}
 
+	j.Spec.Inputs = append(j.Spec.Inputs, odr.InputGit...)
+	j.Spec.Docker.Security = odr.Security
+	j.Spec.Deal.Broadcast = odr.Broadcast
+	j.Spec.Deal.IncludeLateJoiners = odr.LateJoiners
+	j.Spec.Deal.MaxBudget = odr.MaxBudget
+	j.Spec.Verification = model.VerificationConfig{
+		SampleRate:        odr.SampleRate,
+		IgnorePaths:       odr.VerifyIgnore,
+		MaxDifferingFiles: odr.MaxDiffFiles,
+	}
+
+	registryToken := odr.RegistryToken
+	if registryToken == "" {
+		registryToken = os.Getenv("BACALHAU_REGISTRY_TOKEN")
+	}
+	if registryToken != "" {
+		j.Spec.Docker.RegistryAuth = []byte(registryToken)
+	}
+
+	j.Spec.Notifications, err = notificationsFromFlags(odr.NotifyURLs, odr.NotifyEvents, odr.NotifySecret)
+	if err != nil {
+		return &model.Job{}, err
+	}
+
 	return j, nil
 }
+
+// notificationsFromFlags returns a notification for each URL, for the events
+// and signed with the secret.
+func notificationsFromFlags(urls, eventNames []string, secret string) ([]model.JobNotification, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+	var events []model.NotificationEvent
+	for _, name := range eventNames {
+		event, err := model.ParseNotificationEvent(name)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if secret == "" {
+		secret = os.Getenv("BACALHAU_NOTIFY_SECRET")
+	}
+	var notifications []model.JobNotification
+	for _, url := range urls {
+		notification := model.JobNotification{
+			URL:    url,
+			Events: events,
+		}
+		if secret != "" {
+			notification.Secret = []byte(secret)
+		}
+		if err := notification.IsValid(); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, nil
+}
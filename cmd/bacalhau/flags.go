@@ -6,6 +6,7 @@ import (
 
 	"github.com/filecoin-project/bacalhau/pkg/job"
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/storage/git"
 	"github.com/filecoin-project/bacalhau/pkg/storage/url/urldownload"
 	"github.com/spf13/pflag"
 )
@@ -200,6 +201,38 @@ func NewURLStorageSpecArrayFlag(value *[]model.StorageSpec) *ArrayValueFlag[mode
 	}
 }
 
+// parseGitStorageSpec accepts 'url[@ref][:/path]'. As both scp-like remotes
+// and refs can contain the separators, the mount path must be absolute and
+// the ref is only split off if what is left is still a valid repository URL.
+func parseGitStorageSpec(input string) (model.StorageSpec, error) {
+	repoURL, ref, mountPath := input, "", "/inputs"
+	if i := strings.LastIndex(repoURL, ":"); i >= 0 && strings.HasPrefix(repoURL[i+1:], "/") && !strings.HasPrefix(repoURL[i+1:], "//") {
+		repoURL, mountPath = repoURL[:i], repoURL[i+1:]
+	}
+	if i := strings.LastIndex(repoURL, "@"); i >= 0 && !strings.Contains(repoURL[i+1:], ":") {
+		if git.IsURLSupported(repoURL[:i]) == nil {
+			repoURL, ref = repoURL[:i], repoURL[i+1:]
+		}
+	}
+	if err := git.IsURLSupported(repoURL); err != nil {
+		return model.StorageSpec{}, err
+	}
+	return git.NewStorageSpec(repoURL, ref, "", mountPath), nil
+}
+
+func storageSpecToGitMount(input *model.StorageSpec) string {
+	return fmt.Sprintf("%s@%s:%s", input.URL, git.GetRef(*input), input.Path)
+}
+
+func NewGitStorageSpecArrayFlag(value *[]model.StorageSpec) *ArrayValueFlag[model.StorageSpec] {
+	return &ArrayValueFlag[model.StorageSpec]{
+		value:    value,
+		parser:   parseGitStorageSpec,
+		stringer: storageSpecToGitMount,
+		typeStr:  "url@ref:path",
+	}
+}
+
 func VerifierFlag(value *model.Verifier) *ValueFlag[model.Verifier] {
 	return &ValueFlag[model.Verifier]{
 		value:    value,
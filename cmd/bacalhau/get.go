@@ -10,12 +10,22 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/util/templates"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"k8s.io/kubectl/pkg/util/i18n"
 )
 
 var (
 	getLong = templates.LongDesc(i18n.T(`
 		Get the results of the job, including stdout and stderr.
+
+		The results of each shard are checked against the hash of their files published by the compute node that
+		ran it. --shard selects which results are downloaded, while --output-volume, --include and --exclude only select
+		what is written out of them, as the whole results of a shard are needed to verify them.
+
+		Running get again after it was interrupted skips the results of shards that were completely downloaded, so
+		resuming works per result rather than per byte. Only results published to Estuary, which are downloaded over
+		HTTP, resume part way through a file. Partial downloads of results from IPFS or kept on compute nodes are started
+		again from scratch.
 `))
 
 	//nolint:lll // Documentation
@@ -25,6 +35,9 @@ var (
 
 		# Get the results of a job, with a short ID.
 		bacalhau get ebd9bf2f
+
+		# Get only the CSV files of the "outputs" volume of the first shard.
+		bacalhau get --shard 0 --output-volume outputs --include '**/*.csv' ebd9bf2f
 `))
 )
 
@@ -54,10 +67,26 @@ func newGetCmd() *cobra.Command {
 	}
 
 	getCmd.PersistentFlags().AddFlagSet(NewIPFSDownloadFlags(OG.IPFSDownloadSettings))
+	getCmd.PersistentFlags().AddFlagSet(NewDownloadSelectionFlags(OG.IPFSDownloadSettings))
 
 	return getCmd
 }
 
+func NewDownloadSelectionFlags(settings *model.DownloaderSettings) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("Download selection flags", pflag.ContinueOnError)
+	flags.IntSliceVar(&settings.ShardIndexes, "shard", settings.ShardIndexes,
+		"Only download the results of these shards. Can be given more than once.")
+	flags.StringSliceVar(&settings.OutputVolumes, "output-volume", settings.OutputVolumes,
+		"Only write out these output volumes. Can be given more than once.")
+	flags.StringSliceVar(&settings.Include, "include", settings.Include,
+		"Only write out result files matching these glob patterns, which may use ** to match any number of directories.")
+	flags.StringSliceVar(&settings.Exclude, "exclude", settings.Exclude,
+		"Do not write out result files matching these glob patterns.")
+	flags.IntVar(&settings.Parallelism, "parallelism", settings.Parallelism,
+		"How many results to download at the same time.")
+	return flags
+}
+
 func get(cmd *cobra.Command, cmdArgs []string, OG *GetOptions) error {
 	cm := system.NewCleanupManager()
 	defer cm.Cleanup()
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/filecoin-project/bacalhau/pkg/job"
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/requester/publicapi"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/filecoin-project/bacalhau/pkg/util/templates"
 	"github.com/jedib0t/go-pretty/v6/table"
@@ -26,7 +28,13 @@ var (
 		bacalhau list
 
 		# List jobs and output as json
-		bacalhau list --output json`))
+		bacalhau list --output json
+
+		# List failed docker jobs from the last day
+		bacalhau list --state error --engine docker --since 24h
+
+		# List the next page of jobs
+		bacalhau list --cursor <next cursor>`))
 
 	// The tags that will be excluded by default, if the user does not pass any
 	// others to the list command.
@@ -47,6 +55,10 @@ type ListOptions struct {
 	SortBy       ColumnEnum          // Sort by field, defaults to creation time, with newest first [Allowed "id", "created_at"].
 	OutputWide   bool                // Print full values in the table results
 	ReturnAll    bool                // Return all jobs, not just those that belong to the user
+	States       []string            // Only return jobs in these states
+	Engine       string              // Only return jobs for this engine
+	Since        string              // Only return jobs created since this time or duration ago
+	Cursor       string              // Continue listing from the end of a previous page
 }
 
 func NewListOptions() *ListOptions {
@@ -115,6 +127,22 @@ func newListCmd() *cobra.Command {
 		//nolint:lll // Documentation
 		`Fetch all jobs from the network (default is to filter those belonging to the user). This option may take a long time to return, please use with caution.`,
 	)
+	listCmd.PersistentFlags().StringSliceVar(
+		&OL.States, "state", OL.States,
+		fmt.Sprintf(`Only return jobs in these states %v`, model.JobStateTypeNames()),
+	)
+	listCmd.PersistentFlags().StringVar(
+		&OL.Engine, "engine", OL.Engine,
+		fmt.Sprintf(`Only return jobs for this engine %v`, model.EngineNames()),
+	)
+	listCmd.PersistentFlags().StringVar(
+		&OL.Since, "since", OL.Since,
+		`Only return jobs created since a time (e.g. 2022-11-17 or 2022-11-17T13:29:01Z) or a duration ago (e.g. 24h)`,
+	)
+	listCmd.PersistentFlags().StringVar(
+		&OL.Cursor, "cursor", OL.Cursor,
+		`Continue listing jobs after the end of a previous page, using the cursor printed with it`,
+	)
 
 	return listCmd
 }
@@ -166,19 +194,15 @@ func list(cmd *cobra.Command, OL *ListOptions) error {
 	log.Debug().Msgf("Found no-style header flag set to: %t", OL.NoStyle)
 	log.Debug().Msgf("Found output wide flag set to: %t", OL.OutputWide)
 
-	jobs, err := GetAPIClient().List(
-		ctx,
-		OL.IDFilter,
-		OL.IncludeTags,
-		OL.ExcludeTags,
-		OL.MaxJobs,
-		OL.ReturnAll,
-		OL.SortBy.String(),
-		OL.SortReverse,
-	)
+	listReq, err := newListRequest(OL, time.Now())
+	if err != nil {
+		Fatal(cmd, err.Error(), 1)
+	}
+	res, err := GetAPIClient().ListJobs(ctx, listReq)
 	if err != nil {
 		Fatal(cmd, fmt.Sprintf("Error listing jobs: %s", err), 1)
 	}
+	jobs := res.Jobs
 
 	numberInTable := system.Min(OL.MaxJobs, len(jobs))
 	log.Debug().Msgf("Number of jobs printing: %d", numberInTable)
@@ -234,11 +258,64 @@ func list(cmd *cobra.Command, OL *ListOptions) error {
 		}
 
 		tw.Render()
+		if res.NextCursor != "" && !OL.HideHeader {
+			// the hint is left out of output meant to be parsed
+			cmd.PrintErrf("To see more jobs, run again with --cursor %s\n", res.NextCursor)
+		}
 	}
 
 	return nil
 }
 
+// newListRequest turns the list options into a request, parsing the filters.
+func newListRequest(OL *ListOptions, now time.Time) (publicapi.ListRequest, error) {
+	req := publicapi.ListRequest{
+		JobID:       OL.IDFilter,
+		IncludeTags: OL.IncludeTags,
+		ExcludeTags: OL.ExcludeTags,
+		MaxJobs:     OL.MaxJobs,
+		ReturnAll:   OL.ReturnAll,
+		SortBy:      OL.SortBy.String(),
+		SortReverse: OL.SortReverse,
+		Cursor:      OL.Cursor,
+	}
+	for _, name := range OL.States {
+		state, err := model.ParseJobStateType(name)
+		if err != nil {
+			return req, fmt.Errorf("invalid --state %q, expected one of %v", name, model.JobStateTypeNames())
+		}
+		req.States = append(req.States, state)
+	}
+	if OL.Engine != "" {
+		engine, err := model.ParseEngine(OL.Engine)
+		if err != nil {
+			return req, fmt.Errorf("invalid --engine %q, expected one of %v", OL.Engine, model.EngineNames())
+		}
+		req.Engine = engine
+	}
+	if OL.Since != "" {
+		since, err := parseSince(OL.Since, now)
+		if err != nil {
+			return req, err
+		}
+		req.CreatedAfter = since
+	}
+	return req, nil
+}
+
+// parseSince parses a time as RFC3339 or a date, or a duration before now.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q, expected a time (e.g. 2022-11-17T13:29:01Z), a date or a duration (e.g. 24h)", value)
+}
+
 // Renders job details into a table row
 func summarizeJob(ctx context.Context, j *model.Job, OL *ListOptions) (table.Row, error) {
 	//nolint:ineffassign,staticcheck // For tracing
@@ -357,3 +357,40 @@ Compare Ids:
 		}
 	}
 }
+
+func TestNewListRequest(t *testing.T) {
+	now := time.Date(2022, 11, 17, 12, 0, 0, 0, time.UTC)
+	OL := NewListOptions()
+	OL.States = []string{"error", "Completed"}
+	OL.Engine = "docker"
+	OL.Since = "24h"
+	OL.Cursor = "cursor"
+
+	req, err := newListRequest(OL, now)
+	require.NoError(t, err)
+	require.Equal(t, []model.JobStateType{model.JobStateError, model.JobStateCompleted}, req.States)
+	require.Equal(t, model.EngineDocker, req.Engine)
+	require.Equal(t, now.Add(-24*time.Hour), req.CreatedAfter)
+	require.Equal(t, "cursor", req.Cursor)
+
+	for since, expected := range map[string]time.Time{
+		"2022-11-16":           time.Date(2022, 11, 16, 0, 0, 0, 0, time.UTC),
+		"2022-11-16T13:29:01Z": time.Date(2022, 11, 16, 13, 29, 1, 0, time.UTC),
+	} {
+		OL.Since = since
+		req, err = newListRequest(OL, now)
+		require.NoError(t, err, since)
+		require.True(t, expected.Equal(req.CreatedAfter), since)
+	}
+
+	for _, invalid := range []func(*ListOptions){
+		func(o *ListOptions) { o.States = []string{"sleeping"} },
+		func(o *ListOptions) { o.Engine = "vm" },
+		func(o *ListOptions) { o.Since = "yesterday" },
+	} {
+		OL = NewListOptions()
+		invalid(OL)
+		_, err = newListRequest(OL, now)
+		require.Error(t, err)
+	}
+}
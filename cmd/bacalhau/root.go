@@ -73,6 +73,12 @@ func NewRootCmd() *cobra.Command {
 	// List jobs
 	RootCmd.AddCommand(newListCmd())
 
+	// ====== Submit jobs on a schedule
+	RootCmd.AddCommand(newScheduleCmd())
+
+	// ====== Run test scenarios
+	RootCmd.AddCommand(newScenarioCmd())
+
 	// ====== Run a server
 
 	// Serve commands
@@ -81,6 +87,9 @@ func NewRootCmd() *cobra.Command {
 	RootCmd.AddCommand(newIDCmd())
 	RootCmd.AddCommand(newDevStackCmd())
 
+	// ====== Manage API authentication
+	RootCmd.AddCommand(newAuthCmd())
+
 	RootCmd.PersistentFlags().StringVar(
 		&apiHost, "api-host", defaultAPIHost,
 		`The host for the client and server to communicate on (via REST).
@@ -0,0 +1,232 @@
+package bacalhau
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/devstack"
+	"github.com/filecoin-project/bacalhau/pkg/downloader/util"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/node"
+	"github.com/filecoin-project/bacalhau/pkg/requester/publicapi"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/util/templates"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+var (
+	scenarioRunLong = templates.LongDesc(i18n.T(`
+		Run the test scenarios declared in a YAML or JSON file, and report their outcome.
+
+		Each scenario submits a job, waits for its shard executions to reach the expected states, downloads its
+		results and checks them. The scenarios run against a devstack started as declared in the file, or against a
+		live cluster with --against, in which case data can't be stored on IPFS for them.
+
+		The command fails if any scenario failed.
+`))
+
+	//nolint:lll // Documentation
+	scenarioRunExample = templates.Examples(i18n.T(`
+		# Run scenarios against a devstack, and print a JSON report
+		bacalhau scenario run scenarios.yaml
+
+		# Run scenarios against a live cluster, and write a JUnit report for CI
+		bacalhau scenario run scenarios.yaml --against http://bootstrap.production.bacalhau.org:1234 --report junit --report-file report.xml
+`))
+)
+
+type ScenarioRunOptions struct {
+	Against          string                   // The API of the requester node of a live cluster to run against.
+	ReportFormat     string                   // The format of the report: "json" or "junit".
+	ReportFile       string                   // The file to write the report to, stdout if empty.
+	OutputDir        string                   // The directory to keep the results of scenarios in.
+	Timeout          time.Duration            // How long each scenario may take.
+	DownloadSettings model.DownloaderSettings // How results are downloaded from a live cluster.
+}
+
+func NewScenarioRunOptions() *ScenarioRunOptions {
+	return &ScenarioRunOptions{
+		ReportFormat:     scenario.ReportFormatJSON,
+		Timeout:          5 * time.Minute, //nolint:gomnd
+		DownloadSettings: *util.NewDownloadSettings(),
+	}
+}
+
+func newScenarioCmd() *cobra.Command {
+	scenarioCmd := &cobra.Command{
+		Use:   "scenario",
+		Short: "Run test scenarios declared in files",
+	}
+	scenarioCmd.AddCommand(newScenarioRunCmd())
+	return scenarioCmd
+}
+
+func newScenarioRunCmd() *cobra.Command {
+	OSR := NewScenarioRunOptions()
+	runCmd := &cobra.Command{
+		Use:     "run <file>",
+		Short:   "Run the scenarios of a file against a devstack or a live cluster",
+		Long:    scenarioRunLong,
+		Example: scenarioRunExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScenarios(cmd, args[0], OSR)
+		},
+	}
+	runCmd.Flags().StringVar(
+		&OSR.Against, "against", OSR.Against,
+		`The API of a requester node of a live cluster to run the scenarios against, e.g. http://localhost:1234. `+
+			`A devstack is started if empty.`,
+	)
+	runCmd.Flags().StringVar(
+		&OSR.ReportFormat, "report", OSR.ReportFormat,
+		fmt.Sprintf(`The format of the report: %q or %q.`, scenario.ReportFormatJSON, scenario.ReportFormatJUnit),
+	)
+	runCmd.Flags().StringVar(
+		&OSR.ReportFile, "report-file", OSR.ReportFile,
+		`The file to write the report to. The report is written to stdout if empty.`,
+	)
+	runCmd.Flags().StringVar(
+		&OSR.OutputDir, "output-dir", OSR.OutputDir,
+		`The directory to keep the results of each scenario in. Results are deleted once checked if empty.`,
+	)
+	runCmd.Flags().DurationVar(
+		&OSR.Timeout, "timeout", OSR.Timeout,
+		`How long each scenario may take.`,
+	)
+	runCmd.Flags().AddFlagSet(NewIPFSDownloadFlags(&OSR.DownloadSettings))
+	return runCmd
+}
+
+func runScenarios(cmd *cobra.Command, path string, OSR *ScenarioRunOptions) error {
+	cm := system.NewCleanupManager()
+	defer cm.Cleanup()
+	ctx := cmd.Context()
+
+	ctx, rootSpan := system.NewRootSpan(ctx, system.GetTracer(), "cmd/bacalhau/scenario")
+	defer rootSpan.End()
+	cm.RegisterCallback(system.CleanupTraceProvider)
+
+	if OSR.ReportFormat != scenario.ReportFormatJSON && OSR.ReportFormat != scenario.ReportFormatJUnit {
+		return fmt.Errorf("invalid report format %s. Only %s and %s are supported",
+			OSR.ReportFormat, scenario.ReportFormatJSON, scenario.ReportFormatJUnit)
+	}
+
+	file, err := scenario.LoadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var target scenario.Target
+	if OSR.Against != "" {
+		target = scenario.Target{
+			Client:           publicapi.NewRequesterAPIClient(OSR.Against),
+			DownloadSettings: OSR.DownloadSettings,
+		}
+	} else {
+		target, err = startScenarioStack(ctx, cm, file.Stack)
+		if err != nil {
+			return fmt.Errorf("failed to start devstack: %w", err)
+		}
+	}
+
+	report := scenario.Report{Name: file.Name}
+	if report.Name == "" {
+		report.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	for _, fileScenario := range file.Scenarios {
+		scenarioReport := runFileScenario(ctx, cm, target, filepath.Dir(path), fileScenario, OSR)
+		if scenarioReport.Error == "" {
+			cmd.PrintErrf("PASS %s (%s)\n", scenarioReport.Name, time.Duration(scenarioReport.Duration).Round(time.Millisecond))
+		} else {
+			cmd.PrintErrf("FAIL %s (%s): %s\n", scenarioReport.Name, time.Duration(scenarioReport.Duration).Round(time.Millisecond),
+				scenarioReport.Error)
+		}
+		report.Scenarios = append(report.Scenarios, scenarioReport)
+	}
+
+	var w io.Writer = cmd.OutOrStdout()
+	if OSR.ReportFile != "" {
+		f, createErr := os.Create(OSR.ReportFile)
+		if createErr != nil {
+			return fmt.Errorf("failed to create report file: %w", createErr)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err = report.Write(w, OSR.ReportFormat); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	if failed := report.Failed(); failed > 0 {
+		return fmt.Errorf("%d of %d scenarios failed", failed, len(report.Scenarios))
+	}
+	return nil
+}
+
+// startScenarioStack starts the devstack the scenarios of a file are run against.
+func startScenarioStack(ctx context.Context, cm *system.CleanupManager, stack *scenario.FileStack) (scenario.Target, error) {
+	if stack == nil {
+		stack = &scenario.FileStack{}
+	}
+	options := devstack.DevStackOptions{NumberOfHybridNodes: 1, Topology: stack.Topology}
+
+	var devStack *devstack.DevStack
+	var err error
+	computeConfig := node.NewComputeConfigWithDefaults()
+	requesterConfig := node.NewRequesterConfigWithDefaults()
+	if stack.Noop {
+		devStack, err = devstack.NewNoopDevStack(ctx, cm, options, computeConfig, requesterConfig)
+	} else {
+		devStack, err = devstack.NewStandardDevStack(ctx, cm, options, computeConfig, requesterConfig)
+	}
+	if err != nil {
+		return scenario.Target{}, err
+	}
+	return scenario.NewDevStackTarget(ctx, devStack)
+}
+
+// runFileScenario runs a scenario of a file, and reports its outcome.
+func runFileScenario(
+	ctx context.Context,
+	cm *system.CleanupManager,
+	target scenario.Target,
+	dir string,
+	fileScenario scenario.FileScenario,
+	OSR *ScenarioRunOptions) (report scenario.ScenarioReport) {
+	report.Name = fileScenario.Name
+	start := time.Now()
+	defer func() { report.Duration = model.Duration(time.Since(start)) }()
+
+	var resultsDir string
+	var err error
+	if OSR.OutputDir != "" {
+		resultsDir = filepath.Join(OSR.OutputDir, fileScenario.Name)
+		err = os.MkdirAll(resultsDir, os.ModePerm)
+	} else {
+		resultsDir, err = os.MkdirTemp("", "bacalhau-scenario-*")
+		defer os.RemoveAll(resultsDir)
+	}
+	if err != nil {
+		report.Error = fmt.Sprintf("failed to create results directory: %s", err)
+		return report
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, OSR.Timeout)
+	defer cancel()
+	submittedJob, err := scenario.Run(ctx, cm, target, fileScenario.Scenario(dir), resultsDir)
+	if submittedJob != nil {
+		report.JobID = submittedJob.Metadata.ID
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return report
+}
@@ -0,0 +1,197 @@
+package bacalhau
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/requester/schedule"
+	"github.com/filecoin-project/bacalhau/pkg/userstrings"
+	"github.com/filecoin-project/bacalhau/pkg/util/templates"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+var (
+	scheduleLong = templates.LongDesc(i18n.T(`
+		Manage jobs that the requester node submits on a cron schedule.
+
+		Each time a schedule's cron expression is due, the requester submits a fresh
+		job from the schedule's spec, as this client. The concurrency policy decides
+		what happens if the previous job is still running: allow starts the new job
+		anyway, forbid skips it, and replace cancels the old one and starts the new one.
+`))
+
+	//nolint:lll // Documentation
+	scheduleExample = templates.Examples(i18n.T(`
+		# Run the job in job.yaml every night at 2am, New York time
+		bacalhau schedule create job.yaml --cron "0 2 * * *" --timezone America/New_York
+
+		# Run a job every 15 minutes, unless the previous run is still going
+		bacalhau schedule create job.yaml --cron "*/15 * * * *" --concurrency-policy forbid
+
+		# List this client's schedules
+		bacalhau schedule list
+
+		# Pause, resume and delete a schedule
+		bacalhau schedule pause <schedule-id>
+		bacalhau schedule resume <schedule-id>
+		bacalhau schedule delete <schedule-id>
+`))
+)
+
+// ScheduleOptions is a struct to support the schedule commands
+type ScheduleOptions struct {
+	Cron              string // Cron expression the job is submitted on.
+	Timezone          string // Time zone of the cron expression.
+	ConcurrencyPolicy string // What to do when the previous job is still running.
+	HistoryLimit      int    // How many of the submitted jobs to remember.
+}
+
+func NewScheduleOptions() *ScheduleOptions {
+	return &ScheduleOptions{
+		Timezone:          "UTC",
+		ConcurrencyPolicy: string(model.ConcurrencyPolicyAllow),
+		HistoryLimit:      model.DefaultScheduleHistoryLimit,
+	}
+}
+
+func newScheduleCmd() *cobra.Command {
+	OS := NewScheduleOptions()
+
+	scheduleCmd := &cobra.Command{
+		Use:     "schedule",
+		Short:   "Manage jobs submitted on a cron schedule",
+		Long:    scheduleLong,
+		Example: scheduleExample,
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create [job-file]",
+		Short: "Create a schedule from a job file, or from stdin",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createSchedule(cmd, args, OS)
+		},
+	}
+	createCmd.Flags().StringVar(&OS.Cron, "cron", OS.Cron,
+		`Cron expression the job is submitted on, e.g. "0 * * * *" or "@daily".`)
+	createCmd.Flags().StringVar(&OS.Timezone, "timezone", OS.Timezone,
+		`IANA time zone the cron expression is in.`)
+	createCmd.Flags().StringVar(&OS.ConcurrencyPolicy, "concurrency-policy", OS.ConcurrencyPolicy,
+		fmt.Sprintf(`What to do when the previous job is still running, one of %v.`, model.ConcurrencyPolicies()))
+	createCmd.Flags().IntVar(&OS.HistoryLimit, "history-limit", OS.HistoryLimit,
+		`How many of the submitted jobs to remember.`)
+	_ = createCmd.MarkFlagRequired("cron")
+
+	scheduleCmd.AddCommand(
+		createCmd,
+		&cobra.Command{
+			Use:   "list",
+			Short: "List this client's schedules",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				schedules, err := GetAPIClient().ListSchedules(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return printYAML(cmd, schedules)
+			},
+		},
+		newSchedulePauseCmd("pause", "Pause a schedule, skipping its ticks until it is resumed", true),
+		newSchedulePauseCmd("resume", "Resume a paused schedule", false),
+		&cobra.Command{
+			Use:   "delete <schedule-id>",
+			Short: "Delete a schedule. The jobs it submitted are not affected",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return GetAPIClient().DeleteSchedule(cmd.Context(), args[0])
+			},
+		},
+	)
+
+	return scheduleCmd
+}
+
+func newSchedulePauseCmd(use, short string, paused bool) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " <schedule-id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			updated, err := GetAPIClient().PauseSchedule(cmd.Context(), args[0], paused)
+			if err != nil {
+				return err
+			}
+			return printYAML(cmd, updated)
+		},
+	}
+}
+
+func createSchedule(cmd *cobra.Command, args []string, OS *ScheduleOptions) error {
+	j, err := readJobFile(cmd, args)
+	if err != nil {
+		return err
+	}
+	policy, err := model.ParseConcurrencyPolicy(OS.ConcurrencyPolicy)
+	if err != nil {
+		return err
+	}
+	payload := model.JobScheduleCreatePayload{
+		APIVersion:        j.APIVersion,
+		Spec:              &j.Spec,
+		Cron:              OS.Cron,
+		Timezone:          OS.Timezone,
+		ConcurrencyPolicy: policy,
+		HistoryLimit:      OS.HistoryLimit,
+	}
+	// fail early on mistakes in the schedule, before the node sees it
+	if err = schedule.VerifyCreatePayload(payload); err != nil {
+		return err
+	}
+
+	created, err := GetAPIClient().CreateSchedule(cmd.Context(), payload)
+	if err != nil {
+		return err
+	}
+	return printYAML(cmd, created)
+}
+
+// readJobFile reads a job, or a job with info, from the file in args or from
+// stdin.
+func readJobFile(cmd *cobra.Command, args []string) (*model.Job, error) {
+	var data []byte
+	var err error
+	if len(args) == 0 {
+		data, err = ReadFromStdinIfAvailable(cmd, args)
+	} else {
+		data, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading job: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf(userstrings.JobSpecBad)
+	}
+
+	var rawMap map[string]interface{}
+	if err = model.YAMLUnmarshalWithMax(data, &rawMap); err != nil {
+		return nil, fmt.Errorf("error parsing job: %w", err)
+	}
+	if _, isJobWithInfo := rawMap["Job"]; isJobWithInfo {
+		var jwi model.JobWithInfo
+		if err = model.YAMLUnmarshalWithMax(data, &jwi); err != nil {
+			return nil, fmt.Errorf("error parsing job: %w", err)
+		}
+		return &jwi.Job, nil
+	}
+
+	j, err := model.NewJobWithSaneProductionDefaults()
+	if err != nil {
+		return nil, err
+	}
+	if err = model.YAMLUnmarshalWithMax(data, j); err != nil {
+		return nil, fmt.Errorf("error parsing job: %w", err)
+	}
+	return j, nil
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/filecoin-project/bacalhau/pkg/compute/capacity"
+	"github.com/filecoin-project/bacalhau/pkg/docker"
 	"github.com/filecoin-project/bacalhau/pkg/libp2p"
 	"github.com/filecoin-project/bacalhau/pkg/logger"
 	filecoinlotus "github.com/filecoin-project/bacalhau/pkg/publisher/filecoin_lotus"
@@ -16,7 +17,10 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/ipfs"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/node"
+	"github.com/filecoin-project/bacalhau/pkg/requester/quota"
+	"github.com/filecoin-project/bacalhau/pkg/simulator"
 	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/transport/httpprotocol"
 	"github.com/filecoin-project/bacalhau/pkg/util/templates"
 	"github.com/multiformats/go-multiaddr"
 
@@ -31,6 +35,7 @@ var DefaultBootstrapAddresses = []string{
 	"/ip4/35.245.251.239/tcp/1235/p2p/QmYgxZiySj3MRkwLSL4X2MF5F9f2PMhAE3LV49XkfNL1o3",
 }
 var DefaultSwarmPort = 1235
+var DefaultTransportPort = 1236
 
 var (
 	serveLong = templates.LongDesc(i18n.T(`
@@ -50,6 +55,12 @@ var (
 		bacalhau serve --node-type compute --node-type requester
 		# or
 		bacalhau serve --node-type compute,requester
+
+		# Start a requester node and a compute node that talk over HTTP with mutual TLS, instead of libp2p
+		bacalhau serve --node-type requester --transport http --transport-address https://requester:1236 \
+			--transport-tls-cert requester.pem --transport-tls-key requester-key.pem --transport-tls-ca ca.pem
+		bacalhau serve --transport http --transport-address https://compute-1:1236 --requester-url https://requester:1236 \
+			--transport-tls-cert compute-1.pem --transport-tls-key compute-1-key.pem --transport-tls-ca ca.pem
 `))
 )
 
@@ -79,7 +90,27 @@ type ServeOptions struct {
 	LotusFilecoinUploadDirectory          string            // Directory to put files when uploading to Lotus (optional)
 	LotusFilecoinMaximumPing              time.Duration     // The maximum ping allowed when selecting a Filecoin miner
 	JobExecutionTimeoutClientIDBypassList []string          // IDs of clients that can submit jobs more than the configured job execution timeout
+	JobPrepareTimeout                     time.Duration     // How long the node can spend preparing to run a job, such as pulling its image.
 	Labels                                map[string]string // Labels to apply to the node that can be used for node selection and filtering
+	InputCacheSize                        string            // The amount of disk space used to keep downloaded inputs between jobs.
+	DockerConfigPath                      string            // The docker config.json holding credentials for private registries.
+	DockerPullPolicy                      string            // When to pull images for jobs.
+	DockerPrePullImages                   []string          // Images to pull when the node starts.
+	DockerSecurityPolicy                  string            // Security policy for job containers, by name or path.
+	ClientQuota                           quota.Limits      // Limits on what each client can submit to the requester.
+	AuthRegistry                          string            // File of client keys and tokens allowed to use the requester API.
+	PriceCPUSecond                        float64           // Price of one CPU core for one second.
+	PriceGBSecond                         float64           // Price of one GB of memory for one second.
+	PriceGPUSecond                        float64           // Price of one GPU for one second.
+	RetainResultsFor                      time.Duration     // How long the compute node keeps results to serve them itself.
+	Transport                             string            // How compute and requester nodes talk to each other: "libp2p" or "http".
+	TransportPort                         int               // The port the HTTP transport listens on.
+	TransportAddress                      string            // The URL other nodes reach the HTTP transport of this node at.
+	RequesterURL                          string            // The URL of the HTTP transport of the requester node a compute node registers with.
+	TransportTLSCert                      string            // The certificate the HTTP transport presents to other nodes.
+	TransportTLSKey                       string            // The key of the certificate of the HTTP transport.
+	TransportTLSCA                        string            // The certificate authority that signs the certificates of all nodes.
+	RecordTrace                           string            // File to record the messages the node exchanges and its job events to.
 }
 
 func NewServeOptions() *ServeOptions {
@@ -91,6 +122,8 @@ func NewServeOptions() *ServeOptions {
 		EstuaryAPIKey:                   os.Getenv("ESTUARY_API_KEY"),
 		HostAddress:                     "0.0.0.0",
 		SwarmPort:                       DefaultSwarmPort,
+		Transport:                       transportLibp2p,
+		TransportPort:                   DefaultTransportPort,
 		MetricsPort:                     2112,
 		JobSelectionDataLocality:        "local",
 		JobSelectionDataRejectStateless: false,
@@ -103,6 +136,12 @@ func NewServeOptions() *ServeOptions {
 		LimitJobCPU:                     "",
 		LimitJobMemory:                  "",
 		LimitJobGPU:                     "",
+		InputCacheSize:                  "",
+		DockerConfigPath:                "",
+		DockerPullPolicy:                string(docker.PullIfNotPresent),
+		DockerPrePullImages:             []string{},
+		DockerSecurityPolicy:            docker.SecurityPolicyDefault,
+		ClientQuota:                     quota.Limits{Window: quota.DefaultWindow},
 		LotusFilecoinPathDirectory:      os.Getenv("LOTUS_PATH"),
 		LotusFilecoinMaximumPing:        2 * time.Second,
 	}
@@ -160,6 +199,72 @@ func setupCapacityManagerCLIFlags(cmd *cobra.Command, OS *ServeOptions) {
 		&OS.JobExecutionTimeoutClientIDBypassList, "job-execution-timeout-bypass-client-id", OS.JobExecutionTimeoutClientIDBypassList,
 		`List of IDs of clients that are allowed to bypass the job execution timeout check`,
 	)
+	cmd.PersistentFlags().DurationVar(
+		&OS.JobPrepareTimeout, "job-prepare-timeout", OS.JobPrepareTimeout,
+		`How long to spend preparing to run a job, such as pulling its image, which doesn't count towards the job's timeout. `+
+			`Defaults to 10m.`,
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.InputCacheSize, "input-cache-size", OS.InputCacheSize,
+		`Disk space used to keep downloaded job inputs between jobs (e.g. 500Mb, 2Gb, 8Gb). Inputs are not kept when not set.`,
+	)
+	cmd.PersistentFlags().DurationVar(
+		&OS.RetainResultsFor, "retain-results-for", OS.RetainResultsFor,
+		`How long to keep job results on this node to serve them through the requester API (e.g. 24h). `+
+			`Results are only published to the node when set.`,
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.DockerConfigPath, "docker-config", OS.DockerConfigPath,
+		`Path to a docker config.json with credentials (or credential helpers) for private registries. Defaults to docker's own.`,
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.DockerPullPolicy, "docker-pull-policy", OS.DockerPullPolicy,
+		fmt.Sprintf(`When to pull images for docker jobs, one of %v. Images pinned to a digest are never re-pulled.`, docker.PullPolicies()),
+	)
+	cmd.PersistentFlags().StringSliceVar(
+		&OS.DockerPrePullImages, "docker-pre-pull", OS.DockerPrePullImages,
+		`Images to pull when the node starts, so jobs using them start straight away (including with --docker-pull-policy=never).`,
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.DockerSecurityPolicy, "docker-security-policy", OS.DockerSecurityPolicy,
+		fmt.Sprintf(`Security policy for job containers: %q (docker's defaults), %q (unprivileged user, read only root filesystem, `+
+			`no capabilities) or the path to a YAML policy file.`, docker.SecurityPolicyDefault, docker.SecurityPolicyHardened),
+	)
+}
+
+func setupRequesterCLIFlags(cmd *cobra.Command, OS *ServeOptions) {
+	cmd.PersistentFlags().IntVar(
+		&OS.ClientQuota.MaxConcurrentJobs, "client-max-concurrent-jobs", OS.ClientQuota.MaxConcurrentJobs,
+		`Maximum number of jobs each client can have in progress. Unlimited when 0.`,
+	)
+	cmd.PersistentFlags().IntVar(
+		&OS.ClientQuota.MaxShards, "client-max-shards", OS.ClientQuota.MaxShards,
+		`Maximum number of shard executions (shards times concurrency) a single job can ask for. Unlimited when 0.`,
+	)
+	cmd.PersistentFlags().DurationVar(
+		&OS.ClientQuota.MaxTimeout, "client-max-timeout", OS.ClientQuota.MaxTimeout,
+		`Maximum execution timeout a job can ask for. Unlimited when 0.`,
+	)
+	cmd.PersistentFlags().Float64Var(
+		&OS.ClientQuota.MaxCPUHours, "client-max-cpu-hours", OS.ClientQuota.MaxCPUHours,
+		`Maximum CPU-hours each client's jobs can request within the quota window. Unlimited when 0.`,
+	)
+	cmd.PersistentFlags().Float64Var(
+		&OS.ClientQuota.MaxMemoryGBHours, "client-max-memory-gb-hours", OS.ClientQuota.MaxMemoryGBHours,
+		`Maximum memory GB-hours each client's jobs can request within the quota window. Unlimited when 0.`,
+	)
+	cmd.PersistentFlags().Float64Var(
+		&OS.ClientQuota.MaxGPUHours, "client-max-gpu-hours", OS.ClientQuota.MaxGPUHours,
+		`Maximum GPU-hours each client's jobs can request within the quota window. Unlimited when 0.`,
+	)
+	cmd.PersistentFlags().DurationVar(
+		&OS.ClientQuota.Window, "client-quota-window", OS.ClientQuota.Window,
+		`Sliding window that client resource-hours are counted over.`,
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.AuthRegistry, "auth-registry", OS.AuthRegistry,
+		`File of registered client keys and tokens (see "bacalhau auth"). If set, only registered clients can use the requester API.`,
+	)
 }
 
 func setupLibp2pCLIFlags(cmd *cobra.Command, OS *ServeOptions) {
@@ -177,6 +282,68 @@ func setupLibp2pCLIFlags(cmd *cobra.Command, OS *ServeOptions) {
 	)
 }
 
+const (
+	transportLibp2p = "libp2p"
+	transportHTTP   = "http"
+)
+
+func setupTransportCLIFlags(cmd *cobra.Command, OS *ServeOptions) {
+	cmd.PersistentFlags().StringVar(
+		&OS.Transport, "transport", OS.Transport,
+		`How compute and requester nodes talk to each other: over libp2p streams ("libp2p") or over HTTP ("http"). `+
+			`With HTTP, compute nodes register with their requester node instead of being discovered over libp2p.`,
+	)
+	cmd.PersistentFlags().IntVar(
+		&OS.TransportPort, "transport-port", OS.TransportPort,
+		`The port to listen on for the HTTP transport.`,
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.TransportAddress, "transport-address", OS.TransportAddress,
+		`The URL other nodes reach the HTTP transport of this node at (e.g. https://compute-1:1236).`,
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.RequesterURL, "requester-url", OS.RequesterURL,
+		`The URL of the HTTP transport of the requester node that this compute node registers with. `+
+			`Hybrid nodes register with themselves when not set.`,
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.RecordTrace, "record-trace", OS.RecordTrace,
+		`File to record the requests and callbacks this node exchanges with other nodes, and the events of its jobs, to. `+
+			`The trace can be replayed with "bacalhau simulator replay".`,
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.TransportTLSCert, "transport-tls-cert", OS.TransportTLSCert,
+		`The certificate this node presents to other nodes over the HTTP transport.`,
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.TransportTLSKey, "transport-tls-key", OS.TransportTLSKey,
+		`The key of the certificate of the HTTP transport.`,
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.TransportTLSCA, "transport-tls-ca", OS.TransportTLSCA,
+		`The certificate authority that signs the certificates of all nodes. With a certificate, key and authority, `+
+			`the HTTP transport uses HTTP/2 with mutual TLS; otherwise plain HTTP, which is only fit for trusted networks.`,
+	)
+}
+
+func getTransportConfig(OS *ServeOptions) (*httpprotocol.Config, error) {
+	switch OS.Transport {
+	case transportLibp2p:
+		return nil, nil
+	case transportHTTP:
+		return &httpprotocol.Config{
+			ListenAddress: fmt.Sprintf("%s:%d", OS.HostAddress, OS.TransportPort),
+			Address:       OS.TransportAddress,
+			RequesterURL:  OS.RequesterURL,
+			TLSCertFile:   OS.TransportTLSCert,
+			TLSKeyFile:    OS.TransportTLSKey,
+			TLSCAFile:     OS.TransportTLSCA,
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid transport %s. Only %s and %s values are supported", OS.Transport, transportLibp2p, transportHTTP)
+	}
+}
+
 func getPeers(OS *ServeOptions) []multiaddr.Multiaddr {
 	var peersStrings []string
 	if OS.PeerConnect == "none" {
@@ -213,7 +380,41 @@ func getJobSelectionConfig(OS *ServeOptions) model.JobSelectionPolicy {
 	return jobSelectionPolicy
 }
 
-func getComputeConfig(OS *ServeOptions) node.ComputeConfig {
+func setupPricingCLIFlags(cmd *cobra.Command, OS *ServeOptions) {
+	cmd.PersistentFlags().Float64Var(
+		&OS.PriceCPUSecond, "price-cpu-second", OS.PriceCPUSecond,
+		`Price of one CPU core for one second, quoted in bids for jobs.`,
+	)
+	cmd.PersistentFlags().Float64Var(
+		&OS.PriceGBSecond, "price-gb-second", OS.PriceGBSecond,
+		`Price of one GB of memory for one second, quoted in bids for jobs.`,
+	)
+	cmd.PersistentFlags().Float64Var(
+		&OS.PriceGPUSecond, "price-gpu-second", OS.PriceGPUSecond,
+		`Price of one GPU for one second, quoted in bids for jobs.`,
+	)
+}
+
+func getRequesterConfig(OS *ServeOptions) node.RequesterConfig {
+	params := node.DefaultRequesterConfig
+	params.ClientQuota = OS.ClientQuota
+	params.AuthRegistryPath = OS.AuthRegistry
+	return node.NewRequesterConfigWith(params)
+}
+
+func getComputeConfig(OS *ServeOptions) (node.ComputeConfig, error) {
+	pullPolicy, err := docker.ParsePullPolicy(OS.DockerPullPolicy)
+	if err != nil {
+		return node.ComputeConfig{}, err
+	}
+	securityPolicy, err := docker.LoadSecurityPolicy(OS.DockerSecurityPolicy)
+	if err != nil {
+		return node.ComputeConfig{}, err
+	}
+	if OS.PriceCPUSecond < 0 || OS.PriceGBSecond < 0 || OS.PriceGPUSecond < 0 {
+		return node.ComputeConfig{}, fmt.Errorf("prices must not be negative")
+	}
+
 	return node.NewComputeConfigWith(node.ComputeConfigParams{
 		JobSelectionPolicy: getJobSelectionConfig(OS),
 		TotalResourceLimits: capacity.ParseResourceUsageConfig(model.ResourceUsageConfig{
@@ -228,7 +429,19 @@ func getComputeConfig(OS *ServeOptions) node.ComputeConfig {
 		}),
 		IgnorePhysicalResourceLimits:          os.Getenv("BACALHAU_CAPACITY_MANAGER_OVER_COMMIT") != "",
 		JobExecutionTimeoutClientIDBypassList: OS.JobExecutionTimeoutClientIDBypassList,
-	})
+		JobPrepareTimeout:                     OS.JobPrepareTimeout,
+		InputCacheSize:                        capacity.ConvertBytesString(OS.InputCacheSize),
+		DockerConfigPath:                      OS.DockerConfigPath,
+		DockerPullPolicy:                      pullPolicy,
+		DockerPrePullImages:                   OS.DockerPrePullImages,
+		DockerSecurityPolicy:                  securityPolicy,
+		PricingPolicy: model.PricingPolicy{
+			CPUSecond: OS.PriceCPUSecond,
+			GBSecond:  OS.PriceGBSecond,
+			GPUSecond: OS.PriceGPUSecond,
+		},
+		ResultsRetention: OS.RetainResultsFor,
+	}), nil
 }
 
 func newServeCmd() *cobra.Command {
@@ -288,8 +501,11 @@ func newServeCmd() *cobra.Command {
 	)
 
 	setupLibp2pCLIFlags(serveCmd, OS)
+	setupTransportCLIFlags(serveCmd, OS)
 	setupJobSelectionCLIFlags(serveCmd, OS)
 	setupCapacityManagerCLIFlags(serveCmd, OS)
+	setupPricingCLIFlags(serveCmd, OS)
+	setupRequesterCLIFlags(serveCmd, OS)
 
 	return serveCmd
 }
@@ -330,6 +546,16 @@ func serve(cmd *cobra.Command, OS *ServeOptions) error {
 		Fatal(cmd, "--job-selection-data-locality must be either 'local' or 'anywhere'", 1)
 	}
 
+	computeConfig, err := getComputeConfig(OS)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Invalid compute node config: %s", err), 1)
+	}
+
+	transportConfig, err := getTransportConfig(OS)
+	if err != nil {
+		Fatal(cmd, err.Error(), 1)
+	}
+
 	// Establishing p2p connection
 	peers := getPeers(OS)
 	log.Debug().Msgf("libp2p connecting to: %s", peers)
@@ -367,11 +593,12 @@ func serve(cmd *cobra.Command, OS *ServeOptions) error {
 		HostAddress:          OS.HostAddress,
 		APIPort:              apiPort,
 		MetricsPort:          OS.MetricsPort,
-		ComputeConfig:        getComputeConfig(OS),
-		RequesterNodeConfig:  node.NewRequesterConfigWithDefaults(),
+		ComputeConfig:        computeConfig,
+		RequesterNodeConfig:  getRequesterConfig(OS),
 		IsComputeNode:        isComputeNode,
 		IsRequesterNode:      isRequesterNode,
 		Labels:               OS.Labels,
+		HTTPTransport:        transportConfig,
 	}
 
 	if OS.LotusFilecoinStorageDuration != time.Duration(0) &&
@@ -385,6 +612,14 @@ func serve(cmd *cobra.Command, OS *ServeOptions) error {
 		}
 	}
 
+	if OS.RecordTrace != "" {
+		nodeConfig.TraceRecorder, err = simulator.NewFileRecorder(libp2pHost.ID().String(), OS.RecordTrace)
+		if err != nil {
+			Fatal(cmd, err.Error(), 1)
+		}
+		cm.RegisterCallback(nodeConfig.TraceRecorder.Close)
+	}
+
 	// Create node
 	node, err := node.NewStandardNode(ctx, nodeConfig)
 	if err != nil {
@@ -2,28 +2,120 @@ package bacalhau
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/filecoin-project/bacalhau/pkg/devstack"
 	"github.com/filecoin-project/bacalhau/pkg/libp2p"
 	"github.com/filecoin-project/bacalhau/pkg/localdb/inmemory"
 	"github.com/filecoin-project/bacalhau/pkg/node"
+	"github.com/filecoin-project/bacalhau/pkg/simulator"
 	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/util/templates"
+	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/multiformats/go-multiaddr"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
 )
 
+const (
+	replayTargetRequester = "requester"
+	replayTargetCompute   = "compute"
+)
+
+var (
+	simulatorReplayLong = templates.LongDesc(i18n.T(`
+		Replay a trace recorded with --record-trace against the requester or compute node built into this binary.
+
+		The requester target submits the jobs of the trace to a scheduler, and answers its requests to compute nodes
+		with the recorded responses and callbacks. The compute target sends the requests a compute node received to a
+		compute node with noop executors, and compares its responses and callbacks with the recorded ones.
+
+		The command fails if the replay exchanged different messages than recorded.
+`))
+
+	//nolint:lll // Documentation
+	simulatorReplayExample = templates.Examples(i18n.T(`
+		# Record the messages of all the nodes of a devstack that uses the simulator
+		bacalhau simulator --record-trace trace.jsonl
+
+		# Replay the trace against the scheduler of this binary, twice as fast as recorded
+		bacalhau simulator replay trace.jsonl --time-scale 0.5
+
+		# Replay the requests one of the compute nodes of the trace received
+		bacalhau simulator replay trace.jsonl --target compute --node QmdZQ7ZbhnvWY1J12XYKGHApJ6aufKyLNSvf8jZBrBaAVL
+`))
+)
+
+type SimulatorReplayOptions struct {
+	Target      string        // The node under replay: "requester" or "compute".
+	NodeID      string        // The compute node of the trace to replay the requests of.
+	TimeScale   float64       // Multiplies the recorded delays between messages.
+	IdleTimeout time.Duration // Ends the replay once no message was exchanged for this long.
+	RecordTrace string        // File to record the replay to.
+}
+
+func NewSimulatorReplayOptions() *SimulatorReplayOptions {
+	return &SimulatorReplayOptions{
+		Target:      replayTargetRequester,
+		TimeScale:   1,
+		IdleTimeout: 30 * time.Second, //nolint:gomnd
+	}
+}
+
 func newSimulatorCmd() *cobra.Command {
-	return &cobra.Command{
+	recordTrace := ""
+	simulatorCmd := &cobra.Command{
 		Use:   "simulator",
 		Short: "Run the bacalhau simulator",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSimulator(cmd)
+			return runSimulator(cmd, recordTrace)
 		},
 	}
+	simulatorCmd.Flags().StringVar(
+		&recordTrace, "record-trace", recordTrace,
+		`File to record the requests and callbacks of all the nodes that use the simulator, and the events of its jobs, to.`,
+	)
+	simulatorCmd.AddCommand(newSimulatorReplayCmd())
+	return simulatorCmd
 }
 
-func runSimulator(cmd *cobra.Command) error {
+func newSimulatorReplayCmd() *cobra.Command {
+	ORs := NewSimulatorReplayOptions()
+	replayCmd := &cobra.Command{
+		Use:     "replay <trace>",
+		Short:   "Replay a recorded trace against a requester or compute node",
+		Long:    simulatorReplayLong,
+		Example: simulatorReplayExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSimulatorReplay(cmd, args[0], ORs)
+		},
+	}
+	replayCmd.Flags().StringVar(
+		&ORs.Target, "target", ORs.Target,
+		fmt.Sprintf(`The node to replay the trace against: %q or %q.`, replayTargetRequester, replayTargetCompute),
+	)
+	replayCmd.Flags().StringVar(
+		&ORs.NodeID, "node", ORs.NodeID,
+		`The compute node of the trace to replay the requests of, if the trace holds requests to more than one.`,
+	)
+	replayCmd.Flags().Float64Var(
+		&ORs.TimeScale, "time-scale", ORs.TimeScale,
+		`Multiplies the recorded delays between messages: 1 replays in real time, 0 as fast as possible.`,
+	)
+	replayCmd.Flags().DurationVar(
+		&ORs.IdleTimeout, "idle-timeout", ORs.IdleTimeout,
+		`End the replay once no message was exchanged for this long.`,
+	)
+	replayCmd.Flags().StringVar(
+		&ORs.RecordTrace, "record-trace", ORs.RecordTrace,
+		`File to record the replay to, to compare it with the replayed trace.`,
+	)
+	return replayCmd
+}
+
+func runSimulator(cmd *cobra.Command, recordTrace string) error {
 	//Cleanup manager ensures that resources are freed before exiting:
 	cm := system.NewCleanupManager()
 	//cm.RegisterCallback(system.CleanupTraceProvider)
@@ -63,6 +155,13 @@ func runSimulator(cmd *cobra.Command) error {
 		IsComputeNode:       true,
 		IsRequesterNode:     true,
 	}
+	if recordTrace != "" {
+		nodeConfig.TraceRecorder, err = simulator.NewFileRecorder(libp2pHost.ID().String(), recordTrace)
+		if err != nil {
+			Fatal(cmd, err.Error(), 1)
+		}
+		cm.RegisterCallback(nodeConfig.TraceRecorder.Close)
+	}
 	node, err := node.NewNode(ctx, nodeConfig, devstack.NewNoopNodeDependencyInjector())
 	if err != nil {
 		Fatal(cmd, fmt.Sprintf("Error creating node: %s", err), 1)
@@ -76,3 +175,109 @@ func runSimulator(cmd *cobra.Command) error {
 	<-ctx.Done() // block until killed
 	return nil
 }
+
+func runSimulatorReplay(cmd *cobra.Command, tracePath string, ORs *SimulatorReplayOptions) error {
+	cm := system.NewCleanupManager()
+	defer cm.Cleanup()
+	ctx := cmd.Context()
+
+	trace, err := simulator.LoadTrace(tracePath)
+	if err != nil {
+		return fmt.Errorf("failed to read trace: %w", err)
+	}
+	if len(trace) == 0 {
+		return fmt.Errorf("trace %s is empty", tracePath)
+	}
+
+	libp2pHost, err := libp2p.NewHost(0)
+	if err != nil {
+		return fmt.Errorf("error creating libp2p host: %w", err)
+	}
+	cm.RegisterCallback(libp2pHost.Close)
+
+	var recorder *simulator.Recorder
+	if ORs.RecordTrace != "" {
+		recorder, err = simulator.NewFileRecorder(libp2pHost.ID().String(), ORs.RecordTrace)
+		if err != nil {
+			return err
+		}
+		cm.RegisterCallback(recorder.Close)
+	}
+
+	var report simulator.ReplayReport
+	switch ORs.Target {
+	case replayTargetRequester:
+		requesterConfig := node.NewRequesterConfigWithDefaults()
+		report, err = simulator.ReplayRequester(ctx, cm, simulator.RequesterReplayParams{
+			Host:                               libp2pHost,
+			Trace:                              trace,
+			TimeScale:                          ORs.TimeScale,
+			IdleTimeout:                        ORs.IdleTimeout,
+			JobNegotiationTimeout:              requesterConfig.JobNegotiationTimeout,
+			StateManagerBackgroundTaskInterval: requesterConfig.StateManagerBackgroundTaskInterval,
+			Recorder:                           recorder,
+		})
+	case replayTargetCompute:
+		report, err = replayCompute(cmd, cm, libp2pHost, trace, ORs, recorder)
+	default:
+		return fmt.Errorf("invalid target %s. Only %s and %s values are supported",
+			ORs.Target, replayTargetRequester, replayTargetCompute)
+	}
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Replayed %d messages as recorded\n", report.Replayed)
+	for _, message := range report.Diverged {
+		cmd.Printf("Diverged: %s\n", message)
+	}
+	for _, message := range report.Unexpected {
+		cmd.Printf("Unexpected: %s\n", message)
+	}
+	for _, message := range report.Missing {
+		cmd.Printf("Missing: %s\n", message)
+	}
+	if !report.Matched() {
+		return fmt.Errorf("the replay diverged from trace %s", tracePath)
+	}
+	return nil
+}
+
+// replayCompute replays a trace against a compute node with noop executors, verifiers and publishers.
+func replayCompute(
+	cmd *cobra.Command,
+	cm *system.CleanupManager,
+	libp2pHost host.Host,
+	trace []simulator.TraceRecord,
+	ORs *SimulatorReplayOptions,
+	recorder *simulator.Recorder) (simulator.ReplayReport, error) {
+	ctx := cmd.Context()
+	datastore, err := inmemory.NewInMemoryDatastore()
+	if err != nil {
+		return simulator.ReplayReport{}, err
+	}
+	computeNode, err := node.NewNode(ctx, node.NodeConfig{
+		CleanupManager:      cm,
+		LocalDB:             datastore,
+		Host:                libp2pHost,
+		HostAddress:         "0.0.0.0",
+		ComputeConfig:       node.NewComputeConfigWithDefaults(),
+		RequesterNodeConfig: node.NewRequesterConfigWithDefaults(),
+		IsComputeNode:       true,
+		TraceRecorder:       recorder,
+	}, devstack.NewNoopNodeDependencyInjector())
+	if err != nil {
+		return simulator.ReplayReport{}, fmt.Errorf("error creating node: %w", err)
+	}
+
+	replayer := simulator.NewComputeReplayer(simulator.ComputeReplayParams{
+		NodeID:      libp2pHost.ID().String(),
+		Endpoint:    computeNode.ComputeNode.LocalEndpoint,
+		Trace:       trace,
+		TraceNodeID: ORs.NodeID,
+		TimeScale:   ORs.TimeScale,
+		IdleTimeout: ORs.IdleTimeout,
+	})
+	computeNode.ComputeNode.RegisterLocalComputeCallback(replayer)
+	return replayer.Replay(ctx)
+}
@@ -74,6 +74,7 @@ var eventsWorthPrinting = map[model.JobEventType]eventStruct{
 
 	// Should we print at all? Empty events get skipped
 	model.JobEventBidCancelled: {},
+	model.JobEventImagePulled:  {},
 	model.JobEventBidRejected:  {},
 	model.JobEventDealUpdated:  {},
 }
@@ -470,8 +471,9 @@ func downloadResultsHandler(
 	if err != nil {
 		return err
 	}
+	processedDownloadSettings.Progress = cmd.ErrOrStderr()
 
-	downloaderProvider := util.NewStandardDownloaders(cm, &processedDownloadSettings)
+	downloaderProvider := util.NewStandardDownloaders(cm, &processedDownloadSettings, GetAPIClient())
 	if err != nil {
 		return err
 	}
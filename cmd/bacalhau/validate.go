@@ -1,12 +1,14 @@
 package bacalhau
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/model/v1beta2"
 	"github.com/filecoin-project/bacalhau/pkg/util/templates"
 	"github.com/invopop/jsonschema"
 	"github.com/spf13/cobra"
@@ -36,6 +38,9 @@ var (
 
 		# Output the jsonschema for a bacalhau job
 		bacalhau validate --output-schema
+
+		# Convert a job written with an older API version to the latest one
+		bacalhau validate --convert ./job.yaml > ./job-latest.yaml
 `))
 )
 
@@ -44,6 +49,7 @@ type ValidateOptions struct {
 	OutputFormat    string // Output format (json or yaml)
 	OutputSchema    bool   // Output the schema to stdout
 	OutputDirectory string // Output directory for the job
+	Convert         bool   // Output the job converted to the latest API version
 }
 
 func NewValidateOptions() *ValidateOptions {
@@ -71,20 +77,22 @@ func newValidateCmd() *cobra.Command {
 
 	validateCmd.PersistentFlags().BoolVar(
 		&OV.OutputSchema, "output-schema", OV.OutputSchema,
-		`Output the JSON schema for a Job to stdout then exit`,
+		`Output the JSON schema for a Job of the latest API version to stdout then exit`,
+	)
+	validateCmd.PersistentFlags().BoolVar(
+		&OV.Convert, "convert", OV.Convert,
+		`Output the job converted to the latest API version, if it is valid`,
 	)
 
 	return validateCmd
 }
 
 func validate(cmd *cobra.Command, cmdArgs []string, OV *ValidateOptions) error {
-	j := &model.Job{}
-	jsonSchemaData, err := GenerateJobJSONSchema()
-	if err != nil {
-		return err
-	}
-
 	if OV.OutputSchema {
+		jsonSchemaData, err := GenerateJobJSONSchema(model.APIVersionLatest())
+		if err != nil {
+			return err
+		}
 		//nolint
 		cmd.Printf("%s", jsonSchemaData)
 		return nil
@@ -97,6 +105,7 @@ func validate(cmd *cobra.Command, cmdArgs []string, OV *ValidateOptions) error {
 
 	OV.Filename = cmdArgs[0]
 	var byteResult []byte
+	var err error
 
 	if OV.Filename == "" {
 		// Read from stdin
@@ -124,8 +133,9 @@ func validate(cmd *cobra.Command, cmdArgs []string, OV *ValidateOptions) error {
 		}
 
 		if fileextension == ".json" || fileextension == ".yaml" || fileextension == ".yml" {
-			// Yaml can parse json
-			err = model.YAMLUnmarshalWithMax(byteResult, &j)
+			// Yaml can parse json. The job's fields depend on its API version, which the schema checks below
+			var document map[string]interface{}
+			err = model.YAMLUnmarshalWithMax(byteResult, &document)
 			if err != nil {
 				Fatal(cmd, fmt.Sprintf("Error unmarshaling yaml from file (%s): %s", OV.Filename, err), 1)
 			}
@@ -141,7 +151,12 @@ func validate(cmd *cobra.Command, cmdArgs []string, OV *ValidateOptions) error {
 		Fatal(cmd, fmt.Sprintf("Error converting yaml to json: %s", err), 1)
 	}
 
-	// println(str)
+	// the job is validated against the schema of the API version it is written with
+	version := jobDocumentAPIVersion(fileContentsAsJSONBytes)
+	jsonSchemaData, err := GenerateJobJSONSchema(version)
+	if err != nil {
+		return err
+	}
 	schemaLoader := gojsonschema.NewStringLoader(string(jsonSchemaData))
 	documentLoader := gojsonschema.NewStringLoader(string(fileContentsAsJSONBytes))
 
@@ -150,7 +165,13 @@ func validate(cmd *cobra.Command, cmdArgs []string, OV *ValidateOptions) error {
 		Fatal(cmd, fmt.Sprintf("Error validating json: %s", err), 1)
 	}
 
-	if result.Valid() {
+	if result.Valid() && OV.Convert {
+		converted, convertErr := convertJobDocument(version, fileContentsAsJSONBytes)
+		if convertErr != nil {
+			Fatal(cmd, fmt.Sprintf("Error converting job: %s", convertErr), 1)
+		}
+		cmd.Print(string(converted))
+	} else if result.Valid() {
 		cmd.Println("The Job is valid")
 	} else {
 		msg := "The Job is not valid. See errors:\n"
@@ -162,8 +183,81 @@ func validate(cmd *cobra.Command, cmdArgs []string, OV *ValidateOptions) error {
 	return nil
 }
 
-func GenerateJobJSONSchema() ([]byte, error) {
-	s := jsonschema.Reflect(&model.Job{})
+// jobDocumentAPIVersion returns the API version a job is written with, which is V1beta1 for documents that don't say.
+func jobDocumentAPIVersion(jsonData []byte) model.APIVersion {
+	var document struct {
+		APIVersion string `json:"APIVersion"`
+	}
+	if err := json.Unmarshal(jsonData, &document); err != nil {
+		return model.V1beta1
+	}
+	version, err := model.ParseAPIVersion(document.APIVersion)
+	if err != nil {
+		return model.V1beta1
+	}
+	return version
+}
+
+// convertJobDocument converts a job written with the given API version to a YAML document of the latest version. Empty
+// fields are left out, so that the document only holds what was written.
+func convertJobDocument(version model.APIVersion, jsonData []byte) ([]byte, error) {
+	job, err := model.APIVersionParseJob(version.String(), string(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	convertedJSON, err := model.JSONMarshalWithMax(model.ConvertToV1beta2Job(job))
+	if err != nil {
+		return nil, err
+	}
+	var document interface{}
+	if err = json.Unmarshal(convertedJSON, &document); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(removeEmptyFields(document))
+}
+
+// removeEmptyFields removes the fields of JSON objects whose values are empty objects, recursively.
+func removeEmptyFields(value interface{}) interface{} {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	for key, field := range object {
+		field = removeEmptyFields(field)
+		if fieldObject, isObject := field.(map[string]interface{}); isObject && len(fieldObject) == 0 {
+			delete(object, key)
+		} else {
+			object[key] = field
+		}
+	}
+	return object
+}
+
+func GenerateJobJSONSchema(version model.APIVersion) ([]byte, error) {
+	if version == model.V1beta2 {
+		return generateJSONSchema(&v1beta2.Job{}, []jsonSchemaEnum{
+			{Path: "$defs.EngineSpec.properties.Type", Enums: model.EngineNames()},
+			{Path: "$defs.VerifierSpec.properties.Type", Enums: model.VerifierNames()},
+			{Path: "$defs.Spec.properties.Publishers.items", Enums: model.PublisherNames()},
+			{Path: "$defs.StorageSpec.properties.StorageSource", Enums: model.StorageSourceNames()},
+		})
+	}
+	return generateJSONSchema(&model.Job{}, []jsonSchemaEnum{
+		{Path: "$defs.Spec.properties.Engine", Enums: model.EngineNames()},
+		{Path: "$defs.Spec.properties.Verifier", Enums: model.VerifierNames()},
+		{Path: "$defs.Spec.properties.Publisher", Enums: model.PublisherNames()},
+		{Path: "$defs.StorageSpec.properties.StorageSource", Enums: model.StorageSourceNames()},
+	})
+}
+
+// jsonSchemaEnum lists the values of an enum type found at a path of the schema
+type jsonSchemaEnum struct {
+	Path  string
+	Enums []string
+}
+
+func generateJSONSchema(job interface{}, enumTypes []jsonSchemaEnum) ([]byte, error) {
+	s := jsonschema.Reflect(job)
 	// Find key in a json document in Golang
 	// https://stackoverflow.com/questions/52953282/how-to-find-a-key-in-a-json-document
 
@@ -175,24 +269,6 @@ func GenerateJobJSONSchema() ([]byte, error) {
 	// JSON String
 	jsonString := string(jsonSchemaData)
 
-	enumTypes := []struct {
-		Name  string
-		Path  string
-		Enums []string
-	}{
-		{Name: "Engine",
-			Path:  "$defs.Spec.properties.Engine",
-			Enums: model.EngineNames()},
-		{Name: "Verifier",
-			Path:  "$defs.Spec.properties.Verifier",
-			Enums: model.VerifierNames()},
-		{Name: "Publisher",
-			Path:  "$defs.Spec.properties.Publisher",
-			Enums: model.PublisherNames()},
-		{Name: "StorageSource",
-			Path:  "$defs.StorageSpec.properties.StorageSource",
-			Enums: model.StorageSourceNames()},
-	}
 	for _, enumType := range enumTypes {
 		// Use sjson to find the enum type path in the JSON
 		jsonString, _ = sjson.Set(jsonString, enumType.Path+".type", "string")
@@ -4,8 +4,11 @@ package bacalhau
 
 import (
 	"fmt"
+	"os"
 	"testing"
 
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/model/v1beta2"
 	testutils "github.com/filecoin-project/bacalhau/pkg/test/utils"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -25,8 +28,9 @@ func (s *ValidateSuite) TestValidate() {
 		testFile string
 		valid    bool
 	}{
-		"validJobFile":   {testFile: "../../testdata/job-noop.yaml", valid: true},
-		"InvalidJobFile": {testFile: "../../testdata/job-noop-invalid.yml", valid: false},
+		"validJobFile":        {testFile: "../../testdata/job-noop.yaml", valid: true},
+		"validV1beta2JobFile": {testFile: "../../testdata/job-noop-v1beta2.yaml", valid: true},
+		"InvalidJobFile":      {testFile: "../../testdata/job-noop-invalid.yml", valid: false},
 	}
 	for name, test := range tests {
 		func() {
@@ -53,3 +57,19 @@ func (s *ValidateSuite) TestValidate() {
 
 	}
 }
+
+func (s *ValidateSuite) TestConvert() {
+	Fatal = FakeFatalErrorHandler
+
+	_, out, err := ExecuteTestCobraCommand(s.T(), "validate", "--convert", "../../testdata/job-noop.yaml")
+	require.NoError(s.T(), err)
+
+	expected, err := os.ReadFile("../../testdata/job-noop-v1beta2.yaml")
+	require.NoError(s.T(), err)
+	var expectedJob, convertedJob v1beta2.Job
+	require.NoError(s.T(), model.YAMLUnmarshalWithMax(expected, &expectedJob))
+	require.NoError(s.T(), model.YAMLUnmarshalWithMax([]byte(out), &convertedJob))
+	// the converted job also says what the V1beta1 job left to defaults
+	expectedJob.Spec.Network.Type = model.NetworkNone.String()
+	require.Equal(s.T(), expectedJob, convertedJob)
+}
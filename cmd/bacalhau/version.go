@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/filecoin-project/bacalhau/pkg/model"
@@ -33,6 +34,46 @@ import (
 type Versions struct {
 	ClientVersion *model.BuildVersionInfo `json:"clientVersion,omitempty"`
 	ServerVersion *model.BuildVersionInfo `json:"serverVersion,omitempty"`
+	// NodeVersions are the versions the compute nodes known to the server run, by node ID
+	NodeVersions map[string]model.NodeVersions `json:"nodeVersions,omitempty"`
+}
+
+// nodeVersionGroup is a version run by some of the compute nodes
+type nodeVersionGroup struct {
+	versions model.NodeVersions
+	nodes    int
+}
+
+// String returns a description of the version
+func (g nodeVersionGroup) String() string {
+	if !g.versions.IsKnown() {
+		return "unknown (predates version negotiation)"
+	}
+	return fmt.Sprintf("%s (protocol %s, API versions %s)", g.versions.BuildVersion.GitVersion,
+		g.versions.ProtocolVersion, strings.Join(g.versions.APIVersions, ", "))
+}
+
+// groupNodeVersions returns the distinct versions the nodes run, most common first.
+func groupNodeVersions(nodeVersions map[string]model.NodeVersions) []nodeVersionGroup {
+	groups := make(map[string]*nodeVersionGroup)
+	for _, versions := range nodeVersions {
+		key := nodeVersionGroup{versions: versions}.String()
+		if _, ok := groups[key]; !ok {
+			groups[key] = &nodeVersionGroup{versions: versions}
+		}
+		groups[key].nodes++
+	}
+	result := make([]nodeVersionGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].nodes != result[j].nodes {
+			return result[i].nodes > result[j].nodes
+		}
+		return result[i].String() < result[j].String()
+	})
+	return result
 }
 
 // VersionOptions is a struct to support version command
@@ -54,6 +95,7 @@ func newVersionCmd() *cobra.Command {
 	versionCmd := &cobra.Command{
 		Use:   "version",
 		Short: "Get the client and server version.",
+		Long:  "Get the client and server version, and the versions the compute nodes known to the server run.",
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			return runVersion(cmd, oV)
 		},
@@ -117,6 +159,18 @@ func (oV *VersionOptions) Run(ctx context.Context, cmd *cobra.Command) error {
 		}
 
 		versions.ServerVersion = serverVersion
+
+		// servers that predate version negotiation don't list their nodes
+		nodes, err := GetAPIClient().Nodes(ctx)
+		if err != nil {
+			log.Ctx(ctx).Debug().Err(err).Msgf("could not get compute node versions")
+		}
+		for _, node := range nodes {
+			if versions.NodeVersions == nil {
+				versions.NodeVersions = make(map[string]model.NodeVersions, len(nodes))
+			}
+			versions.NodeVersions[node.PeerInfo.ID.String()] = node.Versions
+		}
 	}
 
 	switch oV.Output {
@@ -125,6 +179,16 @@ func (oV *VersionOptions) Run(ctx context.Context, cmd *cobra.Command) error {
 		if versions.ServerVersion != nil {
 			cmd.Printf("Server Version: %s\n", versions.ServerVersion.GitVersion)
 		}
+		if len(versions.NodeVersions) > 0 {
+			groups := groupNodeVersions(versions.NodeVersions)
+			cmd.Println("Compute Node Versions:")
+			for _, group := range groups {
+				cmd.Printf("  %s: %d node(s)\n", group, group.nodes)
+			}
+			if len(groups) > 1 {
+				cmd.Printf("Warning: compute nodes run %d different versions\n", len(groups))
+			}
+		}
 	case YAMLFormat:
 		marshaled, err := model.YAMLMarshalWithMax(versions)
 		if err != nil {
@@ -70,3 +70,21 @@ func (suite *VersionSuite) Test_VersionOutputs() {
 	require.Equal(suite.T(), yamlDoc.ClientVersion.GitCommit, yamlDoc.ServerVersion.GitCommit, "Client and Server do not match in yaml.")
 
 }
+
+func TestGroupNodeVersions(t *testing.T) {
+	current := model.NodeVersions{
+		BuildVersion:    model.BuildVersionInfo{GitVersion: "v0.3.20"},
+		ProtocolVersion: model.ProtocolVersion,
+		APIVersions:     model.SupportedAPIVersions(),
+	}
+	groups := groupNodeVersions(map[string]model.NodeVersions{
+		"a": current,
+		"b": current,
+		"c": {},
+	})
+	require.Len(t, groups, 2)
+	require.Equal(t, 2, groups[0].nodes)
+	require.Contains(t, groups[0].String(), "v0.3.20")
+	require.Equal(t, 1, groups[1].nodes)
+	require.Contains(t, groups[1].String(), "unknown")
+}
@@ -98,6 +98,18 @@ func newRunWasmCmd() *cobra.Command {
 		VerifierFlag(&wasmJob.Spec.Verifier), "verifier",
 		`What verification engine to use to run the job`,
 	)
+	runWasmCommand.PersistentFlags().Float64Var(
+		&wasmJob.Spec.Verification.SampleRate, "verification-sample-rate", wasmJob.Spec.Verification.SampleRate,
+		`With the optimistic verifier, the fraction of shards (between 0 and 1) that also run on a second node to check the results`,
+	)
+	runWasmCommand.PersistentFlags().StringSliceVar(
+		&wasmJob.Spec.Verification.IgnorePaths, "verification-ignore", wasmJob.Spec.Verification.IgnorePaths,
+		`Glob patterns of result files the verifier does not compare, e.g. logs with timestamps (e.g. "*.log")`,
+	)
+	runWasmCommand.PersistentFlags().IntVar(
+		&wasmJob.Spec.Verification.MaxDifferingFiles, "verification-max-differing-files", wasmJob.Spec.Verification.MaxDifferingFiles,
+		`With the optimistic verifier, how many files may differ between results that are still considered the same`,
+	)
 	runWasmCommand.PersistentFlags().Var(
 		PublisherFlag(&wasmJob.Spec.Publisher), "publisher",
 		`What publisher engine to use to publish the job results`,
@@ -114,6 +126,18 @@ func newRunWasmCmd() *cobra.Command {
 		&wasmJob.Spec.Deal.MinBids, "min-bids", wasmJob.Spec.Deal.MinBids,
 		`Minimum number of bids that must be received before concurrency-many bids will be accepted (at random)`,
 	)
+	runWasmCommand.PersistentFlags().BoolVar(
+		&wasmJob.Spec.Deal.Broadcast, "broadcast", wasmJob.Spec.Deal.Broadcast,
+		`Run the job on every node that matches the node selectors, instead of on concurrency-many nodes`,
+	)
+	runWasmCommand.PersistentFlags().BoolVar(
+		&wasmJob.Spec.Deal.IncludeLateJoiners, "include-late-joiners", wasmJob.Spec.Deal.IncludeLateJoiners,
+		`With --broadcast, also run the job on matching nodes that join the network while it is running`,
+	)
+	runWasmCommand.PersistentFlags().Float64Var(
+		&wasmJob.Spec.Deal.MaxBudget, "max-budget", wasmJob.Spec.Deal.MaxBudget,
+		`The most to pay for each shard, summed over the prices of the accepted bids. The cheapest bids are accepted first.`,
+	)
 	runWasmCommand.PersistentFlags().Float64Var(
 		&wasmJob.Spec.Timeout, "timeout", wasmJob.Spec.Timeout,
 		`Job execution timeout in seconds (e.g. 300 for 5 minutes and 0.1 for 100ms)`,
@@ -216,7 +240,8 @@ func runWasm(
 	// We can only use a Deterministic verifier if we have multiple nodes running the job
 	// If the user has selected a Deterministic verifier (or we are using it by default)
 	// then switch back to a Noop Verifier if the concurrency is too low.
-	if wasmJob.Spec.Deal.Concurrency <= 1 && wasmJob.Spec.Verifier == model.VerifierDeterministic {
+	// Broadcast jobs run independently on each node, so there is nothing to compare.
+	if (wasmJob.Spec.Deal.Concurrency <= 1 || wasmJob.Spec.Deal.Broadcast) && wasmJob.Spec.Verifier == model.VerifierDeterministic {
 		wasmJob.Spec.Verifier = model.VerifierNoop
 	}
 
@@ -20,7 +20,7 @@ const canaryAnnotation = "canary"
 
 func getSampleDockerJob() *model.Job {
 	var j = &model.Job{
-		APIVersion: model.APIVersionLatest().String(),
+		APIVersion: model.APIVersionModel().String(),
 	}
 	j.Spec = model.Spec{
 		Engine:    model.EngineDocker,
@@ -44,7 +44,7 @@ func getSampleDockerJob() *model.Job {
 
 func getSampleDockerIPFSJob() *model.Job {
 	var j = &model.Job{
-		APIVersion: model.APIVersionLatest().String(),
+		APIVersion: model.APIVersionModel().String(),
 	}
 	j.Spec = model.Spec{
 		Engine:    model.EngineDocker,
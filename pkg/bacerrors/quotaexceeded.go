@@ -0,0 +1,68 @@
+package bacerrors
+
+import (
+	"fmt"
+)
+
+type QuotaExceeded GenericError
+
+// NewQuotaExceeded returns an error for a job that a client can't submit
+// because it would take them over one of their limits. requested is what the
+// job needs, on top of what the client is already using.
+func NewQuotaExceeded(clientID, limit string, max, used, requested interface{}) *QuotaExceeded {
+	var e QuotaExceeded
+	e.Code = ErrorCodeQuotaExceeded
+	e.Message = fmt.Sprintf(ErrorMessageQuotaExceeded, limit, max, used, requested)
+	e.Details = map[string]interface{}{
+		"client_id": clientID,
+		"limit":     limit,
+		"max":       max,
+		"used":      used,
+		"requested": requested,
+	}
+	e.SetError(fmt.Errorf("%s", e.Message))
+	return &e
+}
+
+func (e *QuotaExceeded) GetMessage() string {
+	return e.Message
+}
+func (e *QuotaExceeded) SetMessage(s string) {
+	e.Message = s
+}
+
+func (e *QuotaExceeded) Error() string {
+	return e.GetError().Error()
+}
+func (e *QuotaExceeded) GetError() error {
+	return e.Err
+}
+func (e *QuotaExceeded) SetError(err error) {
+	e.Err = err
+}
+
+func (e *QuotaExceeded) GetCode() string {
+	return ErrorCodeQuotaExceeded
+}
+func (e *QuotaExceeded) SetCode(string) {
+	e.Code = ErrorCodeQuotaExceeded
+}
+
+func (e *QuotaExceeded) GetDetails() map[string]interface{} {
+	return e.Details
+}
+
+func (e *QuotaExceeded) GetLimit() string {
+	if limit, ok := e.Details["limit"]; ok {
+		return limit.(string)
+	}
+	return ""
+}
+
+const (
+	ErrorCodeQuotaExceeded = "error-quota-exceeded"
+
+	ErrorMessageQuotaExceeded = "Quota exceeded. Limit: %s, max: %v, used: %v, requested: %v"
+)
+
+var _ BacalhauErrorInterface = (*QuotaExceeded)(nil)
@@ -0,0 +1,64 @@
+package bacerrors
+
+import (
+	"fmt"
+)
+
+type ScheduleNotFound GenericError
+
+func NewScheduleNotFound(id string) *ScheduleNotFound {
+	var e ScheduleNotFound
+	e.Code = ErrorCodeScheduleNotFound
+	e.Message = fmt.Sprintf(ErrorMessageScheduleNotFound, id)
+	e.Details = make(map[string]interface{})
+	e.Details["id"] = id
+	e.SetID(id)
+	e.SetError(fmt.Errorf("%s", e.Message))
+	return &e
+}
+
+func (e *ScheduleNotFound) GetMessage() string {
+	return e.Message
+}
+func (e *ScheduleNotFound) SetMessage(s string) {
+	e.Message = s
+}
+
+func (e *ScheduleNotFound) Error() string {
+	return e.GetError().Error()
+}
+func (e *ScheduleNotFound) GetError() error {
+	return e.Err
+}
+func (e *ScheduleNotFound) SetError(err error) {
+	e.Err = err
+}
+
+func (e *ScheduleNotFound) GetCode() string {
+	return ErrorCodeScheduleNotFound
+}
+func (e *ScheduleNotFound) SetCode(string) {
+	e.Code = ErrorCodeScheduleNotFound
+}
+
+func (e *ScheduleNotFound) GetDetails() map[string]interface{} {
+	return e.Details
+}
+
+func (e *ScheduleNotFound) GetID() string {
+	if id, ok := e.Details["id"]; ok {
+		return id.(string)
+	}
+	return ""
+}
+func (e *ScheduleNotFound) SetID(s string) {
+	e.Details["id"] = s
+}
+
+const (
+	ErrorCodeScheduleNotFound = "error-schedule-not-found"
+
+	ErrorMessageScheduleNotFound = "Schedule not found. ID: %s"
+)
+
+var _ BacalhauErrorInterface = (*ScheduleNotFound)(nil)
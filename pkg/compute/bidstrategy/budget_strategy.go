@@ -0,0 +1,45 @@
+package bidstrategy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+)
+
+type BudgetStrategyParams struct {
+	PricingPolicy model.PricingPolicy
+}
+
+// BudgetStrategy skips jobs whose budget can't pay for even a single
+// execution at this node's prices.
+type BudgetStrategy struct {
+	pricingPolicy model.PricingPolicy
+}
+
+func NewBudgetStrategy(params BudgetStrategyParams) *BudgetStrategy {
+	return &BudgetStrategy{
+		pricingPolicy: params.PricingPolicy,
+	}
+}
+
+func (s *BudgetStrategy) ShouldBid(context.Context, BidStrategyRequest) (BidStrategyResponse, error) {
+	return newShouldBidResponse(), nil
+}
+
+func (s *BudgetStrategy) ShouldBidBasedOnUsage(
+	_ context.Context, request BidStrategyRequest, usage model.ResourceUsageData) (BidStrategyResponse, error) {
+	budget := request.Job.Spec.Deal.MaxBudget
+	if budget <= 0 {
+		return newShouldBidResponse(), nil
+	}
+
+	price := s.pricingPolicy.Quote(usage, request.Job.Spec.GetTimeout())
+	if price > budget {
+		return BidStrategyResponse{
+			ShouldBid: false,
+			Reason:    fmt.Sprintf("job price %g exceeds its budget %g", price, budget),
+		}, nil
+	}
+	return newShouldBidResponse(), nil
+}
@@ -0,0 +1,50 @@
+//go:build unit || !integration
+
+package bidstrategy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudgetStrategy(t *testing.T) {
+	usage := model.ResourceUsageData{CPU: 1}
+	tests := []struct {
+		name      string
+		policy    model.PricingPolicy
+		budget    float64
+		shouldBid bool
+		reason    string
+	}{
+		{name: "no-budget", policy: model.PricingPolicy{CPUSecond: 1}, shouldBid: true},
+		{name: "free", budget: 1, shouldBid: true},
+		{name: "within-budget", policy: model.PricingPolicy{CPUSecond: 0.1}, budget: 1, shouldBid: true},
+		{name: "exact-budget", policy: model.PricingPolicy{CPUSecond: 0.1}, budget: 0.5, shouldBid: true},
+		{
+			name:      "over-budget",
+			policy:    model.PricingPolicy{CPUSecond: 1},
+			budget:    1,
+			shouldBid: false,
+			reason:    "job price 5 exceeds its budget 1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			subject := NewBudgetStrategy(BudgetStrategyParams{PricingPolicy: test.policy})
+			request := getBidStrategyRequest()
+			request.Job.Spec.Timeout = 5
+			request.Job.Spec.Deal.MaxBudget = test.budget
+
+			response, err := subject.ShouldBidBasedOnUsage(context.Background(), request, usage)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.shouldBid, response.ShouldBid)
+			assert.Equal(t, test.reason, response.Reason)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package bidstrategy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/docker"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/rs/zerolog/log"
+)
+
+// ImageAccessChecker returns an error if the image can't be pulled without credentials.
+type ImageAccessChecker func(ctx context.Context, image string) error
+
+// DefaultImageAccessCacheTTL is how long the outcome of checking whether an
+// image can be pulled without credentials is remembered.
+const DefaultImageAccessCacheTTL = 10 * time.Minute
+
+type RegistryCredentialsStrategyParams struct {
+	Credentials *docker.RegistryCredentials
+	// ImageAccessChecker defaults to asking the local docker daemon
+	ImageAccessChecker ImageAccessChecker
+	// ImageAccessCacheTTL defaults to DefaultImageAccessCacheTTL
+	ImageAccessCacheTTL time.Duration
+}
+
+type RegistryCredentialsStrategy struct {
+	credentials        *docker.RegistryCredentials
+	imageAccessChecker ImageAccessChecker
+	cacheTTL           time.Duration
+	cacheMu            sync.Mutex
+	cache              map[string]imageAccess
+}
+
+// imageAccess is the remembered outcome of checking access to an image.
+type imageAccess struct {
+	needsAuth bool
+	expiresAt time.Time
+}
+
+func NewRegistryCredentialsStrategy(params RegistryCredentialsStrategyParams) *RegistryCredentialsStrategy {
+	imageAccessChecker := params.ImageAccessChecker
+	if imageAccessChecker == nil {
+		imageAccessChecker = checkImageAccessWithDocker
+	}
+	cacheTTL := params.ImageAccessCacheTTL
+	if cacheTTL == 0 {
+		cacheTTL = DefaultImageAccessCacheTTL
+	}
+	return &RegistryCredentialsStrategy{
+		credentials:        params.Credentials,
+		imageAccessChecker: imageAccessChecker,
+		cacheTTL:           cacheTTL,
+		cache:              make(map[string]imageAccess),
+	}
+}
+
+func (s *RegistryCredentialsStrategy) ShouldBid(ctx context.Context, request BidStrategyRequest) (BidStrategyResponse, error) {
+	spec := request.Job.Spec
+	// jobs that bring their own token can always try to pull
+	if spec.Engine != model.EngineDocker || request.HasRegistryAuth || len(spec.Docker.RegistryAuth) > 0 {
+		return newShouldBidResponse(), nil
+	}
+
+	registry, err := docker.RegistryForImage(spec.Docker.Image)
+	if err != nil {
+		return BidStrategyResponse{ShouldBid: false, Reason: err.Error()}, nil
+	}
+	if s.credentials != nil && s.credentials.Has(ctx, registry) {
+		return newShouldBidResponse(), nil
+	}
+
+	// only reject when the registry says we need credentials, anything else
+	// (e.g. the registry being unreachable) will be reported when pulling
+	if s.needsAuth(ctx, spec.Docker.Image) {
+		return BidStrategyResponse{
+			ShouldBid: false,
+			Reason:    fmt.Sprintf("no credentials for registry %s", registry),
+		}, nil
+	}
+	return newShouldBidResponse(), nil
+}
+
+// needsAuth returns true if the registry says the image can't be pulled
+// without credentials. Outcomes are remembered per image so that the registry
+// isn't asked again for every job, except when the check itself failed.
+func (s *RegistryCredentialsStrategy) needsAuth(ctx context.Context, image string) bool {
+	now := time.Now()
+	s.cacheMu.Lock()
+	access, ok := s.cache[image]
+	s.cacheMu.Unlock()
+	if ok && now.Before(access.expiresAt) {
+		return access.needsAuth
+	}
+
+	err := s.imageAccessChecker(ctx, image)
+	needsAuth := docker.IsAuthError(err)
+	if err != nil && !needsAuth {
+		log.Ctx(ctx).Debug().Err(err).Msgf("could not check access to image %s", image)
+		return false
+	}
+
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	for cachedImage, cachedAccess := range s.cache {
+		if !now.Before(cachedAccess.expiresAt) {
+			delete(s.cache, cachedImage)
+		}
+	}
+	s.cache[image] = imageAccess{needsAuth: needsAuth, expiresAt: now.Add(s.cacheTTL)}
+	return needsAuth
+}
+
+func (s *RegistryCredentialsStrategy) ShouldBidBasedOnUsage(
+	_ context.Context, _ BidStrategyRequest, _ model.ResourceUsageData) (BidStrategyResponse, error) {
+	return newShouldBidResponse(), nil
+}
+
+func checkImageAccessWithDocker(ctx context.Context, image string) error {
+	client, err := docker.NewDockerClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return docker.CheckImageAccess(ctx, client, image, "")
+}
+
+// Compile-time check of interface implementation
+var _ BidStrategy = (*RegistryCredentialsStrategy)(nil)
@@ -0,0 +1,122 @@
+//go:build unit || !integration
+
+package bidstrategy
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/filecoin-project/bacalhau/pkg/docker"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type RegistryCredentialsStrategySuite struct {
+	suite.Suite
+	strategy *RegistryCredentialsStrategy
+	checked  []string
+}
+
+func TestRegistryCredentialsStrategySuite(t *testing.T) {
+	suite.Run(t, new(RegistryCredentialsStrategySuite))
+}
+
+func (s *RegistryCredentialsStrategySuite) SetupTest() {
+	configPath := filepath.Join(s.T().TempDir(), "config.json")
+	auth := base64.StdEncoding.EncodeToString([]byte("user:password"))
+	config := fmt.Sprintf(`{"auths": {"https://registry.example.com": {"auth": %q}}}`, auth)
+	require.NoError(s.T(), os.WriteFile(configPath, []byte(config), 0600))
+
+	s.checked = nil
+	s.strategy = NewRegistryCredentialsStrategy(RegistryCredentialsStrategyParams{
+		Credentials: docker.NewRegistryCredentials(configPath),
+		ImageAccessChecker: func(_ context.Context, image string) error {
+			s.checked = append(s.checked, image)
+			switch image {
+			case "private.example.com/image":
+				return errdefs.Unauthorized(fmt.Errorf("authentication required"))
+			case "offline.example.com/image":
+				return fmt.Errorf("connection refused")
+			}
+			return nil
+		},
+	})
+}
+
+func (s *RegistryCredentialsStrategySuite) shouldBid(image string, registryAuth []byte) bool {
+	request := getBidStrategyRequest()
+	request.Job.Spec.Engine = model.EngineDocker
+	request.Job.Spec.Docker = model.JobSpecDocker{Image: image, RegistryAuth: registryAuth}
+	response, err := s.strategy.ShouldBid(context.Background(), request)
+	require.NoError(s.T(), err)
+	return response.ShouldBid
+}
+
+func (s *RegistryCredentialsStrategySuite) TestNodeCredentials() {
+	require.True(s.T(), s.shouldBid("registry.example.com/org/image:v1", nil))
+	require.Empty(s.T(), s.checked, "registries we have credentials for should not be checked")
+}
+
+func (s *RegistryCredentialsStrategySuite) TestPublicImage() {
+	require.True(s.T(), s.shouldBid("ubuntu:latest", nil))
+	require.Equal(s.T(), []string{"ubuntu:latest"}, s.checked)
+}
+
+func (s *RegistryCredentialsStrategySuite) TestPrivateImage() {
+	require.False(s.T(), s.shouldBid("private.example.com/image", nil))
+}
+
+func (s *RegistryCredentialsStrategySuite) TestImageAccessCached() {
+	require.True(s.T(), s.shouldBid("ubuntu:latest", nil))
+	require.True(s.T(), s.shouldBid("ubuntu:latest", nil))
+	require.False(s.T(), s.shouldBid("private.example.com/image", nil))
+	require.False(s.T(), s.shouldBid("private.example.com/image", nil))
+	require.Equal(s.T(), []string{"ubuntu:latest", "private.example.com/image"}, s.checked)
+
+	// failed checks are retried
+	require.True(s.T(), s.shouldBid("offline.example.com/image", nil))
+	require.True(s.T(), s.shouldBid("offline.example.com/image", nil))
+	require.Equal(s.T(), []string{"offline.example.com/image", "offline.example.com/image"}, s.checked[2:])
+}
+
+func (s *RegistryCredentialsStrategySuite) TestImageAccessCacheExpires() {
+	s.strategy.cacheTTL = time.Nanosecond
+	require.True(s.T(), s.shouldBid("ubuntu:latest", nil))
+	time.Sleep(time.Millisecond)
+	require.True(s.T(), s.shouldBid("ubuntu:latest", nil))
+	require.Equal(s.T(), []string{"ubuntu:latest", "ubuntu:latest"}, s.checked)
+}
+
+func (s *RegistryCredentialsStrategySuite) TestJobToken() {
+	require.True(s.T(), s.shouldBid("private.example.com/image", []byte("sealed")))
+	require.Empty(s.T(), s.checked)
+}
+
+func (s *RegistryCredentialsStrategySuite) TestTokenSentOnAccept() {
+	request := getBidStrategyRequest()
+	request.Job.Spec.Engine = model.EngineDocker
+	request.Job.Spec.Docker = model.JobSpecDocker{Image: "private.example.com/image"}
+	request.HasRegistryAuth = true
+	response, err := s.strategy.ShouldBid(context.Background(), request)
+	require.NoError(s.T(), err)
+	require.True(s.T(), response.ShouldBid)
+	require.Empty(s.T(), s.checked)
+}
+
+func (s *RegistryCredentialsStrategySuite) TestUnreachableRegistry() {
+	require.True(s.T(), s.shouldBid("offline.example.com/image", nil))
+}
+
+func (s *RegistryCredentialsStrategySuite) TestOtherEngines() {
+	response, err := s.strategy.ShouldBid(context.Background(), getBidStrategyRequest())
+	require.NoError(s.T(), err)
+	require.True(s.T(), response.ShouldBid)
+	require.Empty(s.T(), s.checked)
+}
@@ -0,0 +1,46 @@
+package bidstrategy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/filecoin-project/bacalhau/pkg/docker"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+)
+
+type SecurityPolicyStrategyParams struct {
+	Policy docker.SecurityPolicy
+}
+
+// SecurityPolicyStrategy rejects docker jobs that ask for relaxations of the
+// node's container security policy that it doesn't allow.
+type SecurityPolicyStrategy struct {
+	policy docker.SecurityPolicy
+}
+
+func NewSecurityPolicyStrategy(params SecurityPolicyStrategyParams) *SecurityPolicyStrategy {
+	return &SecurityPolicyStrategy{
+		policy: params.Policy,
+	}
+}
+
+func (s *SecurityPolicyStrategy) ShouldBid(_ context.Context, request BidStrategyRequest) (BidStrategyResponse, error) {
+	if request.Job.Spec.Engine != model.EngineDocker {
+		return newShouldBidResponse(), nil
+	}
+	if err := s.policy.Allows(request.Job.Spec.Docker.Security); err != nil {
+		return BidStrategyResponse{
+			ShouldBid: false,
+			Reason:    fmt.Sprintf("security policy: %s", err),
+		}, nil
+	}
+	return newShouldBidResponse(), nil
+}
+
+func (s *SecurityPolicyStrategy) ShouldBidBasedOnUsage(
+	_ context.Context, _ BidStrategyRequest, _ model.ResourceUsageData) (BidStrategyResponse, error) {
+	return newShouldBidResponse(), nil
+}
+
+// Compile-time check of interface implementation
+var _ BidStrategy = (*SecurityPolicyStrategy)(nil)
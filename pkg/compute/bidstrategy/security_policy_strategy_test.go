@@ -0,0 +1,49 @@
+//go:build unit || !integration
+
+package bidstrategy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/bacalhau/pkg/docker"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityPolicyStrategy(t *testing.T) {
+	hardened := docker.NewHardenedSecurityPolicy()
+	relaxed := docker.NewHardenedSecurityPolicy()
+	relaxed.AllowRunAsRoot = true
+	relaxed.AllowedCapabilities = []string{"NET_ADMIN"}
+
+	for _, tc := range []struct {
+		name      string
+		policy    docker.SecurityPolicy
+		engine    model.Engine
+		security  model.JobSpecDockerSecurity
+		shouldBid bool
+	}{
+		{"no relaxations", hardened, model.EngineDocker, model.JobSpecDockerSecurity{}, true},
+		{"root not allowed", hardened, model.EngineDocker, model.JobSpecDockerSecurity{RunAsRoot: true}, false},
+		{"root allowed", relaxed, model.EngineDocker, model.JobSpecDockerSecurity{RunAsRoot: true}, true},
+		{"root on default policy", docker.SecurityPolicy{}, model.EngineDocker, model.JobSpecDockerSecurity{RunAsRoot: true}, true},
+		{"writable rootfs not allowed", relaxed, model.EngineDocker, model.JobSpecDockerSecurity{WritableRootFilesystem: true}, false},
+		{"capability allowed", relaxed, model.EngineDocker, model.JobSpecDockerSecurity{AddCapabilities: []string{"cap_net_admin"}}, true},
+		{"capability not allowed", relaxed, model.EngineDocker, model.JobSpecDockerSecurity{AddCapabilities: []string{"SYS_ADMIN"}}, false},
+		{"capability on default policy", docker.SecurityPolicy{}, model.EngineDocker,
+			model.JobSpecDockerSecurity{AddCapabilities: []string{"NET_ADMIN"}}, false},
+		{"other engines", hardened, model.EngineWasm, model.JobSpecDockerSecurity{RunAsRoot: true}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			strategy := NewSecurityPolicyStrategy(SecurityPolicyStrategyParams{Policy: tc.policy})
+			request := getBidStrategyRequest()
+			request.Job.Spec.Engine = tc.engine
+			request.Job.Spec.Docker.Security = tc.security
+
+			response, err := strategy.ShouldBid(context.Background(), request)
+			require.NoError(t, err)
+			require.Equal(t, tc.shouldBid, response.ShouldBid, response.Reason)
+		})
+	}
+}
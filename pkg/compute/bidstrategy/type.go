@@ -9,6 +9,9 @@ import (
 type BidStrategyRequest struct {
 	NodeID string
 	Job    model.Job
+	// HasRegistryAuth is true if a registry token will be sent with the job
+	// once the bid is accepted.
+	HasRegistryAuth bool
 }
 
 type BidStrategyResponse struct {
@@ -22,6 +22,7 @@ type BaseEndpointParams struct {
 	UsageCalculator capacity.UsageCalculator
 	BidStrategy     bidstrategy.BidStrategy
 	Executor        Executor
+	PricingPolicy   model.PricingPolicy
 }
 
 // Base implementation of Endpoint
@@ -31,6 +32,7 @@ type BaseEndpoint struct {
 	usageCalculator capacity.UsageCalculator
 	bidStrategy     bidstrategy.BidStrategy
 	executor        Executor
+	pricingPolicy   model.PricingPolicy
 }
 
 func NewBaseEndpoint(params BaseEndpointParams) BaseEndpoint {
@@ -40,6 +42,7 @@ func NewBaseEndpoint(params BaseEndpointParams) BaseEndpoint {
 		usageCalculator: params.UsageCalculator,
 		bidStrategy:     params.BidStrategy,
 		executor:        params.Executor,
+		pricingPolicy:   params.PricingPolicy,
 	}
 }
 
@@ -56,8 +59,9 @@ func (s BaseEndpoint) AskForBid(ctx context.Context, request AskForBidRequest) (
 	// ask the bidding strategy if we should bid on this job
 	// TODO: we should check at the shard level, not the job level
 	bidStrategyRequest := bidstrategy.BidStrategyRequest{
-		NodeID: s.id,
-		Job:    request.Job,
+		NodeID:          s.id,
+		Job:             request.Job,
+		HasRegistryAuth: request.HasRegistryAuth,
 	}
 
 	// Check bidding strategies before having to calculate resource usage
@@ -149,6 +153,7 @@ func (s BaseEndpoint) prepareAskForBidShardResponse(
 				ShardIndex:  shardIndex,
 			},
 			Accepted: true,
+			Price:    s.pricingPolicy.Quote(shardRequirements, request.Job.Spec.GetTimeout()),
 		}, nil
 	}
 }
@@ -176,6 +181,13 @@ func (s BaseEndpoint) BidAccepted(ctx context.Context, request BidAcceptedReques
 		"client_id":   execution.Shard.Job.Metadata.ClientID,
 	}).Inc()
 
+	// the registry token is only kept in memory for the run, not in the store
+	if len(request.RegistryAuth) > 0 {
+		job := *execution.Shard.Job
+		job.Spec.Docker.RegistryAuth = request.RegistryAuth
+		execution.Shard.Job = &job
+	}
+
 	err = s.executor.Run(ctx, execution)
 	if err != nil {
 		return BidAcceptedResponse{}, err
@@ -9,6 +9,7 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/publisher"
 	"github.com/filecoin-project/bacalhau/pkg/verifier"
+	"github.com/filecoin-project/bacalhau/pkg/verifier/manifest"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog/log"
 )
@@ -47,6 +48,28 @@ func NewBaseExecutor(params BaseExecutorParams) *BaseExecutor {
 	}
 }
 
+// Prepare the execution of a shard before it runs, if its executor has anything to prepare.
+func (e BaseExecutor) Prepare(ctx context.Context, execution store.Execution) (err error) {
+	defer func() {
+		if err != nil {
+			e.handleFailure(ctx, execution, err, "Preparing")
+		}
+	}()
+	if e.simulatorConfig.IsBadActor {
+		return nil
+	}
+
+	jobExecutor, err := e.executors.GetExecutor(ctx, execution.Shard.Job.Spec.Engine)
+	if err != nil {
+		return
+	}
+	if preparer, ok := jobExecutor.(executor.Preparer); ok {
+		log.Ctx(ctx).Debug().Msgf("Preparing execution %s", execution.ID)
+		err = preparer.PrepareShard(ctx, execution.Shard)
+	}
+	return
+}
+
 // Run the execution of a shard after it has been accepted, and propose a result to the requester to be verified.
 func (e BaseExecutor) Run(ctx context.Context, execution store.Execution) (err error) {
 	ctx = log.Ctx(ctx).With().
@@ -163,10 +186,19 @@ func (e BaseExecutor) Publish(ctx context.Context, execution store.Execution) (e
 	if err != nil {
 		return
 	}
+	resultsManifest, err := manifest.Build(resultFolder)
+	if err != nil {
+		return
+	}
 	publishedResult, err := jobPublisher.PublishShardResult(ctx, execution.Shard, e.ID, resultFolder)
 	if err != nil {
 		return
 	}
+	// let clients check that what they download is what we published
+	if publishedResult.Metadata == nil {
+		publishedResult.Metadata = make(map[string]string)
+	}
+	publishedResult.Metadata[model.MetadataResultsHash] = resultsManifest.Hash()
 
 	err = e.store.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
 		ExecutionID:   execution.ID,
@@ -250,3 +282,4 @@ func (e BaseExecutor) handleFailure(ctx context.Context, execution store.Executi
 
 // compile-time interface check
 var _ Executor = (*BaseExecutor)(nil)
+var _ Preparer = (*BaseExecutor)(nil)
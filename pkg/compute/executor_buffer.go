@@ -8,18 +8,22 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/compute/capacity"
 	"github.com/filecoin-project/bacalhau/pkg/compute/store"
 	"github.com/filecoin-project/bacalhau/pkg/logger"
+	"github.com/filecoin-project/bacalhau/pkg/system"
 	sync "github.com/lukemarsden/golang-mutex-tracer"
 )
 
 type bufferTask struct {
 	execution  store.Execution
 	enqueuedAt time.Time
+	// traceCtx is the context the execution was enqueued in, whose trace the execution continues
+	traceCtx context.Context
 }
 
-func newBufferTask(execution store.Execution) *bufferTask {
+func newBufferTask(ctx context.Context, execution store.Execution) *bufferTask {
 	return &bufferTask{
 		execution:  execution,
 		enqueuedAt: time.Now(),
+		traceCtx:   ctx,
 	}
 }
 
@@ -30,6 +34,7 @@ type ExecutorBufferParams struct {
 	RunningCapacityTracker     capacity.Tracker
 	EnqueuedCapacityTracker    capacity.Tracker
 	DefaultJobExecutionTimeout time.Duration
+	PrepareTimeout             time.Duration
 	BackoffDuration            time.Duration
 }
 
@@ -49,6 +54,7 @@ type ExecutorBuffer struct {
 	enqueued                   map[string]*bufferTask
 	enqueuedList               []string
 	defaultJobExecutionTimeout time.Duration
+	prepareTimeout             time.Duration
 	backoffDuration            time.Duration
 	backoffUntil               time.Time
 	mu                         sync.Mutex
@@ -65,6 +71,7 @@ func NewExecutorBuffer(params ExecutorBufferParams) *ExecutorBuffer {
 		enqueued:                   make(map[string]*bufferTask),
 		enqueuedList:               make([]string, 0),
 		defaultJobExecutionTimeout: params.DefaultJobExecutionTimeout,
+		prepareTimeout:             params.PrepareTimeout,
 		backoffDuration:            params.BackoffDuration,
 	}
 
@@ -113,7 +120,7 @@ func (s *ExecutorBuffer) Run(ctx context.Context, execution store.Execution) (er
 		return
 	}
 
-	s.enqueued[execution.ID] = newBufferTask(execution)
+	s.enqueued[execution.ID] = newBufferTask(ctx, execution)
 	s.enqueuedList = append(s.enqueuedList, execution.ID)
 	s.deque()
 	return err
@@ -121,10 +128,35 @@ func (s *ExecutorBuffer) Run(ctx context.Context, execution store.Execution) (er
 
 // doRun triggers the execution by the delegate backend.Executor and frees up the capacity when the execution is done.
 func (s *ExecutorBuffer) doRun(ctx context.Context, task *bufferTask) {
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.runningCapacity.Remove(ctx, task.execution.ResourceUsage)
+		delete(s.running, task.execution.ID)
+		s.deque()
+	}()
+
 	timeout := task.execution.Shard.Job.Spec.GetTimeout()
 	if timeout == 0 {
 		timeout = s.defaultJobExecutionTimeout
 	}
+
+	// preparation, such as pulling images, gets its own timeout so it doesn't eat into the job's,
+	// falling back to the job's timeout if none is configured.
+	// failures are already reported to the callback by the delegate.
+	if preparer, ok := s.delegateService.(Preparer); ok {
+		prepareTimeout := s.prepareTimeout
+		if prepareTimeout == 0 {
+			prepareTimeout = timeout
+		}
+		prepareCtx, cancelPrepare := context.WithTimeout(ctx, prepareTimeout)
+		err := preparer.Prepare(prepareCtx, task.execution)
+		cancelPrepare()
+		if err != nil {
+			return
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
@@ -147,12 +179,6 @@ func (s *ExecutorBuffer) doRun(ctx context.Context, task *bufferTask) {
 		// no need to check for run errors as they are already handled by the delegate backend.Executor and
 		// to the callback.
 	}
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.runningCapacity.Remove(ctx, task.execution.ResourceUsage)
-	delete(s.running, task.execution.ID)
-	s.deque()
 }
 
 // deque tries to run the next execution in the queue if there is enough capacity.
@@ -177,7 +203,7 @@ func (s *ExecutorBuffer) deque() {
 			s.enqueuedCapacity.Remove(ctx, task.execution.ResourceUsage)
 			delete(s.enqueued, executionID)
 			s.running[executionID] = task
-			go s.doRun(logger.ContextWithNodeIDLogger(context.Background(), s.ID), task)
+			go s.doRun(system.ContextWithTraceOf(logger.ContextWithNodeIDLogger(context.Background(), s.ID), task.traceCtx), task)
 		} else {
 			remainingEnqueuedList = append(remainingEnqueuedList, executionID)
 		}
@@ -189,7 +215,7 @@ func (s *ExecutorBuffer) deque() {
 func (s *ExecutorBuffer) Publish(ctx context.Context, execution store.Execution) error {
 	// TODO: Enqueue publish tasks
 	go func() {
-		_ = s.delegateService.Publish(logger.ContextWithNodeIDLogger(context.Background(), s.ID), execution)
+		_ = s.delegateService.Publish(system.ContextWithTraceOf(logger.ContextWithNodeIDLogger(context.Background(), s.ID), ctx), execution)
 	}()
 	return nil
 }
@@ -197,7 +223,7 @@ func (s *ExecutorBuffer) Publish(ctx context.Context, execution store.Execution)
 func (s *ExecutorBuffer) Cancel(ctx context.Context, execution store.Execution) error {
 	// TODO: Enqueue cancel tasks
 	go func() {
-		_ = s.delegateService.Cancel(logger.ContextWithNodeIDLogger(context.Background(), s.ID), execution)
+		_ = s.delegateService.Cancel(system.ContextWithTraceOf(logger.ContextWithNodeIDLogger(context.Background(), s.ID), ctx), execution)
 	}()
 	return nil
 }
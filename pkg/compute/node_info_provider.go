@@ -6,6 +6,8 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/compute/capacity"
 	"github.com/filecoin-project/bacalhau/pkg/executor"
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/storage/cache"
+	"github.com/filecoin-project/bacalhau/pkg/version"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
@@ -17,8 +19,14 @@ type NodeInfoProviderParams struct {
 	CapacityTracker    capacity.Tracker
 	ExecutorBuffer     *ExecutorBuffer
 	MaxJobRequirements model.ResourceUsageData
+	// InputCache is optional and advertises the inputs the node has cached
+	InputCache *cache.Cache
 }
 
+// maxCachedInputs limits how many cached inputs are advertised so node info
+// stays small enough to publish frequently
+const maxCachedInputs = 256
+
 type NodeInfoProvider struct {
 	h                  host.Host
 	labels             map[string]string
@@ -26,6 +34,8 @@ type NodeInfoProvider struct {
 	capacityTracker    capacity.Tracker
 	executorBuffer     *ExecutorBuffer
 	maxJobRequirements model.ResourceUsageData
+	inputCache         *cache.Cache
+	versions           model.NodeVersions
 }
 
 func NewNodeInfoProvider(params NodeInfoProviderParams) *NodeInfoProvider {
@@ -36,6 +46,12 @@ func NewNodeInfoProvider(params NodeInfoProviderParams) *NodeInfoProvider {
 		capacityTracker:    params.CapacityTracker,
 		executorBuffer:     params.ExecutorBuffer,
 		maxJobRequirements: params.MaxJobRequirements,
+		inputCache:         params.InputCache,
+		versions: model.NodeVersions{
+			BuildVersion:    *version.Get(),
+			ProtocolVersion: model.ProtocolVersion,
+			APIVersions:     model.SupportedAPIVersions(),
+		},
 	}
 }
 
@@ -47,6 +63,11 @@ func (n *NodeInfoProvider) GetNodeInfo(ctx context.Context) model.NodeInfo {
 		}
 	}
 
+	var cachedInputs []string
+	if n.inputCache != nil {
+		cachedInputs = n.inputCache.Keys(maxCachedInputs)
+	}
+
 	return model.NodeInfo{
 		PeerInfo: peer.AddrInfo{
 			ID:    n.h.ID(),
@@ -61,7 +82,9 @@ func (n *NodeInfoProvider) GetNodeInfo(ctx context.Context) model.NodeInfo {
 			MaxJobRequirements: n.maxJobRequirements,
 			RunningExecutions:  len(n.executorBuffer.RunningExecutions()),
 			EnqueuedExecutions: len(n.executorBuffer.EnqueuedExecutions()),
+			CachedInputs:       cachedInputs,
 		},
+		Versions: n.versions,
 	}
 }
 
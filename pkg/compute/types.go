@@ -2,6 +2,7 @@ package compute
 
 import (
 	"context"
+	"io"
 
 	"github.com/filecoin-project/bacalhau/pkg/compute/store"
 	"github.com/filecoin-project/bacalhau/pkg/model"
@@ -38,6 +39,20 @@ type Executor interface {
 	Cancel(ctx context.Context, execution store.Execution) error
 }
 
+// Preparer is implemented by Executors that can prepare an execution before running it, e.g. by pulling its image.
+// Preparation is not counted towards the job's timeout.
+type Preparer interface {
+	Prepare(ctx context.Context, execution store.Execution) error
+}
+
+// ResultsProvider serves the results that a compute node kept after publishing them, so that clients can fetch them
+// from the node itself rather than from a storage network.
+type ResultsProvider interface {
+	// FetchResults streams the results of a shard as a tar archive, if the source of the request is the requester of
+	// the job.
+	FetchResults(context.Context, FetchResultsRequest) (io.ReadCloser, error)
+}
+
 // Callback Callbacks are used to notify the caller of the result of a job execution.
 type Callback interface {
 	OnRunComplete(ctx context.Context, result RunResult)
@@ -53,6 +68,14 @@ type Callback interface {
 type RoutingMetadata struct {
 	SourcePeerID string
 	TargetPeerID string
+	// TraceContext carries the trace the request or callback is part of, as W3C trace context and baggage
+	// headers, so that the receiving node can continue it.
+	TraceContext map[string]string `json:",omitempty"`
+}
+
+// Routing returns the routing metadata of the requests and callback results that embed it.
+func (m *RoutingMetadata) Routing() *RoutingMetadata {
+	return m
 }
 
 type ExecutionMetadata struct {
@@ -76,6 +99,9 @@ type AskForBidRequest struct {
 	// ShardIndexes specifies the shard indexes to be executed.
 	// This enables the requester to ask for bids for a subset of the shards of a job.
 	ShardIndexes []int
+	// HasRegistryAuth is true if the job brings a registry token, which is
+	// only sent with BidAcceptedRequest.
+	HasRegistryAuth bool
 }
 
 type AskForBidResponse struct {
@@ -86,6 +112,8 @@ type AskForBidShardResponse struct {
 	ExecutionMetadata
 	Accepted bool
 	Reason   string
+	// Price the node asks for running the shard, quoted from its pricing policy
+	Price float64
 }
 
 type BidAcceptedRequest struct {
@@ -93,6 +121,10 @@ type BidAcceptedRequest struct {
 	ExecutionID   string
 	Accepted      bool
 	Justification string
+	// Price agreed for the execution
+	Price float64
+	// RegistryAuth is the job's registry token, encrypted for this node.
+	RegistryAuth []byte `json:",omitempty"`
 }
 
 type BidAcceptedResponse struct {
@@ -138,6 +170,17 @@ type CancelExecutionResponse struct {
 	ExecutionMetadata
 }
 
+type FetchResultsRequest struct {
+	RoutingMetadata
+	JobID      string
+	ShardIndex int
+}
+
+// FetchResultsResponse is sent before the archive of the results, which only follows if Err is empty.
+type FetchResultsResponse struct {
+	Err string
+}
+
 ///////////////////////////////////
 // Callback result models
 ///////////////////////////////////
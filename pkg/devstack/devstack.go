@@ -21,6 +21,8 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/node"
 	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/transport/chaos"
+	"github.com/filecoin-project/bacalhau/pkg/util/generic"
 	"github.com/multiformats/go-multiaddr"
 	"github.com/phayes/freeport"
 	"github.com/rs/zerolog/log"
@@ -39,6 +41,8 @@ type DevStackOptions struct {
 	EstuaryAPIKey              string
 	SimulatorAddr              string // if this is set, we will use the simulator transport
 	SimulatorMode              bool   // if this is set, the first node will be a simulator node and will use the simulator transport
+	// Topology declares the nodes of the cluster, and replaces the node and bad actor counts when set
+	Topology *Topology
 }
 type DevStack struct {
 	Nodes          []*node.Node
@@ -137,16 +141,24 @@ func NewDevStack(
 		}
 	}
 
-	totalNodeCount := options.NumberOfHybridNodes + options.NumberOfRequesterOnlyNodes + options.NumberOfComputeOnlyNodes
-	requesterNodeCount := options.NumberOfHybridNodes + options.NumberOfRequesterOnlyNodes
-	computeNodeCount := options.NumberOfHybridNodes + options.NumberOfComputeOnlyNodes
+	var specs []nodeSpec
+	if options.Topology != nil {
+		if err = options.Topology.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid topology: %w", err)
+		}
+		specs = options.Topology.nodeSpecs()
+	} else {
+		specs = options.nodeSpecs()
+	}
 
-	if requesterNodeCount == 0 {
+	if len(specs) == 0 || !specs[0].isRequester {
 		return nil, fmt.Errorf("at least one requester node is required")
 	}
-	for i := 0; i < totalNodeCount; i++ {
-		isRequesterNode := i < requesterNodeCount
-		isComputeNode := (totalNodeCount - i) <= computeNodeCount
+	// names of the nodes by ID, so that faults can be declared between nodes before their IDs are known
+	nodeNames := generic.SyncMapFromMap(map[string]string{})
+	for i, spec := range specs {
+		isRequesterNode := spec.isRequester
+		isComputeNode := spec.isCompute
 		log.Debug().Msgf(`Creating Node #%d as {RequesterNode: %t, ComputeNode: %t}`, i+1, isRequesterNode, isComputeNode)
 
 		// -------------------------------------
@@ -249,17 +261,47 @@ func NewDevStack(
 		// Create and Run Node
 		//////////////////////////////////////
 
+		nodeComputeConfig := computeConfig
+		nodeInjector := injector
+		labels := map[string]string{}
+		if spec.group != nil {
+			if spec.group.ComputeConfig != nil {
+				nodeComputeConfig, err = spec.group.ComputeConfig.NewComputeConfig()
+				if err != nil {
+					return nil, err
+				}
+			}
+			nodeInjector = groupInjector(injector, *spec.group)
+			for key, value := range spec.group.Labels {
+				labels[key] = value
+			}
+		}
+		labels["name"] = spec.name
+		labels["id"] = libp2pHost.ID().String()
+		labels["env"] = "devstack"
+		nodeNames.Put(libp2pHost.ID().String(), spec.name)
+
+		var faultInjector *chaos.Injector
+		if spec.group != nil && spec.group.Faults != nil {
+			faultInjector = chaos.NewInjector(chaos.InjectorParams{
+				NodeID: libp2pHost.ID().String(),
+				Config: *spec.group.Faults,
+				PeerName: func(peerID string) string {
+					name, _ := nodeNames.Get(peerID)
+					return name
+				},
+			})
+		}
+
 		// here is where we can parse string based CLI options
 		// into more meaningful model.SimulatorConfig values
-		isBadComputeActor := (options.NumberOfBadComputeActors > 0) && (i >= computeNodeCount-options.NumberOfBadComputeActors)
-		isBadRequesterActor := (options.NumberOfBadRequesterActors > 0) && (i >= requesterNodeCount-options.NumberOfBadRequesterActors)
-
-		if isBadComputeActor {
-			computeConfig.SimulatorConfig.IsBadActor = isBadComputeActor
+		nodeRequesterConfig := requesterNodeConfig
+		if spec.isBadComputeActor {
+			nodeComputeConfig.SimulatorConfig.IsBadActor = true
 		}
 
-		if isBadRequesterActor {
-			requesterNodeConfig.SimulatorConfig.IsBadActor = isBadRequesterActor
+		if spec.isBadRequesterActor {
+			nodeRequesterConfig.SimulatorConfig.IsBadActor = true
 		}
 
 		// If we are running in a simulator mode, and didn't pass in a node ID, then the first node will be the simulator node
@@ -282,16 +324,13 @@ func NewDevStack(
 			HostAddress:          "0.0.0.0",
 			APIPort:              apiPort,
 			MetricsPort:          metricsPort,
-			ComputeConfig:        computeConfig,
-			RequesterNodeConfig:  requesterNodeConfig,
+			ComputeConfig:        nodeComputeConfig,
+			RequesterNodeConfig:  nodeRequesterConfig,
 			SimulatorNodeID:      simulatorNodeID,
 			IsComputeNode:        isComputeNode,
 			IsRequesterNode:      isRequesterNode,
-			Labels: map[string]string{
-				"name": fmt.Sprintf("node-%d", i),
-				"id":   libp2pHost.ID().String(),
-				"env":  "devstack",
-			},
+			Labels:               labels,
+			FaultInjector:        faultInjector,
 		}
 
 		if lotus != nil {
@@ -316,7 +355,7 @@ func NewDevStack(
 		}
 
 		var n *node.Node
-		n, err = node.NewNode(ctx, nodeConfig, injector)
+		n, err = node.NewNode(ctx, nodeConfig, nodeInjector)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,222 @@
+package devstack
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute/capacity"
+	"github.com/filecoin-project/bacalhau/pkg/docker"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/node"
+	"github.com/filecoin-project/bacalhau/pkg/transport/chaos"
+	"sigs.k8s.io/yaml"
+)
+
+// Topology declares the nodes of a devstack as groups of identically configured nodes, so that clusters of nodes
+// with different roles, labels, capacities, engines and job selection policies can be started locally and in tests.
+type Topology struct {
+	NodeGroups []NodeGroup `json:"NodeGroups"`
+}
+
+// NodeGroup is a set of nodes started with the same configuration.
+type NodeGroup struct {
+	// Name is used to name the nodes of the group, e.g. gpu-0, gpu-1. Nodes are named node-<index> if empty.
+	Name string `json:"Name,omitempty"`
+	// Count is the number of nodes in the group, 1 if zero.
+	Count     int  `json:"Count,omitempty"`
+	Requester bool `json:"Requester,omitempty"`
+	Compute   bool `json:"Compute,omitempty"`
+	// Labels are added to the labels devstack gives every node.
+	Labels map[string]string `json:"Labels,omitempty"`
+	// Engines, StorageSources and Publishers restrict the ones compute nodes support. All are supported if empty.
+	Engines        []model.Engine            `json:"Engines,omitempty"`
+	StorageSources []model.StorageSourceType `json:"StorageSources,omitempty"`
+	Publishers     []model.Publisher         `json:"Publishers,omitempty"`
+	// ComputeConfig replaces the compute config the devstack was started with for nodes of the group.
+	ComputeConfig     *ComputeConfigParams `json:"ComputeConfig,omitempty"`
+	BadComputeActor   bool                 `json:"BadComputeActor,omitempty"`
+	BadRequesterActor bool                 `json:"BadRequesterActor,omitempty"`
+	// Faults are injected into the messages nodes of the group exchange with other nodes, which are listed by name.
+	Faults *chaos.Config `json:"Faults,omitempty"`
+}
+
+// ComputeConfigParams are the node.ComputeConfigParams that can be declared in a topology. Resource limits are
+// written like job resources, sizes like "1GB" and durations like "5m".
+type ComputeConfigParams struct {
+	TotalResourceLimits          model.ResourceUsageConfig `json:"TotalResourceLimits,omitempty"`
+	QueueResourceLimits          model.ResourceUsageConfig `json:"QueueResourceLimits,omitempty"`
+	JobResourceLimits            model.ResourceUsageConfig `json:"JobResourceLimits,omitempty"`
+	DefaultJobResourceLimits     model.ResourceUsageConfig `json:"DefaultJobResourceLimits,omitempty"`
+	IgnorePhysicalResourceLimits bool                      `json:"IgnorePhysicalResourceLimits,omitempty"`
+
+	JobNegotiationTimeout      model.Duration `json:"JobNegotiationTimeout,omitempty"`
+	MinJobExecutionTimeout     model.Duration `json:"MinJobExecutionTimeout,omitempty"`
+	MaxJobExecutionTimeout     model.Duration `json:"MaxJobExecutionTimeout,omitempty"`
+	DefaultJobExecutionTimeout model.Duration `json:"DefaultJobExecutionTimeout,omitempty"`
+
+	JobSelectionPolicy model.JobSelectionPolicy `json:"JobSelectionPolicy,omitempty"`
+	PricingPolicy      model.PricingPolicy      `json:"PricingPolicy,omitempty"`
+
+	InputCacheSize   string         `json:"InputCacheSize,omitempty"`
+	ResultsRetention model.Duration `json:"ResultsRetention,omitempty"`
+
+	DockerPullPolicy     string   `json:"DockerPullPolicy,omitempty"`
+	DockerPrePullImages  []string `json:"DockerPrePullImages,omitempty"`
+	DockerSecurityPolicy string   `json:"DockerSecurityPolicy,omitempty"`
+}
+
+// LoadTopology reads a topology from a YAML or JSON file.
+func LoadTopology(path string) (*Topology, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read topology: %w", err)
+	}
+	var topology Topology
+	if err = yaml.UnmarshalStrict(data, &topology); err != nil {
+		return nil, fmt.Errorf("invalid topology %s: %w", path, err)
+	}
+	if err = topology.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid topology %s: %w", path, err)
+	}
+	return &topology, nil
+}
+
+// Validate checks that the topology describes a network that can be started.
+func (t Topology) Validate() error {
+	hasRequester := false
+	for i, group := range t.NodeGroups {
+		if group.Count < 0 {
+			return fmt.Errorf("node group %d: count must not be negative", i)
+		}
+		if !group.Requester && !group.Compute {
+			return fmt.Errorf("node group %d: nodes must be requester or compute nodes", i)
+		}
+		if group.BadComputeActor && !group.Compute {
+			return fmt.Errorf("node group %d: only compute nodes can be bad compute actors", i)
+		}
+		if group.BadRequesterActor && !group.Requester {
+			return fmt.Errorf("node group %d: only requester nodes can be bad requester actors", i)
+		}
+		if group.ComputeConfig != nil {
+			if _, err := group.ComputeConfig.toParams(); err != nil {
+				return fmt.Errorf("node group %d: %w", i, err)
+			}
+		}
+		if group.Faults != nil {
+			if err := group.Faults.Validate(); err != nil {
+				return fmt.Errorf("node group %d: faults: %w", i, err)
+			}
+		}
+		hasRequester = hasRequester || group.Requester
+	}
+	if !hasRequester {
+		return fmt.Errorf("at least one requester node is required")
+	}
+	return nil
+}
+
+// NewComputeConfig returns the compute config of nodes declared with these params.
+func (p ComputeConfigParams) NewComputeConfig() (node.ComputeConfig, error) {
+	params, err := p.toParams()
+	if err != nil {
+		return node.ComputeConfig{}, err
+	}
+	return node.NewComputeConfigWith(params), nil
+}
+
+func (p ComputeConfigParams) toParams() (node.ComputeConfigParams, error) {
+	pullPolicy, err := docker.ParsePullPolicy(p.DockerPullPolicy)
+	if err != nil {
+		return node.ComputeConfigParams{}, err
+	}
+	securityPolicy, err := docker.LoadSecurityPolicy(p.DockerSecurityPolicy)
+	if err != nil {
+		return node.ComputeConfigParams{}, err
+	}
+	return node.ComputeConfigParams{
+		TotalResourceLimits:          capacity.ParseResourceUsageConfig(p.TotalResourceLimits),
+		QueueResourceLimits:          capacity.ParseResourceUsageConfig(p.QueueResourceLimits),
+		JobResourceLimits:            capacity.ParseResourceUsageConfig(p.JobResourceLimits),
+		DefaultJobResourceLimits:     capacity.ParseResourceUsageConfig(p.DefaultJobResourceLimits),
+		IgnorePhysicalResourceLimits: p.IgnorePhysicalResourceLimits,
+		JobNegotiationTimeout:        time.Duration(p.JobNegotiationTimeout),
+		MinJobExecutionTimeout:       time.Duration(p.MinJobExecutionTimeout),
+		MaxJobExecutionTimeout:       time.Duration(p.MaxJobExecutionTimeout),
+		DefaultJobExecutionTimeout:   time.Duration(p.DefaultJobExecutionTimeout),
+		JobSelectionPolicy:           p.JobSelectionPolicy,
+		PricingPolicy:                p.PricingPolicy,
+		InputCacheSize:               capacity.ConvertBytesString(p.InputCacheSize),
+		ResultsRetention:             time.Duration(p.ResultsRetention),
+		DockerPullPolicy:             pullPolicy,
+		DockerPrePullImages:          p.DockerPrePullImages,
+		DockerSecurityPolicy:         securityPolicy,
+	}, nil
+}
+
+// nodeSpec is the role and configuration of a single devstack node.
+type nodeSpec struct {
+	name                string
+	isRequester         bool
+	isCompute           bool
+	isBadComputeActor   bool
+	isBadRequesterActor bool
+	// group is the group the node was declared in, or nil if the node was declared by DevStackOptions node counts
+	group *NodeGroup
+}
+
+// nodeSpecs returns the nodes of the topology, with requester nodes first so that the first node of the devstack,
+// which the other nodes connect to, can accept jobs.
+func (t Topology) nodeSpecs() []nodeSpec {
+	var specs []nodeSpec
+	for i := range t.NodeGroups {
+		group := &t.NodeGroups[i]
+		count := group.Count
+		if count == 0 {
+			count = 1
+		}
+		for j := 0; j < count; j++ {
+			spec := nodeSpec{
+				isRequester:         group.Requester,
+				isCompute:           group.Compute,
+				isBadComputeActor:   group.BadComputeActor,
+				isBadRequesterActor: group.BadRequesterActor,
+				group:               group,
+			}
+			if group.Name != "" {
+				spec.name = fmt.Sprintf("%s-%d", group.Name, j)
+			}
+			specs = append(specs, spec)
+		}
+	}
+	sort.SliceStable(specs, func(i, j int) bool {
+		return specs[i].isRequester && !specs[j].isRequester
+	})
+	for i := range specs {
+		if specs[i].name == "" {
+			specs[i].name = fmt.Sprintf("node-%d", i)
+		}
+	}
+	return specs
+}
+
+// nodeSpecs returns the nodes declared by the node counts of the options: requester only nodes, then hybrid nodes,
+// then compute only nodes.
+func (options DevStackOptions) nodeSpecs() []nodeSpec {
+	totalNodeCount := options.NumberOfHybridNodes + options.NumberOfRequesterOnlyNodes + options.NumberOfComputeOnlyNodes
+	requesterNodeCount := options.NumberOfHybridNodes + options.NumberOfRequesterOnlyNodes
+	computeNodeCount := options.NumberOfHybridNodes + options.NumberOfComputeOnlyNodes
+
+	specs := make([]nodeSpec, 0, totalNodeCount)
+	for i := 0; i < totalNodeCount; i++ {
+		specs = append(specs, nodeSpec{
+			name:                fmt.Sprintf("node-%d", i),
+			isRequester:         i < requesterNodeCount,
+			isCompute:           (totalNodeCount - i) <= computeNodeCount,
+			isBadComputeActor:   (options.NumberOfBadComputeActors > 0) && (i >= computeNodeCount-options.NumberOfBadComputeActors),
+			isBadRequesterActor: (options.NumberOfBadRequesterActors > 0) && (i >= requesterNodeCount-options.NumberOfBadRequesterActors),
+		})
+	}
+	return specs
+}
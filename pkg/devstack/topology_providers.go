@@ -0,0 +1,98 @@
+package devstack
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/filecoin-project/bacalhau/pkg/executor"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/node"
+	"github.com/filecoin-project/bacalhau/pkg/publisher"
+	"github.com/filecoin-project/bacalhau/pkg/storage"
+	"golang.org/x/exp/slices"
+)
+
+// groupInjector restricts the executors, storage sources and publishers created by the injector to the ones the
+// node group supports.
+func groupInjector(injector node.NodeDependencyInjector, group NodeGroup) node.NodeDependencyInjector {
+	if len(group.Engines) > 0 {
+		factory := injector.ExecutorsFactory
+		injector.ExecutorsFactory = node.ExecutorsFactoryFunc(
+			func(ctx context.Context, nodeConfig node.NodeConfig) (executor.ExecutorProvider, error) {
+				provider, err := factory.Get(ctx, nodeConfig)
+				if err != nil {
+					return nil, err
+				}
+				return &groupExecutorProvider{ExecutorProvider: provider, engines: group.Engines}, nil
+			})
+	}
+	if len(group.StorageSources) > 0 {
+		factory := injector.StorageProvidersFactory
+		injector.StorageProvidersFactory = node.StorageProvidersFactoryFunc(
+			func(ctx context.Context, nodeConfig node.NodeConfig) (storage.StorageProvider, error) {
+				provider, err := factory.Get(ctx, nodeConfig)
+				if err != nil {
+					return nil, err
+				}
+				return &groupStorageProvider{StorageProvider: provider, sources: group.StorageSources}, nil
+			})
+	}
+	if len(group.Publishers) > 0 {
+		factory := injector.PublishersFactory
+		injector.PublishersFactory = node.PublishersFactoryFunc(
+			func(ctx context.Context, nodeConfig node.NodeConfig) (publisher.PublisherProvider, error) {
+				provider, err := factory.Get(ctx, nodeConfig)
+				if err != nil {
+					return nil, err
+				}
+				return &groupPublisherProvider{PublisherProvider: provider, publishers: group.Publishers}, nil
+			})
+	}
+	return injector
+}
+
+type groupExecutorProvider struct {
+	executor.ExecutorProvider
+	engines []model.Engine
+}
+
+func (p *groupExecutorProvider) GetExecutor(ctx context.Context, engineType model.Engine) (executor.Executor, error) {
+	if !slices.Contains(p.engines, engineType) {
+		return nil, fmt.Errorf("engine %s is not enabled on this node", engineType)
+	}
+	return p.ExecutorProvider.GetExecutor(ctx, engineType)
+}
+
+func (p *groupExecutorProvider) HasExecutor(ctx context.Context, engineType model.Engine) bool {
+	_, err := p.GetExecutor(ctx, engineType)
+	return err == nil
+}
+
+type groupStorageProvider struct {
+	storage.StorageProvider
+	sources []model.StorageSourceType
+}
+
+func (p *groupStorageProvider) GetStorage(ctx context.Context, storageType model.StorageSourceType) (storage.Storage, error) {
+	if !slices.Contains(p.sources, storageType) {
+		return nil, fmt.Errorf("storage source %s is not enabled on this node", storageType)
+	}
+	return p.StorageProvider.GetStorage(ctx, storageType)
+}
+
+type groupPublisherProvider struct {
+	publisher.PublisherProvider
+	publishers []model.Publisher
+}
+
+func (p *groupPublisherProvider) GetPublisher(ctx context.Context, publisherType model.Publisher) (publisher.Publisher, error) {
+	if !slices.Contains(p.publishers, publisherType) {
+		return nil, fmt.Errorf("publisher %s is not enabled on this node", publisherType)
+	}
+	return p.PublisherProvider.GetPublisher(ctx, publisherType)
+}
+
+// Compile-time interface checks:
+var _ executor.ExecutorProvider = (*groupExecutorProvider)(nil)
+var _ storage.StorageProvider = (*groupStorageProvider)(nil)
+var _ publisher.PublisherProvider = (*groupPublisherProvider)(nil)
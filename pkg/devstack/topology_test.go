@@ -0,0 +1,109 @@
+//go:build unit || !integration
+
+package devstack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/executor"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTopology(t *testing.T) {
+	topology, err := LoadTopology("../../testdata/devstack-topology.yaml")
+	require.NoError(t, err)
+	require.Len(t, topology.NodeGroups, 3)
+
+	faults := topology.NodeGroups[0].Faults
+	require.Equal(t, []string{"gpu-0"}, faults.Rules[0].Peers)
+	require.Equal(t, model.Duration(100*time.Millisecond), faults.Rules[0].Jitter)
+	require.Equal(t, model.Duration(2*time.Minute), faults.Partitions[0].End)
+
+	gpu := topology.NodeGroups[1]
+	require.Equal(t, map[string]string{"gpu": "true"}, gpu.Labels)
+	require.Equal(t, []model.Engine{model.EngineDocker}, gpu.Engines)
+	require.Equal(t, model.Duration(time.Hour), gpu.ComputeConfig.MaxJobExecutionTimeout)
+	require.True(t, gpu.ComputeConfig.JobSelectionPolicy.AcceptNetworkedJobs)
+
+	params, err := gpu.ComputeConfig.toParams()
+	require.NoError(t, err)
+	require.Equal(t, 8.0, params.TotalResourceLimits.CPU)
+	require.Equal(t, uint64(1), params.TotalResourceLimits.GPU)
+	require.Equal(t, time.Hour, params.MaxJobExecutionTimeout)
+
+	specs := topology.nodeSpecs()
+	require.Len(t, specs, 4)
+	names := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		names = append(names, spec.name)
+	}
+	require.Equal(t, []string{"requester-0", "gpu-0", "gpu-1", "wasm-0"}, names)
+	require.True(t, specs[0].isRequester)
+	require.False(t, specs[0].isCompute)
+	require.True(t, specs[3].isBadComputeActor)
+}
+
+func TestTopologyRequestersFirst(t *testing.T) {
+	topology := Topology{NodeGroups: []NodeGroup{
+		{Compute: true, Count: 2},
+		{Requester: true, Compute: true},
+	}}
+	require.NoError(t, topology.Validate())
+
+	specs := topology.nodeSpecs()
+	require.Len(t, specs, 3)
+	require.True(t, specs[0].isRequester)
+	require.Equal(t, "node-0", specs[0].name)
+	require.False(t, specs[1].isRequester)
+}
+
+func TestInvalidTopology(t *testing.T) {
+	for name, topology := range map[string]string{
+		"no requester":      "NodeGroups: [{Compute: true}]",
+		"no role":           "NodeGroups: [{Requester: true}, {Count: 2}]",
+		"bad actor role":    "NodeGroups: [{Requester: true, BadComputeActor: true}]",
+		"unknown field":     "NodeGroups: [{Requester: true, Replicas: 2}]",
+		"unknown engine":    "NodeGroups: [{Requester: true, Compute: true, Engines: [Kubernetes]}]",
+		"numeric duration":  "NodeGroups: [{Requester: true, Compute: true, ComputeConfig: {ResultsRetention: 60}}]",
+		"pull policy":       "NodeGroups: [{Requester: true, Compute: true, ComputeConfig: {DockerPullPolicy: sometimes}}]",
+		"fault probability": "NodeGroups: [{Requester: true, Faults: {Rules: [{LossProbability: 2}]}}]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "topology.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(topology), 0600))
+			_, err := LoadTopology(path)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestOptionsNodeSpecs(t *testing.T) {
+	specs := DevStackOptions{
+		NumberOfRequesterOnlyNodes: 1,
+		NumberOfHybridNodes:        1,
+		NumberOfComputeOnlyNodes:   2,
+		NumberOfBadRequesterActors: 1,
+	}.nodeSpecs()
+	require.Len(t, specs, 4)
+	require.Equal(t, nodeSpec{name: "node-0", isRequester: true}, specs[0])
+	require.Equal(t, nodeSpec{name: "node-1", isRequester: true, isCompute: true, isBadRequesterActor: true}, specs[1])
+	require.Equal(t, nodeSpec{name: "node-3", isCompute: true, isBadRequesterActor: true}, specs[3])
+}
+
+func TestGroupExecutorProvider(t *testing.T) {
+	ctx := context.Background()
+	provider := &groupExecutorProvider{
+		ExecutorProvider: executor.NewTypeExecutorProvider(map[model.Engine]executor.Executor{}),
+		engines:          []model.Engine{model.EngineWasm},
+	}
+	_, err := provider.GetExecutor(ctx, model.EngineDocker)
+	require.ErrorContains(t, err, "not enabled")
+	_, err = provider.GetExecutor(ctx, model.EngineWasm)
+	require.ErrorContains(t, err, "no matching executor")
+	require.False(t, provider.HasExecutor(ctx, model.EngineWasm))
+}
@@ -0,0 +1,262 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+)
+
+const (
+	dockerHubRegistry  = "docker.io"
+	dockerHubServerURL = "https://index.docker.io/v1/"
+
+	// the username credential helpers return for identity tokens
+	identityTokenUsername = "<token>"
+)
+
+// RegistryCredentials looks up credentials for container registries in a
+// docker config.json file, including any credential helpers it configures,
+// in the same way as the docker CLI does.
+//
+// The file is read on every lookup so that credentials can be rotated
+// without restarting the node.
+type RegistryCredentials struct {
+	configPath string
+}
+
+// NewRegistryCredentials returns credentials read from the docker config.json
+// at configPath, or from docker's default location if it is empty.
+func NewRegistryCredentials(configPath string) *RegistryCredentials {
+	if configPath == "" {
+		configDir := os.Getenv("DOCKER_CONFIG")
+		if configDir == "" {
+			home, err := os.UserHomeDir()
+			if err == nil {
+				configDir = filepath.Join(home, ".docker")
+			}
+		}
+		configPath = filepath.Join(configDir, "config.json")
+	}
+	return &RegistryCredentials{configPath: configPath}
+}
+
+type dockerConfigFile struct {
+	Auths       map[string]dockerConfigAuth `json:"auths"`
+	CredsStore  string                      `json:"credsStore,omitempty"`
+	CredHelpers map[string]string           `json:"credHelpers,omitempty"`
+}
+
+type dockerConfigAuth struct {
+	Auth          string `json:"auth,omitempty"`
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+	RegistryToken string `json:"registrytoken,omitempty"`
+}
+
+type credentialHelperResponse struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// Get returns the credentials configured for the registry, and false if
+// there are none.
+func (c *RegistryCredentials) Get(ctx context.Context, registry string) (types.AuthConfig, bool, error) {
+	registry = normalizeRegistry(registry)
+	config, err := c.load()
+	if err != nil || config == nil {
+		return types.AuthConfig{}, false, err
+	}
+
+	for key, helper := range config.CredHelpers {
+		if normalizeRegistry(key) == registry {
+			return getFromCredentialHelper(ctx, helper, registry)
+		}
+	}
+
+	for key, auth := range config.Auths {
+		if normalizeRegistry(key) != registry {
+			continue
+		}
+		authConfig, err := auth.toAuthConfig(registryServerAddress(registry))
+		if err != nil {
+			return types.AuthConfig{}, false, fmt.Errorf("invalid credentials for %s in %s: %w", registry, c.configPath, err)
+		}
+		// entries may only record that a credential store holds the secret
+		if authConfig != (types.AuthConfig{ServerAddress: authConfig.ServerAddress}) {
+			return authConfig, true, nil
+		}
+	}
+
+	if config.CredsStore != "" {
+		return getFromCredentialHelper(ctx, config.CredsStore, registry)
+	}
+	return types.AuthConfig{}, false, nil
+}
+
+// Has returns true if there are usable credentials for the registry.
+func (c *RegistryCredentials) Has(ctx context.Context, registry string) bool {
+	_, ok, err := c.Get(ctx, registry)
+	return err == nil && ok
+}
+
+func (c *RegistryCredentials) load() (*dockerConfigFile, error) {
+	data, err := os.ReadFile(c.configPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	config := &dockerConfigFile{}
+	if err = json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid docker config %s: %w", c.configPath, err)
+	}
+	return config, nil
+}
+
+func (a dockerConfigAuth) toAuthConfig(serverAddress string) (types.AuthConfig, error) {
+	authConfig := types.AuthConfig{
+		Username:      a.Username,
+		Password:      a.Password,
+		IdentityToken: a.IdentityToken,
+		RegistryToken: a.RegistryToken,
+		ServerAddress: serverAddress,
+	}
+	if a.Auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(a.Auth)
+		if err != nil {
+			return types.AuthConfig{}, err
+		}
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return types.AuthConfig{}, fmt.Errorf("auth should be base64 encoded username:password")
+		}
+		authConfig.Username, authConfig.Password = username, password
+	}
+	return authConfig, nil
+}
+
+func getFromCredentialHelper(ctx context.Context, helper, registry string) (types.AuthConfig, bool, error) {
+	serverAddress := registryServerAddress(registry)
+	//nolint:gosec // the helper name comes from the node operator's docker config
+	cmd := exec.CommandContext(ctx, "docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(serverAddress)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// helpers report missing credentials on stdout and exit with an error
+		if strings.Contains(strings.ToLower(stdout.String()), "credentials not found") {
+			return types.AuthConfig{}, false, nil
+		}
+		return types.AuthConfig{}, false,
+			fmt.Errorf("credential helper %s failed for %s: %w: %s", helper, registry, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var response credentialHelperResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return types.AuthConfig{}, false, fmt.Errorf("invalid response from credential helper %s: %w", helper, err)
+	}
+	authConfig := types.AuthConfig{ServerAddress: serverAddress}
+	if response.Username == identityTokenUsername {
+		authConfig.IdentityToken = response.Secret
+	} else {
+		authConfig.Username = response.Username
+		authConfig.Password = response.Secret
+	}
+	return authConfig, true, nil
+}
+
+// RegistryForImage returns the registry an image is pulled from, e.g.
+// docker.io for "ubuntu" or ghcr.io for "ghcr.io/org/image:tag".
+func RegistryForImage(image string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", fmt.Errorf("invalid image %q: %w", image, err)
+	}
+	return reference.Domain(named), nil
+}
+
+// ParseRegistryToken converts a token supplied with a job into credentials
+// for the registry. Tokens of the form "username:password" are used as a
+// login, anything else is used as a bearer token for the registry.
+func ParseRegistryToken(registry, token string) types.AuthConfig {
+	authConfig := types.AuthConfig{ServerAddress: registryServerAddress(normalizeRegistry(registry))}
+	if username, password, ok := strings.Cut(token, ":"); ok {
+		authConfig.Username, authConfig.Password = username, password
+	} else {
+		authConfig.RegistryToken = token
+	}
+	return authConfig
+}
+
+// EncodeRegistryAuth encodes credentials in the form expected by the docker
+// API when pulling images.
+func EncodeRegistryAuth(authConfig types.AuthConfig) (string, error) {
+	data, err := json.Marshal(authConfig)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(data), nil
+}
+
+// CheckImageAccess returns nil if the image is present locally or the
+// registry will let us pull it with the given encoded credentials.
+func CheckImageAccess(ctx context.Context, dockerClient *dockerclient.Client, image string, registryAuth string) error {
+	_, _, err := dockerClient.ImageInspectWithRaw(ctx, image)
+	if err == nil {
+		return nil
+	}
+	if !dockerclient.IsErrNotFound(err) {
+		return err
+	}
+	_, err = dockerClient.DistributionInspect(ctx, image, registryAuth)
+	return err
+}
+
+// IsAuthError returns true if the error is the registry refusing access
+// because of missing or invalid credentials.
+func IsAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errdefs.IsUnauthorized(err) || errdefs.IsForbidden(err) {
+		return true
+	}
+	// the daemon doesn't always preserve the registry's status code
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "unauthorized") ||
+		strings.Contains(message, "authentication required") ||
+		strings.Contains(message, "access to the resource is denied")
+}
+
+func normalizeRegistry(registry string) string {
+	registry = strings.TrimPrefix(registry, "https://")
+	registry = strings.TrimPrefix(registry, "http://")
+	registry, _, _ = strings.Cut(registry, "/")
+	registry = strings.ToLower(registry)
+	switch registry {
+	case "index.docker.io", "registry-1.docker.io":
+		return dockerHubRegistry
+	}
+	return registry
+}
+
+func registryServerAddress(registry string) string {
+	if registry == dockerHubRegistry {
+		return dockerHubServerURL
+	}
+	return registry
+}
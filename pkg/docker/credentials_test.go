@@ -0,0 +1,74 @@
+//go:build unit || !integration
+
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryForImage(t *testing.T) {
+	for image, expected := range map[string]string{
+		"ubuntu":                "docker.io",
+		"library/ubuntu:22.04":  "docker.io",
+		"ghcr.io/org/image:tag": "ghcr.io",
+		"localhost:5000/image@sha256:" + digestHex: "localhost:5000",
+	} {
+		registry, err := RegistryForImage(image)
+		require.NoError(t, err, image)
+		require.Equal(t, expected, registry, image)
+	}
+	_, err := RegistryForImage("Invalid Image")
+	require.Error(t, err)
+}
+
+func TestParseRegistryToken(t *testing.T) {
+	require.Equal(t, types.AuthConfig{
+		Username:      "user",
+		Password:      "pass",
+		ServerAddress: dockerHubServerURL,
+	}, ParseRegistryToken("index.docker.io", "user:pass"))
+	require.Equal(t, types.AuthConfig{
+		RegistryToken: "token",
+		ServerAddress: "ghcr.io",
+	}, ParseRegistryToken("ghcr.io", "token"))
+}
+
+func TestRegistryCredentials(t *testing.T) {
+	ctx := context.Background()
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	credentials := NewRegistryCredentials(configPath)
+
+	require.False(t, credentials.Has(ctx, "ghcr.io"), "a missing config has no credentials")
+
+	auth := base64.StdEncoding.EncodeToString([]byte("user:pass"))
+	config := `{"auths": {
+		"https://index.docker.io/v1/": {"auth": "` + auth + `"},
+		"ghcr.io": {"identitytoken": "token"},
+		"quay.io": {}
+	}}`
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0600))
+
+	authConfig, ok, err := credentials.Get(ctx, "docker.io")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "user", authConfig.Username)
+	require.Equal(t, "pass", authConfig.Password)
+	require.Equal(t, dockerHubServerURL, authConfig.ServerAddress)
+
+	authConfig, ok, err = credentials.Get(ctx, "ghcr.io")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "token", authConfig.IdentityToken)
+
+	require.False(t, credentials.Has(ctx, "quay.io"), "empty entries are not credentials")
+	require.False(t, credentials.Has(ctx, "registry.example.com"))
+}
+
+const digestHex = "0000000000000000000000000000000000000000000000000000000000000000"
@@ -0,0 +1,123 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/rs/zerolog/log"
+)
+
+// PullPolicy decides when a compute node pulls the image for a job.
+type PullPolicy string
+
+const (
+	// PullAlways pulls the image before every job so mutable tags are kept up
+	// to date. Images pinned to a digest are only pulled if not present.
+	PullAlways PullPolicy = "always"
+	// PullIfNotPresent only pulls images that are not present locally.
+	PullIfNotPresent PullPolicy = "if-not-present"
+	// PullNever never pulls, so jobs can only use images already present
+	// (e.g. from the node's pre-pull list).
+	PullNever PullPolicy = "never"
+)
+
+func PullPolicies() []PullPolicy {
+	return []PullPolicy{PullAlways, PullIfNotPresent, PullNever}
+}
+
+// ParsePullPolicy parses a pull policy, defaulting to PullIfNotPresent if it
+// is empty.
+func ParsePullPolicy(str string) (PullPolicy, error) {
+	if str == "" {
+		return PullIfNotPresent, nil
+	}
+	for _, policy := range PullPolicies() {
+		if strings.EqualFold(string(policy), str) {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown image pull policy %q, expected one of %v", str, PullPolicies())
+}
+
+// IsPinned returns true if the image refers to a digest rather than only a
+// mutable tag.
+func IsPinned(image string) bool {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return false
+	}
+	_, ok := named.(reference.Canonical)
+	return ok
+}
+
+// ImageExists returns true if the image is present locally.
+func ImageExists(ctx context.Context, dockerClient *dockerclient.Client, image string) (bool, error) {
+	_, _, err := dockerClient.ImageInspectWithRaw(ctx, image)
+	if err == nil {
+		return true, nil
+	} else if dockerclient.IsErrNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// PullImageWithPolicy makes sure the image is present locally according to
+// the pull policy, using the encoded registry credentials (see
+// EncodeRegistryAuth) if they are not empty. It returns how long was spent
+// pulling, which is zero if no pull was needed.
+func PullImageWithPolicy(
+	ctx context.Context,
+	dockerClient *dockerclient.Client,
+	image string,
+	registryAuth string,
+	policy PullPolicy,
+) (time.Duration, error) {
+	if policy != PullAlways || IsPinned(image) {
+		exists, err := ImageExists(ctx, dockerClient, image)
+		if err != nil {
+			return 0, err
+		}
+		if exists {
+			return 0, nil
+		}
+		if policy == PullNever {
+			return 0, fmt.Errorf("image %s is not present on this node and its pull policy is %s", image, policy)
+		}
+	}
+
+	log.Ctx(ctx).Debug().Str("image", image).Str("policy", string(policy)).Msg("Pulling image")
+	start := time.Now()
+	if err := pullImage(ctx, dockerClient, image, registryAuth); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// ResolveImageDigest returns the image pinned to the digest its tag currently
+// refers to in the registry, e.g. "ubuntu:22.04" becomes
+// "ubuntu:22.04@sha256:...". Images that are already pinned are returned
+// unchanged.
+func ResolveImageDigest(ctx context.Context, dockerClient *dockerclient.Client, image string, registryAuth string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", fmt.Errorf("invalid image %q: %w", image, err)
+	}
+	if _, ok := named.(reference.Canonical); ok {
+		return image, nil
+	}
+
+	named = reference.TagNameOnly(named)
+	inspect, err := dockerClient.DistributionInspect(ctx, named.String(), registryAuth)
+	if err != nil {
+		return "", err
+	}
+	pinned, err := reference.WithDigest(named, inspect.Descriptor.Digest)
+	if err != nil {
+		return "", err
+	}
+	return reference.FamiliarString(pinned), nil
+}
@@ -0,0 +1,111 @@
+//go:build unit || !integration
+
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePullPolicy(t *testing.T) {
+	for str, expected := range map[string]PullPolicy{
+		"":               PullIfNotPresent,
+		"always":         PullAlways,
+		"If-Not-Present": PullIfNotPresent,
+		"never":          PullNever,
+	} {
+		policy, err := ParsePullPolicy(str)
+		require.NoError(t, err, str)
+		require.Equal(t, expected, policy, str)
+	}
+	_, err := ParsePullPolicy("sometimes")
+	require.Error(t, err)
+}
+
+func TestIsPinned(t *testing.T) {
+	require.True(t, IsPinned("ubuntu@sha256:"+digestHex))
+	require.True(t, IsPinned("ghcr.io/org/image:tag@sha256:"+digestHex))
+	require.False(t, IsPinned("ubuntu"))
+	require.False(t, IsPinned("ubuntu:22.04"))
+	require.False(t, IsPinned("Invalid Image"))
+}
+
+func TestPullImageWithPolicy(t *testing.T) {
+	pinned := "ubuntu@sha256:" + digestHex
+	for _, test := range []struct {
+		name    string
+		policy  PullPolicy
+		image   string
+		present bool
+		pulled  []string
+		error   bool
+	}{
+		{name: "if-not-present, absent", policy: PullIfNotPresent, image: "ubuntu:22.04", pulled: []string{"ubuntu:22.04"}},
+		{name: "if-not-present, present", policy: PullIfNotPresent, image: "ubuntu:22.04", present: true},
+		{name: "always, present", policy: PullAlways, image: "ubuntu:22.04", present: true, pulled: []string{"ubuntu:22.04"}},
+		{name: "always, pinned and present", policy: PullAlways, image: pinned, present: true},
+		{name: "always, pinned and absent", policy: PullAlways, image: pinned, pulled: []string{"ubuntu:sha256:" + digestHex}},
+		{name: "never, present", policy: PullNever, image: "ubuntu:22.04", present: true},
+		{name: "never, absent", policy: PullNever, image: "ubuntu:22.04", error: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			daemon := NewFakeDaemon(t)
+			if test.present {
+				daemon.AddImage(test.image)
+			}
+
+			pullDuration, err := PullImageWithPolicy(context.Background(), daemon.Client, test.image, "auth", test.policy)
+			if test.error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.pulled, daemon.Pulled())
+			if len(test.pulled) > 0 {
+				require.Positive(t, pullDuration)
+				require.Equal(t, "auth", daemon.RegistryAuth())
+			} else {
+				require.Zero(t, pullDuration)
+			}
+		})
+	}
+}
+
+func TestPullImageWithPolicyFails(t *testing.T) {
+	daemon := NewFakeDaemon(t)
+	daemon.FailPull("private/image:latest")
+
+	_, err := PullImageWithPolicy(context.Background(), daemon.Client, "private/image", "", PullIfNotPresent)
+	require.ErrorContains(t, err, "pull access denied")
+	require.Equal(t, []string{"private/image:latest"}, daemon.Pulled())
+}
+
+func TestResolveImageDigest(t *testing.T) {
+	ctx := context.Background()
+	daemon := NewFakeDaemon(t)
+	digest := "sha256:" + digestHex
+	daemon.SetDigest("docker.io/library/ubuntu:22.04", digest)
+	daemon.SetDigest("docker.io/library/ubuntu:latest", digest)
+	daemon.SetDigest("ghcr.io/org/image:v1", digest)
+
+	for image, expected := range map[string]string{
+		"ubuntu:22.04":           "ubuntu:22.04@" + digest,
+		"ubuntu":                 "ubuntu:latest@" + digest,
+		"ghcr.io/org/image:v1":   "ghcr.io/org/image:v1@" + digest,
+		"ubuntu@" + digest:       "ubuntu@" + digest,
+		"ubuntu:20.04@" + digest: "ubuntu:20.04@" + digest,
+	} {
+		pinned, err := ResolveImageDigest(ctx, daemon.Client, image, "auth")
+		require.NoError(t, err, image)
+		require.Equal(t, expected, pinned, image)
+		require.True(t, IsPinned(pinned), image)
+	}
+	require.Equal(t, "auth", daemon.RegistryAuth())
+
+	_, err := ResolveImageDigest(ctx, daemon.Client, "ubuntu:unknown", "")
+	require.Error(t, err)
+	_, err = ResolveImageDigest(ctx, daemon.Client, "Invalid Image", "")
+	require.Error(t, err)
+}
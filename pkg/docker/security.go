@@ -0,0 +1,186 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// SecurityPolicyDefault leaves containers with docker's default settings.
+	SecurityPolicyDefault = "default"
+	// SecurityPolicyHardened runs containers unprivileged and locked down.
+	SecurityPolicyHardened = "hardened"
+
+	seccompUnconfined = "unconfined"
+	allCapabilities   = "ALL"
+)
+
+// SecurityPolicy is a compute node's policy for the containers it runs jobs
+// in. Jobs can ask for some of the restrictions to be relaxed (see
+// model.JobSpecDockerSecurity), which the node only accepts if the policy
+// allows it.
+type SecurityPolicy struct {
+	// user to run containers as, as "uid" or "uid:gid". The image's own user
+	// is used when empty.
+	User string `json:"User,omitempty"`
+	// mount the container's root filesystem read only
+	ReadOnlyRootFilesystem bool `json:"ReadOnlyRootFilesystem,omitempty"`
+	// tmpfs mounts to give containers somewhere to write, as a map of path
+	// to mount options, e.g. {"/tmp": "size=64m"}
+	Tmpfs map[string]string `json:"Tmpfs,omitempty"`
+	// linux capabilities to drop, or ALL
+	DropCapabilities []string `json:"DropCapabilities,omitempty"`
+	// path to a seccomp profile, or "unconfined". Docker's default profile is
+	// used when empty.
+	SeccompProfile string `json:"SeccompProfile,omitempty"`
+	// name of an AppArmor profile loaded on the host. Docker's default
+	// profile is used when empty.
+	AppArmorProfile string `json:"AppArmorProfile,omitempty"`
+	// maximum number of processes in a container, or unlimited when zero
+	PidsLimit int64 `json:"PidsLimit,omitempty"`
+	// stop processes gaining privileges, e.g. through setuid binaries
+	NoNewPrivileges bool `json:"NoNewPrivileges,omitempty"`
+
+	// relaxations jobs may ask for
+	AllowRunAsRoot              bool     `json:"AllowRunAsRoot,omitempty"`
+	AllowWritableRootFilesystem bool     `json:"AllowWritableRootFilesystem,omitempty"`
+	AllowedCapabilities         []string `json:"AllowedCapabilities,omitempty"`
+}
+
+// NewHardenedSecurityPolicy returns a policy that runs containers as nobody
+// with a read only root filesystem, no capabilities and no way to gain
+// privileges.
+func NewHardenedSecurityPolicy() SecurityPolicy {
+	return SecurityPolicy{
+		User:                   "65534:65534",
+		ReadOnlyRootFilesystem: true,
+		Tmpfs:                  map[string]string{"/tmp": "rw,noexec,nosuid,size=512m"},
+		DropCapabilities:       []string{allCapabilities},
+		PidsLimit:              1024, //nolint:gomnd
+		NoNewPrivileges:        true,
+	}
+}
+
+// LoadSecurityPolicy returns the named policy (default or hardened), or loads
+// one from a YAML or JSON file.
+func LoadSecurityPolicy(nameOrPath string) (SecurityPolicy, error) {
+	switch nameOrPath {
+	case "", SecurityPolicyDefault:
+		return SecurityPolicy{}, nil
+	case SecurityPolicyHardened:
+		return NewHardenedSecurityPolicy(), nil
+	}
+
+	data, err := os.ReadFile(nameOrPath)
+	if err != nil {
+		return SecurityPolicy{}, fmt.Errorf("unable to read security policy: %w", err)
+	}
+	var policy SecurityPolicy
+	if err = yaml.UnmarshalStrict(data, &policy); err != nil {
+		return SecurityPolicy{}, fmt.Errorf("invalid security policy %s: %w", nameOrPath, err)
+	}
+	if err = policy.Validate(); err != nil {
+		return SecurityPolicy{}, fmt.Errorf("invalid security policy %s: %w", nameOrPath, err)
+	}
+	return policy, nil
+}
+
+// Validate checks that the policy can be applied to containers.
+func (p SecurityPolicy) Validate() error {
+	if p.PidsLimit < 0 {
+		return fmt.Errorf("PidsLimit must not be negative")
+	}
+	if p.SeccompProfile != "" && p.SeccompProfile != seccompUnconfined {
+		if _, err := os.Stat(p.SeccompProfile); err != nil {
+			return fmt.Errorf("seccomp profile: %w", err)
+		}
+	}
+	return nil
+}
+
+// Allows returns an error describing the first relaxation the job needs
+// that the policy doesn't allow.
+func (p SecurityPolicy) Allows(relaxations model.JobSpecDockerSecurity) error {
+	if relaxations.RunAsRoot && p.User != "" && !p.AllowRunAsRoot {
+		return fmt.Errorf("running as root is not allowed")
+	}
+	if relaxations.WritableRootFilesystem && p.ReadOnlyRootFilesystem && !p.AllowWritableRootFilesystem {
+		return fmt.Errorf("a writable root filesystem is not allowed")
+	}
+	for _, capability := range relaxations.AddCapabilities {
+		if !containsCapability(p.AllowedCapabilities, capability) {
+			return fmt.Errorf("capability %s is not allowed", normalizeCapability(capability))
+		}
+	}
+	return nil
+}
+
+// Apply configures a container to follow the policy, relaxed as the job
+// asks. Allows should be checked first.
+func (p SecurityPolicy) Apply(
+	relaxations model.JobSpecDockerSecurity,
+	containerConfig *container.Config,
+	hostConfig *container.HostConfig,
+) error {
+	if !relaxations.RunAsRoot {
+		containerConfig.User = p.User
+	}
+	hostConfig.ReadonlyRootfs = p.ReadOnlyRootFilesystem && !relaxations.WritableRootFilesystem
+	if len(p.Tmpfs) > 0 {
+		hostConfig.Tmpfs = make(map[string]string, len(p.Tmpfs))
+		for path, options := range p.Tmpfs {
+			hostConfig.Tmpfs[path] = options
+		}
+	}
+	if len(p.DropCapabilities) > 0 {
+		hostConfig.CapDrop = strslice.StrSlice(p.DropCapabilities)
+	}
+	if len(relaxations.AddCapabilities) > 0 {
+		hostConfig.CapAdd = strslice.StrSlice(relaxations.AddCapabilities)
+	}
+	if p.PidsLimit > 0 {
+		pidsLimit := p.PidsLimit
+		hostConfig.Resources.PidsLimit = &pidsLimit
+	}
+
+	if p.NoNewPrivileges {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "no-new-privileges")
+	}
+	if p.AppArmorProfile != "" {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "apparmor="+p.AppArmorProfile)
+	}
+	switch p.SeccompProfile {
+	case "":
+	case seccompUnconfined:
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "seccomp="+seccompUnconfined)
+	default:
+		// the API expects the profile itself rather than a path to it
+		profile, err := os.ReadFile(p.SeccompProfile)
+		if err != nil {
+			return fmt.Errorf("unable to read seccomp profile: %w", err)
+		}
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "seccomp="+string(profile))
+	}
+	return nil
+}
+
+func containsCapability(capabilities []string, capability string) bool {
+	capability = normalizeCapability(capability)
+	for _, c := range capabilities {
+		c = normalizeCapability(c)
+		if c == allCapabilities || c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeCapability(capability string) string {
+	return strings.TrimPrefix(strings.ToUpper(capability), "CAP_")
+}
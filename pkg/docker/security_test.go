@@ -0,0 +1,76 @@
+//go:build unit || !integration
+
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSecurityPolicy(t *testing.T) {
+	policy, err := LoadSecurityPolicy("")
+	require.NoError(t, err)
+	require.Equal(t, SecurityPolicy{}, policy)
+
+	policy, err = LoadSecurityPolicy(SecurityPolicyHardened)
+	require.NoError(t, err)
+	require.Equal(t, NewHardenedSecurityPolicy(), policy)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("User: \"1000\"\nPidsLimit: 10\nAllowedCapabilities: [NET_ADMIN]\n"), 0600))
+	policy, err = LoadSecurityPolicy(path)
+	require.NoError(t, err)
+	require.Equal(t, SecurityPolicy{User: "1000", PidsLimit: 10, AllowedCapabilities: []string{"NET_ADMIN"}}, policy)
+
+	require.NoError(t, os.WriteFile(path, []byte("Unknown: true\n"), 0600))
+	_, err = LoadSecurityPolicy(path)
+	require.Error(t, err, "unknown fields should be rejected")
+
+	require.NoError(t, os.WriteFile(path, []byte("SeccompProfile: "+filepath.Join(dir, "missing.json")+"\n"), 0600))
+	_, err = LoadSecurityPolicy(path)
+	require.Error(t, err)
+
+	_, err = LoadSecurityPolicy(filepath.Join(dir, "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestSecurityPolicyApply(t *testing.T) {
+	seccompPath := filepath.Join(t.TempDir(), "seccomp.json")
+	require.NoError(t, os.WriteFile(seccompPath, []byte(`{"defaultAction":"SCMP_ACT_ALLOW"}`), 0600))
+	policy := NewHardenedSecurityPolicy()
+	policy.SeccompProfile = seccompPath
+	policy.AppArmorProfile = "bacalhau"
+
+	containerConfig, hostConfig := &container.Config{}, &container.HostConfig{}
+	require.NoError(t, policy.Apply(model.JobSpecDockerSecurity{}, containerConfig, hostConfig))
+	require.Equal(t, "65534:65534", containerConfig.User)
+	require.True(t, hostConfig.ReadonlyRootfs)
+	require.Contains(t, hostConfig.Tmpfs, "/tmp")
+	require.Equal(t, []string{"ALL"}, []string(hostConfig.CapDrop))
+	require.Empty(t, hostConfig.CapAdd)
+	require.Equal(t, int64(1024), *hostConfig.PidsLimit)
+	require.ElementsMatch(t, []string{
+		"no-new-privileges",
+		"apparmor=bacalhau",
+		`seccomp={"defaultAction":"SCMP_ACT_ALLOW"}`,
+	}, hostConfig.SecurityOpt)
+
+	containerConfig, hostConfig = &container.Config{}, &container.HostConfig{}
+	relaxations := model.JobSpecDockerSecurity{RunAsRoot: true, WritableRootFilesystem: true, AddCapabilities: []string{"NET_ADMIN"}}
+	require.NoError(t, policy.Apply(relaxations, containerConfig, hostConfig))
+	require.Empty(t, containerConfig.User)
+	require.False(t, hostConfig.ReadonlyRootfs)
+	require.Equal(t, []string{"NET_ADMIN"}, []string(hostConfig.CapAdd))
+
+	// the default policy leaves docker's defaults alone
+	containerConfig, hostConfig = &container.Config{}, &container.HostConfig{}
+	require.NoError(t, SecurityPolicy{}.Apply(model.JobSpecDockerSecurity{}, containerConfig, hostConfig))
+	require.Equal(t, &container.Config{}, containerConfig)
+	require.Equal(t, &container.HostConfig{}, hostConfig)
+}
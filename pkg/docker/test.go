@@ -2,11 +2,18 @@ package docker
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"runtime"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/stretchr/testify/require"
 )
 
@@ -34,3 +41,114 @@ func MaybeNeedDocker(t *testing.T, needDocker bool) {
 		}
 	}
 }
+
+// FakeDaemon serves the parts of the docker API used to pull images and
+// resolve their digests, so those can be tested without docker.
+type FakeDaemon struct {
+	Client *client.Client
+
+	mu sync.Mutex
+	// images present locally
+	images map[string]bool
+	// images that fail to pull
+	failing map[string]bool
+	// digests of images in the registry
+	digests map[string]string
+	// images pulled, as name:tag
+	pulled []string
+	// registry auth sent with the last pull or distribution inspect
+	registryAuth string
+}
+
+func NewFakeDaemon(t *testing.T) *FakeDaemon {
+	d := &FakeDaemon{
+		images:  map[string]bool{},
+		failing: map[string]bool{},
+		digests: map[string]string{},
+	}
+	server := httptest.NewServer(http.HandlerFunc(d.serveHTTP))
+	t.Cleanup(server.Close)
+
+	var err error
+	d.Client, err = client.NewClientWithOpts(
+		client.WithHost("tcp://"+server.Listener.Addr().String()),
+		client.WithVersion("1.41"),
+	)
+	require.NoError(t, err)
+	return d
+}
+
+// AddImage makes the image present locally.
+func (d *FakeDaemon) AddImage(image string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.images[image] = true
+}
+
+// FailPull makes pulling the image, as name:tag, fail.
+func (d *FakeDaemon) FailPull(image string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.failing[image] = true
+}
+
+// SetDigest sets the digest the image, as a normalized name:tag, refers to in the registry.
+func (d *FakeDaemon) SetDigest(image, digest string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.digests[image] = digest
+}
+
+// Pulled returns the images pulled so far.
+func (d *FakeDaemon) Pulled() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.pulled...)
+}
+
+// RegistryAuth returns the registry credentials sent with the last request that needed them.
+func (d *FakeDaemon) RegistryAuth() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.registryAuth
+}
+
+func (d *FakeDaemon) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1.41")
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/images/") && strings.HasSuffix(path, "/json"):
+		image := strings.TrimSuffix(strings.TrimPrefix(path, "/images/"), "/json")
+		if !d.images[image] {
+			http.Error(w, `{"message":"No such image: `+image+`"}`, http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(types.ImageInspect{ID: image})
+	case r.Method == http.MethodPost && path == "/images/create":
+		d.registryAuth = r.Header.Get("X-Registry-Auth")
+		image := r.URL.Query().Get("fromImage") + ":" + r.URL.Query().Get("tag")
+		d.pulled = append(d.pulled, image)
+		if d.failing[image] {
+			_ = json.NewEncoder(w).Encode(jsonmessage.JSONMessage{
+				Error: &jsonmessage.JSONError{Message: "pull access denied for " + image},
+			})
+			return
+		}
+		d.images[image] = true
+		_ = json.NewEncoder(w).Encode(jsonmessage.JSONMessage{Status: "Downloaded newer image for " + image})
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/distribution/") && strings.HasSuffix(path, "/json"):
+		d.registryAuth = r.Header.Get("X-Registry-Auth")
+		image := strings.TrimSuffix(strings.TrimPrefix(path, "/distribution/"), "/json")
+		digest, ok := d.digests[image]
+		if !ok {
+			http.Error(w, `{"message":"manifest unknown"}`, http.StatusNotFound)
+			return
+		}
+		// the fields of registry.DistributionInspect that are used
+		_ = json.NewEncoder(w).Encode(map[string]any{"Descriptor": map[string]string{"digest": digest}})
+	default:
+		http.Error(w, `{"message":"not implemented"}`, http.StatusNotImplemented)
+	}
+}
@@ -237,17 +237,18 @@ func WaitForContainerLogs(ctx context.Context,
 }
 
 func PullImage(ctx context.Context, dockerClient *dockerclient.Client, image string) error {
-	_, _, err := dockerClient.ImageInspectWithRaw(ctx, image)
-	if err == nil {
-		return nil
-	}
-	if !dockerclient.IsErrNotFound(err) {
-		return err
-	}
+	return PullImageWithAuth(ctx, dockerClient, image, "")
+}
 
-	log.Debug().Str("image", image).Msg("Pulling image as it wasn't found")
+// PullImageWithAuth pulls the image if it isn't present locally, using the
+// encoded registry credentials (see EncodeRegistryAuth) if they are not empty.
+func PullImageWithAuth(ctx context.Context, dockerClient *dockerclient.Client, image string, registryAuth string) error {
+	_, err := PullImageWithPolicy(ctx, dockerClient, image, registryAuth, PullIfNotPresent)
+	return err
+}
 
-	output, err := dockerClient.ImagePull(ctx, image, types.ImagePullOptions{})
+func pullImage(ctx context.Context, dockerClient *dockerclient.Client, image string, registryAuth string) error {
+	output, err := dockerClient.ImagePull(ctx, image, types.ImagePullOptions{RegistryAuth: registryAuth})
 	if err != nil {
 		return err
 	}
@@ -2,6 +2,7 @@ package downloader
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -9,10 +10,16 @@ import (
 
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/verifier/manifest"
 	cp "github.com/n-marshall/go-cp"
 	"github.com/rs/zerolog/log"
+	"go.ptx.dk/multierrgroup"
 )
 
+// manifestSuffix is added to the path of a downloaded result to name the
+// manifest of its files, which is written once the download completes
+const manifestSuffix = ".manifest.json"
+
 // specialFiles - i.e. anything that is not a volume
 // the boolean value is whether we should append to the global log
 var specialFiles = map[string]bool{
@@ -21,8 +28,9 @@ var specialFiles = map[string]bool{
 	model.DownloadFilenameExitCode: false,
 }
 
+// * select the shards and output volumes to download
 // * make a temp dir
-// * download all cids into temp dir
+// * download all cids into temp dir, skipping those already downloaded
 // * ensure top level output dir exists
 // * iterate over each shard
 // * make new folder for shard logs
@@ -31,7 +39,7 @@ var specialFiles = map[string]bool{
 // * iterate over each output volume
 // * make new folder for output volume
 // * iterate over each shard and merge files in output folder to results dir
-func DownloadJob( //nolint:funlen,gocyclo
+func DownloadJob( //nolint:funlen
 	ctx context.Context,
 	// these are the outputs named in the job spec
 	// we need them so we know which volumes exists
@@ -50,6 +58,18 @@ func DownloadJob( //nolint:funlen,gocyclo
 		return nil
 	}
 
+	publishedShardResults, err := selectShardResults(publishedShardResults, settings.ShardIndexes)
+	if err != nil {
+		return err
+	}
+	outputVolumes, err = selectOutputVolumes(outputVolumes, settings.OutputVolumes)
+	if err != nil {
+		return err
+	}
+	if err = manifest.ValidatePatterns(append(settings.Include, settings.Exclude...)); err != nil {
+		return err
+	}
+
 	// this is the full path to the top level folder we are writing our results
 	// we have already processed this in the case of a default
 	// (i.e. the folder named after the job has been created and assigned)
@@ -63,7 +83,8 @@ func DownloadJob( //nolint:funlen,gocyclo
 		return fmt.Errorf("output dir does not exist: %s", resultsOutputDir)
 	}
 
-	err = os.MkdirAll(filepath.Join(resultsOutputDir, model.DownloadCIDsFolderName), model.DownloadFolderPerm)
+	cidsDir := filepath.Join(resultsOutputDir, model.DownloadCIDsFolderName)
+	err = os.MkdirAll(cidsDir, model.DownloadFolderPerm)
 	if err != nil {
 		return err
 	}
@@ -73,12 +94,52 @@ func DownloadJob( //nolint:funlen,gocyclo
 	// each shard context understands the various folder paths
 	// and other data it needs to download and resolve itself
 	shardContexts := []shardCIDContext{}
-	// keep track of which cids we have downloaded to avoid
+	// keep track of which cids we need to download to avoid
 	// downloading the same cid multiple times
-	downloadedCids := map[string]bool{}
+	cidResults := []model.PublishedResult{}
+	seenCids := map[string]bool{}
 
 	// the base folder for globally merged volumes
 	volumeDir := filepath.Join(resultsOutputDir, model.DownloadVolumesFolderName)
+	shardsDir := filepath.Join(resultsOutputDir, model.DownloadShardsFolderName)
+
+	// loop over shard results - work out their cid and shard folders
+	// then add to an array of contexts
+	for _, shardResult := range publishedShardResults {
+		shardContexts = append(shardContexts, shardCIDContext{
+			Result:         shardResult,
+			OutputVolumes:  outputVolumes,
+			RootDir:        resultsOutputDir,
+			CIDDownloadDir: filepath.Join(cidsDir, shardResult.Data.CID),
+			ShardDir: filepath.Join(
+				shardsDir,
+				fmt.Sprintf("%d_node_%s", shardResult.ShardIndex, system.GetShortID(shardResult.NodeID)),
+			),
+			VolumeDir:       volumeDir,
+			SelectedVolumes: settings.OutputVolumes,
+			Include:         settings.Include,
+			Exclude:         settings.Exclude,
+		})
+
+		if !seenCids[shardResult.Data.CID] {
+			cidResults = append(cidResults, shardResult)
+			seenCids[shardResult.Data.CID] = true
+		}
+	}
+
+	err = fetchResults(ctx, cidResults, cidsDir, downloadProvider, settings)
+	if err != nil {
+		return err
+	}
+
+	// the merged folders are rebuilt from the downloaded cids every time,
+	// so that resuming a download doesn't append the logs twice
+	for _, dir := range []string{volumeDir, shardsDir} {
+		err = os.RemoveAll(dir)
+		if err != nil {
+			return err
+		}
+	}
 	err = os.Mkdir(volumeDir, model.DownloadFolderPerm)
 	if err != nil {
 		return err
@@ -92,38 +153,6 @@ func DownloadJob( //nolint:funlen,gocyclo
 		}
 	}
 
-	// loop over shard results - create their cid and shard folders
-	// then add to an array of contexts
-	for _, shardResult := range publishedShardResults {
-		cidDownloadDir := filepath.Join(resultsOutputDir, model.DownloadCIDsFolderName, shardResult.Data.CID)
-		shardDir := filepath.Join(
-			resultsOutputDir,
-			model.DownloadShardsFolderName,
-			fmt.Sprintf("%d_node_%s", shardResult.ShardIndex, system.GetShortID(shardResult.NodeID)),
-		)
-
-		shardContexts = append(shardContexts, shardCIDContext{
-			Result:         shardResult,
-			OutputVolumes:  outputVolumes,
-			RootDir:        resultsOutputDir,
-			CIDDownloadDir: cidDownloadDir,
-			ShardDir:       shardDir,
-			VolumeDir:      volumeDir,
-		})
-
-		// get downloader for each shard and download it's CID
-		// (if we have not already done so)
-		downloader, err := downloadProvider.GetDownloader(shardResult.Data.StorageSource) //nolint
-		_, ok := downloadedCids[shardResult.Data.CID]
-		if !ok {
-			err = downloader.FetchResult(ctx, shardResult, cidDownloadDir)
-			if err != nil {
-				return err
-			}
-			downloadedCids[shardResult.Data.CID] = true
-		}
-	}
-
 	// now that we have downloaded the unique CIDs of the results
 	// we want to re-construct folders for each shard and volume
 	for _, shardContext := range shardContexts {
@@ -136,6 +165,173 @@ func DownloadJob( //nolint:funlen,gocyclo
 	return nil
 }
 
+// selectShardResults returns the results of the given shards, or all of them
+// if no shards are given.
+func selectShardResults(results []model.PublishedResult, shardIndexes []int) ([]model.PublishedResult, error) {
+	if len(shardIndexes) == 0 {
+		return results, nil
+	}
+	var selected []model.PublishedResult
+	for _, shardIndex := range shardIndexes {
+		found := false
+		for _, result := range results {
+			if result.ShardIndex == shardIndex {
+				selected = append(selected, result)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no results found for shard %d", shardIndex)
+		}
+	}
+	return selected, nil
+}
+
+// selectOutputVolumes returns the named output volumes, or all of them if no
+// names are given.
+func selectOutputVolumes(volumes []model.StorageSpec, names []string) ([]model.StorageSpec, error) {
+	if len(names) == 0 {
+		return volumes, nil
+	}
+	var selected []model.StorageSpec
+	for _, name := range names {
+		found := false
+		for _, volume := range volumes {
+			if volume.Name == name {
+				selected = append(selected, volume)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("the job has no output volume named %q", name)
+		}
+	}
+	return selected, nil
+}
+
+// fetchResults downloads the results into cidsDir, a few at a time.
+func fetchResults(
+	ctx context.Context,
+	results []model.PublishedResult,
+	cidsDir string,
+	downloadProvider DownloaderProvider,
+	settings *model.DownloaderSettings,
+) error {
+	parallelism := settings.Parallelism
+	if parallelism <= 0 {
+		parallelism = model.DefaultDownloadParallelism
+	}
+	semaphore := make(chan struct{}, parallelism)
+	progress := newDownloadProgress(settings.Progress, len(results))
+
+	waitgroup := multierrgroup.Group{}
+	for _, result := range results {
+		result := result // https://golang.org/doc/faq#closures_and_goroutines
+		waitgroup.Go(func() error {
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			alreadyComplete, err := fetchResult(ctx, result, filepath.Join(cidsDir, result.Data.CID), downloadProvider)
+			if err != nil {
+				return fmt.Errorf("error downloading results of shard %d from node %s: %w",
+					result.ShardIndex, system.GetShortID(result.NodeID), err)
+			}
+			progress.done(result, alreadyComplete)
+			return nil
+		})
+	}
+	return waitgroup.Wait()
+}
+
+// fetchResult downloads a result to downloadPath, unless a complete download
+// is already there, in which case it returns true without downloading it
+// again. Completed downloads are recorded in a manifest of their
+// files next to them, which is checked before skipping the download again.
+// Downloads are checked against the hash of the manifest of the results that
+// the compute node published, if it did.
+func fetchResult(
+	ctx context.Context,
+	result model.PublishedResult,
+	downloadPath string,
+	downloadProvider DownloaderProvider,
+) (bool, error) {
+	manifestPath := downloadPath + manifestSuffix
+	publishedHash := result.Data.Metadata[model.MetadataResultsHash]
+	if publishedHash == "" {
+		log.Ctx(ctx).Debug().Msgf("Results of shard %d from node %s can't be verified as they were published without a hash",
+			result.ShardIndex, result.NodeID)
+	}
+	complete, err := checkDownload(ctx, downloadPath, manifestPath, publishedHash)
+	if err != nil || complete {
+		return complete, err
+	}
+
+	downloader, err := downloadProvider.GetDownloader(result.Data.StorageSource)
+	if err != nil {
+		return false, err
+	}
+	err = downloader.FetchResult(ctx, result, downloadPath)
+	if err != nil {
+		return false, err
+	}
+
+	m, err := manifest.Build(downloadPath)
+	if err != nil {
+		return false, err
+	}
+	if publishedHash != "" && m.Hash() != publishedHash {
+		// nothing of this download can be trusted, so don't resume from it
+		if err = os.RemoveAll(downloadPath); err != nil {
+			return false, err
+		}
+		return false, fmt.Errorf("downloaded results do not match the hash %s published by the node", publishedHash)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return false, err
+	}
+	return false, os.WriteFile(manifestPath, data, model.DownloadFilePerm)
+}
+
+// checkDownload returns true if a download completed before and its files
+// still match its manifest, and the manifest matches the published hash if
+// any. A download that doesn't match is removed, while one that never
+// completed is left for the downloader to resume.
+func checkDownload(ctx context.Context, downloadPath, manifestPath, publishedHash string) (bool, error) {
+	data, err := os.ReadFile(manifestPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	var expected manifest.Manifest
+	err = json.Unmarshal(data, &expected)
+	if err == nil {
+		var actual manifest.Manifest
+		actual, err = manifest.Build(downloadPath)
+		if err == nil {
+			diff := actual.Diff(expected)
+			switch {
+			case !diff.IsEmpty():
+				err = fmt.Errorf("%d files have changed", diff.Count())
+			case publishedHash != "" && expected.Hash() != publishedHash:
+				err = fmt.Errorf("the results do not match the hash %s published by the node", publishedHash)
+			default:
+				return true, nil
+			}
+		}
+	}
+
+	log.Ctx(ctx).Warn().Err(err).Msgf("Downloading %s again as it does not match its manifest", downloadPath)
+	err = os.RemoveAll(downloadPath)
+	if err != nil {
+		return false, err
+	}
+	return false, os.Remove(manifestPath)
+}
+
 func moveShardData(
 	ctx context.Context,
 	shardContext shardCIDContext,
@@ -173,6 +369,13 @@ func moveShardData(
 		// are we dealing with a special case file?
 		shouldAppendLogs, isSpecialFile := specialFiles[basePath]
 
+		if !isSpecialFile && !shardContext.selected(basePath, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		if d.IsDir() {
 			err = os.MkdirAll(shardTargetPath, model.DownloadFolderPerm)
 			if err != nil {
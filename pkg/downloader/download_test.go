@@ -16,6 +16,7 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/logger"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/verifier/manifest"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -317,3 +318,131 @@ func (ds *DownloaderSuite) TestCustomVolumeNames() {
 
 	requireFileExists(ds, model.DownloadVolumesFolderName, "secrets", "private.pem")
 }
+
+func (ds *DownloaderSuite) TestSelectedOutput() {
+	cid0 := mockShardOutput(ds, func(s string) {
+		mockFile(ds, s, "outputs", "data0.csv")
+		mockFile(ds, s, "outputs", "data0.log")
+		mockFile(ds, s, "logs", "run.log")
+	})
+	cid1 := mockShardOutput(ds, func(s string) {
+		mockFile(ds, s, "outputs", "data1.csv")
+	})
+
+	ds.downloadSettings.ShardIndexes = []int{0}
+	ds.downloadSettings.OutputVolumes = []string{"outputs"}
+	ds.downloadSettings.Include = []string{"*.csv", "*.log"}
+	ds.downloadSettings.Exclude = []string{"outputs/*.log"}
+
+	err := DownloadJob(
+		context.Background(),
+		[]model.StorageSpec{
+			{StorageSource: model.StorageSourceIPFS, Name: "outputs", Path: "/outputs"},
+			{StorageSource: model.StorageSourceIPFS, Name: "logs", Path: "/logs"},
+		},
+		[]model.PublishedResult{
+			{
+				NodeID:     "testnode",
+				ShardIndex: 0,
+				Data:       model.StorageSpec{StorageSource: model.StorageSourceIPFS, Name: "shard-0", CID: cid0},
+			},
+			{
+				NodeID:     "testnode",
+				ShardIndex: 1,
+				Data:       model.StorageSpec{StorageSource: model.StorageSourceIPFS, Name: "shard-1", CID: cid1},
+			},
+		},
+		ds.downloadProvider,
+		ds.downloadSettings,
+	)
+	require.NoError(ds.T(), err)
+
+	requireFileExists(ds, model.DownloadVolumesFolderName, "outputs", "data0.csv")
+	require.NoFileExists(ds.T(), filepath.Join(ds.outputDir, model.DownloadVolumesFolderName, "outputs", "data0.log"))
+	require.NoFileExists(ds.T(), filepath.Join(ds.outputDir, model.DownloadVolumesFolderName, "logs", "run.log"))
+	require.NoFileExists(ds.T(), filepath.Join(ds.outputDir, model.DownloadVolumesFolderName, "outputs", "data1.csv"))
+	require.NoDirExists(ds.T(), filepath.Join(ds.outputDir, model.DownloadCIDsFolderName, cid1))
+}
+
+func (ds *DownloaderSuite) TestResumedDownload() {
+	var stdout []byte
+	cid := mockShardOutput(ds, func(s string) {
+		stdout = mockFile(ds, s, model.DownloadFilenameStdout)
+		mockFile(ds, s, "outputs", "data.csv")
+	})
+
+	download := func() {
+		err := DownloadJob(
+			context.Background(),
+			[]model.StorageSpec{{StorageSource: model.StorageSourceIPFS, Name: "outputs", Path: "/outputs"}},
+			[]model.PublishedResult{
+				{
+					NodeID:     "testnode",
+					ShardIndex: 0,
+					Data:       model.StorageSpec{StorageSource: model.StorageSourceIPFS, Name: "shard-0", CID: cid},
+				},
+			},
+			ds.downloadProvider,
+			ds.downloadSettings,
+		)
+		require.NoError(ds.T(), err)
+	}
+
+	download()
+	requireFileExists(ds, model.DownloadCIDsFolderName, cid+manifestSuffix)
+
+	// a complete download is kept, and the logs are not appended twice
+	download()
+	requireFile(ds, stdout, model.DownloadVolumesFolderName, model.DownloadFilenameStdout)
+
+	// a download that no longer matches its manifest is fetched again
+	corrupted := filepath.Join(ds.outputDir, model.DownloadCIDsFolderName, cid, "outputs", "data.csv")
+	require.NoError(ds.T(), os.WriteFile(corrupted, []byte("corrupted"), model.DownloadFilePerm))
+	download()
+	contents, err := os.ReadFile(corrupted)
+	require.NoError(ds.T(), err)
+	require.NotEqual(ds.T(), []byte("corrupted"), contents)
+}
+
+func (ds *DownloaderSuite) TestPublishedHash() {
+	var resultsDir string
+	cid := mockShardOutput(ds, func(s string) {
+		resultsDir = s
+		mockFile(ds, s, model.DownloadFilenameStdout)
+		mockFile(ds, s, "outputs", "data.csv")
+	})
+	m, err := manifest.Build(resultsDir)
+	require.NoError(ds.T(), err)
+
+	download := func(publishedHash string) error {
+		return DownloadJob(
+			context.Background(),
+			[]model.StorageSpec{{StorageSource: model.StorageSourceIPFS, Name: "outputs", Path: "/outputs"}},
+			[]model.PublishedResult{
+				{
+					NodeID:     "testnode",
+					ShardIndex: 0,
+					Data: model.StorageSpec{
+						StorageSource: model.StorageSourceIPFS,
+						Name:          "shard-0",
+						CID:           cid,
+						Metadata:      map[string]string{model.MetadataResultsHash: publishedHash},
+					},
+				},
+			},
+			ds.downloadProvider,
+			ds.downloadSettings,
+		)
+	}
+
+	require.NoError(ds.T(), download(m.Hash()))
+	requireFileExists(ds, model.DownloadVolumesFolderName, "outputs", "data.csv")
+
+	// a complete download that doesn't match what was published is fetched again, and rejected
+	err = download(manifest.Manifest{}.Hash())
+	require.ErrorContains(ds.T(), err, "do not match the hash")
+	require.NoDirExists(ds.T(), filepath.Join(ds.outputDir, model.DownloadCIDsFolderName, cid))
+	require.NoFileExists(ds.T(), filepath.Join(ds.outputDir, model.DownloadCIDsFolderName, cid+manifestSuffix))
+
+	require.NoError(ds.T(), download(m.Hash()))
+}
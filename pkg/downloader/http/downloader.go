@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -50,23 +51,52 @@ func (httpDownloader *Downloader) FetchResult(ctx context.Context, result model.
 	return nil
 }
 
+// fetch downloads the url to filepath, resuming from the end of what is
+// already there if the server supports range requests.
 func fetch(ctx context.Context, url string, filepath string) error {
-	_, span := system.GetTracer().Start(ctx, "pkg/downloader.http.fetchHttp")
+	ctx, span := system.GetTracer().Start(ctx, "pkg/downloader.http.fetchHttp")
 	defer span.End()
-	// Create a new file at the specified filepath
-	out, err := os.OpenFile(filepath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, model.DownloadFilePerm)
+
+	var offset int64
+	if info, err := os.Stat(filepath); err == nil && info.Mode().IsRegular() {
+		offset = info.Size()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return err
 	}
-	defer out.Close()
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
 
 	// Make an HTTP GET request to the URL
-	response, err := http.Get(url) //nolint
+	response, err := http.DefaultClient.Do(request)
 	if err != nil {
 		return err
 	}
 	defer response.Body.Close()
 
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	switch {
+	case offset > 0 && response.StatusCode == http.StatusPartialContent:
+		log.Ctx(ctx).Debug().Msgf("Resuming download of %s from byte %d", url, offset)
+		flags = os.O_WRONLY | os.O_APPEND
+	case offset > 0 && response.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// what we have doesn't fit what the server has, so start again
+		_ = os.Remove(filepath)
+		return fetch(ctx, url, filepath)
+	case response.StatusCode < 200 || response.StatusCode > 299:
+		return fmt.Errorf("error downloading %s: %s", url, response.Status)
+	}
+
+	// Create a new file at the specified filepath, or add to the existing one
+	out, err := os.OpenFile(filepath, flags, model.DownloadFilePerm)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
 	// Write the contents of the response body to the file
 	_, err = io.Copy(out, response.Body)
 	if err != nil {
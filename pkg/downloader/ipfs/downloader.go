@@ -3,6 +3,7 @@ package ipfs
 import (
 	"context"
 	"errors"
+	"os"
 	"strings"
 	"time"
 
@@ -42,6 +43,13 @@ func (ipfsDownloader *Downloader) FetchResult(ctx context.Context, result model.
 		return err
 	}
 
+	// IPFS can't resume a download, so start again from scratch if an earlier
+	// one was interrupted
+	err = os.RemoveAll(downloadPath)
+	if err != nil {
+		return err
+	}
+
 	err = func() error {
 		log.Ctx(ctx).Debug().Msgf(
 			"Downloading result CID %s '%s' to '%s'...",
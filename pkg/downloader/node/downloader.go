@@ -0,0 +1,68 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/util/tarstream"
+	"github.com/rs/zerolog/log"
+)
+
+// ResultsFetcher streams the results that a compute node kept for a shard as
+// a tar archive, e.g. through the API of a requester node.
+type ResultsFetcher interface {
+	FetchResults(ctx context.Context, jobID, nodeID string, shardIndex int) (io.ReadCloser, error)
+}
+
+type Downloader struct {
+	fetcher  ResultsFetcher
+	settings *model.DownloaderSettings
+}
+
+func NewNodeDownloader(fetcher ResultsFetcher, settings *model.DownloaderSettings) *Downloader {
+	return &Downloader{
+		fetcher:  fetcher,
+		settings: settings,
+	}
+}
+
+func (d *Downloader) FetchResult(ctx context.Context, result model.PublishedResult, downloadPath string) error {
+	ctx, span := system.GetTracer().Start(ctx, "pkg/downloader.node.FetchResult")
+	defer span.End()
+
+	jobID := result.Data.Metadata[model.MetadataJobID]
+	if jobID == "" {
+		return fmt.Errorf("results of shard %d from node %s do not name their job", result.ShardIndex, result.NodeID)
+	}
+
+	log.Ctx(ctx).Debug().Msgf(
+		"Downloading results of shard %d of job %s kept by node %s to '%s'...",
+		result.ShardIndex, jobID, result.NodeID, downloadPath,
+	)
+
+	// an archive can't be resumed part way through, so start from scratch
+	if err := os.RemoveAll(downloadPath); err != nil {
+		return err
+	}
+
+	innerCtx, cancel := context.WithDeadline(ctx, time.Now().Add(d.settings.Timeout))
+	defer cancel()
+
+	archive, err := d.fetcher.FetchResults(innerCtx, jobID, result.NodeID, result.ShardIndex)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	err = tarstream.Extract(archive, downloadPath)
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.Ctx(ctx).Error().Msg("Timed out while downloading result.")
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+package downloader
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+)
+
+// downloadProgress reports each result as it finishes downloading.
+type downloadProgress struct {
+	mu    sync.Mutex
+	out   io.Writer
+	count int
+	total int
+}
+
+func newDownloadProgress(out io.Writer, total int) *downloadProgress {
+	return &downloadProgress{out: out, total: total}
+}
+
+func (p *downloadProgress) done(result model.PublishedResult, alreadyComplete bool) {
+	if p.out == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.count++
+	action := "Downloaded"
+	if alreadyComplete {
+		action = "Already downloaded"
+	}
+	fmt.Fprintf(p.out, "[%d/%d] %s results of shard %d from node %s\n",
+		p.count, p.total, action, result.ShardIndex, system.GetShortID(result.NodeID))
+}
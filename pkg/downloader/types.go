@@ -2,12 +2,17 @@ package downloader
 
 import (
 	"context"
+	"path/filepath"
+	"strings"
 
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/verifier/manifest"
+	"golang.org/x/exp/slices"
 )
 
 type Downloader interface {
-	// FetchResult fetches published result and saves it to downloadPath
+	// FetchResult fetches published result and saves it to downloadPath,
+	// which may hold what an earlier interrupted download left behind
 	FetchResult(ctx context.Context, result model.PublishedResult, downloadPath string) error
 }
 
@@ -22,4 +27,27 @@ type shardCIDContext struct {
 	CIDDownloadDir string
 	ShardDir       string
 	VolumeDir      string
+	// the output volumes to merge, or all of them if empty
+	SelectedVolumes []string
+	Include         []string
+	Exclude         []string
+}
+
+// selected returns true if the file or directory at the path, relative to the
+// downloaded CID, should be merged into the results.
+func (c shardCIDContext) selected(path string, isDir bool) bool {
+	path = filepath.ToSlash(path)
+	if len(c.SelectedVolumes) > 0 {
+		volume, _, _ := strings.Cut(path, "/")
+		if !slices.Contains(c.SelectedVolumes, volume) {
+			return false
+		}
+	}
+	if isDir {
+		return true
+	}
+	if len(c.Include) > 0 && !manifest.Matches(path, c.Include) {
+		return false
+	}
+	return !manifest.Matches(path, c.Exclude)
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/downloader"
 	"github.com/filecoin-project/bacalhau/pkg/downloader/estuary"
 	"github.com/filecoin-project/bacalhau/pkg/downloader/ipfs"
+	"github.com/filecoin-project/bacalhau/pkg/downloader/node"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/rs/zerolog/log"
@@ -18,6 +19,7 @@ func NewDownloadSettings() *model.DownloaderSettings {
 		// we leave this blank so the CLI will auto-create a job folder in pwd
 		OutputDir:      "",
 		IPFSSwarmAddrs: "",
+		Parallelism:    model.DefaultDownloadParallelism,
 	}
 
 	switch system.GetEnvironment() {
@@ -39,14 +41,22 @@ func NewDownloadSettings() *model.DownloaderSettings {
 	return &settings
 }
 
+// NewStandardDownloaders returns the downloaders of results published to
+// IPFS and Estuary, and of results kept by compute nodes if resultsFetcher
+// isn't nil.
 func NewStandardDownloaders(
 	cm *system.CleanupManager,
-	settings *model.DownloaderSettings) downloader.DownloaderProvider {
+	settings *model.DownloaderSettings,
+	resultsFetcher node.ResultsFetcher) downloader.DownloaderProvider {
 	ipfsDownloader := ipfs.NewIPFSDownloader(cm, settings)
 	estuaryDownloader := estuary.NewEstuaryDownloader(cm, settings)
 
-	return downloader.NewMappedDownloaderProvider(map[model.StorageSourceType]downloader.Downloader{
+	downloaders := map[model.StorageSourceType]downloader.Downloader{
 		model.StorageSourceIPFS:    ipfsDownloader,
 		model.StorageSourceEstuary: estuaryDownloader,
-	})
+	}
+	if resultsFetcher != nil {
+		downloaders[model.StorageSourceNode] = node.NewNodeDownloader(resultsFetcher, settings)
+	}
+	return downloader.NewMappedDownloaderProvider(downloaders)
 }
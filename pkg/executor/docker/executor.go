@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/filecoin-project/bacalhau/pkg/compute/capacity"
@@ -24,6 +25,7 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/storage"
 	"github.com/filecoin-project/bacalhau/pkg/storage/util"
 	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -43,6 +45,22 @@ type Executor struct {
 	StorageProvider storage.StorageProvider
 
 	Client *dockerclient.Client
+
+	// credentials for private registries configured on this node
+	RegistryCredentials *docker.RegistryCredentials
+
+	// used to decrypt registry tokens sent with jobs
+	Decrypter verifier.DecrypterFunction
+
+	// when to pull the images for jobs
+	PullPolicy docker.PullPolicy
+
+	// restrictions applied to every job container
+	SecurityPolicy docker.SecurityPolicy
+
+	// time spent pulling images for shards that have been prepared but not yet run
+	pullDurations   map[string]time.Duration
+	pullDurationsMu sync.Mutex
 }
 
 func NewExecutor(
@@ -50,6 +68,10 @@ func NewExecutor(
 	cm *system.CleanupManager,
 	id string,
 	storageProvider storage.StorageProvider,
+	registryCredentials *docker.RegistryCredentials,
+	decrypter verifier.DecrypterFunction,
+	pullPolicy docker.PullPolicy,
+	securityPolicy docker.SecurityPolicy,
 ) (*Executor, error) {
 	dockerClient, err := docker.NewDockerClient()
 	if err != nil {
@@ -57,9 +79,14 @@ func NewExecutor(
 	}
 
 	de := &Executor{
-		ID:              id,
-		StorageProvider: storageProvider,
-		Client:          dockerClient,
+		ID:                  id,
+		StorageProvider:     storageProvider,
+		Client:              dockerClient,
+		RegistryCredentials: registryCredentials,
+		Decrypter:           decrypter,
+		PullPolicy:          pullPolicy,
+		SecurityPolicy:      securityPolicy,
+		pullDurations:       map[string]time.Duration{},
 	}
 
 	cm.RegisterCallback(func() error {
@@ -100,6 +127,47 @@ func (e *Executor) GetVolumeSize(ctx context.Context, volume model.StorageSpec)
 	return storageProvider.GetVolumeSize(ctx, volume)
 }
 
+// PrepareShard pulls the shard's image ahead of RunShard, so that the time spent
+// pulling isn't counted towards the job's timeout.
+func (e *Executor) PrepareShard(ctx context.Context, shard model.JobShard) error {
+	pullDuration, err := e.pullImage(ctx, shard)
+	if err != nil {
+		return err
+	}
+	e.pullDurationsMu.Lock()
+	defer e.pullDurationsMu.Unlock()
+	e.pullDurations[shard.ID()] = pullDuration
+	return nil
+}
+
+// PrePullImages pulls images in the background so that jobs using them can
+// start straight away, including when the pull policy is never.
+func (e *Executor) PrePullImages(ctx context.Context, images []string) {
+	for _, image := range images {
+		go func(image string) {
+			registryAuth, err := e.getRegistryAuth(ctx, model.JobSpecDocker{Image: image})
+			if err == nil {
+				_, err = docker.PullImageWithPolicy(ctx, e.Client, image, registryAuth, PrePullPolicy(e.PullPolicy))
+			}
+			if err != nil {
+				log.Ctx(ctx).Warn().Err(err).Msgf("Failed to pre-pull image %s", image)
+				return
+			}
+			log.Ctx(ctx).Debug().Msgf("Pre-pulled image %s", image)
+		}(image)
+	}
+}
+
+// PrePullPolicy returns the policy used to pre-pull images on a node whose
+// jobs use the given policy. Pre-pulling is how images are made available to
+// nodes that never pull for jobs.
+func PrePullPolicy(policy docker.PullPolicy) docker.PullPolicy {
+	if policy == docker.PullNever {
+		return docker.PullIfNotPresent
+	}
+	return policy
+}
+
 //nolint:funlen,gocyclo // will clean up
 func (e *Executor) RunShard(
 	ctx context.Context,
@@ -145,10 +213,15 @@ func (e *Executor) RunShard(
 		}
 	}
 
+	if err = e.SecurityPolicy.Allows(shard.Job.Spec.Docker.Security); err != nil {
+		return executor.FailResult(errors.Wrap(err, "job is not allowed by the node's security policy"))
+	}
+
 	// for this phase of the outputs we ignore the engine because it's just about collecting the
 	// data from the job and keeping it locally
 	// the engine property of the output storage spec is how we will "publish" the output volume
 	// if and when the deal is settled
+	var outputDirs []string
 	for _, output := range shard.Job.Spec.Outputs {
 		if output.Name == "" {
 			err = fmt.Errorf("output volume has no name: %+v", output)
@@ -165,6 +238,7 @@ func (e *Executor) RunShard(
 		if err != nil {
 			return executor.FailResult(err)
 		}
+		outputDirs = append(outputDirs, srcd)
 
 		log.Ctx(ctx).Trace().Msgf("Output Volume: %+v", output)
 
@@ -183,10 +257,10 @@ func (e *Executor) RunShard(
 		})
 	}
 
-	if os.Getenv("SKIP_IMAGE_PULL") == "" {
-		if err := docker.PullImage(ctx, e.Client, shard.Job.Spec.Docker.Image); err != nil { //nolint:govet // ignore err shadowing
-			err = errors.Wrapf(err, `Could not pull image %q - could be due to repo/image not existing,
- or registry needing authorization`, shard.Job.Spec.Docker.Image)
+	pullDuration, prepared := e.takePullDuration(shard)
+	if !prepared {
+		pullDuration, err = e.pullImage(ctx, shard)
+		if err != nil {
 			return executor.FailResult(err)
 		}
 	}
@@ -194,8 +268,11 @@ func (e *Executor) RunShard(
 	// json the job spec and pass it into all containers
 	// TODO: check if this will overwrite a user supplied version of this value
 	// (which is what we actually want to happen)
-	log.Ctx(ctx).Debug().Msgf("Job Spec: %+v", shard.Job.Spec)
-	jsonJobSpec, err := model.JSONMarshalWithMax(shard.Job.Spec)
+	// the registry token is only meant for us, not the job
+	containerJobSpec := shard.Job.Spec
+	containerJobSpec.Docker.RegistryAuth = nil
+	log.Ctx(ctx).Debug().Msgf("Job Spec: %+v", containerJobSpec)
+	jsonJobSpec, err := model.JSONMarshalWithMax(containerJobSpec)
 	if err != nil {
 		return executor.FailResult(err)
 	}
@@ -239,6 +316,19 @@ func (e *Executor) RunShard(
 		},
 	}
 
+	err = e.SecurityPolicy.Apply(shard.Job.Spec.Docker.Security, containerConfig, hostConfig)
+	if err != nil {
+		return executor.FailResult(err)
+	}
+	// a container running as a different user still needs to write its outputs
+	if containerConfig.User != "" {
+		for _, outputDir := range outputDirs {
+			if err = os.Chmod(outputDir, util.OS_ALL_RWX); err != nil {
+				return executor.FailResult(err)
+			}
+		}
+	}
+
 	// Create a network if the job requests it
 	err = e.setupNetworkForJob(ctx, shard, containerConfig, hostConfig)
 	if err != nil {
@@ -296,16 +386,79 @@ func (e *Executor) RunShard(
 		}
 	}
 
-	return executor.WriteJobResults(
+	result, err := executor.WriteJobResults(
 		jobResultsDir,
 		stdoutPipe,
 		stderrPipe,
 		int(containerExitStatusCode),
 		multierr.Combine(containerError, logsErr),
 	)
+	if result != nil {
+		result.ImagePullDuration = pullDuration
+	}
+	return result, err
+}
+
+// pullImage makes sure the shard's image is present according to the pull
+// policy and returns how long was spent pulling it.
+func (e *Executor) pullImage(ctx context.Context, shard model.JobShard) (time.Duration, error) {
+	if os.Getenv("SKIP_IMAGE_PULL") != "" {
+		return 0, nil
+	}
+	image := shard.Job.Spec.Docker.Image
+	registryAuth, err := e.getRegistryAuth(ctx, shard.Job.Spec.Docker)
+	if err != nil {
+		return 0, err
+	}
+	pullDuration, err := docker.PullImageWithPolicy(ctx, e.Client, image, registryAuth, e.PullPolicy)
+	if err != nil {
+		return 0, errors.Wrapf(err, `Could not pull image %q - could be due to repo/image not existing,
+ or registry needing authorization`, image)
+	}
+	return pullDuration, nil
+}
+
+// takePullDuration returns the time spent pulling the image if the shard was
+// prepared by PrepareShard.
+func (e *Executor) takePullDuration(shard model.JobShard) (time.Duration, bool) {
+	e.pullDurationsMu.Lock()
+	defer e.pullDurationsMu.Unlock()
+	pullDuration, ok := e.pullDurations[shard.ID()]
+	delete(e.pullDurations, shard.ID())
+	return pullDuration, ok
+}
+
+// getRegistryAuth returns the encoded credentials to pull the job's image,
+// preferring a token sent with the job over those configured on the node.
+func (e *Executor) getRegistryAuth(ctx context.Context, spec model.JobSpecDocker) (string, error) {
+	registry, err := docker.RegistryForImage(spec.Image)
+	if err != nil {
+		return "", err
+	}
+
+	if len(spec.RegistryAuth) > 0 {
+		if e.Decrypter == nil {
+			return "", fmt.Errorf("registry tokens are not supported by this node")
+		}
+		token, err := e.Decrypter(ctx, spec.RegistryAuth) //nolint:govet // ignore err shadowing
+		if err != nil {
+			return "", errors.Wrap(err, "failed to decrypt registry token")
+		}
+		return docker.EncodeRegistryAuth(docker.ParseRegistryToken(registry, string(token)))
+	}
+
+	if e.RegistryCredentials == nil {
+		return "", nil
+	}
+	authConfig, ok, err := e.RegistryCredentials.Get(ctx, registry)
+	if err != nil || !ok {
+		return "", err
+	}
+	return docker.EncodeRegistryAuth(authConfig)
 }
 
 func (e *Executor) CancelShard(ctx context.Context, shard model.JobShard) error {
+	e.takePullDuration(shard)
 	return docker.RemoveObjectsWithLabel(ctx, e.Client, labelJobName, e.labelJobValue(shard))
 }
 
@@ -359,3 +512,4 @@ func (e *Executor) labelJobValue(shard model.JobShard) string {
 
 // Compile-time interface check:
 var _ executor.Executor = (*Executor)(nil)
+var _ executor.Preparer = (*Executor)(nil)
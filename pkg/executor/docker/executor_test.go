@@ -3,17 +3,23 @@
 package docker
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/filecoin-project/bacalhau/pkg/compute/capacity"
 	"github.com/filecoin-project/bacalhau/pkg/docker"
 	"github.com/filecoin-project/bacalhau/pkg/model"
@@ -46,6 +52,10 @@ func (s *ExecutorTestSuite) SetupTest() {
 		s.cm,
 		"bacalhau-executor-unittest",
 		storage.NewMappedStorageProvider(map[model.StorageSourceType]storage.Storage{}),
+		nil,
+		nil,
+		docker.PullIfNotPresent,
+		docker.SecurityPolicy{},
 	)
 	require.NoError(s.T(), err)
 
@@ -300,3 +310,109 @@ func (s *ExecutorTestSuite) TestTimesOutCorrectly() {
 	s.ErrorIs(err, context.DeadlineExceeded)
 	s.Truef(strings.HasPrefix(result.STDOUT, expected), "'%s' does not start with '%s'", result.STDOUT, expected)
 }
+
+func TestGetRegistryAuth(t *testing.T) {
+	ctx := context.Background()
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	nodeAuth := base64.StdEncoding.EncodeToString([]byte("node:secret"))
+	config := fmt.Sprintf(`{"auths": {"https://registry.example.com": {"auth": %q}}}`, nodeAuth)
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0600))
+
+	e := &Executor{
+		RegistryCredentials: docker.NewRegistryCredentials(configPath),
+		Decrypter: func(_ context.Context, data []byte) ([]byte, error) {
+			if !bytes.HasPrefix(data, []byte("sealed:")) {
+				return nil, fmt.Errorf("not sealed for this node")
+			}
+			return bytes.TrimPrefix(data, []byte("sealed:")), nil
+		},
+	}
+	decode := func(encoded string) types.AuthConfig {
+		data, err := base64.URLEncoding.DecodeString(encoded)
+		require.NoError(t, err)
+		var authConfig types.AuthConfig
+		require.NoError(t, json.Unmarshal(data, &authConfig))
+		return authConfig
+	}
+
+	// the job's token is preferred over the node's credentials
+	encoded, err := e.getRegistryAuth(ctx, model.JobSpecDocker{
+		Image:        "registry.example.com/org/image",
+		RegistryAuth: []byte("sealed:user:password"),
+	})
+	require.NoError(t, err)
+	authConfig := decode(encoded)
+	require.Equal(t, "user", authConfig.Username)
+	require.Equal(t, "password", authConfig.Password)
+
+	encoded, err = e.getRegistryAuth(ctx, model.JobSpecDocker{Image: "registry.example.com/org/image"})
+	require.NoError(t, err)
+	require.Equal(t, "node", decode(encoded).Username)
+
+	encoded, err = e.getRegistryAuth(ctx, model.JobSpecDocker{Image: "ubuntu"})
+	require.NoError(t, err)
+	require.Empty(t, encoded)
+
+	_, err = e.getRegistryAuth(ctx, model.JobSpecDocker{Image: "ubuntu", RegistryAuth: []byte("for another node")})
+	require.ErrorContains(t, err, "failed to decrypt registry token")
+
+	e.Decrypter = nil
+	_, err = e.getRegistryAuth(ctx, model.JobSpecDocker{Image: "ubuntu", RegistryAuth: []byte("sealed:token")})
+	require.Error(t, err)
+}
+
+func TestPrepareShard(t *testing.T) {
+	t.Setenv("SKIP_IMAGE_PULL", "")
+	ctx := context.Background()
+	daemon := docker.NewFakeDaemon(t)
+	e := &Executor{
+		Client:        daemon.Client,
+		PullPolicy:    docker.PullIfNotPresent,
+		pullDurations: map[string]time.Duration{},
+	}
+	shard := model.JobShard{
+		Job: &model.Job{
+			Metadata: model.Metadata{ID: "job"},
+			Spec:     model.Spec{Docker: model.JobSpecDocker{Image: "ubuntu:22.04"}},
+		},
+	}
+
+	require.NoError(t, e.PrepareShard(ctx, shard))
+	require.Equal(t, []string{"ubuntu:22.04"}, daemon.Pulled())
+
+	// the time spent pulling is handed over to the shard's run only once
+	pullDuration, prepared := e.takePullDuration(shard)
+	require.True(t, prepared)
+	require.Positive(t, pullDuration)
+	_, prepared = e.takePullDuration(shard)
+	require.False(t, prepared)
+
+	// the image is now present, so preparing again doesn't pull
+	require.NoError(t, e.PrepareShard(ctx, shard))
+	require.Equal(t, []string{"ubuntu:22.04"}, daemon.Pulled())
+	pullDuration, prepared = e.takePullDuration(shard)
+	require.True(t, prepared)
+	require.Zero(t, pullDuration)
+}
+
+func TestPrepareShardFails(t *testing.T) {
+	t.Setenv("SKIP_IMAGE_PULL", "")
+	ctx := context.Background()
+	daemon := docker.NewFakeDaemon(t)
+	e := &Executor{
+		Client:        daemon.Client,
+		PullPolicy:    docker.PullNever,
+		pullDurations: map[string]time.Duration{},
+	}
+	shard := model.JobShard{
+		Job: &model.Job{
+			Metadata: model.Metadata{ID: "job"},
+			Spec:     model.Spec{Docker: model.JobSpecDocker{Image: "ubuntu:22.04"}},
+		},
+	}
+
+	require.ErrorContains(t, e.PrepareShard(ctx, shard), "not present on this node")
+	require.Empty(t, daemon.Pulled())
+	_, prepared := e.takePullDuration(shard)
+	require.False(t, prepared)
+}
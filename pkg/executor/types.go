@@ -42,3 +42,10 @@ type Executor interface {
 		shard model.JobShard,
 	) error
 }
+
+// Preparer is implemented by executors that have slow setup to do before
+// running a shard, such as pulling an image. Preparation is done before the
+// job's timeout starts.
+type Preparer interface {
+	PrepareShard(ctx context.Context, shard model.JobShard) error
+}
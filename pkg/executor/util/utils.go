@@ -3,6 +3,7 @@ package util
 import (
 	"context"
 
+	dockerutils "github.com/filecoin-project/bacalhau/pkg/docker"
 	"github.com/filecoin-project/bacalhau/pkg/executor"
 	"github.com/filecoin-project/bacalhau/pkg/executor/docker"
 	"github.com/filecoin-project/bacalhau/pkg/executor/language"
@@ -11,24 +12,40 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/executor/wasm"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/storage"
+	"github.com/filecoin-project/bacalhau/pkg/storage/cache"
 	"github.com/filecoin-project/bacalhau/pkg/storage/combo"
 	filecoinunsealed "github.com/filecoin-project/bacalhau/pkg/storage/filecoin_unsealed"
+	"github.com/filecoin-project/bacalhau/pkg/storage/git"
 	"github.com/filecoin-project/bacalhau/pkg/storage/inline"
 	apicopy "github.com/filecoin-project/bacalhau/pkg/storage/ipfs_apicopy"
 	noop_storage "github.com/filecoin-project/bacalhau/pkg/storage/noop"
 	"github.com/filecoin-project/bacalhau/pkg/storage/url/urldownload"
 	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
 )
 
 type StandardStorageProviderOptions struct {
 	IPFSMultiaddress     string
 	FilecoinUnsealedPath string
 	DownloadPath         string
+	// Cache is shared by the drivers that download inputs. If nil, a
+	// temporary cache is used that only keeps inputs while they are in use.
+	Cache *cache.Cache
 }
 
 type StandardExecutorOptions struct {
 	DockerID string
 	Storage  StandardStorageProviderOptions
+	// DockerRegistryCredentials are used to pull images from private registries
+	DockerRegistryCredentials *dockerutils.RegistryCredentials
+	// Decrypter is used to read registry tokens sent with jobs
+	Decrypter verifier.DecrypterFunction
+	// DockerPullPolicy decides when images are pulled for jobs
+	DockerPullPolicy dockerutils.PullPolicy
+	// DockerPrePullImages are pulled in the background when the executor is created
+	DockerPrePullImages []string
+	// DockerSecurityPolicy restricts the containers jobs run in
+	DockerSecurityPolicy dockerutils.SecurityPolicy
 }
 
 func NewStandardStorageProvider(
@@ -36,12 +53,21 @@ func NewStandardStorageProvider(
 	cm *system.CleanupManager,
 	options StandardStorageProviderOptions,
 ) (storage.StorageProvider, error) {
-	ipfsAPICopyStorage, err := apicopy.NewStorage(cm, options.IPFSMultiaddress)
+	inputCache := options.Cache
+	if inputCache == nil {
+		var err error
+		inputCache, err = cache.NewTemporaryCache(cm, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ipfsAPICopyStorage, err := apicopy.NewStorage(cm, inputCache, options.IPFSMultiaddress)
 	if err != nil {
 		return nil, err
 	}
 
-	urlDownloadStorage, err := urldownload.NewStorage(cm)
+	urlDownloadStorage, err := urldownload.NewStorage(cm, inputCache)
 	if err != nil {
 		return nil, err
 	}
@@ -53,6 +79,11 @@ func NewStandardStorageProvider(
 
 	inlineStorage := inline.NewStorage()
 
+	gitStorage, err := git.NewStorage(cm, inputCache)
+	if err != nil {
+		return nil, err
+	}
+
 	var useIPFSDriver storage.Storage = ipfsAPICopyStorage
 
 	// if we are using a FilecoinUnsealedPath then construct a combo
@@ -95,6 +126,7 @@ func NewStandardStorageProvider(
 		model.StorageSourceURLDownload:      urlDownloadStorage,
 		model.StorageSourceFilecoinUnsealed: filecoinUnsealedStorage,
 		model.StorageSourceInline:           inlineStorage,
+		model.StorageSourceGit:              gitStorage,
 	}), nil
 }
 
@@ -120,10 +152,20 @@ func NewStandardExecutorProvider(
 		return nil, err
 	}
 
-	dockerExecutor, err := docker.NewExecutor(ctx, cm, executorOptions.DockerID, storageProvider)
+	dockerExecutor, err := docker.NewExecutor(
+		ctx,
+		cm,
+		executorOptions.DockerID,
+		storageProvider,
+		executorOptions.DockerRegistryCredentials,
+		executorOptions.Decrypter,
+		executorOptions.DockerPullPolicy,
+		executorOptions.DockerSecurityPolicy,
+	)
 	if err != nil {
 		return nil, err
 	}
+	dockerExecutor.PrePullImages(ctx, executorOptions.DockerPrePullImages)
 
 	wasmExecutor, err := wasm.NewExecutor(ctx, storageProvider)
 	if err != nil {
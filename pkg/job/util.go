@@ -136,15 +136,23 @@ func ShortID(id string) string {
 }
 
 func ComputeStateSummary(j *model.Job) string {
+	currentJobState := ComputeJobState(j.Status.State)
+	stateSummary := currentJobState.String()
+	return stateSummary
+}
+
+// ComputeJobState returns the state of a job as a whole, which is the most
+// advanced state of any of its shards. It is not a valid state if no shards
+// have a state yet.
+func ComputeJobState(state model.JobState) model.JobStateType {
 	var currentJobState model.JobStateType
-	jobShardStates := FlattenShardStates(j.Status.State)
+	jobShardStates := FlattenShardStates(state)
 	for i := range jobShardStates {
 		if jobShardStates[i].State > currentJobState {
 			currentJobState = jobShardStates[i].State
 		}
 	}
-	stateSummary := currentJobState.String()
-	return stateSummary
+	return currentJobState
 }
 
 func ComputeResultsSummary(j *model.Job) string {
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/verifier/manifest"
 )
 
 func VerifyJobCreatePayload(ctx context.Context, jc *model.JobCreatePayload) error {
@@ -32,7 +34,7 @@ func VerifyJob(ctx context.Context, j *model.Job) error {
 		return fmt.Errorf("job deal is empty")
 	}
 
-	if j.Spec.Deal.Concurrency <= 0 {
+	if j.Spec.Deal.Concurrency <= 0 && !j.Spec.Deal.Broadcast {
 		return fmt.Errorf("concurrency must be >= 1")
 	}
 
@@ -56,15 +58,44 @@ func VerifyJob(ctx context.Context, j *model.Job) error {
 		return err
 	}
 
-	if j.Spec.Deal.Confidence > j.Spec.Deal.Concurrency {
+	if j.Spec.Deal.Broadcast {
+		// each node runs the job independently, so there is nothing to compare
+		if j.Spec.Verifier == model.VerifierDeterministic || j.Spec.Verifier == model.VerifierOptimistic {
+			return fmt.Errorf("the %s verifier cannot be used with broadcast jobs", strings.ToLower(j.Spec.Verifier.String()))
+		}
+	} else if j.Spec.Deal.Confidence > j.Spec.Deal.Concurrency {
 		return fmt.Errorf("the deal confidence cannot be higher than the concurrency")
 	}
 
+	if j.Spec.Deal.IncludeLateJoiners && !j.Spec.Deal.Broadcast {
+		return fmt.Errorf("only broadcast jobs can include late joining nodes")
+	}
+
+	if j.Spec.Deal.MaxBudget < 0 {
+		return fmt.Errorf("the deal budget cannot be negative")
+	}
+
+	if j.Spec.Verification.SampleRate < 0 || j.Spec.Verification.SampleRate > 1 {
+		return fmt.Errorf("the verification sample rate must be between 0 and 1")
+	}
+	if j.Spec.Verification.MaxDifferingFiles < 0 {
+		return fmt.Errorf("the number of differing files allowed by verification cannot be negative")
+	}
+	if err := manifest.ValidatePatterns(j.Spec.Verification.IgnorePaths); err != nil {
+		return err
+	}
+
 	for _, inputVolume := range j.Spec.Inputs {
 		if !model.IsValidStorageSourceType(inputVolume.StorageSource) {
 			return fmt.Errorf("invalid input volume type: %s", inputVolume.StorageSource.String())
 		}
 	}
 
+	for _, notification := range j.Spec.Notifications {
+		if err := notification.IsValid(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
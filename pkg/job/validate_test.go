@@ -0,0 +1,72 @@
+//go:build unit || !integration
+
+package job
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyBroadcastJob(t *testing.T) {
+	ctx := context.Background()
+	newBroadcastJob := func() *model.Job {
+		j, err := model.NewJobWithSaneProductionDefaults()
+		require.NoError(t, err)
+		j.Spec.Deal = model.Deal{Broadcast: true}
+		return j
+	}
+
+	// concurrency doesn't matter for broadcast jobs
+	require.NoError(t, VerifyJob(ctx, newBroadcastJob()))
+
+	j := newBroadcastJob()
+	j.Spec.Deal.IncludeLateJoiners = true
+	require.NoError(t, VerifyJob(ctx, j))
+
+	j = newBroadcastJob()
+	j.Spec.Verifier = model.VerifierDeterministic
+	require.ErrorContains(t, VerifyJob(ctx, j), "deterministic verifier")
+
+	j = newBroadcastJob()
+	j.Spec.Deal = model.Deal{Concurrency: 1, IncludeLateJoiners: true}
+	require.ErrorContains(t, VerifyJob(ctx, j), "late joining")
+}
+
+func TestVerifyJobBudget(t *testing.T) {
+	ctx := context.Background()
+	j, err := model.NewJobWithSaneProductionDefaults()
+	require.NoError(t, err)
+
+	j.Spec.Deal.MaxBudget = 10
+	require.NoError(t, VerifyJob(ctx, j))
+
+	j.Spec.Deal.MaxBudget = -1
+	require.ErrorContains(t, VerifyJob(ctx, j), "budget")
+}
+
+func TestVerifyJobVerification(t *testing.T) {
+	ctx := context.Background()
+	newJob := func(config model.VerificationConfig) *model.Job {
+		j, err := model.NewJobWithSaneProductionDefaults()
+		require.NoError(t, err)
+		j.Spec.Verifier = model.VerifierOptimistic
+		j.Spec.Verification = config
+		return j
+	}
+
+	require.NoError(t, VerifyJob(ctx, newJob(model.VerificationConfig{
+		SampleRate:        0.1,
+		IgnorePaths:       []string{"*.log"},
+		MaxDifferingFiles: 1,
+	})))
+	require.ErrorContains(t, VerifyJob(ctx, newJob(model.VerificationConfig{SampleRate: 1.5})), "sample rate")
+	require.ErrorContains(t, VerifyJob(ctx, newJob(model.VerificationConfig{MaxDifferingFiles: -1})), "differing files")
+	require.ErrorContains(t, VerifyJob(ctx, newJob(model.VerificationConfig{IgnorePaths: []string{"[a"}})), "pattern")
+
+	j := newJob(model.VerificationConfig{})
+	j.Spec.Deal = model.Deal{Broadcast: true}
+	require.ErrorContains(t, VerifyJob(ctx, j), "optimistic verifier")
+}
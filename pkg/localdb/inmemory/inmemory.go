@@ -2,6 +2,7 @@ package inmemory
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"time"
 
@@ -24,6 +25,8 @@ type InMemoryDatastore struct {
 	states      map[string]*model.JobState
 	events      map[string][]model.JobEvent
 	localEvents map[string][]model.JobLocalEvent
+	deliveries  map[string][]model.NotificationDelivery
+	schedules   map[string]model.JobSchedule
 	mtx         sync.RWMutex
 }
 
@@ -33,6 +36,8 @@ func NewInMemoryDatastore() (*InMemoryDatastore, error) {
 		states:      map[string]*model.JobState{},
 		events:      map[string][]model.JobEvent{},
 		localEvents: map[string][]model.JobLocalEvent{},
+		deliveries:  map[string][]model.NotificationDelivery{},
+		schedules:   map[string]model.JobSchedule{},
 	}
 	res.mtx.EnableTracerWithOpts(sync.Opts{
 		Threshold: 10 * time.Millisecond,
@@ -114,11 +119,15 @@ func (d *InMemoryDatastore) GetJobs(ctx context.Context, query localdb.JobQuery)
 		return []*model.Job{j}, nil
 	}
 
-	for _, j := range maps.Values(d.jobs) {
-		if query.Limit > 0 && len(result) == query.Limit {
-			break
+	var cursor localdb.JobCursor
+	if query.Cursor != "" {
+		var err error
+		if cursor, err = localdb.ParseJobCursor(query.Cursor); err != nil {
+			return nil, err
 		}
+	}
 
+	for _, j := range maps.Values(d.jobs) {
 		if !query.ReturnAll && query.ClientID != "" && query.ClientID != j.Metadata.ClientID {
 			// Job is not for the requesting client, so ignore it.
 			continue
@@ -138,7 +147,11 @@ func (d *InMemoryDatastore) GetJobs(ctx context.Context, query localdb.JobQuery)
 			}
 		}
 
-		if !included {
+		if !included || !d.matchesFilters(j, query) {
+			continue
+		}
+
+		if query.Cursor != "" && !cursor.IsAfter(j, query.SortBy, query.SortReverse) {
 			continue
 		}
 
@@ -147,27 +160,72 @@ func (d *InMemoryDatastore) GetJobs(ctx context.Context, query localdb.JobQuery)
 
 	listSorter := func(i, j int) bool {
 		switch query.SortBy {
-		case "id":
+		case localdb.SortByID:
 			if query.SortReverse {
 				// what does it mean to sort by ID?
 				return result[i].Metadata.ID > result[j].Metadata.ID
 			} else {
 				return result[i].Metadata.ID < result[j].Metadata.ID
 			}
-		case "created_at":
+		case localdb.SortByCreatedAt, "":
+			// jobs created in the same second are ordered by ID, as they are
+			// for cursors
+			iCreated := localdb.CursorTime(result[i].Metadata.CreatedAt)
+			jCreated := localdb.CursorTime(result[j].Metadata.CreatedAt)
+			if iCreated.Equal(jCreated) {
+				return (result[i].Metadata.ID < result[j].Metadata.ID) != query.SortReverse
+			}
 			if query.SortReverse {
-				return result[i].Metadata.CreatedAt.UTC().Unix() > result[j].Metadata.CreatedAt.UTC().Unix()
+				return iCreated.After(jCreated)
 			} else {
-				return result[i].Metadata.CreatedAt.UTC().Unix() < result[j].Metadata.CreatedAt.UTC().Unix()
+				return iCreated.Before(jCreated)
 			}
 		default:
 			return false
 		}
 	}
 	sort.Slice(result, listSorter)
+
+	if query.Offset > 0 {
+		result = result[system.Min(query.Offset, len(result)):]
+	}
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
 	return result, nil
 }
 
+// matchesFilters returns true if the job matches the state, engine, creation
+// time and annotation filters of the query.
+func (d *InMemoryDatastore) matchesFilters(j *model.Job, query localdb.JobQuery) bool {
+	if model.IsValidEngine(query.Engine) && j.Spec.Engine != query.Engine {
+		return false
+	}
+	// compare to the second, as the SQL datastores do
+	createdAt := localdb.CursorTime(j.Metadata.CreatedAt)
+	if !query.CreatedAfter.IsZero() && createdAt.Before(localdb.CursorTime(query.CreatedAfter)) {
+		return false
+	}
+	if !query.CreatedBefore.IsZero() && createdAt.After(localdb.CursorTime(query.CreatedBefore)) {
+		return false
+	}
+	for key, value := range query.Annotations {
+		if !localdb.MatchesAnnotation(j.Spec.Annotations, key, value) {
+			return false
+		}
+	}
+	if len(query.States) > 0 {
+		var state model.JobState
+		if s, ok := d.states[j.Metadata.ID]; ok {
+			state = *s
+		}
+		if !slices.Contains(query.States, jobutils.ComputeJobState(state)) {
+			return false
+		}
+	}
+	return true
+}
+
 func (d *InMemoryDatastore) GetJobsCount(ctx context.Context, query localdb.JobQuery) (int, error) {
 	useQuery := query
 	useQuery.Limit = 0
@@ -318,6 +376,130 @@ func (d *InMemoryDatastore) UpdateShardState(
 	return nil
 }
 
+func (d *InMemoryDatastore) AddNotificationDelivery(ctx context.Context, jobID string, delivery model.NotificationDelivery) error {
+	//nolint:ineffassign,staticcheck
+	ctx, span := system.GetTracer().Start(ctx, "pkg/localdb/inmemory/InMemoryDatastore.AddNotificationDelivery")
+	defer span.End()
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	_, ok := d.jobs[jobID]
+	if !ok {
+		return bacerrors.NewJobNotFound(jobID)
+	}
+	d.deliveries[jobID] = append(d.deliveries[jobID], delivery)
+	return nil
+}
+
+func (d *InMemoryDatastore) GetNotificationDeliveries(ctx context.Context, jobID string) ([]model.NotificationDelivery, error) {
+	//nolint:ineffassign,staticcheck
+	ctx, span := system.GetTracer().Start(ctx, "pkg/localdb/inmemory/InMemoryDatastore.GetNotificationDeliveries")
+	defer span.End()
+
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	_, ok := d.jobs[jobID]
+	if !ok {
+		return nil, bacerrors.NewJobNotFound(jobID)
+	}
+	return slices.Clone(d.deliveries[jobID]), nil
+}
+
+func (d *InMemoryDatastore) AddJobSchedule(ctx context.Context, schedule model.JobSchedule) error {
+	//nolint:ineffassign,staticcheck
+	ctx, span := system.GetTracer().Start(ctx, "pkg/localdb/inmemory/InMemoryDatastore.AddJobSchedule")
+	defer span.End()
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if _, ok := d.schedules[schedule.ID]; ok {
+		return fmt.Errorf("schedule %s already exists", schedule.ID)
+	}
+	d.schedules[schedule.ID] = cloneSchedule(schedule)
+	return nil
+}
+
+// Errors:
+//
+//   - error-schedule-not-found        		  -- if the schedule is not found
+func (d *InMemoryDatastore) GetJobSchedule(ctx context.Context, id string) (model.JobSchedule, error) {
+	//nolint:ineffassign,staticcheck
+	ctx, span := system.GetTracer().Start(ctx, "pkg/localdb/inmemory/InMemoryDatastore.GetJobSchedule")
+	defer span.End()
+
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	schedule, ok := d.schedules[id]
+	if !ok {
+		return model.JobSchedule{}, bacerrors.NewScheduleNotFound(id)
+	}
+	return cloneSchedule(schedule), nil
+}
+
+func (d *InMemoryDatastore) GetJobSchedules(ctx context.Context, clientID string) ([]model.JobSchedule, error) {
+	//nolint:ineffassign,staticcheck
+	ctx, span := system.GetTracer().Start(ctx, "pkg/localdb/inmemory/InMemoryDatastore.GetJobSchedules")
+	defer span.End()
+
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	schedules := []model.JobSchedule{}
+	for _, schedule := range d.schedules {
+		if clientID == "" || schedule.ClientID == clientID {
+			schedules = append(schedules, cloneSchedule(schedule))
+		}
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		if !schedules[i].CreatedAt.Equal(schedules[j].CreatedAt) {
+			return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+		}
+		return schedules[i].ID < schedules[j].ID
+	})
+	return schedules, nil
+}
+
+// Errors:
+//
+//   - error-schedule-not-found        		  -- if the schedule is not found
+func (d *InMemoryDatastore) UpdateJobSchedule(ctx context.Context, schedule model.JobSchedule) error {
+	//nolint:ineffassign,staticcheck
+	ctx, span := system.GetTracer().Start(ctx, "pkg/localdb/inmemory/InMemoryDatastore.UpdateJobSchedule")
+	defer span.End()
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if _, ok := d.schedules[schedule.ID]; !ok {
+		return bacerrors.NewScheduleNotFound(schedule.ID)
+	}
+	d.schedules[schedule.ID] = cloneSchedule(schedule)
+	return nil
+}
+
+// Errors:
+//
+//   - error-schedule-not-found        		  -- if the schedule is not found
+func (d *InMemoryDatastore) DeleteJobSchedule(ctx context.Context, id string) error {
+	//nolint:ineffassign,staticcheck
+	ctx, span := system.GetTracer().Start(ctx, "pkg/localdb/inmemory/InMemoryDatastore.DeleteJobSchedule")
+	defer span.End()
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if _, ok := d.schedules[id]; !ok {
+		return bacerrors.NewScheduleNotFound(id)
+	}
+	delete(d.schedules, id)
+	return nil
+}
+
+// cloneSchedule copies the slices of a schedule that change as it runs, so
+// callers can't change the stored schedule.
+func cloneSchedule(schedule model.JobSchedule) model.JobSchedule {
+	schedule.JobIDs = slices.Clone(schedule.JobIDs)
+	schedule.ActiveJobIDs = slices.Clone(schedule.ActiveJobIDs)
+	return schedule
+}
+
 // helper method to read a single job from memory. This is used by both GetJob and GetJobs.
 // It is important that we don't attempt to acquire a lock inside this method to avoid deadlocks since
 // the callers are expected to be holding a lock, and golang doesn't support reentrant locks.
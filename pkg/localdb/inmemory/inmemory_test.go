@@ -4,8 +4,11 @@ package inmemory
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/filecoin-project/bacalhau/pkg/localdb"
 	_ "github.com/filecoin-project/bacalhau/pkg/logger"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/stretchr/testify/require"
@@ -79,3 +82,69 @@ func TestInMemoryDataStore(t *testing.T) {
 	require.Equal(t, model.JobStateBidding, shardState.State)
 	require.Equal(t, "hello", shardState.Status)
 }
+
+func TestInMemoryDataStoreQueries(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewInMemoryDatastore()
+	require.NoError(t, err)
+
+	date := time.Date(2021, 11, 22, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		engine := model.EngineDocker
+		if i%2 == 1 {
+			engine = model.EngineWasm
+		}
+		job := &model.Job{
+			Metadata: model.Metadata{
+				ID:        fmt.Sprintf("job%d", i),
+				CreatedAt: date.Add(time.Duration(i) * time.Hour),
+			},
+			Spec: model.Spec{
+				Engine:      engine,
+				Annotations: []string{fmt.Sprintf("team:%d", i%2)},
+			},
+		}
+		require.NoError(t, store.AddJob(ctx, job))
+		state := model.JobStateCompleted
+		if i < 2 {
+			state = model.JobStateError
+		}
+		require.NoError(t, store.UpdateShardState(ctx, job.Metadata.ID, "node", 0, model.JobShardState{
+			NodeID: "node",
+			State:  state,
+		}))
+	}
+
+	ids := func(query localdb.JobQuery) []string {
+		jobs, err := store.GetJobs(ctx, query)
+		require.NoError(t, err)
+		var res []string
+		for _, j := range jobs {
+			res = append(res, j.Metadata.ID)
+		}
+		return res
+	}
+
+	require.Equal(t, []string{"job0", "job1"}, ids(localdb.JobQuery{
+		States: []model.JobStateType{model.JobStateError},
+	}))
+	require.Equal(t, []string{"job1"}, ids(localdb.JobQuery{
+		States: []model.JobStateType{model.JobStateError},
+		Engine: model.EngineWasm,
+	}))
+	require.Equal(t, []string{"job4", "job5"}, ids(localdb.JobQuery{
+		CreatedAfter: date.Add(4 * time.Hour),
+	}))
+	require.Equal(t, []string{"job1", "job3", "job5"}, ids(localdb.JobQuery{
+		Annotations: map[string]string{"team": "1"},
+	}))
+
+	firstPage := ids(localdb.JobQuery{Limit: 4, SortBy: localdb.SortByID, SortReverse: true})
+	require.Equal(t, []string{"job5", "job4", "job3", "job2"}, firstPage)
+	require.Equal(t, []string{"job1", "job0"}, ids(localdb.JobQuery{
+		Limit:       4,
+		SortBy:      localdb.SortByID,
+		SortReverse: true,
+		Cursor:      localdb.NewJobCursor(&model.Job{Metadata: model.Metadata{ID: "job2"}}),
+	}))
+}
@@ -0,0 +1,89 @@
+package localdb
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	SortByID        = "id"
+	SortByCreatedAt = "created_at"
+
+	// AnnotationSeparator separates the key and value of annotations that are
+	// matched by JobQuery.Annotations.
+	AnnotationSeparator = ":"
+)
+
+// JobCursor is where a page of jobs ended. Jobs are ordered by their creation
+// time to the second and then by ID, so pages stay consistent even if jobs
+// are added between requests.
+type JobCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+}
+
+// NewJobCursor returns an opaque cursor that continues a query after the job.
+func NewJobCursor(j *model.Job) string {
+	//nolint:errchkjson // marshalling a time and a string can't fail
+	data, _ := json.Marshal(JobCursor{
+		CreatedAt: CursorTime(j.Metadata.CreatedAt),
+		ID:        j.Metadata.ID,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseJobCursor decodes a cursor made by NewJobCursor.
+func ParseJobCursor(cursor string) (JobCursor, error) {
+	var res JobCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return res, fmt.Errorf("invalid cursor %q: %w", cursor, err)
+	}
+	if err = json.Unmarshal(data, &res); err != nil {
+		return res, fmt.Errorf("invalid cursor %q: %w", cursor, err)
+	}
+	return res, nil
+}
+
+// CursorTime truncates a creation time to the precision jobs are sorted by.
+func CursorTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+// IsAfter returns true if the job comes after the cursor when sorting by
+// sortBy, or before it if reverse is set.
+func (c JobCursor) IsAfter(j *model.Job, sortBy string, reverse bool) bool {
+	cmp := strings.Compare(j.Metadata.ID, c.ID)
+	if sortBy != SortByID {
+		createdAt := CursorTime(j.Metadata.CreatedAt)
+		if createdAt.Before(c.CreatedAt) {
+			cmp = -1
+		} else if createdAt.After(c.CreatedAt) {
+			cmp = 1
+		}
+	}
+	if reverse {
+		return cmp < 0
+	}
+	return cmp > 0
+}
+
+// MatchesAnnotation returns true if the annotations include key:value, or
+// any annotation for key if value is empty.
+func MatchesAnnotation(annotations []string, key, value string) bool {
+	if value != "" {
+		return slices.Contains(annotations, key+AnnotationSeparator+value)
+	}
+	for _, annotation := range annotations {
+		if annotation == key || strings.HasPrefix(annotation, key+AnnotationSeparator) {
+			return true
+		}
+	}
+	return false
+}
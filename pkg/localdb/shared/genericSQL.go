@@ -12,6 +12,7 @@ import (
 	"database/sql"
 
 	"github.com/filecoin-project/bacalhau/pkg/bacerrors"
+	jobutils "github.com/filecoin-project/bacalhau/pkg/job"
 	"github.com/filecoin-project/bacalhau/pkg/localdb"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/system"
@@ -28,6 +29,16 @@ type SQLClient interface {
 	Query(query string, args ...any) (*sql.Rows, error)
 }
 
+// jobAPIVersion is the API version jobs are stored with. Rows written with older versions are converted to it the
+// next time they are updated.
+const jobAPIVersion = model.V1beta2
+
+// marshalJob encodes a job the way it is stored.
+func marshalJob(j *model.Job) (string, error) {
+	jobData, err := json.Marshal(model.ConvertToV1beta2Job(*j))
+	return string(jobData), err
+}
+
 type GenericSQLDatastore struct {
 	mtx              sync.RWMutex
 	name             string
@@ -135,21 +146,82 @@ func getJobsSQL(
 		args = append(args, query.ClientID)
 	}
 
-	after := ""
+	if len(query.States) > 0 {
+		placeholders := make([]string, 0, len(query.States))
+		for _, state := range query.States {
+			placeholders = append(placeholders, getQueryCounter())
+			args = append(args, state.String())
+		}
+		clauses = append(clauses, fmt.Sprintf("job.state in (%s)", strings.Join(placeholders, ", ")))
+	}
 
-	applyOrdering := func(field string) {
-		order := "asc"
-		if query.SortReverse {
-			order = "desc"
+	if model.IsValidEngine(query.Engine) {
+		clauses = append(clauses, fmt.Sprintf("job.executor = %s", getQueryCounter()))
+		args = append(args, query.Engine.String())
+	}
+
+	if !query.CreatedAfter.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("job.created >= %s", getQueryCounter()))
+		args = append(args, formatTime(query.CreatedAfter))
+	}
+
+	if !query.CreatedBefore.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("job.created <= %s", getQueryCounter()))
+		args = append(args, formatTime(query.CreatedBefore))
+	}
+
+	for key, value := range query.Annotations {
+		if value != "" {
+			handleTag(key+localdb.AnnotationSeparator+value, true)
+			continue
+		}
+		prefix := key + localdb.AnnotationSeparator
+		clauses = append(clauses, fmt.Sprintf(`
+		(
+			select count(*) from job_annotation
+			where (job_annotation.annotation = %s or substr(job_annotation.annotation, 1, %s) = %s)
+			and job_annotation.job_id = job.id
+		) > 0
+		`, getQueryCounter(), getQueryCounter(), getQueryCounter()))
+		args = append(args, key, len(prefix), prefix)
+	}
+
+	comparison := ">"
+	if query.SortReverse {
+		comparison = "<"
+	}
+	if query.Cursor != "" {
+		cursor, err := localdb.ParseJobCursor(query.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		if query.SortBy == localdb.SortByID {
+			clauses = append(clauses, fmt.Sprintf("job.id %s %s", comparison, getQueryCounter()))
+			args = append(args, cursor.ID)
+		} else {
+			clauses = append(clauses, fmt.Sprintf("(job.created %s %s or (job.created = %s and job.id %s %s))",
+				comparison, getQueryCounter(), getQueryCounter(), comparison, getQueryCounter()))
+			args = append(args, formatTime(cursor.CreatedAt), formatTime(cursor.CreatedAt), cursor.ID)
 		}
-		after = after + " order by " + field + " " + order
 	}
 
-	if query.SortBy == "created_at" {
-		applyOrdering("created")
-	} else if query.SortBy == "id" {
-		applyOrdering("id")
-	} else if query.SortBy != "" {
+	after := ""
+
+	order := "asc"
+	if query.SortReverse {
+		order = "desc"
+	}
+
+	switch {
+	case countMode:
+		// counts aren't ordered
+	case query.SortBy == localdb.SortByCreatedAt || query.SortBy == "":
+		// jobs created in the same second are ordered by ID, as they are for
+		// cursors
+		after = after + " order by created " + order + ", id " + order
+	case query.SortBy == localdb.SortByID:
+		after = after + " order by id " + order
+	default:
 		return "", nil, fmt.Errorf("invalid sort_by: %s", query.SortBy)
 	}
 
@@ -259,6 +331,7 @@ func (d *GenericSQLDatastore) GetJobsCount(ctx context.Context, query localdb.Jo
 	useQuery.Limit = 0
 	useQuery.Offset = 0
 	useQuery.SortBy = ""
+	useQuery.Cursor = ""
 
 	sqlQuery, args, err := getJobsSQL(useQuery, true)
 	if err != nil {
@@ -399,18 +472,18 @@ func (d *GenericSQLDatastore) AddJob(ctx context.Context, j *model.Job) error {
 	sqlStatement := `
 INSERT INTO job (id, created, executor, clientid, apiversion, jobdata)
 VALUES ($1, $2, $3, $4, $5, $6)`
-	jobData, err := json.Marshal(j)
+	jobData, err := marshalJob(j)
 	if err != nil {
 		return err
 	}
 	_, err = tx.Exec(
 		sqlStatement,
 		j.Metadata.ID,
-		j.Metadata.CreatedAt.UTC().Format(time.RFC3339),
+		formatTime(j.Metadata.CreatedAt),
 		j.Spec.Engine.String(),
 		j.Metadata.ClientID,
-		model.APIVersionLatest().String(),
-		string(jobData),
+		jobAPIVersion.String(),
+		jobData,
 	)
 	if err != nil {
 		return err
@@ -448,7 +521,7 @@ VALUES ($1, $2, $3, $4)`
 		sqlStatement,
 		jobID,
 		ev.EventTime.UTC().Format(time.RFC3339),
-		model.APIVersionLatest().String(),
+		model.APIVersionModel().String(),
 		string(eventData),
 	)
 	if err != nil {
@@ -474,7 +547,7 @@ VALUES ($1, $2, $3, $4)`
 		sqlStatement,
 		jobID,
 		time.Now().UTC().Format(time.RFC3339),
-		model.APIVersionLatest().String(),
+		model.APIVersionModel().String(),
 		string(eventData),
 	)
 	if err != nil {
@@ -502,14 +575,14 @@ func (d *GenericSQLDatastore) UpdateJobDeal(ctx context.Context, jobID string, d
 	}
 	job.Spec.Deal = deal
 	sqlStatement := `UPDATE JOB SET jobdata = $1, apiversion = $2 WHERE id = $3`
-	jobData, err := json.Marshal(job)
+	jobData, err := marshalJob(job)
 	if err != nil {
 		return err
 	}
 	_, err = tx.Exec(
 		sqlStatement,
-		string(jobData),
-		model.APIVersionLatest().String(),
+		jobData,
+		jobAPIVersion.String(),
 		jobID,
 	)
 	if err != nil {
@@ -565,6 +638,15 @@ func (d *GenericSQLDatastore) UpdateShardState(
 	//nolint:errcheck
 	defer tx.Rollback()
 
+	// the job is written again with the state, as the API version of the row applies to both
+	job, err := getJob(tx, ctx, jobID)
+	if err != nil {
+		return err
+	}
+	jobData, err := marshalJob(job)
+	if err != nil {
+		return err
+	}
 	state, err := getJobState(tx, ctx, jobID)
 	if err != nil {
 		return err
@@ -573,15 +655,22 @@ func (d *GenericSQLDatastore) UpdateShardState(
 	if err != nil {
 		return err
 	}
-	sqlStatement := `UPDATE JOB SET statedata = $1, apiversion = $2 WHERE id = $3`
+	sqlStatement := `UPDATE JOB SET jobdata = $1, statedata = $2, apiversion = $3, state = $4 WHERE id = $5`
 	stateData, err := json.Marshal(state)
 	if err != nil {
 		return err
 	}
+	jobState := jobutils.ComputeJobState(state)
+	stateColumn := ""
+	if model.IsValidJobState(jobState) {
+		stateColumn = jobState.String()
+	}
 	_, err = tx.Exec(
 		sqlStatement,
+		jobData,
 		string(stateData),
-		model.APIVersionLatest().String(),
+		jobAPIVersion.String(),
+		stateColumn,
 		jobID,
 	)
 	if err != nil {
@@ -590,6 +679,191 @@ func (d *GenericSQLDatastore) UpdateShardState(
 	return tx.Commit()
 }
 
+func (d *GenericSQLDatastore) AddNotificationDelivery(ctx context.Context, jobID string, delivery model.NotificationDelivery) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	//nolint:ineffassign,staticcheck
+	ctx, span := d.GetSpan(ctx, "AddNotificationDelivery")
+	defer span.End()
+	sqlStatement := `
+INSERT INTO notification_delivery (job_id, created, deliverydata)
+VALUES ($1, $2, $3)`
+	deliveryData, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec(
+		sqlStatement,
+		jobID,
+		formatTime(delivery.Time),
+		string(deliveryData),
+	)
+	return err
+}
+
+func (d *GenericSQLDatastore) GetNotificationDeliveries(ctx context.Context, jobID string) ([]model.NotificationDelivery, error) {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	//nolint:ineffassign,staticcheck
+	ctx, span := d.GetSpan(ctx, "GetNotificationDeliveries")
+	defer span.End()
+
+	rows, err := d.db.Query(`
+select
+	deliverydata
+from
+	notification_delivery
+where
+	job_id = $1
+order by
+	created asc, id asc
+`, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var deliveries []model.NotificationDelivery
+	for rows.Next() {
+		var deliveryData string
+		var delivery model.NotificationDelivery
+		if err = rows.Scan(&deliveryData); err != nil {
+			return deliveries, err
+		}
+		if err = json.Unmarshal([]byte(deliveryData), &delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err = rows.Err(); err != nil {
+		return deliveries, err
+	}
+	return deliveries, nil
+}
+
+func (d *GenericSQLDatastore) AddJobSchedule(ctx context.Context, schedule model.JobSchedule) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	//nolint:ineffassign,staticcheck
+	ctx, span := d.GetSpan(ctx, "AddJobSchedule")
+	defer span.End()
+	scheduleData, err := json.Marshal(schedule)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec(`
+INSERT INTO job_schedule (id, client_id, created, scheduledata)
+VALUES ($1, $2, $3, $4)`,
+		schedule.ID,
+		schedule.ClientID,
+		formatTime(schedule.CreatedAt),
+		string(scheduleData),
+	)
+	return err
+}
+
+func (d *GenericSQLDatastore) GetJobSchedule(ctx context.Context, id string) (model.JobSchedule, error) {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	//nolint:ineffassign,staticcheck
+	ctx, span := d.GetSpan(ctx, "GetJobSchedule")
+	defer span.End()
+	var scheduleData string
+	row := d.db.QueryRow("select scheduledata from job_schedule where id = $1 limit 1", id)
+	if err := row.Scan(&scheduleData); err != nil {
+		if err == sql.ErrNoRows {
+			return model.JobSchedule{}, bacerrors.NewScheduleNotFound(id)
+		}
+		return model.JobSchedule{}, err
+	}
+	var schedule model.JobSchedule
+	err := json.Unmarshal([]byte(scheduleData), &schedule)
+	return schedule, err
+}
+
+func (d *GenericSQLDatastore) GetJobSchedules(ctx context.Context, clientID string) ([]model.JobSchedule, error) {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	//nolint:ineffassign,staticcheck
+	ctx, span := d.GetSpan(ctx, "GetJobSchedules")
+	defer span.End()
+
+	sqlStatement := `select scheduledata from job_schedule`
+	var args []interface{}
+	if clientID != "" {
+		sqlStatement += ` where client_id = $1`
+		args = append(args, clientID)
+	}
+	rows, err := d.db.Query(sqlStatement+` order by created asc, id asc`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	schedules := []model.JobSchedule{}
+	for rows.Next() {
+		var scheduleData string
+		var schedule model.JobSchedule
+		if err = rows.Scan(&scheduleData); err != nil {
+			return schedules, err
+		}
+		if err = json.Unmarshal([]byte(scheduleData), &schedule); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	if err = rows.Err(); err != nil {
+		return schedules, err
+	}
+	return schedules, nil
+}
+
+func (d *GenericSQLDatastore) UpdateJobSchedule(ctx context.Context, schedule model.JobSchedule) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	//nolint:ineffassign,staticcheck
+	ctx, span := d.GetSpan(ctx, "UpdateJobSchedule")
+	defer span.End()
+	scheduleData, err := json.Marshal(schedule)
+	if err != nil {
+		return err
+	}
+	result, err := d.db.Exec(`UPDATE job_schedule SET scheduledata = $1 WHERE id = $2`, string(scheduleData), schedule.ID)
+	if err != nil {
+		return err
+	}
+	return scheduleExists(result, schedule.ID)
+}
+
+func (d *GenericSQLDatastore) DeleteJobSchedule(ctx context.Context, id string) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	//nolint:ineffassign,staticcheck
+	ctx, span := d.GetSpan(ctx, "DeleteJobSchedule")
+	defer span.End()
+	result, err := d.db.Exec(`DELETE FROM job_schedule WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return scheduleExists(result, id)
+}
+
+// scheduleExists returns an error if a statement didn't affect the schedule.
+func scheduleExists(result sql.Result, id string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return bacerrors.NewScheduleNotFound(id)
+	}
+	return nil
+}
+
+// formatTime formats times as they are stored in the job table, which sorts
+// and compares them as strings in SQLite.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
 //go:embed migrations/*.sql
 var fs embed.FS
 
@@ -611,10 +885,54 @@ func (d *GenericSQLDatastore) MigrateUp() error {
 		return err
 	}
 	err = migrations.Up()
-	if err != migrate.ErrNoChange {
+	if err != nil && err != migrate.ErrNoChange {
 		return err
 	}
-	return nil
+	return d.backfillJobStates()
+}
+
+// backfillJobStates sets the state column of jobs stored before it was added,
+// which can only be derived from their shard states in Go.
+func (d *GenericSQLDatastore) backfillJobStates() error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`select id, apiversion, statedata from job where state = '' and statedata != ''`)
+	if err != nil {
+		return err
+	}
+	states := make(map[string]string)
+	for rows.Next() {
+		var id, apiversion, statedata string
+		if err = rows.Scan(&id, &apiversion, &statedata); err != nil {
+			rows.Close()
+			return err
+		}
+		state, parseErr := model.APIVersionParseJobState(apiversion, statedata)
+		if parseErr != nil {
+			rows.Close()
+			return fmt.Errorf("failed to parse state of job %s: %w", id, parseErr)
+		}
+		if jobState := jobutils.ComputeJobState(state); model.IsValidJobState(jobState) {
+			states[id] = jobState.String()
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	for id, state := range states {
+		if _, err = tx.Exec(`UPDATE JOB SET state = $1 WHERE id = $2`, state, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 func (d *GenericSQLDatastore) MigrateDown() error {
@@ -0,0 +1,3 @@
+drop index idx_job_created;
+drop index idx_job_state;
+alter table job drop column state;
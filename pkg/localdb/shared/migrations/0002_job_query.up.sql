@@ -0,0 +1,6 @@
+-- the state of each job as a whole, derived from its shard states so jobs can
+-- be queried by state. The state of existing jobs is backfilled by
+-- GenericSQLDatastore.MigrateUp, as it can't be derived in SQL.
+alter table job add column state varchar(255) default '';
+CREATE INDEX idx_job_state ON job (state);
+CREATE INDEX idx_job_created ON job (created);
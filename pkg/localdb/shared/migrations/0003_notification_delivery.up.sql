@@ -0,0 +1,8 @@
+create table notification_delivery (
+  id SERIAL PRIMARY KEY,
+  job_id varchar(255),
+  created timestamp,
+  deliverydata text,
+  FOREIGN KEY(job_id) REFERENCES job(id)
+);
+CREATE INDEX idx_notification_delivery_job_id ON notification_delivery (job_id);
@@ -0,0 +1,7 @@
+create table job_schedule (
+  id varchar(255) PRIMARY KEY,
+  client_id varchar(255),
+  created timestamp,
+  scheduledata text
+);
+CREATE INDEX idx_job_schedule_client_id ON job_schedule (client_id);
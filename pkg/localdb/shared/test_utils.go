@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/filecoin-project/bacalhau/pkg/bacerrors"
 	"github.com/filecoin-project/bacalhau/pkg/localdb"
 	_ "github.com/filecoin-project/bacalhau/pkg/logger"
 	"github.com/filecoin-project/bacalhau/pkg/model"
@@ -358,3 +359,188 @@ func (suite *GenericSQLSuite) TestUpdateDeal() {
 	require.NoError(suite.T(), err)
 	require.Equal(suite.T(), 3, updatedJob.Spec.Deal.Concurrency)
 }
+
+//nolint:funlen
+func (suite *GenericSQLSuite) TestGetJobsFilters() {
+	skipIfNotLinux(suite.T())
+	ctx := context.Background()
+	date := time.Date(2021, 11, 22, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		engine := model.EngineDocker
+		if i%2 == 1 {
+			engine = model.EngineWasm
+		}
+		job := &model.Job{
+			Metadata: model.Metadata{
+				ID:        fmt.Sprintf("hellojob%d", i),
+				CreatedAt: date.Add(time.Duration(i) * time.Hour),
+			},
+			Spec: model.Spec{
+				Engine:      engine,
+				Annotations: []string{fmt.Sprintf("team:%d", i%3)},
+			},
+		}
+		require.NoError(suite.T(), suite.datastore.AddJob(ctx, job))
+		state := model.JobStateRunning
+		if i < 3 {
+			state = model.JobStateError
+		}
+		require.NoError(suite.T(), suite.datastore.UpdateShardState(ctx, job.Metadata.ID, "node", 0, model.JobShardState{
+			NodeID: "node",
+			State:  state,
+		}))
+	}
+
+	ids := func(jobs []*model.Job) []string {
+		var res []string
+		for _, j := range jobs {
+			res = append(res, j.Metadata.ID)
+		}
+		return res
+	}
+
+	failed, err := suite.datastore.GetJobs(ctx, localdb.JobQuery{
+		States: []model.JobStateType{model.JobStateError},
+	})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), []string{"hellojob0", "hellojob1", "hellojob2"}, ids(failed))
+
+	failedDocker, err := suite.datastore.GetJobs(ctx, localdb.JobQuery{
+		States: []model.JobStateType{model.JobStateError},
+		Engine: model.EngineDocker,
+	})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), []string{"hellojob0", "hellojob2"}, ids(failedDocker))
+
+	timeRange, err := suite.datastore.GetJobs(ctx, localdb.JobQuery{
+		CreatedAfter:  date.Add(2 * time.Hour),
+		CreatedBefore: date.Add(4 * time.Hour),
+	})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), []string{"hellojob2", "hellojob3", "hellojob4"}, ids(timeRange))
+
+	annotated, err := suite.datastore.GetJobs(ctx, localdb.JobQuery{
+		Annotations: map[string]string{"team": "1"},
+	})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), []string{"hellojob1", "hellojob4", "hellojob7"}, ids(annotated))
+
+	anyTeam, err := suite.datastore.GetJobsCount(ctx, localdb.JobQuery{
+		Annotations: map[string]string{"team": ""},
+	})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 10, anyTeam)
+
+	// page through the jobs newest first
+	var paged []string
+	query := localdb.JobQuery{Limit: 4, SortBy: localdb.SortByCreatedAt, SortReverse: true}
+	for {
+		page, err := suite.datastore.GetJobs(ctx, query)
+		require.NoError(suite.T(), err)
+		paged = append(paged, ids(page)...)
+		if len(page) < query.Limit {
+			break
+		}
+		query.Cursor = localdb.NewJobCursor(page[len(page)-1])
+	}
+	require.Equal(suite.T(), []string{
+		"hellojob9", "hellojob8", "hellojob7", "hellojob6", "hellojob5",
+		"hellojob4", "hellojob3", "hellojob2", "hellojob1", "hellojob0",
+	}, paged)
+}
+
+func (suite *GenericSQLSuite) TestBackfillJobStates() {
+	ctx := context.Background()
+	job := &model.Job{Metadata: model.Metadata{ID: "hellojob", CreatedAt: time.Now()}}
+	require.NoError(suite.T(), suite.datastore.AddJob(ctx, job))
+	require.NoError(suite.T(), suite.datastore.UpdateShardState(ctx, job.Metadata.ID, "node", 0, model.JobShardState{
+		NodeID: "node",
+		State:  model.JobStateError,
+	}))
+
+	// as stored before the state column was added
+	_, err := suite.datastore.GetDB().Exec(`UPDATE JOB SET state = '' WHERE id = $1`, job.Metadata.ID)
+	require.NoError(suite.T(), err)
+	query := localdb.JobQuery{States: []model.JobStateType{model.JobStateError}}
+	failed, err := suite.datastore.GetJobs(ctx, query)
+	require.NoError(suite.T(), err)
+	require.Empty(suite.T(), failed)
+
+	require.NoError(suite.T(), suite.datastore.MigrateUp())
+	failed, err = suite.datastore.GetJobs(ctx, query)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), failed, 1)
+	require.Equal(suite.T(), job.Metadata.ID, failed[0].Metadata.ID)
+}
+
+func (suite *GenericSQLSuite) TestNotificationDeliveries() {
+	skipIfNotLinux(suite.T())
+	ctx := context.Background()
+	job := &model.Job{
+		Metadata: model.Metadata{
+			ID: "hellojob",
+		},
+	}
+	require.NoError(suite.T(), suite.datastore.AddJob(ctx, job))
+
+	date := time.Date(2021, 11, 22, 0, 0, 0, 0, time.UTC)
+	for attempt := 1; attempt <= 3; attempt++ {
+		err := suite.datastore.AddNotificationDelivery(ctx, job.Metadata.ID, model.NotificationDelivery{
+			JobID:     job.Metadata.ID,
+			URL:       "https://example.com/hook",
+			Event:     model.NotificationEventCompleted,
+			Attempt:   attempt,
+			Delivered: attempt == 3,
+			Time:      date.Add(time.Duration(attempt) * time.Minute),
+		})
+		require.NoError(suite.T(), err)
+	}
+
+	deliveries, err := suite.datastore.GetNotificationDeliveries(ctx, job.Metadata.ID)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), deliveries, 3)
+	require.Equal(suite.T(), 1, deliveries[0].Attempt)
+	require.False(suite.T(), deliveries[0].Delivered)
+	require.Equal(suite.T(), 3, deliveries[2].Attempt)
+	require.True(suite.T(), deliveries[2].Delivered)
+}
+
+func (suite *GenericSQLSuite) TestJobSchedules() {
+	skipIfNotLinux(suite.T())
+	ctx := context.Background()
+	date := time.Date(2021, 11, 22, 0, 0, 0, 0, time.UTC)
+	for i, clientID := range []string{"client1", "client2", "client1"} {
+		err := suite.datastore.AddJobSchedule(ctx, model.JobSchedule{
+			ID:        fmt.Sprintf("schedule%d", i),
+			ClientID:  clientID,
+			Cron:      "@hourly",
+			CreatedAt: date.Add(time.Duration(i) * time.Minute),
+		})
+		require.NoError(suite.T(), err)
+	}
+
+	all, err := suite.datastore.GetJobSchedules(ctx, "")
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), all, 3)
+	require.Equal(suite.T(), "schedule0", all[0].ID)
+
+	own, err := suite.datastore.GetJobSchedules(ctx, "client1")
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), own, 2)
+	require.Equal(suite.T(), "schedule2", own[1].ID)
+
+	schedule := own[0]
+	schedule.Paused = true
+	schedule.JobIDs = []string{"job1"}
+	require.NoError(suite.T(), suite.datastore.UpdateJobSchedule(ctx, schedule))
+	stored, err := suite.datastore.GetJobSchedule(ctx, schedule.ID)
+	require.NoError(suite.T(), err)
+	require.True(suite.T(), stored.Paused)
+	require.Equal(suite.T(), []string{"job1"}, stored.JobIDs)
+
+	require.NoError(suite.T(), suite.datastore.DeleteJobSchedule(ctx, schedule.ID))
+	_, err = suite.datastore.GetJobSchedule(ctx, schedule.ID)
+	require.IsType(suite.T(), &bacerrors.ScheduleNotFound{}, err)
+	require.IsType(suite.T(), &bacerrors.ScheduleNotFound{}, suite.datastore.DeleteJobSchedule(ctx, schedule.ID))
+	require.IsType(suite.T(), &bacerrors.ScheduleNotFound{}, suite.datastore.UpdateJobSchedule(ctx, schedule))
+}
@@ -2,6 +2,7 @@ package localdb
 
 import (
 	"context"
+	"time"
 
 	"github.com/filecoin-project/bacalhau/pkg/model"
 )
@@ -16,6 +17,20 @@ type JobQuery struct {
 	ReturnAll   bool                `json:"return_all"`
 	SortBy      string              `json:"sort_by"`
 	SortReverse bool                `json:"sort_reverse"`
+	// only return jobs in one of these states, where the state of a job is
+	// the most advanced state of its shards (see job.ComputeJobState)
+	States []model.JobStateType `json:"states,omitempty"`
+	// only return jobs for this engine
+	Engine model.Engine `json:"engine,omitempty"`
+	// only return jobs created at or after/before these times
+	CreatedAfter  time.Time `json:"created_after,omitempty"`
+	CreatedBefore time.Time `json:"created_before,omitempty"`
+	// only return jobs with these "key:value" annotations. An empty value
+	// matches any annotation with the key.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// only return jobs after the one this cursor was made from (see
+	// NewJobCursor), in the order the query sorts by
+	Cursor string `json:"cursor,omitempty"`
 }
 
 type LocalEventFilter func(ev model.JobLocalEvent) bool
@@ -45,4 +60,16 @@ type LocalDB interface {
 		shardIndex int,
 		state model.JobShardState,
 	) error
+	// AddNotificationDelivery records an attempt to deliver a job's webhook
+	// notification, and GetNotificationDeliveries returns them in order.
+	AddNotificationDelivery(ctx context.Context, jobID string, delivery model.NotificationDelivery) error
+	GetNotificationDeliveries(ctx context.Context, jobID string) ([]model.NotificationDelivery, error)
+	// AddJobSchedule stores a new recurring job schedule, and
+	// UpdateJobSchedule replaces a stored one. GetJobSchedules returns a
+	// client's schedules, or every schedule if clientID is empty, oldest first.
+	AddJobSchedule(ctx context.Context, schedule model.JobSchedule) error
+	GetJobSchedule(ctx context.Context, id string) (model.JobSchedule, error)
+	GetJobSchedules(ctx context.Context, clientID string) ([]model.JobSchedule, error)
+	UpdateJobSchedule(ctx context.Context, schedule model.JobSchedule) error
+	DeleteJobSchedule(ctx context.Context, id string) error
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/filecoin-project/bacalhau/pkg/model/v1alpha1"
+	"github.com/filecoin-project/bacalhau/pkg/model/v1beta2"
 )
 
 //go:generate stringer -type=APIVersion
@@ -14,13 +15,21 @@ const (
 	apiVersionUnknown APIVersion = iota // must be first
 	V1alpha1
 	V1beta1
+	V1beta2
 	apiVersionDone // must be last
 )
 
+// APIVersionLatest returns the latest API version jobs can be written in.
 func APIVersionLatest() APIVersion {
 	return apiVersionDone - 1
 }
 
+// APIVersionModel returns the API version of the Job model, which is the version jobs are exchanged between nodes
+// with. Documents of later versions are converted to it when they are read.
+func APIVersionModel() APIVersion {
+	return V1beta1
+}
+
 func ParseAPIVersion(str string) (APIVersion, error) {
 	for typ := apiVersionUnknown + 1; typ < apiVersionDone; typ++ {
 		if equal(typ.String(), str) {
@@ -51,6 +60,13 @@ func APIVersionParseJob(versionString string, data string) (Job, error) {
 			return Job{}, fmt.Errorf("error parsing V1beta1 Job JSON: %s", data)
 		}
 		return job, nil
+	} else if version == V1beta2 {
+		var job v1beta2.Job
+		err := json.Unmarshal([]byte(data), &job)
+		if err != nil {
+			return Job{}, fmt.Errorf("error parsing V1beta2 Job JSON: %s", data)
+		}
+		return ConvertFromV1beta2Job(job)
 	}
 	return Job{}, fmt.Errorf("unknown api version '%s'", version)
 }
@@ -67,11 +83,12 @@ func APIVersionParseJobEvent(versionString string, data string) (JobEvent, error
 			return JobEvent{}, fmt.Errorf("error parsing V1alpha1 JobEvent JSON: %s %s", err.Error(), data)
 		}
 		return ConvertV1alpha1JobEvent(oldEvent), nil
-	} else if version == V1beta1 {
+	} else if version == V1beta1 || version == V1beta2 {
+		// events are written the same way since V1beta1
 		var ev JobEvent
 		err := json.Unmarshal([]byte(data), &ev)
 		if err != nil {
-			return JobEvent{}, fmt.Errorf("error parsing V1beta1 JobEvent JSON: %s %s", err.Error(), data)
+			return JobEvent{}, fmt.Errorf("error parsing %s JobEvent JSON: %s %s", version, err.Error(), data)
 		}
 		return ev, nil
 	}
@@ -90,11 +107,11 @@ func APIVersionParseJobLocalEvent(versionString string, data string) (JobLocalEv
 			return JobLocalEvent{}, fmt.Errorf("error parsing V1alpha1 JobLocalEvent JSON: %s %s", err.Error(), data)
 		}
 		return ConvertV1alpha1JobLocalEvent(oldEvent), nil
-	} else if version == V1beta1 {
+	} else if version == V1beta1 || version == V1beta2 {
 		var ev JobLocalEvent
 		err := json.Unmarshal([]byte(data), &ev)
 		if err != nil {
-			return JobLocalEvent{}, fmt.Errorf("error parsing V1beta1 JobLocalEvent JSON: %s %s", err.Error(), data)
+			return JobLocalEvent{}, fmt.Errorf("error parsing %s JobLocalEvent JSON: %s %s", version, err.Error(), data)
 		}
 		return ev, nil
 	}
@@ -116,11 +133,11 @@ func APIVersionParseJobState(versionString string, data string) (JobState, error
 			return JobState{}, fmt.Errorf("error parsing V1alpha1 JobState JSON: %s", data)
 		}
 		return ConvertV1alpha1JobState(oldEvent), nil
-	} else if version == V1beta1 {
+	} else if version == V1beta1 || version == V1beta2 {
 		var ev JobState
 		err := json.Unmarshal([]byte(data), &ev)
 		if err != nil {
-			return JobState{}, fmt.Errorf("error parsing V1beta1 Job JSON: %s", data)
+			return JobState{}, fmt.Errorf("error parsing %s JobState JSON: %s", version, data)
 		}
 		return ev, nil
 	}
@@ -35,7 +35,12 @@ func ConvertV1alpha1Spec(
 		Engine:    Engine(data.Engine),
 		Verifier:  Verifier(data.Verifier),
 		Publisher: Publisher(data.Publisher),
-		Docker:    JobSpecDocker(data.Docker),
+		Docker: JobSpecDocker{
+			Image:                data.Docker.Image,
+			Entrypoint:           data.Docker.Entrypoint,
+			EnvironmentVariables: data.Docker.EnvironmentVariables,
+			WorkingDirectory:     data.Docker.WorkingDirectory,
+		},
 		Language: JobSpecLanguage{
 			Language:         data.Language.Language,
 			LanguageVersion:  data.Language.LanguageVersion,
@@ -60,15 +65,36 @@ func ConvertV1alpha1Spec(
 		Sharding:      JobShardingConfig(data.Sharding),
 		DoNotTrack:    data.DoNotTrack,
 		ExecutionPlan: JobExecutionPlan(executionPlan),
-		Deal:          Deal(deal),
+		Deal:          ConvertV1alpha1Deal(deal),
+	}
+}
+
+func ConvertV1alpha1Deal(deal v1alpha1.Deal) Deal {
+	return Deal{
+		Concurrency: deal.Concurrency,
+		Confidence:  deal.Confidence,
+		MinBids:     deal.MinBids,
+	}
+}
+
+func ConvertV1alpha1VerificationResult(result v1alpha1.VerificationResult) VerificationResult {
+	return VerificationResult{
+		Complete: result.Complete,
+		Result:   result.Result,
 	}
 }
 
 func ConvertV1alpha1RunCommandResult(data *v1alpha1.RunCommandResult) *RunCommandResult {
 	var runOutput *RunCommandResult
 	if data != nil {
-		converted := RunCommandResult(*data)
-		runOutput = &converted
+		runOutput = &RunCommandResult{
+			STDOUT:          data.STDOUT,
+			StdoutTruncated: data.StdoutTruncated,
+			STDERR:          data.STDERR,
+			StderrTruncated: data.StderrTruncated,
+			ExitCode:        data.ExitCode,
+			ErrorMsg:        data.ErrorMsg,
+		}
 	}
 	return runOutput
 }
@@ -80,7 +106,7 @@ func ConvertV1alpha1ShardState(data v1alpha1.JobShardState) JobShardState {
 		State:                JobStateType(data.State),
 		Status:               data.Status,
 		VerificationProposal: data.VerificationProposal,
-		VerificationResult:   VerificationResult(data.VerificationResult),
+		VerificationResult:   ConvertV1alpha1VerificationResult(data.VerificationResult),
 		PublishedResult:      ConvertV1alpha1StorageSpec(data.PublishedResult),
 		RunOutput:            ConvertV1alpha1RunCommandResult(data.RunOutput),
 	}
@@ -104,7 +130,7 @@ func ConvertV1alpha1JobState(data v1alpha1.JobState) JobState {
 
 func ConvertV1alpha1JobEvent(event v1alpha1.JobEvent) JobEvent {
 	return JobEvent{
-		APIVersion:           APIVersionLatest().String(),
+		APIVersion:           APIVersionModel().String(),
 		JobID:                event.JobID,
 		ShardIndex:           event.ShardIndex,
 		ClientID:             event.ClientID,
@@ -113,10 +139,10 @@ func ConvertV1alpha1JobEvent(event v1alpha1.JobEvent) JobEvent {
 		EventName:            JobEventType(event.EventName),
 		Spec:                 ConvertV1alpha1Spec(event.Spec, event.JobExecutionPlan, event.Deal),
 		JobExecutionPlan:     JobExecutionPlan(event.JobExecutionPlan),
-		Deal:                 Deal(event.Deal),
+		Deal:                 ConvertV1alpha1Deal(event.Deal),
 		Status:               event.Status,
 		VerificationProposal: event.VerificationProposal,
-		VerificationResult:   VerificationResult(event.VerificationResult),
+		VerificationResult:   ConvertV1alpha1VerificationResult(event.VerificationResult),
 		PublishedResult:      ConvertV1alpha1StorageSpec(event.PublishedResult),
 		EventTime:            event.EventTime,
 		SenderPublicKey:      PublicKey(event.SenderPublicKey),
@@ -157,7 +183,7 @@ func ConvertV1alpha1JobLocalEvents(events []v1alpha1.JobLocalEvent) []JobLocalEv
 
 func ConvertV1alpha1Job(data v1alpha1.Job) Job {
 	return Job{
-		APIVersion: APIVersionLatest().String(),
+		APIVersion: APIVersionModel().String(),
 		Metadata: Metadata{
 			ID:        data.ID,
 			CreatedAt: data.CreatedAt,
@@ -94,7 +94,7 @@ func getJobs() (v1alpha1.Job, Job) {
 	}
 
 	latest := Job{
-		APIVersion: APIVersionLatest().String(),
+		APIVersion: APIVersionModel().String(),
 		Metadata: Metadata{
 			ID:        jobID,
 			CreatedAt: createdAt,
@@ -143,7 +143,7 @@ func getJobs() (v1alpha1.Job, Job) {
 			},
 			Events: []JobEvent{
 				{
-					APIVersion:   APIVersionLatest().String(),
+					APIVersion:   APIVersionModel().String(),
 					JobID:        jobID,
 					ShardIndex:   shardIndex,
 					ClientID:     clientID,
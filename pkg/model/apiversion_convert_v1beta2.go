@@ -0,0 +1,311 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/filecoin-project/bacalhau/pkg/model/v1beta2"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+func ConvertToV1beta2StorageSpec(data StorageSpec) v1beta2.StorageSpec {
+	spec := v1beta2.StorageSpec{
+		Name:     data.Name,
+		CID:      data.CID,
+		URL:      data.URL,
+		Path:     data.Path,
+		Metadata: data.Metadata,
+	}
+	if IsValidStorageSourceType(data.StorageSource) {
+		spec.StorageSource = data.StorageSource.String()
+	}
+	return spec
+}
+
+func ConvertFromV1beta2StorageSpec(data v1beta2.StorageSpec) (StorageSpec, error) {
+	spec := StorageSpec{
+		Name:     data.Name,
+		CID:      data.CID,
+		URL:      data.URL,
+		Path:     data.Path,
+		Metadata: data.Metadata,
+	}
+	if data.StorageSource != "" {
+		var err error
+		spec.StorageSource, err = ParseStorageSourceType(data.StorageSource)
+		if err != nil {
+			return StorageSpec{}, err
+		}
+	}
+	return spec, nil
+}
+
+func ConvertToV1beta2StorageSpecs(data []StorageSpec) []v1beta2.StorageSpec {
+	if data == nil {
+		return nil
+	}
+	ret := []v1beta2.StorageSpec{}
+	for _, spec := range data {
+		ret = append(ret, ConvertToV1beta2StorageSpec(spec))
+	}
+	return ret
+}
+
+func ConvertFromV1beta2StorageSpecs(data []v1beta2.StorageSpec) ([]StorageSpec, error) {
+	if data == nil {
+		return nil, nil
+	}
+	ret := []StorageSpec{}
+	for _, spec := range data {
+		converted, err := ConvertFromV1beta2StorageSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, converted)
+	}
+	return ret, nil
+}
+
+func ConvertToV1beta2Spec(data Spec) v1beta2.Spec {
+	spec := v1beta2.Spec{
+		Verifier: v1beta2.VerifierSpec{
+			SampleRate:        data.Verification.SampleRate,
+			IgnorePaths:       data.Verification.IgnorePaths,
+			MaxDifferingFiles: data.Verification.MaxDifferingFiles,
+		},
+		Resources: v1beta2.ResourceUsageConfig(data.Resources),
+		Network: v1beta2.NetworkConfig{
+			Type:    data.Network.Type.String(),
+			Domains: data.Network.Domains,
+		},
+		Timeout:     data.Timeout,
+		Inputs:      ConvertToV1beta2StorageSpecs(data.Inputs),
+		Contexts:    ConvertToV1beta2StorageSpecs(data.Contexts),
+		Outputs:     ConvertToV1beta2StorageSpecs(data.Outputs),
+		Annotations: data.Annotations,
+		Sharding:    v1beta2.JobShardingConfig(data.Sharding),
+		DoNotTrack:  data.DoNotTrack,
+		ExecutionPlan: v1beta2.JobExecutionPlan{
+			TotalShards: data.ExecutionPlan.TotalShards,
+		},
+		Deal: v1beta2.Deal(data.Deal),
+	}
+	if IsValidEngine(data.Engine) {
+		spec.Engine.Type = data.Engine.String()
+	}
+	if IsValidVerifier(data.Verifier) {
+		spec.Verifier.Type = data.Verifier.String()
+	}
+	if IsValidPublisher(data.Publisher) {
+		spec.Publishers = []string{data.Publisher.String()}
+	}
+
+	// the configuration of engines other than the job's is kept if it is set, so that no information is lost
+	if !reflect.ValueOf(data.Docker).IsZero() {
+		spec.Engine.Docker = &v1beta2.DockerSpec{
+			Image:                data.Docker.Image,
+			Entrypoint:           data.Docker.Entrypoint,
+			EnvironmentVariables: data.Docker.EnvironmentVariables,
+			WorkingDirectory:     data.Docker.WorkingDirectory,
+			RegistryAuth:         data.Docker.RegistryAuth,
+			Security:             v1beta2.DockerSecurity(data.Docker.Security),
+		}
+	}
+	if !reflect.ValueOf(data.Language).IsZero() {
+		spec.Engine.Language = &v1beta2.LanguageSpec{
+			Language:         data.Language.Language,
+			LanguageVersion:  data.Language.LanguageVersion,
+			Deterministic:    data.Language.Deterministic,
+			Context:          ConvertToV1beta2StorageSpec(data.Language.Context),
+			Command:          data.Language.Command,
+			ProgramPath:      data.Language.ProgramPath,
+			RequirementsPath: data.Language.RequirementsPath,
+		}
+	}
+	if !reflect.ValueOf(data.Wasm).IsZero() {
+		spec.Engine.Wasm = &v1beta2.WasmSpec{
+			EntryModule:          ConvertToV1beta2StorageSpec(data.Wasm.EntryModule),
+			EntryPoint:           data.Wasm.EntryPoint,
+			Parameters:           data.Wasm.Parameters,
+			EnvironmentVariables: data.Wasm.EnvironmentVariables,
+			ImportModules:        ConvertToV1beta2StorageSpecs(data.Wasm.ImportModules),
+		}
+	}
+
+	for _, selector := range data.NodeSelectors {
+		spec.NodeSelectors = append(spec.NodeSelectors, v1beta2.LabelSelectorRequirement{
+			Key:      selector.Key,
+			Operator: string(selector.Operator),
+			Values:   selector.Values,
+		})
+	}
+	for _, notification := range data.Notifications {
+		converted := v1beta2.JobNotification{
+			URL:    notification.URL,
+			Secret: notification.Secret,
+		}
+		for _, event := range notification.Events {
+			converted.Events = append(converted.Events, string(event))
+		}
+		spec.Notifications = append(spec.Notifications, converted)
+	}
+	return spec
+}
+
+func ConvertFromV1beta2Spec(data v1beta2.Spec) (Spec, error) {
+	spec := Spec{
+		Verification: VerificationConfig{
+			SampleRate:        data.Verifier.SampleRate,
+			IgnorePaths:       data.Verifier.IgnorePaths,
+			MaxDifferingFiles: data.Verifier.MaxDifferingFiles,
+		},
+		Resources: ResourceUsageConfig(data.Resources),
+		Network: NetworkConfig{
+			Domains: data.Network.Domains,
+		},
+		Timeout:     data.Timeout,
+		Annotations: data.Annotations,
+		Sharding:    JobShardingConfig(data.Sharding),
+		DoNotTrack:  data.DoNotTrack,
+		ExecutionPlan: JobExecutionPlan{
+			TotalShards: data.ExecutionPlan.TotalShards,
+		},
+		Deal: Deal(data.Deal),
+	}
+
+	var err error
+	if data.Engine.Type != "" {
+		if spec.Engine, err = ParseEngine(data.Engine.Type); err != nil {
+			return Spec{}, err
+		}
+	}
+	if data.Verifier.Type != "" {
+		if spec.Verifier, err = ParseVerifier(data.Verifier.Type); err != nil {
+			return Spec{}, err
+		}
+	}
+	if len(data.Publishers) > 1 {
+		return Spec{}, fmt.Errorf("jobs can only have one publisher, got %v", data.Publishers)
+	}
+	if len(data.Publishers) == 1 {
+		if spec.Publisher, err = ParsePublisher(data.Publishers[0]); err != nil {
+			return Spec{}, err
+		}
+	}
+	if data.Network.Type != "" {
+		if spec.Network.Type, err = ParseNetwork(data.Network.Type); err != nil {
+			return Spec{}, err
+		}
+	}
+	if spec.Inputs, err = ConvertFromV1beta2StorageSpecs(data.Inputs); err != nil {
+		return Spec{}, err
+	}
+	if spec.Contexts, err = ConvertFromV1beta2StorageSpecs(data.Contexts); err != nil {
+		return Spec{}, err
+	}
+	if spec.Outputs, err = ConvertFromV1beta2StorageSpecs(data.Outputs); err != nil {
+		return Spec{}, err
+	}
+
+	if docker := data.Engine.Docker; docker != nil {
+		spec.Docker = JobSpecDocker{
+			Image:                docker.Image,
+			Entrypoint:           docker.Entrypoint,
+			EnvironmentVariables: docker.EnvironmentVariables,
+			WorkingDirectory:     docker.WorkingDirectory,
+			RegistryAuth:         docker.RegistryAuth,
+			Security:             JobSpecDockerSecurity(docker.Security),
+		}
+	}
+	if language := data.Engine.Language; language != nil {
+		spec.Language = JobSpecLanguage{
+			Language:         language.Language,
+			LanguageVersion:  language.LanguageVersion,
+			Deterministic:    language.Deterministic,
+			Command:          language.Command,
+			ProgramPath:      language.ProgramPath,
+			RequirementsPath: language.RequirementsPath,
+		}
+		if spec.Language.Context, err = ConvertFromV1beta2StorageSpec(language.Context); err != nil {
+			return Spec{}, err
+		}
+	}
+	if wasm := data.Engine.Wasm; wasm != nil {
+		spec.Wasm = JobSpecWasm{
+			EntryPoint:           wasm.EntryPoint,
+			Parameters:           wasm.Parameters,
+			EnvironmentVariables: wasm.EnvironmentVariables,
+		}
+		if spec.Wasm.EntryModule, err = ConvertFromV1beta2StorageSpec(wasm.EntryModule); err != nil {
+			return Spec{}, err
+		}
+		if spec.Wasm.ImportModules, err = ConvertFromV1beta2StorageSpecs(wasm.ImportModules); err != nil {
+			return Spec{}, err
+		}
+	}
+
+	for _, selector := range data.NodeSelectors {
+		spec.NodeSelectors = append(spec.NodeSelectors, LabelSelectorRequirement{
+			Key:      selector.Key,
+			Operator: selection.Operator(selector.Operator),
+			Values:   selector.Values,
+		})
+	}
+	for _, notification := range data.Notifications {
+		converted := JobNotification{
+			URL:    notification.URL,
+			Secret: notification.Secret,
+		}
+		for _, event := range notification.Events {
+			converted.Events = append(converted.Events, NotificationEvent(event))
+		}
+		spec.Notifications = append(spec.Notifications, converted)
+	}
+	return spec, nil
+}
+
+// ConvertToV1beta2Job converts a job to how it is written with the V1beta2 API. The job's state and events are not
+// part of it.
+func ConvertToV1beta2Job(data Job) v1beta2.Job {
+	metadata := v1beta2.Metadata{
+		ID:       data.Metadata.ID,
+		ClientID: data.Metadata.ClientID,
+	}
+	if !data.Metadata.CreatedAt.IsZero() {
+		metadata.CreatedAt = &data.Metadata.CreatedAt
+	}
+	return v1beta2.Job{
+		APIVersion: V1beta2.String(),
+		Metadata:   metadata,
+		Spec:       ConvertToV1beta2Spec(data.Spec),
+		Requester: v1beta2.JobRequester{
+			NodeID:    data.Status.Requester.RequesterNodeID,
+			PublicKey: data.Status.Requester.RequesterPublicKey,
+		},
+	}
+}
+
+func ConvertFromV1beta2Job(data v1beta2.Job) (Job, error) {
+	spec, err := ConvertFromV1beta2Spec(data.Spec)
+	if err != nil {
+		return Job{}, err
+	}
+	metadata := Metadata{
+		ID:       data.Metadata.ID,
+		ClientID: data.Metadata.ClientID,
+	}
+	if data.Metadata.CreatedAt != nil {
+		metadata.CreatedAt = *data.Metadata.CreatedAt
+	}
+	return Job{
+		APIVersion: APIVersionModel().String(),
+		Metadata:   metadata,
+		Spec:       spec,
+		Status: JobStatus{
+			Requester: JobRequester{
+				RequesterNodeID:    data.Requester.NodeID,
+				RequesterPublicKey: data.Requester.PublicKey,
+			},
+		},
+	}, nil
+}
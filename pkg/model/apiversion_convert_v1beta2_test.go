@@ -0,0 +1,104 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/model/v1beta2"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+func getV1beta2TestJob() Job {
+	return Job{
+		APIVersion: APIVersionModel().String(),
+		Metadata: Metadata{
+			ID:        "test-job",
+			CreatedAt: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+			ClientID:  "test-client",
+		},
+		Spec: Spec{
+			Engine:   EngineDocker,
+			Verifier: VerifierDeterministic,
+			Verification: VerificationConfig{
+				SampleRate:  0.5,
+				IgnorePaths: []string{"*.log"},
+			},
+			Publisher: PublisherIpfs,
+			Docker: JobSpecDocker{
+				Image:        "ubuntu",
+				Entrypoint:   []string{"echo", "hello"},
+				RegistryAuth: []byte("token"),
+				Security:     JobSpecDockerSecurity{AddCapabilities: []string{"NET_ADMIN"}},
+			},
+			Wasm: JobSpecWasm{
+				EntryModule:          StorageSpec{StorageSource: StorageSourceIPFS, CID: "QmX"},
+				EnvironmentVariables: map[string]string{"A": "B"},
+			},
+			Resources: ResourceUsageConfig{CPU: "1", Memory: "1Gb", GPU: "1"},
+			Network:   NetworkConfig{Type: NetworkHTTP, Domains: []string{"example.com"}},
+			Timeout:   300,
+			Inputs:    []StorageSpec{{StorageSource: StorageSourceURLDownload, URL: "https://example.com", Path: "/inputs"}},
+			Outputs:   []StorageSpec{{StorageSource: StorageSourceIPFS, Name: "outputs", Path: "/outputs"}},
+			NodeSelectors: []LabelSelectorRequirement{
+				{Key: "region", Operator: selection.In, Values: []string{"eu"}},
+			},
+			Sharding:      JobShardingConfig{GlobPattern: "/inputs/*", BatchSize: 2, BasePath: "/inputs"},
+			ExecutionPlan: JobExecutionPlan{TotalShards: 3},
+			Deal:          Deal{Concurrency: 2, Confidence: 1, MaxBudget: 10},
+			Notifications: []JobNotification{
+				{URL: "https://example.com/hook", Events: []NotificationEvent{NotificationEventCompleted}, Secret: []byte("secret")},
+			},
+		},
+		Status: JobStatus{
+			Requester: JobRequester{RequesterNodeID: "test-node", RequesterPublicKey: PublicKey("key")},
+		},
+	}
+}
+
+func TestConvertV1beta2Job(t *testing.T) {
+	job := getV1beta2TestJob()
+
+	converted := ConvertToV1beta2Job(job)
+	require.Equal(t, EngineDocker.String(), converted.Spec.Engine.Type)
+	require.NotNil(t, converted.Spec.Engine.Docker)
+	require.NotNil(t, converted.Spec.Engine.Wasm)
+	require.Nil(t, converted.Spec.Engine.Language)
+	require.Equal(t, []string{PublisherIpfs.String()}, converted.Spec.Publishers)
+
+	roundTripped, err := ConvertFromV1beta2Job(converted)
+	require.NoError(t, err)
+	require.Equal(t, job, roundTripped)
+
+	// and through the JSON the requester stores
+	data, err := json.Marshal(converted)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"Inputs"`)
+	parsed, err := APIVersionParseJob(V1beta2.String(), string(data))
+	require.NoError(t, err)
+	require.Equal(t, job, parsed)
+}
+
+func TestConvertV1beta2JobErrors(t *testing.T) {
+	converted := ConvertToV1beta2Job(getV1beta2TestJob())
+	converted.Spec.Publishers = []string{PublisherIpfs.String(), PublisherEstuary.String()}
+	_, err := ConvertFromV1beta2Job(converted)
+	require.Error(t, err)
+
+	_, err = ConvertFromV1beta2Job(v1beta2.Job{Spec: v1beta2.Spec{Engine: v1beta2.EngineSpec{Type: "fortran"}}})
+	require.Error(t, err)
+
+	job, err := ConvertFromV1beta2Job(v1beta2.Job{})
+	require.NoError(t, err)
+	require.Equal(t, Spec{}, job.Spec)
+}
+
+func TestV1beta2JobsReadAsModelVersion(t *testing.T) {
+	// jobs are exchanged as the model version, whatever version they were written in
+	job, err := ConvertFromV1beta2Job(ConvertToV1beta2Job(*NewJob()))
+	require.NoError(t, err)
+	require.Equal(t, V1beta1.String(), job.APIVersion)
+	require.Equal(t, V1beta1.String(), NewJob().APIVersion)
+	require.NotContains(t, SupportedAPIVersions(), V1beta2.String())
+}
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// ProtocolVersion is the version of the protocol requester and compute nodes talk to each other with. Nodes only
+// exchange jobs with nodes running the same major protocol version, and minor versions add job fields that nodes
+// running older versions don't know of.
+const ProtocolVersion = "1.1.0"
+
+// legacyProtocolVersion is the protocol version of nodes that don't advertise their versions, as they predate version
+// negotiation.
+const legacyProtocolVersion = "1.0.0"
+
+// protocolFeatures are the job fields added to the protocol after its first version, with the protocol version nodes
+// understand them from. Nodes running an older version silently drop the fields, so jobs using them aren't sent there.
+var protocolFeatures = []struct {
+	field  string
+	since  string
+	usedBy func(Job) bool
+}{
+	{"Deal.Broadcast", "1.1.0", func(j Job) bool { return j.Spec.Deal.Broadcast }},
+	{"Deal.IncludeLateJoiners", "1.1.0", func(j Job) bool { return j.Spec.Deal.IncludeLateJoiners }},
+	{"Deal.MaxBudget", "1.1.0", func(j Job) bool { return j.Spec.Deal.MaxBudget > 0 }},
+	{"Spec.Verifier", "1.1.0", func(j Job) bool { return j.Spec.Verifier == VerifierOptimistic }},
+	{"Spec.Verification", "1.1.0", func(j Job) bool {
+		v := j.Spec.Verification
+		return v.SampleRate > 0 || len(v.IgnorePaths) > 0 || v.MaxDifferingFiles > 0
+	}},
+	{"Docker.RegistryAuth", "1.1.0", func(j Job) bool { return len(j.Spec.Docker.RegistryAuth) > 0 }},
+	{"Docker.Security", "1.1.0", func(j Job) bool { return !j.Spec.Docker.Security.IsEmpty() }},
+}
+
+// legacyAPIVersion is the latest API version understood by nodes that don't advertise their versions, as they predate
+// version negotiation.
+const legacyAPIVersion = V1beta1
+
+// SupportedAPIVersions returns the API versions of the jobs this node can run. V1alpha1 jobs are only ever read back
+// from storage, and later versions only change how jobs are written, so neither is sent between nodes.
+func SupportedAPIVersions() []string {
+	var versions []string
+	for version := V1beta1; version <= APIVersionModel(); version++ {
+		versions = append(versions, version.String())
+	}
+	return versions
+}
+
+// NodeVersions are the build, protocol and API versions a node runs.
+type NodeVersions struct {
+	BuildVersion BuildVersionInfo `json:"BuildVersion"`
+	// ProtocolVersion is the version of the protocol the node talks to other nodes with
+	ProtocolVersion string `json:"ProtocolVersion"`
+	// APIVersions are the API versions of the jobs the node can run
+	APIVersions []string `json:"APIVersions"`
+}
+
+// IsKnown returns true if the node advertised its versions.
+func (v NodeVersions) IsKnown() bool {
+	return v.ProtocolVersion != ""
+}
+
+// SupportsProtocol returns true if the node talks a protocol version compatible with this node's.
+func (v NodeVersions) SupportsProtocol() bool {
+	if !v.IsKnown() {
+		return true
+	}
+	return majorVersion(v.ProtocolVersion) == majorVersion(ProtocolVersion)
+}
+
+// UnsupportedFields returns the fields the job uses that the node drops, as they were added in a later protocol
+// version than the one it runs.
+func (v NodeVersions) UnsupportedFields(job Job) []string {
+	protocolVersion := legacyProtocolVersion
+	if v.IsKnown() {
+		protocolVersion = v.ProtocolVersion
+	}
+	nodeVersion, err := semver.NewVersion(protocolVersion)
+	if err != nil {
+		// a version that can't be parsed understands none of the later fields
+		nodeVersion = semver.MustParse(legacyProtocolVersion)
+	}
+	var fields []string
+	for _, feature := range protocolFeatures {
+		if feature.usedBy(job) && nodeVersion.LessThan(semver.MustParse(feature.since)) {
+			fields = append(fields, feature.field)
+		}
+	}
+	return fields
+}
+
+// SupportsAPIVersion returns true if the node can run jobs of the given API version.
+func (v NodeVersions) SupportsAPIVersion(version APIVersion) bool {
+	if !v.IsKnown() {
+		return version <= legacyAPIVersion
+	}
+	for _, supported := range v.APIVersions {
+		if equal(supported, version.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanRunJob returns an error if the node can't run the job as it is: it talks an incompatible protocol, doesn't
+// support the job's API version, or would drop fields the job uses. Jobs aren't converted to older API versions, so
+// such nodes are skipped.
+func (v NodeVersions) CanRunJob(job Job) error {
+	jobVersion, err := job.GetAPIVersion()
+	if err != nil {
+		return err
+	}
+	if !v.SupportsProtocol() {
+		return fmt.Errorf("node runs protocol version %s, which is incompatible with %s", v.ProtocolVersion, ProtocolVersion)
+	}
+	if fields := v.UnsupportedFields(job); len(fields) > 0 {
+		return fmt.Errorf("node runs a protocol version older than %s, and would drop the job's %s",
+			ProtocolVersion, strings.Join(fields, ", "))
+	}
+	if !v.SupportsAPIVersion(jobVersion) {
+		return fmt.Errorf("node supports API versions %v, and not the job's version %s", v.APIVersions, jobVersion)
+	}
+	return nil
+}
+
+// GetAPIVersion returns the API version of the job, which is the version of the model if it isn't set.
+func (j Job) GetAPIVersion() (APIVersion, error) {
+	if j.APIVersion == "" {
+		return APIVersionModel(), nil
+	}
+	return ParseAPIVersion(j.APIVersion)
+}
+
+func majorVersion(version string) string {
+	return strings.SplitN(strings.TrimPrefix(version, "v"), ".", 2)[0] //nolint:gomnd // the major version and the rest
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanRunJob(t *testing.T) {
+	job := Job{APIVersion: V1beta1.String()}
+
+	current := NodeVersions{ProtocolVersion: ProtocolVersion, APIVersions: SupportedAPIVersions()}
+	require.NoError(t, current.CanRunJob(job))
+
+	// nodes that don't advertise their versions run the legacy version
+	require.NoError(t, NodeVersions{}.CanRunJob(job))
+
+	require.Error(t, NodeVersions{ProtocolVersion: "2.0.0", APIVersions: SupportedAPIVersions()}.CanRunJob(job))
+
+	// jobs aren't converted to older API versions
+	require.ErrorContains(t,
+		NodeVersions{ProtocolVersion: ProtocolVersion, APIVersions: []string{V1alpha1.String()}}.CanRunJob(job),
+		"not the job's version")
+}
+
+func TestCanRunJobRejectsDroppedFields(t *testing.T) {
+	job := Job{APIVersion: V1beta1.String(), Spec: Spec{
+		Verifier:     VerifierOptimistic,
+		Verification: VerificationConfig{SampleRate: 0.5},
+		Deal:         Deal{Broadcast: true, MaxBudget: 10},
+		Docker:       JobSpecDocker{RegistryAuth: []byte("user:password")},
+	}}
+
+	require.NoError(t, NodeVersions{ProtocolVersion: ProtocolVersion, APIVersions: SupportedAPIVersions()}.CanRunJob(job))
+
+	expected := []string{"Deal.Broadcast", "Deal.MaxBudget", "Spec.Verifier", "Spec.Verification", "Docker.RegistryAuth"}
+	for _, versions := range []NodeVersions{
+		{},
+		{ProtocolVersion: "1.0.0", APIVersions: SupportedAPIVersions()},
+		{ProtocolVersion: "not-a-version", APIVersions: SupportedAPIVersions()},
+	} {
+		require.Equal(t, expected, versions.UnsupportedFields(job))
+		require.Error(t, versions.CanRunJob(job))
+	}
+
+	// jobs that don't use the new fields still run on older nodes
+	require.NoError(t, NodeVersions{}.CanRunJob(Job{APIVersion: V1beta1.String()}))
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type RunCommandResult struct {
 	// stdout of the run. Yaml provided for `describe` output
 	STDOUT string `json:"stdout"`
@@ -18,6 +20,10 @@ type RunCommandResult struct {
 
 	// Runner error
 	ErrorMsg string `json:"runnerError"`
+
+	// time spent pulling the image before the run started, which isn't
+	// counted towards the job's timeout
+	ImagePullDuration time.Duration `json:"imagePullDuration,omitempty"`
 }
 
 func NewRunCommandResult() *RunCommandResult {
@@ -1,21 +1,38 @@
 package model
 
-import "time"
+import (
+	"io"
+	"time"
+)
 
 const (
-	DownloadFilenameStdout    = "stdout"
-	DownloadFilenameStderr    = "stderr"
-	DownloadFilenameExitCode  = "exitCode"
-	DownloadVolumesFolderName = "combined_results"
-	DownloadShardsFolderName  = "per_shard"
-	DownloadCIDsFolderName    = "raw"
-	DownloadFolderPerm        = 0755
-	DownloadFilePerm          = 0644
-	DefaultIPFSTimeout        = 5 * time.Minute
+	DownloadFilenameStdout     = "stdout"
+	DownloadFilenameStderr     = "stderr"
+	DownloadFilenameExitCode   = "exitCode"
+	DownloadVolumesFolderName  = "combined_results"
+	DownloadShardsFolderName   = "per_shard"
+	DownloadCIDsFolderName     = "raw"
+	DownloadFolderPerm         = 0755
+	DownloadFilePerm           = 0644
+	DefaultIPFSTimeout         = 5 * time.Minute
+	DefaultDownloadParallelism = 4
 )
 
 type DownloaderSettings struct {
 	Timeout        time.Duration
 	OutputDir      string
 	IPFSSwarmAddrs string
+	// only download the results of these shards, or of all of them if empty
+	ShardIndexes []int
+	// only merge these output volumes, or all of them if empty. The whole
+	// results of each shard are still downloaded, so that they can be verified.
+	OutputVolumes []string
+	// glob patterns of result files to merge, or all of them if empty
+	Include []string
+	// glob patterns of result files not to merge
+	Exclude []string
+	// how many results are fetched at the same time
+	Parallelism int
+	// where to report download progress, or nowhere if nil
+	Progress io.Writer
 }
@@ -55,14 +55,14 @@ type JobStatus struct {
 // TODO: There's probably a better way we want to globally version APIs
 func NewJob() *Job {
 	return &Job{
-		APIVersion: APIVersionLatest().String(),
+		APIVersion: APIVersionModel().String(),
 	}
 }
 
 func NewJobWithSaneProductionDefaults() (*Job, error) {
 	j := NewJob()
 	err := mergo.Merge(j, &Job{
-		APIVersion: APIVersionLatest().String(),
+		APIVersion: APIVersionModel().String(),
 		Spec: Spec{
 			Engine:    EngineDocker,
 			Verifier:  VerifierNoop,
@@ -188,6 +188,18 @@ type Deal struct {
 	// jobs will be spread evenly across the network (assuming that this value
 	// is some large proportion of the size of the network).
 	MinBids int `json:"MinBids,omitempty"`
+	// Run the job on every node that matches its node selectors, instead of
+	// on Concurrency of them. Each node's execution is independent of the
+	// others, so their results are not compared and Concurrency, Confidence
+	// and MinBids are ignored.
+	Broadcast bool `json:"Broadcast,omitempty"`
+	// For broadcast jobs, also run the job on matching nodes that join the
+	// network while it is still running.
+	IncludeLateJoiners bool `json:"IncludeLateJoiners,omitempty"`
+	// The most the client will pay for each shard, summed over the price
+	// quotes of the bids accepted for it. The cheapest bids are accepted
+	// first. Zero means there is no budget.
+	MaxBudget float64 `json:"MaxBudget,omitempty"`
 }
 
 // LabelSelectorRequirement A selector that contains values, a key, and an operator that relates the key and values.
@@ -214,6 +226,9 @@ type Spec struct {
 
 	Verifier Verifier `json:"Verifier,omitempty"`
 
+	// how the verifier compares the results of the job
+	Verification VerificationConfig `json:"Verification,omitempty"`
+
 	// there can be multiple publishers for the job
 	Publisher Publisher `json:"Publisher,omitempty"`
 
@@ -234,7 +249,7 @@ type Spec struct {
 
 	// the data volumes we will read in the job
 	// for example "read this ipfs cid"
-	// TODO: #667 Replace with "Inputs", "Outputs" (note the caps) for yaml/json when we update the n.js file
+	// The lower-case "inputs" and "outputs" are kept for V1beta1 documents, V1beta2 capitalises them (#667)
 	Inputs []StorageSpec `json:"inputs,omitempty"`
 
 	// Input volumes that will not be sharded
@@ -263,6 +278,9 @@ type Spec struct {
 
 	// The deal the client has made, such as which job bids they have accepted.
 	Deal Deal `json:"Deal,omitempty"`
+
+	// webhooks the requester node calls as the job progresses
+	Notifications []JobNotification `json:"Notifications,omitempty"`
 }
 
 // Return timeout duration
@@ -300,6 +318,31 @@ type JobSpecDocker struct {
 	EnvironmentVariables []string `json:"EnvironmentVariables,omitempty"`
 	// working directory inside the container
 	WorkingDirectory string `json:"WorkingDirectory,omitempty"`
+	// optional token used to pull Image from a private registry, either
+	// "username:password" or a registry bearer token. It is submitted in
+	// plain text and then only stored or sent encrypted for the node
+	// holding the job.
+	RegistryAuth []byte `json:"RegistryAuth,omitempty"`
+	// relaxations of the compute node's container security policy that the
+	// job needs. Nodes that don't allow them will not bid on the job.
+	Security JobSpecDockerSecurity `json:"Security,omitempty"`
+}
+
+// JobSpecDockerSecurity describes the ways a job needs a compute node to relax
+// its container security policy.
+type JobSpecDockerSecurity struct {
+	// run as the image's own user, which is often root, rather than the
+	// node's unprivileged user
+	RunAsRoot bool `json:"RunAsRoot,omitempty"`
+	// allow writes to the container's root filesystem
+	WritableRootFilesystem bool `json:"WritableRootFilesystem,omitempty"`
+	// linux capabilities to add to the container, e.g. NET_ADMIN
+	AddCapabilities []string `json:"AddCapabilities,omitempty"`
+}
+
+// IsEmpty returns true if the job doesn't need any relaxations.
+func (s JobSpecDockerSecurity) IsEmpty() bool {
+	return !s.RunAsRoot && !s.WritableRootFilesystem && len(s.AddCapabilities) == 0
 }
 
 // for language style executors (can target docker or wasm)
@@ -380,6 +423,9 @@ type JobEvent struct {
 	VerificationProposal []byte             `json:"VerificationProposal,omitempty"`
 	VerificationResult   VerificationResult `json:"VerificationResult,omitempty"`
 	PublishedResult      StorageSpec        `json:"PublishedResult,omitempty"`
+	// this is only defined in "bid" and "bid_accepted" events, the price
+	// quoted by the compute node and the price agreed for the execution
+	Price float64 `json:"Price,omitempty"`
 
 	EventTime       time.Time `json:"EventTime,omitempty" example:"2022-11-17T13:32:55.756658941Z"`
 	SenderPublicKey PublicKey `json:"SenderPublicKey,omitempty"`
@@ -395,6 +441,9 @@ type JobEvent struct {
 type VerificationResult struct {
 	Complete bool `json:"Complete,omitempty"`
 	Result   bool `json:"Result,omitempty"`
+	// how the result files differ from the ones they were compared to, if
+	// they do
+	Diff *ResultsDiff `json:"Diff,omitempty"`
 }
 
 type JobCreatePayload struct {
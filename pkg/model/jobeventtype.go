@@ -66,6 +66,9 @@ const (
 	// not hear back it will be stuck in reserving the resources for the job
 	JobEventInvalidRequest
 
+	// a compute node pulled the image of a job before running it
+	JobEventImagePulled
+
 	jobEventDone // must be last
 )
 
@@ -24,12 +24,13 @@ func _() {
 	_ = x[JobEventResultsPublished-13]
 	_ = x[JobEventError-14]
 	_ = x[JobEventInvalidRequest-15]
-	_ = x[jobEventDone-16]
+	_ = x[JobEventImagePulled-16]
+	_ = x[jobEventDone-17]
 }
 
-const _JobEventType_name = "jobEventUnknownInitialSubmissionCreatedDealUpdatedBidBidAcceptedBidRejectedBidCancelledRunningComputeErrorResultsProposedResultsAcceptedResultsRejectedResultsPublishedErrorInvalidRequestjobEventDone"
+const _JobEventType_name = "jobEventUnknownInitialSubmissionCreatedDealUpdatedBidBidAcceptedBidRejectedBidCancelledRunningComputeErrorResultsProposedResultsAcceptedResultsRejectedResultsPublishedErrorInvalidRequestImagePulledjobEventDone"
 
-var _JobEventType_index = [...]uint8{0, 15, 32, 39, 50, 53, 64, 75, 87, 94, 106, 121, 136, 151, 167, 172, 186, 198}
+var _JobEventType_index = [...]uint8{0, 15, 32, 39, 50, 53, 64, 75, 87, 94, 106, 121, 136, 151, 167, 172, 186, 197, 209}
 
 func (i JobEventType) String() string {
 	if i < 0 || i >= JobEventType(len(_JobEventType_index)-1) {
@@ -22,6 +22,8 @@ type NodeInfo struct {
 	NodeType        NodeType          `json:"NodeType"`
 	Labels          map[string]string `json:"Labels"`
 	ComputeNodeInfo ComputeNodeInfo   `json:"ComputeNodeInfo"`
+	// Versions is empty for nodes that predate version negotiation
+	Versions NodeVersions `json:"Versions"`
 }
 
 // IsComputeNode returns true if the node is a compute node
@@ -36,4 +38,6 @@ type ComputeNodeInfo struct {
 	MaxJobRequirements ResourceUsageData `json:"MaxJobRequirements"`
 	RunningExecutions  int               `json:"RunningExecutions"`
 	EnqueuedExecutions int               `json:"EnqueuedExecutions"`
+	// CachedInputs are the keys of the most recently used inputs held in the node's input cache
+	CachedInputs []string `json:"CachedInputs,omitempty"`
 }
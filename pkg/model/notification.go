@@ -0,0 +1,93 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// NotificationEvent is a point in a job's lifecycle that a job can ask to be
+// notified of.
+type NotificationEvent string
+
+const (
+	// the job as a whole completed, once results for every shard are published
+	NotificationEventCompleted NotificationEvent = "completed"
+	// the requester node declared the job failed
+	NotificationEventFailed NotificationEvent = "failed"
+	// the results of a shard were published
+	NotificationEventPublished NotificationEvent = "published"
+)
+
+func NotificationEvents() []NotificationEvent {
+	return []NotificationEvent{NotificationEventCompleted, NotificationEventFailed, NotificationEventPublished}
+}
+
+func ParseNotificationEvent(str string) (NotificationEvent, error) {
+	for _, event := range NotificationEvents() {
+		if strings.EqualFold(string(event), str) {
+			return event, nil
+		}
+	}
+	return "", fmt.Errorf("unknown notification event %q, expected one of %v", str, NotificationEvents())
+}
+
+// JobNotification is a webhook the requester node POSTs to as the job
+// progresses.
+type JobNotification struct {
+	// http or https URL to POST JSON payloads to
+	URL string `json:"URL"`
+	// the events to notify of, or all of them if empty
+	Events []NotificationEvent `json:"Events,omitempty"`
+	// optional secret used to sign payloads with HMAC-SHA256, so receivers
+	// can check they came from the requester. It is submitted in plain text
+	// and then only stored encrypted for the requester node.
+	Secret []byte `json:"Secret,omitempty"`
+}
+
+// IsValid returns an error if the notification can't be delivered.
+func (n JobNotification) IsValid() error {
+	u, err := url.Parse(n.URL)
+	if err != nil {
+		return fmt.Errorf("invalid notification URL %q: %w", n.URL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid notification URL %q: must be an absolute http or https URL", n.URL)
+	}
+	for _, event := range n.Events {
+		if _, err = ParseNotificationEvent(string(event)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Wants returns true if the notification is for the event.
+func (n JobNotification) Wants(event NotificationEvent) bool {
+	if len(n.Events) == 0 {
+		return true
+	}
+	for _, e := range n.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationDelivery records an attempt to deliver a notification.
+type NotificationDelivery struct {
+	JobID      string            `json:"JobID"`
+	URL        string            `json:"URL"`
+	Event      NotificationEvent `json:"Event"`
+	ShardIndex int               `json:"ShardIndex"`
+	// attempts made so far, including this one
+	Attempt int `json:"Attempt"`
+	// the HTTP status code of the response, if one was received
+	StatusCode int    `json:"StatusCode,omitempty"`
+	Error      string `json:"Error,omitempty"`
+	// whether the receiver accepted the notification with a 2xx response
+	Delivered bool      `json:"Delivered"`
+	Time      time.Time `json:"Time"`
+}
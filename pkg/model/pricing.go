@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/c2h5oh/datasize"
+)
+
+// PricingPolicy is what a compute node charges for running a shard. Prices
+// are in the same unit as a job's Deal.MaxBudget.
+type PricingPolicy struct {
+	// price of one CPU core for one second
+	CPUSecond float64 `json:"CPUSecond,omitempty"`
+	// price of one GB of memory for one second
+	GBSecond float64 `json:"GBSecond,omitempty"`
+	// price of one GPU for one second
+	GPUSecond float64 `json:"GPUSecond,omitempty"`
+}
+
+// IsZero returns true if the policy doesn't charge for anything.
+func (p PricingPolicy) IsZero() bool {
+	return p.CPUSecond == 0 && p.GBSecond == 0 && p.GPUSecond == 0
+}
+
+// Quote returns the price of running a shard that needs the given resources
+// for the given duration.
+func (p PricingPolicy) Quote(resources ResourceUsageData, duration time.Duration) float64 {
+	gb := float64(resources.Memory) / float64(datasize.GB)
+	perSecond := resources.CPU*p.CPUSecond + gb*p.GBSecond + float64(resources.GPU)*p.GPUSecond
+	return perSecond * duration.Seconds()
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPricingPolicyQuote(t *testing.T) {
+	policy := PricingPolicy{CPUSecond: 2, GBSecond: 0.5, GPUSecond: 10}
+
+	testCases := []struct {
+		name      string
+		resources ResourceUsageData
+		duration  time.Duration
+		expected  float64
+	}{
+		{name: "nothing", duration: time.Minute, expected: 0},
+		{name: "cpu", resources: ResourceUsageData{CPU: 0.5}, duration: 10 * time.Second, expected: 10},
+		{name: "memory", resources: ResourceUsageData{Memory: uint64(4 * datasize.GB)}, duration: 10 * time.Second, expected: 20},
+		{name: "gpu", resources: ResourceUsageData{GPU: 2}, duration: time.Second, expected: 20},
+		{
+			name:      "all",
+			resources: ResourceUsageData{CPU: 1, Memory: uint64(2 * datasize.GB), GPU: 1},
+			duration:  2 * time.Second,
+			expected:  26,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.InDelta(t, tc.expected, policy.Quote(tc.resources, tc.duration), 1e-9)
+		})
+	}
+
+	require.Zero(t, PricingPolicy{}.Quote(ResourceUsageData{CPU: 4, GPU: 1}, time.Hour))
+	require.True(t, PricingPolicy{}.IsZero())
+	require.False(t, policy.IsZero())
+}
@@ -13,6 +13,7 @@ const (
 	PublisherIpfs
 	PublisherFilecoin
 	PublisherEstuary
+	PublisherNode
 	publisherDone // must be last
 )
 
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConcurrencyPolicy is what a schedule does when it is time to start a job,
+// but the job it started last time is still running.
+type ConcurrencyPolicy string
+
+const (
+	// ConcurrencyPolicyAllow starts the new job anyway.
+	ConcurrencyPolicyAllow ConcurrencyPolicy = "allow"
+	// ConcurrencyPolicyForbid skips the new job.
+	ConcurrencyPolicyForbid ConcurrencyPolicy = "forbid"
+	// ConcurrencyPolicyReplace cancels the running jobs and starts the new
+	// one.
+	ConcurrencyPolicyReplace ConcurrencyPolicy = "replace"
+)
+
+func ConcurrencyPolicies() []ConcurrencyPolicy {
+	return []ConcurrencyPolicy{ConcurrencyPolicyAllow, ConcurrencyPolicyForbid, ConcurrencyPolicyReplace}
+}
+
+func ParseConcurrencyPolicy(s string) (ConcurrencyPolicy, error) {
+	for _, policy := range ConcurrencyPolicies() {
+		if s == string(policy) {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown concurrency policy %q, must be one of %v", s, ConcurrencyPolicies())
+}
+
+// DefaultScheduleHistoryLimit is how many jobs a schedule remembers, if it
+// doesn't say.
+const DefaultScheduleHistoryLimit = 10
+
+// JobScheduleCreatePayload is what clients sign and send to create a schedule.
+type JobScheduleCreatePayload struct {
+	ClientID   string `json:"ClientID,omitempty" validate:"required"`
+	APIVersion string `json:"APIVersion" example:"V1beta1"`
+	// the job to start at each tick
+	Spec *Spec `json:"Spec,omitempty" validate:"required"`
+	// a standard 5 field cron expression, or a macro like @hourly
+	Cron string `json:"Cron" validate:"required"`
+	// IANA time zone the cron expression is in. Defaults to UTC.
+	Timezone          string            `json:"Timezone,omitempty"`
+	ConcurrencyPolicy ConcurrencyPolicy `json:"ConcurrencyPolicy,omitempty"`
+	// how many of the jobs started by the schedule to remember
+	HistoryLimit int `json:"HistoryLimit,omitempty"`
+}
+
+// JobSchedule is a job that the requester starts again and again, on a cron
+// schedule.
+type JobSchedule struct {
+	ID                string            `json:"ID"`
+	ClientID          string            `json:"ClientID"`
+	APIVersion        string            `json:"APIVersion"`
+	Spec              Spec              `json:"Spec"`
+	Cron              string            `json:"Cron"`
+	Timezone          string            `json:"Timezone,omitempty"`
+	ConcurrencyPolicy ConcurrencyPolicy `json:"ConcurrencyPolicy"`
+	HistoryLimit      int               `json:"HistoryLimit"`
+	Paused            bool              `json:"Paused"`
+	CreatedAt         time.Time         `json:"CreatedAt"`
+	// the last tick the schedule handled, whether or not it started a job
+	LastScheduleTime time.Time `json:"LastScheduleTime,omitempty"`
+	// the last error starting a job, cleared when one starts
+	LastError string `json:"LastError,omitempty"`
+	// the IDs of the latest jobs the schedule started, oldest first, up to
+	// HistoryLimit of them
+	JobIDs []string `json:"JobIDs,omitempty"`
+	// the jobs that are still counted as running for the concurrency policy
+	ActiveJobIDs []string `json:"ActiveJobIDs,omitempty"`
+}
+
+// ScheduleAnnotationKey annotates jobs started by a schedule with its ID, as
+// "bacalhau-schedule:<id>", so they can be listed.
+const ScheduleAnnotationKey = "bacalhau-schedule"
@@ -20,6 +20,8 @@ const (
 	StorageSourceFilecoin
 	StorageSourceEstuary
 	StorageSourceInline
+	StorageSourceGit
+	StorageSourceNode
 	storageSourceDone // must be last
 )
 
@@ -38,3 +38,11 @@ type PublishedResult struct {
 	ShardIndex int         `json:"ShardIndex,omitempty"`
 	Data       StorageSpec `json:"Data,omitempty"`
 }
+
+// MetadataJobID is the key of the job's ID in the metadata of results that
+// compute nodes keep themselves, which is needed to fetch them from the node.
+const MetadataJobID = "JobID"
+
+// MetadataResultsHash is the key of the hash of the manifest of the files
+// that compute nodes publish, which clients check their downloads against.
+const MetadataResultsHash = "ResultsHash"
@@ -15,12 +15,14 @@ func _() {
 	_ = x[StorageSourceFilecoin-4]
 	_ = x[StorageSourceEstuary-5]
 	_ = x[StorageSourceInline-6]
-	_ = x[storageSourceDone-7]
+	_ = x[StorageSourceGit-7]
+	_ = x[StorageSourceNode-8]
+	_ = x[storageSourceDone-9]
 }
 
-const _StorageSourceType_name = "storageSourceUnknownIPFSURLDownloadFilecoinUnsealedFilecoinEstuaryInlinestorageSourceDone"
+const _StorageSourceType_name = "storageSourceUnknownIPFSURLDownloadFilecoinUnsealedFilecoinEstuaryInlineGitNodestorageSourceDone"
 
-var _StorageSourceType_index = [...]uint8{0, 20, 24, 35, 51, 59, 66, 72, 89}
+var _StorageSourceType_index = [...]uint8{0, 20, 24, 35, 51, 59, 66, 72, 75, 79, 96}
 
 func (i StorageSourceType) String() string {
 	if i < 0 || i >= StorageSourceType(len(_StorageSourceType_index)-1) {
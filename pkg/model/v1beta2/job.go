@@ -0,0 +1,271 @@
+// Package v1beta2 describes jobs as they are written with the V1beta2 API. Compared to V1beta1, the engine specific
+// configuration is nested under the engine it configures, jobs can list several publishers, and every field is
+// capitalised the same way.
+package v1beta2
+
+import (
+	"time"
+)
+
+// Job is a job as written with the V1beta2 API. The job's state and events are not part of it, as they are kept
+// separately from what was asked of the network.
+type Job struct {
+	APIVersion string `json:"APIVersion" example:"V1beta2"`
+
+	Metadata Metadata `json:"Metadata,omitempty"`
+
+	// The specification of this job.
+	Spec Spec `json:"Spec,omitempty"`
+
+	// The requester node that owns this job.
+	Requester JobRequester `json:"Requester,omitempty"`
+}
+
+type Metadata struct {
+	// The unique global ID of this job in the bacalhau network.
+	ID string `json:"ID,omitempty" example:"92d5d4ee-3765-4f78-8353-623f5f26df08"`
+
+	// Time the job was submitted to the bacalhau network.
+	CreatedAt *time.Time `json:"CreatedAt,omitempty" example:"2022-11-17T13:29:01.871140291Z"`
+
+	// The ID of the client that created this job.
+	ClientID string `json:"ClientID,omitempty" example:"ac13188e93c97a9c2e7cf8e86c7313156a73436036f30da1ececc2ce79f9ea51"`
+}
+
+type JobRequester struct {
+	// The ID of the requester node that owns this job.
+	NodeID string `json:"NodeID,omitempty" example:"QmXaXu9N5GNetatsvwnTfQqNtSeKAD6uCmarbh3LMRYAcF"`
+
+	// The public key of the requester node that created this job, encoded in base64.
+	PublicKey []byte `json:"PublicKey,omitempty"`
+}
+
+// Spec is a complete specification of a job that can be run on some execution provider.
+type Spec struct {
+	// the engine that runs the job, and its configuration
+	Engine EngineSpec `json:"Engine,omitempty"`
+
+	// how the results of the job are verified
+	Verifier VerifierSpec `json:"Verifier,omitempty"`
+
+	// where the results of the job are published, e.g. IPFS
+	Publishers []string `json:"Publishers,omitempty"`
+
+	// the compute (cpu, ram) resources this job requires
+	Resources ResourceUsageConfig `json:"Resources,omitempty"`
+
+	// The type of networking access that the job needs
+	Network NetworkConfig `json:"Network,omitempty"`
+
+	// How long a job can run in seconds before it is killed.
+	// This includes the time required to run, verify and publish results
+	Timeout float64 `json:"Timeout,omitempty"`
+
+	// the data volumes we will read in the job, for example "read this ipfs cid"
+	Inputs []StorageSpec `json:"Inputs,omitempty"`
+
+	// Input volumes that will not be sharded, for example to upload code into a base image.
+	// Every shard will get the full range of context volumes
+	Contexts []StorageSpec `json:"Contexts,omitempty"`
+
+	// the data volumes we will write in the job, for example "write the results to ipfs"
+	Outputs []StorageSpec `json:"Outputs,omitempty"`
+
+	// Annotations on the job - could be user or machine assigned
+	Annotations []string `json:"Annotations,omitempty"`
+
+	// NodeSelectors is a selector which must be true for the compute node to run this job.
+	NodeSelectors []LabelSelectorRequirement `json:"NodeSelectors,omitempty"`
+
+	// the sharding config for this job
+	// describes how the job might be split up into parallel shards
+	Sharding JobShardingConfig `json:"Sharding,omitempty"`
+
+	// Do not track specified by the client
+	DoNotTrack bool `json:"DoNotTrack,omitempty"`
+
+	// how will this job be executed by nodes on the network
+	ExecutionPlan JobExecutionPlan `json:"ExecutionPlan,omitempty"`
+
+	// The deal the client has made, such as which job bids they have accepted.
+	Deal Deal `json:"Deal,omitempty"`
+
+	// webhooks the requester node calls as the job progresses
+	Notifications []JobNotification `json:"Notifications,omitempty"`
+}
+
+// EngineSpec is the engine that runs the job, e.g. docker, and its configuration. Only the configuration of the
+// engine that runs the job is expected to be set.
+type EngineSpec struct {
+	Type     string        `json:"Type,omitempty" example:"Docker"`
+	Docker   *DockerSpec   `json:"Docker,omitempty"`
+	Language *LanguageSpec `json:"Language,omitempty"`
+	Wasm     *WasmSpec     `json:"Wasm,omitempty"`
+}
+
+// VerifierSpec is how the results of the job are verified, e.g. deterministically, and how the verifier compares them.
+type VerifierSpec struct {
+	Type string `json:"Type,omitempty" example:"Noop"`
+	// The fraction of shards, between 0 and 1, that the optimistic verifier
+	// runs a second time on a different node to check the first result.
+	SampleRate float64 `json:"SampleRate,omitempty"`
+	// Glob patterns of result files that are not compared, such as logs
+	// with timestamps. Patterns without a slash match file names in any
+	// directory.
+	IgnorePaths []string `json:"IgnorePaths,omitempty"`
+	// How many files may differ between two results that are still
+	// considered the same by the optimistic verifier.
+	MaxDifferingFiles int `json:"MaxDifferingFiles,omitempty"`
+}
+
+// for VM style executors
+type DockerSpec struct {
+	// this should be pullable by docker
+	Image string `json:"Image,omitempty"`
+	// optionally override the default entrypoint
+	Entrypoint []string `json:"Entrypoint,omitempty"`
+	// a map of env to run the container with
+	EnvironmentVariables []string `json:"EnvironmentVariables,omitempty"`
+	// working directory inside the container
+	WorkingDirectory string `json:"WorkingDirectory,omitempty"`
+	// optional token used to pull Image from a private registry, either
+	// "username:password" or a registry bearer token.
+	RegistryAuth []byte `json:"RegistryAuth,omitempty"`
+	// relaxations of the compute node's container security policy that the
+	// job needs.
+	Security DockerSecurity `json:"Security,omitempty"`
+}
+
+// DockerSecurity describes the ways a job needs a compute node to relax its container security policy.
+type DockerSecurity struct {
+	// run as the image's own user rather than the node's unprivileged user
+	RunAsRoot bool `json:"RunAsRoot,omitempty"`
+	// allow writes to the container's root filesystem
+	WritableRootFilesystem bool `json:"WritableRootFilesystem,omitempty"`
+	// linux capabilities to add to the container, e.g. NET_ADMIN
+	AddCapabilities []string `json:"AddCapabilities,omitempty"`
+}
+
+// for language style executors (can target docker or wasm)
+type LanguageSpec struct {
+	Language        string `json:"Language,omitempty"`        // e.g. python
+	LanguageVersion string `json:"LanguageVersion,omitempty"` // e.g. 3.8
+	// must this job be run in a deterministic context?
+	Deterministic bool `json:"Deterministic,omitempty"`
+	// context is a tar file stored in ipfs, containing e.g. source code and requirements
+	Context StorageSpec `json:"Context,omitempty"`
+	// optional program specified on commandline, like python -c "print(1+1)"
+	Command string `json:"Command,omitempty"`
+	// optional program path relative to the context dir. one of Command or ProgramPath must be specified
+	ProgramPath string `json:"ProgramPath,omitempty"`
+	// optional requirements.txt (or equivalent) path relative to the context dir
+	RequirementsPath string `json:"RequirementsPath,omitempty"`
+}
+
+// Describes a raw WASM job
+type WasmSpec struct {
+	// The module that contains the WASM code to start running.
+	EntryModule StorageSpec `json:"EntryModule,omitempty"`
+	// The name of the function in the EntryModule to call to run the job.
+	EntryPoint string `json:"EntryPoint,omitempty"`
+	// The arguments supplied to the program (i.e. as ARGV).
+	Parameters []string `json:"Parameters,omitempty"`
+	// The variables available in the environment of the running program.
+	EnvironmentVariables map[string]string `json:"EnvironmentVariables,omitempty"`
+	// Other WASM modules whose exports will be available as imports to the EntryModule.
+	ImportModules []StorageSpec `json:"ImportModules,omitempty"`
+}
+
+type StorageSpec struct {
+	// e.g. IPFS or URLDownload
+	StorageSource string `json:"StorageSource,omitempty" example:"IPFS"`
+
+	Name string `json:"Name,omitempty"`
+
+	CID string `json:"CID,omitempty" example:"QmTVmC7JBD2ES2qGPqBNVWnX1KeEPNrPGb7rJ8cpFgtefe"`
+
+	URL string `json:"URL,omitempty"`
+
+	// the path the volume is mounted at inside the job
+	Path string `json:"Path,omitempty"`
+
+	Metadata map[string]string `json:"Metadata,omitempty"`
+}
+
+type ResourceUsageConfig struct {
+	// https://github.com/BTBurke/k8sresource string
+	CPU string `json:"CPU,omitempty"`
+	// github.com/c2h5oh/datasize string
+	Memory string `json:"Memory,omitempty"`
+	// github.com/c2h5oh/datasize string
+	Disk string `json:"Disk,omitempty"`
+	// unsigned integer string
+	GPU string `json:"GPU,omitempty"`
+}
+
+type NetworkConfig struct {
+	// e.g. None, Full or HTTP
+	Type    string   `json:"Type,omitempty" example:"None"`
+	Domains []string `json:"Domains,omitempty"`
+}
+
+// LabelSelectorRequirement is a selector that contains values, a key, and an operator that relates the key and values.
+type LabelSelectorRequirement struct {
+	// key is the label key that the selector applies to.
+	Key string `json:"Key"`
+	// operator represents a key's relationship to a set of values.
+	// Valid operators are In, NotIn, Exists and DoesNotExist.
+	Operator string `json:"Operator"`
+	// values is an array of string values. If the operator is In or NotIn,
+	// the values array must be non-empty. If the operator is Exists or DoesNotExist,
+	// the values array must be empty.
+	Values []string `json:"Values,omitempty"`
+}
+
+// describe how we chunk a job up into shards
+type JobShardingConfig struct {
+	// divide the inputs up into the smallest possible unit
+	// for example /* would mean "all top level files or folders"
+	// this being an empty string means "no sharding"
+	GlobPattern string `json:"GlobPattern,omitempty"`
+	// how many "items" are to be processed in each shard
+	BatchSize int `json:"BatchSize,omitempty"`
+	// when using multiple input volumes
+	// what path do we treat as the common mount path to apply the glob pattern to
+	BasePath string `json:"BasePath,omitempty"`
+}
+
+type JobExecutionPlan struct {
+	// how many shards are there in total for this job
+	TotalShards int `json:"TotalShards,omitempty"`
+}
+
+// The deal the client has made with the bacalhau network.
+type Deal struct {
+	// The maximum number of concurrent compute node bids that will be
+	// accepted by the requester node on behalf of the client.
+	Concurrency int `json:"Concurrency,omitempty"`
+	// The number of nodes that must agree on a verification result
+	Confidence int `json:"Confidence,omitempty"`
+	// The minimum number of bids that must be received before the Requester
+	// node will randomly accept concurrency-many of them.
+	MinBids int `json:"MinBids,omitempty"`
+	// Run the job on every node that matches its node selectors, instead of
+	// on Concurrency of them.
+	Broadcast bool `json:"Broadcast,omitempty"`
+	// For broadcast jobs, also run the job on matching nodes that join the
+	// network while it is still running.
+	IncludeLateJoiners bool `json:"IncludeLateJoiners,omitempty"`
+	// The most the client will pay for each shard. Zero means there is no budget.
+	MaxBudget float64 `json:"MaxBudget,omitempty"`
+}
+
+// JobNotification is a webhook the requester node POSTs to as the job progresses.
+type JobNotification struct {
+	// http or https URL to POST JSON payloads to
+	URL string `json:"URL"`
+	// the events to notify of, or all of them if empty
+	Events []string `json:"Events,omitempty"`
+	// optional secret used to sign payloads with HMAC-SHA256
+	Secret []byte `json:"Secret,omitempty"`
+}
@@ -11,9 +11,47 @@ const (
 	verifierUnknown Verifier = iota // must be first
 	VerifierNoop
 	VerifierDeterministic
+	VerifierOptimistic
 	verifierDone // must be last
 )
 
+// VerificationConfig tunes how the verifier compares the results of a job.
+type VerificationConfig struct {
+	// The fraction of shards, between 0 and 1, that the optimistic verifier
+	// runs a second time on a different node to check the first result.
+	SampleRate float64 `json:"SampleRate,omitempty"`
+	// Glob patterns of result files that are not compared, such as logs
+	// with timestamps. Patterns without a slash match file names in any
+	// directory.
+	IgnorePaths []string `json:"IgnorePaths,omitempty"`
+	// How many files may differ between two results that are still
+	// considered the same by the optimistic verifier.
+	MaxDifferingFiles int `json:"MaxDifferingFiles,omitempty"`
+}
+
+// ResultsDiff lists how the result files of an execution differ from the ones
+// they were compared to.
+type ResultsDiff struct {
+	// the node whose results these were compared to
+	ComparedTo string `json:"ComparedTo,omitempty"`
+	// files only in the other results
+	Missing []string `json:"Missing,omitempty"`
+	// files only in these results
+	Extra []string `json:"Extra,omitempty"`
+	// files in both results with different contents
+	Differing []string `json:"Differing,omitempty"`
+}
+
+// Count returns how many files differ.
+func (d ResultsDiff) Count() int {
+	return len(d.Missing) + len(d.Extra) + len(d.Differing)
+}
+
+// IsEmpty returns true if the results hold the same files.
+func (d ResultsDiff) IsEmpty() bool {
+	return d.Count() == 0
+}
+
 func ParseVerifier(str string) (Verifier, error) {
 	for typ := verifierUnknown + 1; typ < verifierDone; typ++ {
 		if equal(typ.String(), str) {
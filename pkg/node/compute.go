@@ -11,17 +11,23 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/compute/sensors"
 	"github.com/filecoin-project/bacalhau/pkg/compute/store"
 	"github.com/filecoin-project/bacalhau/pkg/compute/store/inmemory"
+	"github.com/filecoin-project/bacalhau/pkg/docker"
 	"github.com/filecoin-project/bacalhau/pkg/executor"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/publicapi"
 	"github.com/filecoin-project/bacalhau/pkg/publisher"
+	node_publisher "github.com/filecoin-project/bacalhau/pkg/publisher/node"
 	"github.com/filecoin-project/bacalhau/pkg/pubsub"
 	"github.com/filecoin-project/bacalhau/pkg/simulator"
+	"github.com/filecoin-project/bacalhau/pkg/storage/cache"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/filecoin-project/bacalhau/pkg/transport/bprotocol"
+	"github.com/filecoin-project/bacalhau/pkg/transport/chaos"
+	"github.com/filecoin-project/bacalhau/pkg/transport/httpprotocol"
 	simulator_protocol "github.com/filecoin-project/bacalhau/pkg/transport/simulator"
 	"github.com/filecoin-project/bacalhau/pkg/verifier"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/rs/zerolog/log"
 )
 
 type Compute struct {
@@ -30,7 +36,7 @@ type Compute struct {
 	Capacity        capacity.Tracker
 	ExecutionStore  store.ExecutionStore
 	Executors       executor.ExecutorProvider
-	computeCallback *bprotocol.CallbackProxy
+	computeCallback computeCallbackProxy
 	cleanupFunc     func(ctx context.Context)
 }
 
@@ -47,7 +53,12 @@ func NewComputeNode(
 	executors executor.ExecutorProvider,
 	verifiers verifier.VerifierProvider,
 	publishers publisher.PublisherProvider,
-	nodeInfoPubSub pubsub.PubSub[model.NodeInfo]) (*Compute, error) {
+	inputCache *cache.Cache,
+	retainedResults *node_publisher.NodePublisher,
+	nodeInfoPubSub pubsub.PubSub[model.NodeInfo],
+	httpTransport *httpprotocol.Transport,
+	faultInjector *chaos.Injector,
+	traceRecorder *simulator.Recorder) (*Compute, error) {
 	executionStore := inmemory.NewStore()
 
 	// executor/backend
@@ -60,9 +71,18 @@ func NewComputeNode(
 
 	// Callback to send compute events (i.e. requester endpoint)
 	var computeCallback compute.Callback
-	standardComputeCallback := bprotocol.NewCallbackProxy(bprotocol.CallbackProxyParams{
-		Host: host,
-	})
+	var standardComputeCallback computeCallbackProxy
+	if httpTransport != nil {
+		standardComputeCallback = httpprotocol.NewCallbackProxy(httpprotocol.CallbackProxyParams{
+			NodeID:       host.ID().String(),
+			Client:       httpTransport.Client,
+			RequesterURL: httpTransport.RequesterURL,
+		})
+	} else {
+		standardComputeCallback = bprotocol.NewCallbackProxy(bprotocol.CallbackProxyParams{
+			Host: host,
+		})
+	}
 	if simulatorNodeID != "" {
 		simulatorProxy := simulator_protocol.NewCallbackProxy(simulator_protocol.CallbackProxyParams{
 			SimulatorNodeID: simulatorNodeID,
@@ -73,12 +93,32 @@ func NewComputeNode(
 			simulatorProxy.RegisterLocalComputeCallback(simulatorRequestHandler)
 			// set standard callback implementation so that the simulator can forward requests to the correct endpoints
 			// after it finishes its validation and processing of the request
-			simulatorRequestHandler.SetRequesterProxy(standardComputeCallback)
+			var forwardCallback compute.Callback = standardComputeCallback
+			if traceRecorder != nil {
+				// the simulator node records the callbacks of all nodes as it forwards them
+				forwardCallback = simulator.NewRecordingCallback(simulator.RecordingCallbackParams{
+					Callback: forwardCallback,
+					Recorder: traceRecorder,
+				})
+			}
+			simulatorRequestHandler.SetRequesterProxy(forwardCallback)
 		}
 		computeCallback = simulatorProxy
 	} else {
 		computeCallback = standardComputeCallback
 	}
+	if faultInjector != nil {
+		computeCallback = chaos.NewCallbackProxy(chaos.CallbackProxyParams{
+			Callback: computeCallback,
+			Injector: faultInjector,
+		})
+	}
+	if traceRecorder != nil && simulatorRequestHandler == nil {
+		computeCallback = simulator.NewRecordingCallback(simulator.RecordingCallbackParams{
+			Callback: computeCallback,
+			Recorder: traceRecorder,
+		})
+	}
 
 	baseExecutor := compute.NewBaseExecutor(compute.BaseExecutorParams{
 		ID:              host.ID().String(),
@@ -97,6 +137,7 @@ func NewComputeNode(
 		RunningCapacityTracker:     runningCapacityTracker,
 		EnqueuedCapacityTracker:    enqueuedCapacityTracker,
 		DefaultJobExecutionTimeout: config.DefaultJobExecutionTimeout,
+		PrepareTimeout:             config.JobPrepareTimeout,
 		BackoffDuration:            config.ExecutorBufferBackoffDuration,
 	})
 	runningInfoProvider := sensors.NewRunningExecutionsInfoProvider(sensors.RunningExecutionsInfoProviderParams{
@@ -146,6 +187,12 @@ func NewComputeNode(
 			Executors: executors,
 			Verifiers: verifiers,
 		}),
+		bidstrategy.NewRegistryCredentialsStrategy(bidstrategy.RegistryCredentialsStrategyParams{
+			Credentials: docker.NewRegistryCredentials(config.DockerConfigPath),
+		}),
+		bidstrategy.NewSecurityPolicyStrategy(bidstrategy.SecurityPolicyStrategyParams{
+			Policy: config.DockerSecurityPolicy,
+		}),
 		bidstrategy.NewExternalCommandStrategy(bidstrategy.ExternalCommandStrategyParams{
 			Command: config.JobSelectionPolicy.ProbeExec,
 		}),
@@ -164,6 +211,9 @@ func NewComputeNode(
 			MinJobExecutionTimeout:                config.MinJobExecutionTimeout,
 			JobExecutionTimeoutClientIDBypassList: config.JobExecutionTimeoutClientIDBypassList,
 		}),
+		bidstrategy.NewBudgetStrategy(bidstrategy.BudgetStrategyParams{
+			PricingPolicy: config.PricingPolicy,
+		}),
 	)
 
 	// node info
@@ -174,6 +224,7 @@ func NewComputeNode(
 		CapacityTracker:    runningCapacityTracker,
 		ExecutorBuffer:     bufferRunner,
 		MaxJobRequirements: config.JobResourceLimits,
+		InputCache:         inputCache,
 	})
 	nodeInfoPublisher := compute.NewNodeInfoPublisher(compute.NodeInfoPublisherParams{
 		PubSub:           nodeInfoPubSub,
@@ -187,21 +238,41 @@ func NewComputeNode(
 		UsageCalculator: capacityCalculator,
 		BidStrategy:     biddingStrategy,
 		Executor:        bufferRunner,
+		PricingPolicy:   config.PricingPolicy,
 	})
 
 	// if this node is the simulator, then we set the simulator request handler as the stream handler
+	var computeEndpoint compute.Endpoint = baseEndpoint
 	if simulatorRequestHandler != nil {
-		bprotocol.NewComputeHandler(bprotocol.ComputeHandlerParams{
-			Host:            host,
-			ComputeEndpoint: simulatorRequestHandler,
+		computeEndpoint = simulatorRequestHandler
+	}
+	if httpTransport != nil {
+		httpprotocol.NewComputeHandler(httpprotocol.ComputeHandlerParams{
+			Server:          httpTransport.Server,
+			ComputeEndpoint: computeEndpoint,
 		})
 	} else {
 		bprotocol.NewComputeHandler(bprotocol.ComputeHandlerParams{
 			Host:            host,
-			ComputeEndpoint: baseEndpoint,
+			ComputeEndpoint: computeEndpoint,
 		})
 	}
 
+	// serve the results the node keeps to requesters fetching them
+	if retainedResults != nil {
+		if httpTransport != nil {
+			httpprotocol.NewResultsHandler(httpprotocol.ResultsHandlerParams{
+				Server:          httpTransport.Server,
+				ResultsProvider: retainedResults,
+			})
+		} else {
+			bprotocol.NewResultsHandler(bprotocol.ResultsHandlerParams{
+				Host:            host,
+				ResultsProvider: retainedResults,
+			})
+		}
+	}
+
 	// register debug info providers for the /debug endpoint
 	debugInfoProviders := []model.DebugInfoProvider{
 		sensors.NewNodeDebugInfoProvider(sensors.NodeDebugInfoProviderParams{
@@ -229,7 +300,11 @@ func NewComputeNode(
 	// eagerly publish node info to the network
 	err = nodeInfoPublisher.Publish(ctx)
 	if err != nil {
-		return nil, err
+		if httpTransport == nil {
+			return nil, err
+		}
+		// the requester node may not be up yet, and registering with it is retried periodically
+		log.Ctx(ctx).Warn().Err(err).Msg("failed to register with the requester node")
 	}
 
 	return &Compute{
@@ -242,6 +317,13 @@ func NewComputeNode(
 	}, nil
 }
 
+// computeCallbackProxy sends compute callbacks to requester nodes over a transport, or to the local requester of
+// hybrid nodes.
+type computeCallbackProxy interface {
+	compute.Callback
+	RegisterLocalComputeCallback(callback compute.Callback)
+}
+
 func (c *Compute) RegisterLocalComputeCallback(callback compute.Callback) {
 	c.computeCallback.RegisterLocalComputeCallback(callback)
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/filecoin-project/bacalhau/pkg/compute/capacity"
+	"github.com/filecoin-project/bacalhau/pkg/docker"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 )
 
@@ -25,18 +26,34 @@ type ComputeConfigParams struct {
 	MinJobExecutionTimeout     time.Duration
 	MaxJobExecutionTimeout     time.Duration
 	DefaultJobExecutionTimeout time.Duration
+	JobPrepareTimeout          time.Duration
 
 	JobExecutionTimeoutClientIDBypassList []string
 
 	// Bid strategies config
 	JobSelectionPolicy model.JobSelectionPolicy
 
+	// Pricing config
+	PricingPolicy model.PricingPolicy
+
 	// logging running executions
 	LogRunningExecutionsInterval time.Duration
 
 	// interval to publish node info to pubsub network
 	NodeInfoPublisherInterval time.Duration
 
+	// Input cache config
+	InputCacheSize uint64
+
+	// Retained results config
+	ResultsRetention time.Duration
+
+	// Docker config
+	DockerConfigPath     string
+	DockerPullPolicy     docker.PullPolicy
+	DockerPrePullImages  []string
+	DockerSecurityPolicy docker.SecurityPolicy
+
 	SimulatorConfig model.SimulatorConfigCompute
 }
 
@@ -62,6 +79,9 @@ type ComputeConfig struct {
 	// DefaultJobExecutionTimeout default value for the execution timeout this compute node will assign to jobs with
 	// no timeout requirement defined.
 	DefaultJobExecutionTimeout time.Duration
+	// JobPrepareTimeout is how long the node can spend preparing to run a job, such as pulling its image. It doesn't
+	// count towards the job's execution timeout.
+	JobPrepareTimeout time.Duration
 
 	// JobExecutionTimeoutClientIDBypassList is the list of clients that are allowed to bypass the job execution timeout
 	// check.
@@ -70,12 +90,34 @@ type ComputeConfig struct {
 	// Bid strategies config
 	JobSelectionPolicy model.JobSelectionPolicy
 
+	// PricingPolicy is what the node charges for running jobs. Its price quote is attached to every bid, and the
+	// node doesn't bid on jobs whose budget can't pay for it.
+	PricingPolicy model.PricingPolicy
+
 	// logging running executions
 	LogRunningExecutionsInterval time.Duration
 
 	// interval to publish node info to pubsub network
 	NodeInfoPublisherInterval time.Duration
 
+	// InputCacheSize is the number of bytes of downloaded inputs that are kept on disk between jobs. Inputs are
+	// still shared between shards using them at the same time when this is zero.
+	InputCacheSize uint64
+
+	// ResultsRetention is how long the node keeps the results of jobs that are published to the node itself, for
+	// clients to fetch them through a requester node. Jobs can't publish to the node when this is zero.
+	ResultsRetention time.Duration
+
+	// DockerConfigPath is the docker config.json holding credentials for private registries. Docker's default
+	// location is used when empty.
+	DockerConfigPath string
+	// DockerPullPolicy decides when images are pulled for jobs.
+	DockerPullPolicy docker.PullPolicy
+	// DockerPrePullImages are pulled when the node starts so jobs using them don't wait for a pull.
+	DockerPrePullImages []string
+	// DockerSecurityPolicy restricts the containers jobs run in.
+	DockerSecurityPolicy docker.SecurityPolicy
+
 	SimulatorConfig model.SimulatorConfigCompute
 }
 
@@ -103,6 +145,9 @@ func NewComputeConfigWith(params ComputeConfigParams) (config ComputeConfig) {
 	if params.DefaultJobExecutionTimeout == 0 {
 		params.DefaultJobExecutionTimeout = DefaultComputeConfig.DefaultJobExecutionTimeout
 	}
+	if params.JobPrepareTimeout == 0 {
+		params.JobPrepareTimeout = DefaultComputeConfig.JobPrepareTimeout
+	}
 	if params.LogRunningExecutionsInterval == 0 {
 		params.LogRunningExecutionsInterval = DefaultComputeConfig.LogRunningExecutionsInterval
 	}
@@ -112,6 +157,9 @@ func NewComputeConfigWith(params ComputeConfigParams) (config ComputeConfig) {
 	if params.ExecutorBufferBackoffDuration == 0 {
 		params.ExecutorBufferBackoffDuration = DefaultComputeConfig.ExecutorBufferBackoffDuration
 	}
+	if params.DockerPullPolicy == "" {
+		params.DockerPullPolicy = DefaultComputeConfig.DockerPullPolicy
+	}
 
 	// Get available physical resources in the host
 	physicalResourcesProvider := params.PhysicalResourcesProvider
@@ -154,13 +202,21 @@ func NewComputeConfigWith(params ComputeConfigParams) (config ComputeConfig) {
 		MinJobExecutionTimeout:     params.MinJobExecutionTimeout,
 		MaxJobExecutionTimeout:     params.MaxJobExecutionTimeout,
 		DefaultJobExecutionTimeout: params.DefaultJobExecutionTimeout,
+		JobPrepareTimeout:          params.JobPrepareTimeout,
 
 		JobExecutionTimeoutClientIDBypassList: params.JobExecutionTimeoutClientIDBypassList,
 
 		JobSelectionPolicy: params.JobSelectionPolicy,
+		PricingPolicy:      params.PricingPolicy,
 
 		LogRunningExecutionsInterval: params.LogRunningExecutionsInterval,
 		NodeInfoPublisherInterval:    params.NodeInfoPublisherInterval,
+		InputCacheSize:               params.InputCacheSize,
+		ResultsRetention:             params.ResultsRetention,
+		DockerConfigPath:             params.DockerConfigPath,
+		DockerPullPolicy:             params.DockerPullPolicy,
+		DockerPrePullImages:          params.DockerPrePullImages,
+		DockerSecurityPolicy:         params.DockerSecurityPolicy,
 		SimulatorConfig:              params.SimulatorConfig,
 	}
 
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/filecoin-project/bacalhau/pkg/compute/capacity/system"
+	"github.com/filecoin-project/bacalhau/pkg/docker"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 )
 
@@ -19,9 +20,12 @@ var DefaultComputeConfig = ComputeConfigParams{
 	MinJobExecutionTimeout:     500 * time.Millisecond,
 	MaxJobExecutionTimeout:     60 * time.Minute,
 	DefaultJobExecutionTimeout: 10 * time.Minute,
+	JobPrepareTimeout:          10 * time.Minute,
 
 	LogRunningExecutionsInterval: 10 * time.Second,
 	NodeInfoPublisherInterval:    30 * time.Second,
+
+	DockerPullPolicy: docker.PullIfNotPresent,
 }
 
 var DefaultRequesterConfig = RequesterConfigParams{
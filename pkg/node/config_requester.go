@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/requester/quota"
 )
 
 type RequesterConfigParams struct {
@@ -18,6 +19,8 @@ type RequesterConfigParams struct {
 	NodeInfoStoreTTL                   time.Duration
 	DiscoveredPeerStoreTTL             time.Duration
 	SimulatorConfig                    model.SimulatorConfigRequester
+	ClientQuota                        quota.Limits
+	AuthRegistryPath                   string
 }
 
 type RequesterConfig struct {
@@ -43,6 +46,11 @@ type RequesterConfig struct {
 	// We only need to store the peer long enough for the requester to connect to the compute node for the duration of the job.
 	DiscoveredPeerStoreTTL time.Duration
 	SimulatorConfig        model.SimulatorConfigRequester
+	// ClientQuota limits what each client can submit. Zero values are unlimited.
+	ClientQuota quota.Limits
+	// AuthRegistryPath is the file of registered client keys and tokens. If set,
+	// only registered clients can use the requester API.
+	AuthRegistryPath string
 }
 
 func NewRequesterConfigWithDefaults() RequesterConfig {
@@ -91,6 +99,8 @@ func NewRequesterConfigWith(params RequesterConfigParams) (config RequesterConfi
 		NodeInfoStoreTTL:        params.NodeInfoStoreTTL,
 		DiscoveredPeerStoreTTL:  params.DiscoveredPeerStoreTTL,
 		SimulatorConfig:         params.SimulatorConfig,
+		ClientQuota:             params.ClientQuota,
+		AuthRegistryPath:        params.AuthRegistryPath,
 	}
 
 	return config
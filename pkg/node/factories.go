@@ -6,6 +6,7 @@ import (
 
 	"github.com/filecoin-project/bacalhau/pkg/localdb"
 
+	"github.com/filecoin-project/bacalhau/pkg/docker"
 	"github.com/filecoin-project/bacalhau/pkg/executor"
 	executor_util "github.com/filecoin-project/bacalhau/pkg/executor/util"
 	"github.com/filecoin-project/bacalhau/pkg/publisher"
@@ -81,6 +82,7 @@ func (f *StandardStorageProvidersFactory) Get(
 		executor_util.StandardStorageProviderOptions{
 			IPFSMultiaddress:     nodeConfig.IPFSClient.APIAddress(),
 			FilecoinUnsealedPath: nodeConfig.FilecoinUnsealedPath,
+			Cache:                nodeConfig.inputCache,
 		},
 	)
 }
@@ -98,10 +100,16 @@ func (f *StandardExecutorsFactory) Get(
 		ctx,
 		nodeConfig.CleanupManager,
 		executor_util.StandardExecutorOptions{
-			DockerID: fmt.Sprintf("bacalhau-%s", nodeConfig.Host.ID().String()),
+			DockerID:                  fmt.Sprintf("bacalhau-%s", nodeConfig.Host.ID().String()),
+			DockerRegistryCredentials: docker.NewRegistryCredentials(nodeConfig.ComputeConfig.DockerConfigPath),
+			Decrypter:                 verifier.NewEncrypter(nodeConfig.Host.Peerstore().PrivKey(nodeConfig.Host.ID())).Unseal,
+			DockerPullPolicy:          nodeConfig.ComputeConfig.DockerPullPolicy,
+			DockerPrePullImages:       nodeConfig.ComputeConfig.DockerPrePullImages,
+			DockerSecurityPolicy:      nodeConfig.ComputeConfig.DockerSecurityPolicy,
 			Storage: executor_util.StandardStorageProviderOptions{
 				IPFSMultiaddress:     nodeConfig.IPFSClient.APIAddress(),
 				FilecoinUnsealedPath: nodeConfig.FilecoinUnsealedPath,
+				Cache:                nodeConfig.inputCache,
 			},
 		},
 	)
@@ -117,11 +125,14 @@ func (f *StandardVerifiersFactory) Get(
 	ctx context.Context,
 	nodeConfig NodeConfig) (verifier.VerifierProvider, error) {
 	encrypter := verifier.NewEncrypter(nodeConfig.Host.Peerstore().PrivKey(nodeConfig.Host.ID()))
+	// proposals can be results manifests, which are too large to encrypt with the node's key directly,
+	// while older nodes still propose a dirhash encrypted with it
 	return verifier_util.NewStandardVerifiers(
 		ctx,
 		nodeConfig.CleanupManager,
 		localdb.GetStateResolver(nodeConfig.LocalDB),
-		encrypter.Encrypt,
+		encrypter.Seal,
+		encrypter.Unseal,
 		encrypter.Decrypt,
 	)
 }
@@ -135,12 +146,17 @@ type StandardPublishersFactory struct{}
 func (f *StandardPublishersFactory) Get(
 	ctx context.Context,
 	nodeConfig NodeConfig) (publisher.PublisherProvider, error) {
+	var nodePublisher publisher.Publisher
+	if nodeConfig.retainedResults != nil {
+		nodePublisher = nodeConfig.retainedResults
+	}
 	return publisher_util.NewIPFSPublishers(
 		ctx,
 		nodeConfig.CleanupManager,
 		nodeConfig.IPFSClient.APIAddress(),
 		nodeConfig.EstuaryAPIKey,
 		nodeConfig.LotusConfig,
+		nodePublisher,
 	)
 }
 
@@ -2,6 +2,8 @@ package node
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/filecoin-project/bacalhau/pkg/config"
@@ -10,9 +12,14 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/publicapi"
 	filecoinlotus "github.com/filecoin-project/bacalhau/pkg/publisher/filecoin_lotus"
+	node_publisher "github.com/filecoin-project/bacalhau/pkg/publisher/node"
+	"github.com/filecoin-project/bacalhau/pkg/pubsub"
 	"github.com/filecoin-project/bacalhau/pkg/pubsub/libp2p"
 	"github.com/filecoin-project/bacalhau/pkg/simulator"
+	"github.com/filecoin-project/bacalhau/pkg/storage/cache"
 	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/transport/chaos"
+	"github.com/filecoin-project/bacalhau/pkg/transport/httpprotocol"
 	"github.com/imdario/mergo"
 	libp2p_pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/host"
@@ -41,6 +48,18 @@ type NodeConfig struct {
 	IsRequesterNode      bool
 	IsComputeNode        bool
 	Labels               map[string]string
+	// HTTPTransport carries the compute–requester protocol over HTTP instead of libp2p when set
+	HTTPTransport *httpprotocol.Config
+	// FaultInjector is optional and injects faults into the messages the node exchanges with other nodes
+	FaultInjector *chaos.Injector
+	// TraceRecorder is optional and records the requests and callbacks the node exchanges with other nodes, and the
+	// events of its jobs
+	TraceRecorder *simulator.Recorder
+
+	// inputCache is shared by the storage drivers of compute nodes
+	inputCache *cache.Cache
+	// retainedResults keeps the results compute nodes publish to themselves
+	retainedResults *node_publisher.NodePublisher
 }
 
 // Lazy node dependency injector that generate instances of different
@@ -70,6 +89,7 @@ type Node struct {
 	IPFSClient     *ipfs.Client
 	Host           host.Host
 	metricsPort    int
+	httpTransport  *httpprotocol.Transport
 }
 
 func (n *Node) Start(ctx context.Context) error {
@@ -85,6 +105,14 @@ func (n *Node) Start(ctx context.Context) error {
 		}
 	}(ctx)
 
+	if n.httpTransport != nil {
+		go func(ctx context.Context) {
+			if err := n.httpTransport.Server.ListenAndServe(ctx, n.CleanupManager); err != nil {
+				log.Ctx(ctx).Error().Msgf("Transport server can't run. Cannot talk to other nodes!: %v", err)
+			}
+		}(ctx)
+	}
+
 	return nil
 }
 
@@ -104,6 +132,17 @@ func NewNode(
 		return nil, err
 	}
 
+	if config.IsComputeNode {
+		config.inputCache, err = newInputCache(config)
+		if err != nil {
+			return nil, err
+		}
+		config.retainedResults, err = newRetainedResults(config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	storageProviders, err := injector.StorageProvidersFactory.Get(ctx, config)
 	if err != nil {
 		return nil, err
@@ -149,15 +188,27 @@ func NewNode(
 		return nil, err
 	}
 
-	// PubSub to publish node info to the network
-	nodeInfoPubSub, err := libp2p.NewPubSub[model.NodeInfo](libp2p.PubSubParams{
-		Host:      config.Host,
-		TopicName: NodeInfoTopic,
-		PubSub:    gossipSub,
-	})
-	if err != nil {
-		gossipSubCancel()
-		return nil, err
+	// PubSub to publish node info to the network, which is replaced by registrations with the requester node when
+	// using the HTTP transport
+	var httpTransport *httpprotocol.Transport
+	var nodeInfoPubSub pubsub.PubSub[model.NodeInfo]
+	if config.HTTPTransport != nil {
+		httpTransport, err = newHTTPTransport(config)
+		if err != nil {
+			gossipSubCancel()
+			return nil, err
+		}
+		nodeInfoPubSub = httpTransport.Registry
+	} else {
+		nodeInfoPubSub, err = libp2p.NewPubSub[model.NodeInfo](libp2p.PubSubParams{
+			Host:      config.Host,
+			TopicName: NodeInfoTopic,
+			PubSub:    gossipSub,
+		})
+		if err != nil {
+			gossipSubCancel()
+			return nil, err
+		}
 	}
 
 	var requesterNode *Requester
@@ -178,6 +229,9 @@ func NewNode(
 			storageProviders,
 			nodeInfoPubSub,
 			gossipSub,
+			httpTransport,
+			config.FaultInjector,
+			config.TraceRecorder,
 		)
 		if err != nil {
 			gossipSubCancel()
@@ -199,7 +253,12 @@ func NewNode(
 			executors,
 			verifiers,
 			publishers,
+			config.inputCache,
+			config.retainedResults,
 			nodeInfoPubSub,
+			httpTransport,
+			config.FaultInjector,
+			config.TraceRecorder,
 		)
 		if err != nil {
 			gossipSubCancel()
@@ -233,6 +292,9 @@ func NewNode(
 		// To enable nodes self-dialing themselves as libp2p doesn't support it.
 		computeNode.RegisterLocalComputeCallback(requesterNode.localCallback)
 		requesterNode.RegisterLocalComputeEndpoint(computeNode.LocalEndpoint)
+		if config.retainedResults != nil {
+			requesterNode.RegisterLocalResultsProvider(config.retainedResults)
+		}
 	}
 
 	node := &Node{
@@ -243,11 +305,89 @@ func NewNode(
 		RequesterNode:  requesterNode,
 		Host:           config.Host,
 		metricsPort:    config.MetricsPort,
+		httpTransport:  httpTransport,
 	}
 
 	return node, nil
 }
 
+// newInputCache opens the input cache of a compute node, which is kept per
+// host so that it survives restarts of the same node. There is nothing to
+// keep when the cache has no space, so a temporary one is used instead.
+func newInputCache(nodeConfig NodeConfig) (*cache.Cache, error) {
+	if nodeConfig.ComputeConfig.InputCacheSize == 0 {
+		return cache.NewTemporaryCache(nodeConfig.CleanupManager, 0)
+	}
+	dir := filepath.Join(config.GetStoragePath(), "bacalhau-input-cache", nodeConfig.Host.ID().String())
+	return cache.NewCache(dir, nodeConfig.ComputeConfig.InputCacheSize)
+}
+
+// newRetainedResults keeps the results that a compute node publishes to
+// itself, per host so that they survive restarts of the same node, unless the
+// node doesn't keep results.
+func newRetainedResults(nodeConfig NodeConfig) (*node_publisher.NodePublisher, error) {
+	if nodeConfig.ComputeConfig.ResultsRetention == 0 {
+		return nil, nil
+	}
+	retainedResults, err := node_publisher.NewNodePublisher(node_publisher.NodePublisherParams{
+		ResultsDir: filepath.Join(config.GetStoragePath(), "bacalhau-retained-results", nodeConfig.Host.ID().String()),
+		Retention:  nodeConfig.ComputeConfig.ResultsRetention,
+	})
+	if err != nil {
+		return nil, err
+	}
+	nodeConfig.CleanupManager.RegisterCallback(func() error {
+		retainedResults.Stop()
+		return nil
+	})
+	return retainedResults, nil
+}
+
+// newHTTPTransport sets up the HTTP transport of a node. Requester nodes accept the registrations of compute nodes,
+// and hybrid nodes register with themselves unless told otherwise.
+func newHTTPTransport(nodeConfig NodeConfig) (*httpprotocol.Transport, error) {
+	transportConfig := *nodeConfig.HTTPTransport
+	if transportConfig.Address == "" {
+		return nil, fmt.Errorf("the HTTP transport needs the address other nodes reach this node at")
+	}
+	if transportConfig.RequesterURL == "" {
+		if !nodeConfig.IsRequesterNode {
+			return nil, fmt.Errorf("compute nodes using the HTTP transport need the URL of their requester node")
+		}
+		transportConfig.RequesterURL = transportConfig.Address
+	}
+	tlsConfig, err := transportConfig.TLS()
+	if err != nil {
+		return nil, err
+	}
+
+	nodeID := nodeConfig.Host.ID().String()
+	server := httpprotocol.NewServer(httpprotocol.ServerParams{
+		NodeID:        nodeID,
+		ListenAddress: transportConfig.ListenAddress,
+		TLSConfig:     tlsConfig,
+	})
+	client := httpprotocol.NewClient(httpprotocol.ClientParams{
+		TLSConfig: tlsConfig,
+	})
+	registryParams := httpprotocol.RegistryParams{
+		Client:       client,
+		PrivateKey:   nodeConfig.Host.Peerstore().PrivKey(nodeConfig.Host.ID()),
+		Address:      transportConfig.Address,
+		RequesterURL: transportConfig.RequesterURL,
+	}
+	if nodeConfig.IsRequesterNode {
+		registryParams.Server = server
+		registryParams.Peerstore = nodeConfig.Host.Peerstore()
+	}
+	return &httpprotocol.Transport{
+		Server:       server,
+		Client:       client,
+		Registry:     httpprotocol.NewRegistry(registryParams),
+		RequesterURL: transportConfig.RequesterURL,
+	}, nil
+}
+
 // IsRequesterNode returns true if the node is a requester node
 func (n *Node) IsRequesterNode() bool {
 	return n.RequesterNode != nil
@@ -2,6 +2,7 @@ package node
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/filecoin-project/bacalhau/pkg/compute"
@@ -9,17 +10,23 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/localdb"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/publicapi"
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/auth"
 	"github.com/filecoin-project/bacalhau/pkg/pubsub"
 	"github.com/filecoin-project/bacalhau/pkg/pubsub/libp2p"
 	"github.com/filecoin-project/bacalhau/pkg/requester"
 	"github.com/filecoin-project/bacalhau/pkg/requester/discovery"
 	"github.com/filecoin-project/bacalhau/pkg/requester/nodestore"
+	"github.com/filecoin-project/bacalhau/pkg/requester/notification"
 	requester_publicapi "github.com/filecoin-project/bacalhau/pkg/requester/publicapi"
+	"github.com/filecoin-project/bacalhau/pkg/requester/quota"
 	"github.com/filecoin-project/bacalhau/pkg/requester/ranking"
+	"github.com/filecoin-project/bacalhau/pkg/requester/schedule"
 	"github.com/filecoin-project/bacalhau/pkg/simulator"
 	"github.com/filecoin-project/bacalhau/pkg/storage"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/filecoin-project/bacalhau/pkg/transport/bprotocol"
+	"github.com/filecoin-project/bacalhau/pkg/transport/chaos"
+	"github.com/filecoin-project/bacalhau/pkg/transport/httpprotocol"
 	simulator_protocol "github.com/filecoin-project/bacalhau/pkg/transport/simulator"
 	"github.com/filecoin-project/bacalhau/pkg/verifier"
 	libp2p_pubsub "github.com/libp2p/go-libp2p-pubsub"
@@ -32,7 +39,8 @@ type Requester struct {
 	// Visible for testing
 	Endpoint      requester.Endpoint
 	JobStore      localdb.LocalDB
-	computeProxy  *bprotocol.ComputeProxy
+	computeProxy  computeEndpointProxy
+	resultsProxy  resultsProviderProxy
 	localCallback *requester.Scheduler
 	cleanupFunc   func(ctx context.Context)
 }
@@ -51,6 +59,9 @@ func NewRequesterNode(
 	storageProviders storage.StorageProvider,
 	nodeInfoPubSub pubsub.PubSub[model.NodeInfo],
 	gossipSub *libp2p_pubsub.PubSub,
+	httpTransport *httpprotocol.Transport,
+	faultInjector *chaos.Injector,
+	traceRecorder *simulator.Recorder,
 ) (*Requester, error) {
 	// prepare event handlers
 	tracerContextProvider := system.NewTracerContextProvider(host.ID().String())
@@ -58,9 +69,18 @@ func NewRequesterNode(
 
 	// compute proxy
 	var computeProxy compute.Endpoint
-	standardComputeProxy := bprotocol.NewComputeProxy(bprotocol.ComputeProxyParams{
-		Host: host,
-	})
+	var standardComputeProxy computeEndpointProxy
+	if httpTransport != nil {
+		standardComputeProxy = httpprotocol.NewComputeProxy(httpprotocol.ComputeProxyParams{
+			NodeID:   host.ID().String(),
+			Client:   httpTransport.Client,
+			Registry: httpTransport.Registry,
+		})
+	} else {
+		standardComputeProxy = bprotocol.NewComputeProxy(bprotocol.ComputeProxyParams{
+			Host: host,
+		})
+	}
 	// if we are running in simulator mode, then we use the simulator proxy to forward all requests to th simulator node.
 	if simulatorNodeID != "" {
 		simulatorProxy := simulator_protocol.NewComputeProxy(simulator_protocol.ComputeProxyParams{
@@ -72,16 +92,37 @@ func NewRequesterNode(
 			simulatorProxy.RegisterLocalComputeEndpoint(simulatorRequestHandler)
 			// set standard endpoint implementation so that the simulator can forward requests to the correct endpoints
 			// after it finishes its validation and processing of the request
-			simulatorRequestHandler.SetComputeProxy(standardComputeProxy)
+			var forwardProxy compute.Endpoint = standardComputeProxy
+			if traceRecorder != nil {
+				// the simulator node records the requests of all nodes as it forwards them
+				forwardProxy = simulator.NewRecordingEndpoint(simulator.RecordingEndpointParams{
+					Endpoint: forwardProxy,
+					Recorder: traceRecorder,
+				})
+			}
+			simulatorRequestHandler.SetComputeProxy(forwardProxy)
 		}
 		computeProxy = simulatorProxy
 	} else {
 		computeProxy = standardComputeProxy
 	}
+	if faultInjector != nil {
+		computeProxy = chaos.NewComputeProxy(chaos.ComputeProxyParams{
+			Endpoint: computeProxy,
+			Injector: faultInjector,
+		})
+	}
+	if traceRecorder != nil && simulatorRequestHandler == nil {
+		computeProxy = simulator.NewRecordingEndpoint(simulator.RecordingEndpointParams{
+			Endpoint: computeProxy,
+			Recorder: traceRecorder,
+		})
+	}
 
 	// compute node discoverer
 	nodeInfoStore := nodestore.NewInMemoryNodeInfoStore(nodestore.InMemoryNodeInfoStoreParams{
-		TTL: config.NodeInfoStoreTTL,
+		TTL:    config.NodeInfoStoreTTL,
+		NodeID: host.ID().String(),
 	})
 	nodeDiscoveryChain := discovery.NewChain(true)
 	nodeDiscoveryChain.Add(
@@ -94,12 +135,19 @@ func NewRequesterNode(
 	)
 
 	// compute node ranker
+	verificationRanker := ranking.NewVerificationNodeRanker(ranking.VerificationNodeRankerParams{})
 	nodeRankerChain := ranking.NewChain()
 	nodeRankerChain.Add(
 		// rankers that act as filters and give a -1 score to nodes that do not match the filter
+		ranking.NewVersionsNodeRanker(),
 		ranking.NewEnginesNodeRanker(),
 		ranking.NewLabelsNodeRanker(),
 		ranking.NewMaxUsageNodeRanker(),
+		// also prefers the nodes with fewer rejected results
+		verificationRanker,
+
+		// preference rankers
+		ranking.NewInputLocalityNodeRanker(),
 
 		// arbitrary rankers
 		ranking.NewRandomNodeRanker(ranking.RandomNodeRankerParams{
@@ -107,19 +155,23 @@ func NewRequesterNode(
 		}),
 	)
 
+	encrypter := verifier.NewEncrypter(host.Peerstore().PrivKey(host.ID()))
 	scheduler := requester.NewScheduler(ctx, cleanupManager, requester.SchedulerParams{
-		ID:               host.ID().String(),
-		Host:             host,
-		PeerStoreTTL:     config.DiscoveredPeerStoreTTL,
-		JobStore:         jobStore,
-		NodeDiscoverer:   nodeDiscoveryChain,
-		NodeRanker:       nodeRankerChain,
-		ComputeEndpoint:  computeProxy,
-		Verifiers:        verifiers,
-		StorageProviders: storageProviders,
+		ID:                   host.ID().String(),
+		Host:                 host,
+		PeerStoreTTL:         config.DiscoveredPeerStoreTTL,
+		JobStore:             jobStore,
+		NodeDiscoverer:       nodeDiscoveryChain,
+		NodeRanker:           nodeRankerChain,
+		VerificationRecorder: verificationRanker,
+		ComputeEndpoint:      computeProxy,
+		Verifiers:            verifiers,
+		StorageProviders:     storageProviders,
 		EventEmitter: requester.NewEventEmitter(requester.EventEmitterParams{
 			EventConsumer: localJobEventConsumer,
 		}),
+		Encrypter:                          encrypter.Seal,
+		Decrypter:                          encrypter.Unseal,
 		JobNegotiationTimeout:              config.JobNegotiationTimeout,
 		StateManagerBackgroundTaskInterval: config.StateManagerBackgroundTaskInterval,
 	})
@@ -130,6 +182,13 @@ func NewRequesterNode(
 	if err != nil {
 		return nil, err
 	}
+	quotaTracker := quota.NewTracker(quota.TrackerParams{
+		Limits:   config.ClientQuota,
+		JobStore: jobStore,
+	})
+	if err = quotaTracker.Restore(ctx); err != nil {
+		return nil, fmt.Errorf("failed to restore client quotas: %w", err)
+	}
 	endpoint := requester.NewBaseEndpoint(&requester.BaseEndpointParams{
 		ID:                         host.ID().String(),
 		PublicKey:                  marshaledPublicKey,
@@ -137,21 +196,27 @@ func NewRequesterNode(
 		Scheduler:                  scheduler,
 		Verifiers:                  verifiers,
 		StorageProviders:           storageProviders,
+		Encrypter:                  encrypter.Seal,
 		MinJobExecutionTimeout:     config.MinJobExecutionTimeout,
 		DefaultJobExecutionTimeout: config.DefaultJobExecutionTimeout,
+		QuotaTracker:               quotaTracker,
 	})
 
-	// if this node is the simulator, then we pass incoming requests to the simulator before passing them to the endpoint
+	// if this node is the simulator, then we pass incoming requests to the simulator before passing them to the endpoint,
+	// and otherwise the bacalhau protocol handler forwards them to the scheduler
+	var callback compute.Callback = scheduler
 	if simulatorRequestHandler != nil {
-		bprotocol.NewCallbackHandler(bprotocol.CallbackHandlerParams{
-			Host:     host,
-			Callback: simulatorRequestHandler,
+		callback = simulatorRequestHandler
+	}
+	if httpTransport != nil {
+		httpprotocol.NewCallbackHandler(httpprotocol.CallbackHandlerParams{
+			Server:   httpTransport.Server,
+			Callback: callback,
 		})
 	} else {
-		// register a handler for the bacalhau protocol handler that will forward requests to the scheduler
 		bprotocol.NewCallbackHandler(bprotocol.CallbackHandlerParams{
 			Host:     host,
-			Callback: scheduler,
+			Callback: callback,
 		})
 	}
 
@@ -163,6 +228,36 @@ func NewRequesterNode(
 		}),
 	}
 
+	var authRegistry *auth.Registry
+	if config.AuthRegistryPath != "" {
+		authRegistry, err = auth.NewRegistry(auth.RegistryParams{
+			Path: config.AuthRegistryPath,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// submits jobs for recurring job schedules
+	jobScheduler := schedule.NewScheduler(schedule.SchedulerParams{
+		JobStore: jobStore,
+		Endpoint: endpoint,
+	})
+
+	// fetches the results compute nodes kept, for clients to download through the API
+	var resultsProxy resultsProviderProxy
+	if httpTransport != nil {
+		resultsProxy = httpprotocol.NewResultsProxy(httpprotocol.ResultsProxyParams{
+			NodeID:   host.ID().String(),
+			Client:   httpTransport.Client,
+			Registry: httpTransport.Registry,
+		})
+	} else {
+		resultsProxy = bprotocol.NewResultsProxy(bprotocol.ResultsProxyParams{
+			Host: host,
+		})
+	}
+
 	// register requester public http apis
 	requesterAPIServer := requester_publicapi.NewRequesterAPIServer(requester_publicapi.RequesterAPIServerParams{
 		APIServer:          apiServer,
@@ -170,6 +265,11 @@ func NewRequesterNode(
 		DebugInfoProviders: debugInfoProviders,
 		LocalDB:            jobStore,
 		StorageProviders:   storageProviders,
+		QuotaTracker:       quotaTracker,
+		AuthRegistry:       authRegistry,
+		Schedules:          jobScheduler,
+		ResultsProvider:    resultsProxy,
+		NodeInfoStore:      nodeInfoStore,
 	})
 	err = requesterAPIServer.RegisterAllHandlers()
 	if err != nil {
@@ -200,6 +300,10 @@ func NewRequesterNode(
 	if err != nil {
 		return nil, err
 	}
+	notifier := notification.NewNotifier(notification.NotifierParams{
+		JobStore:  jobStore,
+		Decrypter: encrypter.Unseal,
+	})
 
 	// order of event handlers is important as triggering some handlers might depend on the state of others.
 	localJobEventConsumer.AddHandlers(
@@ -211,11 +315,19 @@ func NewRequesterNode(
 		eventTracer,
 		// update the job state in the local DB
 		localDBEventHandler,
+		// delivers the job's webhook notifications, based on its state in the local DB
+		notifier,
+		// releases finished jobs from their client's quota, based on their state in the local DB
+		quotaTracker,
 		// dispatches events to listening websockets
 		requesterAPIServer,
 		// dispatches events to the network
 		eventhandler.JobEventHandlerFunc(bufferedJobEventPubSub.Publish),
 	)
+	if traceRecorder != nil {
+		// record the event in the trace
+		localJobEventConsumer.AddHandlers(traceRecorder)
+	}
 
 	// register consumers of job events publishes over gossipSub
 	networkJobEventConsumer := eventhandler.NewChainedJobEventHandler(system.NewNoopContextProvider())
@@ -232,6 +344,8 @@ func NewRequesterNode(
 	nodeInfoSubscriber := pubsub.NewChainedSubscriber[model.NodeInfo](true)
 	nodeInfoSubscriber.Add(pubsub.SubscriberFunc[model.NodeInfo](nodeInfoStore.Add))
 	nodeInfoSubscriber.Add(pubsub.SubscriberFunc[model.NodeInfo](requesterAPIServer.PushNodeInfoToWebsocket))
+	// asks nodes that join the network to run the broadcast jobs that include late joiners
+	nodeInfoSubscriber.Add(pubsub.SubscriberFunc[model.NodeInfo](scheduler.HandleNodeInfo))
 	err = nodeInfoPubSub.Subscribe(ctx, nodeInfoSubscriber)
 	if err != nil {
 		return nil, err
@@ -239,6 +353,8 @@ func NewRequesterNode(
 
 	// A single cleanup function to make sure the order of closing dependencies is correct
 	cleanupFunc := func(ctx context.Context) {
+		jobScheduler.Stop()
+
 		cleanupErr := bufferedJobEventPubSub.Close(ctx)
 		if cleanupErr != nil {
 			log.Error().Err(cleanupErr).Msg("failed to close job event pubsub")
@@ -257,21 +373,44 @@ func NewRequesterNode(
 		if cleanupErr != nil {
 			log.Error().Err(cleanupErr).Msg("failed to shutdown event tracer")
 		}
+
+		notifier.Shutdown()
 	}
 
+	// submit scheduled jobs once everything they go through is set up
+	jobScheduler.Start(ctx)
+
 	return &Requester{
 		Endpoint:      endpoint,
 		localCallback: scheduler,
 		JobStore:      jobStore,
 		computeProxy:  standardComputeProxy,
+		resultsProxy:  resultsProxy,
 		cleanupFunc:   cleanupFunc,
 	}, nil
 }
 
+// computeEndpointProxy sends requests to compute nodes over a transport, or to the local compute node of hybrid nodes.
+type computeEndpointProxy interface {
+	compute.Endpoint
+	RegisterLocalComputeEndpoint(endpoint compute.Endpoint)
+}
+
+// resultsProviderProxy fetches the results compute nodes kept over a transport, or from the local compute node of
+// hybrid nodes.
+type resultsProviderProxy interface {
+	compute.ResultsProvider
+	RegisterLocalResultsProvider(provider compute.ResultsProvider)
+}
+
 func (r *Requester) RegisterLocalComputeEndpoint(endpoint compute.Endpoint) {
 	r.computeProxy.RegisterLocalComputeEndpoint(endpoint)
 }
 
+func (r *Requester) RegisterLocalResultsProvider(provider compute.ResultsProvider) {
+	r.resultsProxy.RegisterLocalResultsProvider(provider)
+}
+
 func (r *Requester) cleanup(ctx context.Context) {
 	r.cleanupFunc(ctx)
 }
@@ -0,0 +1,329 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"github.com/filecoin-project/bacalhau/pkg/storage/util"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+)
+
+const (
+	// DefaultMaxClockSkew is how far a signed request's timestamp can be from
+	// the node's clock.
+	DefaultMaxClockSkew = 5 * time.Minute
+
+	tokenPrefix = "bac_"
+	tokenBytes  = 32
+	// how many characters of a token's hash make up its ID
+	tokenIDLength = 12
+	bearerPrefix  = "Bearer "
+)
+
+// ClientKey is a registered client public key, and what its client is
+// allowed to do.
+type ClientKey struct {
+	ClientID    string                 `json:"ClientID"`
+	PublicKey   string                 `json:"PublicKey"`
+	Scopes      []handlerwrapper.Scope `json:"Scopes"`
+	Description string                 `json:"Description,omitempty"`
+	CreatedAt   time.Time              `json:"CreatedAt"`
+}
+
+// Token is a bearer token issued to a client. Only a hash of the token is
+// kept, so it can't be recovered after it is created.
+type Token struct {
+	ID        string                 `json:"ID"`
+	ClientID  string                 `json:"ClientID"`
+	Hash      string                 `json:"Hash,omitempty"`
+	Scopes    []handlerwrapper.Scope `json:"Scopes"`
+	CreatedAt time.Time              `json:"CreatedAt"`
+	// zero if the token doesn't expire
+	ExpiresAt time.Time `json:"ExpiresAt,omitempty"`
+}
+
+// Manager manages registered client keys and tokens, either in a local
+// registry or through a requester node's API.
+type Manager interface {
+	ListKeys(ctx context.Context) ([]ClientKey, error)
+	AddKey(ctx context.Context, publicKey string, scopes []handlerwrapper.Scope, description string) (ClientKey, error)
+	RemoveKey(ctx context.Context, clientID string) error
+	ListTokens(ctx context.Context) ([]Token, error)
+	// CreateToken returns the secret token, which is only available here.
+	CreateToken(ctx context.Context, clientID string, scopes []handlerwrapper.Scope, ttl time.Duration) (string, Token, error)
+	RevokeToken(ctx context.Context, id string) error
+}
+
+type registryFile struct {
+	Keys   []ClientKey `json:"Keys"`
+	Tokens []Token     `json:"Tokens"`
+}
+
+type RegistryParams struct {
+	// JSON file the registry is kept in. Created if it doesn't exist.
+	Path string
+	// defaults to DefaultMaxClockSkew
+	MaxClockSkew time.Duration
+	// defaults to time.Now, visible for testing
+	Clock func() time.Time
+}
+
+// Registry is a file backed registry of client keys and tokens, that
+// authenticates API requests.
+type Registry struct {
+	path         string
+	maxClockSkew time.Duration
+	clock        func() time.Time
+
+	data registryFile
+	mu   sync.RWMutex
+}
+
+func NewRegistry(params RegistryParams) (*Registry, error) {
+	maxClockSkew := params.MaxClockSkew
+	if maxClockSkew <= 0 {
+		maxClockSkew = DefaultMaxClockSkew
+	}
+	clock := params.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+	registry := &Registry{
+		path:         params.Path,
+		maxClockSkew: maxClockSkew,
+		clock:        clock,
+	}
+
+	data, err := os.ReadFile(params.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read auth registry %s: %w", params.Path, err)
+	}
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &registry.data); err != nil {
+			return nil, fmt.Errorf("failed to parse auth registry %s: %w", params.Path, err)
+		}
+	}
+	return registry, nil
+}
+
+func (r *Registry) ListKeys(context.Context) ([]ClientKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]ClientKey{}, r.data.Keys...), nil
+}
+
+// AddKey registers a client's public key, or replaces the scopes of an
+// already registered one.
+func (r *Registry) AddKey(
+	_ context.Context, publicKey string, scopes []handlerwrapper.Scope, description string) (ClientKey, error) {
+	clientID, err := system.ClientIDFromPublicKey(publicKey)
+	if err != nil {
+		return ClientKey{}, err
+	}
+	key := ClientKey{
+		ClientID:    clientID,
+		PublicKey:   publicKey,
+		Scopes:      scopes,
+		Description: description,
+		CreatedAt:   r.clock().UTC(),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := []ClientKey{key}
+	for _, existing := range r.data.Keys {
+		if existing.ClientID != clientID {
+			keys = append(keys, existing)
+		}
+	}
+	r.data.Keys = keys
+	return key, r.save()
+}
+
+func (r *Registry) RemoveKey(_ context.Context, clientID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, key := range r.data.Keys {
+		if key.ClientID == clientID {
+			r.data.Keys = append(r.data.Keys[:i], r.data.Keys[i+1:]...)
+			return r.save()
+		}
+	}
+	return fmt.Errorf("no key registered for client %s", clientID)
+}
+
+// ListTokens returns the issued tokens, without their hashes.
+func (r *Registry) ListTokens(context.Context) ([]Token, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tokens := make([]Token, 0, len(r.data.Tokens))
+	for _, token := range r.data.Tokens {
+		token.Hash = ""
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// CreateToken issues a token to a client, that expires after ttl, or never if
+// ttl is zero.
+func (r *Registry) CreateToken(
+	_ context.Context, clientID string, scopes []handlerwrapper.Scope, ttl time.Duration) (string, Token, error) {
+	if clientID == "" {
+		return "", Token{}, fmt.Errorf("tokens must be issued to a client ID")
+	}
+	random := make([]byte, tokenBytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", Token{}, fmt.Errorf("failed to generate token: %w", err)
+	}
+	secret := tokenPrefix + base64.RawURLEncoding.EncodeToString(random)
+	hash := hashToken(secret)
+	now := r.clock().UTC()
+	token := Token{
+		ID:        hash[:tokenIDLength],
+		ClientID:  clientID,
+		Hash:      hash,
+		Scopes:    scopes,
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		token.ExpiresAt = now.Add(ttl)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data.Tokens = append(r.data.Tokens, token)
+	if err := r.save(); err != nil {
+		return "", Token{}, err
+	}
+	token.Hash = ""
+	return secret, token, nil
+}
+
+func (r *Registry) RevokeToken(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, token := range r.data.Tokens {
+		if token.ID == id {
+			r.data.Tokens = append(r.data.Tokens[:i], r.data.Tokens[i+1:]...)
+			return r.save()
+		}
+	}
+	return fmt.Errorf("no token with ID %s", id)
+}
+
+// Authenticate authenticates requests with a bearer token, or signed with a
+// registered client key.
+func (r *Registry) Authenticate(req *http.Request, body []byte) (*handlerwrapper.Identity, error) {
+	if authorization := req.Header.Get(handlerwrapper.HTTPHeaderAuthorization); authorization != "" {
+		if !strings.HasPrefix(authorization, bearerPrefix) {
+			return nil, fmt.Errorf("unsupported authorization scheme")
+		}
+		return r.authenticateToken(strings.TrimPrefix(authorization, bearerPrefix))
+	}
+	if publicKey := req.Header.Get(handlerwrapper.HTTPHeaderPublicKey); publicKey != "" {
+		return r.authenticateKey(req, publicKey, body)
+	}
+	return nil, fmt.Errorf("a bearer token or a signed request is required")
+}
+
+func (r *Registry) authenticateToken(secret string) (*handlerwrapper.Identity, error) {
+	hash := hashToken(secret)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, token := range r.data.Tokens {
+		if token.Hash != hash {
+			continue
+		}
+		if !token.ExpiresAt.IsZero() && r.clock().After(token.ExpiresAt) {
+			return nil, fmt.Errorf("token %s expired at %s", token.ID, token.ExpiresAt.Format(time.RFC3339))
+		}
+		return &handlerwrapper.Identity{
+			ClientID: token.ClientID,
+			Scopes:   token.Scopes,
+			Method:   "token",
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown token")
+}
+
+func (r *Registry) authenticateKey(req *http.Request, publicKey string, body []byte) (*handlerwrapper.Identity, error) {
+	timestamp := req.Header.Get(handlerwrapper.HTTPHeaderTimestamp)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request timestamp %q", timestamp)
+	}
+	skew := r.clock().Sub(time.Unix(seconds, 0))
+	if skew > r.maxClockSkew || skew < -r.maxClockSkew {
+		return nil, fmt.Errorf("request timestamp is too far from the node's clock")
+	}
+	signature := req.Header.Get(handlerwrapper.HTTPHeaderRequestSignature)
+	message := handlerwrapper.RequestSigningMessage(req.Method, req.URL.Path, timestamp, body)
+	if err = system.Verify(message, signature, publicKey); err != nil {
+		return nil, fmt.Errorf("invalid request signature: %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, key := range r.data.Keys {
+		if key.PublicKey == publicKey {
+			return &handlerwrapper.Identity{
+				ClientID: key.ClientID,
+				Scopes:   key.Scopes,
+				Method:   "key",
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("client key is not registered")
+}
+
+// save writes the registry to its file. Must be called with the lock held.
+func (r *Registry) save() error {
+	data, err := json.MarshalIndent(r.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write to a temporary file and rename it, so the registry is never left
+	// half written
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save auth registry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to save auth registry: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save auth registry: %w", err)
+	}
+	if err = os.Chmod(tmp.Name(), util.OS_USER_RW); err != nil {
+		return fmt.Errorf("failed to save auth registry: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("failed to save auth registry: %w", err)
+	}
+	return nil
+}
+
+func hashToken(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// compile-time checks that Registry implements the expected interfaces
+var _ Manager = (*Registry)(nil)
+var _ handlerwrapper.Authenticator = (*Registry)(nil)
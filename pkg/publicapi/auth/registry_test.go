@@ -0,0 +1,174 @@
+//go:build unit || !integration
+
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type RegistrySuite struct {
+	suite.Suite
+	ctx      context.Context
+	path     string
+	now      time.Time
+	registry *Registry
+}
+
+func TestRegistrySuite(t *testing.T) {
+	suite.Run(t, new(RegistrySuite))
+}
+
+func (s *RegistrySuite) SetupTest() {
+	s.Require().NoError(system.InitConfigForTesting(s.T()))
+	s.ctx = context.Background()
+	s.path = filepath.Join(s.T().TempDir(), "auth.json")
+	s.now = time.Now()
+	s.registry = s.newRegistry()
+}
+
+func (s *RegistrySuite) newRegistry() *Registry {
+	registry, err := NewRegistry(RegistryParams{
+		Path:  s.path,
+		Clock: func() time.Time { return s.now },
+	})
+	s.Require().NoError(err)
+	return registry
+}
+
+// signedRequest returns a request signed with this client's key.
+func (s *RegistrySuite) signedRequest(body string, at time.Time) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/requester/list", strings.NewReader(body))
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	signature, err := system.SignForClient(handlerwrapper.RequestSigningMessage(req.Method, req.URL.Path, timestamp, []byte(body)))
+	s.Require().NoError(err)
+	req.Header.Set(handlerwrapper.HTTPHeaderPublicKey, system.GetClientPublicKey())
+	req.Header.Set(handlerwrapper.HTTPHeaderTimestamp, timestamp)
+	req.Header.Set(handlerwrapper.HTTPHeaderRequestSignature, signature)
+	return req
+}
+
+func tokenRequest(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/requester/list", nil)
+	req.Header.Set(handlerwrapper.HTTPHeaderAuthorization, "Bearer "+token)
+	return req
+}
+
+func (s *RegistrySuite) TestKeyAuthentication() {
+	body := `{"client_id": "me"}`
+
+	_, err := s.registry.Authenticate(s.signedRequest(body, s.now), []byte(body))
+	s.Require().ErrorContains(err, "not registered")
+
+	key, err := s.registry.AddKey(s.ctx, system.GetClientPublicKey(), []handlerwrapper.Scope{handlerwrapper.ScopeSubmit}, "me")
+	s.Require().NoError(err)
+	s.Require().Equal(system.GetClientID(), key.ClientID)
+
+	identity, err := s.registry.Authenticate(s.signedRequest(body, s.now), []byte(body))
+	s.Require().NoError(err)
+	s.Require().Equal(system.GetClientID(), identity.ClientID)
+	s.Require().True(identity.HasScope(handlerwrapper.ScopeSubmit))
+	s.Require().False(identity.HasScope(handlerwrapper.ScopeListOwn))
+
+	// the body is signed
+	_, err = s.registry.Authenticate(s.signedRequest(body, s.now), []byte(`{"client_id": "someone else"}`))
+	s.Require().ErrorContains(err, "invalid request signature")
+
+	// old requests can't be replayed
+	_, err = s.registry.Authenticate(s.signedRequest(body, s.now.Add(-time.Hour)), []byte(body))
+	s.Require().ErrorContains(err, "timestamp")
+
+	s.Require().NoError(s.registry.RemoveKey(s.ctx, key.ClientID))
+	_, err = s.registry.Authenticate(s.signedRequest(body, s.now), []byte(body))
+	s.Require().ErrorContains(err, "not registered")
+}
+
+func (s *RegistrySuite) TestTokenAuthentication() {
+	secret, token, err := s.registry.CreateToken(s.ctx, "client", []handlerwrapper.Scope{handlerwrapper.ScopeListAll}, time.Hour)
+	s.Require().NoError(err)
+	s.Require().Empty(token.Hash)
+
+	identity, err := s.registry.Authenticate(tokenRequest(secret), nil)
+	s.Require().NoError(err)
+	s.Require().Equal("client", identity.ClientID)
+	s.Require().True(identity.HasScope(handlerwrapper.ScopeListOwn))
+	s.Require().True(identity.CanAccessClient("another client"))
+
+	_, err = s.registry.Authenticate(tokenRequest(secret+"x"), nil)
+	s.Require().ErrorContains(err, "unknown token")
+
+	s.now = s.now.Add(2 * time.Hour)
+	_, err = s.registry.Authenticate(tokenRequest(secret), nil)
+	s.Require().ErrorContains(err, "expired")
+
+	s.Require().NoError(s.registry.RevokeToken(s.ctx, token.ID))
+	tokens, err := s.registry.ListTokens(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Empty(tokens)
+}
+
+func (s *RegistrySuite) TestPersistence() {
+	_, err := s.registry.AddKey(s.ctx, system.GetClientPublicKey(), []handlerwrapper.Scope{handlerwrapper.ScopeAdmin}, "")
+	s.Require().NoError(err)
+	secret, _, err := s.registry.CreateToken(s.ctx, "client", []handlerwrapper.Scope{handlerwrapper.ScopeSubmit}, 0)
+	s.Require().NoError(err)
+
+	reloaded := s.newRegistry()
+	keys, err := reloaded.ListKeys(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(keys, 1)
+	identity, err := reloaded.Authenticate(tokenRequest(secret), nil)
+	s.Require().NoError(err)
+	s.Require().Equal("client", identity.ClientID)
+}
+
+func (s *RegistrySuite) TestAuthHandler() {
+	_, err := s.registry.AddKey(s.ctx, system.GetClientPublicKey(), []handlerwrapper.Scope{handlerwrapper.ScopeListOwn}, "")
+	s.Require().NoError(err)
+
+	var seen *handlerwrapper.Identity
+	var seenBody string
+	handler := func(scope handlerwrapper.Scope) http.Handler {
+		return handlerwrapper.NewAuthHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = handlerwrapper.IdentityFromContext(r.Context())
+			b, _ := io.ReadAll(r.Body)
+			seenBody = string(b)
+		}), s.registry, scope, 0)
+	}
+
+	body := `{"max_jobs": 10}`
+	res := httptest.NewRecorder()
+	handler(handlerwrapper.ScopeListOwn).ServeHTTP(res, s.signedRequest(body, s.now))
+	s.Require().Equal(http.StatusOK, res.Code)
+	s.Require().Equal(system.GetClientID(), seen.ClientID)
+	s.Require().Equal(body, seenBody, "the handler should still be able to read the body")
+
+	res = httptest.NewRecorder()
+	handler(handlerwrapper.ScopeSubmit).ServeHTTP(res, s.signedRequest(body, s.now))
+	s.Require().Equal(http.StatusForbidden, res.Code)
+
+	res = httptest.NewRecorder()
+	handler("").ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/requester/list", strings.NewReader(body)))
+	s.Require().Equal(http.StatusUnauthorized, res.Code)
+}
+
+func TestParseScope(t *testing.T) {
+	scope, err := handlerwrapper.ParseScope("LIST-OWN")
+	require.NoError(t, err)
+	require.Equal(t, handlerwrapper.ScopeListOwn, scope)
+
+	_, err = handlerwrapper.ParseScope("everything")
+	require.Error(t, err)
+}
@@ -8,10 +8,12 @@ import (
 	"io"
 	"net/http"
 	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/filecoin-project/bacalhau/pkg/bacerrors"
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/handlerwrapper"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/filecoin-project/bacalhau/pkg/util/closer"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
@@ -23,15 +25,22 @@ import (
 type APIClient struct {
 	BaseURI        string
 	DefaultHeaders map[string]string
+	// sign requests with the client's ID key, for nodes that only accept
+	// registered clients
+	SignRequests bool
 
 	Client *http.Client
 }
 
 // NewAPIClient returns a new client for a node's API server.
 func NewAPIClient(baseURI string) *APIClient {
+	defaultHeaders := map[string]string{}
+	if token := system.GetAPIToken(); token != "" {
+		defaultHeaders[handlerwrapper.HTTPHeaderAuthorization] = "Bearer " + token
+	}
 	return &APIClient{
 		BaseURI:        baseURI,
-		DefaultHeaders: map[string]string{},
+		DefaultHeaders: defaultHeaders,
 
 		Client: &http.Client{
 			Timeout: 300 * time.Second,
@@ -85,18 +94,59 @@ func (apiClient *APIClient) Post(ctx context.Context, api string, reqData, resDa
 	ctx, span := system.GetTracer().Start(ctx, "pkg/publicapi.Post")
 	defer span.End()
 
+	res, err := apiClient.post(ctx, api, reqData)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err = res.Body.Close(); err != nil {
+			err = fmt.Errorf("error closing response body: %v", err)
+		}
+	}()
+
+	err = json.NewDecoder(res.Body).Decode(resData)
+	if err != nil {
+		if err == io.EOF {
+			return nil // No error, just no data
+		} else {
+			return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error decoding response body: %v", err))
+		}
+	}
+
+	return nil
+}
+
+// PostStream makes a request like Post, but returns the body of the response
+// to be read as a stream, which the caller must close.
+func (apiClient *APIClient) PostStream(ctx context.Context, api string, reqData interface{}) (io.ReadCloser, error) {
+	ctx, span := system.GetTracer().Start(ctx, "pkg/publicapi.PostStream")
+	defer span.End()
+
+	res, err := apiClient.post(ctx, api, reqData)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+func (apiClient *APIClient) post(ctx context.Context, api string, reqData interface{}) (*http.Response, error) {
 	var body bytes.Buffer
 	var err error
 	if err = json.NewEncoder(&body).Encode(reqData); err != nil {
-		return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error encoding request body: %v", err))
+		return nil, bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error encoding request body: %v", err))
 	}
 
 	addr := fmt.Sprintf("%s/%s", apiClient.BaseURI, api)
+	bodyBytes := body.Bytes()
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, addr, &body)
 	if err != nil {
-		return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error creating Post request: %v", err))
+		return nil, bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error creating Post request: %v", err))
 	}
 	req.Header.Set("Content-type", "application/json")
+	if err = signRequest(req, bodyBytes); err != nil {
+		return nil, bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error signing request: %v", err))
+	}
 	for header, value := range apiClient.DefaultHeaders {
 		req.Header.Set(header, value)
 	}
@@ -107,46 +157,49 @@ func (apiClient *APIClient) Post(ctx context.Context, api string, reqData, resDa
 	if err != nil {
 		errString := err.Error()
 		if errorResponse, ok := err.(*bacerrors.ErrorResponse); ok {
-			return errorResponse
+			return nil, errorResponse
 		} else if errString == "context canceled" {
-			return bacerrors.NewContextCanceledError(err.Error())
+			return nil, bacerrors.NewContextCanceledError(err.Error())
 		} else {
-			return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: after posting request: %v", err))
+			return nil, bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: after posting request: %v", err))
 		}
 	}
 
-	defer func() {
-		if err = res.Body.Close(); err != nil {
-			err = fmt.Errorf("error closing response body: %v", err)
-		}
-	}()
-
 	if res.StatusCode != http.StatusOK {
 		var responseBody []byte
 		responseBody, err = io.ReadAll(res.Body)
+		closer.CloseWithLogOnError("apiClient response", res.Body)
 		if err != nil {
-			return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error reading response body: %v", err))
+			return nil, bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error reading response body: %v", err))
 		}
 
 		var serverError *bacerrors.ErrorResponse
 		if err = model.JSONUnmarshalWithMax(responseBody, &serverError); err != nil {
-			return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: after posting request: %v",
+			return nil, bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: after posting request: %v",
 				string(responseBody)))
 		}
 
 		if !reflect.DeepEqual(serverError, bacerrors.BacalhauErrorInterface(nil)) {
-			return serverError
+			return nil, serverError
 		}
+		// the body has been read already, so there is nothing left to decode
+		res.Body = io.NopCloser(bytes.NewReader(nil))
 	}
 
-	err = json.NewDecoder(res.Body).Decode(resData)
+	return res, nil
+}
+
+// signRequest signs a request with the client's ID key, so that requester
+// nodes that only accept registered clients can authenticate it.
+func signRequest(req *http.Request, body []byte) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	signature, err := system.SignForClient(
+		handlerwrapper.RequestSigningMessage(req.Method, req.URL.Path, timestamp, body))
 	if err != nil {
-		if err == io.EOF {
-			return nil // No error, just no data
-		} else {
-			return bacerrors.NewResponseUnknownError(fmt.Errorf("publicapi: error decoding response body: %v", err))
-		}
+		return err
 	}
-
+	req.Header.Set(handlerwrapper.HTTPHeaderPublicKey, system.GetClientPublicKey())
+	req.Header.Set(handlerwrapper.HTTPHeaderTimestamp, timestamp)
+	req.Header.Set(handlerwrapper.HTTPHeaderRequestSignature, signature)
 	return nil
 }
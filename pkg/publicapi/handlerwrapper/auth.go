@@ -0,0 +1,144 @@
+package handlerwrapper
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/filecoin-project/bacalhau/pkg/bacerrors"
+)
+
+// Scope is something an authenticated client is allowed to do.
+type Scope string
+
+const (
+	// ScopeSubmit allows submitting jobs.
+	ScopeSubmit Scope = "submit"
+	// ScopeListOwn allows reading the client's own jobs.
+	ScopeListOwn Scope = "list-own"
+	// ScopeListAll allows reading every client's jobs.
+	ScopeListAll Scope = "list-all"
+	// ScopeAdmin allows everything, including managing keys and tokens.
+	ScopeAdmin Scope = "admin"
+)
+
+func Scopes() []Scope {
+	return []Scope{ScopeSubmit, ScopeListOwn, ScopeListAll, ScopeAdmin}
+}
+
+func ParseScope(s string) (Scope, error) {
+	for _, scope := range Scopes() {
+		if strings.EqualFold(s, string(scope)) {
+			return scope, nil
+		}
+	}
+	return "", fmt.Errorf("unknown scope %q, must be one of %v", s, Scopes())
+}
+
+// Identity is who made a request, and what they are allowed to do.
+type Identity struct {
+	ClientID string  `json:"ClientID"`
+	Scopes   []Scope `json:"Scopes"`
+	// how the client authenticated, "key" or "token"
+	Method string `json:"Method"`
+}
+
+// HasScope returns true if the identity is allowed what scope allows. Admin
+// is allowed everything, and list-all includes list-own.
+func (i *Identity) HasScope(scope Scope) bool {
+	for _, s := range i.Scopes {
+		if s == scope || s == ScopeAdmin || (s == ScopeListAll && scope == ScopeListOwn) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessClient returns true if the identity can read the jobs of a client.
+// A nil identity, when authentication is disabled, can access any client.
+func (i *Identity) CanAccessClient(clientID string) bool {
+	return i == nil || i.ClientID == clientID || i.HasScope(ScopeListAll)
+}
+
+type identityKey struct{}
+
+// IdentityFromContext returns the identity AuthHandler authenticated, or nil
+// if authentication is disabled.
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
+
+func ContextWithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// Authenticator works out who made a request from its headers and body.
+type Authenticator interface {
+	Authenticate(r *http.Request, body []byte) (*Identity, error)
+}
+
+// RequestSigningMessage returns what clients sign with their ID key to
+// authenticate a request.
+func RequestSigningMessage(method, path, timestamp string, body []byte) []byte {
+	bodyHash := sha256.Sum256(body)
+	return []byte(strings.Join([]string{method, path, timestamp, hex.EncodeToString(bodyHash[:])}, "\n"))
+}
+
+type AuthHandler struct {
+	httpHandler   http.Handler
+	authenticator Authenticator
+	scope         Scope
+	maxBodyBytes  int64
+}
+
+// NewAuthHandler returns a handler that only calls httpHandler for requests
+// from authenticated clients that have scope, or any authenticated client if
+// scope is empty. The identity is added to the request's context. Requests
+// with a body larger than maxBodyBytes are rejected, unless it is zero.
+func NewAuthHandler(httpHandler http.Handler, authenticator Authenticator, scope Scope, maxBodyBytes int64) *AuthHandler {
+	return &AuthHandler{
+		httpHandler:   httpHandler,
+		authenticator: authenticator,
+		scope:         scope,
+		maxBodyBytes:  maxBodyBytes,
+	}
+}
+
+func (h *AuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the body is part of what is signed, so read it and put it back. Raw
+	// routes aren't wrapped in a MaxBytesHandler, so limit it here too.
+	if h.maxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.maxBodyBytes)
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, bacerrors.ErrorToErrorResponse(err), status)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	identity, err := h.authenticator.Authenticate(r, body)
+	if err != nil {
+		http.Error(w, bacerrors.ErrorToErrorResponse(fmt.Errorf("unauthorized: %w", err)), http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set(HTTPHeaderClientID, identity.ClientID)
+	if h.scope != "" && !identity.HasScope(h.scope) {
+		err = fmt.Errorf("forbidden: client %s does not have the %s scope", identity.ClientID, h.scope)
+		http.Error(w, bacerrors.ErrorToErrorResponse(err), http.StatusForbidden)
+		return
+	}
+	h.httpHandler.ServeHTTP(w, r.WithContext(ContextWithIdentity(r.Context(), identity)))
+}
@@ -3,3 +3,13 @@ package handlerwrapper
 var HTTPHeaderClientID = "X-Bacalhau-Client-ID"
 
 var HTTPHeaderJobID = "X-Bacalhau-Job-ID"
+
+var HTTPHeaderAuthorization = "Authorization"
+
+// HTTPHeaderPublicKey, HTTPHeaderTimestamp and HTTPHeaderRequestSignature sign
+// requests with the client's ID key, see RequestSigningMessage.
+var HTTPHeaderPublicKey = "X-Bacalhau-Public-Key"
+
+var HTTPHeaderTimestamp = "X-Bacalhau-Timestamp"
+
+var HTTPHeaderRequestSignature = "X-Bacalhau-Request-Signature"
@@ -116,6 +116,12 @@ func (apiServer *APIServer) GetURI() string {
 	return fmt.Sprintf("http://%s:%d", apiServer.Address, apiServer.Port)
 }
 
+// MaxBodyBytes returns the size of the largest request body the server's
+// handlers read.
+func (apiServer *APIServer) MaxBodyBytes() int64 {
+	return int64(apiServer.config.MaxBytesToReadInBody)
+}
+
 // @title         Bacalhau API
 // @description   This page is the reference of the Bacalhau REST API. Project docs are available at https://docs.bacalhau.org/. Find more information about Bacalhau at https://github.com/filecoin-project/bacalhau.
 // @contact.name  Bacalhau Team
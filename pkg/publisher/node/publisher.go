@@ -0,0 +1,219 @@
+// Package node publishes results by keeping them on the compute node, which
+// serves them to clients through the requester node so that they never leave
+// the cluster through a storage network.
+package node
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/job"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/publisher"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/util/tarstream"
+	"github.com/filecoin-project/bacalhau/pkg/verifier/manifest"
+	"github.com/rs/zerolog/log"
+)
+
+const defaultExpiryInterval = time.Minute
+
+// requesterFile is kept in the folder of a job, next to the folders of its
+// shards, and holds the ID of the requester node results are served to.
+const requesterFile = "requester"
+
+type NodePublisherParams struct {
+	// ResultsDir is where results are kept, in a folder per job and shard.
+	ResultsDir string
+	// Retention is how long results are kept for after they are published.
+	Retention time.Duration
+	// ExpiryInterval is how often results that are kept for longer than Retention are removed.
+	ExpiryInterval time.Duration
+}
+
+// NodePublisher keeps the results of shards on the compute node until they
+// expire, and serves them to whoever fetches them through the node.
+type NodePublisher struct {
+	resultsDir string
+	retention  time.Duration
+	interval   time.Duration
+
+	stopChannel chan struct{}
+	stopOnce    sync.Once
+}
+
+func NewNodePublisher(params NodePublisherParams) (*NodePublisher, error) {
+	err := os.MkdirAll(params.ResultsDir, model.DownloadFolderPerm)
+	if err != nil {
+		return nil, fmt.Errorf("error creating retained results dir %s: %w", params.ResultsDir, err)
+	}
+	p := &NodePublisher{
+		resultsDir:  params.ResultsDir,
+		retention:   params.Retention,
+		interval:    params.ExpiryInterval,
+		stopChannel: make(chan struct{}),
+	}
+	if p.interval == 0 {
+		p.interval = defaultExpiryInterval
+	}
+
+	go p.expiryBackgroundTask()
+	return p, nil
+}
+
+func (p *NodePublisher) IsInstalled(context.Context) (bool, error) {
+	return true, nil
+}
+
+func (p *NodePublisher) PublishShardResult(
+	ctx context.Context,
+	shard model.JobShard,
+	hostID string,
+	shardResultPath string,
+) (model.StorageSpec, error) {
+	ctx, span := system.GetTracer().Start(ctx, "pkg/publisher/node.PublishShardResult")
+	defer span.End()
+
+	dir, err := p.shardDir(shard.Job.Metadata.ID, shard.Index)
+	if err != nil {
+		return model.StorageSpec{}, err
+	}
+	err = os.RemoveAll(dir)
+	if err != nil {
+		return model.StorageSpec{}, err
+	}
+
+	// copy the results, as the verifier's results dir doesn't outlive the node
+	archive := tarstream.Reader(ctx, shardResultPath)
+	defer archive.Close()
+	err = tarstream.Extract(archive, dir)
+	if err != nil {
+		return model.StorageSpec{}, fmt.Errorf("error keeping results of shard %s: %w", shard.ID(), err)
+	}
+	requesterNodeID := shard.Job.Status.Requester.RequesterNodeID
+	err = os.WriteFile(filepath.Join(filepath.Dir(dir), requesterFile), []byte(requesterNodeID), model.DownloadFilePerm)
+	if err != nil {
+		return model.StorageSpec{}, fmt.Errorf("error recording requester of shard %s: %w", shard.ID(), err)
+	}
+
+	// results expire a retention period after the time they were kept
+	now := time.Now()
+	err = os.Chtimes(dir, now, now)
+	if err != nil {
+		return model.StorageSpec{}, err
+	}
+
+	// identify the results by their contents, like a CID, so that downloading
+	// identical results of several nodes only fetches them once
+	m, err := manifest.Build(dir)
+	if err != nil {
+		return model.StorageSpec{}, err
+	}
+	spec := job.GetPublishedStorageSpec(shard, model.StorageSourceNode, hostID, m.Hash())
+	spec.Metadata[model.MetadataJobID] = shard.Job.Metadata.ID
+	return spec, nil
+}
+
+// FetchResults streams the results of a shard to the requester of its job, as
+// long as they haven't expired.
+func (p *NodePublisher) FetchResults(ctx context.Context, request compute.FetchResultsRequest) (io.ReadCloser, error) {
+	dir, err := p.shardDir(request.JobID, request.ShardIndex)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) || (err == nil && p.expired(info)) {
+		return nil, fmt.Errorf("results of shard %d of job %s are not kept on this node", request.ShardIndex, request.JobID)
+	} else if err != nil {
+		return nil, err
+	}
+	requesterNodeID, err := os.ReadFile(filepath.Join(filepath.Dir(dir), requesterFile))
+	if err != nil {
+		return nil, fmt.Errorf("error reading requester of job %s: %w", request.JobID, err)
+	}
+	if request.SourcePeerID == "" || request.SourcePeerID != string(requesterNodeID) {
+		return nil, fmt.Errorf("results of job %s are only served to its requester", request.JobID)
+	}
+	return tarstream.Reader(ctx, dir), nil
+}
+
+// Stop stops removing expired results.
+func (p *NodePublisher) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopChannel)
+	})
+}
+
+func (p *NodePublisher) shardDir(jobID string, shardIndex int) (string, error) {
+	// the job ID comes from clients, so don't let it point anywhere else
+	if jobID == "" || jobID == "." || jobID == ".." || filepath.Base(jobID) != jobID {
+		return "", fmt.Errorf("invalid job ID %q", jobID)
+	}
+	return filepath.Join(p.resultsDir, jobID, strconv.Itoa(shardIndex)), nil
+}
+
+func (p *NodePublisher) expired(info os.FileInfo) bool {
+	return time.Since(info.ModTime()) > p.retention
+}
+
+func (p *NodePublisher) expiryBackgroundTask() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.removeExpired()
+		case <-p.stopChannel:
+			return
+		}
+	}
+}
+
+// removeExpired removes the results that expired, and the folders of jobs
+// with no results left along with their requester.
+func (p *NodePublisher) removeExpired() {
+	jobs, err := os.ReadDir(p.resultsDir)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list retained results")
+		return
+	}
+	for _, jobEntry := range jobs {
+		jobDir := filepath.Join(p.resultsDir, jobEntry.Name())
+		shards, err := os.ReadDir(jobDir)
+		if err != nil {
+			log.Error().Err(err).Msgf("failed to list retained results of job %s", jobEntry.Name())
+			continue
+		}
+		remaining := 0
+		for _, shardEntry := range shards {
+			if !shardEntry.IsDir() {
+				continue
+			}
+			remaining++
+			info, err := shardEntry.Info()
+			if err != nil || !p.expired(info) {
+				continue
+			}
+			err = os.RemoveAll(filepath.Join(jobDir, shardEntry.Name()))
+			if err != nil {
+				log.Error().Err(err).Msgf("failed to remove expired results of job %s", jobEntry.Name())
+				continue
+			}
+			remaining--
+		}
+		if remaining == 0 {
+			_ = os.RemoveAll(jobDir)
+		}
+	}
+}
+
+// compile-time interface check
+var _ publisher.Publisher = (*NodePublisher)(nil)
+var _ compute.ResultsProvider = (*NodePublisher)(nil)
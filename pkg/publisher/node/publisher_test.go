@@ -0,0 +1,101 @@
+//go:build unit || !integration
+
+package node
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/util/tarstream"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPublisher(t *testing.T, retention time.Duration) *NodePublisher {
+	p, err := NewNodePublisher(NodePublisherParams{
+		ResultsDir: t.TempDir(),
+		Retention:  retention,
+	})
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+	return p
+}
+
+func publishTestResults(t *testing.T, p *NodePublisher, jobID string) model.StorageSpec {
+	results := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(results, "stdout"), []byte("hello"), model.DownloadFilePerm))
+	shard := model.JobShard{Job: &model.Job{
+		Metadata: model.Metadata{ID: jobID},
+		Status:   model.JobStatus{Requester: model.JobRequester{RequesterNodeID: "requester"}},
+	}, Index: 1}
+	spec, err := p.PublishShardResult(context.Background(), shard, "host", results)
+	require.NoError(t, err)
+	return spec
+}
+
+func fetchRequest(jobID string, shardIndex int) compute.FetchResultsRequest {
+	return compute.FetchResultsRequest{
+		RoutingMetadata: compute.RoutingMetadata{SourcePeerID: "requester"},
+		JobID:           jobID,
+		ShardIndex:      shardIndex,
+	}
+}
+
+func TestPublishAndFetch(t *testing.T) {
+	p := newTestPublisher(t, time.Hour)
+	spec := publishTestResults(t, p, "job-id")
+	require.Equal(t, model.StorageSourceNode, spec.StorageSource)
+	require.Equal(t, "job-id", spec.Metadata[model.MetadataJobID])
+	require.NotEmpty(t, spec.CID)
+
+	archive, err := p.FetchResults(context.Background(), fetchRequest("job-id", 1))
+	require.NoError(t, err)
+	defer archive.Close()
+	dst := filepath.Join(t.TempDir(), "results")
+	require.NoError(t, tarstream.Extract(archive, dst))
+	contents, err := os.ReadFile(filepath.Join(dst, "stdout"))
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(contents))
+
+	_, err = p.FetchResults(context.Background(), fetchRequest("job-id", 0))
+	require.Error(t, err)
+}
+
+func TestFetchFromOtherNode(t *testing.T) {
+	p := newTestPublisher(t, time.Hour)
+	publishTestResults(t, p, "job-id")
+
+	request := fetchRequest("job-id", 1)
+	for _, source := range []string{"", "other-requester"} {
+		request.SourcePeerID = source
+		_, err := p.FetchResults(context.Background(), request)
+		require.ErrorContains(t, err, "only served to its requester")
+	}
+}
+
+func TestFetchInvalidJobID(t *testing.T) {
+	p := newTestPublisher(t, time.Hour)
+	for _, jobID := range []string{"", ".", "..", "../job-id", "job-id/1"} {
+		_, err := p.FetchResults(context.Background(), fetchRequest(jobID, 0))
+		require.Error(t, err, jobID)
+	}
+}
+
+func TestExpiredResults(t *testing.T) {
+	p := newTestPublisher(t, time.Hour)
+	publishTestResults(t, p, "job-id")
+
+	// pretend the results were kept before the retention period
+	past := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(p.resultsDir, "job-id", "1"), past, past))
+
+	_, err := p.FetchResults(context.Background(), fetchRequest("job-id", 1))
+	require.Error(t, err)
+
+	p.removeExpired()
+	require.NoDirExists(t, filepath.Join(p.resultsDir, "job-id"))
+}
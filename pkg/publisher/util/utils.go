@@ -21,6 +21,8 @@ func NewIPFSPublishers(
 	ipfsMultiAddress string,
 	estuaryAPIKey string,
 	lotusConfig *filecoinlotus.PublisherConfig,
+	// optional, keeps results on the compute node
+	nodePublisher publisher.Publisher,
 ) (publisher.PublisherProvider, error) {
 	defaultPriorityPublisherTimeout := time.Second * 2
 	noopPublisher := noop.NewNoopPublisher()
@@ -51,12 +53,16 @@ func NewIPFSPublishers(
 		}
 	}
 
-	return publisher.NewMappedPublisherProvider(map[model.Publisher]publisher.Publisher{
+	publishers := map[model.Publisher]publisher.Publisher{
 		model.PublisherNoop:     noopPublisher,
 		model.PublisherIpfs:     ipfsPublisher,
 		model.PublisherEstuary:  estuaryPublisher,
 		model.PublisherFilecoin: combo.NewPiggybackedPublisher(ipfsPublisher, lotus),
-	}), nil
+	}
+	if nodePublisher != nil {
+		publishers[model.PublisherNode] = nodePublisher
+	}
+	return publisher.NewMappedPublisherProvider(publishers), nil
 }
 
 func NewNoopPublishers(
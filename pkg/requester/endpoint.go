@@ -8,6 +8,7 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/localdb"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/requester/jobtransform"
+	"github.com/filecoin-project/bacalhau/pkg/requester/quota"
 	"github.com/filecoin-project/bacalhau/pkg/storage"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/filecoin-project/bacalhau/pkg/verifier"
@@ -23,8 +24,11 @@ type BaseEndpointParams struct {
 	Scheduler                  *Scheduler
 	Verifiers                  verifier.VerifierProvider
 	StorageProviders           storage.StorageProvider
+	Encrypter                  verifier.EncrypterFunction
 	MinJobExecutionTimeout     time.Duration
 	DefaultJobExecutionTimeout time.Duration
+	// optional, rejects jobs that would take their client over its limits
+	QuotaTracker *quota.Tracker
 }
 
 // BaseEndpoint base implementation of requester Endpoint
@@ -34,6 +38,7 @@ type BaseEndpoint struct {
 	jobStore   localdb.LocalDB
 	scheduler  *Scheduler
 	transforms []jobtransform.Transformer
+	quota      *quota.Tracker
 }
 
 func NewBaseEndpoint(params *BaseEndpointParams) *BaseEndpoint {
@@ -42,6 +47,16 @@ func NewBaseEndpoint(params *BaseEndpointParams) *BaseEndpoint {
 		jobtransform.NewTimeoutApplier(params.MinJobExecutionTimeout, params.DefaultJobExecutionTimeout),
 		jobtransform.NewExecutionPlanner(params.StorageProviders),
 	}
+	if params.Encrypter != nil {
+		transforms = append(transforms,
+			jobtransform.NewRegistryAuthSealer(params.PublicKey, params.Encrypter),
+			jobtransform.NewNotificationSecretSealer(params.PublicKey, params.Encrypter),
+		)
+	}
+	if params.QuotaTracker != nil {
+		// admission comes last, once the job's timeout and execution plan are set
+		transforms = append(transforms, params.QuotaTracker.Admit)
+	}
 
 	return &BaseEndpoint{
 		id:         params.ID,
@@ -49,6 +64,7 @@ func NewBaseEndpoint(params *BaseEndpointParams) *BaseEndpoint {
 		jobStore:   params.JobStore,
 		scheduler:  params.Scheduler,
 		transforms: transforms,
+		quota:      params.QuotaTracker,
 	}
 }
 
@@ -97,6 +113,10 @@ func (node *BaseEndpoint) SubmitJob(ctx context.Context, data model.JobCreatePay
 		Job: *job,
 	})
 	if err != nil {
+		if node.quota != nil {
+			// the job never started, so it shouldn't count against its client
+			node.quota.Release(job.Metadata.ID)
+		}
 		return &model.Job{}, fmt.Errorf("error starting job: %w", err)
 	}
 
@@ -107,9 +127,18 @@ func (node *BaseEndpoint) UpdateDeal(ctx context.Context, jobID string, deal mod
 	return node.jobStore.UpdateJobDeal(ctx, jobID, deal)
 }
 
+// CancelJob fails the job's shards that are still running, and cancels their executions.
 func (node *BaseEndpoint) CancelJob(ctx context.Context, request CancelJobRequest) (CancelJobResult, error) {
-	//TODO implement me
-	panic("implement me")
+	job, err := node.jobStore.GetJob(ctx, request.JobID)
+	if err != nil {
+		return CancelJobResult{}, err
+	}
+	reason := request.Reason
+	if reason == "" {
+		reason = "job cancelled"
+	}
+	node.scheduler.CancelJob(ctx, job, reason)
+	return CancelJobResult{}, nil
 }
 
 func (node *BaseEndpoint) newRootSpanForJob(ctx context.Context, jobID string) (context.Context, trace.Span) {
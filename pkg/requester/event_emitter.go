@@ -2,6 +2,7 @@ package requester
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/filecoin-project/bacalhau/pkg/compute"
@@ -46,12 +47,14 @@ func (e EventEmitter) EmitBidReceived(
 	// we flip senders to mimic a bid was received instead of being asked
 	event.SourceNodeID = request.RoutingMetadata.TargetPeerID
 	event.TargetNodeID = "" // localdb don't assume a target node for events coming from compute nodes
+	event.Price = response.Price
 	e.EmitEventSilently(ctx, event)
 }
 
 func (e EventEmitter) EmitBidAccepted(
 	ctx context.Context, request compute.BidAcceptedRequest, response compute.BidAcceptedResponse) {
 	event := e.constructEvent(request.RoutingMetadata, response.ExecutionMetadata, model.JobEventBidAccepted)
+	event.Price = request.Price
 	e.EmitEventSilently(ctx, event)
 }
 
@@ -61,22 +64,25 @@ func (e EventEmitter) EmitBidRejected(
 	e.EmitEventSilently(ctx, event)
 }
 
-func (e EventEmitter) EmitResultAccepted(
-	ctx context.Context, request compute.ResultAcceptedRequest, response compute.ResultAcceptedResponse) {
+func (e EventEmitter) EmitResultAccepted(ctx context.Context,
+	request compute.ResultAcceptedRequest, response compute.ResultAcceptedResponse, diff *model.ResultsDiff) {
 	event := e.constructEvent(request.RoutingMetadata, response.ExecutionMetadata, model.JobEventResultsAccepted)
 	event.VerificationResult = model.VerificationResult{
 		Complete: true,
 		Result:   true,
+		Diff:     diff,
 	}
 	e.EmitEventSilently(ctx, event)
 }
 
-func (e EventEmitter) EmitResultRejected(
-	ctx context.Context, request compute.ResultRejectedRequest, response compute.ResultRejectedResponse) {
+func (e EventEmitter) EmitResultRejected(ctx context.Context,
+	request compute.ResultRejectedRequest, response compute.ResultRejectedResponse, diff *model.ResultsDiff) {
 	event := e.constructEvent(request.RoutingMetadata, response.ExecutionMetadata, model.JobEventResultsRejected)
+	event.Status = request.Justification
 	event.VerificationResult = model.VerificationResult{
 		Complete: true,
 		Result:   false,
+		Diff:     diff,
 	}
 	e.EmitEventSilently(ctx, event)
 }
@@ -89,6 +95,17 @@ func (e EventEmitter) EmitRunComplete(ctx context.Context, response compute.RunR
 	e.EmitEventSilently(ctx, event)
 }
 
+// EmitImagePulled records how long the compute node spent pulling the job's image before running it.
+func (e EventEmitter) EmitImagePulled(ctx context.Context, response compute.RunResult) {
+	if response.RunCommandResult == nil || response.RunCommandResult.ImagePullDuration == 0 {
+		return
+	}
+	event := e.constructEvent(response.RoutingMetadata, response.ExecutionMetadata, model.JobEventImagePulled)
+	event.Status = fmt.Sprintf("Pulled image in %s", response.RunCommandResult.ImagePullDuration)
+	event.TargetNodeID = "" // localDB don't assume a target node for events coming from compute nodes
+	e.EmitEventSilently(ctx, event)
+}
+
 func (e EventEmitter) EmitPublishComplete(ctx context.Context, response compute.PublishResult) {
 	event := e.constructEvent(response.RoutingMetadata, response.ExecutionMetadata, model.JobEventResultsPublished)
 	event.PublishedResult = response.PublishResult
@@ -0,0 +1,46 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/eventhandler"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitImagePulled(t *testing.T) {
+	ctx := context.Background()
+	var events []model.JobEvent
+	emitter := NewEventEmitter(EventEmitterParams{
+		EventConsumer: eventhandler.JobEventHandlerFunc(func(ctx context.Context, event model.JobEvent) error {
+			events = append(events, event)
+			return nil
+		}),
+	})
+	result := compute.RunResult{
+		ExecutionMetadata: compute.ExecutionMetadata{JobID: "job-id", ExecutionID: "execution-a"},
+		RoutingMetadata:   compute.RoutingMetadata{SourcePeerID: "node-a", TargetPeerID: "requester"},
+		RunCommandResult:  &model.RunCommandResult{ImagePullDuration: 2 * time.Second},
+	}
+
+	emitter.EmitImagePulled(ctx, result)
+	require.Len(t, events, 1)
+	require.Equal(t, model.JobEventImagePulled, events[0].EventName)
+	require.Equal(t, "job-id", events[0].JobID)
+	require.Equal(t, "execution-a", events[0].ExecutionID)
+	require.Equal(t, "node-a", events[0].SourceNodeID)
+	require.Empty(t, events[0].TargetNodeID)
+	require.Equal(t, "Pulled image in 2s", events[0].Status)
+
+	// nothing is emitted for executions that didn't need to pull, or that didn't run in a container
+	result.RunCommandResult.ImagePullDuration = 0
+	emitter.EmitImagePulled(ctx, result)
+	result.RunCommandResult = nil
+	emitter.EmitImagePulled(ctx, result)
+	require.Len(t, events, 1)
+}
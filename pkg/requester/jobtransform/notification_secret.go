@@ -0,0 +1,27 @@
+package jobtransform
+
+import (
+	"context"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
+)
+
+// Encrypts any notification secrets submitted with the job for the requester
+// itself, so that they are never stored or returned in plain text
+func NewNotificationSecretSealer(publicKey []byte, seal verifier.EncrypterFunction) Transformer {
+	return func(ctx context.Context, job *model.Job) (modified bool, err error) {
+		for i := range job.Spec.Notifications {
+			notification := &job.Spec.Notifications[i]
+			if len(notification.Secret) == 0 {
+				continue
+			}
+			notification.Secret, err = seal(ctx, notification.Secret, publicKey)
+			if err != nil {
+				return false, err
+			}
+			modified = true
+		}
+		return modified, nil
+	}
+}
@@ -0,0 +1,20 @@
+package jobtransform
+
+import (
+	"context"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
+)
+
+// Encrypts any registry token submitted with the job for the requester itself,
+// so that it is never stored or returned in plain text
+func NewRegistryAuthSealer(publicKey []byte, seal verifier.EncrypterFunction) Transformer {
+	return func(ctx context.Context, job *model.Job) (modified bool, err error) {
+		if len(job.Spec.Docker.RegistryAuth) == 0 {
+			return
+		}
+		job.Spec.Docker.RegistryAuth, err = seal(ctx, job.Spec.Docker.RegistryAuth, publicKey)
+		return err == nil, err
+	}
+}
@@ -0,0 +1,176 @@
+package requester
+
+import (
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	sync "github.com/lukemarsden/golang-mutex-tracer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus metrics for monitoring requester nodes:
+var (
+	jobsByState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "requester_jobs",
+			Help: "Number of jobs tracked by the requester node, by whether their shards are in progress, completed or failed.",
+		},
+		[]string{"node_id", "state"},
+	)
+
+	shardsByState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "requester_job_shards",
+			Help: "Number of job shards tracked by the requester node, by the state of their lifecycle.",
+		},
+		[]string{"node_id", "state"},
+	)
+
+	shardStateDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "requester_job_shard_state_duration_seconds",
+			Help: "Time job shards spent in each state of their lifecycle on the requester node.",
+			// from a second to a few hours, as shards wait on bids and on jobs to run
+			Buckets: prometheus.ExponentialBuckets(1, 4, 8), //nolint:gomnd
+		},
+		[]string{"node_id", "state"},
+	)
+
+	bidsAsked = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "requester_bids_asked",
+			Help: "Number of times the requester node asked a compute node to bid on a job.",
+		},
+		[]string{"node_id", "compute_node_id"},
+	)
+
+	bidsReceived = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "requester_bids_received",
+			Help: "Number of shard bids the requester node received from a compute node.",
+		},
+		[]string{"node_id", "compute_node_id"},
+	)
+
+	bidsRejected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "requester_bids_rejected",
+			Help: "Number of shard bids from a compute node that the requester node rejected.",
+		},
+		[]string{"node_id", "compute_node_id"},
+	)
+
+	verifications = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "requester_verifications",
+			Help: "Number of results verified by the requester node, by verifier and outcome.",
+		},
+		[]string{"node_id", "verifier", "result"},
+	)
+)
+
+const (
+	verificationPassed = "passed"
+	verificationFailed = "failed"
+)
+
+// states of jobs in the jobs gauge
+const (
+	jobInProgress = "InProgress"
+	jobCompleted  = "Completed"
+	jobError      = "Error"
+)
+
+// jobMetrics keeps the jobs gauge up to date with the states of the jobs' shards.
+type jobMetrics struct {
+	jobs map[string]*jobShardStates
+	mu   sync.Mutex
+}
+
+type jobShardStates struct {
+	nodeID      string
+	totalShards int
+	shards      map[int]shardStateType
+	state       string
+}
+
+func newJobMetrics() *jobMetrics {
+	metrics := &jobMetrics{jobs: make(map[string]*jobShardStates)}
+	metrics.mu.EnableTracerWithOpts(sync.Opts{
+		Threshold: 10 * time.Millisecond,
+		Id:        "Requester.JobMetricsMu",
+	})
+	return metrics
+}
+
+// shardTransitioned records the new state of a shard, moving its job to another state in the gauge if needed.
+func (m *jobMetrics) shardTransitioned(nodeID string, shard model.JobShard, state shardStateType) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[shard.Job.Metadata.ID]
+	if !ok {
+		job = &jobShardStates{
+			nodeID:      nodeID,
+			totalShards: shard.Job.Spec.ExecutionPlan.TotalShards,
+			shards:      make(map[int]shardStateType),
+		}
+		m.jobs[shard.Job.Metadata.ID] = job
+	}
+	// failed shards move on to completed, but their job still failed
+	if job.shards[shard.Index] != shardError {
+		job.shards[shard.Index] = state
+	}
+	job.update(job.currentState())
+}
+
+// shardRemoved forgets a shard, and its job once none of its shards are left.
+func (m *jobMetrics) shardRemoved(shard model.JobShard) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[shard.Job.Metadata.ID]
+	if !ok {
+		return
+	}
+	delete(job.shards, shard.Index)
+	if len(job.shards) == 0 {
+		job.update("")
+		delete(m.jobs, shard.Job.Metadata.ID)
+	}
+}
+
+// currentState is in progress until all the shards of the job are done, and then failed if any of them failed.
+func (j *jobShardStates) currentState() string {
+	failed := false
+	for _, state := range j.shards {
+		switch state {
+		case shardError:
+			failed = true
+		case shardCompleted:
+		default:
+			return jobInProgress
+		}
+	}
+	switch {
+	case failed:
+		return jobError
+	case len(j.shards) < j.totalShards:
+		return jobInProgress
+	default:
+		return jobCompleted
+	}
+}
+
+// update moves the job to the new state in the gauge, or out of it if the state is empty.
+func (j *jobShardStates) update(state string) {
+	if state == j.state {
+		return
+	}
+	if j.state != "" {
+		jobsByState.WithLabelValues(j.nodeID, j.state).Dec()
+	}
+	if state != "" {
+		jobsByState.WithLabelValues(j.nodeID, state).Inc()
+	}
+	j.state = state
+}
@@ -0,0 +1,165 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobMetrics(t *testing.T) {
+	// the gauge is shared by every test run
+	nodeID := fmt.Sprintf("job-metrics-%d", time.Now().UnixNano())
+	gauge := func(state string) float64 {
+		return testutil.ToFloat64(jobsByState.WithLabelValues(nodeID, state))
+	}
+	metrics := newJobMetrics()
+	job := &model.Job{
+		Metadata: model.Metadata{ID: "job-id"},
+		Spec:     model.Spec{ExecutionPlan: model.JobExecutionPlan{TotalShards: 2}},
+	}
+	first := model.JobShard{Job: job, Index: 0}
+	second := model.JobShard{Job: job, Index: 1}
+
+	metrics.shardTransitioned(nodeID, first, shardEnqueuingBids)
+	require.Equal(t, 1.0, gauge(jobInProgress))
+
+	// the job is in progress until all its shards are done
+	metrics.shardTransitioned(nodeID, first, shardCompleted)
+	require.Equal(t, 1.0, gauge(jobInProgress))
+	metrics.shardTransitioned(nodeID, second, shardError)
+	require.Equal(t, 0.0, gauge(jobInProgress))
+	require.Equal(t, 1.0, gauge(jobError))
+	require.Equal(t, 0.0, gauge(jobCompleted))
+
+	// failed shards always go on to complete
+	metrics.shardTransitioned(nodeID, second, shardCompleted)
+	require.Equal(t, 1.0, gauge(jobError))
+	require.Equal(t, 0.0, gauge(jobCompleted))
+
+	// and forgotten once all its shards are
+	metrics.shardRemoved(first)
+	require.Equal(t, 1.0, gauge(jobError))
+	metrics.shardRemoved(second)
+	require.Equal(t, 0.0, gauge(jobError))
+	metrics.shardRemoved(second)
+	require.Equal(t, 0.0, gauge(jobError))
+
+	// a job completes once every one of its shards completed
+	metrics.shardTransitioned(nodeID, first, shardCompleted)
+	require.Equal(t, 1.0, gauge(jobInProgress))
+	metrics.shardTransitioned(nodeID, second, shardCompleted)
+	require.Equal(t, 0.0, gauge(jobInProgress))
+	require.Equal(t, 1.0, gauge(jobCompleted))
+}
+
+func (s *ShardFSMSuite) shardsGauge(state shardStateType) float64 {
+	return testutil.ToFloat64(shardsByState.WithLabelValues(s.scheduler.id, state.String()))
+}
+
+func (s *ShardFSMSuite) jobsGauge(state string) float64 {
+	return testutil.ToFloat64(jobsByState.WithLabelValues(s.scheduler.id, state))
+}
+
+func (s *ShardFSMSuite) TestShardMetrics() {
+	s.verifier.results = []verifier.VerifierResult{
+		{JobID: "job-id", NodeID: "node-a", ExecutionID: "execution-a", Verified: true},
+	}
+	shardState := s.startShard(model.Deal{Concurrency: 1}, time.Minute, "node-a")
+	ctx := context.Background()
+	s.Eventually(func() bool {
+		return s.shardsGauge(shardEnqueuingBids) == 1
+	}, fsmTestTimeout, 10*time.Millisecond)
+	s.Equal(1.0, s.jobsGauge(jobInProgress))
+
+	shardState.bid(ctx, "node-a", "execution-a", 0)
+	receive(s, s.compute.accepted, "bid of node-a to be accepted")
+	shardState.verifyResult(ctx, "node-a", "execution-a")
+	receive(s, s.verifier.verified, "shard to be verified")
+	s.Eventually(func() bool {
+		return s.shardsGauge(shardWaitingToPublishResults) == 1
+	}, fsmTestTimeout, 10*time.Millisecond)
+	s.Equal(0.0, s.shardsGauge(shardEnqueuingBids))
+	s.Equal(0.0, s.shardsGauge(shardVerifyingResults))
+	s.Equal(1.0, testutil.ToFloat64(verifications.WithLabelValues(s.scheduler.id, model.VerifierNoop.String(), verificationPassed)))
+
+	shardState.resultsPublished(ctx, "node-a", "execution-a")
+	s.Eventually(func() bool {
+		return s.shardsGauge(shardCompleted) == 1
+	}, fsmTestTimeout, 10*time.Millisecond)
+	s.Equal(0.0, s.shardsGauge(shardWaitingToPublishResults))
+	s.Equal(0.0, s.jobsGauge(jobInProgress))
+	s.Equal(1.0, s.jobsGauge(jobCompleted))
+}
+
+func (s *ShardFSMSuite) TestRejectedResultMetrics() {
+	s.verifier.results = []verifier.VerifierResult{
+		{JobID: "job-id", NodeID: "node-a", ExecutionID: "execution-a", Reason: "results differ from the majority"},
+	}
+	shardState := s.startShard(model.Deal{Concurrency: 1}, time.Minute, "node-a")
+	ctx := context.Background()
+	shardState.bid(ctx, "node-a", "execution-a", 0)
+	receive(s, s.compute.accepted, "bid of node-a to be accepted")
+	shardState.verifyResult(ctx, "node-a", "execution-a")
+	receive(s, s.verifier.verified, "shard to be verified")
+
+	// there are no results to publish, so the shard is done
+	s.Eventually(func() bool {
+		return s.shardsGauge(shardCompleted) == 1
+	}, fsmTestTimeout, 10*time.Millisecond)
+	s.Equal(1.0, testutil.ToFloat64(verifications.WithLabelValues(s.scheduler.id, model.VerifierNoop.String(), verificationFailed)))
+	s.Equal(0.0, testutil.ToFloat64(verifications.WithLabelValues(s.scheduler.id, model.VerifierNoop.String(), verificationPassed)))
+}
+
+func (s *ShardFSMSuite) TestFailedJobMetrics() {
+	shardState := s.startShard(model.Deal{Concurrency: 1}, time.Minute, "node-a")
+	ctx := context.Background()
+	shardState.bid(ctx, "node-a", "execution-a", 0)
+	receive(s, s.compute.accepted, "bid of node-a to be accepted")
+	s.Eventually(func() bool {
+		return s.shardsGauge(shardWaitingForResults) == 1
+	}, fsmTestTimeout, 10*time.Millisecond)
+	s.Equal(1.0, s.jobsGauge(jobInProgress))
+
+	s.scheduler.CancelJob(ctx, shardState.shard.Job, "replaced")
+	s.Eventually(func() bool {
+		return s.jobsGauge(jobError) == 1
+	}, fsmTestTimeout, 10*time.Millisecond)
+	s.Equal(1.0, s.shardsGauge(shardCompleted))
+	s.Equal(0.0, s.shardsGauge(shardWaitingForResults))
+	s.Equal(0.0, s.jobsGauge(jobInProgress))
+}
+
+func (s *ShardFSMSuite) TestBidMetrics() {
+	nodeA, nodeB := peer.ID("node-a"), peer.ID("node-b")
+	s.compute.setBid(nodeA.String(), 2)
+	s.compute.setBid(nodeB.String(), 1)
+	shardState := s.startShard(model.Deal{Concurrency: 1}, time.Minute, nodeA.String(), nodeB.String())
+	ctx := context.Background()
+
+	for _, nodeID := range []peer.ID{nodeA, nodeB} {
+		_, span := s.scheduler.newSpan(ctx, "askForBid", "job-id")
+		s.scheduler.notifyAskForBid(ctx, span, shardState.shard.Job, model.NodeInfo{
+			PeerInfo: peer.AddrInfo{ID: nodeID},
+			Versions: model.NodeVersions{ProtocolVersion: model.ProtocolVersion, APIVersions: model.SupportedAPIVersions()},
+		}, []int{0})
+	}
+
+	// the cheapest bid is accepted, and the other one rejected
+	s.Equal("execution-"+nodeB.String(), receive(s, s.compute.accepted, "bid of node-b to be accepted"))
+	s.Equal("execution-"+nodeA.String(), receive(s, s.compute.rejected, "bid of node-a to be rejected"))
+	for _, nodeID := range []peer.ID{nodeA, nodeB} {
+		s.Equal(1.0, testutil.ToFloat64(bidsAsked.WithLabelValues(s.scheduler.id, nodeID.String())), nodeID)
+		s.Equal(1.0, testutil.ToFloat64(bidsReceived.WithLabelValues(s.scheduler.id, nodeID.String())), nodeID)
+	}
+	s.Equal(1.0, testutil.ToFloat64(bidsRejected.WithLabelValues(s.scheduler.id, nodeA.String())))
+	s.Equal(0.0, testutil.ToFloat64(bidsRejected.WithLabelValues(s.scheduler.id, nodeB.String())))
+}
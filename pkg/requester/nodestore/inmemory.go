@@ -8,9 +8,19 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/requester"
 	"github.com/libp2p/go-libp2p/core/peer"
 	sync "github.com/lukemarsden/golang-mutex-tracer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/rs/zerolog/log"
 )
 
+var storeSize = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "requester_node_info_store_size",
+		Help: "Number of compute nodes known to the requester node.",
+	},
+	[]string{"node_id"},
+)
+
 // TODO: replace the manual and lazy eviction with a more efficient caching library
 type nodeInfoWrapper struct {
 	model.NodeInfo
@@ -19,10 +29,13 @@ type nodeInfoWrapper struct {
 
 type InMemoryNodeInfoStoreParams struct {
 	TTL time.Duration
+	// NodeID of the node owning the store, used to label its metrics
+	NodeID string
 }
 
 type InMemoryNodeInfoStore struct {
 	ttl             time.Duration
+	nodeID          string
 	nodeInfoMap     map[peer.ID]nodeInfoWrapper
 	engineNodeIDMap map[model.Engine]map[peer.ID]struct{}
 	mu              sync.RWMutex
@@ -31,6 +44,7 @@ type InMemoryNodeInfoStore struct {
 func NewInMemoryNodeInfoStore(params InMemoryNodeInfoStoreParams) *InMemoryNodeInfoStore {
 	res := &InMemoryNodeInfoStore{
 		ttl:             params.TTL,
+		nodeID:          params.NodeID,
 		nodeInfoMap:     make(map[peer.ID]nodeInfoWrapper),
 		engineNodeIDMap: make(map[model.Engine]map[peer.ID]struct{}),
 	}
@@ -70,6 +84,7 @@ func (r *InMemoryNodeInfoStore) Add(ctx context.Context, nodeInfo model.NodeInfo
 		NodeInfo: nodeInfo,
 		evictAt:  time.Now().Add(r.ttl),
 	}
+	storeSize.WithLabelValues(r.nodeID).Set(float64(len(r.nodeInfoMap)))
 
 	log.Ctx(ctx).Trace().Msgf("Added node info %+v", nodeInfo)
 	return nil
@@ -156,6 +171,7 @@ func (r *InMemoryNodeInfoStore) doDelete(ctx context.Context, peerID peer.ID) er
 		delete(r.engineNodeIDMap[engine], peerID)
 	}
 	delete(r.nodeInfoMap, peerID)
+	storeSize.WithLabelValues(r.nodeID).Set(float64(len(r.nodeInfoMap)))
 	return nil
 }
 
@@ -8,6 +8,7 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/requester"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -18,7 +19,8 @@ type InMemoryNodeInfoStoreSuite struct {
 
 func (s *InMemoryNodeInfoStoreSuite) SetupTest() {
 	s.store = NewInMemoryNodeInfoStore(InMemoryNodeInfoStoreParams{
-		TTL: 1 * time.Hour,
+		TTL:    1 * time.Hour,
+		NodeID: s.T().Name(),
 	})
 }
 
@@ -110,6 +112,18 @@ func (s *InMemoryNodeInfoStoreSuite) Test_Delete() {
 	s.Empty(wasmNodes)
 }
 
+func (s *InMemoryNodeInfoStoreSuite) Test_SizeMetric() {
+	ctx := context.Background()
+	size := storeSize.WithLabelValues(s.T().Name())
+	s.NoError(s.store.Add(ctx, generateNodeInfo("node1", model.EngineDocker)))
+	s.NoError(s.store.Add(ctx, generateNodeInfo("node2", model.EngineWasm)))
+	s.NoError(s.store.Add(ctx, generateNodeInfo("node2", model.EngineWasm)))
+	s.Equal(float64(2), testutil.ToFloat64(size))
+
+	s.NoError(s.store.Delete(ctx, peer.ID("node1")))
+	s.Equal(float64(1), testutil.ToFloat64(size))
+}
+
 func (s *InMemoryNodeInfoStoreSuite) Test_Replace() {
 	ctx := context.Background()
 	nodeInfo1 := generateNodeInfo("node1", model.EngineDocker)
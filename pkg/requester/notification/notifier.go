@@ -0,0 +1,373 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/eventhandler"
+	jobutils "github.com/filecoin-project/bacalhau/pkg/job"
+	"github.com/filecoin-project/bacalhau/pkg/localdb"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/util/publicaddr"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// HeaderEvent is the header holding the event a notification is for.
+	HeaderEvent = "X-Bacalhau-Event"
+	// HeaderSignature is the header holding the HMAC-SHA256 signature of the
+	// payload, as "sha256=<hex>", if the notification has a secret.
+	HeaderSignature = "X-Bacalhau-Signature"
+
+	signaturePrefix = "sha256="
+
+	DefaultMaxAttempts  = 5
+	DefaultRetryBackoff = 2 * time.Second
+	DefaultTimeout      = 10 * time.Second
+)
+
+// Payload is the JSON body POSTed to notification URLs.
+type Payload struct {
+	JobID string                  `json:"job_id"`
+	Event model.NotificationEvent `json:"event"`
+	// the shard that published results, or that failed the job
+	ShardIndex int `json:"shard_index"`
+	// the node that published results
+	NodeID          string             `json:"node_id,omitempty"`
+	PublishedResult *model.StorageSpec `json:"published_result,omitempty"`
+	// why the job failed
+	Status string    `json:"status,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+type NotifierParams struct {
+	JobStore localdb.LocalDB
+	// decrypts notification secrets that were sealed for this node
+	Decrypter verifier.DecrypterFunction
+	// defaults to a client with DefaultTimeout, that only follows redirects
+	// to public hosts
+	Client *http.Client
+	// how many times to try delivering a notification, waiting RetryBackoff
+	// before the first retry and doubling the wait after each one
+	MaxAttempts  int
+	RetryBackoff time.Duration
+}
+
+// Notifier is a JobEventHandler that delivers the webhook notifications jobs
+// ask for (see model.JobNotification). It must come after the handler that
+// updates the job store, as it reads the job's state from there. Deliveries
+// happen in the background, and each attempt is recorded in the job store.
+// Notifications are only delivered to public hosts, so that jobs can't make
+// the requester send requests to its own network.
+type Notifier struct {
+	jobStore     localdb.LocalDB
+	decrypter    verifier.DecrypterFunction
+	client       *http.Client
+	maxAttempts  int
+	retryBackoff time.Duration
+
+	// closed on shutdown to stop waiting to retry
+	done chan struct{}
+	wg   sync.WaitGroup
+	// job level events whose delivery started but isn't recorded in the job
+	// store yet, to only notify them once
+	notifying map[string]bool
+	mu        sync.Mutex
+
+	// allowPrivateHosts lets tests deliver to local servers
+	allowPrivateHosts bool
+}
+
+func NewNotifier(params NotifierParams) *Notifier {
+	client := params.Client
+	if client == nil {
+		client = &http.Client{
+			Timeout: DefaultTimeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 10 { //nolint:gomnd // the default limit of http.Client
+					return fmt.Errorf("stopped after %d redirects", len(via))
+				}
+				return publicaddr.CheckHost(req.Context(), req.URL.Hostname())
+			},
+		}
+	}
+	maxAttempts := params.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	retryBackoff := params.RetryBackoff
+	if retryBackoff <= 0 {
+		retryBackoff = DefaultRetryBackoff
+	}
+	return &Notifier{
+		jobStore:     params.JobStore,
+		decrypter:    params.Decrypter,
+		client:       client,
+		maxAttempts:  maxAttempts,
+		retryBackoff: retryBackoff,
+		done:         make(chan struct{}),
+		notifying:    map[string]bool{},
+	}
+}
+
+func (n *Notifier) HandleJobEvent(ctx context.Context, event model.JobEvent) error {
+	if event.EventName != model.JobEventResultsPublished && event.EventName != model.JobEventError {
+		return nil
+	}
+	job, err := n.jobStore.GetJob(ctx, event.JobID)
+	if err != nil {
+		return err
+	}
+	if len(job.Spec.Notifications) == 0 {
+		return nil
+	}
+
+	payload := Payload{
+		JobID:      event.JobID,
+		ShardIndex: event.ShardIndex,
+		Time:       event.EventTime,
+	}
+	switch event.EventName {
+	case model.JobEventResultsPublished:
+		published := payload
+		published.Event = model.NotificationEventPublished
+		published.NodeID = event.SourceNodeID
+		published.PublishedResult = &event.PublishedResult
+		n.notify(job, published)
+
+		state, err := n.jobStore.GetJobState(ctx, event.JobID)
+		if err != nil {
+			return err
+		}
+		if !isJobCompleted(job, state) {
+			return nil
+		}
+		first, err := n.firstTime(ctx, event.JobID, model.NotificationEventCompleted)
+		if err != nil || !first {
+			return err
+		}
+		completed := payload
+		completed.Event = model.NotificationEventCompleted
+		completed.ShardIndex = 0
+		n.notify(job, completed)
+	case model.JobEventError:
+		first, err := n.firstTime(ctx, event.JobID, model.NotificationEventFailed)
+		if err != nil || !first {
+			return err
+		}
+		failed := payload
+		failed.Event = model.NotificationEventFailed
+		failed.Status = event.Status
+		n.notify(job, failed)
+	}
+	return nil
+}
+
+// Shutdown stops retrying deliveries and waits for those in flight.
+func (n *Notifier) Shutdown() {
+	close(n.done)
+	n.wg.Wait()
+}
+
+// firstTime returns true the first time it is called for a job level event,
+// which is when no delivery of it is in progress or recorded in the job store.
+// Callers must then notify the event, which stops it being tracked in memory
+// once its deliveries are recorded.
+func (n *Notifier) firstTime(ctx context.Context, jobID string, event model.NotificationEvent) (bool, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	key := notifyingKey(jobID, event)
+	if n.notifying[key] {
+		return false, nil
+	}
+	deliveries, err := n.jobStore.GetNotificationDeliveries(ctx, jobID)
+	if err != nil {
+		return false, err
+	}
+	for _, delivery := range deliveries {
+		if delivery.Event == event {
+			return false, nil
+		}
+	}
+	n.notifying[key] = true
+	return true, nil
+}
+
+func (n *Notifier) notify(job *model.Job, payload Payload) {
+	var deliveries sync.WaitGroup
+	for _, notification := range job.Spec.Notifications {
+		if !notification.Wants(payload.Event) {
+			continue
+		}
+		n.wg.Add(1)
+		deliveries.Add(1)
+		go func(notification model.JobNotification) {
+			defer n.wg.Done()
+			defer deliveries.Done()
+			// deliveries outlive the event that triggered them, and requests
+			// are bounded by the client's timeout
+			n.deliver(context.Background(), notification, payload)
+		}(notification)
+	}
+	if payload.Event == model.NotificationEventPublished {
+		return
+	}
+	// the job store dedups the event once its deliveries are recorded
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		deliveries.Wait()
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		delete(n.notifying, notifyingKey(payload.JobID, payload.Event))
+	}()
+}
+
+func notifyingKey(jobID string, event model.NotificationEvent) string {
+	return jobID + "/" + string(event)
+}
+
+// checkURL returns an error if the URL's host isn't public.
+func (n *Notifier) checkURL(ctx context.Context, rawURL string) error {
+	if n.allowPrivateHosts {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	return publicaddr.CheckHost(ctx, u.Hostname())
+}
+
+func (n *Notifier) deliver(ctx context.Context, notification model.JobNotification, payload Payload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		n.record(ctx, notification, payload, 1, 0, err)
+		return
+	}
+	if err = n.checkURL(ctx, notification.URL); err != nil {
+		n.record(ctx, notification, payload, 1, 0, err)
+		return
+	}
+	var signature string
+	if len(notification.Secret) > 0 {
+		secret, err := n.decrypter(ctx, notification.Secret)
+		if err != nil {
+			n.record(ctx, notification, payload, 1, 0, fmt.Errorf("unable to decrypt secret: %w", err))
+			return
+		}
+		signature = Sign(secret, body)
+	}
+
+	backoff := n.retryBackoff
+	for attempt := 1; ; attempt++ {
+		statusCode, err := n.post(ctx, notification.URL, payload.Event, body, signature)
+		n.record(ctx, notification, payload, attempt, statusCode, err)
+		if err == nil || attempt >= n.maxAttempts || !isRetryable(statusCode) {
+			return
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-n.done:
+			return
+		}
+	}
+}
+
+func (n *Notifier) post(
+	ctx context.Context, url string, event model.NotificationEvent, body []byte, signature string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(event))
+	if signature != "" {
+		req.Header.Set(HeaderSignature, signature)
+	}
+	res, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected response status %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+func (n *Notifier) record(
+	ctx context.Context,
+	notification model.JobNotification,
+	payload Payload,
+	attempt int,
+	statusCode int,
+	deliveryErr error,
+) {
+	delivery := model.NotificationDelivery{
+		JobID:      payload.JobID,
+		URL:        notification.URL,
+		Event:      payload.Event,
+		ShardIndex: payload.ShardIndex,
+		Attempt:    attempt,
+		StatusCode: statusCode,
+		Delivered:  deliveryErr == nil,
+		Time:       time.Now(),
+	}
+	if deliveryErr != nil {
+		delivery.Error = deliveryErr.Error()
+		log.Ctx(ctx).Debug().Err(deliveryErr).Str("JobID", payload.JobID).Str("URL", notification.URL).
+			Int("Attempt", attempt).Msg("failed to deliver notification")
+	}
+	if err := n.jobStore.AddNotificationDelivery(ctx, payload.JobID, delivery); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("JobID", payload.JobID).Msg("failed to record notification delivery")
+	}
+}
+
+// isRetryable returns true if a failed delivery might succeed later, which is
+// when no response was received or the receiver had a problem.
+func isRetryable(statusCode int) bool {
+	return statusCode == 0 || statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// isJobCompleted returns true if every shard of the job has completed and
+// there is nothing left in progress.
+func isJobCompleted(job *model.Job, state model.JobState) bool {
+	completedShards := map[int]bool{}
+	for _, shardState := range jobutils.FlattenShardStates(state) { //nolint:gocritic
+		if !shardState.State.IsTerminal() {
+			return false
+		}
+		if shardState.State == model.JobStateCompleted {
+			completedShards[shardState.ShardIndex] = true
+		}
+	}
+	return len(completedShards) == jobutils.GetJobTotalShards(job)
+}
+
+// Sign returns the signature of a payload for the HeaderSignature header.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature returns true if signature is the HeaderSignature of body,
+// so receivers can check notifications came from the requester.
+func VerifySignature(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// compile-time check that Notifier implements the expected interface
+var _ eventhandler.JobEventHandler = (*Notifier)(nil)
@@ -0,0 +1,245 @@
+//go:build unit || !integration
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/localdb/inmemory"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+const jobID = "notification-test-job"
+
+type received struct {
+	payload   Payload
+	event     string
+	signature string
+	body      []byte
+}
+
+type receiver struct {
+	mu       sync.Mutex
+	received []received
+	// status codes to respond with in turn, then 200
+	statuses []int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, _ := io.ReadAll(req.Body)
+	var payload Payload
+	_ = json.Unmarshal(body, &payload)
+	r.received = append(r.received, received{
+		payload:   payload,
+		event:     req.Header.Get(HeaderEvent),
+		signature: req.Header.Get(HeaderSignature),
+		body:      body,
+	})
+	if len(r.statuses) > 0 {
+		w.WriteHeader(r.statuses[0])
+		r.statuses = r.statuses[1:]
+	}
+}
+
+func setup(t *testing.T, notifications ...model.JobNotification) (*Notifier, *inmemory.InMemoryDatastore) {
+	store, err := inmemory.NewInMemoryDatastore()
+	require.NoError(t, err)
+	require.NoError(t, store.AddJob(context.Background(), &model.Job{
+		Metadata: model.Metadata{ID: jobID},
+		Spec:     model.Spec{Notifications: notifications},
+	}))
+	return newNotifier(store), store
+}
+
+// newNotifier returns a notifier that delivers to local test servers.
+func newNotifier(store *inmemory.InMemoryDatastore) *Notifier {
+	notifier := NewNotifier(NotifierParams{
+		JobStore: store,
+		Decrypter: func(ctx context.Context, data []byte) ([]byte, error) {
+			return data, nil
+		},
+		RetryBackoff: time.Millisecond,
+	})
+	notifier.allowPrivateHosts = true
+	return notifier
+}
+
+func setShardState(t *testing.T, store *inmemory.InMemoryDatastore, nodeID string, state model.JobStateType) {
+	require.NoError(t, store.UpdateShardState(context.Background(), jobID, nodeID, 0, model.JobShardState{
+		NodeID: nodeID,
+		State:  state,
+	}))
+}
+
+func TestNotifyPublishedAndCompleted(t *testing.T) {
+	ctx := context.Background()
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	secret := []byte("secret")
+	notifier, store := setup(t, model.JobNotification{URL: server.URL, Secret: secret})
+	setShardState(t, store, "node1", model.JobStateRunning)
+	setShardState(t, store, "node2", model.JobStateCompleted)
+
+	published := model.JobEvent{
+		JobID:           jobID,
+		SourceNodeID:    "node2",
+		EventName:       model.JobEventResultsPublished,
+		PublishedResult: model.StorageSpec{CID: "cid"},
+	}
+	require.NoError(t, notifier.HandleJobEvent(ctx, published))
+
+	// the job completes once the other node is done too
+	setShardState(t, store, "node1", model.JobStateCompleted)
+	published.SourceNodeID = "node1"
+	require.NoError(t, notifier.HandleJobEvent(ctx, published))
+	require.NoError(t, notifier.HandleJobEvent(ctx, published))
+	// wait for deliveries, including retries
+	notifier.wg.Wait()
+	notifier.Shutdown()
+
+	events := map[string]int{}
+	for _, rec := range r.received {
+		events[rec.event]++
+		require.Equal(t, string(rec.payload.Event), rec.event)
+		require.True(t, VerifySignature(secret, rec.body, rec.signature))
+	}
+	require.Equal(t, map[string]int{"published": 3, "completed": 1}, events)
+
+	deliveries, err := store.GetNotificationDeliveries(ctx, jobID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 4)
+	for _, delivery := range deliveries {
+		require.True(t, delivery.Delivered)
+		require.Equal(t, http.StatusOK, delivery.StatusCode)
+	}
+}
+
+func TestNotifyFailedWithRetries(t *testing.T) {
+	ctx := context.Background()
+	r := &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	notifier, store := setup(t,
+		model.JobNotification{URL: server.URL, Events: []model.NotificationEvent{model.NotificationEventFailed}},
+		model.JobNotification{URL: server.URL + "/published", Events: []model.NotificationEvent{model.NotificationEventPublished}},
+	)
+	failed := model.JobEvent{
+		JobID:     jobID,
+		EventName: model.JobEventError,
+		Status:    "out of memory",
+	}
+	require.NoError(t, notifier.HandleJobEvent(ctx, failed))
+	require.NoError(t, notifier.HandleJobEvent(ctx, failed))
+	// wait for deliveries, including retries
+	notifier.wg.Wait()
+	notifier.Shutdown()
+
+	require.Len(t, r.received, 3)
+	require.Equal(t, model.NotificationEventFailed, r.received[2].payload.Event)
+	require.Equal(t, "out of memory", r.received[2].payload.Status)
+	require.Empty(t, r.received[2].signature)
+
+	deliveries, err := store.GetNotificationDeliveries(ctx, jobID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 3)
+	require.Equal(t, http.StatusServiceUnavailable, deliveries[0].StatusCode)
+	require.False(t, deliveries[0].Delivered)
+	require.Equal(t, 3, deliveries[2].Attempt)
+	require.True(t, deliveries[2].Delivered)
+}
+
+func TestNotifyDoesNotRetryClientErrors(t *testing.T) {
+	ctx := context.Background()
+	r := &receiver{statuses: []int{http.StatusNotFound}}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	notifier, store := setup(t, model.JobNotification{URL: server.URL})
+	require.NoError(t, notifier.HandleJobEvent(ctx, model.JobEvent{JobID: jobID, EventName: model.JobEventError}))
+	// wait for deliveries, including retries
+	notifier.wg.Wait()
+	notifier.Shutdown()
+
+	deliveries, err := store.GetNotificationDeliveries(ctx, jobID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.False(t, deliveries[0].Delivered)
+	require.Equal(t, http.StatusNotFound, deliveries[0].StatusCode)
+}
+
+func TestJobEventsNotifiedOnceAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	notifier, store := setup(t, model.JobNotification{URL: server.URL})
+	failed := model.JobEvent{JobID: jobID, EventName: model.JobEventError}
+	require.NoError(t, notifier.HandleJobEvent(ctx, failed))
+	notifier.wg.Wait()
+	notifier.Shutdown()
+	// nothing is kept in memory once the deliveries are recorded
+	require.Empty(t, notifier.notifying)
+
+	// a restarted requester finds the delivery in the job store
+	restarted := newNotifier(store)
+	require.NoError(t, restarted.HandleJobEvent(ctx, failed))
+	restarted.wg.Wait()
+	restarted.Shutdown()
+	require.Len(t, r.received, 1)
+}
+
+func TestNotifyOnlyPublicHosts(t *testing.T) {
+	ctx := context.Background()
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	notifier, store := setup(t, model.JobNotification{URL: server.URL})
+	notifier.allowPrivateHosts = false
+	require.NoError(t, notifier.HandleJobEvent(ctx, model.JobEvent{JobID: jobID, EventName: model.JobEventError}))
+	notifier.wg.Wait()
+	notifier.Shutdown()
+	require.Empty(t, r.received)
+
+	deliveries, err := store.GetNotificationDeliveries(ctx, jobID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1, "a private host should not be retried")
+	require.False(t, deliveries[0].Delivered)
+	require.Contains(t, deliveries[0].Error, "not a public address")
+}
+
+func TestRedirectsOnlyToPublicHosts(t *testing.T) {
+	ctx := context.Background()
+	r := &receiver{}
+	private := httptest.NewServer(r)
+	defer private.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(private.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+
+	notifier, store := setup(t, model.JobNotification{URL: redirect.URL})
+	// the public host check is skipped for the first request only, as the test servers are all local
+	notifier.maxAttempts = 1
+	require.NoError(t, notifier.HandleJobEvent(ctx, model.JobEvent{JobID: jobID, EventName: model.JobEventError}))
+	notifier.wg.Wait()
+	notifier.Shutdown()
+	require.Empty(t, r.received)
+
+	deliveries, err := store.GetNotificationDeliveries(ctx, jobID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Contains(t, deliveries[0].Error, "not a public address")
+}
@@ -3,6 +3,7 @@ package publicapi
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -31,11 +32,14 @@ func NewRequesterAPIClient(baseURI string) *RequesterAPIClient {
 	return NewRequesterAPIClientFromClient(publicapi.NewAPIClient(baseURI))
 }
 
-// NewRequesterAPIClientFromClient returns a new client for a node's API server.
+// NewRequesterAPIClientFromClient returns a new client for a node's API server. Its requests are signed, as the
+// requester node may only accept registered clients.
 func NewRequesterAPIClientFromClient(baseClient *publicapi.APIClient) *RequesterAPIClient {
-	return &RequesterAPIClient{
+	client := &RequesterAPIClient{
 		APIClient: *baseClient,
 	}
+	client.SignRequests = true
+	return client
 }
 
 // List returns the list of jobs in the node's transport.
@@ -53,8 +57,7 @@ func (apiClient *RequesterAPIClient) List(
 	ctx, span := system.GetTracer().Start(ctx, "pkg/publicapi.List")
 	defer span.End()
 
-	req := listRequest{
-		ClientID:    system.GetClientID(),
+	req := ListRequest{
 		MaxJobs:     maxJobs,
 		JobID:       idFilter,
 		IncludeTags: includeTags,
@@ -64,15 +67,29 @@ func (apiClient *RequesterAPIClient) List(
 		SortReverse: sortReverse,
 	}
 
-	var res listResponse
-	if err := apiClient.Post(ctx, APIPrefix+"list", req, &res); err != nil {
-		e := err
-		return nil, e
+	res, err := apiClient.ListJobs(ctx, req)
+	if err != nil {
+		return nil, err
 	}
-
 	return res.Jobs, nil
 }
 
+// ListJobs returns the page of jobs matching the request, along with a cursor
+// for the next page if there are more. The request's client ID is set to this
+// client's.
+func (apiClient *RequesterAPIClient) ListJobs(ctx context.Context, req ListRequest) (*ListResponse, error) {
+	ctx, span := system.GetTracer().Start(ctx, "pkg/publicapi.ListJobs")
+	defer span.End()
+
+	req.ClientID = system.GetClientID()
+
+	var res ListResponse
+	if err := apiClient.Post(ctx, APIPrefix+"list", req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
 // Get returns job data for a particular job ID. If no match is found, Get returns false with a nil error.
 func (apiClient *RequesterAPIClient) Get(ctx context.Context, jobID string) (*model.Job, bool, error) {
 	ctx, span := system.GetTracer().Start(ctx, "pkg/publicapi.Get")
@@ -214,6 +231,26 @@ func (apiClient *RequesterAPIClient) GetResults(ctx context.Context, jobID strin
 	return res.Results, nil
 }
 
+// FetchResults streams the results of a shard that a compute node kept, as a
+// tar archive, which the caller must close.
+func (apiClient *RequesterAPIClient) FetchResults(
+	ctx context.Context, jobID, nodeID string, shardIndex int) (io.ReadCloser, error) {
+	ctx, span := system.GetTracer().Start(ctx, "pkg/publicapi.FetchResults")
+	defer span.End()
+
+	if jobID == "" {
+		return nil, fmt.Errorf("jobID must be non-empty in a FetchResults call")
+	}
+
+	req := fetchResultsRequest{
+		ClientID:   system.GetClientID(),
+		JobID:      jobID,
+		NodeID:     nodeID,
+		ShardIndex: shardIndex,
+	}
+	return apiClient.PostStream(ctx, APIPrefix+"results/fetch", req)
+}
+
 // Submit submits a new job to the node's transport.
 func (apiClient *RequesterAPIClient) Submit(
 	ctx context.Context,
@@ -265,3 +302,101 @@ func (apiClient *RequesterAPIClient) Debug(ctx context.Context) (map[string]mode
 
 	return res, nil
 }
+
+// Nodes returns the compute nodes the requester knows of.
+func (apiClient *RequesterAPIClient) Nodes(ctx context.Context) ([]model.NodeInfo, error) {
+	ctx, span := system.GetTracer().Start(ctx, "pkg/publicapi.Nodes")
+	defer span.End()
+
+	var res nodesResponse
+	if err := apiClient.Post(ctx, APIPrefix+"nodes", nodesRequest{}, &res); err != nil {
+		return nil, err
+	}
+	return res.Nodes, nil
+}
+
+// GetQuota returns the limits applied to the current client, and its usage.
+func (apiClient *RequesterAPIClient) GetQuota(ctx context.Context) (*QuotaResponse, error) {
+	ctx, span := system.GetTracer().Start(ctx, "pkg/publicapi.GetQuota")
+	defer span.End()
+
+	req := quotaRequest{
+		ClientID: system.GetClientID(),
+	}
+
+	var res QuotaResponse
+	if err := apiClient.Post(ctx, APIPrefix+"quota", req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// CreateSchedule signs and creates a schedule that submits a job every time
+// its cron expression is due.
+func (apiClient *RequesterAPIClient) CreateSchedule(
+	ctx context.Context, payload model.JobScheduleCreatePayload) (model.JobSchedule, error) {
+	ctx, span := system.GetTracer().Start(ctx, "pkg/publicapi.CreateSchedule")
+	defer span.End()
+
+	payload.ClientID = system.GetClientID()
+	jsonData, err := model.JSONMarshalWithMax(payload)
+	if err != nil {
+		return model.JobSchedule{}, err
+	}
+	signature, err := system.SignForClient(jsonData)
+	if err != nil {
+		return model.JobSchedule{}, err
+	}
+
+	var res schedulesResponse
+	req := scheduleCreateRequest{
+		ScheduleCreatePayload: payload,
+		ClientSignature:       signature,
+		ClientPublicKey:       system.GetClientPublicKey(),
+	}
+	if err = apiClient.Post(ctx, APIPrefix+"schedules/create", req, &res); err != nil {
+		return model.JobSchedule{}, err
+	}
+	if len(res.Schedules) != 1 {
+		return model.JobSchedule{}, fmt.Errorf("expected the created schedule, got %d schedules", len(res.Schedules))
+	}
+	return res.Schedules[0], nil
+}
+
+// ListSchedules returns this client's schedules.
+func (apiClient *RequesterAPIClient) ListSchedules(ctx context.Context) ([]model.JobSchedule, error) {
+	ctx, span := system.GetTracer().Start(ctx, "pkg/publicapi.ListSchedules")
+	defer span.End()
+
+	var res schedulesResponse
+	req := scheduleRequest{ClientID: system.GetClientID()}
+	if err := apiClient.Post(ctx, APIPrefix+"schedules/list", req, &res); err != nil {
+		return nil, err
+	}
+	return res.Schedules, nil
+}
+
+// PauseSchedule pauses a schedule, or resumes it if paused is false.
+func (apiClient *RequesterAPIClient) PauseSchedule(ctx context.Context, id string, paused bool) (model.JobSchedule, error) {
+	ctx, span := system.GetTracer().Start(ctx, "pkg/publicapi.PauseSchedule")
+	defer span.End()
+
+	var res schedulesResponse
+	req := scheduleRequest{ClientID: system.GetClientID(), ID: id, Paused: paused}
+	if err := apiClient.Post(ctx, APIPrefix+"schedules/pause", req, &res); err != nil {
+		return model.JobSchedule{}, err
+	}
+	if len(res.Schedules) != 1 {
+		return model.JobSchedule{}, fmt.Errorf("expected the updated schedule, got %d schedules", len(res.Schedules))
+	}
+	return res.Schedules[0], nil
+}
+
+// DeleteSchedule deletes a schedule. Jobs it already started are not affected.
+func (apiClient *RequesterAPIClient) DeleteSchedule(ctx context.Context, id string) error {
+	ctx, span := system.GetTracer().Start(ctx, "pkg/publicapi.DeleteSchedule")
+	defer span.End()
+
+	req := scheduleRequest{ClientID: system.GetClientID(), ID: id}
+	return apiClient.Post(ctx, APIPrefix+"schedules/delete", req, &schedulesResponse{})
+}
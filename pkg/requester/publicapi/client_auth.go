@@ -0,0 +1,85 @@
+package publicapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/auth"
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+)
+
+// Whoami returns who the node authenticates the client as.
+func (apiClient *RequesterAPIClient) Whoami(ctx context.Context) (*WhoamiResponse, error) {
+	ctx, span := system.GetTracer().Start(ctx, "pkg/publicapi.Whoami")
+	defer span.End()
+
+	var res WhoamiResponse
+	if err := apiClient.Post(ctx, APIPrefix+"auth/whoami", struct{}{}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// AuthAPIClient manages a requester node's registered client keys and tokens
+// through its API, which requires the admin scope.
+type AuthAPIClient struct {
+	*RequesterAPIClient
+}
+
+func NewAuthAPIClient(client *RequesterAPIClient) *AuthAPIClient {
+	return &AuthAPIClient{RequesterAPIClient: client}
+}
+
+func (c *AuthAPIClient) ListKeys(ctx context.Context) ([]auth.ClientKey, error) {
+	var res authKeysResponse
+	err := c.Post(ctx, APIPrefix+"auth/keys/list", authKeyRequest{}, &res)
+	return res.Keys, err
+}
+
+func (c *AuthAPIClient) AddKey(
+	ctx context.Context, publicKey string, scopes []handlerwrapper.Scope, description string) (auth.ClientKey, error) {
+	var res authKeysResponse
+	err := c.Post(ctx, APIPrefix+"auth/keys/add", authKeyRequest{
+		PublicKey:   publicKey,
+		Scopes:      scopes,
+		Description: description,
+	}, &res)
+	if err != nil || len(res.Keys) == 0 {
+		return auth.ClientKey{}, err
+	}
+	return res.Keys[0], nil
+}
+
+func (c *AuthAPIClient) RemoveKey(ctx context.Context, clientID string) error {
+	var res authKeysResponse
+	return c.Post(ctx, APIPrefix+"auth/keys/remove", authKeyRequest{ClientID: clientID}, &res)
+}
+
+func (c *AuthAPIClient) ListTokens(ctx context.Context) ([]auth.Token, error) {
+	var res authTokensResponse
+	err := c.Post(ctx, APIPrefix+"auth/tokens/list", authTokenRequest{}, &res)
+	return res.Tokens, err
+}
+
+func (c *AuthAPIClient) CreateToken(
+	ctx context.Context, clientID string, scopes []handlerwrapper.Scope, ttl time.Duration) (string, auth.Token, error) {
+	var res authTokensResponse
+	err := c.Post(ctx, APIPrefix+"auth/tokens/create", authTokenRequest{
+		ClientID: clientID,
+		Scopes:   scopes,
+		TTL:      ttl,
+	}, &res)
+	if err != nil || len(res.Tokens) == 0 {
+		return "", auth.Token{}, err
+	}
+	return res.Secret, res.Tokens[0], nil
+}
+
+func (c *AuthAPIClient) RevokeToken(ctx context.Context, id string) error {
+	var res authTokensResponse
+	return c.Post(ctx, APIPrefix+"auth/tokens/revoke", authTokenRequest{ID: id}, &res)
+}
+
+// compile-time check that AuthAPIClient implements the expected interface
+var _ auth.Manager = (*AuthAPIClient)(nil)
@@ -0,0 +1,128 @@
+package publicapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/bacerrors"
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/auth"
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/handlerwrapper"
+)
+
+type WhoamiResponse struct {
+	// false if the node doesn't authenticate clients
+	AuthEnabled bool                     `json:"auth_enabled"`
+	Identity    *handlerwrapper.Identity `json:"identity,omitempty"`
+}
+
+type authKeyRequest struct {
+	// the base64-encoded public key to add
+	PublicKey   string                 `json:"public_key,omitempty"`
+	Scopes      []handlerwrapper.Scope `json:"scopes,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	// the client whose key to remove
+	ClientID string `json:"client_id,omitempty"`
+}
+
+type authTokenRequest struct {
+	ClientID string                 `json:"client_id,omitempty"`
+	Scopes   []handlerwrapper.Scope `json:"scopes,omitempty"`
+	TTL      time.Duration          `json:"ttl,omitempty"`
+	// the token to revoke
+	ID string `json:"id,omitempty"`
+}
+
+type authKeysResponse struct {
+	Keys []auth.ClientKey `json:"keys"`
+}
+
+type authTokensResponse struct {
+	Tokens []auth.Token `json:"tokens"`
+	// the secret of a created token
+	Secret string `json:"secret,omitempty"`
+}
+
+// whoami godoc
+// @ID      pkg/requester/publicapi/auth/whoami
+// @Summary Returns who the node authenticated the request as, and their scopes.
+// @Tags    Auth
+// @Produce json
+// @Success 200 {object} WhoamiResponse
+// @Failure 401 {object} string
+// @Router  /requester/auth/whoami [post]
+func (s *RequesterAPIServer) whoami(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(res).Encode(WhoamiResponse{
+		AuthEnabled: s.authRegistry != nil,
+		Identity:    handlerwrapper.IdentityFromContext(req.Context()),
+	})
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *RequesterAPIServer) listKeys(ctx context.Context, _ authKeyRequest) (authKeysResponse, error) {
+	keys, err := s.authRegistry.ListKeys(ctx)
+	return authKeysResponse{Keys: keys}, err
+}
+
+func (s *RequesterAPIServer) addKey(ctx context.Context, keyReq authKeyRequest) (authKeysResponse, error) {
+	key, err := s.authRegistry.AddKey(ctx, keyReq.PublicKey, keyReq.Scopes, keyReq.Description)
+	return authKeysResponse{Keys: []auth.ClientKey{key}}, err
+}
+
+func (s *RequesterAPIServer) removeKey(ctx context.Context, keyReq authKeyRequest) (authKeysResponse, error) {
+	return authKeysResponse{}, s.authRegistry.RemoveKey(ctx, keyReq.ClientID)
+}
+
+func (s *RequesterAPIServer) listTokens(ctx context.Context, _ authTokenRequest) (authTokensResponse, error) {
+	tokens, err := s.authRegistry.ListTokens(ctx)
+	return authTokensResponse{Tokens: tokens}, err
+}
+
+func (s *RequesterAPIServer) createToken(ctx context.Context, tokenReq authTokenRequest) (authTokensResponse, error) {
+	secret, token, err := s.authRegistry.CreateToken(ctx, tokenReq.ClientID, tokenReq.Scopes, tokenReq.TTL)
+	return authTokensResponse{Tokens: []auth.Token{token}, Secret: secret}, err
+}
+
+func (s *RequesterAPIServer) revokeToken(ctx context.Context, tokenReq authTokenRequest) (authTokensResponse, error) {
+	return authTokensResponse{}, s.authRegistry.RevokeToken(ctx, tokenReq.ID)
+}
+
+// jsonHandler returns a handler that decodes the request, calls fn and encodes
+// its response. Errors from fn are bad requests, unless they are forbidden or
+// about something that doesn't exist.
+func jsonHandler[Req any, Res any](fn func(context.Context, Req) (Res, error)) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		var request Req
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			http.Error(res, bacerrors.ErrorToErrorResponse(err), http.StatusBadRequest)
+			return
+		}
+		response, err := fn(req.Context(), request)
+		if err != nil {
+			http.Error(res, bacerrors.ErrorToErrorResponse(err), errorStatusCode(err))
+			return
+		}
+		res.WriteHeader(http.StatusOK)
+		if err = json.NewEncoder(res).Encode(response); err != nil {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// errForbidden is wrapped by errors about clients acting on what isn't theirs.
+var errForbidden = errors.New("forbidden")
+
+func errorStatusCode(err error) int {
+	if errors.Is(err, errForbidden) {
+		return http.StatusForbidden
+	}
+	if _, ok := err.(*bacerrors.ScheduleNotFound); ok {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
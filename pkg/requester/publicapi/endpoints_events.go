@@ -42,6 +42,9 @@ func (s *RequesterAPIServer) events(res http.ResponseWriter, req *http.Request)
 	res.Header().Set(handlerwrapper.HTTPHeaderJobID, eventsReq.JobID)
 
 	ctx := req.Context()
+	if !s.canAccessJob(ctx, res, eventsReq.JobID) {
+		return
+	}
 	events, err := s.localDB.GetJobEvents(ctx, eventsReq.JobID)
 	if err != nil {
 		http.Error(res, err.Error(), http.StatusInternalServerError)
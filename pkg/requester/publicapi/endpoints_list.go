@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/filecoin-project/bacalhau/pkg/bacerrors"
 	"github.com/filecoin-project/bacalhau/pkg/localdb"
@@ -22,12 +23,23 @@ type listRequest struct {
 	ReturnAll   bool                `json:"return_all" `
 	SortBy      string              `json:"sort_by" example:"created_at"`
 	SortReverse bool                `json:"sort_reverse"`
+	// the rest are optional filters, see localdb.JobQuery
+	States        []model.JobStateType `json:"states,omitempty" example:"['Error']"`
+	Engine        model.Engine         `json:"engine,omitempty" example:"Docker"`
+	CreatedAfter  time.Time            `json:"created_after,omitempty" example:"2022-11-17T13:29:01Z"`
+	CreatedBefore time.Time            `json:"created_before,omitempty" example:"2022-11-18T13:29:01Z"`
+	Annotations   map[string]string    `json:"annotations,omitempty"`
+	// next_cursor from the previous page of jobs
+	Cursor string `json:"cursor,omitempty"`
 }
 
 type ListRequest = listRequest
 
 type listResponse struct {
 	Jobs []*model.Job `json:"jobs"`
+	// pass as the cursor to get the next page of jobs. Empty if there are no
+	// more jobs.
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 type ListResponse = listResponse
@@ -57,6 +69,20 @@ func (s *RequesterAPIServer) list(res http.ResponseWriter, req *http.Request) {
 	}
 	res.Header().Set(handlerwrapper.HTTPHeaderClientID, listReq.ClientID)
 	res.Header().Set(handlerwrapper.HTTPHeaderJobID, listReq.JobID)
+	if identity := handlerwrapper.IdentityFromContext(ctx); identity != nil && !identity.HasScope(handlerwrapper.ScopeListAll) {
+		// clients that can only list their own jobs get their own jobs
+		listReq.ClientID = identity.ClientID
+		listReq.ReturnAll = false
+	}
+	if listReq.JobID != "" && !s.canAccessJob(ctx, res, listReq.JobID) {
+		return
+	}
+	if listReq.Cursor != "" {
+		if _, err := localdb.ParseJobCursor(listReq.Cursor); err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
 	jobList, err := s.getJobsList(ctx, listReq)
 	if err != nil {
@@ -76,8 +102,15 @@ func (s *RequesterAPIServer) list(res http.ResponseWriter, req *http.Request) {
 		}
 	}
 	res.WriteHeader(http.StatusOK)
+	var nextCursor string
+	if listReq.JobID == "" && listReq.MaxJobs > 0 && len(jobList) > listReq.MaxJobs {
+		// one more job than asked for was fetched to know whether there is a next page
+		jobList = jobList[:listReq.MaxJobs]
+		nextCursor = localdb.NewJobCursor(jobList[len(jobList)-1])
+	}
 	err = json.NewEncoder(res).Encode(ListResponse{
-		Jobs: jobList,
+		Jobs:       jobList,
+		NextCursor: nextCursor,
 	})
 	if err != nil {
 		http.Error(res, err.Error(), http.StatusInternalServerError)
@@ -89,15 +122,25 @@ func (s *RequesterAPIServer) getJobsList(ctx context.Context, listReq ListReques
 	ctx, span := system.GetTracer().Start(ctx, "pkg/publicapi.list")
 	defer span.End()
 
+	limit := listReq.MaxJobs
+	if listReq.JobID == "" && limit > 0 {
+		limit++
+	}
 	list, err := s.localDB.GetJobs(ctx, localdb.JobQuery{
-		ClientID:    listReq.ClientID,
-		ID:          listReq.JobID,
-		Limit:       listReq.MaxJobs,
-		IncludeTags: listReq.IncludeTags,
-		ExcludeTags: listReq.ExcludeTags,
-		ReturnAll:   listReq.ReturnAll,
-		SortBy:      listReq.SortBy,
-		SortReverse: listReq.SortReverse,
+		ClientID:      listReq.ClientID,
+		ID:            listReq.JobID,
+		Limit:         limit,
+		IncludeTags:   listReq.IncludeTags,
+		ExcludeTags:   listReq.ExcludeTags,
+		ReturnAll:     listReq.ReturnAll,
+		SortBy:        listReq.SortBy,
+		SortReverse:   listReq.SortReverse,
+		States:        listReq.States,
+		Engine:        listReq.Engine,
+		CreatedAfter:  listReq.CreatedAfter,
+		CreatedBefore: listReq.CreatedBefore,
+		Annotations:   listReq.Annotations,
+		Cursor:        listReq.Cursor,
 	})
 	if err != nil {
 		return nil, err
@@ -40,6 +40,10 @@ func (s *RequesterAPIServer) localEvents(res http.ResponseWriter, req *http.Requ
 	res.Header().Set(handlerwrapper.HTTPHeaderClientID, eventsReq.ClientID)
 	res.Header().Set(handlerwrapper.HTTPHeaderJobID, eventsReq.JobID)
 
+	if !s.canAccessJob(req.Context(), res, eventsReq.JobID) {
+		return
+	}
+
 	events, err := s.localDB.GetJobLocalEvents(req.Context(), eventsReq.JobID)
 	if err != nil {
 		http.Error(res, err.Error(), http.StatusInternalServerError)
@@ -0,0 +1,19 @@
+package publicapi
+
+import (
+	"context"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+)
+
+type nodesRequest struct{}
+
+type nodesResponse struct {
+	Nodes []model.NodeInfo `json:"nodes"`
+}
+
+// nodes returns the compute nodes the requester knows of, including the versions they run.
+func (s *RequesterAPIServer) nodes(ctx context.Context, _ nodesRequest) (nodesResponse, error) {
+	nodes, err := s.nodeInfoStore.List(ctx)
+	return nodesResponse{Nodes: nodes}, err
+}
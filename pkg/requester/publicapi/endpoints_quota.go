@@ -0,0 +1,60 @@
+package publicapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"github.com/filecoin-project/bacalhau/pkg/requester/quota"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+)
+
+type quotaRequest struct {
+	ClientID string `json:"client_id" validate:"required" example:"ac13188e93c97a9c2e7cf8e86c7313156a73436036f30da1ececc2ce79f9ea51"`
+}
+
+type QuotaResponse struct {
+	ClientID string       `json:"client_id"`
+	Limits   quota.Limits `json:"limits"`
+	Usage    quota.Usage  `json:"usage"`
+}
+
+// quota godoc
+// @ID          pkg/requester/publicapi/quota
+// @Summary     Returns the limits applied to a client, and its current usage.
+// @Description Limits that are not set are unlimited. Resource-hours are counted over the limits' window.
+// @Tags        Job
+// @Accept      json
+// @Produce     json
+// @Param       quotaRequest body     quotaRequest true " "
+// @Success     200          {object} QuotaResponse
+// @Failure     400          {object} string
+// @Router      /requester/quota [post]
+func (s *RequesterAPIServer) quota(res http.ResponseWriter, req *http.Request) {
+	_, span := system.GetSpanFromRequest(req, "pkg/publicapi/quota")
+	defer span.End()
+
+	var quotaReq quotaRequest
+	if err := json.NewDecoder(req.Body).Decode(&quotaReq); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res.Header().Set(handlerwrapper.HTTPHeaderClientID, quotaReq.ClientID)
+	if !handlerwrapper.IdentityFromContext(req.Context()).CanAccessClient(quotaReq.ClientID) {
+		http.Error(res, "forbidden: clients can only see their own quota", http.StatusForbidden)
+		return
+	}
+
+	quotaRes := QuotaResponse{ClientID: quotaReq.ClientID}
+	if s.quotaTracker != nil {
+		quotaRes.Limits = s.quotaTracker.Limits()
+		quotaRes.Usage = s.quotaTracker.Usage(quotaReq.ClientID)
+	}
+
+	res.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(res).Encode(quotaRes)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -2,8 +2,11 @@ package publicapi
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 
+	"github.com/filecoin-project/bacalhau/pkg/compute"
 	"github.com/filecoin-project/bacalhau/pkg/localdb"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/publicapi/handlerwrapper"
@@ -19,6 +22,13 @@ type resultsResponse struct {
 	Results []model.PublishedResult `json:"results"`
 }
 
+type fetchResultsRequest struct {
+	ClientID   string `json:"client_id" example:"ac13188e93c97a9c2e7cf8e86c7313156a73436036f30da1ececc2ce79f9ea51"`
+	JobID      string `json:"job_id" example:"9304c616-291f-41ad-b862-54e133c0149e"`
+	NodeID     string `json:"node_id" example:"QmdZQ7ZbhnvWY1J12XYKGHApJ6aufKyLNSvf8jZBrBaAVL"`
+	ShardIndex int    `json:"shard_index" example:"0"`
+}
+
 // results godoc
 // @ID                   pkg/requester/publicapi/results
 // @Summary              Returns the results of the job-id specified in the body payload.
@@ -42,6 +52,9 @@ func (s *RequesterAPIServer) results(res http.ResponseWriter, req *http.Request)
 	}
 	res.Header().Set(handlerwrapper.HTTPHeaderClientID, stateReq.ClientID)
 	res.Header().Set(handlerwrapper.HTTPHeaderJobID, stateReq.JobID)
+	if !s.canAccessJob(ctx, res, stateReq.JobID) {
+		return
+	}
 
 	ctx = system.AddJobIDToBaggage(ctx, stateReq.JobID)
 	system.AddJobIDFromBaggageToSpan(ctx, span)
@@ -62,3 +75,74 @@ func (s *RequesterAPIServer) results(res http.ResponseWriter, req *http.Request)
 		return
 	}
 }
+
+// fetchResults godoc
+// @ID          pkg/requester/publicapi/results/fetch
+// @Summary     Streams the results of a shard that a compute node kept, as a tar archive.
+// @Description Fetches the results of a shard from the compute node that published them to itself.
+// @Tags        Job
+// @Accept      json
+// @Produce     application/x-tar
+// @Param       fetchResultsRequest body     fetchResultsRequest true " "
+// @Success     200                 {string} string
+// @Failure     400                 {object} string
+// @Failure     404                 {object} string
+// @Failure     500                 {object} string
+// @Router      /requester/results/fetch [post]
+func (s *RequesterAPIServer) fetchResults(res http.ResponseWriter, req *http.Request) {
+	ctx, span := system.GetSpanFromRequest(req, "pkg/publicapi.fetchResults")
+	defer span.End()
+
+	var fetchReq fetchResultsRequest
+	if err := json.NewDecoder(req.Body).Decode(&fetchReq); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res.Header().Set(handlerwrapper.HTTPHeaderClientID, fetchReq.ClientID)
+	res.Header().Set(handlerwrapper.HTTPHeaderJobID, fetchReq.JobID)
+	if !s.canAccessJob(ctx, res, fetchReq.JobID) {
+		return
+	}
+
+	ctx = system.AddJobIDToBaggage(ctx, fetchReq.JobID)
+	system.AddJobIDFromBaggageToSpan(ctx, span)
+
+	// only proxy results the job published to the node, so the node is not
+	// asked for anything else
+	results, err := localdb.GetStateResolver(s.localDB).GetResults(ctx, fetchReq.JobID)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	found := false
+	for _, result := range results {
+		if result.NodeID == fetchReq.NodeID && result.ShardIndex == fetchReq.ShardIndex &&
+			result.Data.StorageSource == model.StorageSourceNode {
+			found = true
+			break
+		}
+	}
+	if !found {
+		err = fmt.Errorf("node %s kept no results of shard %d of job %s", fetchReq.NodeID, fetchReq.ShardIndex, fetchReq.JobID)
+		http.Error(res, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	archive, err := s.resultsProvider.FetchResults(ctx, compute.FetchResultsRequest{
+		RoutingMetadata: compute.RoutingMetadata{
+			TargetPeerID: fetchReq.NodeID,
+		},
+		JobID:      fetchReq.JobID,
+		ShardIndex: fetchReq.ShardIndex,
+	})
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer archive.Close()
+
+	res.Header().Set("Content-Type", "application/x-tar")
+	res.WriteHeader(http.StatusOK)
+	// the response has started, so errors can only cut it short
+	_, _ = io.Copy(res, archive)
+}
@@ -0,0 +1,115 @@
+package publicapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/filecoin-project/bacalhau/pkg/job"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/handlerwrapper"
+)
+
+type scheduleCreateRequest struct {
+	// The schedule to create, including the job to start at each tick:
+	ScheduleCreatePayload model.JobScheduleCreatePayload `json:"schedule_create_payload" validate:"required"`
+
+	// A base64-encoded signature of the payload, signed by the client:
+	ClientSignature string `json:"signature" validate:"required"`
+
+	// The base64-encoded public key of the client:
+	ClientPublicKey string `json:"client_public_key" validate:"required"`
+}
+
+type scheduleRequest struct {
+	ClientID string `json:"client_id"`
+	// the schedule to pause, resume or delete
+	ID     string `json:"id,omitempty"`
+	Paused bool   `json:"paused,omitempty"`
+}
+
+type schedulesResponse struct {
+	Schedules []model.JobSchedule `json:"schedules"`
+}
+
+func (s *RequesterAPIServer) createSchedule(ctx context.Context, createReq scheduleCreateRequest) (schedulesResponse, error) {
+	payload := createReq.ScheduleCreatePayload
+	if payload.ClientID == "" {
+		return schedulesResponse{}, errors.New("schedule create payload must contain a client ID")
+	}
+	if err := verifyClientSignature(payload.ClientID, payload, createReq.ClientSignature, createReq.ClientPublicKey); err != nil {
+		return schedulesResponse{}, err
+	}
+	identity := handlerwrapper.IdentityFromContext(ctx)
+	if identity != nil && identity.ClientID != payload.ClientID {
+		return schedulesResponse{}, fmt.Errorf("%w: authenticated as client %s but creating a schedule as %s",
+			errForbidden, identity.ClientID, payload.ClientID)
+	}
+	// the scheduled job must be one the client could submit
+	if err := job.VerifyJobCreatePayload(ctx, &model.JobCreatePayload{
+		ClientID:   payload.ClientID,
+		APIVersion: payload.APIVersion,
+		Spec:       payload.Spec,
+	}); err != nil {
+		return schedulesResponse{}, err
+	}
+
+	schedule, err := s.schedules.Create(ctx, payload)
+	if err != nil {
+		return schedulesResponse{}, err
+	}
+	return schedulesResponse{Schedules: []model.JobSchedule{schedule}}, nil
+}
+
+func (s *RequesterAPIServer) listSchedules(ctx context.Context, listReq scheduleRequest) (schedulesResponse, error) {
+	clientID := listReq.ClientID
+	if identity := handlerwrapper.IdentityFromContext(ctx); identity != nil {
+		if clientID == "" && !identity.HasScope(handlerwrapper.ScopeListAll) {
+			clientID = identity.ClientID
+		}
+		if clientID != "" && !identity.CanAccessClient(clientID) {
+			return schedulesResponse{}, fmt.Errorf("%w: can't list the schedules of client %s", errForbidden, clientID)
+		}
+	}
+	schedules, err := s.schedules.List(ctx, clientID)
+	return schedulesResponse{Schedules: schedules}, err
+}
+
+func (s *RequesterAPIServer) pauseSchedule(ctx context.Context, pauseReq scheduleRequest) (schedulesResponse, error) {
+	if err := s.checkScheduleOwner(ctx, pauseReq); err != nil {
+		return schedulesResponse{}, err
+	}
+	schedule, err := s.schedules.SetPaused(ctx, pauseReq.ID, pauseReq.Paused)
+	if err != nil {
+		return schedulesResponse{}, err
+	}
+	return schedulesResponse{Schedules: []model.JobSchedule{schedule}}, nil
+}
+
+func (s *RequesterAPIServer) deleteSchedule(ctx context.Context, deleteReq scheduleRequest) (schedulesResponse, error) {
+	if err := s.checkScheduleOwner(ctx, deleteReq); err != nil {
+		return schedulesResponse{}, err
+	}
+	return schedulesResponse{}, s.schedules.Delete(ctx, deleteReq.ID)
+}
+
+// checkScheduleOwner returns an error if the schedule doesn't belong to the
+// client making the request. Admins can change any schedule.
+func (s *RequesterAPIServer) checkScheduleOwner(ctx context.Context, scheduleReq scheduleRequest) error {
+	schedule, err := s.schedules.Get(ctx, scheduleReq.ID)
+	if err != nil {
+		return err
+	}
+	clientID := scheduleReq.ClientID
+	identity := handlerwrapper.IdentityFromContext(ctx)
+	if identity != nil {
+		if identity.HasScope(handlerwrapper.ScopeAdmin) {
+			return nil
+		}
+		clientID = identity.ClientID
+	}
+	if schedule.ClientID != clientID {
+		return fmt.Errorf("%w: schedule %s belongs to another client", errForbidden, schedule.ID)
+	}
+	return nil
+}
@@ -42,6 +42,9 @@ func (s *RequesterAPIServer) states(res http.ResponseWriter, req *http.Request)
 	}
 	res.Header().Set(handlerwrapper.HTTPHeaderClientID, stateReq.ClientID)
 	res.Header().Set(handlerwrapper.HTTPHeaderJobID, stateReq.JobID)
+	if !s.canAccessJob(ctx, res, stateReq.JobID) {
+		return
+	}
 	ctx = system.AddJobIDToBaggage(ctx, stateReq.JobID)
 
 	js, err := getJobStateFromRequest(ctx, s, stateReq)
@@ -39,6 +39,7 @@ type submitResponse struct {
 // @Param                submitRequest body     submitRequest true " "
 // @Success              200           {object} submitResponse
 // @Failure              400           {object} string
+// @Failure              429           {object} string
 // @Failure              500           {object} string
 // @Router               /requester/submit [post]
 func (s *RequesterAPIServer) submit(res http.ResponseWriter, req *http.Request) {
@@ -67,6 +68,14 @@ func (s *RequesterAPIServer) submit(res http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	identity := handlerwrapper.IdentityFromContext(ctx)
+	if identity != nil && identity.ClientID != submitReq.JobCreatePayload.ClientID {
+		err := fmt.Errorf("forbidden: authenticated as client %s but submitting as %s",
+			identity.ClientID, submitReq.JobCreatePayload.ClientID)
+		http.Error(res, bacerrors.ErrorToErrorResponse(err), http.StatusForbidden)
+		return
+	}
+
 	if err := job.VerifyJobCreatePayload(ctx, &submitReq.JobCreatePayload); err != nil {
 		log.Ctx(ctx).Debug().Msgf("====> VerifyJobCreate error: %s", err)
 		errorResponse := bacerrors.ErrorToErrorResponse(err)
@@ -82,6 +91,10 @@ func (s *RequesterAPIServer) submit(res http.ResponseWriter, req *http.Request)
 	span.SetAttributes(attribute.String(model.TracerAttributeNameJobID, j.Metadata.ID))
 
 	if err != nil {
+		if _, ok := err.(*bacerrors.QuotaExceeded); ok {
+			http.Error(res, bacerrors.ErrorToErrorResponse(err), http.StatusTooManyRequests)
+			return
+		}
 		http.Error(res, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,30 @@
+package publicapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus metrics for monitoring the requester API:
+var (
+	submitDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "requester_api_submit_duration_seconds",
+			Help:    "Time taken by the requester API to handle job submissions, by response status code.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"status_code"},
+	)
+)
+
+// measureSubmit records how long the handler takes to handle job submissions.
+func measureSubmit(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		m := httpsnoop.CaptureMetrics(handler, res, req)
+		submitDuration.WithLabelValues(strconv.Itoa(m.Code)).Observe(m.Duration.Seconds())
+	})
+}
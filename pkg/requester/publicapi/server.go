@@ -3,10 +3,15 @@ package publicapi
 import (
 	"net/http"
 
+	"github.com/filecoin-project/bacalhau/pkg/compute"
 	"github.com/filecoin-project/bacalhau/pkg/localdb"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/publicapi"
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/auth"
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/handlerwrapper"
 	"github.com/filecoin-project/bacalhau/pkg/requester"
+	"github.com/filecoin-project/bacalhau/pkg/requester/quota"
+	"github.com/filecoin-project/bacalhau/pkg/requester/schedule"
 	"github.com/filecoin-project/bacalhau/pkg/storage"
 	"github.com/gorilla/websocket"
 	sync "github.com/lukemarsden/golang-mutex-tracer"
@@ -20,6 +25,16 @@ type RequesterAPIServerParams struct {
 	DebugInfoProviders []model.DebugInfoProvider
 	LocalDB            localdb.LocalDB
 	StorageProviders   storage.StorageProvider
+	// optional, serves clients' quota usage
+	QuotaTracker *quota.Tracker
+	// optional, only registered clients can use the API if set
+	AuthRegistry *auth.Registry
+	// optional, serves recurring job schedules
+	Schedules *schedule.Scheduler
+	// optional, serves the results compute nodes kept
+	ResultsProvider compute.ResultsProvider
+	// optional, serves the compute nodes the requester knows of
+	NodeInfoStore requester.NodeInfoStore
 }
 
 type RequesterAPIServer struct {
@@ -28,6 +43,11 @@ type RequesterAPIServer struct {
 	debugInfoProviders []model.DebugInfoProvider
 	localDB            localdb.LocalDB
 	storageProviders   storage.StorageProvider
+	quotaTracker       *quota.Tracker
+	authRegistry       *auth.Registry
+	schedules          *schedule.Scheduler
+	resultsProvider    compute.ResultsProvider
+	nodeInfoStore      requester.NodeInfoStore
 	// jobId or "" (for all events) -> connections for that subscription
 	websockets      map[string][]*websocket.Conn
 	websocketsMutex sync.RWMutex
@@ -43,21 +63,74 @@ func NewRequesterAPIServer(params RequesterAPIServerParams) *RequesterAPIServer
 		debugInfoProviders: params.DebugInfoProviders,
 		localDB:            params.LocalDB,
 		storageProviders:   params.StorageProviders,
+		quotaTracker:       params.QuotaTracker,
+		authRegistry:       params.AuthRegistry,
+		schedules:          params.Schedules,
+		resultsProvider:    params.ResultsProvider,
+		nodeInfoStore:      params.NodeInfoStore,
 		websockets:         make(map[string][]*websocket.Conn),
 	}
 }
 
 func (s *RequesterAPIServer) RegisterAllHandlers() error {
-	handlerConfigs := []publicapi.HandlerConfig{
-		{URI: "/" + APIPrefix + "list", Handler: http.HandlerFunc(s.list)},
-		{URI: "/" + APIPrefix + "states", Handler: http.HandlerFunc(s.states)},
-		{URI: "/" + APIPrefix + "results", Handler: http.HandlerFunc(s.results)},
-		{URI: "/" + APIPrefix + "events", Handler: http.HandlerFunc(s.events)},
-		{URI: "/" + APIPrefix + "local_events", Handler: http.HandlerFunc(s.localEvents)},
-		{URI: "/" + APIPrefix + "submit", Handler: http.HandlerFunc(s.submit)},
-		{URI: "/" + APIPrefix + "websocket", Handler: http.HandlerFunc(s.websocket), Raw: true},
-		{URI: "/" + APIPrefix + "node/websocket", Handler: http.HandlerFunc(s.websocketNode), Raw: true},
-		{URI: "/" + APIPrefix + "debug", Handler: http.HandlerFunc(s.debug)},
+	type route struct {
+		uri     string
+		handler http.Handler
+		raw     bool
+		// required when authentication is enabled
+		scope handlerwrapper.Scope
+	}
+	routes := []route{
+		{uri: "list", handler: http.HandlerFunc(s.list), scope: handlerwrapper.ScopeListOwn},
+		{uri: "states", handler: http.HandlerFunc(s.states), scope: handlerwrapper.ScopeListOwn},
+		{uri: "results", handler: http.HandlerFunc(s.results), scope: handlerwrapper.ScopeListOwn},
+		{uri: "events", handler: http.HandlerFunc(s.events), scope: handlerwrapper.ScopeListOwn},
+		{uri: "local_events", handler: http.HandlerFunc(s.localEvents), scope: handlerwrapper.ScopeListOwn},
+		{uri: "submit", handler: measureSubmit(http.HandlerFunc(s.submit)), scope: handlerwrapper.ScopeSubmit},
+		// websockets stream every client's events
+		{uri: "websocket", handler: http.HandlerFunc(s.websocket), raw: true, scope: handlerwrapper.ScopeListAll},
+		{uri: "node/websocket", handler: http.HandlerFunc(s.websocketNode), raw: true, scope: handlerwrapper.ScopeListAll},
+		{uri: "debug", handler: http.HandlerFunc(s.debug), scope: handlerwrapper.ScopeAdmin},
+		{uri: "quota", handler: http.HandlerFunc(s.quota), scope: handlerwrapper.ScopeListOwn},
+		{uri: "auth/whoami", handler: http.HandlerFunc(s.whoami)},
+	}
+	if s.schedules != nil {
+		routes = append(routes,
+			route{uri: "schedules/create", handler: jsonHandler(s.createSchedule), scope: handlerwrapper.ScopeSubmit},
+			route{uri: "schedules/list", handler: jsonHandler(s.listSchedules), scope: handlerwrapper.ScopeListOwn},
+			route{uri: "schedules/pause", handler: jsonHandler(s.pauseSchedule), scope: handlerwrapper.ScopeSubmit},
+			route{uri: "schedules/delete", handler: jsonHandler(s.deleteSchedule), scope: handlerwrapper.ScopeSubmit},
+		)
+	}
+	if s.resultsProvider != nil {
+		// results are streamed, which the middleware's timeout handler would buffer
+		routes = append(routes,
+			route{uri: "results/fetch", handler: http.HandlerFunc(s.fetchResults), raw: true, scope: handlerwrapper.ScopeListOwn},
+		)
+	}
+	if s.nodeInfoStore != nil {
+		routes = append(routes,
+			route{uri: "nodes", handler: jsonHandler(s.nodes)},
+		)
+	}
+	if s.authRegistry != nil {
+		routes = append(routes,
+			route{uri: "auth/keys/list", handler: jsonHandler(s.listKeys), scope: handlerwrapper.ScopeAdmin},
+			route{uri: "auth/keys/add", handler: jsonHandler(s.addKey), scope: handlerwrapper.ScopeAdmin},
+			route{uri: "auth/keys/remove", handler: jsonHandler(s.removeKey), scope: handlerwrapper.ScopeAdmin},
+			route{uri: "auth/tokens/list", handler: jsonHandler(s.listTokens), scope: handlerwrapper.ScopeAdmin},
+			route{uri: "auth/tokens/create", handler: jsonHandler(s.createToken), scope: handlerwrapper.ScopeAdmin},
+			route{uri: "auth/tokens/revoke", handler: jsonHandler(s.revokeToken), scope: handlerwrapper.ScopeAdmin},
+		)
+	}
+
+	handlerConfigs := make([]publicapi.HandlerConfig, 0, len(routes))
+	for _, r := range routes {
+		handler := r.handler
+		if s.authRegistry != nil {
+			handler = handlerwrapper.NewAuthHandler(handler, s.authRegistry, r.scope, s.apiServer.MaxBodyBytes())
+		}
+		handlerConfigs = append(handlerConfigs, publicapi.HandlerConfig{URI: "/" + APIPrefix + r.uri, Handler: handler, Raw: r.raw})
 	}
 	return s.apiServer.RegisterHandlers(handlerConfigs...)
 }
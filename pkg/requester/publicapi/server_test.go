@@ -0,0 +1,149 @@
+//go:build unit || !integration
+
+package publicapi
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/localdb/inmemory"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/publicapi"
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/auth"
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/phayes/freeport"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuthRoutesSuite struct {
+	suite.Suite
+	ctx      context.Context
+	registry *auth.Registry
+	uri      string
+}
+
+func TestAuthRoutesSuite(t *testing.T) {
+	suite.Run(t, new(AuthRoutesSuite))
+}
+
+func (s *AuthRoutesSuite) SetupTest() {
+	s.Require().NoError(system.InitConfigForTesting(s.T()))
+	s.ctx = context.Background()
+	cm := system.NewCleanupManager()
+	s.T().Cleanup(cm.Cleanup)
+
+	store, err := inmemory.NewInMemoryDatastore()
+	s.Require().NoError(err)
+	for _, job := range []*model.Job{
+		{Metadata: model.Metadata{ID: "job-of-a", ClientID: "client-a", CreatedAt: time.Now()}},
+		{Metadata: model.Metadata{ID: "job-of-b", ClientID: "client-b", CreatedAt: time.Now()}},
+	} {
+		s.Require().NoError(store.AddJob(s.ctx, job))
+	}
+
+	s.registry, err = auth.NewRegistry(auth.RegistryParams{Path: filepath.Join(s.T().TempDir(), "auth.json")})
+	s.Require().NoError(err)
+
+	mn := mocknet.New()
+	s.T().Cleanup(func() { _ = mn.Close() })
+	host, err := mn.GenPeer()
+	s.Require().NoError(err)
+	port, err := freeport.GetFreePort()
+	s.Require().NoError(err)
+	config := publicapi.DefaultAPIServerConfig
+	config.MaxBytesToReadInBody = 1024
+	apiServer, err := publicapi.NewAPIServer(publicapi.APIServerParams{
+		Address: "127.0.0.1",
+		Port:    port,
+		Host:    host,
+		Config:  config,
+	})
+	s.Require().NoError(err)
+	server := NewRequesterAPIServer(RequesterAPIServerParams{
+		APIServer:    apiServer,
+		LocalDB:      store,
+		AuthRegistry: s.registry,
+	})
+	s.Require().NoError(server.RegisterAllHandlers())
+	go func() {
+		_ = apiServer.ListenAndServe(s.ctx, cm)
+	}()
+	s.uri = apiServer.GetURI()
+	s.Require().Eventually(func() bool {
+		alive, _ := publicapi.NewAPIClient(s.uri).Alive(s.ctx)
+		return alive
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
+// token returns a token issued to the client with the scopes.
+func (s *AuthRoutesSuite) token(clientID string, scopes ...handlerwrapper.Scope) string {
+	secret, _, err := s.registry.CreateToken(s.ctx, clientID, scopes, time.Hour)
+	s.Require().NoError(err)
+	return secret
+}
+
+// post returns the status code of a request to a route of the requester API.
+func (s *AuthRoutesSuite) post(route, token, body string) int {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.uri+"/"+APIPrefix+route, strings.NewReader(body))
+	s.Require().NoError(err)
+	if token != "" {
+		req.Header.Set(handlerwrapper.HTTPHeaderAuthorization, "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer res.Body.Close()
+	return res.StatusCode
+}
+
+// list returns the IDs of the jobs listed for the token.
+func (s *AuthRoutesSuite) list(token string) []string {
+	client := NewRequesterAPIClient(s.uri)
+	client.DefaultHeaders[handlerwrapper.HTTPHeaderAuthorization] = "Bearer " + token
+	jobs, err := client.List(s.ctx, "", nil, nil, 10, true, "id", false)
+	s.Require().NoError(err)
+	var ids []string
+	for _, job := range jobs {
+		ids = append(ids, job.Metadata.ID)
+	}
+	return ids
+}
+
+func (s *AuthRoutesSuite) TestRouteScopes() {
+	submitter := s.token("client-a", handlerwrapper.ScopeSubmit)
+	reader := s.token("client-a", handlerwrapper.ScopeListOwn)
+	admin := s.token("admin", handlerwrapper.ScopeAdmin)
+
+	s.Equal(http.StatusUnauthorized, s.post("list", "", `{}`))
+	s.Equal(http.StatusForbidden, s.post("list", submitter, `{}`))
+	s.Equal(http.StatusOK, s.post("list", reader, `{}`))
+
+	s.Equal(http.StatusForbidden, s.post("submit", reader, `{}`))
+	s.Equal(http.StatusForbidden, s.post("debug", reader, `{}`))
+	s.Equal(http.StatusForbidden, s.post("auth/keys/list", reader, `{}`))
+	s.Equal(http.StatusOK, s.post("auth/keys/list", admin, `{}`))
+
+	// websockets stream every client's events
+	s.Equal(http.StatusForbidden, s.post("websocket", reader, ``))
+}
+
+func (s *AuthRoutesSuite) TestListOwnJobs() {
+	s.Equal([]string{"job-of-a"}, s.list(s.token("client-a", handlerwrapper.ScopeListOwn)))
+	s.Equal([]string{"job-of-b"}, s.list(s.token("client-b", handlerwrapper.ScopeListOwn)))
+	s.Equal([]string{"job-of-a", "job-of-b"}, s.list(s.token("client-a", handlerwrapper.ScopeListAll)))
+
+	// asking for another client's job by ID must not get around the scope
+	s.Equal(http.StatusForbidden, s.post("list", s.token("client-b", handlerwrapper.ScopeListOwn), `{"id": "job-of-a"}`))
+	s.Equal(http.StatusOK, s.post("list", s.token("client-a", handlerwrapper.ScopeListOwn), `{"id": "job-of-a"}`))
+	s.Equal(http.StatusOK, s.post("list", s.token("client-b", handlerwrapper.ScopeListAll), `{"id": "job-of-a"}`))
+}
+
+func (s *AuthRoutesSuite) TestRawRouteBodyLimit() {
+	reader := s.token("client-a", handlerwrapper.ScopeListAll)
+	s.Equal(http.StatusRequestEntityTooLarge, s.post("websocket", reader, strings.Repeat("a", 2048)))
+}
@@ -1,10 +1,14 @@
 package publicapi
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
 
+	"github.com/filecoin-project/bacalhau/pkg/bacerrors"
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/publicapi/handlerwrapper"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 )
 
@@ -12,15 +16,21 @@ func verifySubmitRequest(req *submitRequest) error {
 	if req.JobCreatePayload.ClientID == "" {
 		return errors.New("job create payload must contain a client ID")
 	}
-	if req.ClientSignature == "" {
+	return verifyClientSignature(req.JobCreatePayload.ClientID, req.JobCreatePayload, req.ClientSignature, req.ClientPublicKey)
+}
+
+// verifyClientSignature checks that a payload was signed by the client it
+// claims to come from.
+func verifyClientSignature(clientID string, payload interface{}, signature, publicKey string) error {
+	if signature == "" {
 		return errors.New("client's signature is required")
 	}
-	if req.ClientPublicKey == "" {
+	if publicKey == "" {
 		return errors.New("client's public key is required")
 	}
 
 	// Check that the client's public key matches the client ID:
-	ok, err := system.PublicKeyMatchesID(req.ClientPublicKey, req.JobCreatePayload.ClientID)
+	ok, err := system.PublicKeyMatchesID(publicKey, clientID)
 	if err != nil {
 		return fmt.Errorf("error verifying client ID: %w", err)
 	}
@@ -29,15 +39,39 @@ func verifySubmitRequest(req *submitRequest) error {
 	}
 
 	// Check that the signature is valid:
-	jsonData, err := model.JSONMarshalWithMax(req.JobCreatePayload)
+	jsonData, err := model.JSONMarshalWithMax(payload)
 	if err != nil {
 		return fmt.Errorf("error marshaling job data: %w", err)
 	}
 
-	err = system.Verify(jsonData, req.ClientSignature, req.ClientPublicKey)
+	err = system.Verify(jsonData, signature, publicKey)
 	if err != nil {
 		return fmt.Errorf("client's signature is invalid: %w", err)
 	}
 
 	return nil
 }
+
+// canAccessJob returns false, after writing an error response, if the client
+// that made the request isn't allowed to read the job. Jobs that don't exist
+// are left for the caller to report.
+func (s *RequesterAPIServer) canAccessJob(ctx context.Context, res http.ResponseWriter, jobID string) bool {
+	identity := handlerwrapper.IdentityFromContext(ctx)
+	if identity == nil {
+		return true
+	}
+	j, err := s.localDB.GetJob(ctx, jobID)
+	if err != nil {
+		if _, ok := err.(*bacerrors.JobNotFound); ok {
+			return true
+		}
+		http.Error(res, bacerrors.ErrorToErrorResponse(err), http.StatusInternalServerError)
+		return false
+	}
+	if !identity.CanAccessClient(j.Metadata.ClientID) {
+		err = fmt.Errorf("forbidden: job %s belongs to another client", jobID)
+		http.Error(res, bacerrors.ErrorToErrorResponse(err), http.StatusForbidden)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,308 @@
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/filecoin-project/bacalhau/pkg/bacerrors"
+	"github.com/filecoin-project/bacalhau/pkg/compute/capacity"
+	"github.com/filecoin-project/bacalhau/pkg/eventhandler"
+	jobutils "github.com/filecoin-project/bacalhau/pkg/job"
+	"github.com/filecoin-project/bacalhau/pkg/localdb"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/requester/jobtransform"
+)
+
+type TrackerParams struct {
+	Limits   Limits
+	JobStore localdb.LocalDB
+	// defaults to time.Now, visible for testing
+	Clock func() time.Time
+}
+
+// Tracker admits jobs submitted to the requester if they are within their
+// client's limits, and tracks each client's usage. It is also a
+// JobEventHandler that releases jobs once they are done, so it must come after
+// the handler that updates the job store.
+type Tracker struct {
+	limits   Limits
+	jobStore localdb.LocalDB
+	clock    func() time.Time
+
+	clients map[string]*clientUsage
+	// active job ID -> client ID
+	activeJobs map[string]string
+	mu         sync.Mutex
+}
+
+type clientUsage struct {
+	// active job ID -> when the job stops counting as active, which is zero
+	// for jobs released once their shards are done
+	activeJobs map[string]time.Time
+	admissions []admission
+}
+
+type admission struct {
+	jobID string
+	time  time.Time
+	hours ResourceHours
+}
+
+func NewTracker(params TrackerParams) *Tracker {
+	limits := params.Limits
+	if limits.Window <= 0 {
+		limits.Window = DefaultWindow
+	}
+	clock := params.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+	return &Tracker{
+		limits:     limits,
+		jobStore:   params.JobStore,
+		clock:      clock,
+		clients:    map[string]*clientUsage{},
+		activeJobs: map[string]string{},
+	}
+}
+
+// Limits returns the limits applied to each client.
+func (t *Tracker) Limits() Limits {
+	return t.limits
+}
+
+// Admit returns a QuotaExceeded error if the job would take its client over
+// any of their limits, and otherwise counts the job against them. It must run
+// after the transforms that set the job's timeout and execution plan.
+func (t *Tracker) Admit(ctx context.Context, job *model.Job) (bool, error) {
+	if t.limits.IsZero() {
+		return false, nil
+	}
+	clientID := job.Metadata.ClientID
+
+	timeout := job.Spec.GetTimeout()
+	if t.limits.MaxTimeout > 0 && timeout > t.limits.MaxTimeout {
+		return false, bacerrors.NewQuotaExceeded(clientID, LimitTimeout, t.limits.MaxTimeout, 0, timeout)
+	}
+	executions := jobutils.GetJobTotalExecutionCount(job)
+	if t.limits.MaxShards > 0 && executions > t.limits.MaxShards {
+		return false, bacerrors.NewQuotaExceeded(clientID, LimitShards, t.limits.MaxShards, 0, executions)
+	}
+	hours := JobResourceHours(job)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	client := t.client(clientID)
+	usage := t.usage(client)
+	if t.limits.MaxConcurrentJobs > 0 && usage.ConcurrentJobs >= t.limits.MaxConcurrentJobs {
+		return false, bacerrors.NewQuotaExceeded(
+			clientID, LimitConcurrentJobs, t.limits.MaxConcurrentJobs, usage.ConcurrentJobs, 1)
+	}
+	if exceeds(t.limits.MaxCPUHours, usage.CPUHours, hours.CPU) {
+		return false, bacerrors.NewQuotaExceeded(clientID, LimitCPUHours, t.limits.MaxCPUHours, usage.CPUHours, hours.CPU)
+	}
+	if exceeds(t.limits.MaxMemoryGBHours, usage.MemoryGBHours, hours.MemoryGB) {
+		return false, bacerrors.NewQuotaExceeded(
+			clientID, LimitMemoryGBHours, t.limits.MaxMemoryGBHours, usage.MemoryGBHours, hours.MemoryGB)
+	}
+	if exceeds(t.limits.MaxGPUHours, usage.GPUHours, hours.GPU) {
+		return false, bacerrors.NewQuotaExceeded(clientID, LimitGPUHours, t.limits.MaxGPUHours, usage.GPUHours, hours.GPU)
+	}
+
+	t.admit(clientID, job.Metadata.ID, t.clock(), hours, time.Time{})
+	return false, nil
+}
+
+// admit counts a job against its client's limits. Must be called with the
+// lock held.
+func (t *Tracker) admit(clientID, jobID string, admittedAt time.Time, hours ResourceHours, activeUntil time.Time) {
+	client := t.client(clientID)
+	client.activeJobs[jobID] = activeUntil
+	client.admissions = append(client.admissions, admission{jobID: jobID, time: admittedAt, hours: hours})
+	t.activeJobs[jobID] = clientID
+}
+
+// Release stops counting a job that was admitted but never started against
+// its client's limits, including its resource-hours.
+func (t *Tracker) Release(jobID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	clientID, ok := t.activeJobs[jobID]
+	if !ok {
+		return
+	}
+	delete(t.activeJobs, jobID)
+	client, ok := t.clients[clientID]
+	if !ok {
+		return
+	}
+	delete(client.activeJobs, jobID)
+	kept := client.admissions[:0]
+	for _, a := range client.admissions {
+		if a.jobID != jobID {
+			kept = append(kept, a)
+		}
+	}
+	client.admissions = kept
+}
+
+// Restore rebuilds the clients' usage from the job store, so that restarting
+// the requester doesn't reset it. Jobs created within the window count
+// towards their resource-hours. Jobs whose shards aren't done yet count as
+// active until their timeout passes, as the requester doesn't resume them and
+// so won't see them finish.
+func (t *Tracker) Restore(ctx context.Context) error {
+	if t.limits.IsZero() {
+		return nil
+	}
+	now := t.clock()
+	windowStart := now.Add(-t.limits.Window)
+	since := windowStart
+	if t.limits.MaxTimeout > t.limits.Window {
+		since = now.Add(-t.limits.MaxTimeout)
+	}
+	jobs, err := t.jobStore.GetJobs(ctx, localdb.JobQuery{ReturnAll: true, CreatedAfter: since})
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, job := range jobs {
+		if _, ok := t.activeJobs[job.Metadata.ID]; ok {
+			continue
+		}
+		activeUntil := job.Metadata.CreatedAt.Add(job.Spec.GetTimeout())
+		if activeUntil.After(now) {
+			state, err := t.jobStore.GetJobState(ctx, job.Metadata.ID)
+			if err != nil {
+				return err
+			}
+			if done, _ := jobutils.WaitForTerminalStates(jobutils.GetJobTotalShards(job))(state); done {
+				activeUntil = time.Time{}
+			}
+		}
+		if !activeUntil.After(now) && job.Metadata.CreatedAt.Before(windowStart) {
+			continue
+		}
+		t.admit(job.Metadata.ClientID, job.Metadata.ID, job.Metadata.CreatedAt, JobResourceHours(job), activeUntil)
+		if !activeUntil.After(now) {
+			t.release(job.Metadata.ID)
+		}
+	}
+	return nil
+}
+
+// Usage returns what a client is currently using of its limits.
+func (t *Tracker) Usage(clientID string) Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	client, ok := t.clients[clientID]
+	if !ok {
+		return Usage{}
+	}
+	return t.usage(client)
+}
+
+// HandleJobEvent releases a job from its client's concurrent jobs once all of
+// its shards are in a terminal state. Resource-hours stay counted until they
+// fall out of the window.
+func (t *Tracker) HandleJobEvent(ctx context.Context, event model.JobEvent) error {
+	t.mu.Lock()
+	_, ok := t.activeJobs[event.JobID]
+	t.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	job, err := t.jobStore.GetJob(ctx, event.JobID)
+	if err != nil {
+		return err
+	}
+	state, err := t.jobStore.GetJobState(ctx, event.JobID)
+	if err != nil {
+		return err
+	}
+	done, err := jobutils.WaitForTerminalStates(jobutils.GetJobTotalShards(job))(state)
+	if err != nil || !done {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.release(event.JobID)
+	return nil
+}
+
+// release stops counting a job as active, but keeps its resource-hours. Must
+// be called with the lock held.
+func (t *Tracker) release(jobID string) {
+	clientID, ok := t.activeJobs[jobID]
+	if !ok {
+		return
+	}
+	delete(t.activeJobs, jobID)
+	if client, ok := t.clients[clientID]; ok {
+		delete(client.activeJobs, jobID)
+	}
+}
+
+// client returns the usage of a client, creating it if needed. Must be
+// called with the lock held.
+func (t *Tracker) client(clientID string) *clientUsage {
+	client, ok := t.clients[clientID]
+	if !ok {
+		client = &clientUsage{activeJobs: map[string]time.Time{}}
+		t.clients[clientID] = client
+	}
+	return client
+}
+
+// usage drops the client's admissions that are out of the window and its
+// active jobs that have expired, and sums up the rest. Must be called with the
+// lock held.
+func (t *Tracker) usage(client *clientUsage) Usage {
+	now := t.clock()
+	for jobID, activeUntil := range client.activeJobs {
+		if !activeUntil.IsZero() && !activeUntil.After(now) {
+			t.release(jobID)
+		}
+	}
+	windowStart := now.Add(-t.limits.Window)
+	kept := client.admissions[:0]
+	usage := Usage{ConcurrentJobs: len(client.activeJobs)}
+	for _, a := range client.admissions {
+		if a.time.Before(windowStart) {
+			continue
+		}
+		kept = append(kept, a)
+		usage.CPUHours += a.hours.CPU
+		usage.MemoryGBHours += a.hours.MemoryGB
+		usage.GPUHours += a.hours.GPU
+	}
+	client.admissions = kept
+	return usage
+}
+
+// JobResourceHours returns the resource-hours a job requests, which is its
+// requested resources for its timeout, for each of its shard executions.
+// Resources the job doesn't request are not counted.
+func JobResourceHours(job *model.Job) ResourceHours {
+	resources := capacity.ParseResourceUsageConfig(job.Spec.Resources)
+	hours := job.Spec.GetTimeout().Hours() * float64(jobutils.GetJobTotalExecutionCount(job))
+	return ResourceHours{
+		CPU:      resources.CPU * hours,
+		MemoryGB: float64(resources.Memory) / float64(datasize.GB) * hours,
+		GPU:      float64(resources.GPU) * hours,
+	}
+}
+
+func exceeds(max, used, requested float64) bool {
+	return max > 0 && used+requested > max
+}
+
+// compile-time checks that Tracker implements the expected interfaces
+var _ eventhandler.JobEventHandler = (*Tracker)(nil)
+var _ jobtransform.Transformer = (*Tracker)(nil).Admit
@@ -0,0 +1,194 @@
+//go:build unit || !integration
+
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/bacerrors"
+	"github.com/filecoin-project/bacalhau/pkg/localdb/inmemory"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+const clientID = "quota-test-client"
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func setup(t *testing.T, limits Limits) (*Tracker, *inmemory.InMemoryDatastore, *fakeClock) {
+	store, err := inmemory.NewInMemoryDatastore()
+	require.NoError(t, err)
+	clock := &fakeClock{now: time.Now()}
+	tracker := NewTracker(TrackerParams{
+		Limits:   limits,
+		JobStore: store,
+		Clock:    clock.Now,
+	})
+	return tracker, store, clock
+}
+
+func newJob(id string, cpu string, timeout time.Duration, shards int) *model.Job {
+	return &model.Job{
+		Metadata: model.Metadata{ID: id, ClientID: clientID},
+		Spec: model.Spec{
+			Resources:     model.ResourceUsageConfig{CPU: cpu},
+			Timeout:       timeout.Seconds(),
+			ExecutionPlan: model.JobExecutionPlan{TotalShards: shards},
+		},
+	}
+}
+
+func requireQuotaExceeded(t *testing.T, err error, limit string) {
+	require.Error(t, err)
+	quotaErr, ok := err.(*bacerrors.QuotaExceeded)
+	require.True(t, ok, "expected a QuotaExceeded error, got %T", err)
+	require.Equal(t, limit, quotaErr.GetLimit())
+	require.Equal(t, clientID, quotaErr.GetDetails()["client_id"])
+}
+
+func TestAdmitWithoutLimits(t *testing.T) {
+	tracker, _, _ := setup(t, Limits{})
+	for i := 0; i < 10; i++ {
+		_, err := tracker.Admit(context.Background(), newJob("job", "100", time.Hour, 100))
+		require.NoError(t, err)
+	}
+}
+
+func TestAdmitPerJobLimits(t *testing.T) {
+	tracker, _, _ := setup(t, Limits{MaxShards: 4, MaxTimeout: time.Hour})
+	ctx := context.Background()
+
+	_, err := tracker.Admit(ctx, newJob("ok", "1", time.Hour, 4))
+	require.NoError(t, err)
+
+	_, err = tracker.Admit(ctx, newJob("too-long", "1", 2*time.Hour, 1))
+	requireQuotaExceeded(t, err, LimitTimeout)
+
+	_, err = tracker.Admit(ctx, newJob("too-many-shards", "1", time.Hour, 5))
+	requireQuotaExceeded(t, err, LimitShards)
+
+	concurrent := newJob("too-concurrent", "1", time.Hour, 2)
+	concurrent.Spec.Deal.Concurrency = 3
+	_, err = tracker.Admit(ctx, concurrent)
+	requireQuotaExceeded(t, err, LimitShards)
+}
+
+func TestAdmitConcurrentJobs(t *testing.T) {
+	tracker, store, _ := setup(t, Limits{MaxConcurrentJobs: 1})
+	ctx := context.Background()
+
+	first := newJob("quota-test-first-job", "", time.Minute, 1)
+	require.NoError(t, store.AddJob(ctx, first))
+	_, err := tracker.Admit(ctx, first)
+	require.NoError(t, err)
+	require.Equal(t, 1, tracker.Usage(clientID).ConcurrentJobs)
+
+	_, err = tracker.Admit(ctx, newJob("second", "", time.Minute, 1))
+	requireQuotaExceeded(t, err, LimitConcurrentJobs)
+
+	// the job is still running, so it is not released
+	require.NoError(t, store.UpdateShardState(ctx, first.Metadata.ID, "node", 0, model.JobShardState{
+		NodeID: "node",
+		State:  model.JobStateRunning,
+	}))
+	require.NoError(t, tracker.HandleJobEvent(ctx, model.JobEvent{JobID: first.Metadata.ID}))
+	require.Equal(t, 1, tracker.Usage(clientID).ConcurrentJobs)
+
+	require.NoError(t, store.UpdateShardState(ctx, first.Metadata.ID, "node", 0, model.JobShardState{
+		NodeID: "node",
+		State:  model.JobStateCompleted,
+	}))
+	require.NoError(t, tracker.HandleJobEvent(ctx, model.JobEvent{JobID: first.Metadata.ID}))
+	require.Equal(t, 0, tracker.Usage(clientID).ConcurrentJobs)
+
+	_, err = tracker.Admit(ctx, newJob("second", "", time.Minute, 1))
+	require.NoError(t, err)
+}
+
+func TestAdmitResourceHoursWindow(t *testing.T) {
+	tracker, _, clock := setup(t, Limits{MaxCPUHours: 10, Window: time.Hour})
+	ctx := context.Background()
+
+	// 2 CPUs for 1 hour on 3 shards
+	_, err := tracker.Admit(ctx, newJob("first", "2", time.Hour, 3))
+	require.NoError(t, err)
+	require.InDelta(t, 6, tracker.Usage(clientID).CPUHours, 0.001)
+
+	_, err = tracker.Admit(ctx, newJob("second", "2", time.Hour, 3))
+	requireQuotaExceeded(t, err, LimitCPUHours)
+
+	_, err = tracker.Admit(ctx, newJob("small", "500m", time.Hour, 1))
+	require.NoError(t, err)
+	require.InDelta(t, 6.5, tracker.Usage(clientID).CPUHours, 0.001)
+
+	// usage falls out of the window
+	clock.now = clock.now.Add(61 * time.Minute)
+	require.Zero(t, tracker.Usage(clientID).CPUHours)
+	_, err = tracker.Admit(ctx, newJob("second", "2", time.Hour, 3))
+	require.NoError(t, err)
+
+	// other clients have their own usage
+	require.Equal(t, Usage{}, tracker.Usage("other-client"))
+}
+
+func TestRelease(t *testing.T) {
+	tracker, _, _ := setup(t, Limits{MaxConcurrentJobs: 1, MaxCPUHours: 10})
+	ctx := context.Background()
+
+	_, err := tracker.Admit(ctx, newJob("first", "2", time.Hour, 3))
+	require.NoError(t, err)
+	_, err = tracker.Admit(ctx, newJob("second", "1", time.Hour, 1))
+	requireQuotaExceeded(t, err, LimitConcurrentJobs)
+
+	// a job that failed to start uses nothing
+	tracker.Release("first")
+	require.Equal(t, Usage{}, tracker.Usage(clientID))
+	_, err = tracker.Admit(ctx, newJob("second", "1", time.Hour, 1))
+	require.NoError(t, err)
+	require.InDelta(t, 1, tracker.Usage(clientID).CPUHours, 0.001)
+}
+
+func TestRestore(t *testing.T) {
+	tracker, store, clock := setup(t, Limits{MaxConcurrentJobs: 2, MaxCPUHours: 10, Window: 2 * time.Hour})
+	ctx := context.Background()
+
+	addJob := func(id string, createdAgo time.Duration, state model.JobStateType) {
+		job := newJob(id, "1", time.Hour, 1)
+		job.Metadata.CreatedAt = clock.now.Add(-createdAgo)
+		require.NoError(t, store.AddJob(ctx, job))
+		require.NoError(t, store.UpdateShardState(ctx, id, "node", 0, model.JobShardState{NodeID: "node", State: state}))
+	}
+	addJob("running", 10*time.Minute, model.JobStateRunning)
+	addJob("completed", 20*time.Minute, model.JobStateCompleted)
+	addJob("timed-out", 90*time.Minute, model.JobStateRunning)
+	addJob("out-of-window", 3*time.Hour, model.JobStateCompleted)
+
+	require.NoError(t, tracker.Restore(ctx))
+	usage := tracker.Usage(clientID)
+	require.Equal(t, 1, usage.ConcurrentJobs)
+	require.InDelta(t, 3, usage.CPUHours, 0.001)
+
+	// the running job is released when it finishes, or else when its timeout passes
+	clock.now = clock.now.Add(time.Hour)
+	require.Equal(t, 0, tracker.Usage(clientID).ConcurrentJobs)
+}
+
+func TestJobResourceHours(t *testing.T) {
+	job := newJob("job", "2", 30*time.Minute, 2)
+	job.Spec.Resources.Memory = "4Gb"
+	job.Spec.Resources.GPU = "1"
+	job.Spec.Deal.Concurrency = 2
+
+	hours := JobResourceHours(job)
+	require.InDelta(t, 4, hours.CPU, 0.001)
+	require.InDelta(t, 8, hours.MemoryGB, 0.001)
+	require.InDelta(t, 2, hours.GPU, 0.001)
+}
@@ -0,0 +1,59 @@
+package quota
+
+import (
+	"time"
+)
+
+const (
+	// DefaultWindow is the window resource-hours are counted over, if the
+	// limits don't set one.
+	DefaultWindow = 24 * time.Hour
+
+	LimitConcurrentJobs = "concurrent_jobs"
+	LimitShards         = "shards"
+	LimitTimeout        = "timeout"
+	LimitCPUHours       = "cpu_hours"
+	LimitMemoryGBHours  = "memory_gb_hours"
+	LimitGPUHours       = "gpu_hours"
+)
+
+// Limits are the limits applied to each client, by ClientID. Zero values mean
+// unlimited.
+type Limits struct {
+	// MaxConcurrentJobs is how many jobs a client can have in progress.
+	MaxConcurrentJobs int `json:"MaxConcurrentJobs,omitempty"`
+	// MaxShards is how many shard executions a single job can ask for, which
+	// is its number of shards times its concurrency.
+	MaxShards int `json:"MaxShards,omitempty"`
+	// MaxTimeout is the longest execution timeout a job can ask for.
+	MaxTimeout time.Duration `json:"MaxTimeout,omitempty"`
+	// MaxCPUHours, MaxMemoryGBHours and MaxGPUHours are how many
+	// resource-hours a client's jobs can request within Window. A job requests
+	// its resources for its timeout, for each of its shard executions.
+	MaxCPUHours      float64 `json:"MaxCPUHours,omitempty"`
+	MaxMemoryGBHours float64 `json:"MaxMemoryGBHours,omitempty"`
+	MaxGPUHours      float64 `json:"MaxGPUHours,omitempty"`
+	// Window is the sliding window resource-hours are counted over.
+	Window time.Duration `json:"Window,omitempty"`
+}
+
+// IsZero returns true if no limit is set.
+func (l Limits) IsZero() bool {
+	return l.MaxConcurrentJobs == 0 && l.MaxShards == 0 && l.MaxTimeout == 0 &&
+		l.MaxCPUHours == 0 && l.MaxMemoryGBHours == 0 && l.MaxGPUHours == 0
+}
+
+// Usage is what a client is currently using of its limits.
+type Usage struct {
+	ConcurrentJobs int     `json:"ConcurrentJobs"`
+	CPUHours       float64 `json:"CPUHours"`
+	MemoryGBHours  float64 `json:"MemoryGBHours"`
+	GPUHours       float64 `json:"GPUHours"`
+}
+
+// ResourceHours are the resource-hours a job requests.
+type ResourceHours struct {
+	CPU      float64
+	MemoryGB float64
+	GPU      float64
+}
@@ -0,0 +1,75 @@
+package ranking
+
+import (
+	"context"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/requester"
+	"github.com/filecoin-project/bacalhau/pkg/storage/cache"
+	"github.com/filecoin-project/bacalhau/pkg/storage/git"
+	"github.com/filecoin-project/bacalhau/pkg/storage/url/urldownload"
+)
+
+type InputLocalityNodeRanker struct {
+}
+
+func NewInputLocalityNodeRanker() *InputLocalityNodeRanker {
+	return &InputLocalityNodeRanker{}
+}
+
+// RankNodes ranks nodes based on how many of the job's inputs they already hold in their input cache:
+// - Rank 10: Node has all of the job's inputs cached.
+// - Rank 0 to 10: Node has a proportional share of the job's inputs cached.
+// - Rank 0: Node has none of the inputs cached, the job has no inputs, or the node doesn't advertise its cache.
+func (s *InputLocalityNodeRanker) RankNodes(ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]requester.NodeRank, error) {
+	var keys []string
+	for _, input := range job.Spec.Inputs {
+		if key := inputCacheKey(input); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	ranks := make([]requester.NodeRank, len(nodes))
+	for i, node := range nodes {
+		rank := 0
+		if len(keys) > 0 && len(node.ComputeNodeInfo.CachedInputs) > 0 {
+			cached := make(map[string]bool, len(node.ComputeNodeInfo.CachedInputs))
+			for _, key := range node.ComputeNodeInfo.CachedInputs {
+				cached[key] = true
+			}
+			hits := 0
+			for _, key := range keys {
+				if cached[key] {
+					hits++
+				}
+			}
+			rank = 10 * hits / len(keys)
+		}
+		ranks[i] = requester.NodeRank{
+			NodeInfo: node,
+			Rank:     rank,
+		}
+	}
+	return ranks, nil
+}
+
+// inputCacheKey returns the key a compute node caches the input under, or an
+// empty string if the input is never cached or its key can't be known up
+// front (e.g. a git branch, as checkouts are cached by the commit it points to).
+func inputCacheKey(input model.StorageSpec) string {
+	switch input.StorageSource {
+	case model.StorageSourceIPFS:
+		return cache.Key(input.StorageSource, input.CID)
+	case model.StorageSourceURLDownload:
+		key, err := urldownload.CacheKey(input)
+		if err != nil {
+			return ""
+		}
+		return key
+	case model.StorageSourceGit:
+		key, _ := git.CacheKey(input)
+		return key
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,106 @@
+package ranking
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/storage/cache"
+	"github.com/filecoin-project/bacalhau/pkg/storage/git"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type InputLocalityNodeRankerSuite struct {
+	suite.Suite
+	InputLocalityNodeRanker *InputLocalityNodeRanker
+	nodes                   []model.NodeInfo
+}
+
+func (s *InputLocalityNodeRankerSuite) SetupSuite() {
+	cid1 := cache.Key(model.StorageSourceIPFS, "cid1")
+	url1 := cache.Key(model.StorageSourceURLDownload, "https://example.com/data.csv")
+	s.nodes = []model.NodeInfo{
+		{
+			PeerInfo:        peer.AddrInfo{ID: peer.ID("all")},
+			ComputeNodeInfo: model.ComputeNodeInfo{CachedInputs: []string{url1, cid1}},
+		},
+		{
+			PeerInfo:        peer.AddrInfo{ID: peer.ID("half")},
+			ComputeNodeInfo: model.ComputeNodeInfo{CachedInputs: []string{cid1, cache.Key(model.StorageSourceIPFS, "other")}},
+		},
+		{
+			PeerInfo:        peer.AddrInfo{ID: peer.ID("none")},
+			ComputeNodeInfo: model.ComputeNodeInfo{CachedInputs: []string{cache.Key(model.StorageSourceIPFS, "other")}},
+		},
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("unknown")},
+		},
+	}
+}
+
+func (s *InputLocalityNodeRankerSuite) SetupTest() {
+	s.InputLocalityNodeRanker = NewInputLocalityNodeRanker()
+}
+
+func TestInputLocalityNodeRankerSuite(t *testing.T) {
+	suite.Run(t, new(InputLocalityNodeRankerSuite))
+}
+
+func (s *InputLocalityNodeRankerSuite) TestRankNodes() {
+	job := model.Job{Spec: model.Spec{Inputs: []model.StorageSpec{
+		{StorageSource: model.StorageSourceIPFS, CID: "cid1"},
+		{StorageSource: model.StorageSourceURLDownload, URL: "https://example.com/data.csv"},
+	}}}
+	ranks, err := s.InputLocalityNodeRanker.RankNodes(context.Background(), job, s.nodes)
+	s.NoError(err)
+	s.Equal(len(s.nodes), len(ranks))
+	assertEquals(s.T(), ranks, "all", 10)
+	assertEquals(s.T(), ranks, "half", 5)
+	assertEquals(s.T(), ranks, "none", 0)
+	assertEquals(s.T(), ranks, "unknown", 0)
+}
+
+func (s *InputLocalityNodeRankerSuite) TestRankNodes_MatchesStorageKeys() {
+	commit := "0123456789abcdef0123456789abcdef01234567"
+	gitKey, ok := git.CacheKey(git.NewStorageSpec("https://example.com/repo.git", commit, "", "/code"))
+	s.Require().True(ok)
+	nodes := []model.NodeInfo{{
+		PeerInfo:        peer.AddrInfo{ID: peer.ID("cached")},
+		ComputeNodeInfo: model.ComputeNodeInfo{CachedInputs: []string{gitKey}},
+	}}
+
+	// checkouts are cached by commit so a pinned input matches, however its
+	// subpath or mount point differ
+	job := model.Job{Spec: model.Spec{Inputs: []model.StorageSpec{
+		git.NewStorageSpec("https://example.com/repo.git", commit, "data", "/inputs"),
+	}}}
+	ranks, err := s.InputLocalityNodeRanker.RankNodes(context.Background(), job, nodes)
+	s.NoError(err)
+	assertEquals(s.T(), ranks, "cached", 10)
+
+	// a branch may no longer point at the cached commit
+	job.Spec.Inputs = []model.StorageSpec{git.NewStorageSpec("https://example.com/repo.git", "main", "", "/code")}
+	ranks, err = s.InputLocalityNodeRanker.RankNodes(context.Background(), job, nodes)
+	s.NoError(err)
+	assertEquals(s.T(), ranks, "cached", 0)
+
+	// downloads are cached under the parsed URL
+	job.Spec.Inputs = []model.StorageSpec{
+		{StorageSource: model.StorageSourceURLDownload, URL: "'https://example.com/data.csv'"},
+	}
+	ranks, err = s.InputLocalityNodeRanker.RankNodes(context.Background(), job, s.nodes)
+	s.NoError(err)
+	assertEquals(s.T(), ranks, "all", 10)
+	assertEquals(s.T(), ranks, "half", 0)
+}
+
+func (s *InputLocalityNodeRankerSuite) TestRankNodes_NoInputs() {
+	ranks, err := s.InputLocalityNodeRanker.RankNodes(context.Background(), model.Job{}, s.nodes)
+	s.NoError(err)
+	s.Equal(len(s.nodes), len(ranks))
+	assertEquals(s.T(), ranks, "all", 0)
+	assertEquals(s.T(), ranks, "half", 0)
+	assertEquals(s.T(), ranks, "none", 0)
+	assertEquals(s.T(), ranks, "unknown", 0)
+}
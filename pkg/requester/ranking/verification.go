@@ -0,0 +1,105 @@
+package ranking
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/requester"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultMaxRejectedResults is how many rejected results a node can have before it is no longer asked to bid.
+	DefaultMaxRejectedResults = 3
+	// DefaultRejectedResultsWindow is how long rejected results count against a node.
+	DefaultRejectedResultsWindow = 24 * time.Hour
+)
+
+type VerificationNodeRankerParams struct {
+	// MaxRejectedResults defaults to DefaultMaxRejectedResults
+	MaxRejectedResults int
+	// RejectedResultsWindow defaults to DefaultRejectedResultsWindow
+	RejectedResultsWindow time.Duration
+}
+
+// VerificationNodeRanker ranks nodes based on how many of their results were rejected by verifiers recently, which
+// the scheduler records through RecordVerification.
+type VerificationNodeRanker struct {
+	maxRejected int
+	window      time.Duration
+	mu          sync.Mutex
+	rejections  map[string][]time.Time
+}
+
+func NewVerificationNodeRanker(params VerificationNodeRankerParams) *VerificationNodeRanker {
+	maxRejected := params.MaxRejectedResults
+	if maxRejected <= 0 {
+		maxRejected = DefaultMaxRejectedResults
+	}
+	window := params.RejectedResultsWindow
+	if window <= 0 {
+		window = DefaultRejectedResultsWindow
+	}
+	return &VerificationNodeRanker{
+		maxRejected: maxRejected,
+		window:      window,
+		rejections:  make(map[string][]time.Time),
+	}
+}
+
+// RecordVerification records the results of nodes that verifiers rejected.
+func (s *VerificationNodeRanker) RecordVerification(ctx context.Context, result verifier.VerifierResult) {
+	if result.Verified {
+		return
+	}
+	log.Ctx(ctx).Debug().Msgf("recording rejected result of node %s for shard %s:%d",
+		result.NodeID, result.JobID, result.ShardIndex)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejections[result.NodeID] = append(s.recentRejections(result.NodeID), time.Now())
+}
+
+// RankNodes ranks nodes based on the results of theirs that were rejected within the window:
+// - Rank 10: Node had none of its results rejected.
+// - Rank 0 to 10: Node had fewer results rejected than allowed, with a lower rank the more were rejected.
+// - Rank -1: Node had as many results rejected as allowed, or more.
+func (s *VerificationNodeRanker) RankNodes(ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]requester.NodeRank, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ranks := make([]requester.NodeRank, len(nodes))
+	for i, node := range nodes {
+		rejected := len(s.recentRejections(node.PeerInfo.ID.String()))
+		rank := 10 - 10*rejected/s.maxRejected
+		if rejected >= s.maxRejected {
+			log.Ctx(ctx).Trace().Msgf("filtering node %s as %d of its results were rejected", node.PeerInfo.ID, rejected)
+			rank = -1
+		}
+		ranks[i] = requester.NodeRank{
+			NodeInfo: node,
+			Rank:     rank,
+		}
+	}
+	return ranks, nil
+}
+
+// recentRejections returns the times the node's results were rejected within the window, and forgets older ones.
+func (s *VerificationNodeRanker) recentRejections(nodeID string) []time.Time {
+	cutoff := time.Now().Add(-s.window)
+	rejections := s.rejections[nodeID]
+	for len(rejections) > 0 && rejections[0].Before(cutoff) {
+		rejections = rejections[1:]
+	}
+	if len(rejections) == 0 {
+		delete(s.rejections, nodeID)
+		return nil
+	}
+	s.rejections[nodeID] = rejections
+	return rejections
+}
+
+// Compile-time interface check:
+var _ requester.NodeRanker = (*VerificationNodeRanker)(nil)
+var _ requester.VerificationRecorder = (*VerificationNodeRanker)(nil)
@@ -0,0 +1,61 @@
+package ranking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type VerificationNodeRankerSuite struct {
+	suite.Suite
+	ranker *VerificationNodeRanker
+	nodes  []model.NodeInfo
+}
+
+func (s *VerificationNodeRankerSuite) SetupTest() {
+	s.ranker = NewVerificationNodeRanker(VerificationNodeRankerParams{MaxRejectedResults: 2})
+	s.nodes = []model.NodeInfo{
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("honest")}},
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("sloppy")}},
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("dishonest")}},
+	}
+}
+
+func TestVerificationNodeRankerSuite(t *testing.T) {
+	suite.Run(t, new(VerificationNodeRankerSuite))
+}
+
+func (s *VerificationNodeRankerSuite) record(nodeID string, verified bool) {
+	s.ranker.RecordVerification(context.Background(), verifier.VerifierResult{NodeID: peer.ID(nodeID).String(), Verified: verified})
+}
+
+func (s *VerificationNodeRankerSuite) TestRankNodes() {
+	s.record("honest", true)
+	s.record("sloppy", false)
+	s.record("dishonest", false)
+	s.record("dishonest", false)
+
+	ranks, err := s.ranker.RankNodes(context.Background(), model.Job{}, s.nodes)
+	s.NoError(err)
+	s.Equal(len(s.nodes), len(ranks))
+	assertEquals(s.T(), ranks, "honest", 10)
+	assertEquals(s.T(), ranks, "sloppy", 5)
+	assertEquals(s.T(), ranks, "dishonest", -1)
+}
+
+func (s *VerificationNodeRankerSuite) TestRejectionsExpire() {
+	s.ranker.window = 10 * time.Millisecond
+	s.record("dishonest", false)
+	s.record("dishonest", false)
+	time.Sleep(20 * time.Millisecond)
+
+	ranks, err := s.ranker.RankNodes(context.Background(), model.Job{}, s.nodes)
+	s.NoError(err)
+	assertEquals(s.T(), ranks, "dishonest", 10)
+	s.Empty(s.ranker.rejections)
+}
@@ -0,0 +1,41 @@
+package ranking
+
+import (
+	"context"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/requester"
+	"github.com/rs/zerolog/log"
+)
+
+type VersionsNodeRanker struct {
+}
+
+func NewVersionsNodeRanker() *VersionsNodeRanker {
+	return &VersionsNodeRanker{}
+}
+
+// RankNodes ranks nodes based on the protocol and API versions the compute nodes support:
+// - Rank 10: Node supports the job's API version.
+// - Rank 0: Node doesn't advertise its versions, but the job only uses fields it understands.
+// - Rank -1: Node talks an incompatible protocol, would drop job fields, or doesn't support the job's API version.
+func (s *VersionsNodeRanker) RankNodes(ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]requester.NodeRank, error) {
+	if _, err := job.GetAPIVersion(); err != nil {
+		return nil, err
+	}
+	ranks := make([]requester.NodeRank, len(nodes))
+	for i, node := range nodes {
+		rank := 0
+		if err := node.Versions.CanRunJob(job); err != nil {
+			log.Trace().Err(err).Msgf("filtering node %s that can't run job %s", node.PeerInfo.ID, job.Metadata.ID)
+			rank = -1
+		} else if node.Versions.IsKnown() {
+			rank = 10
+		}
+		ranks[i] = requester.NodeRank{
+			NodeInfo: node,
+			Rank:     rank,
+		}
+	}
+	return ranks, nil
+}
@@ -0,0 +1,73 @@
+package ranking
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type VersionsNodeRankerSuite struct {
+	suite.Suite
+	VersionsNodeRanker *VersionsNodeRanker
+}
+
+func (s *VersionsNodeRankerSuite) SetupTest() {
+	s.VersionsNodeRanker = NewVersionsNodeRanker()
+}
+
+func TestVersionsNodeRankerSuite(t *testing.T) {
+	suite.Run(t, new(VersionsNodeRankerSuite))
+}
+
+func (s *VersionsNodeRankerSuite) TestRankNodes() {
+	job := model.Job{APIVersion: model.V1beta1.String()}
+	nodes := []model.NodeInfo{
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("current")},
+			Versions: model.NodeVersions{ProtocolVersion: model.ProtocolVersion, APIVersions: model.SupportedAPIVersions()},
+		},
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("legacy")},
+		},
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("old-api")},
+			Versions: model.NodeVersions{ProtocolVersion: model.ProtocolVersion, APIVersions: []string{model.V1alpha1.String()}},
+		},
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("new-protocol")},
+			Versions: model.NodeVersions{ProtocolVersion: "2.0.0", APIVersions: model.SupportedAPIVersions()},
+		},
+	}
+	ranks, err := s.VersionsNodeRanker.RankNodes(context.Background(), job, nodes)
+	s.NoError(err)
+	s.Equal(len(nodes), len(ranks))
+	assertEquals(s.T(), ranks, "current", 10)
+	assertEquals(s.T(), ranks, "legacy", 0)
+	assertEquals(s.T(), ranks, "old-api", -1)
+	assertEquals(s.T(), ranks, "new-protocol", -1)
+}
+
+func (s *VersionsNodeRankerSuite) TestRankNodesDroppingJobFields() {
+	job := model.Job{APIVersion: model.V1beta1.String(), Spec: model.Spec{Deal: model.Deal{Broadcast: true}}}
+	nodes := []model.NodeInfo{
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("current")},
+			Versions: model.NodeVersions{ProtocolVersion: model.ProtocolVersion, APIVersions: model.SupportedAPIVersions()},
+		},
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("legacy")},
+		},
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("old-protocol")},
+			Versions: model.NodeVersions{ProtocolVersion: "1.0.0", APIVersions: model.SupportedAPIVersions()},
+		},
+	}
+	ranks, err := s.VersionsNodeRanker.RankNodes(context.Background(), job, nodes)
+	s.NoError(err)
+	assertEquals(s.T(), ranks, "current", 10)
+	assertEquals(s.T(), ranks, "legacy", -1)
+	assertEquals(s.T(), ranks, "old-protocol", -1)
+}
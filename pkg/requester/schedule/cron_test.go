@@ -0,0 +1,72 @@
+//go:build unit || !integration
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronNext(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2023, time.March, 1, 10, 30, 15, 0, time.UTC)
+
+	for _, tc := range []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2023, time.March, 1, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2023, time.March, 1, 10, 45, 0, 0, time.UTC)},
+		{"5,10 9-11 * * *", time.Date(2023, time.March, 1, 11, 5, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2023, time.March, 1, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2023, time.March, 2, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2023, time.March, 5, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2023, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * JAN-feb mon", time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2023, time.March, 5, 0, 0, 0, 0, time.UTC)},
+		// both day fields are restricted, so either matches: the 15th or a Friday
+		{"0 0 15 * fri", time.Date(2023, time.March, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// never matches
+		{"0 0 30 2 *", time.Time{}},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			cron, err := ParseCron(tc.expr)
+			require.NoError(t, err)
+			require.Equal(t, tc.next, cron.Next(from))
+		})
+	}
+}
+
+func TestCronNextInLocation(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	cron, err := ParseCron("0 9 * * *")
+	require.NoError(t, err)
+
+	next := cron.Next(time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC).In(location))
+	require.Equal(t, time.Date(2023, time.March, 1, 14, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+		"@every-minute",
+	} {
+		_, err := ParseCron(expr)
+		require.Error(t, err, expr)
+	}
+}
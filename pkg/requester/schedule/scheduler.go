@@ -0,0 +1,329 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/bacerrors"
+	jobutils "github.com/filecoin-project/bacalhau/pkg/job"
+	"github.com/filecoin-project/bacalhau/pkg/localdb"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/requester"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultInterval is how often the scheduler checks for schedules that are
+// due.
+const DefaultInterval = 15 * time.Second
+
+type SchedulerParams struct {
+	JobStore localdb.LocalDB
+	// endpoint that jobs are submitted to, so they go through the same
+	// transforms and admission as jobs submitted by clients
+	Endpoint requester.Endpoint
+	// defaults to DefaultInterval
+	Interval time.Duration
+	// defaults to time.Now, visible for testing
+	Clock func() time.Time
+}
+
+// Scheduler stores recurring job schedules, and submits a fresh job for each
+// of them every time their cron expression is due. All changes to schedules
+// go through the scheduler, so they don't race with its background task.
+type Scheduler struct {
+	jobStore localdb.LocalDB
+	endpoint requester.Endpoint
+	interval time.Duration
+	clock    func() time.Time
+
+	mu          sync.Mutex
+	stopChannel chan struct{}
+	stopOnce    sync.Once
+}
+
+func NewScheduler(params SchedulerParams) *Scheduler {
+	interval := params.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	clock := params.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+	s := &Scheduler{
+		jobStore:    params.JobStore,
+		endpoint:    params.Endpoint,
+		interval:    interval,
+		clock:       clock,
+		stopChannel: make(chan struct{}),
+	}
+	return s
+}
+
+// Start starts the background task that submits scheduled jobs, until Stop is
+// called or the context is done.
+func (s *Scheduler) Start(ctx context.Context) {
+	go s.backgroundTask(ctx)
+}
+
+// Create validates and stores a new schedule. Its first job is submitted at
+// the first tick after it is created.
+func (s *Scheduler) Create(ctx context.Context, payload model.JobScheduleCreatePayload) (model.JobSchedule, error) {
+	if err := VerifyCreatePayload(payload); err != nil {
+		return model.JobSchedule{}, err
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return model.JobSchedule{}, fmt.Errorf("error creating schedule id: %w", err)
+	}
+	schedule := model.JobSchedule{
+		ID:                id.String(),
+		ClientID:          payload.ClientID,
+		APIVersion:        payload.APIVersion,
+		Spec:              *payload.Spec,
+		Cron:              payload.Cron,
+		Timezone:          payload.Timezone,
+		ConcurrencyPolicy: payload.ConcurrencyPolicy,
+		HistoryLimit:      payload.HistoryLimit,
+		CreatedAt:         s.clock().UTC(),
+	}
+	if schedule.ConcurrencyPolicy == "" {
+		schedule.ConcurrencyPolicy = model.ConcurrencyPolicyAllow
+	}
+	if schedule.HistoryLimit == 0 {
+		schedule.HistoryLimit = model.DefaultScheduleHistoryLimit
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = s.jobStore.AddJobSchedule(ctx, schedule); err != nil {
+		return model.JobSchedule{}, err
+	}
+	return schedule, nil
+}
+
+// Get returns a stored schedule.
+func (s *Scheduler) Get(ctx context.Context, id string) (model.JobSchedule, error) {
+	return s.jobStore.GetJobSchedule(ctx, id)
+}
+
+// List returns the schedules of a client, or all of them if clientID is
+// empty.
+func (s *Scheduler) List(ctx context.Context, clientID string) ([]model.JobSchedule, error) {
+	return s.jobStore.GetJobSchedules(ctx, clientID)
+}
+
+// SetPaused pauses or resumes a schedule. Ticks that pass while a schedule is
+// paused are skipped.
+func (s *Scheduler) SetPaused(ctx context.Context, id string, paused bool) (model.JobSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schedule, err := s.jobStore.GetJobSchedule(ctx, id)
+	if err != nil {
+		return model.JobSchedule{}, err
+	}
+	if schedule.Paused == paused {
+		return schedule, nil
+	}
+	schedule.Paused = paused
+	if !paused {
+		// don't start a job for the ticks missed while paused
+		schedule.LastScheduleTime = s.clock().UTC()
+	}
+	return schedule, s.jobStore.UpdateJobSchedule(ctx, schedule)
+}
+
+// Delete removes a schedule. The jobs it started are left alone.
+func (s *Scheduler) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobStore.DeleteJobSchedule(ctx, id)
+}
+
+func (s *Scheduler) backgroundTask(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.tick(ctx)
+		case <-s.stopChannel:
+			log.Ctx(ctx).Info().Msg("stopped running job schedules")
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops the background task that submits scheduled jobs.
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChannel)
+	})
+}
+
+// tick handles every schedule that is due.
+func (s *Scheduler) tick(ctx context.Context) {
+	schedules, err := s.jobStore.GetJobSchedules(ctx, "")
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to list job schedules")
+		return
+	}
+	for _, schedule := range schedules {
+		if schedule.Paused {
+			continue
+		}
+		if err = s.run(ctx, schedule.ID); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("ScheduleID", schedule.ID).Msg("failed to run job schedule")
+		}
+	}
+}
+
+// run submits a job for a schedule if it is due. If several ticks were missed,
+// e.g. while the node was down, only one job is submitted for all of them.
+func (s *Scheduler) run(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// read it again under the lock, as it could have changed since it was listed
+	schedule, err := s.jobStore.GetJobSchedule(ctx, id)
+	if err != nil {
+		if _, ok := err.(*bacerrors.ScheduleNotFound); ok {
+			return nil
+		}
+		return err
+	}
+	if schedule.Paused {
+		return nil
+	}
+
+	due, err := s.dueTime(schedule)
+	if err != nil || due.IsZero() {
+		return err
+	}
+	schedule.LastScheduleTime = due.UTC()
+
+	schedule.ActiveJobIDs, err = s.activeJobs(ctx, schedule.ActiveJobIDs)
+	if err != nil {
+		return err
+	}
+	switch schedule.ConcurrencyPolicy {
+	case model.ConcurrencyPolicyForbid:
+		if len(schedule.ActiveJobIDs) > 0 {
+			log.Ctx(ctx).Debug().Str("ScheduleID", schedule.ID).Strs("ActiveJobIDs", schedule.ActiveJobIDs).
+				Msg("skipping scheduled job, previous jobs are still running")
+			return s.jobStore.UpdateJobSchedule(ctx, schedule)
+		}
+	case model.ConcurrencyPolicyReplace:
+		for _, jobID := range schedule.ActiveJobIDs {
+			_, err = s.endpoint.CancelJob(ctx, requester.CancelJobRequest{
+				JobID:  jobID,
+				Reason: fmt.Sprintf("replaced by a newer job of schedule %s", schedule.ID),
+			})
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Str("ScheduleID", schedule.ID).Str("JobID", jobID).
+					Msg("failed to cancel replaced scheduled job")
+			}
+		}
+		schedule.ActiveJobIDs = nil
+	}
+
+	spec := schedule.Spec
+	spec.Annotations = append(append([]string{}, spec.Annotations...),
+		model.ScheduleAnnotationKey+localdb.AnnotationSeparator+schedule.ID)
+	job, err := s.endpoint.SubmitJob(ctx, model.JobCreatePayload{
+		ClientID:   schedule.ClientID,
+		APIVersion: schedule.APIVersion,
+		Spec:       &spec,
+	})
+	if err != nil {
+		schedule.LastError = err.Error()
+		log.Ctx(ctx).Debug().Err(err).Str("ScheduleID", schedule.ID).Msg("failed to submit scheduled job")
+		return s.jobStore.UpdateJobSchedule(ctx, schedule)
+	}
+
+	schedule.LastError = ""
+	schedule.ActiveJobIDs = append(schedule.ActiveJobIDs, job.Metadata.ID)
+	schedule.JobIDs = append(schedule.JobIDs, job.Metadata.ID)
+	if len(schedule.JobIDs) > schedule.HistoryLimit {
+		schedule.JobIDs = schedule.JobIDs[len(schedule.JobIDs)-schedule.HistoryLimit:]
+	}
+	log.Ctx(ctx).Debug().Str("ScheduleID", schedule.ID).Str("JobID", job.Metadata.ID).Msg("submitted scheduled job")
+	return s.jobStore.UpdateJobSchedule(ctx, schedule)
+}
+
+// dueTime returns the latest tick of a schedule that is due and hasn't been
+// handled yet, or the zero time if there is none.
+func (s *Scheduler) dueTime(schedule model.JobSchedule) (time.Time, error) {
+	cron, err := ParseCron(schedule.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	last := schedule.LastScheduleTime
+	if last.IsZero() {
+		last = schedule.CreatedAt
+	}
+
+	now := s.clock()
+	due := time.Time{}
+	for next := cron.Next(last.In(location)); !next.IsZero() && !next.After(now); next = cron.Next(next) {
+		due = next
+	}
+	return due, nil
+}
+
+// activeJobs returns the jobs that haven't reached a terminal state yet.
+func (s *Scheduler) activeJobs(ctx context.Context, jobIDs []string) ([]string, error) {
+	var active []string
+	for _, jobID := range jobIDs {
+		job, err := s.jobStore.GetJob(ctx, jobID)
+		if err != nil {
+			if _, ok := err.(*bacerrors.JobNotFound); ok {
+				continue
+			}
+			return nil, err
+		}
+		state, err := s.jobStore.GetJobState(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		done, err := jobutils.WaitForTerminalStates(jobutils.GetJobTotalShards(job))(state)
+		if err != nil {
+			return nil, err
+		}
+		if !done {
+			active = append(active, jobID)
+		}
+	}
+	return active, nil
+}
+
+// VerifyCreatePayload checks that a schedule's cron expression, time zone and
+// policies are valid.
+func VerifyCreatePayload(payload model.JobScheduleCreatePayload) error {
+	if payload.Spec == nil {
+		return fmt.Errorf("schedule must contain a job spec")
+	}
+	if _, err := ParseCron(payload.Cron); err != nil {
+		return err
+	}
+	if _, err := time.LoadLocation(payload.Timezone); err != nil {
+		return fmt.Errorf("invalid time zone %q: %w", payload.Timezone, err)
+	}
+	if payload.ConcurrencyPolicy != "" {
+		if _, err := model.ParseConcurrencyPolicy(string(payload.ConcurrencyPolicy)); err != nil {
+			return err
+		}
+	}
+	if payload.HistoryLimit < 0 {
+		return fmt.Errorf("history limit must not be negative, got %d", payload.HistoryLimit)
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+//go:build unit || !integration
+
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/localdb/inmemory"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/requester"
+	"github.com/stretchr/testify/suite"
+)
+
+const clientID = "schedule-test-client"
+
+// fakeEndpoint stores submitted jobs, as the requester endpoint would.
+type fakeEndpoint struct {
+	store     *inmemory.InMemoryDatastore
+	submitted []model.JobCreatePayload
+	cancelled []string
+	err       error
+}
+
+func (e *fakeEndpoint) SubmitJob(ctx context.Context, payload model.JobCreatePayload) (*model.Job, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	e.submitted = append(e.submitted, payload)
+	job := &model.Job{
+		Metadata: model.Metadata{ID: fmt.Sprintf("schedule-test-job-%d", len(e.submitted)), ClientID: payload.ClientID},
+		Spec:     *payload.Spec,
+	}
+	return job, e.store.AddJob(ctx, job)
+}
+
+func (e *fakeEndpoint) UpdateDeal(context.Context, string, model.Deal) error {
+	return nil
+}
+
+func (e *fakeEndpoint) CancelJob(_ context.Context, request requester.CancelJobRequest) (requester.CancelJobResult, error) {
+	e.cancelled = append(e.cancelled, request.JobID)
+	return requester.CancelJobResult{}, nil
+}
+
+type SchedulerSuite struct {
+	suite.Suite
+	ctx       context.Context
+	now       time.Time
+	store     *inmemory.InMemoryDatastore
+	endpoint  *fakeEndpoint
+	scheduler *Scheduler
+}
+
+func TestSchedulerSuite(t *testing.T) {
+	suite.Run(t, new(SchedulerSuite))
+}
+
+func (s *SchedulerSuite) SetupTest() {
+	var err error
+	s.ctx = context.Background()
+	s.now = time.Date(2023, time.March, 1, 10, 0, 30, 0, time.UTC)
+	s.store, err = inmemory.NewInMemoryDatastore()
+	s.Require().NoError(err)
+	s.endpoint = &fakeEndpoint{store: s.store}
+	s.scheduler = NewScheduler(SchedulerParams{
+		JobStore: s.store,
+		Endpoint: s.endpoint,
+		// ticks are driven by the tests
+		Interval: time.Hour,
+		Clock:    func() time.Time { return s.now },
+	})
+	s.T().Cleanup(s.scheduler.Stop)
+}
+
+func (s *SchedulerSuite) create(policy model.ConcurrencyPolicy, historyLimit int) model.JobSchedule {
+	schedule, err := s.scheduler.Create(s.ctx, model.JobScheduleCreatePayload{
+		ClientID:          clientID,
+		APIVersion:        model.APIVersionLatest().String(),
+		Spec:              &model.Spec{Engine: model.EngineNoop, Annotations: []string{"nightly"}},
+		Cron:              "*/5 * * * *",
+		ConcurrencyPolicy: policy,
+		HistoryLimit:      historyLimit,
+	})
+	s.Require().NoError(err)
+	return schedule
+}
+
+func (s *SchedulerSuite) get(id string) model.JobSchedule {
+	schedule, err := s.scheduler.Get(s.ctx, id)
+	s.Require().NoError(err)
+	return schedule
+}
+
+func (s *SchedulerSuite) complete(jobID string) {
+	s.Require().NoError(s.store.UpdateShardState(s.ctx, jobID, "node", 0, model.JobShardState{
+		NodeID: "node",
+		State:  model.JobStateCompleted,
+	}))
+}
+
+func (s *SchedulerSuite) TestCreateDefaults() {
+	schedule := s.create("", 0)
+	s.Require().Equal(model.ConcurrencyPolicyAllow, schedule.ConcurrencyPolicy)
+	s.Require().Equal(model.DefaultScheduleHistoryLimit, schedule.HistoryLimit)
+	s.Require().Equal(s.now, schedule.CreatedAt)
+
+	schedules, err := s.scheduler.List(s.ctx, clientID)
+	s.Require().NoError(err)
+	s.Require().Len(schedules, 1)
+}
+
+func (s *SchedulerSuite) TestSubmitsAtEachTick() {
+	schedule := s.create(model.ConcurrencyPolicyAllow, 2)
+
+	// not due yet
+	s.scheduler.tick(s.ctx)
+	s.Require().Empty(s.endpoint.submitted)
+
+	s.now = time.Date(2023, time.March, 1, 10, 5, 0, 0, time.UTC)
+	s.scheduler.tick(s.ctx)
+	s.Require().Len(s.endpoint.submitted, 1)
+	s.Require().Equal(clientID, s.endpoint.submitted[0].ClientID)
+	s.Require().Equal([]string{"nightly", "bacalhau-schedule:" + schedule.ID}, s.endpoint.submitted[0].Spec.Annotations)
+	s.Require().Equal([]string{"nightly"}, s.get(schedule.ID).Spec.Annotations, "the stored spec should not change")
+
+	// the same tick is only handled once
+	s.scheduler.tick(s.ctx)
+	s.Require().Len(s.endpoint.submitted, 1)
+
+	// missed ticks only submit one job
+	s.now = time.Date(2023, time.March, 1, 10, 21, 0, 0, time.UTC)
+	s.scheduler.tick(s.ctx)
+	s.Require().Len(s.endpoint.submitted, 2)
+	updated := s.get(schedule.ID)
+	s.Require().Equal(time.Date(2023, time.March, 1, 10, 20, 0, 0, time.UTC), updated.LastScheduleTime)
+
+	// only the latest jobs are remembered
+	s.now = s.now.Add(5 * time.Minute)
+	s.scheduler.tick(s.ctx)
+	updated = s.get(schedule.ID)
+	s.Require().Equal([]string{"schedule-test-job-2", "schedule-test-job-3"}, updated.JobIDs)
+	s.Require().Len(updated.ActiveJobIDs, 3)
+}
+
+func (s *SchedulerSuite) TestForbidConcurrency() {
+	schedule := s.create(model.ConcurrencyPolicyForbid, 0)
+
+	s.now = s.now.Add(5 * time.Minute)
+	s.scheduler.tick(s.ctx)
+	s.Require().Len(s.endpoint.submitted, 1)
+
+	// the first job is still running
+	s.now = s.now.Add(5 * time.Minute)
+	s.scheduler.tick(s.ctx)
+	s.Require().Len(s.endpoint.submitted, 1)
+
+	s.complete("schedule-test-job-1")
+	s.now = s.now.Add(5 * time.Minute)
+	s.scheduler.tick(s.ctx)
+	s.Require().Len(s.endpoint.submitted, 2)
+	s.Require().Equal([]string{"schedule-test-job-2"}, s.get(schedule.ID).ActiveJobIDs)
+}
+
+func (s *SchedulerSuite) TestReplaceConcurrency() {
+	schedule := s.create(model.ConcurrencyPolicyReplace, 0)
+
+	for i := 0; i < 2; i++ {
+		s.now = s.now.Add(5 * time.Minute)
+		s.scheduler.tick(s.ctx)
+	}
+	s.Require().Len(s.endpoint.submitted, 2)
+	s.Require().Equal([]string{"schedule-test-job-2"}, s.get(schedule.ID).ActiveJobIDs)
+	// the replaced job is cancelled
+	s.Require().Equal([]string{"schedule-test-job-1"}, s.endpoint.cancelled)
+}
+
+func (s *SchedulerSuite) TestStart() {
+	scheduler := NewScheduler(SchedulerParams{
+		JobStore: s.store,
+		Endpoint: s.endpoint,
+		Interval: time.Millisecond,
+		Clock:    func() time.Time { return s.now.Add(time.Hour) },
+	})
+	s.create(model.ConcurrencyPolicyAllow, 0)
+
+	// nothing is submitted until the scheduler is started
+	time.Sleep(10 * time.Millisecond)
+	s.Require().Empty(s.endpoint.submitted)
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	scheduler.Start(ctx)
+	s.Require().Eventually(func() bool {
+		_, err := s.store.GetJob(s.ctx, "schedule-test-job-1")
+		return err == nil
+	}, time.Second, time.Millisecond)
+}
+
+func (s *SchedulerSuite) TestPauseAndResume() {
+	schedule := s.create(model.ConcurrencyPolicyAllow, 0)
+
+	_, err := s.scheduler.SetPaused(s.ctx, schedule.ID, true)
+	s.Require().NoError(err)
+	s.now = s.now.Add(time.Hour)
+	s.scheduler.tick(s.ctx)
+	s.Require().Empty(s.endpoint.submitted)
+
+	// ticks missed while paused are skipped
+	resumed, err := s.scheduler.SetPaused(s.ctx, schedule.ID, false)
+	s.Require().NoError(err)
+	s.Require().False(resumed.Paused)
+	s.scheduler.tick(s.ctx)
+	s.Require().Empty(s.endpoint.submitted)
+
+	s.now = s.now.Add(5 * time.Minute)
+	s.scheduler.tick(s.ctx)
+	s.Require().Len(s.endpoint.submitted, 1)
+}
+
+func (s *SchedulerSuite) TestSubmitError() {
+	schedule := s.create(model.ConcurrencyPolicyAllow, 0)
+
+	s.endpoint.err = errors.New("quota exceeded")
+	s.now = s.now.Add(5 * time.Minute)
+	s.scheduler.tick(s.ctx)
+	s.Require().Equal("quota exceeded", s.get(schedule.ID).LastError)
+
+	s.endpoint.err = nil
+	s.now = s.now.Add(5 * time.Minute)
+	s.scheduler.tick(s.ctx)
+	updated := s.get(schedule.ID)
+	s.Require().Empty(updated.LastError)
+	s.Require().Len(updated.JobIDs, 1)
+}
+
+func (s *SchedulerSuite) TestDelete() {
+	schedule := s.create(model.ConcurrencyPolicyAllow, 0)
+	s.Require().NoError(s.scheduler.Delete(s.ctx, schedule.ID))
+	s.now = s.now.Add(5 * time.Minute)
+	s.scheduler.tick(s.ctx)
+	s.Require().Empty(s.endpoint.submitted)
+}
+
+func (s *SchedulerSuite) TestVerifyCreatePayload() {
+	valid := model.JobScheduleCreatePayload{
+		ClientID: clientID,
+		Spec:     &model.Spec{},
+		Cron:     "@daily",
+		Timezone: "Europe/London",
+	}
+	s.Require().NoError(VerifyCreatePayload(valid))
+
+	for name, modify := range map[string]func(*model.JobScheduleCreatePayload){
+		"no spec":       func(p *model.JobScheduleCreatePayload) { p.Spec = nil },
+		"bad cron":      func(p *model.JobScheduleCreatePayload) { p.Cron = "every day" },
+		"bad time zone": func(p *model.JobScheduleCreatePayload) { p.Timezone = "Mars/Olympus" },
+		"bad policy":    func(p *model.JobScheduleCreatePayload) { p.ConcurrencyPolicy = "sometimes" },
+		"bad history":   func(p *model.JobScheduleCreatePayload) { p.HistoryLimit = -1 },
+	} {
+		payload := valid
+		modify(&payload)
+		s.Require().Error(VerifyCreatePayload(payload), name)
+	}
+}
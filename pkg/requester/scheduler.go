@@ -13,10 +13,13 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/storage"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/filecoin-project/bacalhau/pkg/verifier"
+	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/maps"
 )
 
 const OverAskForBidsFactor = 3 // ask up to 3 times the desired number of bids
@@ -29,10 +32,13 @@ type SchedulerParams struct {
 	JobStore                           localdb.LocalDB
 	NodeDiscoverer                     NodeDiscoverer
 	NodeRanker                         NodeRanker
+	VerificationRecorder               VerificationRecorder
 	ComputeEndpoint                    compute.Endpoint
 	Verifiers                          verifier.VerifierProvider
 	StorageProviders                   storage.StorageProvider
 	EventEmitter                       EventEmitter
+	Encrypter                          verifier.EncrypterFunction
+	Decrypter                          verifier.DecrypterFunction
 	JobNegotiationTimeout              time.Duration
 	StateManagerBackgroundTaskInterval time.Duration
 }
@@ -44,10 +50,13 @@ type Scheduler struct {
 	jobStore          localdb.LocalDB
 	nodeDiscoverer    NodeDiscoverer
 	nodeRanker        NodeRanker
+	recorder          VerificationRecorder
 	computeService    compute.Endpoint
 	verifiers         verifier.VerifierProvider
 	storageProviders  storage.StorageProvider
 	eventEmitter      EventEmitter
+	encrypter         verifier.EncrypterFunction
+	decrypter         verifier.DecrypterFunction
 	shardStateManager *shardStateMachineManager
 }
 
@@ -59,25 +68,87 @@ func NewScheduler(ctx context.Context, cm *system.CleanupManager, params Schedul
 		jobStore:         params.JobStore,
 		nodeDiscoverer:   params.NodeDiscoverer,
 		nodeRanker:       params.NodeRanker,
+		recorder:         params.VerificationRecorder,
 		computeService:   params.ComputeEndpoint,
 		verifiers:        params.Verifiers,
 		storageProviders: params.StorageProviders,
 		eventEmitter:     params.EventEmitter,
+		encrypter:        params.Encrypter,
+		decrypter:        params.Decrypter,
 		shardStateManager: newShardStateMachineManager(
 			ctx, cm, params.JobNegotiationTimeout, params.StateManagerBackgroundTaskInterval),
 	}
 }
 
 func (s *Scheduler) StartJob(ctx context.Context, req StartJobRequest) error {
-	nodeIDs, err := s.nodeDiscoverer.FindNodes(ctx, req.Job)
+	rankedNodes, err := s.rankNodes(ctx, req.Job)
 	if err != nil {
 		return err
 	}
-	log.Ctx(ctx).Debug().Msgf("found %d nodes for job %s", len(nodeIDs), req.Job.Metadata.ID)
 
-	rankedNodes, err := s.nodeRanker.RankNodes(ctx, req.Job, nodeIDs)
+	minBids := max(req.Job.Spec.Deal.MinBids, s.jobConcurrency(ctx, req.Job))
+	if req.Job.Spec.Deal.Broadcast {
+		// broadcast jobs run on all the matching nodes, as long as there is one
+		minBids = 1
+	}
+	if len(rankedNodes) < minBids {
+		return NewErrNotEnoughNodes(minBids, len(rankedNodes))
+	}
+
+	err = s.jobStore.AddJob(ctx, &req.Job)
 	if err != nil {
-		return err
+		return fmt.Errorf("error saving job id: %w", err)
+	}
+	s.eventEmitter.EmitJobCreated(ctx, req.Job)
+
+	askedNodes := rankedNodes
+	if !req.Job.Spec.Deal.Broadcast {
+		askedNodes = rankedNodes[:min(len(rankedNodes), minBids*OverAskForBidsFactor)]
+	}
+	askedNodeIDs := make([]string, 0, len(askedNodes))
+	for _, nodeRank := range askedNodes {
+		askedNodeIDs = append(askedNodeIDs, nodeRank.NodeInfo.PeerInfo.ID.String())
+	}
+
+	// TODO: which context should we pass to fsm? We used to pass the request context, but that was wrong
+	//  as it the context would be canceled the request returns
+	s.shardStateManager.startShardsState(logger.ContextWithNodeIDLogger(context.Background(), s.id), &req.Job, s, askedNodeIDs)
+
+	// TODO: ask to bid on certain shards rather than asking all compute nodes to bid on all shards
+	shardIndexes := make([]int, req.Job.Spec.ExecutionPlan.TotalShards)
+	for i := 0; i < req.Job.Spec.ExecutionPlan.TotalShards; i++ {
+		shardIndexes[i] = i
+	}
+	for _, nodeRank := range askedNodes {
+		// create a new space linked to request context, but call noitfyAskForBid with a new context
+		// as the request context will be canceled the request returns
+		_, span := s.newSpan(ctx, "askForBid", req.Job.Metadata.ID)
+		go s.notifyAskForBid(logger.ContextWithNodeIDLogger(context.Background(), s.id), span, &req.Job, nodeRank.NodeInfo, shardIndexes)
+	}
+
+	return nil
+}
+
+// CancelJob fails the shards of the job that are still running, which cancels their executions on the compute nodes.
+func (s *Scheduler) CancelJob(ctx context.Context, job *model.Job, reason string) {
+	for i := 0; i < job.Spec.ExecutionPlan.TotalShards; i++ {
+		if shardState, ok := s.shardStateManager.GetShardState(model.JobShard{Job: job, Index: i}); ok {
+			shardState.fail(ctx, reason)
+		}
+	}
+}
+
+// rankNodes returns the nodes that can run the job, best ranked first.
+func (s *Scheduler) rankNodes(ctx context.Context, job model.Job) ([]NodeRank, error) {
+	nodeIDs, err := s.nodeDiscoverer.FindNodes(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	log.Ctx(ctx).Debug().Msgf("found %d nodes for job %s", len(nodeIDs), job.Metadata.ID)
+
+	rankedNodes, err := s.nodeRanker.RankNodes(ctx, job, nodeIDs)
+	if err != nil {
+		return nil, err
 	}
 
 	// filter nodes with rank below 0
@@ -88,63 +159,147 @@ func (s *Scheduler) StartJob(ctx context.Context, req StartJobRequest) error {
 		}
 	}
 	rankedNodes = filteredNodes
-	log.Debug().Msgf("ranked %d nodes for job %s", len(rankedNodes), req.Job.Metadata.ID)
-
-	minBids := max(req.Job.Spec.Deal.MinBids, req.Job.Spec.Deal.Concurrency)
-	if len(rankedNodes) < minBids {
-		return NewErrNotEnoughNodes(minBids, len(rankedNodes))
-	}
+	log.Debug().Msgf("ranked %d nodes for job %s", len(rankedNodes), job.Metadata.ID)
 
 	sort.Slice(rankedNodes, func(i, j int) bool {
 		return rankedNodes[i].Rank > rankedNodes[j].Rank
 	})
+	return rankedNodes, nil
+}
 
-	err = s.jobStore.AddJob(ctx, &req.Job)
-	if err != nil {
-		return fmt.Errorf("error saving job id: %w", err)
+// shardConcurrency returns how many times a shard runs. It is the deal's concurrency, unless the job's verifier
+// decides it for each shard.
+func (s *Scheduler) shardConcurrency(ctx context.Context, shard model.JobShard) int {
+	jobVerifier, err := s.verifiers.GetVerifier(ctx, shard.Job.Spec.Verifier)
+	if err == nil {
+		if provider, ok := jobVerifier.(verifier.ShardConcurrencyProvider); ok {
+			return provider.GetShardConcurrency(shard)
+		}
 	}
-	s.eventEmitter.EmitJobCreated(ctx, req.Job)
+	return shard.Job.Spec.Deal.Concurrency
+}
 
-	// TODO: which context should we pass to fsm? We used to pass the request context, but that was wrong
-	//  as it the context would be canceled the request returns
-	s.shardStateManager.startShardsState(logger.ContextWithNodeIDLogger(context.Background(), s.id), &req.Job, s)
-	for _, nodeRank := range rankedNodes[:min(len(rankedNodes), minBids*OverAskForBidsFactor)] {
-		// create a new space linked to request context, but call noitfyAskForBid with a new context
-		// as the request context will be canceled the request returns
-		_, span := s.newSpan(ctx, "askForBid", req.Job.Metadata.ID)
-		go s.notifyAskForBid(logger.ContextWithNodeIDLogger(context.Background(), s.id), span, &req.Job, nodeRank.NodeInfo)
+// jobConcurrency returns the most times any of the job's shards runs.
+func (s *Scheduler) jobConcurrency(ctx context.Context, job model.Job) int {
+	concurrency := 1
+	for i := 0; i < job.Spec.ExecutionPlan.TotalShards; i++ {
+		concurrency = max(concurrency, s.shardConcurrency(ctx, model.JobShard{Job: &job, Index: i}))
 	}
+	return concurrency
+}
 
+// askForMoreBids asks nodes that haven't run a shard yet to bid on it, when the shard needs to run on more nodes
+// than it was started with.
+func (s *Scheduler) askForMoreBids(ctx context.Context, shard model.JobShard, excludedNodes map[string]bool, count int) {
+	go func() {
+		rankedNodes, err := s.rankNodes(ctx, *shard.Job)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msgf("failed to rank nodes for shard %s", shard)
+			return
+		}
+		asked := 0
+		for _, nodeRank := range rankedNodes {
+			if asked >= count*OverAskForBidsFactor {
+				break
+			}
+			if excludedNodes[nodeRank.NodeInfo.PeerInfo.ID.String()] {
+				continue
+			}
+			asked++
+			_, span := s.newSpan(ctx, "askForBid", shard.Job.Metadata.ID)
+			go s.notifyAskForBid(ctx, span, shard.Job, nodeRank.NodeInfo, []int{shard.Index})
+		}
+		log.Ctx(ctx).Debug().Msgf("asked %d more nodes to bid on shard %s", asked, shard)
+	}()
+}
+
+// HandleNodeInfo asks nodes that join the network to bid on the running
+// broadcast shards that include late joiners.
+func (s *Scheduler) HandleNodeInfo(ctx context.Context, nodeInfo model.NodeInfo) error {
+	if !nodeInfo.IsComputeNode() {
+		return nil
+	}
+	for _, shardState := range s.shardStateManager.lateJoinerShardStates() {
+		// the shard's state machine decides whether the node is new to it. It may be busy, and node info is
+		// handled for every node on the network, so don't wait for it.
+		go shardState.nodeJoined(ctx, nodeInfo)
+	}
 	return nil
 }
 
-func (s *Scheduler) notifyAskForBid(ctx context.Context, span trace.Span, job *model.Job, nodeInfo model.NodeInfo) {
-	defer span.End()
-	// TODO: ask to bid on certain shards rather than asking all compute nodes to bid on all shards
-	shardIndexes := make([]int, job.Spec.ExecutionPlan.TotalShards)
-	for i := 0; i < job.Spec.ExecutionPlan.TotalShards; i++ {
-		shardIndexes[i] = i
+// askLateJoiner asks a node that joined the network after a broadcast shard
+// started to bid on it, if the node is suitable for the job.
+func (s *Scheduler) askLateJoiner(ctx context.Context, shard model.JobShard, nodeInfo model.NodeInfo) {
+	go func() {
+		ranks, err := s.nodeRanker.RankNodes(ctx, *shard.Job, []model.NodeInfo{nodeInfo})
+		if err != nil || len(ranks) == 0 || ranks[0].Rank < 0 {
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Msgf("failed to rank node %s for shard %s", nodeInfo.PeerInfo.ID, shard)
+			}
+			s.declineBids(ctx, shard.Job, nodeInfo.PeerInfo.ID.String(), []int{shard.Index})
+			return
+		}
+		log.Ctx(ctx).Debug().Msgf("asking late joining node %s to bid on shard %s", nodeInfo.PeerInfo.ID, shard)
+		_, span := s.newSpan(ctx, "askForBid", shard.Job.Metadata.ID)
+		s.notifyAskForBid(ctx, span, shard.Job, nodeInfo, []int{shard.Index})
+	}()
+}
+
+// declineBids tells the state machines of a job's shards that a node won't
+// bid on them, so that they stop waiting for its bid.
+func (s *Scheduler) declineBids(ctx context.Context, job *model.Job, nodeID string, shardIndexes []int) {
+	for _, shardIndex := range shardIndexes {
+		if shardState, ok := s.shardStateManager.GetShardState(model.JobShard{Job: job, Index: shardIndex}); ok {
+			shardState.bidDeclined(ctx, nodeID)
+		}
 	}
+}
+
+func (s *Scheduler) notifyAskForBid(
+	ctx context.Context, span trace.Span, job *model.Job, nodeInfo model.NodeInfo, shardIndexes []int) {
+	defer span.End()
 
 	// add peer info to the host's peerstore to be able to connect to it
 	s.host.Peerstore().AddAddrs(nodeInfo.PeerInfo.ID, nodeInfo.PeerInfo.Addrs, s.peerStoreTTL)
 
+	nodeJob := *job
+	err := nodeInfo.Versions.CanRunJob(nodeJob)
+	if err == nil && len(nodeJob.Spec.Docker.RegistryAuth) > 0 {
+		// the registry token is only sent to the nodes whose bids are accepted,
+		// but we make sure now that we will be able to seal it for this node
+		_, err = s.nodePublicKey(ctx, nodeInfo.PeerInfo)
+		nodeJob.Spec.Docker.RegistryAuth = nil
+	}
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msgf("failed to prepare job %s for node %s", job.Metadata.ID, nodeInfo.PeerInfo.ID)
+		s.declineBids(ctx, job, nodeInfo.PeerInfo.ID.String(), shardIndexes)
+		return
+	}
+
 	request := compute.AskForBidRequest{
-		Job:          *job,
-		ShardIndexes: shardIndexes,
+		Job:             nodeJob,
+		ShardIndexes:    shardIndexes,
+		HasRegistryAuth: len(job.Spec.Docker.RegistryAuth) > 0,
 		RoutingMetadata: compute.RoutingMetadata{
 			SourcePeerID: s.id,
 			TargetPeerID: nodeInfo.PeerInfo.ID.String(),
 		},
 	}
 
+	bidsAsked.WithLabelValues(s.id, request.TargetPeerID).Inc()
 	bid, err := s.computeService.AskForBid(ctx, request)
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msgf("failed to ask for bid: %+v", request)
 	}
 
+	declined := make(map[int]bool, len(shardIndexes))
+	for _, shardIndex := range shardIndexes {
+		declined[shardIndex] = true
+	}
 	for _, shardResponse := range bid.ShardResponse {
 		if shardResponse.Accepted {
+			delete(declined, shardResponse.ShardIndex)
+			bidsReceived.WithLabelValues(s.id, request.TargetPeerID).Inc()
 			s.eventEmitter.EmitBidReceived(ctx, request, shardResponse)
 			shard := model.JobShard{Job: job, Index: shardResponse.ShardIndex}
 			shardState, ok := s.shardStateManager.GetShardState(shard)
@@ -153,20 +308,88 @@ func (s *Scheduler) notifyAskForBid(ctx context.Context, span trace.Span, job *m
 					shard, shardResponse.ExecutionID)
 				continue
 			}
-			shardState.bid(ctx, nodeInfo.PeerInfo.ID.String(), shardResponse.ExecutionID)
+			shardState.bid(ctx, nodeInfo.PeerInfo.ID.String(), shardResponse.ExecutionID, shardResponse.Price)
 		}
 	}
+	s.declineBids(ctx, job, request.TargetPeerID, maps.Keys(declined))
+}
+
+// sealRegistryAuth re-encrypts the registry token the job carries, if any, so
+// that only the given compute node can read it.
+func (s *Scheduler) sealRegistryAuth(ctx context.Context, job *model.Job, nodeID string) ([]byte, error) {
+	if len(job.Spec.Docker.RegistryAuth) == 0 {
+		return nil, nil
+	}
+	peerID, err := peer.Decode(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	// the node's addresses were added to the peerstore when it was asked to bid,
+	// and its public key when it registered if it uses the HTTP transport
+	publicKey, err := s.nodePublicKey(ctx, peer.AddrInfo{ID: peerID})
+	if err != nil {
+		return nil, err
+	}
+	publicKeyBytes, err := crypto.MarshalPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.decrypter(ctx, job.Spec.Docker.RegistryAuth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt registry token: %w", err)
+	}
+	sealed, err := s.encrypter(ctx, token, publicKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt registry token: %w", err)
+	}
+	return sealed, nil
+}
+
+// nodePublicKey returns the public key registry tokens are sealed with for a
+// compute node.
+func (s *Scheduler) nodePublicKey(ctx context.Context, peerInfo peer.AddrInfo) (crypto.PubKey, error) {
+	if s.encrypter == nil || s.decrypter == nil {
+		return nil, fmt.Errorf("registry tokens are not supported by this requester")
+	}
+
+	// RSA public keys are too large to be part of the peer ID, so we may
+	// need to connect to the node to learn it
+	publicKey := s.host.Peerstore().PubKey(peerInfo.ID)
+	if publicKey == nil {
+		if err := s.host.Connect(ctx, peerInfo); err != nil {
+			return nil, err
+		}
+		publicKey = s.host.Peerstore().PubKey(peerInfo.ID)
+	}
+	if publicKey == nil {
+		return nil, fmt.Errorf("no public key known for node %s", peerInfo.ID)
+	}
+	return publicKey, nil
 }
 
 //////////////////////////////
 //    Shard fsm handlers    //
 //////////////////////////////
 
-func (s *Scheduler) notifyBidAccepted(ctx context.Context, targetNodeID string, executionID string) {
+func (s *Scheduler) notifyBidAccepted(
+	ctx context.Context, shard model.JobShard, targetNodeID string, executionID string, price float64) {
 	go func() {
 		log.Ctx(ctx).Debug().Msgf("Requester node %s responding with BidAccepted for bid: %s", s.id, executionID)
+		registryAuth, err := s.sealRegistryAuth(ctx, shard.Job, targetNodeID)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msgf("failed to seal registry token of shard %s for node %s", shard, targetNodeID)
+			s.notifyBidRejected(ctx, targetNodeID, executionID)
+			if shardState, ok := s.shardStateManager.GetShardState(shard); ok {
+				shardState.fail(ctx, fmt.Sprintf("failed to send registry token to node %s: %s", targetNodeID, err))
+			}
+			return
+		}
+
 		request := compute.BidAcceptedRequest{
-			ExecutionID: executionID,
+			ExecutionID:  executionID,
+			Price:        price,
+			RegistryAuth: registryAuth,
 			RoutingMetadata: compute.RoutingMetadata{
 				SourcePeerID: s.id,
 				TargetPeerID: targetNodeID,
@@ -182,6 +405,7 @@ func (s *Scheduler) notifyBidAccepted(ctx context.Context, targetNodeID string,
 }
 
 func (s *Scheduler) notifyBidRejected(ctx context.Context, targetNodeID string, executionID string) {
+	bidsRejected.WithLabelValues(s.id, targetNodeID).Inc()
 	go func() {
 		log.Ctx(ctx).Debug().Msgf("Requester node %s responding with BidRejected for bid: %s", s.id, executionID)
 		request := compute.BidRejectedRequest{
@@ -214,7 +438,7 @@ func (s *Scheduler) notifyResultAccepted(ctx context.Context, result verifier.Ve
 		if err != nil {
 			log.Ctx(ctx).Error().Err(err).Msgf("failed to notify ResultAccepted for execution: %s", result.ExecutionID)
 		} else {
-			s.eventEmitter.EmitResultAccepted(ctx, request, response)
+			s.eventEmitter.EmitResultAccepted(ctx, request, response, result.Diff)
 		}
 	}()
 }
@@ -223,7 +447,8 @@ func (s *Scheduler) notifyResultRejected(ctx context.Context, result verifier.Ve
 	go func() {
 		log.Ctx(ctx).Debug().Msgf("Requester node %s responding with ResultRejected for bid: %s", s.id, result.ExecutionID)
 		request := compute.ResultRejectedRequest{
-			ExecutionID: result.ExecutionID,
+			ExecutionID:   result.ExecutionID,
+			Justification: result.Reason,
 			RoutingMetadata: compute.RoutingMetadata{
 				SourcePeerID: s.id,
 				TargetPeerID: result.NodeID,
@@ -233,7 +458,7 @@ func (s *Scheduler) notifyResultRejected(ctx context.Context, result verifier.Ve
 		if err != nil {
 			log.Ctx(ctx).Error().Err(err).Msgf("failed to notify ResultRejected for execution: %s", result.ExecutionID)
 		} else {
-			s.eventEmitter.EmitResultRejected(ctx, request, response)
+			s.eventEmitter.EmitResultRejected(ctx, request, response, result.Diff)
 		}
 	}()
 }
@@ -289,13 +514,16 @@ func (s *Scheduler) verifyShard(
 	if err != nil {
 		return nil, err
 	}
-	// ask the verifier if we have enough to start the verification yet
-	isExecutionComplete, err := jobVerifier.IsExecutionComplete(ctx, shard)
-	if err != nil {
-		return nil, err
-	}
-	if !isExecutionComplete {
-		return nil, fmt.Errorf("verifying shard %s but execution is not complete", shard)
+	// ask the verifier if we have enough to start the verification yet. Broadcast shards run on however many nodes
+	// bid on them, so only their state machine knows when they are complete.
+	if !shard.Job.Spec.Deal.Broadcast {
+		isExecutionComplete, err := jobVerifier.IsExecutionComplete(ctx, shard)
+		if err != nil {
+			return nil, err
+		}
+		if !isExecutionComplete {
+			return nil, fmt.Errorf("verifying shard %s but execution is not complete", shard)
+		}
 	}
 
 	verificationResults, err := jobVerifier.VerifyShard(ctx, shard)
@@ -307,10 +535,15 @@ func (s *Scheduler) verifyShard(
 	var verifiedResults []verifier.VerifierResult
 	// loop over each verification result and publish events
 	for _, verificationResult := range verificationResults {
+		if s.recorder != nil {
+			s.recorder.RecordVerification(ctx, verificationResult)
+		}
 		if verificationResult.Verified {
+			verifications.WithLabelValues(s.id, shard.Job.Spec.Verifier.String(), verificationPassed).Inc()
 			s.notifyResultAccepted(ctx, verificationResult)
 			verifiedResults = append(verifiedResults, verificationResult)
 		} else {
+			verifications.WithLabelValues(s.id, shard.Job.Spec.Verifier.String(), verificationFailed).Inc()
 			s.notifyResultRejected(ctx, verificationResult)
 		}
 	}
@@ -325,6 +558,7 @@ func (s *Scheduler) verifyShard(
 func (s *Scheduler) OnRunComplete(ctx context.Context, result compute.RunResult) {
 	log.Ctx(ctx).Debug().Msgf("Requester node %s received RunComplete for execution: %s from %s",
 		s.id, result.ExecutionID, result.SourcePeerID)
+	s.eventEmitter.EmitImagePulled(ctx, result)
 	s.eventEmitter.EmitRunComplete(ctx, result)
 	shardState := s.getShardState(ctx, result.ExecutionMetadata)
 	if shardState != nil {
@@ -2,14 +2,17 @@ package requester
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
+	"sort"
 	"time"
 
 	"golang.org/x/exp/maps"
 
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
 	sync "github.com/lukemarsden/golang-mutex-tracer"
 	"github.com/rs/zerolog/log"
 )
@@ -34,10 +37,18 @@ const (
 	actionResultsPublished
 
 	actionFail
+
+	// a node asked to bid on a broadcast shard didn't bid.
+	actionBidDeclined
+
+	// a node joined the network while a broadcast shard is running.
+	actionNodeJoined
 )
 
 func (a shardStateAction) String() string {
-	return [...]string{"ActionBidReceived", "ActionComputeError", "ActionResultReceived", "ActionResultsPublished", "ActionFail"}[a]
+	return [...]string{
+		"ActionBidReceived", "ActionComputeError", "ActionResultReceived", "ActionResultsPublished", "ActionFail",
+		"ActionBidDeclined", "ActionNodeJoined"}[a]
 }
 
 // request to change the state of the fsm
@@ -46,6 +57,8 @@ type shardStateRequest struct {
 	sourceNodeID string // optional field indicating the node that triggered the request
 	executionID  string
 	reason       string
+	nodeInfo     model.NodeInfo // set for actionNodeJoined
+	price        float64        // set for actionBidReceived
 }
 
 // types of shard state machines
@@ -77,12 +90,15 @@ const (
 
 	// The job has been completed, either successfully, or due to an error.
 	shardCompleted
+
+	// Broadcast shard is accepting every bid, and waiting for each node's results.
+	shardBroadcasting
 )
 
 func (s shardStateType) String() string {
 	return [...]string{
 		"InitialState", "EnqueuingBids", "SelectingBids", "AcceptingBids", "WaitingForResults",
-		"VerifyingResults", "WaitingToPublishResults", "Error", "Completed"}[s]
+		"VerifyingResults", "WaitingToPublishResults", "Error", "Completed", "Broadcasting"}[s]
 }
 
 type shardStateMachineManager struct {
@@ -91,7 +107,9 @@ type shardStateMachineManager struct {
 	shardStates map[string]*shardStateMachine
 	// configure the timeout for each shard state
 	jobNegotiationTimeout time.Duration
-	mu                    sync.Mutex
+	// keeps track of the jobs the shards belong to, for the jobs gauge
+	jobMetrics *jobMetrics
+	mu         sync.Mutex
 }
 
 func newShardStateMachineManager(
@@ -103,6 +121,7 @@ func newShardStateMachineManager(
 	stateManager := &shardStateMachineManager{
 		shardStates:           make(map[string]*shardStateMachine),
 		jobNegotiationTimeout: jobNegotiationTimeout,
+		jobMetrics:            newJobMetrics(),
 	}
 
 	stateManager.mu.EnableTracerWithOpts(sync.Opts{
@@ -152,6 +171,8 @@ func (m *shardStateMachineManager) backgroundTask() {
 		if item.timeoutAt.Before(now) {
 			if item.currentState == shardCompleted {
 				delete(m.shardStates, key)
+				shardsByState.WithLabelValues(item.node.id, item.currentState.String()).Dec()
+				m.jobMetrics.shardRemoved(item.shard)
 			} else {
 				timeoutShardStates = append(timeoutShardStates, item)
 			}
@@ -163,9 +184,10 @@ func (m *shardStateMachineManager) backgroundTask() {
 	}
 }
 
-// Start a state machine for all the shards in the job, if they don't exit already
+// Start a state machine for all the shards in the job, if they don't exit already.
+// askedNodes are the nodes that broadcast shards wait to hear from.
 func (m *shardStateMachineManager) startShardsState(
-	ctx context.Context, job *model.Job, n *Scheduler) {
+	ctx context.Context, job *model.Job, n *Scheduler, askedNodes []string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -174,6 +196,10 @@ func (m *shardStateMachineManager) startShardsState(
 		shard := model.JobShard{Job: job, Index: i}
 		if _, ok := m.shardStates[shard.ID()]; !ok {
 			shardState := m.newShardStateMachine(ctx, shard, n)
+			for _, nodeID := range askedNodes {
+				shardState.askedNodes[nodeID] = true
+				shardState.pendingNodes[nodeID] = true
+			}
 			m.shardStates[shard.ID()] = shardState
 
 			go func() {
@@ -190,36 +216,82 @@ func (m *shardStateMachineManager) GetShardState(shard model.JobShard) (*shardSt
 	return shardFsm, ok
 }
 
+// lateJoinerShardStates returns the state machines of broadcast shards that
+// run on nodes that join the network after they started.
+func (m *shardStateMachineManager) lateJoinerShardStates() []*shardStateMachine {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var shardStates []*shardStateMachine
+	for _, shardState := range m.shardStates {
+		if shardState.shard.Job.Spec.Deal.IncludeLateJoiners && shardState.currentState != shardCompleted {
+			shardStates = append(shardStates, shardState)
+		}
+	}
+	return shardStates
+}
+
 type shardStateMachine struct {
 	shard   model.JobShard
 	manager *shardStateMachineManager
 	node    *Scheduler
 	req     chan shardStateRequest
+	// closed once the state machine is completed and no longer reads requests
+	done chan struct{}
+
+	currentState   shardStateType
+	previousState  shardStateType
+	stateEnteredAt time.Time
+	timeoutAt      time.Time
+	errorMsg       string
 
-	currentState  shardStateType
-	previousState shardStateType
-	timeoutAt     time.Time
-	errorMsg      string
+	// how many nodes must run the shard. It is the deal's concurrency, unless the verifier decides it, and can grow
+	// when the verifier needs more results.
+	concurrency int
 
 	// keep track of nodes that have already bid on this shard to deduplicate bids and only accept results
 	// from nodes that have an accepted bid.
 	biddingNodes map[string]string
 
+	// the price each bidding node quoted for running the shard.
+	bidPrices map[string]float64
+
+	// whether a bid was rejected because it didn't fit in the job's budget.
+	overBudget bool
+
 	// keep track of nodes that have already submitted their result proposals to deduplicate results, and know when
 	// result verification should start.
 	completedNodes map[string]string
+
+	// broadcast shards keep track of every node asked to bid, the nodes that haven't answered yet, the nodes
+	// whose execution failed, and the nodes whose verified results are still to be published.
+	askedNodes      map[string]bool
+	pendingNodes    map[string]bool
+	failedNodes     map[string]string
+	publishingNodes map[string]bool
+
+	// broadcast shards give each node until its own deadline to bid, and then to run the shard, as nodes can join
+	// long after the shard started.
+	nodeDeadlines map[string]time.Time
 }
 
 func (m *shardStateMachineManager) newShardStateMachine(ctx context.Context, shard model.JobShard, node *Scheduler) *shardStateMachine {
 	return &shardStateMachine{
-		shard:          shard,
-		manager:        m,
-		node:           node,
-		req:            make(chan shardStateRequest),
-		currentState:   shardInitialState,
-		biddingNodes:   make(map[string]string),
-		completedNodes: make(map[string]string),
-		timeoutAt:      time.Now().Add(m.jobNegotiationTimeout),
+		shard:           shard,
+		manager:         m,
+		node:            node,
+		req:             make(chan shardStateRequest),
+		done:            make(chan struct{}),
+		currentState:    shardInitialState,
+		concurrency:     node.shardConcurrency(ctx, shard),
+		biddingNodes:    make(map[string]string),
+		bidPrices:       make(map[string]float64),
+		completedNodes:  make(map[string]string),
+		askedNodes:      make(map[string]bool),
+		pendingNodes:    make(map[string]bool),
+		failedNodes:     make(map[string]string),
+		publishingNodes: make(map[string]bool),
+		nodeDeadlines:   make(map[string]time.Time),
+		timeoutAt:       time.Now().Add(m.jobNegotiationTimeout),
 	}
 }
 
@@ -229,20 +301,66 @@ func (m *shardStateMachine) String() string {
 
 // run the state machine until it is completed.
 func (m *shardStateMachine) run(ctx context.Context) {
-	for state := enqueuedState; state != nil; {
+	initialState := enqueuedState
+	if m.shard.Job.Spec.Deal.Broadcast {
+		initialState = broadcastingState
+	}
+	for state := initialState; state != nil; {
 		// TODO: #559 Should we create a new context and span for each state execution?
 		state = state(ctx, m)
 	}
-	// close the request channel.
+	// stop accepting requests.
 	// Check `sendRequest` comments for more details.
-	close(m.req)
+	close(m.done)
 }
 
-func (m *shardStateMachine) bid(ctx context.Context, sourceNodeID, executionID string) {
+func (m *shardStateMachine) bid(ctx context.Context, sourceNodeID, executionID string, price float64) {
 	m.sendRequest(ctx, shardStateRequest{
 		action:       actionBidReceived,
 		sourceNodeID: sourceNodeID,
-		executionID:  executionID})
+		executionID:  executionID,
+		price:        price})
+}
+
+// withinBudget returns true if the job's budget can pay the given price for the shard.
+func (m *shardStateMachine) withinBudget(price float64) bool {
+	budget := m.shard.Job.Spec.Deal.MaxBudget
+	return budget <= 0 || price <= budget
+}
+
+// comparesPrices returns true if the shard's bids are selected by their price, because the job has a budget or nodes
+// quoted a price, in which case the shard waits for every asked node to bid before selecting the cheapest bids.
+func (m *shardStateMachine) comparesPrices() bool {
+	if m.shard.Job.Spec.Deal.MaxBudget > 0 {
+		return true
+	}
+	for _, price := range m.bidPrices {
+		if price > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// exceedsBudget returns true if the shard can't reach its concurrency without going over the job's budget, as bids
+// were rejected for their price and no asked node is left to bid.
+func (m *shardStateMachine) exceedsBudget() bool {
+	return m.overBudget && len(m.pendingNodes) == 0 && len(m.biddingNodes) < m.concurrency
+}
+
+// budgetError returns why a shard that exceeds the job's budget failed.
+func (m *shardStateMachine) budgetError() string {
+	return fmt.Sprintf("the bids received exceed the job's budget of %v: only %d of the %d bids needed fit in it",
+		m.shard.Job.Spec.Deal.MaxBudget, len(m.biddingNodes), m.concurrency)
+}
+
+// acceptedPrice returns the sum of the prices of the accepted bids.
+func (m *shardStateMachine) acceptedPrice() float64 {
+	total := 0.0
+	for nodeID := range m.biddingNodes {
+		total += m.bidPrices[nodeID]
+	}
+	return total
 }
 
 func (m *shardStateMachine) computeError(ctx context.Context, sourceNodeID, executionID string) {
@@ -270,28 +388,46 @@ func (m *shardStateMachine) fail(ctx context.Context, reason string) {
 	m.sendRequest(ctx, shardStateRequest{action: actionFail, reason: reason})
 }
 
+func (m *shardStateMachine) bidDeclined(ctx context.Context, sourceNodeID string) {
+	m.sendRequest(ctx, shardStateRequest{
+		action:       actionBidDeclined,
+		sourceNodeID: sourceNodeID})
+}
+
+func (m *shardStateMachine) nodeJoined(ctx context.Context, nodeInfo model.NodeInfo) {
+	m.sendRequest(ctx, shardStateRequest{
+		action:       actionNodeJoined,
+		sourceNodeID: nodeInfo.PeerInfo.ID.String(),
+		nodeInfo:     nodeInfo})
+}
+
 // send a request to the state machine by enqueuing it in the request channel.
 // it is possible due to race condition or duplicate network events that a
 // request is sent after the fsm is completed and no longer a goroutine is
 // consuming from the channel, which will lead to a deadlock in the
 // requesternode when trying to send the request.
-// To mitigate this, we close the channel when the fsm is completed, and handle
-// the panic gracefully here.
+// To mitigate this, we close the done channel when the fsm is completed, and
+// give up on sending the request once it is closed.
 func (m *shardStateMachine) sendRequest(ctx context.Context, request shardStateRequest) {
-	defer func() {
-		if r := recover(); r != nil {
-			// It is acceptable to have multiple compute nodes publish the results for the same shard if we have
-			// multiple concurrent computations. Here we ignore publishing results after the shard has completed.
-			if request.action != actionResultsPublished {
-				go m.notifyInvalidRequest(ctx, request, "shard fsm is completed")
-			}
+	select {
+	case m.req <- request:
+	case <-m.done:
+		// It is acceptable to have multiple compute nodes publish the results for the same shard if we have
+		// multiple concurrent computations. Here we ignore publishing results after the shard has completed.
+		// Declined bids, joining nodes and failing a completed shard have no execution to cancel.
+		if request.action != actionResultsPublished && request.action != actionBidDeclined &&
+			request.action != actionNodeJoined && request.action != actionFail {
+			go m.notifyInvalidRequest(ctx, request, "shard fsm is completed")
 		}
-	}()
-	m.req <- request
+	}
 }
 
 // Notify the compute node that the request is invalid.
 func (m *shardStateMachine) notifyInvalidRequest(ctx context.Context, request shardStateRequest, reason string) {
+	if request.action == actionBidDeclined {
+		// declined bids have no execution to cancel, and can arrive after the shard stopped waiting for them
+		return
+	}
 	log.Ctx(ctx).Warn().Msgf("%s ignoring request due to `%s`: %+v", m, reason, request)
 	m.node.notifyCancel(ctx, reason, request.sourceNodeID, request.executionID)
 }
@@ -303,28 +439,82 @@ type stateFn func(context.Context, *shardStateMachine) stateFn
 
 func (m *shardStateMachine) transitionedTo(ctx context.Context, newState shardStateType) {
 	log.Ctx(ctx).Debug().Msgf("%s transitioning from %s -> %s", m, m.currentState, newState)
+	now := time.Now()
+	if m.currentState != shardInitialState {
+		shardStateDuration.WithLabelValues(m.node.id, m.currentState.String()).Observe(now.Sub(m.stateEnteredAt).Seconds())
+		shardsByState.WithLabelValues(m.node.id, m.currentState.String()).Dec()
+	}
+	shardsByState.WithLabelValues(m.node.id, newState.String()).Inc()
+	m.manager.jobMetrics.shardTransitioned(m.node.id, m.shard, newState)
+
 	m.previousState = m.currentState
 	m.currentState = newState
+	m.stateEnteredAt = now
 }
 
-// Shard is enqueuing bids waiting Min bids before start accepting/rejecting bids.
+// Shard is enqueuing bids waiting Min bids before start accepting/rejecting bids. Bids selected by their price are
+// enqueued until every asked node bid or declined, or until the negotiation timeout, so that the cheapest are accepted
+// rather than the first ones. The shard then has another negotiation timeout to accept enough bids.
 func enqueuedState(ctx context.Context, m *shardStateMachine) stateFn {
 	m.transitionedTo(ctx, shardEnqueuingBids)
 
+	bidWindowEnd := m.timeoutAt
+	var bidWindow *time.Timer
+	var bidWindowC <-chan time.Time
+	defer func() {
+		if bidWindow != nil {
+			bidWindow.Stop()
+		}
+	}()
+	// enoughBids returns true once the shard has enough bids to start the selection process.
+	enoughBids := func() bool {
+		if len(m.biddingNodes) < m.shard.Job.Spec.Deal.MinBids {
+			return false
+		}
+		if !m.comparesPrices() {
+			return true
+		}
+		if len(m.pendingNodes) == 0 && len(m.biddingNodes) > 0 {
+			return true
+		}
+		if bidWindow == nil {
+			bidWindow = time.NewTimer(time.Until(bidWindowEnd))
+			bidWindowC = bidWindow.C
+			m.timeoutAt = bidWindowEnd.Add(m.manager.jobNegotiationTimeout)
+		}
+		return false
+	}
+
 	for {
-		req := <-m.req
+		var req shardStateRequest
+		select {
+		case req = <-m.req:
+		case <-bidWindowC:
+			bidWindowC = nil
+			if len(m.biddingNodes) > 0 && len(m.biddingNodes) >= m.shard.Job.Spec.Deal.MinBids {
+				return selectingBidsState
+			}
+			continue
+		}
 		switch req.action {
 		case actionBidReceived:
+			delete(m.pendingNodes, req.sourceNodeID)
 			if _, ok := m.biddingNodes[req.sourceNodeID]; !ok {
 				m.biddingNodes[req.sourceNodeID] = req.executionID
+				m.bidPrices[req.sourceNodeID] = req.price
 
 				// we have received enough bids to start the selection process.
-				if len(m.biddingNodes) >= m.shard.Job.Spec.Deal.MinBids {
+				if enoughBids() {
 					return selectingBidsState
 				}
 			} else {
 				log.Ctx(ctx).Warn().Msgf("%s ignoring duplicate bid from %s", m, req.sourceNodeID)
 			}
+		case actionBidDeclined:
+			delete(m.pendingNodes, req.sourceNodeID)
+			if len(m.biddingNodes) > 0 && enoughBids() {
+				return selectingBidsState
+			}
 		case actionComputeError:
 			if _, ok := m.biddingNodes[req.sourceNodeID]; ok {
 				// remove the node from the bidding nodes list to be able to accept more bids
@@ -346,21 +536,30 @@ func enqueuedState(ctx context.Context, m *shardStateMachine) stateFn {
 func selectingBidsState(ctx context.Context, m *shardStateMachine) stateFn {
 	m.transitionedTo(ctx, shardSelectingBids)
 
-	// randomize the candidateBids slice before returning it
+	// randomize the candidateBids slice, then prefer the cheapest bids. Bids with the same price stay in random order.
 	candidateBids := maps.Keys(m.biddingNodes)
 	rand.Shuffle(len(candidateBids), func(i, j int) {
 		candidateBids[i], candidateBids[j] = candidateBids[j], candidateBids[i]
 	})
+	sort.SliceStable(candidateBids, func(i, j int) bool {
+		return m.bidPrices[candidateBids[i]] < m.bidPrices[candidateBids[j]]
+	})
 
 	// to hold the bids that were selected and successfully notified.
 	acceptedBids := make(map[string]string)
+	spent := 0.0
 
 	for _, candidate := range candidateBids {
 		executionID := m.biddingNodes[candidate]
-		if len(acceptedBids) < m.shard.Job.Spec.Deal.Concurrency {
-			m.node.notifyBidAccepted(ctx, candidate, executionID)
+		price := m.bidPrices[candidate]
+		if len(acceptedBids) < m.concurrency && m.withinBudget(spent+price) {
+			m.node.notifyBidAccepted(ctx, m.shard, candidate, executionID, price)
 			acceptedBids[candidate] = executionID
+			spent += price
 		} else {
+			if len(acceptedBids) < m.concurrency {
+				m.overBudget = true
+			}
 			m.node.notifyBidRejected(ctx, candidate, executionID)
 		}
 	}
@@ -368,7 +567,12 @@ func selectingBidsState(ctx context.Context, m *shardStateMachine) stateFn {
 	// updated biddingNodes to hold the accepted bids only.
 	m.biddingNodes = acceptedBids
 
-	if len(m.biddingNodes) < m.shard.Job.Spec.Deal.Concurrency {
+	if m.exceedsBudget() {
+		m.errorMsg = m.budgetError()
+		return errorState
+	}
+
+	if len(m.biddingNodes) < m.concurrency {
 		// we still need more bids to reach the concurrency level.
 		return acceptingBidsState
 	} else {
@@ -384,17 +588,34 @@ func acceptingBidsState(ctx context.Context, m *shardStateMachine) stateFn {
 		req := <-m.req
 		switch req.action {
 		case actionBidReceived:
+			delete(m.pendingNodes, req.sourceNodeID)
 			if _, ok := m.biddingNodes[req.sourceNodeID]; !ok {
-				m.node.notifyBidAccepted(ctx, req.sourceNodeID, req.executionID)
+				if !m.withinBudget(m.acceptedPrice() + req.price) {
+					m.overBudget = true
+					m.node.notifyBidRejected(ctx, req.sourceNodeID, req.executionID)
+					if m.exceedsBudget() {
+						m.errorMsg = m.budgetError()
+						return errorState
+					}
+					continue
+				}
+				m.node.notifyBidAccepted(ctx, m.shard, req.sourceNodeID, req.executionID, req.price)
 				// add the bid to the list of accepted bids.
 				m.biddingNodes[req.sourceNodeID] = req.executionID
+				m.bidPrices[req.sourceNodeID] = req.price
 
-				if len(m.biddingNodes) >= m.shard.Job.Spec.Deal.Concurrency {
+				if len(m.biddingNodes) >= m.concurrency {
 					return waitingForResultsState
 				}
 			} else {
 				log.Ctx(ctx).Warn().Msgf("%s ignoring duplicate bid from %s", m, req.sourceNodeID)
 			}
+		case actionBidDeclined:
+			delete(m.pendingNodes, req.sourceNodeID)
+			if m.exceedsBudget() {
+				m.errorMsg = m.budgetError()
+				return errorState
+			}
 		case actionComputeError:
 			if _, ok := m.biddingNodes[req.sourceNodeID]; ok {
 				// remove the node from the bidding nodes list to be able to accept more bids
@@ -448,7 +669,7 @@ func waitingForResultsState(ctx context.Context, m *shardStateMachine) stateFn {
 				// TODO: technically we can start verifying if we have enough results compared to deal's confidence
 				//  and concurrency. Though we will have ot handle the case where verification fails, but can still
 				//  succeed if we wait for more results.
-				if len(m.completedNodes) >= m.shard.Job.Spec.Deal.Concurrency {
+				if len(m.completedNodes) >= m.concurrency {
 					return verifyingResultsState
 				}
 			} else {
@@ -468,11 +689,27 @@ func verifyingResultsState(ctx context.Context, m *shardStateMachine) stateFn {
 	m.transitionedTo(ctx, shardVerifyingResults)
 
 	verifiedResults, err := m.node.verifyShard(ctx, m.shard)
+	var moreExecutions *verifier.MoreExecutionsNeeded
+	if errors.As(err, &moreExecutions) && moreExecutions.Executions > m.concurrency {
+		// run the shard on more nodes, that haven't run it yet, and verify all the results once they are in
+		log.Ctx(ctx).Debug().Msgf("%s needs %d executions to verify its results", m, moreExecutions.Executions)
+		excludedNodes := make(map[string]bool, len(m.biddingNodes))
+		for nodeID := range m.biddingNodes {
+			excludedNodes[nodeID] = true
+		}
+		m.node.askForMoreBids(ctx, m.shard, excludedNodes, moreExecutions.Executions-m.concurrency)
+		m.concurrency = moreExecutions.Executions
+		m.timeoutAt = time.Now().Add(m.manager.jobNegotiationTimeout)
+		return acceptingBidsState
+	}
 	if err != nil {
 		m.errorMsg = fmt.Sprintf("failed to verify job: %s", err)
 		return errorState
 	}
 
+	for _, result := range verifiedResults {
+		m.publishingNodes[result.NodeID] = true
+	}
 	if len(verifiedResults) > 0 {
 		return waitingToPublishResultsState
 	}
@@ -489,13 +726,163 @@ func waitingToPublishResultsState(ctx context.Context, m *shardStateMachine) sta
 		case actionResultsPublished:
 			// TODO: #831 verify that the published results are the same as the ones we expect, or let the verifier
 			//  publish the result and not all the compute nodes.
-			return completedState
+			if !m.shard.Job.Spec.Deal.Broadcast {
+				return completedState
+			}
+			// each node of a broadcast shard publishes its own results
+			delete(m.publishingNodes, req.sourceNodeID)
+			if len(m.publishingNodes) == 0 {
+				return completedState
+			}
+		case actionNodeJoined:
+			// too late to run the shard on the new node
+		case actionFail:
+			m.errorMsg = req.reason
+			return errorState
+		default:
+			m.notifyInvalidRequest(ctx, req, fmt.Sprintf("invalid action %s in state %s", req.action, m.currentState))
+		}
+	}
+}
+
+// Broadcast shard accepts a bid from every node it asked, and waits for each of them to either fail or submit
+// their results. Nodes run the shard independently, so a failure on one node doesn't affect the others, and nodes
+// that don't answer in time are given up on without failing the shard.
+func broadcastingState(ctx context.Context, m *shardStateMachine) stateFn {
+	m.transitionedTo(ctx, shardBroadcasting)
+	for nodeID := range m.pendingNodes {
+		m.setNodeDeadline(nodeID, m.manager.jobNegotiationTimeout)
+	}
+
+	for {
+		// wait for a request, or for the next node to miss its deadline
+		var deadlineC <-chan time.Time
+		var timer *time.Timer
+		if deadline, ok := m.nextNodeDeadline(); ok {
+			timer = time.NewTimer(time.Until(deadline))
+			deadlineC = timer.C
+		}
+		var req shardStateRequest
+		select {
+		case req = <-m.req:
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-deadlineC:
+			m.expireNodes(ctx, time.Now())
+			if done, next := m.broadcastDone(); done {
+				return next
+			}
+			continue
+		}
+
+		switch req.action {
+		case actionBidReceived:
+			if _, ok := m.biddingNodes[req.sourceNodeID]; ok {
+				log.Ctx(ctx).Warn().Msgf("%s ignoring duplicate bid from %s", m, req.sourceNodeID)
+				continue
+			}
+			delete(m.pendingNodes, req.sourceNodeID)
+			delete(m.nodeDeadlines, req.sourceNodeID)
+			if !m.withinBudget(m.acceptedPrice() + req.price) {
+				m.node.notifyBidRejected(ctx, req.sourceNodeID, req.executionID)
+				break
+			}
+			m.node.notifyBidAccepted(ctx, m.shard, req.sourceNodeID, req.executionID, req.price)
+			m.biddingNodes[req.sourceNodeID] = req.executionID
+			m.bidPrices[req.sourceNodeID] = req.price
+			// give the node until the job's timeout to run it
+			m.setNodeDeadline(req.sourceNodeID, m.shard.Job.Spec.GetTimeout())
+		case actionBidDeclined:
+			delete(m.pendingNodes, req.sourceNodeID)
+			delete(m.nodeDeadlines, req.sourceNodeID)
+		case actionNodeJoined:
+			if !m.askedNodes[req.sourceNodeID] {
+				m.askedNodes[req.sourceNodeID] = true
+				m.pendingNodes[req.sourceNodeID] = true
+				m.setNodeDeadline(req.sourceNodeID, m.manager.jobNegotiationTimeout)
+				m.node.askLateJoiner(ctx, m.shard, req.nodeInfo)
+			}
+		case actionComputeError:
+			if _, ok := m.biddingNodes[req.sourceNodeID]; ok {
+				m.failedNodes[req.sourceNodeID] = req.executionID
+				delete(m.nodeDeadlines, req.sourceNodeID)
+			} else {
+				m.notifyInvalidRequest(ctx, req, fmt.Sprintf(
+					"Received %s from node %s that has not bid on this shard", req.action, req.sourceNodeID))
+			}
+		case actionResultReceived:
+			if _, ok := m.biddingNodes[req.sourceNodeID]; ok && m.failedNodes[req.sourceNodeID] == "" {
+				m.completedNodes[req.sourceNodeID] = req.executionID
+				delete(m.nodeDeadlines, req.sourceNodeID)
+			} else {
+				m.notifyInvalidRequest(ctx, req, "results received from a non-bidding node")
+			}
 		case actionFail:
 			m.errorMsg = req.reason
 			return errorState
 		default:
 			m.notifyInvalidRequest(ctx, req, fmt.Sprintf("invalid action %s in state %s", req.action, m.currentState))
 		}
+
+		if done, next := m.broadcastDone(); done {
+			return next
+		}
+	}
+}
+
+// broadcastDone returns true, and the next state, once every node asked has answered, and every node that bid has
+// finished.
+func (m *shardStateMachine) broadcastDone() (bool, stateFn) {
+	if len(m.pendingNodes) > 0 || len(m.completedNodes)+len(m.failedNodes) < len(m.biddingNodes) {
+		return false, nil
+	}
+	if len(m.completedNodes) == 0 {
+		m.errorMsg = fmt.Sprintf("none of the %d nodes asked ran the shard successfully", len(m.askedNodes))
+		return true, errorState
+	}
+	return true, verifyingResultsState
+}
+
+// setNodeDeadline gives a node of a broadcast shard until timeout from now to answer. The shard itself only times
+// out well after the last of its nodes, in case the state machine gets stuck.
+func (m *shardStateMachine) setNodeDeadline(nodeID string, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	m.nodeDeadlines[nodeID] = deadline
+	if shardTimeout := deadline.Add(m.manager.jobNegotiationTimeout); shardTimeout.After(m.timeoutAt) {
+		m.timeoutAt = shardTimeout
+	}
+}
+
+// nextNodeDeadline returns the earliest deadline of the nodes of a broadcast shard, if any node has one.
+func (m *shardStateMachine) nextNodeDeadline() (time.Time, bool) {
+	var next time.Time
+	for _, deadline := range m.nodeDeadlines {
+		if next.IsZero() || deadline.Before(next) {
+			next = deadline
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// expireNodes gives up on the nodes of a broadcast shard whose deadline passed: nodes that didn't bid are treated as
+// declining, and nodes that didn't finish running the shard as failing, which cancels their execution.
+func (m *shardStateMachine) expireNodes(ctx context.Context, now time.Time) {
+	for nodeID, deadline := range m.nodeDeadlines {
+		if deadline.After(now) {
+			continue
+		}
+		delete(m.nodeDeadlines, nodeID)
+		if m.pendingNodes[nodeID] {
+			log.Ctx(ctx).Debug().Msgf("%s giving up on node %s that didn't bid in time", m, nodeID)
+			delete(m.pendingNodes, nodeID)
+			continue
+		}
+		if executionID, ok := m.biddingNodes[nodeID]; ok {
+			log.Ctx(ctx).Debug().Msgf("%s giving up on node %s that didn't run the shard in time", m, nodeID)
+			m.failedNodes[nodeID] = executionID
+			m.node.notifyCancel(ctx, "shard timed out on this node, see --timeout", nodeID, executionID)
+		}
 	}
 }
 
@@ -0,0 +1,527 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/eventhandler"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/suite"
+)
+
+const fsmTestTimeout = 5 * time.Second
+
+type ShardFSMSuite struct {
+	suite.Suite
+	mocknet   mocknet.Mocknet
+	scheduler *Scheduler
+	compute   *fsmTestEndpoint
+	verifier  *fsmTestVerifier
+	events    chan model.JobEvent
+}
+
+func TestShardFSMSuite(t *testing.T) {
+	suite.Run(t, new(ShardFSMSuite))
+}
+
+func (s *ShardFSMSuite) SetupTest() {
+	s.mocknet = mocknet.New()
+	s.T().Cleanup(func() { _ = s.mocknet.Close() })
+	host, err := s.mocknet.GenPeer()
+	s.Require().NoError(err)
+
+	cm := system.NewCleanupManager()
+	s.T().Cleanup(cm.Cleanup)
+	s.compute = &fsmTestEndpoint{
+		bids:          make(map[string]float64),
+		registryAuths: make(map[string][]byte),
+		accepted:      make(chan string, 10),
+		rejected:      make(chan string, 10),
+		cancelled:     make(chan string, 10),
+	}
+	s.verifier = &fsmTestVerifier{verified: make(chan model.JobShard, 10)}
+	// events can still be emitted by the previous test's state machines, so they are sent to this test's channel
+	events := make(chan model.JobEvent, 100)
+	s.events = events
+	s.scheduler = NewScheduler(context.Background(), cm, SchedulerParams{
+		ID:              host.ID().String(),
+		Host:            host,
+		NodeRanker:      fsmTestRanker{},
+		ComputeEndpoint: s.compute,
+		Verifiers:       fsmTestVerifierProvider{verifier: s.verifier},
+		EventEmitter: NewEventEmitter(EventEmitterParams{
+			EventConsumer: eventhandler.JobEventHandlerFunc(func(ctx context.Context, event model.JobEvent) error {
+				events <- event
+				return nil
+			}),
+		}),
+		JobNegotiationTimeout:              100 * time.Millisecond,
+		StateManagerBackgroundTaskInterval: time.Hour,
+	})
+}
+
+// startShard starts the state machine of the only shard of the job, asking the nodes to bid.
+func (s *ShardFSMSuite) startShard(deal model.Deal, timeout time.Duration, askedNodes ...string) *shardStateMachine {
+	job := &model.Job{
+		Metadata: model.Metadata{ID: "job-id"},
+		Spec: model.Spec{
+			Verifier:      model.VerifierNoop,
+			Deal:          deal,
+			Timeout:       timeout.Seconds(),
+			ExecutionPlan: model.JobExecutionPlan{TotalShards: 1},
+		},
+	}
+	ctx := context.Background()
+	s.scheduler.shardStateManager.startShardsState(ctx, job, s.scheduler, askedNodes)
+	shardState, ok := s.scheduler.shardStateManager.GetShardState(model.JobShard{Job: job, Index: 0})
+	s.Require().True(ok)
+	return shardState
+}
+
+// receive returns the next value sent on the channel, failing the test if none is sent in time.
+func receive[T any](s *ShardFSMSuite, c <-chan T, what string) T {
+	select {
+	case value := <-c:
+		return value
+	case <-time.After(fsmTestTimeout):
+		s.FailNow("timed out waiting for " + what)
+		var zero T
+		return zero
+	}
+}
+
+// errorEvent returns the error event the shard failed with, if any was emitted by now.
+func (s *ShardFSMSuite) errorEvent() (model.JobEvent, bool) {
+	for {
+		select {
+		case event := <-s.events:
+			if event.EventName == model.JobEventError {
+				return event, true
+			}
+		default:
+			return model.JobEvent{}, false
+		}
+	}
+}
+
+func (s *ShardFSMSuite) TestBroadcastNodeMissesBidDeadline() {
+	shardState := s.startShard(model.Deal{Broadcast: true}, time.Minute, "node-a", "node-b")
+	ctx := context.Background()
+
+	shardState.bid(ctx, "node-a", "execution-a", 0)
+	s.Equal("execution-a", receive(s, s.compute.accepted, "bid of node-a to be accepted"))
+	shardState.verifyResult(ctx, "node-a", "execution-a")
+
+	// node-b never bids, and is given up on once its deadline passes rather than when the shard times out
+	receive(s, s.verifier.verified, "shard to be verified")
+	_, failed := s.errorEvent()
+	s.False(failed)
+}
+
+func (s *ShardFSMSuite) TestBroadcastNodeMissesRunDeadline() {
+	shardState := s.startShard(model.Deal{Broadcast: true}, 200*time.Millisecond, "node-a", "node-b")
+	ctx := context.Background()
+
+	shardState.bid(ctx, "node-a", "execution-a", 0)
+	shardState.bid(ctx, "node-b", "execution-b", 0)
+	receive(s, s.compute.accepted, "bid to be accepted")
+	receive(s, s.compute.accepted, "bid to be accepted")
+	shardState.verifyResult(ctx, "node-a", "execution-a")
+
+	// node-b bid but never finishes, so it fails on its own and its execution is cancelled
+	s.Equal("execution-b", receive(s, s.compute.cancelled, "execution of node-b to be cancelled"))
+	receive(s, s.verifier.verified, "shard to be verified")
+	_, failed := s.errorEvent()
+	s.False(failed)
+}
+
+func (s *ShardFSMSuite) TestBroadcastFailsWithoutResults() {
+	shardState := s.startShard(model.Deal{Broadcast: true}, time.Minute, "node-a", "node-b")
+	ctx := context.Background()
+
+	shardState.bid(ctx, "node-a", "execution-a", 0)
+	receive(s, s.compute.accepted, "bid of node-a to be accepted")
+	shardState.bidDeclined(ctx, "node-b")
+	shardState.computeError(ctx, "node-a", "execution-a")
+
+	s.Eventually(func() bool {
+		event, failed := s.errorEvent()
+		return failed && event.JobID == "job-id"
+	}, fsmTestTimeout, 10*time.Millisecond)
+}
+
+func (s *ShardFSMSuite) TestVerificationRecorded() {
+	recorder := fsmTestRecorder{recorded: make(chan verifier.VerifierResult, 10)}
+	s.scheduler.recorder = recorder
+	s.verifier.results = []verifier.VerifierResult{
+		{JobID: "job-id", NodeID: "node-a", ExecutionID: "execution-a", Reason: "results differ from the majority"},
+	}
+	shardState := s.startShard(model.Deal{Concurrency: 1}, time.Minute, "node-a")
+	ctx := context.Background()
+	shardState.bid(ctx, "node-a", "execution-a", 0)
+	receive(s, s.compute.accepted, "bid of node-a to be accepted")
+	shardState.verifyResult(ctx, "node-a", "execution-a")
+
+	result := receive(s, recorder.recorded, "rejected result to be recorded")
+	s.Equal("node-a", result.NodeID)
+	s.False(result.Verified)
+}
+
+func (s *ShardFSMSuite) TestLateJoiner() {
+	shardState := s.startShard(model.Deal{Broadcast: true, IncludeLateJoiners: true}, time.Minute, "node-a")
+	ctx := context.Background()
+	shardState.bid(ctx, "node-a", "execution-a", 0)
+	receive(s, s.compute.accepted, "bid of node-a to be accepted")
+
+	mn := mocknet.New()
+	defer mn.Close()
+	lateHost, err := mn.GenPeer()
+	s.Require().NoError(err)
+	lateNodeID := lateHost.ID().String()
+	s.compute.setBid(lateNodeID, 0)
+	nodeInfo := model.NodeInfo{
+		PeerInfo: lateHost.Peerstore().PeerInfo(lateHost.ID()),
+		NodeType: model.NodeTypeCompute,
+		Versions: model.NodeVersions{ProtocolVersion: model.ProtocolVersion, APIVersions: model.SupportedAPIVersions()},
+	}
+	s.Require().NoError(s.scheduler.HandleNodeInfo(ctx, nodeInfo))
+
+	// the late joiner is asked to bid, and its bid accepted
+	s.Equal("execution-"+lateNodeID, receive(s, s.compute.accepted, "bid of the late joiner to be accepted"))
+
+	// and the shard waits for its results too
+	shardState.verifyResult(ctx, "node-a", "execution-a")
+	select {
+	case <-s.verifier.verified:
+		s.Fail("shard verified before the late joiner finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+	shardState.verifyResult(ctx, lateNodeID, "execution-"+lateNodeID)
+	receive(s, s.verifier.verified, "shard to be verified")
+}
+
+func (s *ShardFSMSuite) TestHandleNodeInfoDoesNotBlock() {
+	// a state machine that is registered, but never reads its requests
+	job := &model.Job{
+		Metadata: model.Metadata{ID: "job-id"},
+		Spec:     model.Spec{Deal: model.Deal{Broadcast: true, IncludeLateJoiners: true}},
+	}
+	shard := model.JobShard{Job: job, Index: 0}
+	manager := s.scheduler.shardStateManager
+	manager.mu.Lock()
+	manager.shardStates[shard.ID()] = manager.newShardStateMachine(context.Background(), shard, s.scheduler)
+	manager.mu.Unlock()
+
+	handled := make(chan error, 1)
+	go func() {
+		handled <- s.scheduler.HandleNodeInfo(context.Background(), model.NodeInfo{NodeType: model.NodeTypeCompute})
+	}()
+	s.NoError(receive(s, handled, "node info to be handled"))
+}
+
+func (s *ShardFSMSuite) TestCheapestBidsSelectedFirst() {
+	shardState := s.startShard(model.Deal{Concurrency: 1}, time.Minute, "node-a", "node-b", "node-c")
+	ctx := context.Background()
+
+	shardState.bid(ctx, "node-a", "execution-a", 5)
+	shardState.bid(ctx, "node-b", "execution-b", 2)
+
+	// the first bid isn't accepted before the other nodes had a chance to bid
+	select {
+	case executionID := <-s.compute.accepted:
+		s.Fail("bid accepted before every node bid", executionID)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	shardState.bidDeclined(ctx, "node-c")
+	s.Equal("execution-b", receive(s, s.compute.accepted, "cheapest bid to be accepted"))
+	s.Equal("execution-a", receive(s, s.compute.rejected, "other bid to be rejected"))
+}
+
+func (s *ShardFSMSuite) TestBidsSelectedWhenNegotiationTimesOut() {
+	shardState := s.startShard(model.Deal{Concurrency: 1, MaxBudget: 10}, time.Minute, "node-a", "node-b")
+	ctx := context.Background()
+
+	// node-b never answers, so the bids received are selected once the negotiation timeout passes
+	shardState.bid(ctx, "node-a", "execution-a", 3)
+	s.Equal("execution-a", receive(s, s.compute.accepted, "bid of node-a to be accepted"))
+	shardState.bid(ctx, "node-b", "execution-b", 1)
+	s.Equal("execution-b", receive(s, s.compute.rejected, "late bid to be rejected"))
+}
+
+func (s *ShardFSMSuite) TestBidsOverBudget() {
+	shardState := s.startShard(model.Deal{Concurrency: 1, MaxBudget: 4}, time.Minute, "node-a", "node-b")
+	ctx := context.Background()
+
+	shardState.bid(ctx, "node-a", "execution-a", 5)
+	shardState.bid(ctx, "node-b", "execution-b", 6)
+	receive(s, s.compute.rejected, "bid over budget to be rejected")
+	receive(s, s.compute.rejected, "bid over budget to be rejected")
+
+	var event model.JobEvent
+	s.Eventually(func() bool {
+		var failed bool
+		event, failed = s.errorEvent()
+		return failed
+	}, fsmTestTimeout, 10*time.Millisecond)
+	s.Contains(event.Status, "exceed the job's budget of 4")
+}
+
+func (s *ShardFSMSuite) TestBidsPartlyOverBudget() {
+	shardState := s.startShard(model.Deal{Concurrency: 2, MaxBudget: 5}, time.Minute, "node-a", "node-b", "node-c")
+	ctx := context.Background()
+
+	shardState.bid(ctx, "node-a", "execution-a", 2)
+	shardState.bid(ctx, "node-b", "execution-b", 4)
+	shardState.bidDeclined(ctx, "node-c")
+	s.Equal("execution-a", receive(s, s.compute.accepted, "cheapest bid to be accepted"))
+	s.Equal("execution-b", receive(s, s.compute.rejected, "bid over the remaining budget to be rejected"))
+
+	// the accepted execution is cancelled, as the shard can't run on enough nodes within the budget
+	s.Equal("execution-a", receive(s, s.compute.cancelled, "accepted execution to be cancelled"))
+	var event model.JobEvent
+	s.Eventually(func() bool {
+		var failed bool
+		event, failed = s.errorEvent()
+		return failed
+	}, fsmTestTimeout, 10*time.Millisecond)
+	s.Contains(event.Status, "only 1 of the 2 bids needed fit in it")
+}
+
+func (s *ShardFSMSuite) TestCancelJob() {
+	shardState := s.startShard(model.Deal{Concurrency: 1}, time.Minute, "node-a")
+	ctx := context.Background()
+	shardState.bid(ctx, "node-a", "execution-a", 0)
+	receive(s, s.compute.accepted, "bid of node-a to be accepted")
+
+	s.scheduler.CancelJob(ctx, shardState.shard.Job, "replaced")
+	s.Equal("execution-a", receive(s, s.compute.cancelled, "execution of node-a to be cancelled"))
+	var event model.JobEvent
+	s.Eventually(func() bool {
+		var failed bool
+		event, failed = s.errorEvent()
+		return failed
+	}, fsmTestTimeout, 10*time.Millisecond)
+	s.Contains(event.Status, "replaced")
+
+	// cancelling a job that is done already does nothing
+	s.scheduler.CancelJob(ctx, shardState.shard.Job, "replaced")
+	select {
+	case executionID := <-s.compute.cancelled:
+		s.Fail("execution cancelled twice", executionID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// askWithRegistryToken asks a compute node to bid on a job that brings a registry token.
+func (s *ShardFSMSuite) askWithRegistryToken(nodeHost host.Host) compute.AskForBidRequest {
+	job := &model.Job{
+		Metadata: model.Metadata{ID: "job-id"},
+		Spec: model.Spec{
+			Engine:        model.EngineDocker,
+			Verifier:      model.VerifierNoop,
+			Docker:        model.JobSpecDocker{Image: "registry.example.com/image", RegistryAuth: []byte("sealed:user:password")},
+			Deal:          model.Deal{Concurrency: 1},
+			Timeout:       time.Minute.Seconds(),
+			ExecutionPlan: model.JobExecutionPlan{TotalShards: 1},
+		},
+	}
+	s.scheduler.decrypter = func(_ context.Context, data []byte) ([]byte, error) {
+		return bytes.TrimPrefix(data, []byte("sealed:")), nil
+	}
+	s.Require().NoError(s.mocknet.LinkAll())
+	s.compute.setBid(nodeHost.ID().String(), 0)
+
+	ctx := context.Background()
+	s.scheduler.shardStateManager.startShardsState(ctx, job, s.scheduler, []string{nodeHost.ID().String()})
+	_, span := s.scheduler.newSpan(ctx, "askForBid", job.Metadata.ID)
+	s.scheduler.notifyAskForBid(ctx, span, job, model.NodeInfo{
+		PeerInfo: peer.AddrInfo{ID: nodeHost.ID(), Addrs: nodeHost.Addrs()},
+		Versions: model.NodeVersions{ProtocolVersion: model.ProtocolVersion, APIVersions: model.SupportedAPIVersions()},
+	}, []int{0})
+
+	s.compute.mu.Lock()
+	defer s.compute.mu.Unlock()
+	s.Require().Len(s.compute.asks, 1)
+	return s.compute.asks[0]
+}
+
+func (s *ShardFSMSuite) TestRegistryTokenSentWithBidAccepted() {
+	// registry tokens can only be sealed for RSA keys
+	privateKey, _, err := crypto.GenerateKeyPair(crypto.RSA, 2048)
+	s.Require().NoError(err)
+	nodeHost, err := s.mocknet.AddPeer(privateKey, multiaddr.StringCast("/ip4/127.0.0.1/tcp/4001"))
+	s.Require().NoError(err)
+	nodeEncrypter := verifier.NewEncrypter(privateKey)
+	s.scheduler.encrypter = nodeEncrypter.Seal
+
+	ask := s.askWithRegistryToken(nodeHost)
+	s.Empty(ask.Job.Spec.Docker.RegistryAuth, "nodes asked to bid should not be sent the token")
+	s.True(ask.HasRegistryAuth)
+
+	executionID := receive(s, s.compute.accepted, "bid to be accepted")
+	s.compute.mu.Lock()
+	sealed := s.compute.registryAuths[executionID]
+	s.compute.mu.Unlock()
+	token, err := nodeEncrypter.Unseal(context.Background(), sealed)
+	s.Require().NoError(err)
+	s.Equal("user:password", string(token))
+}
+
+func (s *ShardFSMSuite) TestRegistryTokenNotSealed() {
+	nodeHost, err := s.mocknet.GenPeer()
+	s.Require().NoError(err)
+	s.scheduler.encrypter = func(context.Context, []byte, []byte) ([]byte, error) {
+		return nil, fmt.Errorf("could not cast public key to RSA")
+	}
+
+	s.askWithRegistryToken(nodeHost)
+	receive(s, s.compute.rejected, "bid to be rejected")
+	s.Eventually(func() bool {
+		_, failed := s.errorEvent()
+		return failed
+	}, fsmTestTimeout, 10*time.Millisecond)
+	select {
+	case executionID := <-s.compute.accepted:
+		s.Fail("bid accepted without the registry token", executionID)
+	default:
+	}
+}
+
+// fsmTestEndpoint is a compute endpoint that bids on behalf of the nodes it is told to, and records the responses
+// to their bids.
+type fsmTestEndpoint struct {
+	mu            sync.Mutex
+	bids          map[string]float64
+	asks          []compute.AskForBidRequest
+	registryAuths map[string][]byte
+	accepted      chan string
+	rejected      chan string
+	cancelled     chan string
+}
+
+func (e *fsmTestEndpoint) setBid(nodeID string, price float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.bids[nodeID] = price
+}
+
+func (e *fsmTestEndpoint) AskForBid(_ context.Context, request compute.AskForBidRequest) (compute.AskForBidResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.asks = append(e.asks, request)
+	var response compute.AskForBidResponse
+	price, ok := e.bids[request.TargetPeerID]
+	for _, shardIndex := range request.ShardIndexes {
+		response.ShardResponse = append(response.ShardResponse, compute.AskForBidShardResponse{
+			ExecutionMetadata: compute.ExecutionMetadata{
+				ExecutionID: "execution-" + request.TargetPeerID,
+				JobID:       request.Job.Metadata.ID,
+				ShardIndex:  shardIndex,
+			},
+			Accepted: ok,
+			Price:    price,
+		})
+	}
+	return response, nil
+}
+
+func (e *fsmTestEndpoint) BidAccepted(_ context.Context, request compute.BidAcceptedRequest) (compute.BidAcceptedResponse, error) {
+	e.mu.Lock()
+	e.registryAuths[request.ExecutionID] = request.RegistryAuth
+	e.mu.Unlock()
+	e.accepted <- request.ExecutionID
+	return compute.BidAcceptedResponse{}, nil
+}
+
+func (e *fsmTestEndpoint) BidRejected(_ context.Context, request compute.BidRejectedRequest) (compute.BidRejectedResponse, error) {
+	e.rejected <- request.ExecutionID
+	return compute.BidRejectedResponse{}, nil
+}
+
+func (e *fsmTestEndpoint) ResultAccepted(context.Context, compute.ResultAcceptedRequest) (compute.ResultAcceptedResponse, error) {
+	return compute.ResultAcceptedResponse{}, nil
+}
+
+func (e *fsmTestEndpoint) ResultRejected(context.Context, compute.ResultRejectedRequest) (compute.ResultRejectedResponse, error) {
+	return compute.ResultRejectedResponse{}, nil
+}
+
+func (e *fsmTestEndpoint) CancelExecution(
+	_ context.Context, request compute.CancelExecutionRequest) (compute.CancelExecutionResponse, error) {
+	e.cancelled <- request.ExecutionID
+	return compute.CancelExecutionResponse{}, nil
+}
+
+// fsmTestRanker ranks every node as suitable.
+type fsmTestRanker struct{}
+
+func (fsmTestRanker) RankNodes(_ context.Context, _ model.Job, nodes []model.NodeInfo) ([]NodeRank, error) {
+	ranks := make([]NodeRank, 0, len(nodes))
+	for _, node := range nodes {
+		ranks = append(ranks, NodeRank{NodeInfo: node, Rank: 1})
+	}
+	return ranks, nil
+}
+
+type fsmTestVerifierProvider struct {
+	verifier verifier.Verifier
+}
+
+func (p fsmTestVerifierProvider) GetVerifier(context.Context, model.Verifier) (verifier.Verifier, error) {
+	return p.verifier, nil
+}
+
+func (p fsmTestVerifierProvider) HasVerifier(context.Context, model.Verifier) bool {
+	return true
+}
+
+// fsmTestVerifier records the shards it verifies, and returns the results it is given for them.
+type fsmTestVerifier struct {
+	verified chan model.JobShard
+	results  []verifier.VerifierResult
+}
+
+func (v *fsmTestVerifier) IsInstalled(context.Context) (bool, error) {
+	return true, nil
+}
+
+func (v *fsmTestVerifier) GetShardResultPath(context.Context, model.JobShard) (string, error) {
+	return "", nil
+}
+
+func (v *fsmTestVerifier) GetShardProposal(context.Context, model.JobShard, string) ([]byte, error) {
+	return nil, nil
+}
+
+func (v *fsmTestVerifier) IsExecutionComplete(context.Context, model.JobShard) (bool, error) {
+	return true, nil
+}
+
+func (v *fsmTestVerifier) VerifyShard(_ context.Context, shard model.JobShard) ([]verifier.VerifierResult, error) {
+	v.verified <- shard
+	return v.results, nil
+}
+
+// fsmTestRecorder records the verification results it is told about.
+type fsmTestRecorder struct {
+	recorded chan verifier.VerifierResult
+}
+
+func (r fsmTestRecorder) RecordVerification(_ context.Context, result verifier.VerifierResult) {
+	r.recorded <- result
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
@@ -27,6 +28,12 @@ type NodeRanker interface {
 	RankNodes(ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]NodeRank, error)
 }
 
+// VerificationRecorder is told how the results of nodes were verified, e.g. to rank the nodes whose results were
+// rejected lower.
+type VerificationRecorder interface {
+	RecordVerification(ctx context.Context, result verifier.VerifierResult)
+}
+
 type NodeInfoStore interface {
 	// Add adds a node info to the repo.
 	Add(ctx context.Context, nodeInfo model.NodeInfo) error
@@ -54,6 +61,8 @@ type StartJobRequest struct {
 
 type CancelJobRequest struct {
 	JobID string
+	// why the job is cancelled, which its shards fail with
+	Reason string
 }
 
 type CancelJobResult struct {
@@ -0,0 +1,265 @@
+package scenario
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/filecoin-project/bacalhau/pkg/devstack"
+	"github.com/filecoin-project/bacalhau/pkg/job"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"sigs.k8s.io/yaml"
+)
+
+// A File declares Scenarios in YAML or JSON, so that they can be run with
+// `bacalhau scenario run` against a devstack or a live cluster without writing
+// a Go test.
+type File struct {
+	// The name of the suite of scenarios in reports. The file name is used if
+	// empty.
+	Name string `json:"Name,omitempty"`
+
+	// The devstack the scenarios are run against, unless they are run against
+	// a live cluster. One hybrid node is started if nil.
+	Stack *FileStack `json:"Stack,omitempty"`
+
+	Scenarios []FileScenario `json:"Scenarios"`
+}
+
+// A FileStack declares the devstack that the scenarios of a File are run
+// against.
+type FileStack struct {
+	// The nodes of the devstack, in the format of `bacalhau devstack
+	// --topology`. One hybrid node is started if nil.
+	Topology *devstack.Topology `json:"Topology,omitempty"`
+
+	// Whether the nodes run jobs with the noop executor, as with `bacalhau
+	// devstack --noop`.
+	Noop bool `json:"Noop,omitempty"`
+}
+
+// A FileScenario is a Scenario declared in a File. Checks that are functions
+// in a Scenario are declared with fields instead.
+type FileScenario struct {
+	Name string `json:"Name"`
+
+	// Data stored on IPFS before the job is submitted, and mounted into it.
+	// Data that is already available, e.g. from a URL or a CID, can be
+	// declared in the inputs of the Spec instead.
+	Inputs   []FileStorage `json:"Inputs,omitempty"`
+	Contexts []FileStorage `json:"Contexts,omitempty"`
+
+	Outputs []model.StorageSpec `json:"Outputs,omitempty"`
+	Spec    model.Spec          `json:"Spec"`
+	Deal    model.Deal          `json:"Deal,omitempty"`
+
+	// If set, the submission of the job must fail with an error containing
+	// this text, and nothing else is checked.
+	SubmitError string `json:"SubmitError,omitempty"`
+
+	// The number of shard executions that must reach each state. Any shard
+	// execution in the Error state fails the scenario unless Error is listed.
+	// If empty, the job must complete on as many nodes as the concurrency of
+	// the Deal, which is only correct for jobs that aren't sharded.
+	ExpectedStates map[model.JobStateType]int `json:"ExpectedStates,omitempty"`
+
+	// Assertions about the job and its results, which must all hold.
+	Checks []FileCheck `json:"Checks,omitempty"`
+}
+
+// A FileStorage is data stored on IPFS for a FileScenario. Exactly one of Text
+// and File must be set.
+type FileStorage struct {
+	// Text to store as a file.
+	Text string `json:"Text,omitempty"`
+	// A local file to store, relative to the scenario file.
+	File string `json:"File,omitempty"`
+	// Where the data is mounted in the job.
+	Path string `json:"Path"`
+}
+
+// A FileCheck is an assertion of a FileScenario. Exactly one of its fields
+// must be set.
+type FileCheck struct {
+	// The stdout of the job must contain this text.
+	StdoutContains string `json:"StdoutContains,omitempty"`
+	// The stdout of the job must be exactly this text.
+	StdoutEquals *string `json:"StdoutEquals,omitempty"`
+	// A file of the results must contain some text.
+	FileContains *FileContainsCheck `json:"FileContains,omitempty"`
+	// A directory of the results must hold a number of files.
+	FileCount *FileCountCheck `json:"FileCount,omitempty"`
+	// Every shard execution that completed must have been verified.
+	AllShardsVerified bool `json:"AllShardsVerified,omitempty"`
+}
+
+type FileContainsCheck struct {
+	// The path of the file, relative to the downloaded results.
+	Path string `json:"Path"`
+	Text string `json:"Text"`
+	// The number of lines of the file, which isn't checked if zero.
+	Lines int `json:"Lines,omitempty"`
+}
+
+type FileCountCheck struct {
+	// The path of the directory, relative to the downloaded results.
+	Path  string `json:"Path"`
+	Count int    `json:"Count"`
+}
+
+// LoadFile reads scenarios from a YAML or JSON file.
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read scenario file: %w", err)
+	}
+	var file File
+	if err = yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid scenario file %s: %w", path, err)
+	}
+	if err = file.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario file %s: %w", path, err)
+	}
+	return &file, nil
+}
+
+// Validate checks that the scenarios of the file can be run.
+func (f File) Validate() error {
+	if len(f.Scenarios) == 0 {
+		return fmt.Errorf("no scenarios are declared")
+	}
+	if f.Stack != nil && f.Stack.Topology != nil {
+		if err := f.Stack.Topology.Validate(); err != nil {
+			return fmt.Errorf("stack: %w", err)
+		}
+	}
+	names := make(map[string]bool)
+	for i, scenario := range f.Scenarios {
+		if scenario.Name == "" {
+			return fmt.Errorf("scenario %d: a name is required", i)
+		}
+		if names[scenario.Name] {
+			return fmt.Errorf("scenario %d: name %q is used by another scenario", i, scenario.Name)
+		}
+		names[scenario.Name] = true
+		if err := scenario.Validate(); err != nil {
+			return fmt.Errorf("scenario %q: %w", scenario.Name, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the scenario can be run.
+func (s FileScenario) Validate() error {
+	if !model.IsValidEngine(s.Spec.Engine) {
+		return fmt.Errorf("invalid engine %s", s.Spec.Engine)
+	}
+	for i, storage := range append(append([]FileStorage{}, s.Inputs...), s.Contexts...) {
+		if (storage.Text == "") == (storage.File == "") {
+			return fmt.Errorf("storage %d: exactly one of Text and File must be set", i)
+		}
+		if storage.Path == "" {
+			return fmt.Errorf("storage %d: a path is required", i)
+		}
+	}
+	for state, count := range s.ExpectedStates {
+		if count < 0 {
+			return fmt.Errorf("expected count of %s states must not be negative", state)
+		}
+	}
+	for i, check := range s.Checks {
+		set := 0
+		for _, isSet := range []bool{
+			check.StdoutContains != "",
+			check.StdoutEquals != nil,
+			check.FileContains != nil,
+			check.FileCount != nil,
+			check.AllShardsVerified,
+		} {
+			if isSet {
+				set++
+			}
+		}
+		if set != 1 {
+			return fmt.Errorf("check %d: exactly one assertion must be set", i)
+		}
+		if check.FileContains != nil && check.FileContains.Path == "" {
+			return fmt.Errorf("check %d: FileContains needs a path", i)
+		}
+		if check.FileCount != nil && check.FileCount.Count < 0 {
+			return fmt.Errorf("check %d: FileCount must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// Scenario returns the Scenario declared by the file, reading the files it
+// stores relative to dir.
+func (s FileScenario) Scenario(dir string) Scenario {
+	scenario := Scenario{
+		Inputs:   fileStorage(dir, s.Inputs),
+		Contexts: fileStorage(dir, s.Contexts),
+		Outputs:  s.Outputs,
+		Spec:     s.Spec,
+		Deal:     s.Deal,
+	}
+
+	if s.SubmitError != "" {
+		scenario.SubmitChecker = SubmitJobErrorContains(s.SubmitError)
+	}
+
+	if len(s.ExpectedStates) == 0 {
+		nodes := s.Deal.Concurrency
+		if nodes < 1 {
+			nodes = 1
+		}
+		scenario.JobCheckers = WaitUntilSuccessful(nodes)
+	} else {
+		if _, ok := s.ExpectedStates[model.JobStateError]; !ok {
+			scenario.JobCheckers = append(scenario.JobCheckers, job.WaitThrowErrors([]model.JobStateType{
+				model.JobStateError,
+			}))
+		}
+		scenario.JobCheckers = append(scenario.JobCheckers, job.WaitForJobStates(s.ExpectedStates))
+	}
+
+	var resultsCheckers []CheckResults
+	for _, check := range s.Checks {
+		switch {
+		case check.StdoutContains != "":
+			resultsCheckers = append(resultsCheckers, FileContainsText(model.DownloadFilenameStdout, check.StdoutContains))
+		case check.StdoutEquals != nil:
+			resultsCheckers = append(resultsCheckers, FileEquals(model.DownloadFilenameStdout, *check.StdoutEquals))
+		case check.FileContains != nil && check.FileContains.Lines > 0:
+			resultsCheckers = append(resultsCheckers,
+				FileContains(check.FileContains.Path, check.FileContains.Text, check.FileContains.Lines))
+		case check.FileContains != nil:
+			resultsCheckers = append(resultsCheckers, FileContainsText(check.FileContains.Path, check.FileContains.Text))
+		case check.FileCount != nil:
+			resultsCheckers = append(resultsCheckers, FileCount(check.FileCount.Path, check.FileCount.Count))
+		case check.AllShardsVerified:
+			scenario.JobCheckers = append(scenario.JobCheckers, AllShardsVerified())
+		}
+	}
+	if len(resultsCheckers) > 0 {
+		scenario.ResultsChecker = ManyChecks(resultsCheckers...)
+	}
+	return scenario
+}
+
+func fileStorage(dir string, storage []FileStorage) SetupStorage {
+	if len(storage) == 0 {
+		return nil
+	}
+	var stores []SetupStorage
+	for _, data := range storage {
+		if data.Text != "" {
+			stores = append(stores, StoredText(data.Text, data.Path))
+		} else if filepath.IsAbs(data.File) {
+			stores = append(stores, StoredFile(data.File, data.Path))
+		} else {
+			stores = append(stores, StoredFile(filepath.Join(dir, data.File), data.Path))
+		}
+	}
+	return ManyStores(stores...)
+}
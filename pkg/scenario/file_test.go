@@ -0,0 +1,146 @@
+//go:build unit || !integration
+
+package scenario
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFile(t *testing.T) {
+	file, err := LoadFile("../../testdata/scenarios.yaml")
+	require.NoError(t, err)
+	require.Equal(t, "docker-smoke", file.Name)
+	require.Len(t, file.Stack.Topology.NodeGroups, 2)
+	require.Len(t, file.Scenarios, 4)
+
+	echo := file.Scenarios[0]
+	require.Equal(t, model.EngineDocker, echo.Spec.Engine)
+	require.Equal(t, []string{"echo", "hello from a scenario"}, echo.Spec.Docker.Entrypoint)
+	require.Equal(t, "hello from a scenario\n", *echo.Checks[0].StdoutEquals)
+
+	readInput := file.Scenarios[1]
+	require.Equal(t, "/inputs/fruits.txt", readInput.Inputs[0].Path)
+	require.Equal(t, 2, readInput.Checks[1].FileContains.Lines)
+
+	writeOutputs := file.Scenarios[2]
+	require.Equal(t, "/outputs", writeOutputs.Outputs[0].Path)
+	require.Equal(t, 2, writeOutputs.Checks[0].FileCount.Count)
+
+	verified := file.Scenarios[3]
+	require.Equal(t, model.VerifierDeterministic, verified.Spec.Verifier)
+	require.Equal(t, 2, verified.Deal.Concurrency)
+	require.True(t, verified.Checks[0].AllShardsVerified)
+}
+
+func TestLoadFileRejectsUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenarios.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+Scenarios:
+  - Name: typo
+    Spec:
+      Engine: Noop
+    Checks:
+      - StdoutContain: hello
+`), 0600))
+	_, err := LoadFile(path)
+	require.Error(t, err)
+}
+
+func TestFileValidate(t *testing.T) {
+	noop := model.Spec{Engine: model.EngineNoop}
+	for _, tc := range []struct {
+		name string
+		file File
+	}{
+		{name: "no scenarios", file: File{}},
+		{name: "no name", file: File{Scenarios: []FileScenario{{Spec: noop}}}},
+		{name: "duplicate names", file: File{Scenarios: []FileScenario{{Name: "a", Spec: noop}, {Name: "a", Spec: noop}}}},
+		{name: "no engine", file: File{Scenarios: []FileScenario{{Name: "a"}}}},
+		{name: "text and file", file: File{Scenarios: []FileScenario{{Name: "a", Spec: noop,
+			Inputs: []FileStorage{{Text: "hello", File: "hello.txt", Path: "/inputs"}}}}}},
+		{name: "no path", file: File{Scenarios: []FileScenario{{Name: "a", Spec: noop,
+			Inputs: []FileStorage{{Text: "hello"}}}}}},
+		{name: "empty check", file: File{Scenarios: []FileScenario{{Name: "a", Spec: noop,
+			Checks: []FileCheck{{}}}}}},
+		{name: "two assertions in a check", file: File{Scenarios: []FileScenario{{Name: "a", Spec: noop,
+			Checks: []FileCheck{{StdoutContains: "hello", AllShardsVerified: true}}}}}},
+		{name: "negative state count", file: File{Scenarios: []FileScenario{{Name: "a", Spec: noop,
+			ExpectedStates: map[model.JobStateType]int{model.JobStateCompleted: -1}}}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Error(t, tc.file.Validate())
+		})
+	}
+}
+
+func TestFileScenarioChecks(t *testing.T) {
+	resultsDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(resultsDir, model.DownloadFilenameStdout), []byte("hello\nworld\n"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(resultsDir, "outputs", "nested"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(resultsDir, "outputs", "a"), nil, 0600))
+
+	stdout := "hello\nworld\n"
+	passing := FileScenario{Checks: []FileCheck{
+		{StdoutContains: "world"},
+		{StdoutEquals: &stdout},
+		{FileContains: &FileContainsCheck{Path: model.DownloadFilenameStdout, Text: "hello", Lines: 3}},
+		{FileCount: &FileCountCheck{Path: "outputs", Count: 1}},
+	}}.Scenario("")
+	require.NoError(t, passing.ResultsChecker(resultsDir))
+
+	for _, check := range []FileCheck{
+		{StdoutContains: "goodbye"},
+		{FileContains: &FileContainsCheck{Path: model.DownloadFilenameStdout, Text: "hello", Lines: 1}},
+		{FileCount: &FileCountCheck{Path: "outputs", Count: 2}},
+	} {
+		failing := FileScenario{Checks: []FileCheck{check}}.Scenario("")
+		require.Error(t, failing.ResultsChecker(resultsDir))
+	}
+}
+
+func TestFileScenarioJobCheckers(t *testing.T) {
+	completed := func(verified bool) model.JobShardState {
+		return model.JobShardState{
+			State:              model.JobStateCompleted,
+			VerificationResult: model.VerificationResult{Complete: true, Result: verified},
+		}
+	}
+	jobState := func(shards ...model.JobShardState) model.JobState {
+		state := model.JobState{Nodes: map[string]model.JobNodeState{}}
+		for i, shard := range shards {
+			state.Nodes[string(rune('a'+i))] = model.JobNodeState{Shards: map[int]model.JobShardState{0: shard}}
+		}
+		return state
+	}
+	check := func(scenario Scenario, state model.JobState) (bool, error) {
+		for _, checker := range scenario.JobCheckers {
+			ok, err := checker(state)
+			if !ok || err != nil {
+				return ok, err
+			}
+		}
+		return true, nil
+	}
+
+	concurrent := FileScenario{Deal: model.Deal{Concurrency: 2}}.Scenario("")
+	ok, err := check(concurrent, jobState(completed(true)))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ok, err = check(concurrent, jobState(completed(true), completed(true)))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	verified := FileScenario{Checks: []FileCheck{{AllShardsVerified: true}}}.Scenario("")
+	_, err = check(verified, jobState(completed(false)))
+	require.Error(t, err)
+
+	expectingErrors := FileScenario{ExpectedStates: map[model.JobStateType]int{model.JobStateError: 1}}.Scenario("")
+	ok, err = check(expectingErrors, jobState(model.JobShardState{State: model.JobStateError}))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
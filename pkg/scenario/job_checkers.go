@@ -0,0 +1,40 @@
+package scenario
+
+import (
+	"fmt"
+
+	"github.com/filecoin-project/bacalhau/pkg/job"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+)
+
+// WaitUntilSuccessful returns a set of job.CheckStatesFunctions that will wait
+// until the job they are checking reaches the Completed state on the passed
+// number of nodes. The checks will fail if any job errors.
+func WaitUntilSuccessful(nodes int) []job.CheckStatesFunction {
+	return []job.CheckStatesFunction{
+		job.WaitThrowErrors([]model.JobStateType{
+			model.JobStateError,
+		}),
+		job.WaitForJobStates(map[model.JobStateType]int{
+			model.JobStateCompleted: nodes,
+		}),
+	}
+}
+
+// AllShardsVerified returns a job.CheckStatesFunction that will wait until
+// every shard execution that completed has been verified. The check will fail
+// if any of them failed verification.
+func AllShardsVerified() job.CheckStatesFunction {
+	return func(jobState model.JobState) (bool, error) {
+		for _, shardState := range job.GetFilteredShardStates(jobState, model.JobStateCompleted) { //nolint:gocritic
+			if !shardState.VerificationResult.Complete {
+				return false, nil
+			}
+			if !shardState.VerificationResult.Result {
+				return false, fmt.Errorf("shard %d on node %s failed verification", shardState.ShardIndex, shardState.NodeID)
+			}
+		}
+		return true, nil
+	}
+}
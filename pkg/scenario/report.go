@@ -0,0 +1,124 @@
+package scenario
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+)
+
+const (
+	ReportFormatJSON  = "json"
+	ReportFormatJUnit = "junit"
+)
+
+// A Report is the outcome of running the scenarios of a File.
+type Report struct {
+	Name      string           `json:"Name"`
+	Scenarios []ScenarioReport `json:"Scenarios"`
+}
+
+// A ScenarioReport is the outcome of running one scenario.
+type ScenarioReport struct {
+	Name string `json:"Name"`
+	// The job submitted for the scenario, which is empty if it wasn't submitted
+	JobID    string         `json:"JobID,omitempty"`
+	Duration model.Duration `json:"Duration"`
+	// Why the scenario failed, which is empty if it passed
+	Error string `json:"Error,omitempty"`
+}
+
+// Failed returns the number of scenarios that failed.
+func (r Report) Failed() int {
+	failed := 0
+	for _, scenario := range r.Scenarios {
+		if scenario.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Write writes the report in the format, which is either ReportFormatJSON or
+// ReportFormatJUnit.
+func (r Report) Write(w io.Writer, format string) error {
+	switch format {
+	case ReportFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case ReportFormatJUnit:
+		return r.writeJUnit(w)
+	default:
+		return fmt.Errorf("invalid report format %s. Only %s and %s are supported", format, ReportFormatJSON, ReportFormatJUnit)
+	}
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (r Report) writeJUnit(w io.Writer) error {
+	suite := junitTestSuite{
+		Name:     r.Name,
+		Tests:    len(r.Scenarios),
+		Failures: r.Failed(),
+	}
+	var total time.Duration
+	for _, scenario := range r.Scenarios {
+		total += time.Duration(scenario.Duration)
+		testCase := junitTestCase{
+			Name:      scenario.Name,
+			ClassName: r.Name,
+			Time:      junitSeconds(time.Duration(scenario.Duration)),
+		}
+		if scenario.JobID != "" {
+			testCase.SystemOut = fmt.Sprintf("job %s", scenario.JobID)
+		}
+		if scenario.Error != "" {
+			testCase.Failure = &junitFailure{Message: scenario.Error, Text: scenario.Error}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	suite.Time = junitSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,46 @@
+//go:build unit || !integration
+
+package scenario
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+var testReport = Report{
+	Name: "smoke",
+	Scenarios: []ScenarioReport{
+		{Name: "echo", JobID: "92d5d4ee-3765-4f78-8353-623f5f26df08", Duration: model.Duration(1500 * time.Millisecond)},
+		{Name: "rejected", Duration: model.Duration(time.Second), Error: "expected error, got nil"},
+	},
+}
+
+func TestReportJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testReport.Write(&buf, ReportFormatJSON))
+
+	var read Report
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &read))
+	require.Equal(t, testReport, read)
+	require.Equal(t, 1, read.Failed())
+}
+
+func TestReportJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testReport.Write(&buf, ReportFormatJUnit))
+
+	junit := buf.String()
+	require.Contains(t, junit, `<testsuite name="smoke" tests="2" failures="1" time="2.500">`)
+	require.Contains(t, junit, `<testcase name="echo" classname="smoke" time="1.500">`)
+	require.Contains(t, junit, `<system-out>job 92d5d4ee-3765-4f78-8353-623f5f26df08</system-out>`)
+	require.Contains(t, junit, `<failure message="expected error, got nil">expected error, got nil</failure>`)
+}
+
+func TestReportInvalidFormat(t *testing.T) {
+	require.Error(t, testReport.Write(&bytes.Buffer{}, "xml"))
+}
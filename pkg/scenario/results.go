@@ -41,6 +41,26 @@ func FileContains(
 	}
 }
 
+// FileContainsText returns a CheckResults that asserts that the expected
+// string is in the output file, whatever its size.
+func FileContainsText(
+	outputFilePath string,
+	expectedString string,
+) CheckResults {
+	return func(resultsDir string) error {
+		outputFile := filepath.Join(resultsDir, outputFilePath)
+		resultsContent, err := os.ReadFile(outputFile)
+		if err != nil {
+			return err
+		}
+
+		if !strings.Contains(string(resultsContent), expectedString) {
+			return fmt.Errorf("%s: content mismatch:\nExpected Contains: %q\nActual: %q", outputFile, expectedString, resultsContent)
+		}
+		return nil
+	}
+}
+
 // FileEquals returns a CheckResults that asserts that the expected string is
 // exactly equal to the full contents of the output file.
 func FileEquals(
@@ -61,6 +81,32 @@ func FileEquals(
 	}
 }
 
+// FileCount returns a CheckResults that asserts that the output directory
+// holds the expected number of files, not counting subdirectories.
+func FileCount(
+	outputDirPath string,
+	expectedCount int,
+) CheckResults {
+	return func(resultsDir string) error {
+		outputDir := filepath.Join(resultsDir, outputDirPath)
+		entries, err := os.ReadDir(outputDir)
+		if err != nil {
+			return err
+		}
+
+		actualCount := 0
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				actualCount++
+			}
+		}
+		if actualCount != expectedCount {
+			return fmt.Errorf("%s: file count mismatch:\nExpected: %d\nActual: %d", outputDir, expectedCount, actualCount)
+		}
+		return nil
+	}
+}
+
 // ManyCheckes returns a CheckResults that runs the passed checkers and returns
 // an error if any of them fail.
 func ManyChecks(checks ...CheckResults) CheckResults {
@@ -0,0 +1,160 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/devstack"
+	"github.com/filecoin-project/bacalhau/pkg/downloader"
+	"github.com/filecoin-project/bacalhau/pkg/downloader/util"
+	"github.com/filecoin-project/bacalhau/pkg/ipfs"
+	"github.com/filecoin-project/bacalhau/pkg/job"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/requester/publicapi"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+)
+
+const defaultDownloadTimeout = 5 * time.Second
+
+// A Target is the Bacalhau network a Scenario is run against, which is either a
+// devstack started for the Scenario or a live cluster.
+type Target struct {
+	// The client of the requester node that jobs are submitted to.
+	Client *publicapi.RequesterAPIClient
+
+	// The IPFS nodes that the inputs and contexts of the Scenario are stored
+	// on. Scenarios that store data can't be run if there are none, which is
+	// usually the case for live clusters.
+	IPFSClients []*ipfs.Client
+
+	// How the results of jobs are downloaded. The IPFS swarm addresses must be
+	// set for the results to be found.
+	DownloadSettings model.DownloaderSettings
+}
+
+// NewDevStackTarget returns the Target for running Scenarios against the
+// first node of the stack, which must be a requester node.
+func NewDevStackTarget(ctx context.Context, stack *devstack.DevStack) (Target, error) {
+	swarmAddresses, err := stack.Nodes[0].IPFSClient.SwarmAddresses(ctx)
+	if err != nil {
+		return Target{}, err
+	}
+	return Target{
+		Client:      publicapi.NewRequesterAPIClient(stack.Nodes[0].APIServer.GetURI()),
+		IPFSClients: stack.IPFSClients(),
+		DownloadSettings: model.DownloaderSettings{
+			Timeout:        defaultDownloadTimeout,
+			IPFSSwarmAddrs: strings.Join(swarmAddresses, ","),
+		},
+	}, nil
+}
+
+// Run submits the job of the Scenario to the target, waits for it with the job
+// checkers of the Scenario, downloads its results to resultsDir and checks
+// them. The submitted job is returned, and is nil if the Scenario expected the
+// submission to fail.
+//
+// Unlike ScenarioRunner, Run doesn't need a test and can run Scenarios against
+// any network, which is how `bacalhau scenario run` executes scenario files.
+func Run(
+	ctx context.Context,
+	cm *system.CleanupManager,
+	target Target,
+	scenario Scenario,
+	resultsDir string,
+) (*model.Job, error) {
+	spec := scenario.Spec
+
+	// Setup storage
+	var err error
+	spec.Inputs, err = prepareStorage(ctx, target, scenario.Inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store inputs: %w", err)
+	}
+	spec.Contexts, err = prepareStorage(ctx, target, scenario.Contexts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store contexts: %w", err)
+	}
+	spec.Outputs = scenario.Outputs
+	if spec.Outputs == nil {
+		spec.Outputs = []model.StorageSpec{}
+	}
+
+	// Setup job and submit
+	j, err := model.NewJobWithSaneProductionDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	j.Spec = spec
+	if !model.IsValidEngine(j.Spec.Engine) {
+		return nil, fmt.Errorf("invalid engine %s", j.Spec.Engine)
+	}
+	if !model.IsValidVerifier(j.Spec.Verifier) {
+		j.Spec.Verifier = model.VerifierNoop
+	}
+	if !model.IsValidPublisher(j.Spec.Publisher) {
+		j.Spec.Publisher = model.PublisherIpfs
+	}
+
+	j.Spec.Deal = scenario.Deal
+	if j.Spec.Deal.Concurrency < 1 {
+		j.Spec.Deal.Concurrency = 1
+	}
+
+	submittedJob, submitError := target.Client.Submit(ctx, j)
+	if scenario.SubmitChecker == nil {
+		scenario.SubmitChecker = SubmitJobSuccess()
+	}
+	if err = scenario.SubmitChecker(submittedJob, submitError); err != nil {
+		return submittedJob, fmt.Errorf("unexpected submission response: %w", err)
+	}
+
+	// exit if the scenario expects submission to fail as no further checks can be made
+	if submitError != nil {
+		return nil, nil
+	}
+
+	// Wait for job to complete
+	resolver := target.Client.GetJobStateResolver()
+	shards := job.GetJobTotalExecutionCount(submittedJob)
+	err = resolver.Wait(ctx, submittedJob.Metadata.ID, shards, scenario.JobCheckers...)
+	if err != nil {
+		return submittedJob, fmt.Errorf("job %s did not reach the expected states: %w", submittedJob.Metadata.ID, err)
+	}
+
+	// Check outputs
+	results, err := target.Client.GetResults(ctx, submittedJob.Metadata.ID)
+	if err != nil {
+		return submittedJob, err
+	}
+
+	downloaderSettings := target.DownloadSettings
+	downloaderSettings.OutputDir = resultsDir
+	downloaderProvider := util.NewStandardDownloaders(cm, &downloaderSettings, target.Client)
+	err = downloader.DownloadJob(ctx, spec.Outputs, results, downloaderProvider, &downloaderSettings)
+	if err != nil {
+		return submittedJob, fmt.Errorf("failed to download results: %w", err)
+	}
+
+	if scenario.ResultsChecker != nil {
+		if err = scenario.ResultsChecker(filepath.Join(resultsDir, model.DownloadVolumesFolderName)); err != nil {
+			return submittedJob, err
+		}
+	}
+
+	return submittedJob, nil
+}
+
+func prepareStorage(ctx context.Context, target Target, getStorage SetupStorage) ([]model.StorageSpec, error) {
+	if getStorage == nil {
+		return []model.StorageSpec{}, nil
+	}
+	if len(target.IPFSClients) == 0 {
+		return nil, fmt.Errorf("no IPFS nodes to store data on")
+	}
+	return getStorage(ctx, model.StorageSourceIPFS, target.IPFSClients...)
+}
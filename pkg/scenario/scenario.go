@@ -7,7 +7,9 @@ to be submitted to it, and a set of checks to exercise that the job was executed
 as expected.
 
 Scenarios can be used in standalone way (see `pkg/test/executor/test_runner.go`)
-or using the provided `ScenarioRunner` can be used.
+or with the `ScenarioRunner` test suite of `pkg/test/scenario`. Scenarios can also be
+declared in a YAML `File` and run with `bacalhau scenario run` against a
+devstack or a live cluster, which reports their outcome as JUnit or JSON.
 
 As well as executing jobs against real executors, a Scenario can instead use the
 NoopExecutor to implement a mocked out job. This makes is easier to test network
@@ -74,7 +76,9 @@ type Scenario struct {
 	JobCheckers []job.CheckStatesFunction
 }
 
-// All the information that is needed to uniquely define a devstack.
+// All the information that is needed to uniquely define a devstack. Networks of
+// differently configured nodes can be declared with a devstack.Topology in the
+// DevStackOptions, using the same format as `bacalhau devstack --topology`.
 type StackConfig struct {
 	*devstack.DevStackOptions
 	node.ComputeConfig
@@ -0,0 +1,108 @@
+package simulator
+
+import (
+	"context"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+)
+
+type RecordingEndpointParams struct {
+	Endpoint compute.Endpoint
+	Recorder *Recorder
+}
+
+// RecordingEndpoint records the requests sent to compute nodes through the wrapped endpoint, along with their
+// responses.
+type RecordingEndpoint struct {
+	endpoint compute.Endpoint
+	recorder *Recorder
+}
+
+func NewRecordingEndpoint(params RecordingEndpointParams) *RecordingEndpoint {
+	return &RecordingEndpoint{
+		endpoint: params.Endpoint,
+		recorder: params.Recorder,
+	}
+}
+
+func (e *RecordingEndpoint) AskForBid(ctx context.Context, request compute.AskForBidRequest) (compute.AskForBidResponse, error) {
+	return recordRequest(ctx, e.recorder, TraceAskForBid, request, e.endpoint.AskForBid)
+}
+
+func (e *RecordingEndpoint) BidAccepted(ctx context.Context, request compute.BidAcceptedRequest) (compute.BidAcceptedResponse, error) {
+	return recordRequest(ctx, e.recorder, TraceBidAccepted, request, e.endpoint.BidAccepted)
+}
+
+func (e *RecordingEndpoint) BidRejected(ctx context.Context, request compute.BidRejectedRequest) (compute.BidRejectedResponse, error) {
+	return recordRequest(ctx, e.recorder, TraceBidRejected, request, e.endpoint.BidRejected)
+}
+
+func (e *RecordingEndpoint) ResultAccepted(
+	ctx context.Context, request compute.ResultAcceptedRequest) (compute.ResultAcceptedResponse, error) {
+	return recordRequest(ctx, e.recorder, TraceResultAccepted, request, e.endpoint.ResultAccepted)
+}
+
+func (e *RecordingEndpoint) ResultRejected(
+	ctx context.Context, request compute.ResultRejectedRequest) (compute.ResultRejectedResponse, error) {
+	return recordRequest(ctx, e.recorder, TraceResultRejected, request, e.endpoint.ResultRejected)
+}
+
+func (e *RecordingEndpoint) CancelExecution(
+	ctx context.Context, request compute.CancelExecutionRequest) (compute.CancelExecutionResponse, error) {
+	return recordRequest(ctx, e.recorder, TraceCancelExecution, request, e.endpoint.CancelExecution)
+}
+
+func recordRequest[Request any, Response any](
+	ctx context.Context,
+	recorder *Recorder,
+	recordType TraceRecordType,
+	request Request,
+	send func(context.Context, Request) (Response, error)) (Response, error) {
+	sent := time.Now()
+	response, err := send(ctx, request)
+	recorder.record(ctx, recordType, sent, request, response, time.Since(sent), err)
+	return response, err
+}
+
+type RecordingCallbackParams struct {
+	Callback compute.Callback
+	Recorder *Recorder
+}
+
+// RecordingCallback records the callbacks sent to requester nodes through the wrapped callback.
+type RecordingCallback struct {
+	callback compute.Callback
+	recorder *Recorder
+}
+
+func NewRecordingCallback(params RecordingCallbackParams) *RecordingCallback {
+	return &RecordingCallback{
+		callback: params.Callback,
+		recorder: params.Recorder,
+	}
+}
+
+func (c *RecordingCallback) OnRunComplete(ctx context.Context, result compute.RunResult) {
+	c.recorder.record(ctx, TraceOnRunComplete, time.Now(), result, nil, 0, nil)
+	c.callback.OnRunComplete(ctx, result)
+}
+
+func (c *RecordingCallback) OnPublishComplete(ctx context.Context, result compute.PublishResult) {
+	c.recorder.record(ctx, TraceOnPublishComplete, time.Now(), result, nil, 0, nil)
+	c.callback.OnPublishComplete(ctx, result)
+}
+
+func (c *RecordingCallback) OnCancelComplete(ctx context.Context, result compute.CancelResult) {
+	c.recorder.record(ctx, TraceOnCancelComplete, time.Now(), result, nil, 0, nil)
+	c.callback.OnCancelComplete(ctx, result)
+}
+
+func (c *RecordingCallback) OnComputeFailure(ctx context.Context, result compute.ComputeError) {
+	c.recorder.record(ctx, TraceOnComputeFailure, time.Now(), result, nil, 0, nil)
+	c.callback.OnComputeFailure(ctx, result)
+}
+
+// Compile-time interface check:
+var _ compute.Endpoint = (*RecordingEndpoint)(nil)
+var _ compute.Callback = (*RecordingCallback)(nil)
@@ -0,0 +1,295 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+type ComputeReplayParams struct {
+	// NodeID is the compute node under replay. Requests are sent to it on behalf of itself, so that the node delivers
+	// its callbacks to its local callback, which must be the replayer.
+	NodeID   string
+	Endpoint compute.Endpoint
+	Trace    []TraceRecord
+	// TraceNodeID is the compute node of the trace whose requests are replayed, and can be left empty if the trace only
+	// holds requests to a single compute node
+	TraceNodeID string
+	// TimeScale multiplies the recorded delays between requests, so that zero replays the trace as fast as possible
+	TimeScale float64
+	// IdleTimeout ends the replay once all requests were sent and no callback was received for this long
+	IdleTimeout time.Duration
+}
+
+// ComputeReplayer sends the requests a compute node received in a recorded trace to a compute node at their recorded
+// times, and compares its responses and callbacks with the recorded ones. The compute node chooses new execution IDs
+// when it bids, so later requests are sent with the execution IDs of the replay.
+type ComputeReplayer struct {
+	nodeID      string
+	endpoint    compute.Endpoint
+	trace       []TraceRecord
+	traceNodeID string
+	timeScale   float64
+	idleTimeout time.Duration
+
+	mu                sync.Mutex
+	replayedIDs       map[string]string // recorded execution ID to the execution ID of the replay
+	recordedIDs       map[string]string // execution ID of the replay to the recorded execution ID
+	receivedCallbacks []string
+	lastActivity      time.Time
+	report            ReplayReport
+}
+
+func NewComputeReplayer(params ComputeReplayParams) *ComputeReplayer {
+	return &ComputeReplayer{
+		nodeID:      params.NodeID,
+		endpoint:    params.Endpoint,
+		trace:       params.Trace,
+		traceNodeID: params.TraceNodeID,
+		timeScale:   params.TimeScale,
+		idleTimeout: params.IdleTimeout,
+		replayedIDs: make(map[string]string),
+		recordedIDs: make(map[string]string),
+	}
+}
+
+// Replay sends the recorded requests and waits for the compute node's callbacks.
+func (r *ComputeReplayer) Replay(ctx context.Context) (ReplayReport, error) {
+	traceNodeID, err := r.findTraceNodeID()
+	if err != nil {
+		return ReplayReport{}, err
+	}
+
+	var expectedCallbacks []string
+	start := time.Now()
+	for _, record := range r.trace {
+		keys, decodeErr := decodeKeys(record.Request)
+		if decodeErr != nil {
+			return ReplayReport{}, fmt.Errorf("failed to decode %s recorded at %s: %w", record.Type, record.Time, decodeErr)
+		}
+		if record.Type.IsCallback() && keys.SourcePeerID == traceNodeID {
+			expectedCallbacks = append(expectedCallbacks, executionKey(record.Type, keys.ExecutionID))
+		}
+		if !record.Type.IsRequest() || keys.TargetPeerID != traceNodeID {
+			continue
+		}
+
+		wait := time.Until(start.Add(time.Duration(float64(record.Time.Sub(r.trace[0].Time)) * r.timeScale)))
+		if err = sleep(ctx, wait); err != nil {
+			break
+		}
+		r.send(ctx, record)
+	}
+
+	ticker := time.NewTicker(replayPollInterval)
+	defer ticker.Stop()
+	for err == nil && r.idleFor() < r.idleTimeout {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := r.report
+	received := append([]string(nil), r.receivedCallbacks...)
+	for _, expected := range expectedCallbacks {
+		if i := slices.Index(received, expected); i >= 0 {
+			received = slices.Delete(received, i, i+1)
+			report.Replayed++
+		} else {
+			report.Missing = append(report.Missing, expected)
+		}
+	}
+	report.Unexpected = append(report.Unexpected, received...)
+	return report, err
+}
+
+// findTraceNodeID returns the compute node of the trace whose requests are replayed.
+func (r *ComputeReplayer) findTraceNodeID() (string, error) {
+	if r.traceNodeID != "" {
+		return r.traceNodeID, nil
+	}
+	nodeIDs := make(map[string]bool)
+	for _, record := range r.trace {
+		if !record.Type.IsRequest() {
+			continue
+		}
+		keys, err := decodeKeys(record.Request)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode %s recorded at %s: %w", record.Type, record.Time, err)
+		}
+		nodeIDs[keys.TargetPeerID] = true
+	}
+	if len(nodeIDs) != 1 {
+		return "", fmt.Errorf("the trace holds requests to %d compute nodes, so the one to replay must be chosen: %v",
+			len(nodeIDs), maps.Keys(nodeIDs))
+	}
+	return maps.Keys(nodeIDs)[0], nil
+}
+
+func (r *ComputeReplayer) send(ctx context.Context, record TraceRecord) {
+	switch record.Type {
+	case TraceAskForBid:
+		r.askForBid(ctx, record)
+	case TraceBidAccepted:
+		replayExecutionRequest(ctx, r, record, func(request *compute.BidAcceptedRequest) (*compute.RoutingMetadata, *string) {
+			return &request.RoutingMetadata, &request.ExecutionID
+		}, r.endpoint.BidAccepted)
+	case TraceBidRejected:
+		replayExecutionRequest(ctx, r, record, func(request *compute.BidRejectedRequest) (*compute.RoutingMetadata, *string) {
+			return &request.RoutingMetadata, &request.ExecutionID
+		}, r.endpoint.BidRejected)
+	case TraceResultAccepted:
+		replayExecutionRequest(ctx, r, record, func(request *compute.ResultAcceptedRequest) (*compute.RoutingMetadata, *string) {
+			return &request.RoutingMetadata, &request.ExecutionID
+		}, r.endpoint.ResultAccepted)
+	case TraceResultRejected:
+		replayExecutionRequest(ctx, r, record, func(request *compute.ResultRejectedRequest) (*compute.RoutingMetadata, *string) {
+			return &request.RoutingMetadata, &request.ExecutionID
+		}, r.endpoint.ResultRejected)
+	case TraceCancelExecution:
+		replayExecutionRequest(ctx, r, record, func(request *compute.CancelExecutionRequest) (*compute.RoutingMetadata, *string) {
+			return &request.RoutingMetadata, &request.ExecutionID
+		}, r.endpoint.CancelExecution)
+	}
+}
+
+func (r *ComputeReplayer) askForBid(ctx context.Context, record TraceRecord) {
+	var request compute.AskForBidRequest
+	var recorded compute.AskForBidResponse
+	if err := json.Unmarshal(record.Request, &request); err != nil {
+		r.diverge("failed to decode %s recorded at %s: %s", record.Type, record.Time, err)
+		return
+	}
+	if err := json.Unmarshal(record.Response, &recorded); len(record.Response) > 0 && err != nil {
+		r.diverge("failed to decode %s response recorded at %s: %s", record.Type, record.Time, err)
+		return
+	}
+	key := askForBidKey(request.Job.Metadata.ID, request.TargetPeerID)
+	r.route(&request.RoutingMetadata)
+
+	response, err := r.endpoint.AskForBid(ctx, request)
+	if !r.sameOutcome(key, record, err) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, recordedShard := range recorded.ShardResponse {
+		i := slices.IndexFunc(response.ShardResponse, func(shard compute.AskForBidShardResponse) bool {
+			return shard.ShardIndex == recordedShard.ShardIndex
+		})
+		if i < 0 {
+			r.report.Diverged = append(r.report.Diverged,
+				fmt.Sprintf("%s: no bid on shard %d", key, recordedShard.ShardIndex))
+			continue
+		}
+		shard := response.ShardResponse[i]
+		r.replayedIDs[recordedShard.ExecutionID] = shard.ExecutionID
+		r.recordedIDs[shard.ExecutionID] = recordedShard.ExecutionID
+		if shard.Accepted != recordedShard.Accepted {
+			r.report.Diverged = append(r.report.Diverged, fmt.Sprintf("%s: shard %d was accepted %t as recorded, but %t in the replay",
+				key, recordedShard.ShardIndex, recordedShard.Accepted, shard.Accepted))
+		}
+	}
+}
+
+func replayExecutionRequest[Request any, Response any](
+	ctx context.Context,
+	r *ComputeReplayer,
+	record TraceRecord,
+	fields func(*Request) (*compute.RoutingMetadata, *string),
+	send func(context.Context, Request) (Response, error)) {
+	var request Request
+	if err := json.Unmarshal(record.Request, &request); err != nil {
+		r.diverge("failed to decode %s recorded at %s: %s", record.Type, record.Time, err)
+		return
+	}
+	routing, executionID := fields(&request)
+	key := executionKey(record.Type, *executionID)
+
+	r.mu.Lock()
+	replayedID, ok := r.replayedIDs[*executionID]
+	r.mu.Unlock()
+	if !ok {
+		r.diverge("%s: the execution wasn't bid on in the replay", key)
+		return
+	}
+	*executionID = replayedID
+	r.route(routing)
+
+	_, err := send(ctx, request)
+	r.sameOutcome(key, record, err)
+}
+
+// route sends a request to the compute node under replay on behalf of itself.
+func (r *ComputeReplayer) route(routing *compute.RoutingMetadata) {
+	*routing = compute.RoutingMetadata{SourcePeerID: r.nodeID, TargetPeerID: r.nodeID}
+}
+
+// sameOutcome returns true if a request failed in the replay exactly when it failed as recorded.
+func (r *ComputeReplayer) sameOutcome(key string, record TraceRecord, err error) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastActivity = time.Now()
+	if (err != nil) != (record.Error != "") {
+		r.report.Diverged = append(r.report.Diverged,
+			fmt.Sprintf("%s: failed with %q as recorded, but with %v in the replay", key, record.Error, err))
+		return false
+	}
+	r.report.Replayed++
+	return true
+}
+
+func (r *ComputeReplayer) diverge(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.Diverged = append(r.report.Diverged, fmt.Sprintf(format, args...))
+}
+
+func (r *ComputeReplayer) idleFor() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return time.Since(r.lastActivity)
+}
+
+// received keeps a callback of the compute node under replay, by the recorded execution ID it is about.
+func (r *ComputeReplayer) received(ctx context.Context, recordType TraceRecordType, executionID string) {
+	log.Ctx(ctx).Debug().Msgf("replay: received %s for execution %s", recordType, executionID)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastActivity = time.Now()
+	if recordedID, ok := r.recordedIDs[executionID]; ok {
+		executionID = recordedID
+	}
+	r.receivedCallbacks = append(r.receivedCallbacks, executionKey(recordType, executionID))
+}
+
+func (r *ComputeReplayer) OnRunComplete(ctx context.Context, result compute.RunResult) {
+	r.received(ctx, TraceOnRunComplete, result.ExecutionID)
+}
+
+func (r *ComputeReplayer) OnPublishComplete(ctx context.Context, result compute.PublishResult) {
+	r.received(ctx, TraceOnPublishComplete, result.ExecutionID)
+}
+
+func (r *ComputeReplayer) OnCancelComplete(ctx context.Context, result compute.CancelResult) {
+	r.received(ctx, TraceOnCancelComplete, result.ExecutionID)
+}
+
+func (r *ComputeReplayer) OnComputeFailure(ctx context.Context, result compute.ComputeError) {
+	r.received(ctx, TraceOnComputeFailure, result.ExecutionID)
+}
+
+// Compile-time interface check:
+var _ compute.Callback = (*ComputeReplayer)(nil)
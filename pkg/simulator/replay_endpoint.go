@@ -0,0 +1,302 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/rs/zerolog/log"
+)
+
+// ReplayReport compares the messages exchanged during a replay with the recorded ones.
+type ReplayReport struct {
+	// Replayed is the number of recorded messages that were exchanged again as recorded
+	Replayed int
+	// Unexpected are the messages that were exchanged during the replay but not recorded
+	Unexpected []string
+	// Missing are the recorded messages that were not exchanged during the replay
+	Missing []string
+	// Diverged are the messages that were exchanged during the replay with a different outcome than recorded
+	Diverged []string
+}
+
+// Matched returns true if the replay exchanged the same messages as recorded.
+func (r ReplayReport) Matched() bool {
+	return len(r.Unexpected) == 0 && len(r.Missing) == 0 && len(r.Diverged) == 0
+}
+
+// traceKeys are the fields that requests and callbacks are matched by when replaying a trace.
+type traceKeys struct {
+	SourcePeerID string
+	TargetPeerID string
+	ExecutionID  string
+	Job          struct {
+		Metadata struct {
+			ID string
+		}
+	}
+	ShardResponse []compute.AskForBidShardResponse
+}
+
+func decodeKeys(message json.RawMessage) (traceKeys, error) {
+	var keys traceKeys
+	if len(message) == 0 {
+		return keys, nil
+	}
+	err := json.Unmarshal(message, &keys)
+	return keys, err
+}
+
+// requestKey identifies the recorded request that answers a request sent during a replay.
+func (k traceKeys) requestKey(recordType TraceRecordType) string {
+	if recordType == TraceAskForBid {
+		return askForBidKey(k.Job.Metadata.ID, k.TargetPeerID)
+	}
+	return executionKey(recordType, k.ExecutionID)
+}
+
+// askForBidKey identifies bid requests by their job and compute node, as execution IDs are only chosen by compute
+// nodes when they bid.
+func askForBidKey(jobID, targetPeerID string) string {
+	return fmt.Sprintf("%s for job %s to %s", TraceAskForBid, jobID, targetPeerID)
+}
+
+func executionKey(recordType TraceRecordType, executionID string) string {
+	return fmt.Sprintf("%s for execution %s", recordType, executionID)
+}
+
+// replayEntry is a recorded request, along with the callbacks that were sent about its execution until the next
+// request for the same execution.
+type replayEntry struct {
+	record    TraceRecord
+	key       string
+	callbacks []TraceRecord
+	replayed  bool
+}
+
+type ReplayEndpointParams struct {
+	Trace []TraceRecord
+	// TimeScale multiplies the recorded delays between requests and the callbacks that followed them. Callbacks are
+	// delivered as soon as their request is answered if zero.
+	TimeScale float64
+}
+
+// ReplayEndpoint answers the requests of a requester node with the responses recorded in a trace instead of sending
+// them to compute nodes, and delivers the callbacks that followed each request to the requester node after the
+// recorded delay. This drives the requester through a recorded incident without any compute node.
+type ReplayEndpoint struct {
+	timeScale float64
+	callback  compute.Callback
+
+	mu               sync.Mutex
+	entries          []*replayEntry
+	unexpected       []string
+	lastActivity     time.Time
+	pendingCallbacks int
+}
+
+func NewReplayEndpoint(params ReplayEndpointParams) (*ReplayEndpoint, error) {
+	endpoint := &ReplayEndpoint{
+		timeScale:    params.TimeScale,
+		lastActivity: time.Now(),
+	}
+
+	byExecution := make(map[string]*replayEntry)
+	for _, record := range params.Trace {
+		if !record.Type.IsRequest() && !record.Type.IsCallback() {
+			continue
+		}
+		keys, err := decodeKeys(record.Request)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s recorded at %s: %w", record.Type, record.Time, err)
+		}
+
+		if record.Type.IsCallback() {
+			entry, ok := byExecution[keys.ExecutionID]
+			if !ok {
+				// the trace started after the request the callback is about
+				log.Debug().Msgf("replay: ignoring %s for execution %s without a recorded request", record.Type, keys.ExecutionID)
+				continue
+			}
+			entry.callbacks = append(entry.callbacks, record)
+			continue
+		}
+
+		entry := &replayEntry{record: record, key: keys.requestKey(record.Type)}
+		endpoint.entries = append(endpoint.entries, entry)
+		if record.Type != TraceAskForBid {
+			byExecution[keys.ExecutionID] = entry
+			continue
+		}
+		response, err := decodeKeys(record.Response)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s response recorded at %s: %w", record.Type, record.Time, err)
+		}
+		for _, shardResponse := range response.ShardResponse {
+			byExecution[shardResponse.ExecutionID] = entry
+		}
+	}
+	return endpoint, nil
+}
+
+// SetCallback sets the requester node the recorded callbacks are delivered to.
+func (e *ReplayEndpoint) SetCallback(callback compute.Callback) {
+	e.callback = callback
+}
+
+func (e *ReplayEndpoint) AskForBid(ctx context.Context, request compute.AskForBidRequest) (compute.AskForBidResponse, error) {
+	return replayRequest[compute.AskForBidResponse](ctx, e, askForBidKey(request.Job.Metadata.ID, request.TargetPeerID))
+}
+
+func (e *ReplayEndpoint) BidAccepted(ctx context.Context, request compute.BidAcceptedRequest) (compute.BidAcceptedResponse, error) {
+	return replayRequest[compute.BidAcceptedResponse](ctx, e,
+		executionKey(TraceBidAccepted, request.ExecutionID))
+}
+
+func (e *ReplayEndpoint) BidRejected(ctx context.Context, request compute.BidRejectedRequest) (compute.BidRejectedResponse, error) {
+	return replayRequest[compute.BidRejectedResponse](ctx, e,
+		executionKey(TraceBidRejected, request.ExecutionID))
+}
+
+func (e *ReplayEndpoint) ResultAccepted(
+	ctx context.Context, request compute.ResultAcceptedRequest) (compute.ResultAcceptedResponse, error) {
+	return replayRequest[compute.ResultAcceptedResponse](ctx, e,
+		executionKey(TraceResultAccepted, request.ExecutionID))
+}
+
+func (e *ReplayEndpoint) ResultRejected(
+	ctx context.Context, request compute.ResultRejectedRequest) (compute.ResultRejectedResponse, error) {
+	return replayRequest[compute.ResultRejectedResponse](ctx, e,
+		executionKey(TraceResultRejected, request.ExecutionID))
+}
+
+func (e *ReplayEndpoint) CancelExecution(
+	ctx context.Context, request compute.CancelExecutionRequest) (compute.CancelExecutionResponse, error) {
+	return replayRequest[compute.CancelExecutionResponse](ctx, e,
+		executionKey(TraceCancelExecution, request.ExecutionID))
+}
+
+func replayRequest[Response any](ctx context.Context, e *ReplayEndpoint, key string) (Response, error) {
+	var response Response
+	entry := e.next(key)
+	if entry == nil {
+		log.Ctx(ctx).Warn().Msgf("replay: %s was not recorded", key)
+		return response, fmt.Errorf("replay: %s was not recorded", key)
+	}
+	for _, callback := range entry.callbacks {
+		e.deliver(ctx, entry.record, callback)
+	}
+	if entry.record.Error != "" {
+		return response, errors.New(entry.record.Error)
+	}
+	if err := json.Unmarshal(entry.record.Response, &response); err != nil {
+		return response, fmt.Errorf("replay: failed to decode the response to %s: %w", key, err)
+	}
+	return response, nil
+}
+
+// next returns the first recorded request with the key that wasn't replayed yet, or nil if there is none.
+func (e *ReplayEndpoint) next(key string) *replayEntry {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lastActivity = time.Now()
+	for _, entry := range e.entries {
+		if !entry.replayed && entry.key == key {
+			entry.replayed = true
+			return entry
+		}
+	}
+	e.unexpected = append(e.unexpected, key)
+	return nil
+}
+
+// deliver delivers a recorded callback in the background, after the recorded delay since its request was answered.
+func (e *ReplayEndpoint) deliver(ctx context.Context, request TraceRecord, callback TraceRecord) {
+	answered := request.Time.Add(time.Duration(request.Latency))
+	delay := time.Duration(float64(callback.Time.Sub(answered)) * e.timeScale)
+	e.mu.Lock()
+	e.pendingCallbacks++
+	e.mu.Unlock()
+
+	// the requester doesn't wait for callbacks, so they outlive the request's context
+	ctx2 := log.Ctx(ctx).WithContext(context.Background())
+	go func() {
+		defer func() {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			e.pendingCallbacks--
+			e.lastActivity = time.Now()
+		}()
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+		if err := deliverCallback(ctx2, e.callback, callback); err != nil {
+			log.Ctx(ctx2).Error().Err(err).Msgf("replay: failed to deliver %s", callback.Type)
+		}
+	}()
+}
+
+func deliverCallback(ctx context.Context, callback compute.Callback, record TraceRecord) error {
+	switch record.Type {
+	case TraceOnRunComplete:
+		var result compute.RunResult
+		if err := json.Unmarshal(record.Request, &result); err != nil {
+			return err
+		}
+		callback.OnRunComplete(ctx, result)
+	case TraceOnPublishComplete:
+		var result compute.PublishResult
+		if err := json.Unmarshal(record.Request, &result); err != nil {
+			return err
+		}
+		callback.OnPublishComplete(ctx, result)
+	case TraceOnCancelComplete:
+		var result compute.CancelResult
+		if err := json.Unmarshal(record.Request, &result); err != nil {
+			return err
+		}
+		callback.OnCancelComplete(ctx, result)
+	case TraceOnComputeFailure:
+		var result compute.ComputeError
+		if err := json.Unmarshal(record.Request, &result); err != nil {
+			return err
+		}
+		callback.OnComputeFailure(ctx, result)
+	default:
+		return fmt.Errorf("%s is not a callback", record.Type)
+	}
+	return nil
+}
+
+// IdleFor returns how long ago the last request was answered or callback delivered, or zero while callbacks are
+// waiting to be delivered.
+func (e *ReplayEndpoint) IdleFor() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.pendingCallbacks > 0 {
+		return 0
+	}
+	return time.Since(e.lastActivity)
+}
+
+// Report compares the requests the requester sent so far with the recorded ones.
+func (e *ReplayEndpoint) Report() ReplayReport {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	report := ReplayReport{Unexpected: append([]string(nil), e.unexpected...)}
+	for _, entry := range e.entries {
+		if entry.replayed {
+			report.Replayed += 1 + len(entry.callbacks)
+		} else {
+			report.Missing = append(report.Missing, entry.key)
+		}
+	}
+	return report
+}
+
+// Compile-time interface check:
+var _ compute.Endpoint = (*ReplayEndpoint)(nil)
@@ -0,0 +1,216 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/eventhandler"
+	"github.com/filecoin-project/bacalhau/pkg/localdb"
+	"github.com/filecoin-project/bacalhau/pkg/localdb/inmemory"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/requester"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
+	verifier_util "github.com/filecoin-project/bacalhau/pkg/verifier/util"
+	"github.com/filecoin-project/bacalhau/pkg/version"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
+)
+
+const replayPollInterval = 100 * time.Millisecond
+
+type RequesterReplayParams struct {
+	// Host is the identity of the requester under replay. No messages are sent through it.
+	Host  host.Host
+	Trace []TraceRecord
+	// TimeScale multiplies the recorded delays between messages, so that zero replays the trace as fast as possible
+	TimeScale float64
+	// IdleTimeout ends the replay once all jobs started and no message was exchanged for this long
+	IdleTimeout                        time.Duration
+	JobNegotiationTimeout              time.Duration
+	StateManagerBackgroundTaskInterval time.Duration
+	// Recorder is optional and records the replay, to compare it with the recorded trace
+	Recorder *Recorder
+}
+
+// replayedJob is a job of a trace, and the compute nodes the requester asked to bid on it.
+type replayedJob struct {
+	job     model.Job
+	offset  time.Duration
+	nodeIDs []string
+}
+
+// ReplayRequester drives a requester's scheduler through a recorded trace: it submits the jobs of the trace at their
+// recorded times, and answers the scheduler's requests to compute nodes with the recorded responses and callbacks.
+// The scheduler under replay is the one built into this binary, so that new scheduler code can be run against a
+// production incident. Job events are stored in memory, and recorded if a Recorder is set.
+func ReplayRequester(ctx context.Context, cm *system.CleanupManager, params RequesterReplayParams) (ReplayReport, error) {
+	jobs, err := replayedJobs(params.Trace)
+	if err != nil {
+		return ReplayReport{}, err
+	}
+	endpoint, err := NewReplayEndpoint(ReplayEndpointParams{
+		Trace:     params.Trace,
+		TimeScale: params.TimeScale,
+	})
+	if err != nil {
+		return ReplayReport{}, err
+	}
+
+	jobStore, err := inmemory.NewInMemoryDatastore()
+	if err != nil {
+		return ReplayReport{}, err
+	}
+	verifiers, err := verifier_util.NewNoopVerifiers(ctx, cm, localdb.GetStateResolver(jobStore))
+	if err != nil {
+		return ReplayReport{}, err
+	}
+
+	eventConsumer := eventhandler.NewChainedJobEventHandler(system.NewNoopContextProvider())
+	eventConsumer.AddHandlers(localdb.NewLocalDBEventHandler(jobStore))
+	var computeEndpoint compute.Endpoint = endpoint
+	if params.Recorder != nil {
+		eventConsumer.AddHandlers(params.Recorder)
+		computeEndpoint = NewRecordingEndpoint(RecordingEndpointParams{Endpoint: endpoint, Recorder: params.Recorder})
+	}
+
+	discoverer := &replayNodeDiscoverer{jobs: jobs}
+	encrypter := verifier.NewEncrypter(params.Host.Peerstore().PrivKey(params.Host.ID()))
+	scheduler := requester.NewScheduler(ctx, cm, requester.SchedulerParams{
+		ID:              params.Host.ID().String(),
+		Host:            params.Host,
+		JobStore:        jobStore,
+		NodeDiscoverer:  discoverer,
+		NodeRanker:      discoverer,
+		ComputeEndpoint: computeEndpoint,
+		Verifiers:       verifiers,
+		EventEmitter: requester.NewEventEmitter(requester.EventEmitterParams{
+			EventConsumer: eventConsumer,
+		}),
+		Encrypter:                          encrypter.Seal,
+		Decrypter:                          encrypter.Unseal,
+		JobNegotiationTimeout:              params.JobNegotiationTimeout,
+		StateManagerBackgroundTaskInterval: params.StateManagerBackgroundTaskInterval,
+	})
+	var callback compute.Callback = scheduler
+	if params.Recorder != nil {
+		callback = NewRecordingCallback(RecordingCallbackParams{Callback: scheduler, Recorder: params.Recorder})
+	}
+	endpoint.SetCallback(callback)
+
+	var diverged []string
+	start := time.Now()
+	for _, job := range jobs {
+		wait := time.Until(start.Add(time.Duration(float64(job.offset) * params.TimeScale)))
+		if err = sleep(ctx, wait); err != nil {
+			break
+		}
+		log.Ctx(ctx).Debug().Msgf("replay: starting job %s", job.job.Metadata.ID)
+		if startErr := scheduler.StartJob(ctx, requester.StartJobRequest{Job: job.job}); startErr != nil {
+			diverged = append(diverged, fmt.Sprintf("job %s failed to start: %s", job.job.Metadata.ID, startErr))
+		}
+	}
+
+	ticker := time.NewTicker(replayPollInterval)
+	defer ticker.Stop()
+	for err == nil && endpoint.IdleFor() < params.IdleTimeout {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	report := endpoint.Report()
+	report.Diverged = append(diverged, report.Diverged...)
+	return report, err
+}
+
+// replayedJobs returns the jobs of a trace, from the first time the requester asked a compute node to bid on them,
+// in the order they started.
+func replayedJobs(trace []TraceRecord) ([]*replayedJob, error) {
+	var jobs []*replayedJob
+	byID := make(map[string]*replayedJob)
+	for _, record := range trace {
+		if record.Type != TraceAskForBid {
+			continue
+		}
+		var request compute.AskForBidRequest
+		if err := json.Unmarshal(record.Request, &request); err != nil {
+			return nil, fmt.Errorf("failed to decode %s recorded at %s: %w", record.Type, record.Time, err)
+		}
+		job, ok := byID[request.Job.Metadata.ID]
+		if !ok {
+			job = &replayedJob{job: request.Job, offset: record.Time.Sub(trace[0].Time)}
+			byID[request.Job.Metadata.ID] = job
+			jobs = append(jobs, job)
+		}
+		if !slices.Contains(job.nodeIDs, request.TargetPeerID) {
+			job.nodeIDs = append(job.nodeIDs, request.TargetPeerID)
+		}
+	}
+	return jobs, nil
+}
+
+// replayNodeDiscoverer finds the compute nodes the requester asked to bid on each job of a trace, and ranks them in
+// the order they were asked.
+type replayNodeDiscoverer struct {
+	jobs []*replayedJob
+}
+
+func (d *replayNodeDiscoverer) FindNodes(ctx context.Context, job model.Job) ([]model.NodeInfo, error) {
+	var nodes []model.NodeInfo
+	for _, replayed := range d.jobs {
+		if replayed.job.Metadata.ID != job.Metadata.ID {
+			continue
+		}
+		for _, nodeID := range replayed.nodeIDs {
+			peerID, err := peer.Decode(nodeID)
+			if err != nil {
+				return nil, fmt.Errorf("trace has invalid node ID %s: %w", nodeID, err)
+			}
+			nodes = append(nodes, model.NodeInfo{
+				PeerInfo: peer.AddrInfo{ID: peerID},
+				NodeType: model.NodeTypeCompute,
+				Versions: model.NodeVersions{
+					BuildVersion:    *version.Get(),
+					ProtocolVersion: model.ProtocolVersion,
+					APIVersions:     model.SupportedAPIVersions(),
+				},
+			})
+		}
+	}
+	return nodes, nil
+}
+
+func (d *replayNodeDiscoverer) RankNodes(ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]requester.NodeRank, error) {
+	ranks := make([]requester.NodeRank, len(nodes))
+	for i, node := range nodes {
+		ranks[i] = requester.NodeRank{NodeInfo: node, Rank: len(nodes) - i}
+	}
+	return ranks, nil
+}
+
+// sleep waits for the delay, or until the context is done.
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Compile-time interface checks:
+var _ requester.NodeDiscoverer = (*replayNodeDiscoverer)(nil)
+var _ requester.NodeRanker = (*replayNodeDiscoverer)(nil)
@@ -0,0 +1,323 @@
+//go:build unit || !integration
+
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	requesterID = "requester"
+	jobID       = "92d5d4ee-3765-4f78-8353-623f5f26df08"
+	executionID = "execution-id"
+)
+
+type ReplaySuite struct {
+	suite.Suite
+	start   time.Time
+	trace   []TraceRecord
+	compute *fakeComputeNode
+}
+
+func TestReplaySuite(t *testing.T) {
+	suite.Run(t, new(ReplaySuite))
+}
+
+func (s *ReplaySuite) SetupTest() {
+	s.start = time.Now()
+	s.trace = nil
+	s.compute = &fakeComputeNode{}
+}
+
+// record adds a message to the trace, sent at offset since the start of the trace.
+func (s *ReplaySuite) record(offset time.Duration, recordType TraceRecordType, request any, response any) {
+	record := TraceRecord{Time: s.start.Add(offset), NodeID: requesterID, Type: recordType}
+	var err error
+	record.Request, err = json.Marshal(request)
+	s.Require().NoError(err)
+	if response != nil {
+		record.Response, err = json.Marshal(response)
+		s.Require().NoError(err)
+	}
+	s.trace = append(s.trace, record)
+}
+
+// recordJob records the messages of a job running on a single compute node.
+func (s *ReplaySuite) recordJob(computeID string) {
+	toCompute := compute.RoutingMetadata{SourcePeerID: requesterID, TargetPeerID: computeID}
+	toRequester := compute.RoutingMetadata{SourcePeerID: computeID, TargetPeerID: requesterID}
+	execution := compute.ExecutionMetadata{ExecutionID: executionID, JobID: jobID}
+
+	s.record(0, TraceAskForBid, compute.AskForBidRequest{RoutingMetadata: toCompute, Job: noopJob(), ShardIndexes: []int{0}},
+		compute.AskForBidResponse{ShardResponse: []compute.AskForBidShardResponse{{ExecutionMetadata: execution, Accepted: true}}})
+	s.record(10*time.Millisecond, TraceBidAccepted, compute.BidAcceptedRequest{RoutingMetadata: toCompute, ExecutionID: executionID},
+		compute.BidAcceptedResponse{ExecutionMetadata: execution})
+	s.record(20*time.Millisecond, TraceOnRunComplete, compute.RunResult{RoutingMetadata: toRequester, ExecutionMetadata: execution}, nil)
+	s.record(30*time.Millisecond, TraceResultAccepted, compute.ResultAcceptedRequest{RoutingMetadata: toCompute, ExecutionID: executionID},
+		compute.ResultAcceptedResponse{ExecutionMetadata: execution})
+	s.record(40*time.Millisecond, TraceOnPublishComplete, compute.PublishResult{RoutingMetadata: toRequester, ExecutionMetadata: execution}, nil)
+}
+
+func noopJob() model.Job {
+	job := model.NewJob()
+	job.Metadata.ID = jobID
+	job.Spec = model.Spec{
+		Engine:        model.EngineNoop,
+		Verifier:      model.VerifierNoop,
+		Publisher:     model.PublisherNoop,
+		Deal:          model.Deal{Concurrency: 1},
+		ExecutionPlan: model.JobExecutionPlan{TotalShards: 1},
+	}
+	return *job
+}
+
+func (s *ReplaySuite) TestRecorder() {
+	var buffer bytes.Buffer
+	recorder := NewRecorder(RecorderParams{NodeID: requesterID, Writer: &buffer})
+	endpoint := NewRecordingEndpoint(RecordingEndpointParams{Endpoint: s.compute, Recorder: recorder})
+	callback := NewRecordingCallback(RecordingCallbackParams{Callback: s.compute, Recorder: recorder})
+
+	ctx := context.Background()
+	_, err := endpoint.AskForBid(ctx, compute.AskForBidRequest{Job: noopJob(), ShardIndexes: []int{0}})
+	s.Require().NoError(err)
+	s.compute.fail = true
+	_, err = endpoint.BidAccepted(ctx, compute.BidAcceptedRequest{ExecutionID: executionID})
+	s.Require().Error(err)
+	callback.OnComputeFailure(ctx, compute.ComputeError{Err: "boom"})
+	s.Require().NoError(recorder.HandleJobEvent(ctx, model.JobEvent{JobID: jobID, EventName: model.JobEventCreated, EventTime: time.Now()}))
+
+	trace, err := ReadTrace(&buffer)
+	s.Require().NoError(err)
+	s.Require().Len(trace, 4)
+
+	var types []TraceRecordType
+	for _, record := range trace {
+		s.Equal(requesterID, record.NodeID)
+		types = append(types, record.Type)
+	}
+	s.ElementsMatch([]TraceRecordType{TraceAskForBid, TraceBidAccepted, TraceOnComputeFailure, TraceJobEvent}, types)
+
+	var response compute.AskForBidResponse
+	s.Require().NoError(json.Unmarshal(trace[0].Response, &response))
+	s.Equal("replayed-0", response.ShardResponse[0].ExecutionID)
+	s.Equal("compute node failed", trace[1].Error)
+	s.Empty(trace[1].Response)
+}
+
+func (s *ReplaySuite) TestReadTraceOrdersBySendTime() {
+	s.record(20*time.Millisecond, TraceBidAccepted, compute.BidAcceptedRequest{ExecutionID: executionID}, nil)
+	s.record(0, TraceAskForBid, compute.AskForBidRequest{}, nil)
+
+	var buffer bytes.Buffer
+	for _, record := range s.trace {
+		line, err := json.Marshal(record)
+		s.Require().NoError(err)
+		buffer.Write(append(line, '\n'))
+	}
+	trace, err := ReadTrace(&buffer)
+	s.Require().NoError(err)
+	s.Equal(TraceAskForBid, trace[0].Type)
+	s.Equal(TraceBidAccepted, trace[1].Type)
+
+	_, err = ReadTrace(bytes.NewBufferString("not json\n"))
+	s.ErrorContains(err, "line 1")
+}
+
+func (s *ReplaySuite) TestReplayEndpoint() {
+	s.recordJob("compute")
+	endpoint, err := NewReplayEndpoint(ReplayEndpointParams{Trace: s.trace})
+	s.Require().NoError(err)
+	callback := &fakeComputeNode{}
+	endpoint.SetCallback(callback)
+
+	ctx := context.Background()
+	response, err := endpoint.AskForBid(ctx, compute.AskForBidRequest{
+		RoutingMetadata: compute.RoutingMetadata{TargetPeerID: "compute"},
+		Job:             noopJob(),
+	})
+	s.Require().NoError(err)
+	s.Equal(executionID, response.ShardResponse[0].ExecutionID)
+	s.True(response.ShardResponse[0].Accepted)
+
+	_, err = endpoint.BidAccepted(ctx, compute.BidAcceptedRequest{ExecutionID: executionID})
+	s.Require().NoError(err)
+	s.Eventually(func() bool { return callback.callbacks.Load() == 1 }, time.Second, 10*time.Millisecond)
+
+	// a second acceptance of the same bid was never recorded
+	_, err = endpoint.BidAccepted(ctx, compute.BidAcceptedRequest{ExecutionID: executionID})
+	s.ErrorContains(err, "was not recorded")
+
+	report := endpoint.Report()
+	s.False(report.Matched())
+	s.Equal(3, report.Replayed)
+	s.Equal([]string{executionKey(TraceBidAccepted, executionID)}, report.Unexpected)
+	s.Equal([]string{executionKey(TraceResultAccepted, executionID)}, report.Missing)
+}
+
+func (s *ReplaySuite) TestReplayRequester() {
+	mn := mocknet.New()
+	defer mn.Close()
+	requesterHost, err := mn.GenPeer()
+	s.Require().NoError(err)
+	computeHost, err := mn.GenPeer()
+	s.Require().NoError(err)
+	s.recordJob(computeHost.ID().String())
+
+	var buffer bytes.Buffer
+	cm := system.NewCleanupManager()
+	defer cm.Cleanup()
+	report, err := ReplayRequester(context.Background(), cm, RequesterReplayParams{
+		Host:                               requesterHost,
+		Trace:                              s.trace,
+		IdleTimeout:                        500 * time.Millisecond,
+		JobNegotiationTimeout:              time.Minute,
+		StateManagerBackgroundTaskInterval: time.Minute,
+		Recorder:                           NewRecorder(RecorderParams{NodeID: requesterHost.ID().String(), Writer: &buffer}),
+	})
+	s.Require().NoError(err)
+	s.True(report.Matched(), "%+v", report)
+	s.Equal(5, report.Replayed)
+
+	replayed, err := ReadTrace(&buffer)
+	s.Require().NoError(err)
+	var events []model.JobEventType
+	for _, record := range replayed {
+		if record.Type == TraceJobEvent {
+			var event model.JobEvent
+			s.Require().NoError(json.Unmarshal(record.Request, &event))
+			events = append(events, event.EventName)
+		}
+	}
+	s.Contains(events, model.JobEventResultsPublished)
+}
+
+func (s *ReplaySuite) TestReplayCompute() {
+	s.recordJob("compute")
+	replayer := NewComputeReplayer(ComputeReplayParams{
+		NodeID:      "replayed",
+		Endpoint:    s.compute,
+		Trace:       s.trace,
+		IdleTimeout: 100 * time.Millisecond,
+	})
+	s.compute.callback = replayer
+
+	report, err := replayer.Replay(context.Background())
+	s.Require().NoError(err)
+	s.True(report.Matched(), "%+v", report)
+	s.Equal(5, report.Replayed)
+	s.Equal([]string{"replayed-0", "replayed-0"}, s.compute.executionIDs)
+}
+
+func (s *ReplaySuite) TestReplayComputeDiverges() {
+	s.recordJob("compute")
+	s.compute.rejectBids = true
+	replayer := NewComputeReplayer(ComputeReplayParams{
+		NodeID:      "replayed",
+		Endpoint:    s.compute,
+		Trace:       s.trace,
+		IdleTimeout: 100 * time.Millisecond,
+	})
+	s.compute.callback = replayer
+
+	report, err := replayer.Replay(context.Background())
+	s.Require().NoError(err)
+	s.False(report.Matched())
+	s.Len(report.Diverged, 3)
+	s.Equal([]string{
+		executionKey(TraceOnRunComplete, executionID),
+		executionKey(TraceOnPublishComplete, executionID),
+	}, report.Missing)
+}
+
+func TestComputeReplayNeedsNode(t *testing.T) {
+	trace := []TraceRecord{
+		{Type: TraceAskForBid, Request: json.RawMessage(`{"TargetPeerID":"a"}`)},
+		{Type: TraceAskForBid, Request: json.RawMessage(`{"TargetPeerID":"b"}`)},
+	}
+	_, err := NewComputeReplayer(ComputeReplayParams{Trace: trace}).Replay(context.Background())
+	require.ErrorContains(t, err, "2 compute nodes")
+}
+
+// fakeComputeNode answers requests like a compute node with new execution IDs, and delivers the callbacks a compute
+// node would send after them.
+type fakeComputeNode struct {
+	callback     compute.Callback
+	fail         bool
+	rejectBids   bool
+	executionIDs []string
+	callbacks    atomic.Int32
+}
+
+func (n *fakeComputeNode) AskForBid(_ context.Context, request compute.AskForBidRequest) (compute.AskForBidResponse, error) {
+	var response compute.AskForBidResponse
+	for _, shardIndex := range request.ShardIndexes {
+		response.ShardResponse = append(response.ShardResponse, compute.AskForBidShardResponse{
+			ExecutionMetadata: compute.ExecutionMetadata{
+				ExecutionID: fmt.Sprintf("replayed-%d", shardIndex),
+				JobID:       request.Job.Metadata.ID,
+				ShardIndex:  shardIndex,
+			},
+			Accepted: !n.rejectBids,
+		})
+	}
+	return response, nil
+}
+
+func (n *fakeComputeNode) BidAccepted(ctx context.Context, request compute.BidAcceptedRequest) (compute.BidAcceptedResponse, error) {
+	if n.fail || n.rejectBids {
+		return compute.BidAcceptedResponse{}, errors.New("compute node failed")
+	}
+	n.executionIDs = append(n.executionIDs, request.ExecutionID)
+	n.callback.OnRunComplete(ctx, compute.RunResult{
+		RoutingMetadata:   compute.RoutingMetadata{TargetPeerID: request.SourcePeerID},
+		ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: request.ExecutionID},
+	})
+	return compute.BidAcceptedResponse{}, nil
+}
+
+func (n *fakeComputeNode) BidRejected(context.Context, compute.BidRejectedRequest) (compute.BidRejectedResponse, error) {
+	return compute.BidRejectedResponse{}, nil
+}
+
+func (n *fakeComputeNode) ResultAccepted(
+	ctx context.Context, request compute.ResultAcceptedRequest) (compute.ResultAcceptedResponse, error) {
+	if n.rejectBids {
+		return compute.ResultAcceptedResponse{}, errors.New("unknown execution")
+	}
+	n.executionIDs = append(n.executionIDs, request.ExecutionID)
+	n.callback.OnPublishComplete(ctx, compute.PublishResult{
+		RoutingMetadata:   compute.RoutingMetadata{TargetPeerID: request.SourcePeerID},
+		ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: request.ExecutionID},
+	})
+	return compute.ResultAcceptedResponse{}, nil
+}
+
+func (n *fakeComputeNode) ResultRejected(context.Context, compute.ResultRejectedRequest) (compute.ResultRejectedResponse, error) {
+	return compute.ResultRejectedResponse{}, nil
+}
+
+func (n *fakeComputeNode) CancelExecution(
+	context.Context, compute.CancelExecutionRequest) (compute.CancelExecutionResponse, error) {
+	return compute.CancelExecutionResponse{}, nil
+}
+
+func (n *fakeComputeNode) OnRunComplete(context.Context, compute.RunResult) { n.callbacks.Add(1) }
+func (n *fakeComputeNode) OnPublishComplete(context.Context, compute.PublishResult) {
+	n.callbacks.Add(1)
+}
+func (n *fakeComputeNode) OnCancelComplete(context.Context, compute.CancelResult) { n.callbacks.Add(1) }
+func (n *fakeComputeNode) OnComputeFailure(context.Context, compute.ComputeError) { n.callbacks.Add(1) }
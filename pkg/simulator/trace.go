@@ -0,0 +1,199 @@
+package simulator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/rs/zerolog/log"
+)
+
+// TraceRecordType is the kind of message a trace record holds.
+type TraceRecordType string
+
+const (
+	// requests sent to compute nodes
+	TraceAskForBid       TraceRecordType = "AskForBid"
+	TraceBidAccepted     TraceRecordType = "BidAccepted"
+	TraceBidRejected     TraceRecordType = "BidRejected"
+	TraceResultAccepted  TraceRecordType = "ResultAccepted"
+	TraceResultRejected  TraceRecordType = "ResultRejected"
+	TraceCancelExecution TraceRecordType = "CancelExecution"
+
+	// callbacks sent to requester nodes
+	TraceOnRunComplete     TraceRecordType = "OnRunComplete"
+	TraceOnPublishComplete TraceRecordType = "OnPublishComplete"
+	TraceOnCancelComplete  TraceRecordType = "OnCancelComplete"
+	TraceOnComputeFailure  TraceRecordType = "OnComputeFailure"
+
+	// events of the jobs of requester nodes
+	TraceJobEvent TraceRecordType = "JobEvent"
+)
+
+// IsRequest returns true if the record holds a request sent to a compute node.
+func (t TraceRecordType) IsRequest() bool {
+	switch t {
+	case TraceAskForBid, TraceBidAccepted, TraceBidRejected, TraceResultAccepted, TraceResultRejected, TraceCancelExecution:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsCallback returns true if the record holds a callback sent to a requester node.
+func (t TraceRecordType) IsCallback() bool {
+	switch t {
+	case TraceOnRunComplete, TraceOnPublishComplete, TraceOnCancelComplete, TraceOnComputeFailure:
+		return true
+	default:
+		return false
+	}
+}
+
+// TraceRecord is a single line of a trace.
+type TraceRecord struct {
+	// Time the request or callback was sent, or the job event was emitted
+	Time time.Time `json:"Time"`
+	// NodeID is the node that recorded the message
+	NodeID string          `json:"NodeID"`
+	Type   TraceRecordType `json:"Type"`
+	// Request is the request, callback or job event
+	Request json.RawMessage `json:"Request"`
+	// Response is only set for requests that were answered
+	Response json.RawMessage `json:"Response,omitempty"`
+	// Latency is how long the compute node took to answer the request
+	Latency model.Duration `json:"Latency,omitempty"`
+	// Error is set for requests that failed
+	Error string `json:"Error,omitempty"`
+}
+
+type RecorderParams struct {
+	// NodeID is the node the recorder records the messages of
+	NodeID string
+	Writer io.Writer
+}
+
+// Recorder writes the requests and callbacks a node exchanges with other nodes, and the events of its jobs, to a
+// trace with one JSON record per line.
+type Recorder struct {
+	nodeID string
+	mu     sync.Mutex
+	writer io.Writer
+	closer io.Closer
+}
+
+func NewRecorder(params RecorderParams) *Recorder {
+	return &Recorder{
+		nodeID: params.NodeID,
+		writer: params.Writer,
+	}
+}
+
+// NewFileRecorder creates a recorder that writes the trace to a new file at path.
+func NewFileRecorder(nodeID, path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace file: %w", err)
+	}
+	recorder := NewRecorder(RecorderParams{NodeID: nodeID, Writer: file})
+	recorder.closer = file
+	return recorder, nil
+}
+
+// Close closes the file of recorders created by NewFileRecorder.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// HandleJobEvent records the job events of the node, and implements eventhandler.JobEventHandler.
+func (r *Recorder) HandleJobEvent(ctx context.Context, event model.JobEvent) error {
+	r.record(ctx, TraceJobEvent, event.EventTime, event, nil, 0, nil)
+	return nil
+}
+
+func (r *Recorder) record(
+	ctx context.Context,
+	recordType TraceRecordType,
+	sent time.Time,
+	request any,
+	response any,
+	latency time.Duration,
+	err error) {
+	record := TraceRecord{
+		Time:    sent,
+		NodeID:  r.nodeID,
+		Type:    recordType,
+		Latency: model.Duration(latency),
+	}
+	var marshalErr error
+	record.Request, marshalErr = json.Marshal(request)
+	if marshalErr == nil && response != nil && err == nil {
+		record.Response, marshalErr = json.Marshal(response)
+	}
+	if marshalErr != nil {
+		log.Ctx(ctx).Error().Err(marshalErr).Msgf("failed to record %s", recordType)
+		return
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	line, marshalErr := json.Marshal(record)
+	if marshalErr != nil {
+		log.Ctx(ctx).Error().Err(marshalErr).Msgf("failed to record %s", recordType)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, writeErr := r.writer.Write(append(line, '\n')); writeErr != nil {
+		log.Ctx(ctx).Error().Err(writeErr).Msgf("failed to record %s", recordType)
+	}
+}
+
+// ReadTrace reads the records of a trace, in the order the messages were sent. Requests are only recorded once
+// they are answered, so that order can differ from the order of the lines.
+func ReadTrace(reader io.Reader) ([]TraceRecord, error) {
+	var records []TraceRecord
+	scanner := bufio.NewScanner(reader)
+	// records carry whole jobs, which can be larger than the default token size
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024) //nolint:gomnd
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record TraceRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("line %d of trace: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records, nil
+}
+
+// LoadTrace reads the records of the trace file at path.
+func LoadTrace(path string) ([]TraceRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadTrace(file)
+}
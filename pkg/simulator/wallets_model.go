@@ -2,6 +2,7 @@ package simulator
 
 import (
 	"fmt"
+	"math"
 	"sync"
 	"time"
 
@@ -19,6 +20,9 @@ import (
 
 const MinWallet = 100 //nolint:gomnd
 
+// what a client escrows for a bid that wasn't priced
+const defaultEscrowAmount = 33
+
 type walletsModel struct {
 	// keep track of which wallet address "owns" which job
 	// the "ClientID" is only submitted for the create event
@@ -111,9 +115,13 @@ func (wallets *walletsModel) addEvent(event model.JobEvent) error {
 		event = fromClient(event)
 		client, _ := wallets.jobOwners.Get(event.JobID)
 		server := event.TargetNodeID
-		// TODO: price in job spec! verify price per hour!
+		// escrow the price agreed for the bid, or a flat fee for nodes that don't charge
+		amount := int64(defaultEscrowAmount)
+		if event.Price > 0 {
+			amount = int64(math.Ceil(event.Price))
+		}
 		// TODO: the client itself should escrow the funds, not the smart contract?
-		err := wallets.escrowFunds(client, server, event.JobID, 33) //nolint:gomnd
+		err := wallets.escrowFunds(client, server, event.JobID, amount)
 		if err != nil {
 			return err
 		}
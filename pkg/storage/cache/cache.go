@@ -0,0 +1,395 @@
+// The `cache` package provides a content-addressed, size-bounded cache of
+// prepared input volumes that is shared by all of the storage drivers on a
+// compute node.
+//
+// Entries are identified by a key describing the data (e.g. an IPFS CID or a
+// URL) and a validator that changes whenever the data behind the key does
+// (e.g. the ETag of a URL). Each entry is stored as a directory named after a
+// hash of both, so a changed validator produces a new entry rather than
+// overwriting one that is in use.
+//
+// Entries are reference counted while shards are using them and are only
+// evicted, least recently used first, once they are no longer referenced and
+// the cache is over its size limit. A cache with a zero size limit evicts
+// entries as soon as they are released, which keeps the previous behaviour of
+// downloading inputs for every shard while still sharing a download between
+// shards that run concurrently.
+//
+// The index of entries is persisted next to the data so that a node keeps its
+// warm cache across restarts.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/config"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/storage/util"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	metadataSuffix = ".json"
+	tempPrefix     = "tmp-"
+)
+
+// FillFunc populates the given empty directory with the data for an entry.
+type FillFunc func(ctx context.Context, dir string) error
+
+type entryMetadata struct {
+	Key       string    `json:"Key"`
+	Validator string    `json:"Validator,omitempty"`
+	Size      uint64    `json:"Size"`
+	LastUsed  time.Time `json:"LastUsed"`
+}
+
+type entry struct {
+	entryMetadata
+	id      string
+	refs    int
+	element *list.Element
+}
+
+type fill struct {
+	done chan struct{}
+	err  error
+}
+
+type Cache struct {
+	dir     string
+	maxSize uint64
+
+	mu       sync.Mutex
+	size     uint64
+	entries  map[string]*entry
+	keys     map[string]string
+	lru      *list.List
+	inflight map[string]*fill
+}
+
+// NewCache opens the cache stored in dir, creating it if needed, and evicts
+// entries until it fits within maxSize bytes.
+func NewCache(dir string, maxSize uint64) (*Cache, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	c := &Cache{
+		dir:      dir,
+		maxSize:  maxSize,
+		entries:  map[string]*entry{},
+		keys:     map[string]string{},
+		lru:      list.New(),
+		inflight: map[string]*fill{},
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict()
+
+	log.Debug().Msgf("Input cache opened at %s with %d entries (%d of %d bytes)", dir, len(c.entries), c.size, maxSize)
+	return c, nil
+}
+
+// NewTemporaryCache returns a cache in a new directory under the storage
+// path that is removed when the cleanup manager runs.
+func NewTemporaryCache(cm *system.CleanupManager, maxSize uint64) (*Cache, error) {
+	dir, err := os.MkdirTemp(config.GetStoragePath(), "bacalhau-input-cache")
+	if err != nil {
+		return nil, err
+	}
+	cm.RegisterCallback(func() error {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("unable to remove input cache folder: %w", err)
+		}
+		return nil
+	})
+	return NewCache(dir, maxSize)
+}
+
+// Key returns the cache key used for data from a storage source, where id
+// uniquely identifies the data within the source (e.g. a CID or URL).
+func Key(source model.StorageSourceType, id string) string {
+	return strings.ToLower(source.String()) + "/" + id
+}
+
+// Has returns true if there is an entry for the key, regardless of its
+// validator.
+func (c *Cache) Has(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.keys[key]
+	return ok
+}
+
+// Acquire returns the directory holding the entry for the key and validator,
+// calling fillFunc to populate it if it is not yet cached. Concurrent callers
+// for the same entry wait for a single fill. The entry will not be evicted
+// until Release is called for every successful Acquire.
+func (c *Cache) Acquire(ctx context.Context, key, validator string, fillFunc FillFunc) (string, error) {
+	id := entryID(key, validator)
+	for {
+		c.mu.Lock()
+		if e, ok := c.entries[id]; ok {
+			e.refs++
+			c.touch(e)
+			c.mu.Unlock()
+			return c.entryPath(id), nil
+		}
+		if f, ok := c.inflight[id]; ok {
+			c.mu.Unlock()
+			select {
+			case <-f.done:
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+			if f.err != nil {
+				return "", f.err
+			}
+			continue
+		}
+		f := &fill{done: make(chan struct{})}
+		c.inflight[id] = f
+		c.mu.Unlock()
+
+		var size uint64
+		size, f.err = c.populate(ctx, id, fillFunc)
+
+		c.mu.Lock()
+		delete(c.inflight, id)
+		if f.err == nil {
+			c.add(&entry{
+				entryMetadata: entryMetadata{Key: key, Validator: validator, Size: size, LastUsed: time.Now()},
+				id:            id,
+				refs:          1,
+			})
+		}
+		c.mu.Unlock()
+		close(f.done)
+
+		if f.err != nil {
+			return "", f.err
+		}
+		return c.entryPath(id), nil
+	}
+}
+
+// Release drops a reference to the entry containing path, which may be the
+// directory returned by Acquire or any path within it.
+func (c *Cache) Release(path string) error {
+	rel, err := filepath.Rel(c.dir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("path %s is not within the input cache", path)
+	}
+	id := strings.Split(filepath.ToSlash(rel), "/")[0]
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[id]
+	if !ok || e.refs == 0 {
+		return fmt.Errorf("path %s is not an acquired input cache entry", path)
+	}
+	e.refs--
+	c.touch(e)
+	if err := c.writeMetadata(e); err != nil {
+		log.Warn().Err(err).Msgf("failed to persist input cache entry %s", e.Key)
+	}
+	c.evict()
+	return nil
+}
+
+// Keys returns up to limit keys, most recently used first.
+func (c *Cache) Keys(limit int) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, system.Min(limit, c.lru.Len()))
+	seen := map[string]bool{}
+	for element := c.lru.Front(); element != nil && len(keys) < limit; element = element.Next() {
+		e := element.Value.(*entry)
+		if !seen[e.Key] {
+			seen[e.Key] = true
+			keys = append(keys, e.Key)
+		}
+	}
+	return keys
+}
+
+// Size returns the total size in bytes of all cached entries.
+func (c *Cache) Size() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+func (c *Cache) populate(ctx context.Context, id string, fillFunc FillFunc) (uint64, error) {
+	tempDir, err := os.MkdirTemp(c.dir, tempPrefix)
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err = fillFunc(ctx, tempDir); err != nil {
+		return 0, err
+	}
+	size, err := util.DirSize(tempDir)
+	if err != nil {
+		return 0, err
+	}
+	if err = os.Rename(tempDir, c.entryPath(id)); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// add must be called with the lock held
+func (c *Cache) add(e *entry) {
+	// a changed validator means any older entry for the key is stale
+	if oldID, ok := c.keys[e.Key]; ok && oldID != e.id {
+		if old := c.entries[oldID]; old != nil && old.refs == 0 {
+			c.remove(old)
+		}
+	}
+	e.element = c.lru.PushFront(e)
+	c.entries[e.id] = e
+	c.keys[e.Key] = e.id
+	c.size += e.Size
+	if err := c.writeMetadata(e); err != nil {
+		log.Warn().Err(err).Msgf("failed to persist input cache entry %s", e.Key)
+	}
+	c.evict()
+}
+
+// evict must be called with the lock held
+func (c *Cache) evict() {
+	// with no space at all nothing is kept once it is no longer in use
+	element := c.lru.Back()
+	for (c.size > c.maxSize || c.maxSize == 0) && element != nil {
+		e := element.Value.(*entry)
+		element = element.Prev()
+		if e.refs == 0 {
+			c.remove(e)
+		}
+	}
+}
+
+// remove must be called with the lock held
+func (c *Cache) remove(e *entry) {
+	log.Trace().Msgf("Evicting input cache entry %s", e.Key)
+	c.lru.Remove(e.element)
+	delete(c.entries, e.id)
+	if c.keys[e.Key] == e.id {
+		delete(c.keys, e.Key)
+		// fall back to an older entry for the same key if there is one
+		for _, other := range c.entries {
+			if other.Key == e.Key {
+				c.keys[e.Key] = other.id
+				break
+			}
+		}
+	}
+	c.size -= e.Size
+	if err := os.Remove(c.entryPath(e.id) + metadataSuffix); err != nil && !os.IsNotExist(err) {
+		log.Warn().Err(err).Msgf("failed to remove input cache metadata for %s", e.Key)
+	}
+	if err := os.RemoveAll(c.entryPath(e.id)); err != nil {
+		log.Warn().Err(err).Msgf("failed to remove input cache entry %s", e.Key)
+	}
+}
+
+// touch must be called with the lock held
+func (c *Cache) touch(e *entry) {
+	e.LastUsed = time.Now()
+	c.lru.MoveToFront(e.element)
+}
+
+func (c *Cache) load() error {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	var loaded []*entry
+	for _, file := range files {
+		name := file.Name()
+		path := filepath.Join(c.dir, name)
+		if strings.HasPrefix(name, tempPrefix) {
+			// left over from a fill that was interrupted
+			if err = os.RemoveAll(path); err != nil {
+				return err
+			}
+			continue
+		}
+		if !strings.HasSuffix(name, metadataSuffix) {
+			continue
+		}
+		id := strings.TrimSuffix(name, metadataSuffix)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		e := &entry{id: id}
+		if err = json.Unmarshal(data, &e.entryMetadata); err != nil || entryID(e.Key, e.Validator) != id {
+			log.Warn().Msgf("ignoring invalid input cache metadata %s", path)
+			continue
+		}
+		if ok, _ := system.PathExists(c.entryPath(id)); !ok {
+			continue
+		}
+		loaded = append(loaded, e)
+	}
+
+	// remove any data that isn't referenced by the index
+	for _, file := range files {
+		name := file.Name()
+		if !file.IsDir() || strings.HasPrefix(name, tempPrefix) {
+			continue
+		}
+		if ok, _ := system.PathExists(filepath.Join(c.dir, name+metadataSuffix)); !ok {
+			if err = os.RemoveAll(filepath.Join(c.dir, name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].LastUsed.Before(loaded[j].LastUsed)
+	})
+	for _, e := range loaded {
+		e.element = c.lru.PushFront(e)
+		c.entries[e.id] = e
+		c.keys[e.Key] = e.id
+		c.size += e.Size
+	}
+	return nil
+}
+
+func (c *Cache) writeMetadata(e *entry) error {
+	data, err := json.Marshal(e.entryMetadata)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.entryPath(e.id)+metadataSuffix, data, 0600) //nolint:gomnd
+}
+
+func (c *Cache) entryPath(id string) string {
+	return filepath.Join(c.dir, id)
+}
+
+func entryID(key, validator string) string {
+	hash := sha256.Sum256([]byte(key + "\x00" + validator))
+	return hex.EncodeToString(hash[:])
+}
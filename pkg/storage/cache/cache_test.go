@@ -0,0 +1,183 @@
+//go:build unit || !integration
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/filecoin-project/bacalhau/pkg/logger"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type CacheSuite struct {
+	suite.Suite
+	ctx   context.Context
+	dir   string
+	fills int
+}
+
+func TestCacheSuite(t *testing.T) {
+	suite.Run(t, new(CacheSuite))
+}
+
+func (s *CacheSuite) SetupTest() {
+	logger.ConfigureTestLogging(s.T())
+	s.ctx = context.Background()
+	s.dir = s.T().TempDir()
+	s.fills = 0
+}
+
+// fillWith returns a FillFunc that writes size bytes to a single file
+func (s *CacheSuite) fillWith(size int) FillFunc {
+	return func(_ context.Context, dir string) error {
+		s.fills++
+		return os.WriteFile(filepath.Join(dir, "data"), make([]byte, size), 0644)
+	}
+}
+
+func (s *CacheSuite) acquire(c *Cache, key, validator string, size int) string {
+	path, err := c.Acquire(s.ctx, key, validator, s.fillWith(size))
+	require.NoError(s.T(), err)
+	require.FileExists(s.T(), filepath.Join(path, "data"))
+	return path
+}
+
+func (s *CacheSuite) TestKey() {
+	require.Equal(s.T(), "ipfs/Qm123", Key(model.StorageSourceIPFS, "Qm123"))
+}
+
+func (s *CacheSuite) TestReuse() {
+	c, err := NewCache(s.dir, 100)
+	require.NoError(s.T(), err)
+
+	path := s.acquire(c, "a", "", 10)
+	require.NoError(s.T(), c.Release(filepath.Join(path, "data")))
+	require.True(s.T(), c.Has("a"))
+	require.Equal(s.T(), uint64(10), c.Size())
+
+	require.Equal(s.T(), path, s.acquire(c, "a", "", 10))
+	require.Equal(s.T(), 1, s.fills)
+}
+
+func (s *CacheSuite) TestValidatorChange() {
+	c, err := NewCache(s.dir, 100)
+	require.NoError(s.T(), err)
+
+	first := s.acquire(c, "a", "v1", 10)
+	require.NoError(s.T(), c.Release(first))
+
+	second := s.acquire(c, "a", "v2", 20)
+	require.NotEqual(s.T(), first, second)
+	require.Equal(s.T(), 2, s.fills)
+	require.NoDirExists(s.T(), first, "stale entry should be removed")
+	require.Equal(s.T(), uint64(20), c.Size())
+	require.NoError(s.T(), c.Release(second))
+}
+
+func (s *CacheSuite) TestEvictLeastRecentlyUsed() {
+	c, err := NewCache(s.dir, 25)
+	require.NoError(s.T(), err)
+
+	for _, key := range []string{"a", "b"} {
+		require.NoError(s.T(), c.Release(s.acquire(c, key, "", 10)))
+	}
+	// use a again so b is the least recently used
+	require.NoError(s.T(), c.Release(s.acquire(c, "a", "", 10)))
+	require.NoError(s.T(), c.Release(s.acquire(c, "c", "", 10)))
+
+	require.True(s.T(), c.Has("a"))
+	require.False(s.T(), c.Has("b"))
+	require.True(s.T(), c.Has("c"))
+	require.Equal(s.T(), []string{"c", "a"}, c.Keys(10))
+	require.Equal(s.T(), []string{"c"}, c.Keys(1))
+}
+
+func (s *CacheSuite) TestReferencedEntriesAreKept() {
+	c, err := NewCache(s.dir, 0)
+	require.NoError(s.T(), err)
+
+	path := s.acquire(c, "a", "", 10)
+	require.Equal(s.T(), path, s.acquire(c, "a", "", 10))
+	require.Equal(s.T(), 1, s.fills)
+
+	require.NoError(s.T(), c.Release(path))
+	require.DirExists(s.T(), path, "entry is still in use")
+	require.NoError(s.T(), c.Release(path))
+	require.NoDirExists(s.T(), path)
+	require.False(s.T(), c.Has("a"))
+
+	require.Error(s.T(), c.Release(path))
+	require.Error(s.T(), c.Release(s.T().TempDir()))
+}
+
+func (s *CacheSuite) TestConcurrentAcquire() {
+	c, err := NewCache(s.dir, 100)
+	require.NoError(s.T(), err)
+
+	var mu sync.Mutex
+	fills := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path, err := c.Acquire(s.ctx, "a", "", func(_ context.Context, dir string) error {
+				mu.Lock()
+				fills++
+				mu.Unlock()
+				return os.WriteFile(filepath.Join(dir, "data"), []byte("data"), 0644)
+			})
+			require.NoError(s.T(), err)
+			require.NoError(s.T(), c.Release(path))
+		}()
+	}
+	wg.Wait()
+	require.Equal(s.T(), 1, fills)
+}
+
+func (s *CacheSuite) TestFailedFill() {
+	c, err := NewCache(s.dir, 100)
+	require.NoError(s.T(), err)
+
+	_, err = c.Acquire(s.ctx, "a", "", func(context.Context, string) error {
+		return fmt.Errorf("failed")
+	})
+	require.Error(s.T(), err)
+	require.False(s.T(), c.Has("a"))
+
+	entries, err := os.ReadDir(s.dir)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), entries, "partial fills should be removed")
+}
+
+func (s *CacheSuite) TestPersisted() {
+	c, err := NewCache(s.dir, 100)
+	require.NoError(s.T(), err)
+	path := s.acquire(c, "a", "v1", 10)
+	require.NoError(s.T(), c.Release(path))
+
+	// leftovers from an interrupted fill should be cleaned up
+	require.NoError(s.T(), os.Mkdir(filepath.Join(s.dir, tempPrefix+"123"), os.ModePerm))
+
+	c, err = NewCache(s.dir, 100)
+	require.NoError(s.T(), err)
+	require.True(s.T(), c.Has("a"))
+	require.Equal(s.T(), uint64(10), c.Size())
+	require.Equal(s.T(), path, s.acquire(c, "a", "v1", 10))
+	require.Equal(s.T(), 1, s.fills)
+	require.NoDirExists(s.T(), filepath.Join(s.dir, tempPrefix+"123"))
+
+	// reopening with a smaller limit should evict what no longer fits
+	require.NoError(s.T(), c.Release(path))
+	c, err = NewCache(s.dir, 5)
+	require.NoError(s.T(), err)
+	require.False(s.T(), c.Has("a"))
+	require.Equal(s.T(), uint64(0), c.Size())
+}
@@ -0,0 +1,456 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/filecoin-project/bacalhau/pkg/config"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/storage"
+	"github.com/filecoin-project/bacalhau/pkg/storage/cache"
+	"github.com/filecoin-project/bacalhau/pkg/storage/util"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/util/publicaddr"
+	"github.com/rs/zerolog/log"
+)
+
+// a storage driver that shallow clones a git repository
+// at a given ref or commit into the node's input cache in
+// preparation for a job to run - checkouts are cached by
+// commit hash so that many shards (or jobs) using the same
+// commit only clone it once
+
+const (
+	// MetadataRef is the StorageSpec.Metadata key holding the branch, tag or
+	// commit hash to check out. Defaults to the remote HEAD when not set.
+	MetadataRef = "ref"
+
+	// MetadataSubpath is the StorageSpec.Metadata key holding the path
+	// within the repository that should be mounted. Defaults to the
+	// root of the repository when not set.
+	MetadataSubpath = "subpath"
+
+	defaultRef = "HEAD"
+
+	// allowedProtocols are the only transports git may use for remote
+	// operations, so that remote helpers like ext:: and fd:: and local
+	// repositories can never be reached through a job's input URL
+	allowedProtocols = "https:ssh:git"
+)
+
+var commitHashRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// scp-like syntax used by ssh remotes e.g. git@github.com:org/repo.git
+var scpLikeURLRegex = regexp.MustCompile(`^(?:[\w.-]+@)?([\w.-]+):([^/\\:][^:]*)$`)
+
+type StorageProvider struct {
+	Cache *cache.Cache
+
+	// allowLocalRepos lets tests clone file:// URLs
+	allowLocalRepos bool
+}
+
+func NewStorage(cm *system.CleanupManager, inputCache *cache.Cache) (*StorageProvider, error) {
+	storageHandler := &StorageProvider{
+		Cache: inputCache,
+	}
+
+	log.Debug().Msg("Git driver created")
+	return storageHandler, nil
+}
+
+func (sp *StorageProvider) IsInstalled(context.Context) (bool, error) {
+	_, err := exec.LookPath("git")
+	return err == nil, nil
+}
+
+// We can only know that a checkout is local without talking to the remote
+// when the spec pins an exact commit.
+func (sp *StorageProvider) HasStorageLocally(_ context.Context, spec model.StorageSpec) (bool, error) {
+	key, ok := CacheKey(spec)
+	if !ok {
+		return false, nil
+	}
+	return sp.Cache.Has(key), nil
+}
+
+// The size of a repository is only known once it has been cloned, so this
+// will populate the cache - PrepareStorage will then reuse the checkout if
+// the cache is large enough to keep it.
+func (sp *StorageProvider) GetVolumeSize(ctx context.Context, spec model.StorageSpec) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.GetVolumeSizeRequestTimeout(ctx))
+	defer cancel()
+
+	sourcePath, err := sp.checkout(ctx, spec)
+	if err != nil {
+		return 0, err
+	}
+	defer sp.release(ctx, sourcePath)
+	return util.DirSize(sourcePath)
+}
+
+func (sp *StorageProvider) PrepareStorage(ctx context.Context, spec model.StorageSpec) (storage.StorageVolume, error) {
+	ctx, span := system.GetTracer().Start(ctx, "pkg/storage/git.PrepareStorage")
+	defer span.End()
+
+	var cancel context.CancelFunc
+	ctx, cancel = context.WithTimeout(ctx, config.GetDownloadCidRequestTimeout(ctx))
+	defer cancel()
+
+	sourcePath, err := sp.checkout(ctx, spec)
+	if err != nil {
+		return storage.StorageVolume{}, err
+	}
+
+	return storage.StorageVolume{
+		Type:   storage.StorageVolumeConnectorBind,
+		Source: sourcePath,
+		Target: spec.Path,
+	}, nil
+}
+
+// Checkouts are shared between shards using the same commit, so they are
+// released back to the cache rather than removed.
+func (sp *StorageProvider) CleanupStorage(_ context.Context, _ model.StorageSpec, volume storage.StorageVolume) error {
+	return sp.Cache.Release(volume.Source)
+}
+
+// we don't "upload" anything to a git repository
+func (sp *StorageProvider) Upload(context.Context, string) (model.StorageSpec, error) {
+	return model.StorageSpec{}, fmt.Errorf("not implemented")
+}
+
+// Explode walks the checked out tree and returns a spec for every file and
+// directory in it. Each spec is pinned to the resolved commit so that all
+// shards see exactly the same tree.
+func (sp *StorageProvider) Explode(ctx context.Context, spec model.StorageSpec) ([]model.StorageSpec, error) {
+	ctx, span := system.GetTracer().Start(ctx, "pkg/storage/git.Explode")
+	defer span.End()
+
+	commit, err := sp.resolveCommit(ctx, spec.URL, GetRef(spec))
+	if err != nil {
+		return []model.StorageSpec{}, err
+	}
+	pinnedSpec := NewStorageSpec(spec.URL, commit, GetSubpath(spec), spec.Path)
+	rootPath, err := sp.checkout(ctx, pinnedSpec)
+	if err != nil {
+		return []model.StorageSpec{}, err
+	}
+	defer sp.release(ctx, rootPath)
+
+	basePath := strings.TrimSuffix(spec.Path, "/")
+	specs := []model.StorageSpec{}
+	err = filepath.Walk(rootPath, func(path string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		usePath := basePath
+		subpath := GetSubpath(spec)
+		if relPath != "." {
+			usePath = basePath + "/" + relPath
+			subpath = strings.TrimPrefix(subpath+"/"+relPath, "/")
+		}
+		specs = append(specs, NewStorageSpec(spec.URL, commit, subpath, usePath))
+		return nil
+	})
+	if err != nil {
+		return []model.StorageSpec{}, err
+	}
+	return specs, nil
+}
+
+// checkout acquires the commit referenced by the spec from the input cache,
+// cloning it if needed, and returns the path within it that should be
+// mounted. The returned path must be released once it is no longer used.
+func (sp *StorageProvider) checkout(ctx context.Context, spec model.StorageSpec) (string, error) {
+	subpath, err := cleanSubpath(GetSubpath(spec))
+	if err != nil {
+		return "", err
+	}
+
+	ref := GetRef(spec)
+	commit, err := sp.resolveCommit(ctx, spec.URL, ref)
+	if err != nil {
+		return "", err
+	}
+
+	outputPath, err := sp.Cache.Acquire(ctx, cacheKey(spec.URL, commit), "", func(ctx context.Context, dir string) error {
+		return sp.clone(ctx, spec.URL, ref, commit, dir)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sourcePath, err := resolveSubpath(outputPath, subpath)
+	if err != nil {
+		sp.release(ctx, outputPath)
+		return "", fmt.Errorf("path %q of %s at commit %s: %w", subpath, spec.URL, commit, err)
+	}
+	return sourcePath, nil
+}
+
+func (sp *StorageProvider) release(ctx context.Context, path string) {
+	if err := sp.Cache.Release(path); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("failed to release git checkout")
+	}
+}
+
+// resolveSubpath resolves symlinks so a link in the repository can't be used
+// to mount a path from outside of the checkout.
+func resolveSubpath(outputPath, subpath string) (string, error) {
+	sourcePath, err := filepath.EvalSymlinks(filepath.Join(outputPath, subpath))
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("does not exist")
+	} else if err != nil {
+		return "", err
+	}
+	rootPath, err := filepath.EvalSymlinks(outputPath)
+	if err != nil {
+		return "", err
+	}
+	relPath, err := filepath.Rel(rootPath, sourcePath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return "", fmt.Errorf("points outside of the repository")
+	}
+	return filepath.Join(outputPath, relPath), nil
+}
+
+// clone fetches a single commit into the given directory.
+func (sp *StorageProvider) clone(ctx context.Context, repoURL, ref, commit, outputPath string) error {
+	log.Ctx(ctx).Debug().Str("URL", repoURL).Str("Commit", commit).Msg("Cloning git repository")
+
+	// fetch by name where we have one as not all servers allow fetching
+	// commits that are not advertised
+	fetchRef := ref
+	if IsCommitHash(ref) {
+		fetchRef = commit
+	}
+
+	if _, err := runGit(ctx, outputPath, "init", "--quiet"); err != nil {
+		return err
+	}
+	if _, err := sp.runRemoteGit(ctx, outputPath, "fetch", "--quiet", "--depth", "1", repoURL, fetchRef); err != nil {
+		return fmt.Errorf("failed to fetch %s from %s: %w", ref, repoURL, err)
+	}
+	if _, err := runGit(ctx, outputPath, "-c", "advice.detachedHead=false", "checkout", "--quiet", "FETCH_HEAD"); err != nil {
+		return err
+	}
+	head, err := runGit(ctx, outputPath, "rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	if head = strings.TrimSpace(head); head != commit {
+		return fmt.Errorf("ref %s of %s moved while cloning: expected %s got %s", ref, repoURL, commit, head)
+	}
+	return os.RemoveAll(filepath.Join(outputPath, ".git"))
+}
+
+// resolveCommit turns a branch or tag name into the commit hash it
+// currently points to on the remote.
+func (sp *StorageProvider) resolveCommit(ctx context.Context, repoURL, ref string) (string, error) {
+	if err := sp.checkURL(ctx, repoURL); err != nil {
+		return "", err
+	}
+	if IsCommitHash(ref) {
+		return ref, nil
+	}
+	if !isValidRef(ref) {
+		return "", fmt.Errorf("invalid git ref %q", ref)
+	}
+
+	// ask for the peeled ref as well so annotated tags resolve to a commit
+	out, err := sp.runRemoteGit(ctx, "", "ls-remote", repoURL, ref, ref+"^{}")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s of %s: %w", ref, repoURL, err)
+	}
+
+	var commit string
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if strings.HasSuffix(fields[1], "^{}") {
+			commit = fields[0]
+			break
+		}
+		if commit == "" {
+			commit = fields[0]
+		}
+	}
+	if commit == "" {
+		return "", fmt.Errorf("ref %s not found in %s", ref, repoURL)
+	}
+	return commit, nil
+}
+
+// CacheKey returns the key a checkout of the spec is cached under. Checkouts
+// are cached by commit, so the key is only known without asking the remote
+// when the spec pins an exact commit.
+func CacheKey(spec model.StorageSpec) (string, bool) {
+	ref := GetRef(spec)
+	if !IsCommitHash(ref) {
+		return "", false
+	}
+	return cacheKey(spec.URL, ref), true
+}
+
+func cacheKey(repoURL, commit string) string {
+	return cache.Key(model.StorageSourceGit, repoURL+"@"+commit)
+}
+
+// checkURL checks that the URL is supported, and that its host only resolves
+// to public addresses so that jobs can't read from the node's network.
+func (sp *StorageProvider) checkURL(ctx context.Context, repoURL string) error {
+	if sp.allowLocalRepos && strings.HasPrefix(repoURL, "file://") {
+		return nil
+	}
+	host, err := urlHost(repoURL)
+	if err != nil {
+		return err
+	}
+	return publicaddr.CheckHost(ctx, host)
+}
+
+// runRemoteGit runs a git command that talks to a remote, restricted to the
+// allowed protocols and without following redirects to other hosts.
+func (sp *StorageProvider) runRemoteGit(ctx context.Context, dir string, args ...string) (string, error) {
+	protocols := allowedProtocols
+	if sp.allowLocalRepos {
+		protocols += ":file"
+	}
+	args = append([]string{"-c", "http.followRedirects=false"}, args...)
+	return runGitWithEnv(ctx, dir, []string{"GIT_ALLOW_PROTOCOL=" + protocols}, args...)
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	return runGitWithEnv(ctx, dir, nil, args...)
+}
+
+func runGitWithEnv(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// never block waiting for credentials on a terminal that isn't there
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", gitSubcommand(args), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func cleanSubpath(subpath string) (string, error) {
+	cleaned := filepath.Clean(subpath)
+	if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("invalid subpath %q", subpath)
+	}
+	return cleaned, nil
+}
+
+// gitSubcommand skips the -c options before the subcommand of a git command.
+func gitSubcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-c" {
+			i++
+			continue
+		}
+		return args[i]
+	}
+	return ""
+}
+
+// NewStorageSpec returns a spec that mounts the given subpath of a
+// repository at the given ref or commit.
+func NewStorageSpec(repoURL, ref, subpath, mountPath string) model.StorageSpec {
+	metadata := map[string]string{}
+	if ref != "" {
+		metadata[MetadataRef] = ref
+	}
+	if subpath != "" {
+		metadata[MetadataSubpath] = subpath
+	}
+	return model.StorageSpec{
+		StorageSource: model.StorageSourceGit,
+		URL:           repoURL,
+		Path:          mountPath,
+		Metadata:      metadata,
+	}
+}
+
+func GetRef(spec model.StorageSpec) string {
+	if ref := spec.Metadata[MetadataRef]; ref != "" {
+		return ref
+	}
+	return defaultRef
+}
+
+func GetSubpath(spec model.StorageSpec) string {
+	return strings.Trim(spec.Metadata[MetadataSubpath], "/")
+}
+
+func IsCommitHash(ref string) bool {
+	return commitHashRegex.MatchString(ref)
+}
+
+// refs are passed on the git command line so must never look like a flag
+func isValidRef(ref string) bool {
+	return ref != "" && !strings.HasPrefix(ref, "-") && !strings.ContainsAny(ref, " \t\n:?*[\\~^")
+}
+
+// IsURLSupported checks the URL is a https, ssh or git URL or an scp-like
+// remote on a public host. Remote helpers (e.g. ext::, fd::) would run
+// commands on the node and local repositories would expose its files, so
+// neither is accepted.
+func IsURLSupported(rawURL string) error {
+	_, err := urlHost(rawURL)
+	return err
+}
+
+// urlHost returns the host of a supported URL.
+func urlHost(rawURL string) (string, error) {
+	if strings.HasPrefix(rawURL, "-") || strings.Contains(rawURL, "::") ||
+		strings.IndexFunc(rawURL, func(r rune) bool { return r <= ' ' || r == 0x7f }) >= 0 {
+		return "", fmt.Errorf("invalid git URL: %s", rawURL)
+	}
+	if match := scpLikeURLRegex.FindStringSubmatch(rawURL); match != nil {
+		if err := publicaddr.CheckHostname(match[1]); err != nil {
+			return "", fmt.Errorf("invalid git URL: %w", err)
+		}
+		return match[1], nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid git URL: %s", err)
+	}
+	switch u.Scheme {
+	case "https", "ssh", "git":
+	default:
+		return "", fmt.Errorf("git URLs must begin with 'https', 'ssh' or 'git'. The submitted one began with %q", u.Scheme)
+	}
+	if err = publicaddr.CheckHostname(u.Hostname()); err != nil {
+		return "", fmt.Errorf("invalid git URL: %w", err)
+	}
+	if strings.Trim(u.Path, "/") == "" {
+		return "", fmt.Errorf("git URL must include a repository path")
+	}
+	return u.Hostname(), nil
+}
+
+// Compile time interface check:
+var _ storage.Storage = (*StorageProvider)(nil)
@@ -0,0 +1,225 @@
+//go:build unit || !integration
+
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/filecoin-project/bacalhau/pkg/logger"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/storage/cache"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type GitStorageSuite struct {
+	suite.Suite
+	ctx      context.Context
+	driver   *StorageProvider
+	repoURL  string
+	commit   string
+	tagged   string
+	workPath string
+}
+
+func TestGitStorageSuite(t *testing.T) {
+	suite.Run(t, new(GitStorageSuite))
+}
+
+func (s *GitStorageSuite) SetupTest() {
+	logger.ConfigureTestLogging(s.T())
+	require.NoError(s.T(), system.InitConfigForTesting(s.T()))
+	if _, err := exec.LookPath("git"); err != nil {
+		s.T().Skip("git is not installed")
+	}
+
+	cm := system.NewCleanupManager()
+	s.T().Cleanup(cm.Cleanup)
+	s.ctx = context.Background()
+
+	inputCache, err := cache.NewCache(s.T().TempDir(), 1024*1024)
+	require.NoError(s.T(), err)
+	s.driver, err = NewStorage(cm, inputCache)
+	require.NoError(s.T(), err)
+	s.driver.allowLocalRepos = true
+
+	// build a bare repository with two commits, tagging the first
+	root := s.T().TempDir()
+	barePath := filepath.Join(root, "repo.git")
+	s.workPath = filepath.Join(root, "work")
+	s.git(root, "init", "--quiet", "--bare", barePath)
+	s.git(root, "init", "--quiet", s.workPath)
+	s.writeFile("README.md", "hello")
+	s.writeFile("data/a.txt", "a")
+	s.commitAll("first")
+	s.tagged = s.git(s.workPath, "rev-parse", "HEAD")
+	s.git(s.workPath, "tag", "-a", "v1", "-m", "v1")
+	s.writeFile("data/b.txt", "bb")
+	s.commitAll("second")
+	s.commit = s.git(s.workPath, "rev-parse", "HEAD")
+	s.git(s.workPath, "push", "--quiet", "--tags", barePath, "HEAD:refs/heads/main")
+	s.git(barePath, "symbolic-ref", "HEAD", "refs/heads/main")
+	s.repoURL = "file://" + barePath
+}
+
+func (s *GitStorageSuite) git(dir string, args ...string) string {
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	out, err := runGit(s.ctx, dir, args...)
+	require.NoError(s.T(), err)
+	return strings.TrimSpace(out)
+}
+
+func (s *GitStorageSuite) writeFile(name, content string) {
+	path := filepath.Join(s.workPath, name)
+	require.NoError(s.T(), os.MkdirAll(filepath.Dir(path), os.ModePerm))
+	require.NoError(s.T(), os.WriteFile(path, []byte(content), 0644))
+}
+
+func (s *GitStorageSuite) commitAll(message string) {
+	s.git(s.workPath, "add", "-A")
+	s.git(s.workPath, "commit", "--quiet", "-m", message)
+}
+
+func (s *GitStorageSuite) TestIsInstalled() {
+	installed, err := s.driver.IsInstalled(s.ctx)
+	require.NoError(s.T(), err)
+	require.True(s.T(), installed)
+}
+
+func (s *GitStorageSuite) TestPrepareStorage() {
+	for _, tc := range []struct {
+		name     string
+		ref      string
+		expected []string
+	}{
+		{name: "default branch", ref: "", expected: []string{"README.md", "data/a.txt", "data/b.txt"}},
+		{name: "branch", ref: "main", expected: []string{"README.md", "data/a.txt", "data/b.txt"}},
+		{name: "annotated tag", ref: "v1", expected: []string{"README.md", "data/a.txt"}},
+		{name: "commit", ref: s.tagged, expected: []string{"README.md", "data/a.txt"}},
+	} {
+		s.Run(tc.name, func() {
+			spec := NewStorageSpec(s.repoURL, tc.ref, "", "/code")
+			volume, err := s.driver.PrepareStorage(s.ctx, spec)
+			require.NoError(s.T(), err)
+			require.Equal(s.T(), "/code", volume.Target)
+
+			var files []string
+			err = filepath.Walk(volume.Source, func(path string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					rel, _ := filepath.Rel(volume.Source, path)
+					files = append(files, filepath.ToSlash(rel))
+				}
+				return err
+			})
+			require.NoError(s.T(), err)
+			require.ElementsMatch(s.T(), tc.expected, files)
+		})
+	}
+}
+
+func (s *GitStorageSuite) TestSubpath() {
+	spec := NewStorageSpec(s.repoURL, s.commit, "data", "/data")
+	volume, err := s.driver.PrepareStorage(s.ctx, spec)
+	require.NoError(s.T(), err)
+	content, err := os.ReadFile(filepath.Join(volume.Source, "b.txt"))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "bb", string(content))
+
+	_, err = s.driver.PrepareStorage(s.ctx, NewStorageSpec(s.repoURL, s.commit, "../..", "/data"))
+	require.Error(s.T(), err)
+	_, err = s.driver.PrepareStorage(s.ctx, NewStorageSpec(s.repoURL, s.commit, "missing", "/data"))
+	require.Error(s.T(), err)
+}
+
+func (s *GitStorageSuite) TestHasStorageLocally() {
+	spec := NewStorageSpec(s.repoURL, s.commit, "", "/code")
+	local, err := s.driver.HasStorageLocally(s.ctx, spec)
+	require.NoError(s.T(), err)
+	require.False(s.T(), local)
+
+	_, err = s.driver.PrepareStorage(s.ctx, spec)
+	require.NoError(s.T(), err)
+
+	local, err = s.driver.HasStorageLocally(s.ctx, spec)
+	require.NoError(s.T(), err)
+	require.True(s.T(), local)
+
+	// the key is what the node advertises to requesters ranking by locality
+	key, ok := CacheKey(spec)
+	require.True(s.T(), ok)
+	require.Contains(s.T(), s.driver.Cache.Keys(10), key)
+
+	// named refs can move so are never considered local
+	branch := NewStorageSpec(s.repoURL, "main", "", "/code")
+	local, err = s.driver.HasStorageLocally(s.ctx, branch)
+	require.NoError(s.T(), err)
+	require.False(s.T(), local)
+	_, ok = CacheKey(branch)
+	require.False(s.T(), ok)
+}
+
+func (s *GitStorageSuite) TestGetVolumeSize() {
+	size, err := s.driver.GetVolumeSize(s.ctx, NewStorageSpec(s.repoURL, "main", "", "/code"))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), uint64(len("hello")+len("a")+len("bb")), size)
+}
+
+func (s *GitStorageSuite) TestExplode() {
+	specs, err := s.driver.Explode(s.ctx, NewStorageSpec(s.repoURL, "main", "", "/code"))
+	require.NoError(s.T(), err)
+
+	paths := map[string]model.StorageSpec{}
+	for _, spec := range specs {
+		require.Equal(s.T(), model.StorageSourceGit, spec.StorageSource)
+		require.Equal(s.T(), s.commit, GetRef(spec))
+		paths[spec.Path] = spec
+	}
+	require.Len(s.T(), paths, 5)
+	require.Contains(s.T(), paths, "/code")
+	require.Contains(s.T(), paths, "/code/data")
+	require.Equal(s.T(), "data/b.txt", GetSubpath(paths["/code/data/b.txt"]))
+}
+
+func (s *GitStorageSuite) TestIsURLSupported() {
+	for _, valid := range []string{
+		"https://github.com/filecoin-project/bacalhau.git",
+		"git@github.com:filecoin-project/bacalhau.git",
+		"ssh://git@github.com/filecoin-project/bacalhau",
+		"git://github.com/filecoin-project/bacalhau.git",
+	} {
+		require.NoError(s.T(), IsURLSupported(valid), valid)
+	}
+	for _, invalid := range []string{
+		"ftp://example.com/repo.git",
+		"https://example.com",
+		"--upload-pack=touch:x",
+		"/tmp/repo.git",
+		"file:///tmp/repo.git",
+		"http://example.com/repo.git",
+		"ext::sh -c touch% /tmp/pwned",
+		"fd::17",
+		"https::example.com/repo.git",
+		"localhost:repo.git",
+		"git@localhost:repo.git",
+		"https://localhost/repo.git",
+		"https://127.0.0.1/repo.git",
+		"ssh://[::1]/repo.git",
+		"https://169.254.169.254/latest/meta-data",
+		"https://10.0.0.1/repo.git",
+		"https://intranet/repo.git",
+	} {
+		require.Error(s.T(), IsURLSupported(invalid), invalid)
+	}
+}
+
+func (s *GitStorageSuite) TestLocalReposRejectedByDefault() {
+	s.driver.allowLocalRepos = false
+	_, err := s.driver.PrepareStorage(s.ctx, NewStorageSpec(s.repoURL, "", "", "/code"))
+	require.Error(s.T(), err)
+}
@@ -3,7 +3,6 @@ package apicopy
 import (
 	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -11,42 +10,30 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/ipfs"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/storage"
+	"github.com/filecoin-project/bacalhau/pkg/storage/cache"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/rs/zerolog/log"
 )
 
 // a storage driver runs the downloads content
 // from a remote ipfs server and copies it to
-// to a local directory in preparation for
-// a job to run - it will remove the folder/file once complete
+// the node's input cache in preparation for
+// a job to run - it will release the cache entry once complete
 
 type StorageProvider struct {
-	LocalDir   string
 	IPFSClient *ipfs.Client
+	Cache      *cache.Cache
 }
 
-func NewStorage(cm *system.CleanupManager, ipfsAPIAddress string) (*StorageProvider, error) {
+func NewStorage(cm *system.CleanupManager, inputCache *cache.Cache, ipfsAPIAddress string) (*StorageProvider, error) {
 	cl, err := ipfs.NewClient(ipfsAPIAddress)
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO: consolidate the various config inputs into one package otherwise they are scattered across the codebase
-	dir, err := os.MkdirTemp(config.GetStoragePath(), "bacalhau-ipfs")
-	if err != nil {
-		return nil, err
-	}
-
-	cm.RegisterCallback(func() error {
-		if err := os.RemoveAll(dir); err != nil {
-			return fmt.Errorf("unable to clean up IPFS storage directory: %w", err)
-		}
-		return nil
-	})
-
 	storageHandler := &StorageProvider{
 		IPFSClient: cl,
-		LocalDir:   dir,
+		Cache:      inputCache,
 	}
 
 	log.Trace().Msgf("IPFS API Copy driver created with address: %s", ipfsAPIAddress)
@@ -59,6 +46,9 @@ func (dockerIPFS *StorageProvider) IsInstalled(ctx context.Context) (bool, error
 }
 
 func (dockerIPFS *StorageProvider) HasStorageLocally(ctx context.Context, volume model.StorageSpec) (bool, error) {
+	if dockerIPFS.Cache.Has(cache.Key(model.StorageSourceIPFS, volume.CID)) {
+		return true, nil
+	}
 	return dockerIPFS.IPFSClient.HasCID(ctx, volume.CID)
 }
 
@@ -96,9 +86,8 @@ func (dockerIPFS *StorageProvider) PrepareStorage(ctx context.Context, storageSp
 	return volume, nil
 }
 
-//nolint:lll // Exception to the long rule
-func (dockerIPFS *StorageProvider) CleanupStorage(_ context.Context, storageSpec model.StorageSpec, _ storage.StorageVolume) error {
-	return os.RemoveAll(filepath.Join(dockerIPFS.LocalDir, storageSpec.CID))
+func (dockerIPFS *StorageProvider) CleanupStorage(_ context.Context, _ model.StorageSpec, volume storage.StorageVolume) error {
+	return dockerIPFS.Cache.Release(volume.Source)
 }
 
 func (dockerIPFS *StorageProvider) Upload(ctx context.Context, localPath string) (model.StorageSpec, error) {
@@ -155,21 +144,15 @@ func (dockerIPFS *StorageProvider) getFileFromIPFS(ctx context.Context, storageS
 	ctx, span := system.GetTracer().Start(ctx, "storage/ipfs/apicopy.copyFile")
 	defer span.End()
 
-	outputPath := filepath.Join(dockerIPFS.LocalDir, storageSpec.CID)
-
-	// If the output path already exists, we already have the data, as
-	// ipfsClient.Get(...) renames the result path atomically after it has
-	// finished downloading the CID.
-	ok, err := system.PathExists(outputPath)
+	// CIDs are immutable so there is no need for a validator
+	key := cache.Key(model.StorageSourceIPFS, storageSpec.CID)
+	entryPath, err := dockerIPFS.Cache.Acquire(ctx, key, "", func(ctx context.Context, dir string) error {
+		return dockerIPFS.IPFSClient.Get(ctx, storageSpec.CID, filepath.Join(dir, storageSpec.CID))
+	})
 	if err != nil {
 		return storage.StorageVolume{}, err
 	}
-	if !ok {
-		err = dockerIPFS.IPFSClient.Get(ctx, storageSpec.CID, outputPath)
-		if err != nil {
-			return storage.StorageVolume{}, err
-		}
-	}
+	outputPath := filepath.Join(entryPath, storageSpec.CID)
 
 	volume := storage.StorageVolume{
 		Type:   storage.StorageVolumeConnectorBind,
@@ -11,6 +11,7 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/config"
 	"github.com/filecoin-project/bacalhau/pkg/ipfs"
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/storage/cache"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/stretchr/testify/require"
 )
@@ -30,7 +31,10 @@ func getIpfsStorage(t *testing.T) *StorageProvider {
 	require.NoError(t, err)
 	require.GreaterOrEqual(t, len(apiAddresses), 1)
 
-	storage, err := NewStorage(cm, apiAddresses[0])
+	inputCache, err := cache.NewTemporaryCache(cm, 0)
+	require.NoError(t, err)
+
+	storage, err := NewStorage(cm, inputCache, apiAddresses[0])
 	require.NoError(t, err)
 
 	return storage
@@ -15,6 +15,7 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/config"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/storage"
+	"github.com/filecoin-project/bacalhau/pkg/storage/cache"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/go-resty/resty/v2"
 	"github.com/google/uuid"
@@ -24,14 +25,18 @@ import (
 // a storage driver runs the downloads content
 // from a public URL source and copies it to
 // a local directory in preparation for
-// a job to run - it will remove the folder/file once complete
+// a job to run - downloads of URLs that the server
+// gives a validator for (ETag or Last-Modified) are
+// kept in the node's input cache, everything else is
+// removed once complete
 
 type StorageProvider struct {
 	LocalDir   string
 	HTTPClient *resty.Client
+	Cache      *cache.Cache
 }
 
-func NewStorage(cm *system.CleanupManager) (*StorageProvider, error) {
+func NewStorage(cm *system.CleanupManager, inputCache *cache.Cache) (*StorageProvider, error) {
 	// TODO: consolidate the various config inputs into one package otherwise they are scattered across the codebase
 	dir, err := os.MkdirTemp(config.GetStoragePath(), "bacalhau-url")
 	if err != nil {
@@ -56,6 +61,7 @@ func NewStorage(cm *system.CleanupManager) (*StorageProvider, error) {
 	storageHandler := &StorageProvider{
 		HTTPClient: client,
 		LocalDir:   dir,
+		Cache:      inputCache,
 	}
 
 	log.Debug().Msgf("URL download driver created with output dir: %s", dir)
@@ -66,8 +72,14 @@ func (sp *StorageProvider) IsInstalled(context.Context) (bool, error) {
 	return true, nil
 }
 
-func (sp *StorageProvider) HasStorageLocally(context.Context, model.StorageSpec) (bool, error) {
-	return false, nil
+// A cached download may be stale, but it is still a good indication that
+// the server will validate it rather than us having to download it again.
+func (sp *StorageProvider) HasStorageLocally(_ context.Context, spec model.StorageSpec) (bool, error) {
+	key, err := CacheKey(spec)
+	if err != nil {
+		return false, nil
+	}
+	return sp.Cache.Has(key), nil
 }
 
 // Could do a HEAD request and check Content-Length, but in some cases that's not guaranteed to be the real end file size
@@ -76,10 +88,8 @@ func (sp *StorageProvider) GetVolumeSize(context.Context, model.StorageSpec) (ui
 }
 
 // For the urldownload storage provider, PrepareStorage will download the file from the URL
-//
-//nolint:funlen,gocyclo // TODO: refactor this function
 func (sp *StorageProvider) PrepareStorage(ctx context.Context, storageSpec model.StorageSpec) (storage.StorageVolume, error) {
-	_, span := system.GetTracer().Start(ctx, "pkg/storage/url/urldownload.PrepareStorage")
+	ctx, span := system.GetTracer().Start(ctx, "pkg/storage/url/urldownload.PrepareStorage")
 	defer span.End()
 
 	u, err := IsURLSupported(storageSpec.URL)
@@ -87,21 +97,13 @@ func (sp *StorageProvider) PrepareStorage(ctx context.Context, storageSpec model
 		return storage.StorageVolume{}, err
 	}
 
-	outputPath, err := os.MkdirTemp(sp.LocalDir, "*")
-	if err != nil {
-		return storage.StorageVolume{}, err
-	}
-
 	sp.HTTPClient.SetTimeout(config.GetDownloadURLRequestTimeout())
-	sp.HTTPClient.SetOutputDirectory(outputPath)
 	sp.HTTPClient.SetDoNotParseResponse(true) // We want to stream the response to disk directly
 
 	// Trying a check for head - just trying to fail quickly if the site is clearly wrong.
 	// This MAY fail with 405 (method not allowed) if the server doesn't support HEAD which is generally
 	// OK because we will fail if the server is down - so it is a best effort.
-	req := sp.HTTPClient.R().SetContext(ctx)
-	req = req.SetContext(ctx)
-	r, err := req.Head(u.String())
+	r, err := sp.HTTPClient.R().SetContext(ctx).Head(u.String())
 	log.Debug().Msgf("HEAD request to %s returned status code %d", u.String(), r.StatusCode())
 	if err != nil {
 		return storage.StorageVolume{}, fmt.Errorf("failed to get headers from url (%s): %s", u.String(), err)
@@ -113,6 +115,54 @@ func (sp *StorageProvider) PrepareStorage(ctx context.Context, storageSpec model
 		log.Debug().Msgf("URL %s redirected to %s", u.String(), finalURL.String())
 	}
 
+	// Only responses we can tell have changed are safe to cache
+	var validator string
+	if r.StatusCode() == http.StatusOK {
+		validator = r.Header().Get("ETag")
+		if validator == "" {
+			validator = r.Header().Get("Last-Modified")
+		}
+	}
+	if validator == "" {
+		var outputPath string
+		outputPath, err = os.MkdirTemp(sp.LocalDir, "*")
+		if err != nil {
+			return storage.StorageVolume{}, err
+		}
+		return sp.download(ctx, storageSpec, finalURL, outputPath)
+	}
+
+	key := cacheKey(u)
+	entryPath, err := sp.Cache.Acquire(ctx, key, validator, func(ctx context.Context, dir string) error {
+		_, downloadErr := sp.download(ctx, storageSpec, finalURL, dir)
+		return downloadErr
+	})
+	if err != nil {
+		return storage.StorageVolume{}, err
+	}
+
+	// the entry may have been filled by another shard, so find the file in it
+	files, err := os.ReadDir(entryPath)
+	if err != nil || len(files) != 1 {
+		_ = sp.Cache.Release(entryPath)
+		return storage.StorageVolume{}, fmt.Errorf("invalid cached download of %s in %s: %v", u.String(), entryPath, err)
+	}
+	return storage.StorageVolume{
+		Type:   storage.StorageVolumeConnectorBind,
+		Source: filepath.Join(entryPath, files[0].Name()),
+		Target: filepath.Join(storageSpec.Path, files[0].Name()),
+	}, nil
+}
+
+// download writes the content of the URL to a single file in outputPath.
+//
+//nolint:funlen // TODO: refactor this function
+func (sp *StorageProvider) download(
+	ctx context.Context,
+	storageSpec model.StorageSpec,
+	finalURL *url.URL,
+	outputPath string,
+) (storage.StorageVolume, error) {
 	// Create a new file based on the URL
 	baseName := path.Base(finalURL.Path)
 	var fileName string
@@ -124,11 +174,12 @@ func (sp *StorageProvider) PrepareStorage(ctx context.Context, storageSpec model
 	}
 
 	log.Trace().Msgf("Beginning get %s to %s", finalURL, outputPath)
-	r, err = req.Get(finalURL.String())
+	r, err := sp.HTTPClient.R().SetContext(ctx).Get(finalURL.String())
 	if err != nil {
 		return storage.StorageVolume{},
 			fmt.Errorf("failed to begin download from url %s: %s", finalURL, err)
 	}
+	defer r.RawBody().Close()
 
 	if r.StatusCode() != http.StatusOK {
 		return storage.StorageVolume{},
@@ -141,6 +192,7 @@ func (sp *StorageProvider) PrepareStorage(ctx context.Context, storageSpec model
 	if err != nil {
 		return storage.StorageVolume{}, fmt.Errorf("failed to create file %s: %s", filePath, err)
 	}
+	defer w.Close()
 
 	// stream the body to the client without fully loading it into memory
 	n, err := io.Copy(w, r.RawBody())
@@ -185,9 +237,6 @@ func (sp *StorageProvider) PrepareStorage(ctx context.Context, storageSpec model
 		targetPath = filepath.Join(storageSpec.Path, filepath.Base(finalFileName))
 	}
 
-	r.RawBody().Close()
-	w.Close()
-
 	volume := storage.StorageVolume{
 		Type:   storage.StorageVolumeConnectorBind,
 		Source: filePath,   // The source is the full path to the file
@@ -205,6 +254,11 @@ func (sp *StorageProvider) CleanupStorage(
 	_, span := system.GetTracer().Start(ctx, "pkg/storage/url/urldownload.CleanupStorage")
 	defer span.End()
 
+	// downloads outside of our own directory belong to the input cache
+	if !strings.HasPrefix(volume.Source, sp.LocalDir+string(filepath.Separator)) {
+		return sp.Cache.Release(volume.Source)
+	}
+
 	pathToCleanup := filepath.Dir(volume.Source)
 	log.Ctx(ctx).Debug().Str("Path", pathToCleanup).Msg("Cleaning up")
 	return os.RemoveAll(pathToCleanup)
@@ -249,5 +303,18 @@ func IsURLSupported(rawURL string) (*url.URL, error) {
 	return u, nil
 }
 
+// CacheKey returns the key a download of the spec's URL is cached under.
+func CacheKey(spec model.StorageSpec) (string, error) {
+	u, err := IsURLSupported(spec.URL)
+	if err != nil {
+		return "", err
+	}
+	return cacheKey(u), nil
+}
+
+func cacheKey(u *url.URL) string {
+	return cache.Key(model.StorageSourceURLDownload, u.String())
+}
+
 // Compile time interface check:
 var _ storage.Storage = (*StorageProvider)(nil)
@@ -15,6 +15,7 @@ import (
 
 	"github.com/filecoin-project/bacalhau/pkg/logger"
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/storage/cache"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/require"
@@ -39,10 +40,16 @@ func (s *StorageSuite) SetupTest() {
 	require.NoError(s.T(), system.InitConfigForTesting(s.T()))
 }
 
+func (s *StorageSuite) newCache() *cache.Cache {
+	inputCache, err := cache.NewCache(s.T().TempDir(), 1024*1024)
+	require.NoError(s.T(), err)
+	return inputCache
+}
+
 func (s *StorageSuite) TestNewStorageProvider() {
 	cm := system.NewCleanupManager()
 
-	sp, err := NewStorage(cm)
+	sp, err := NewStorage(cm, s.newCache())
 	require.NoError(s.T(), err, "failed to create storage provider")
 
 	// is dir writable?
@@ -63,7 +70,7 @@ func (s *StorageSuite) TestHasStorageLocally() {
 	cm := system.NewCleanupManager()
 	ctx := context.Background()
 
-	sp, err := NewStorage(cm)
+	sp, err := NewStorage(cm, s.newCache())
 	require.NoError(s.T(), err, "failed to create storage provider")
 
 	spec := model.StorageSpec{
@@ -71,7 +78,7 @@ func (s *StorageSuite) TestHasStorageLocally() {
 		URL:           "foo",
 		Path:          "foo",
 	}
-	// files are not cached thus shall never return true
+	// nothing has been downloaded yet
 	locally, err := sp.HasStorageLocally(ctx, spec)
 	require.NoError(s.T(), err, "failed to check if storage is locally available")
 
@@ -80,6 +87,60 @@ func (s *StorageSuite) TestHasStorageLocally() {
 	}
 }
 
+func (s *StorageSuite) TestPrepareStorageCached() {
+	etag := `"v1"`
+	gets := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		if r.Method == http.MethodGet {
+			gets++
+			w.Write([]byte("content-" + etag))
+		}
+	}))
+	defer ts.Close()
+
+	cm := system.NewCleanupManager()
+	defer cm.Cleanup()
+	ctx := context.Background()
+	sp, err := NewStorage(cm, s.newCache())
+	require.NoError(s.T(), err)
+
+	spec := model.StorageSpec{
+		StorageSource: model.StorageSourceURLDownload,
+		URL:           ts.URL + "/data.txt",
+		Path:          "/inputs",
+	}
+	prepare := func() string {
+		volume, err := sp.PrepareStorage(ctx, spec)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), "/inputs/data.txt", volume.Target)
+		content, err := os.ReadFile(volume.Source)
+		require.NoError(s.T(), err)
+		require.NoError(s.T(), sp.CleanupStorage(ctx, spec, volume))
+		return string(content)
+	}
+
+	require.Equal(s.T(), `content-"v1"`, prepare())
+	require.Equal(s.T(), `content-"v1"`, prepare())
+	require.Equal(s.T(), 1, gets, "unchanged content should be served from the cache")
+
+	locally, err := sp.HasStorageLocally(ctx, spec)
+	require.NoError(s.T(), err)
+	require.True(s.T(), locally)
+
+	// the key is what the node advertises to requesters ranking by locality,
+	// so must match however the URL was written in the job
+	quoted := spec
+	quoted.URL = "'" + spec.URL + "'"
+	key, err := CacheKey(quoted)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), sp.Cache.Keys(10), key)
+
+	etag = `"v2"`
+	require.Equal(s.T(), `content-"v2"`, prepare())
+	require.Equal(s.T(), 2, gets, "changed content should be downloaded again")
+}
+
 func (s *StorageSuite) TestPrepareStorageURL() {
 	fileName := "testfile.py"
 	_ = fileName
@@ -138,7 +199,7 @@ func (s *StorageSuite) TestPrepareStorageURL() {
 
 				cm := system.NewCleanupManager()
 				ctx := context.Background()
-				sp, err := NewStorage(cm)
+				sp, err := NewStorage(cm, s.newCache())
 				if err != nil {
 					return "", fmt.Errorf("%s: failed to create storage provider", name)
 				}
@@ -240,7 +301,7 @@ func (s *StorageSuite) TestImageDownloaderLiveRedirectURL() {
 		content, err := func() (string, error) {
 			cm := system.NewCleanupManager()
 			ctx := context.Background()
-			sp, err := NewStorage(cm)
+			sp, err := NewStorage(cm, s.newCache())
 			if err != nil {
 				return "", fmt.Errorf("%s: failed to create storage provider", name)
 			}
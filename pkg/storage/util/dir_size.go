@@ -0,0 +1,21 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// DirSize returns the total size of the files under a directory.
+func DirSize(path string) (uint64, error) {
+	var size uint64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += uint64(info.Size())
+		}
+		return err
+	})
+	return size, err
+}
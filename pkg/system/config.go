@@ -21,6 +21,8 @@ import (
 const (
 	bitsPerKey = 2048          // number of bits in generated RSA keypairs
 	sigHash    = crypto.SHA256 // hash function to use for sign/verify
+
+	apiTokenKey = "api-token" // config key of the client's bearer token
 )
 
 var (
@@ -186,6 +188,33 @@ func PublicKeyMatchesID(publicKey, clientID string) (bool, error) {
 	return clientID == convertToClientID(pkey), nil
 }
 
+// ClientIDFromPublicKey returns the client ID of a base64-encoded public key.
+func ClientIDFromPublicKey(publicKey string) (string, error) {
+	pkey, err := decodePublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode public key: %w", err)
+	}
+
+	return convertToClientID(pkey), nil
+}
+
+// GetAPIToken returns the bearer token the client sends to requester nodes,
+// or an empty string if there isn't one. Can be set with BACALHAU_API_TOKEN.
+func GetAPIToken() string {
+	return viper.GetString(apiTokenKey)
+}
+
+// SetAPIToken stores the bearer token the client sends to requester nodes in
+// the config file. An empty token removes it.
+// NOTE: must be called after InitConfig().
+func SetAPIToken(token string) error {
+	viper.Set(apiTokenKey, token)
+	if err := viper.WriteConfig(); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
 // ensureDefaultConfigDir ensures that a bacalhau config dir exists.
 func ensureConfigDir() (string, error) {
 	configDir := os.Getenv("BACALHAU_DIR")
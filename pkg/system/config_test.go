@@ -80,3 +80,21 @@ func (s *SystemConfigSuite) TestPublicKeyMatchesID() {
 	require.NoError(s.T(), err)
 	require.True(s.T(), ok)
 }
+
+func (s *SystemConfigSuite) TestAPIToken() {
+	require.NoError(s.T(), InitConfigForTesting(s.T()))
+
+	require.NoError(s.T(), SetAPIToken("bac_token"))
+	require.Equal(s.T(), "bac_token", GetAPIToken())
+
+	require.NoError(s.T(), SetAPIToken(""))
+	require.Empty(s.T(), GetAPIToken())
+}
+
+func (s *SystemConfigSuite) TestClientIDFromPublicKey() {
+	require.NoError(s.T(), InitConfigForTesting(s.T()))
+
+	id, err := ClientIDFromPublicKey(GetClientPublicKey())
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), GetClientID(), id)
+}
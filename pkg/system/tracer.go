@@ -106,6 +106,13 @@ func NewRootSpan(ctx context.Context, t oteltrace.Tracer, name string) (context.
 	return t.Start(ctx, name)
 }
 
+// ContextWithTraceOf returns ctx continuing the trace of traceCtx and carrying its baggage. It is used for work that
+// outlives traceCtx, and so can't be cancelled with it, but is still part of the same trace.
+func ContextWithTraceOf(ctx, traceCtx context.Context) context.Context {
+	ctx = oteltrace.ContextWithSpanContext(ctx, oteltrace.SpanContextFromContext(traceCtx))
+	return baggage.ContextWithBaggage(ctx, baggage.FromContext(traceCtx))
+}
+
 func GetSpanFromRequest(req *http.Request, name string) (context.Context, oteltrace.Span) {
 	ctx := req.Context()
 	ctx, span := tracer.Start(ctx, name)
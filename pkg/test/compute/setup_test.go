@@ -82,7 +82,12 @@ func (s *ComputeSuite) setupNode() {
 		noop_executor.NewNoopExecutorProvider(s.executor),
 		noop_verifier.NewNoopVerifierProvider(s.verifier),
 		noop_publisher.NewNoopPublisherProvider(s.publisher),
+		nil,
+		nil,
 		pubsub.NewInMemoryPubSub[model.NodeInfo](),
+		nil,
+		nil,
+		nil,
 	)
 	s.NoError(err)
 	s.stateResolver = *resolver.NewStateResolver(resolver.StateResolverParams{
@@ -0,0 +1,85 @@
+//go:build integration || !unit
+
+package devstack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/devstack"
+	"github.com/filecoin-project/bacalhau/pkg/job"
+	_ "github.com/filecoin-project/bacalhau/pkg/logger"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/node"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
+	testscenario "github.com/filecoin-project/bacalhau/pkg/test/scenario"
+	"github.com/filecoin-project/bacalhau/pkg/transport/chaos"
+	"github.com/stretchr/testify/suite"
+)
+
+// ChaosSuite checks that jobs still complete, or fail rather than hang, when the messages between requester and
+// compute nodes are lost, delayed, reordered or duplicated.
+type ChaosSuite struct {
+	testscenario.ScenarioRunner
+}
+
+func TestChaosSuite(t *testing.T) {
+	suite.Run(t, new(ChaosSuite))
+}
+
+func (s *ChaosSuite) runChaosScenario(requesterFaults, computeFaults *chaos.Config, checkers []job.CheckStatesFunction) {
+	testScenario := scenario.Scenario{
+		Stack: &scenario.StackConfig{
+			DevStackOptions: &devstack.DevStackOptions{
+				Topology: &devstack.Topology{NodeGroups: []devstack.NodeGroup{
+					{Name: "requester", Requester: true, Faults: requesterFaults},
+					{Name: "compute", Count: 2, Compute: true, Faults: computeFaults},
+				}},
+			},
+			RequesterConfig: node.NewRequesterConfigWith(node.RequesterConfigParams{
+				JobNegotiationTimeout:              10 * time.Second,
+				DefaultJobExecutionTimeout:         10 * time.Second,
+				StateManagerBackgroundTaskInterval: 1 * time.Second,
+			}),
+		},
+		Spec: model.Spec{
+			Engine:    model.EngineNoop,
+			Verifier:  model.VerifierNoop,
+			Publisher: model.PublisherNoop,
+		},
+		Deal:        model.Deal{Concurrency: 1},
+		JobCheckers: checkers,
+	}
+
+	s.RunScenario(testScenario)
+}
+
+func (s *ChaosSuite) TestConvergesWithFlakyLinks() {
+	flaky := &chaos.Config{
+		Seed: 1,
+		Rules: []chaos.Rule{{
+			DuplicateProbability: 0.5,
+			Latency:              model.Duration(50 * time.Millisecond),
+			Jitter:               model.Duration(200 * time.Millisecond),
+		}},
+	}
+	s.runChaosScenario(flaky, flaky, scenario.WaitUntilSuccessful(1))
+}
+
+func (s *ChaosSuite) TestConvergesAroundPartition() {
+	partitioned := &chaos.Config{
+		Partitions: []chaos.Partition{{Peers: []string{"compute-0"}}},
+	}
+	s.runChaosScenario(partitioned, nil, scenario.WaitUntilSuccessful(1))
+}
+
+func (s *ChaosSuite) TestFailsWhenResultsAreLost() {
+	lost := &chaos.Config{
+		Rules: []chaos.Rule{{LossProbability: 1}},
+	}
+	s.runChaosScenario(nil, lost, []job.CheckStatesFunction{
+		job.WaitForJobStates(map[model.JobStateType]int{
+			model.JobStateError: 1,
+		}),
+	})
+}
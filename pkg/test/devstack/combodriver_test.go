@@ -12,16 +12,17 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/job"
 	_ "github.com/filecoin-project/bacalhau/pkg/logger"
 	"github.com/filecoin-project/bacalhau/pkg/model"
-	"github.com/filecoin-project/bacalhau/pkg/test/scenario"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
+	testscenario "github.com/filecoin-project/bacalhau/pkg/test/scenario"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
 type ComboDriverSuite struct {
-	scenario.ScenarioRunner
+	testscenario.ScenarioRunner
 }
 
-var _ scenario.ScenarioTestSuite = (*ComboDriverSuite)(nil)
+var _ testscenario.ScenarioTestSuite = (*ComboDriverSuite)(nil)
 
 // In order for 'go test' to run this suite, we need to create
 // a normal test function and pass our suite to suite.Run
@@ -38,8 +39,8 @@ var testcase scenario.Scenario = scenario.Scenario{
 		Verifier:  model.VerifierNoop,
 		Publisher: model.PublisherIpfs,
 		Wasm: model.JobSpecWasm{
-			EntryPoint:  scenario.CatFileToStdout.Spec.Wasm.EntryPoint,
-			EntryModule: scenario.CatFileToStdout.Spec.Wasm.EntryModule,
+			EntryPoint:  testscenario.CatFileToStdout.Spec.Wasm.EntryPoint,
+			EntryModule: testscenario.CatFileToStdout.Spec.Wasm.EntryModule,
 			Parameters: []string{
 				`/inputs/file.txt`,
 			},
@@ -9,12 +9,13 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/job"
 	_ "github.com/filecoin-project/bacalhau/pkg/logger"
 	"github.com/filecoin-project/bacalhau/pkg/model"
-	"github.com/filecoin-project/bacalhau/pkg/test/scenario"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
+	testscenario "github.com/filecoin-project/bacalhau/pkg/test/scenario"
 	"github.com/stretchr/testify/suite"
 )
 
 type DevstackConcurrencySuite struct {
-	scenario.ScenarioRunner
+	testscenario.ScenarioRunner
 }
 
 // In order for 'go test' to run this suite, we need to create
@@ -25,7 +26,7 @@ func TestDevstackConcurrencySuite(t *testing.T) {
 
 func (suite *DevstackConcurrencySuite) TestConcurrencyLimit() {
 
-	testCase := scenario.WasmHelloWorld
+	testCase := testscenario.WasmHelloWorld
 	testCase.Stack = &scenario.StackConfig{
 		DevStackOptions: &devstack.DevStackOptions{NumberOfHybridNodes: 3},
 	}
@@ -13,12 +13,13 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/job"
 	_ "github.com/filecoin-project/bacalhau/pkg/logger"
 	"github.com/filecoin-project/bacalhau/pkg/model"
-	"github.com/filecoin-project/bacalhau/pkg/test/scenario"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
+	testscenario "github.com/filecoin-project/bacalhau/pkg/test/scenario"
 	"github.com/stretchr/testify/suite"
 )
 
 type DevstackErrorLogsSuite struct {
-	scenario.ScenarioRunner
+	testscenario.ScenarioRunner
 }
 
 func TestDevstackErrorLogsSuite(t *testing.T) {
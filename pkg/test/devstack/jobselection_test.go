@@ -11,12 +11,13 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/job"
 	_ "github.com/filecoin-project/bacalhau/pkg/logger"
 	"github.com/filecoin-project/bacalhau/pkg/model"
-	"github.com/filecoin-project/bacalhau/pkg/test/scenario"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
+	testscenario "github.com/filecoin-project/bacalhau/pkg/test/scenario"
 	"github.com/stretchr/testify/suite"
 )
 
 type DevstackJobSelectionSuite struct {
-	scenario.ScenarioRunner
+	testscenario.ScenarioRunner
 }
 
 // In order for 'go test' to run this suite, we need to create
@@ -48,10 +49,10 @@ func (suite *DevstackJobSelectionSuite) TestSelectAllJobs() {
 					JobSelectionPolicy: testCase.policy,
 				}),
 			},
-			Inputs:   scenario.PartialAdd(testCase.addFilesCount, scenario.WasmCsvTransform.Inputs),
-			Contexts: scenario.WasmCsvTransform.Contexts,
-			Outputs:  scenario.WasmCsvTransform.Outputs,
-			Spec:     scenario.WasmCsvTransform.Spec,
+			Inputs:   scenario.PartialAdd(testCase.addFilesCount, testscenario.WasmCsvTransform.Inputs),
+			Contexts: testscenario.WasmCsvTransform.Contexts,
+			Outputs:  testscenario.WasmCsvTransform.Outputs,
+			Spec:     testscenario.WasmCsvTransform.Spec,
 			Deal:     model.Deal{Concurrency: testCase.nodeCount},
 			JobCheckers: []job.CheckStatesFunction{
 				job.WaitDontExceedCount(testCase.expectedAccepts),
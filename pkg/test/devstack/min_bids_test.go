@@ -10,12 +10,13 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/job"
 	_ "github.com/filecoin-project/bacalhau/pkg/logger"
 	"github.com/filecoin-project/bacalhau/pkg/model"
-	"github.com/filecoin-project/bacalhau/pkg/test/scenario"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
+	testscenario "github.com/filecoin-project/bacalhau/pkg/test/scenario"
 	"github.com/stretchr/testify/suite"
 )
 
 type MinBidsSuite struct {
-	scenario.ScenarioRunner
+	testscenario.ScenarioRunner
 }
 
 // In order for 'go test' to run this suite, we need to create
@@ -35,7 +36,7 @@ type minBidsTestCase struct {
 }
 
 func (s *MinBidsSuite) testMinBids(testCase minBidsTestCase) {
-	spec := scenario.WasmHelloWorld.Spec
+	spec := testscenario.WasmHelloWorld.Spec
 	spec.Sharding = model.JobShardingConfig{
 		GlobPattern: "/input/*",
 		BatchSize:   1,
@@ -9,12 +9,13 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/job"
 	_ "github.com/filecoin-project/bacalhau/pkg/logger"
 	"github.com/filecoin-project/bacalhau/pkg/model"
-	"github.com/filecoin-project/bacalhau/pkg/test/scenario"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
+	testscenario "github.com/filecoin-project/bacalhau/pkg/test/scenario"
 	"github.com/stretchr/testify/suite"
 )
 
 type MultipleCIDSuite struct {
-	scenario.ScenarioRunner
+	testscenario.ScenarioRunner
 }
 
 // In order for 'go test' to run this suite, we need to create
@@ -40,8 +41,8 @@ func (s *MultipleCIDSuite) TestMultipleCIDs() {
 			Verifier:  model.VerifierNoop,
 			Publisher: model.PublisherIpfs,
 			Wasm: model.JobSpecWasm{
-				EntryPoint:  scenario.CatFileToStdout.Spec.Wasm.EntryPoint,
-				EntryModule: scenario.CatFileToStdout.Spec.Wasm.EntryModule,
+				EntryPoint:  testscenario.CatFileToStdout.Spec.Wasm.EntryPoint,
+				EntryModule: testscenario.CatFileToStdout.Spec.Wasm.EntryModule,
 				Parameters: []string{
 					filepath.Join(dirCID1, fileName1),
 					filepath.Join(dirCID2, fileName2),
@@ -8,12 +8,13 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/job"
 	_ "github.com/filecoin-project/bacalhau/pkg/logger"
 	"github.com/filecoin-project/bacalhau/pkg/model"
-	"github.com/filecoin-project/bacalhau/pkg/test/scenario"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
+	testscenario "github.com/filecoin-project/bacalhau/pkg/test/scenario"
 	"github.com/stretchr/testify/suite"
 )
 
 type PublishOnErrorSuite struct {
-	scenario.ScenarioRunner
+	testscenario.ScenarioRunner
 }
 
 // In order for 'go test' to run this suite, we need to create
@@ -32,8 +33,8 @@ func (s *PublishOnErrorSuite) TestPublishOnError() {
 			Verifier:  model.VerifierNoop,
 			Publisher: model.PublisherIpfs,
 			Wasm: model.JobSpecWasm{
-				EntryPoint:  scenario.CatFileToStdout.Spec.Wasm.EntryPoint,
-				EntryModule: scenario.CatFileToStdout.Spec.Wasm.EntryModule,
+				EntryPoint:  testscenario.CatFileToStdout.Spec.Wasm.EntryPoint,
+				EntryModule: testscenario.CatFileToStdout.Spec.Wasm.EntryModule,
 				Parameters: []string{
 					"data/hello.txt",
 					"does/not/exist.txt",
@@ -19,16 +19,18 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/node"
 	"github.com/filecoin-project/bacalhau/pkg/requester/publicapi"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
+	"github.com/filecoin-project/bacalhau/pkg/storage/cache"
 	apicopy "github.com/filecoin-project/bacalhau/pkg/storage/ipfs_apicopy"
 	"github.com/filecoin-project/bacalhau/pkg/system"
-	"github.com/filecoin-project/bacalhau/pkg/test/scenario"
+	testscenario "github.com/filecoin-project/bacalhau/pkg/test/scenario"
 	testutils "github.com/filecoin-project/bacalhau/pkg/test/utils"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
 type ShardingSuite struct {
-	scenario.ScenarioRunner
+	testscenario.ScenarioRunner
 }
 
 // In order for 'go test' to run this suite, we need to create
@@ -86,7 +88,10 @@ func (suite *ShardingSuite) TestExplodeCid() {
 	directoryCid, err := ipfs.AddFileToNodes(ctx, dirPath, stack.IPFSClients[:nodeCount]...)
 	require.NoError(suite.T(), err)
 
-	ipfsProvider, err := apicopy.NewStorage(cm, node.APIAddress())
+	inputCache, err := cache.NewTemporaryCache(cm, 0)
+	require.NoError(suite.T(), err)
+
+	ipfsProvider, err := apicopy.NewStorage(cm, inputCache, node.APIAddress())
 	require.NoError(suite.T(), err)
 
 	results, err := ipfsProvider.Explode(ctx, model.StorageSpec{
@@ -229,7 +234,7 @@ func (suite *ShardingSuite) TestNoShards() {
 		Engine:    model.EngineWasm,
 		Verifier:  model.VerifierNoop,
 		Publisher: model.PublisherNoop,
-		Wasm:      scenario.WasmHelloWorld.Spec.Wasm,
+		Wasm:      testscenario.WasmHelloWorld.Spec.Wasm,
 		Inputs: []model.StorageSpec{
 			{
 				StorageSource: model.StorageSourceIPFS,
@@ -277,7 +282,7 @@ func (suite *ShardingSuite) TestExplodeVideos() {
 			ExecutorConfig: noop.ExecutorConfig{},
 		},
 		Inputs:   scenario.StoredFile(dirPath, "/inputs"),
-		Contexts: scenario.WasmHelloWorld.Contexts,
+		Contexts: testscenario.WasmHelloWorld.Contexts,
 		Spec: model.Spec{
 			Engine:    model.EngineNoop,
 			Verifier:  model.VerifierNoop,
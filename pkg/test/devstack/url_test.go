@@ -14,13 +14,14 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/job"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/node"
-	"github.com/filecoin-project/bacalhau/pkg/test/scenario"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
+	testscenario "github.com/filecoin-project/bacalhau/pkg/test/scenario"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
 type URLTestSuite struct {
-	scenario.ScenarioRunner
+	testscenario.ScenarioRunner
 }
 
 func TestURLTests(t *testing.T) {
@@ -74,8 +75,8 @@ func runURLTest(
 			Verifier:  model.VerifierNoop,
 			Publisher: model.PublisherIpfs,
 			Wasm: model.JobSpecWasm{
-				EntryPoint:  scenario.CatFileToStdout.Spec.Wasm.EntryPoint,
-				EntryModule: scenario.CatFileToStdout.Spec.Wasm.EntryModule,
+				EntryPoint:  testscenario.CatFileToStdout.Spec.Wasm.EntryPoint,
+				EntryModule: testscenario.CatFileToStdout.Spec.Wasm.EntryModule,
 				Parameters: []string{
 					testCase.mount1,
 					testCase.mount2,
@@ -232,8 +233,8 @@ func (s *URLTestSuite) TestIPFSURLCombo() {
 			Verifier:  model.VerifierNoop,
 			Publisher: model.PublisherIpfs,
 			Wasm: model.JobSpecWasm{
-				EntryPoint:  scenario.CatFileToStdout.Spec.Wasm.EntryPoint,
-				EntryModule: scenario.CatFileToStdout.Spec.Wasm.EntryModule,
+				EntryPoint:  testscenario.CatFileToStdout.Spec.Wasm.EntryPoint,
+				EntryModule: testscenario.CatFileToStdout.Spec.Wasm.EntryModule,
 				Parameters: []string{
 					urlmount,
 					path.Join(ipfsmount, ipfsfile),
@@ -7,11 +7,11 @@ import (
 
 	"github.com/filecoin-project/bacalhau/pkg/docker"
 	"github.com/filecoin-project/bacalhau/pkg/model"
-	"github.com/filecoin-project/bacalhau/pkg/test/scenario"
+	testscenario "github.com/filecoin-project/bacalhau/pkg/test/scenario"
 )
 
 func TestScenarios(t *testing.T) {
-	for name, testCase := range scenario.GetAllScenarios() {
+	for name, testCase := range testscenario.GetAllScenarios() {
 		t.Run(
 			name,
 			func(t *testing.T) {
@@ -15,6 +15,7 @@ import (
 	_ "github.com/filecoin-project/bacalhau/pkg/logger"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/storage"
+	"github.com/filecoin-project/bacalhau/pkg/storage/cache"
 	apicopy "github.com/filecoin-project/bacalhau/pkg/storage/ipfs_apicopy"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/spf13/cobra"
@@ -50,7 +51,11 @@ func (suite *IPFSHostStorageSuite) TestIpfsApiCopyFile() {
 		func(ctx context.Context, cm *system.CleanupManager, api string) (
 			storage.Storage, error) {
 
-			return apicopy.NewStorage(cm, api)
+			inputCache, err := cache.NewTemporaryCache(cm, 0)
+			if err != nil {
+				return nil, err
+			}
+			return apicopy.NewStorage(cm, inputCache, api)
 		},
 	)
 }
@@ -62,7 +67,11 @@ func (suite *IPFSHostStorageSuite) TestIPFSAPICopyFolder() {
 		func(ctx context.Context, cm *system.CleanupManager, api string) (
 			storage.Storage, error) {
 
-			return apicopy.NewStorage(cm, api)
+			inputCache, err := cache.NewTemporaryCache(cm, 0)
+			if err != nil {
+				return nil, err
+			}
+			return apicopy.NewStorage(cm, inputCache, api)
 		},
 	)
 }
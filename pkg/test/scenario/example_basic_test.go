@@ -4,12 +4,13 @@ import (
 	"testing"
 
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
 	"github.com/stretchr/testify/suite"
 )
 
-var basicScenario Scenario = Scenario{
-	Inputs:   StoredText("hello, world!", "/inputs"),
-	Contexts: StoredFile("../../../testdata/wasm/cat/main.wasm", "/job"),
+var basicScenario scenario.Scenario = scenario.Scenario{
+	Inputs:   scenario.StoredText("hello, world!", "/inputs"),
+	Contexts: scenario.StoredFile("../../../testdata/wasm/cat/main.wasm", "/job"),
 	Outputs:  []model.StorageSpec{},
 	Spec: model.Spec{
 		Engine: model.EngineWasm,
@@ -17,8 +18,8 @@ var basicScenario Scenario = Scenario{
 			EntryPoint: "_start",
 		},
 	},
-	ResultsChecker: FileEquals(model.DownloadFilenameStdout, "hello, world!\n"),
-	JobCheckers:    WaitUntilSuccessful(1),
+	ResultsChecker: scenario.FileEquals(model.DownloadFilenameStdout, "hello, world!\n"),
+	JobCheckers:    scenario.WaitUntilSuccessful(1),
 }
 
 type ExampleTest struct {
@@ -8,11 +8,12 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/executor"
 	"github.com/filecoin-project/bacalhau/pkg/executor/noop"
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
 	"github.com/stretchr/testify/suite"
 )
 
-var noopScenario Scenario = Scenario{
-	Stack: &StackConfig{
+var noopScenario scenario.Scenario = scenario.Scenario{
+	Stack: &scenario.StackConfig{
 		ExecutorConfig: noop.ExecutorConfig{
 			ExternalHooks: noop.ExecutorConfigExternalHooks{
 				JobHandler: func(ctx context.Context, shard model.JobShard, resultsDir string) (*model.RunCommandResult, error) {
@@ -27,8 +28,8 @@ var noopScenario Scenario = Scenario{
 			EntryPoint: "_start",
 		},
 	},
-	ResultsChecker: FileEquals(model.DownloadFilenameStdout, "hello, world!\n"),
-	JobCheckers:    WaitUntilSuccessful(1),
+	ResultsChecker: scenario.FileEquals(model.DownloadFilenameStdout, "hello, world!\n"),
+	JobCheckers:    scenario.WaitUntilSuccessful(1),
 }
 
 type NoopTest struct {
@@ -1,21 +1,15 @@
+// Package scenario provides the ScenarioRunner test suite, which runs the
+// scenarios of pkg/scenario against a devstack, and scenarios shared by tests.
 package scenario
 
 import (
 	"context"
-	"path/filepath"
-	"strings"
-	"time"
-
-	"github.com/filecoin-project/bacalhau/pkg/downloader/ipfs"
-
-	"github.com/filecoin-project/bacalhau/pkg/downloader"
 
 	"github.com/filecoin-project/bacalhau/pkg/devstack"
 	"github.com/filecoin-project/bacalhau/pkg/docker"
-	"github.com/filecoin-project/bacalhau/pkg/job"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/node"
-	"github.com/filecoin-project/bacalhau/pkg/requester/publicapi"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 	testutils "github.com/filecoin-project/bacalhau/pkg/test/utils"
 	"github.com/stretchr/testify/require"
@@ -60,25 +54,11 @@ func (s *ScenarioRunner) TearDownTest() {
 	s.Span.End()
 }
 
-func (s *ScenarioRunner) prepareStorage(stack *devstack.DevStack, getStorage SetupStorage) []model.StorageSpec {
-	if getStorage == nil {
-		return []model.StorageSpec{}
-	}
-
-	clients := stack.IPFSClients()
-	require.GreaterOrEqual(s.T(), len(clients), 1, "No IPFS clients to upload to?")
-
-	storageList, stErr := getStorage(s.Ctx, model.StorageSourceIPFS, stack.IPFSClients()...)
-	require.NoError(s.T(), stErr)
-
-	return storageList
-}
-
 // Set up the test devstack according to the passed options. By default, the
 // devstack will have 1 node with local only data and no timeouts.
-func (s *ScenarioRunner) setupStack(config *StackConfig) (*devstack.DevStack, *system.CleanupManager) {
+func (s *ScenarioRunner) setupStack(config *scenario.StackConfig) (*devstack.DevStack, *system.CleanupManager) {
 	if config == nil {
-		config = &StackConfig{}
+		config = &scenario.StackConfig{}
 	}
 
 	if config.DevStackOptions == nil {
@@ -110,11 +90,11 @@ func (s *ScenarioRunner) setupStack(config *StackConfig) (*devstack.DevStack, *s
 //
 // Spin up a devstack, execute the job, check the results, and tear down the
 // devstack.
-func (s *ScenarioRunner) RunScenario(scenario Scenario) (resultsDir string) {
-	spec := scenario.Spec
+func (s *ScenarioRunner) RunScenario(testCase scenario.Scenario) (resultsDir string) {
+	spec := testCase.Spec
 	docker.MaybeNeedDocker(s.T(), spec.Engine == model.EngineDocker)
 
-	stack, cm := s.setupStack(scenario.Stack)
+	stack, cm := s.setupStack(testCase.Stack)
 
 	// Check that the stack has the appropriate executor installed
 	for _, node := range stack.Nodes {
@@ -128,79 +108,16 @@ func (s *ScenarioRunner) RunScenario(scenario Scenario) (resultsDir string) {
 
 	// TODO: assert network connectivity
 
-	// Setup storage
-	spec.Inputs = s.prepareStorage(stack, scenario.Inputs)
-	spec.Contexts = s.prepareStorage(stack, scenario.Contexts)
-	spec.Outputs = scenario.Outputs
-	if spec.Outputs == nil {
-		spec.Outputs = []model.StorageSpec{}
-	}
-
-	// Setup job and submit
-	j, err := model.NewJobWithSaneProductionDefaults()
-	require.NoError(s.T(), err)
-
-	j.Spec = spec
-	require.True(s.T(), model.IsValidEngine(j.Spec.Engine))
-	if !model.IsValidVerifier(j.Spec.Verifier) {
-		j.Spec.Verifier = model.VerifierNoop
-	}
-	if !model.IsValidPublisher(j.Spec.Publisher) {
-		j.Spec.Publisher = model.PublisherIpfs
-	}
-
-	j.Spec.Deal = scenario.Deal
-	if j.Spec.Deal.Concurrency < 1 {
-		j.Spec.Deal.Concurrency = 1
-	}
-
-	apiClient := publicapi.NewRequesterAPIClient(stack.Nodes[0].APIServer.GetURI())
-	submittedJob, submitError := apiClient.Submit(s.Ctx, j)
-	if scenario.SubmitChecker == nil {
-		scenario.SubmitChecker = SubmitJobSuccess()
-	}
-	err = scenario.SubmitChecker(submittedJob, submitError)
-	require.NoError(s.T(), err)
-
-	// exit if the test expects submission to fail as no further assertions can be made
-	if submitError != nil {
-		return
-	}
-	// Wait for job to complete
-	resolver := apiClient.GetJobStateResolver()
-	checkers := scenario.JobCheckers
-	shards := job.GetJobTotalExecutionCount(submittedJob)
-	err = resolver.Wait(s.Ctx, submittedJob.Metadata.ID, shards, checkers...)
-	require.NoError(s.T(), err)
-
-	// Check outputs
-	results, err := apiClient.GetResults(s.Ctx, submittedJob.Metadata.ID)
+	target, err := scenario.NewDevStackTarget(s.Ctx, stack)
 	require.NoError(s.T(), err)
 
 	resultsDir = s.T().TempDir()
-	swarmAddresses, err := stack.Nodes[0].IPFSClient.SwarmAddresses(s.Ctx)
-	require.NoError(s.T(), err)
-
-	downloaderSettings := &model.DownloaderSettings{
-		Timeout:        time.Second * 5,
-		OutputDir:      resultsDir,
-		IPFSSwarmAddrs: strings.Join(swarmAddresses, ","),
-	}
-
-	ipfsDownloader := ipfs.NewIPFSDownloader(cm, downloaderSettings)
-	require.NoError(s.T(), err)
-
-	downloaderProvider := downloader.NewMappedDownloaderProvider(map[model.StorageSourceType]downloader.Downloader{
-		model.StorageSourceIPFS: ipfsDownloader,
-	})
-
-	err = downloader.DownloadJob(s.Ctx, spec.Outputs, results, downloaderProvider, downloaderSettings)
+	submittedJob, err := scenario.Run(s.Ctx, cm, target, testCase, resultsDir)
 	require.NoError(s.T(), err)
 
-	if scenario.ResultsChecker != nil {
-		err = scenario.ResultsChecker(filepath.Join(resultsDir, model.DownloadVolumesFolderName))
-		require.NoError(s.T(), err)
+	// no results can be checked if the test expects submission to fail
+	if submittedJob == nil {
+		return ""
 	}
-
 	return resultsDir
 }
@@ -2,6 +2,7 @@ package scenario
 
 import (
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
 	"github.com/filecoin-project/bacalhau/testdata/wasm/cat"
 	"github.com/filecoin-project/bacalhau/testdata/wasm/csv"
 	"github.com/filecoin-project/bacalhau/testdata/wasm/env"
@@ -14,31 +15,31 @@ const simpleOutputPath = "/output_data/output_file.txt"
 const stdoutString = model.DownloadFilenameStdout
 const catProgram = "cat " + simpleMountPath + " > " + simpleOutputPath
 
-var CatFileToStdout = Scenario{
-	Inputs: StoredText(
+var CatFileToStdout = scenario.Scenario{
+	Inputs: scenario.StoredText(
 		helloWorld,
 		simpleMountPath,
 	),
-	ResultsChecker: ManyChecks(
-		FileEquals(model.DownloadFilenameStderr, ""),
-		FileEquals(model.DownloadFilenameStdout, helloWorld),
+	ResultsChecker: scenario.ManyChecks(
+		scenario.FileEquals(model.DownloadFilenameStderr, ""),
+		scenario.FileEquals(model.DownloadFilenameStdout, helloWorld),
 	),
 	Spec: model.Spec{
 		Engine: model.EngineWasm,
 		Wasm: model.JobSpecWasm{
 			EntryPoint:  "_start",
-			EntryModule: InlineData(cat.Program()),
+			EntryModule: scenario.InlineData(cat.Program()),
 			Parameters:  []string{simpleMountPath},
 		},
 	},
 }
 
-var CatFileToVolume = Scenario{
-	Inputs: StoredText(
+var CatFileToVolume = scenario.Scenario{
+	Inputs: scenario.StoredText(
 		catProgram,
 		simpleMountPath,
 	),
-	ResultsChecker: FileEquals(
+	ResultsChecker: scenario.FileEquals(
 		"test/output_file.txt",
 		catProgram,
 	),
@@ -60,12 +61,12 @@ var CatFileToVolume = Scenario{
 	},
 }
 
-var GrepFile = Scenario{
-	Inputs: StoredFile(
+var GrepFile = scenario.Scenario{
+	Inputs: scenario.StoredFile(
 		"../../../testdata/grep_file.txt",
 		simpleMountPath,
 	),
-	ResultsChecker: FileContains(
+	ResultsChecker: scenario.FileContains(
 		stdoutString,
 		"kiwi is delicious",
 		2,
@@ -83,12 +84,12 @@ var GrepFile = Scenario{
 	},
 }
 
-var SedFile = Scenario{
-	Inputs: StoredFile(
+var SedFile = scenario.Scenario{
+	Inputs: scenario.StoredFile(
 		"../../../testdata/sed_file.txt",
 		simpleMountPath,
 	),
-	ResultsChecker: FileContains(
+	ResultsChecker: scenario.FileContains(
 		stdoutString,
 		"LISBON",
 		5, //nolint:gomnd // magic number ok for testing
@@ -107,12 +108,12 @@ var SedFile = Scenario{
 	},
 }
 
-var AwkFile = Scenario{
-	Inputs: StoredFile(
+var AwkFile = scenario.Scenario{
+	Inputs: scenario.StoredFile(
 		"../../../testdata/awk_file.txt",
 		simpleMountPath,
 	),
-	ResultsChecker: FileContains(
+	ResultsChecker: scenario.FileContains(
 		stdoutString,
 		"LISBON",
 		501, //nolint:gomnd // magic number appropriate for test
@@ -131,8 +132,8 @@ var AwkFile = Scenario{
 	},
 }
 
-var WasmHelloWorld = Scenario{
-	ResultsChecker: FileEquals(
+var WasmHelloWorld = scenario.Scenario{
+	ResultsChecker: scenario.FileEquals(
 		stdoutString,
 		"Hello, world!\n",
 	),
@@ -140,14 +141,14 @@ var WasmHelloWorld = Scenario{
 		Engine: model.EngineWasm,
 		Wasm: model.JobSpecWasm{
 			EntryPoint:  "_start",
-			EntryModule: InlineData(noop.Program()),
+			EntryModule: scenario.InlineData(noop.Program()),
 			Parameters:  []string{},
 		},
 	},
 }
 
-var WasmEnvVars = Scenario{
-	ResultsChecker: FileContains(
+var WasmEnvVars = scenario.Scenario{
+	ResultsChecker: scenario.FileContains(
 		"stdout",
 		"AWESOME=definitely\nTEST=yes\n",
 		3, //nolint:gomnd // magic number appropriate for test
@@ -156,7 +157,7 @@ var WasmEnvVars = Scenario{
 		Engine: model.EngineWasm,
 		Wasm: model.JobSpecWasm{
 			EntryPoint:  "_start",
-			EntryModule: InlineData(env.Program()),
+			EntryModule: scenario.InlineData(env.Program()),
 			EnvironmentVariables: map[string]string{
 				"TEST":    "yes",
 				"AWESOME": "definitely",
@@ -165,12 +166,12 @@ var WasmEnvVars = Scenario{
 	},
 }
 
-var WasmCsvTransform = Scenario{
-	Inputs: StoredFile(
+var WasmCsvTransform = scenario.Scenario{
+	Inputs: scenario.StoredFile(
 		"../../../testdata/wasm/csv/inputs",
 		"/inputs",
 	),
-	ResultsChecker: FileContains(
+	ResultsChecker: scenario.FileContains(
 		"outputs/parents-children.csv",
 		"http://www.wikidata.org/entity/Q14949904,Tugela,http://www.wikidata.org/entity/Q1001792,Makybe Diva",
 		269, //nolint:gomnd // magic number appropriate for test
@@ -179,7 +180,7 @@ var WasmCsvTransform = Scenario{
 		Engine: model.EngineWasm,
 		Wasm: model.JobSpecWasm{
 			EntryPoint:  "_start",
-			EntryModule: InlineData(csv.Program()),
+			EntryModule: scenario.InlineData(csv.Program()),
 			Parameters: []string{
 				"inputs/horses.csv",
 				"outputs/parents-children.csv",
@@ -194,8 +195,8 @@ var WasmCsvTransform = Scenario{
 	},
 }
 
-func GetAllScenarios() map[string]Scenario {
-	return map[string]Scenario{
+func GetAllScenarios() map[string]scenario.Scenario {
+	return map[string]scenario.Scenario{
 		"cat_file_to_stdout": CatFileToStdout,
 		"cat_file_to_volume": CatFileToVolume,
 		"grep_file":          GrepFile,
@@ -5,13 +5,14 @@ import (
 
 	"github.com/filecoin-project/bacalhau/pkg/devstack"
 	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/scenario"
 	"github.com/filecoin-project/bacalhau/pkg/system"
-	"github.com/filecoin-project/bacalhau/pkg/test/scenario"
+	testscenario "github.com/filecoin-project/bacalhau/pkg/test/scenario"
 	"github.com/stretchr/testify/suite"
 )
 
 type SimulatorSuite struct {
-	scenario.ScenarioRunner
+	testscenario.ScenarioRunner
 }
 
 // In order for 'go test' to run this suite, we need to create
@@ -31,8 +32,8 @@ func (suite *SimulatorSuite) TestSimulatorSanity() {
 		Spec: model.Spec{
 			Engine: model.EngineWasm,
 			Wasm: model.JobSpecWasm{
-				EntryPoint:  scenario.WasmHelloWorld.Spec.Wasm.EntryPoint,
-				EntryModule: scenario.WasmHelloWorld.Spec.Wasm.EntryModule,
+				EntryPoint:  testscenario.WasmHelloWorld.Spec.Wasm.EntryPoint,
+				EntryModule: testscenario.WasmHelloWorld.Spec.Wasm.EntryModule,
 				Parameters:  []string{},
 			},
 		},
@@ -78,6 +78,10 @@ func handleCallbackStream[Request any](
 
 	// TODO: validate which context to user here, and whether running in a goroutine is ok
 	newCtx := logger.ContextWithNodeIDLogger(context.Background(), stream.Conn().LocalPeer().String())
-	go f(newCtx, *request)
+	newCtx, span := startHandlerSpan(newCtx, stream, request)
+	go func() {
+		defer span.End()
+		f(newCtx, *request)
+	}()
 	stream.Close()
 }
@@ -7,10 +7,12 @@ import (
 
 	"github.com/filecoin-project/bacalhau/pkg/compute"
 	"github.com/filecoin-project/bacalhau/pkg/logger"
+	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/rs/zerolog/log"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 type CallbackProxyParams struct {
@@ -40,25 +42,25 @@ func (p *CallbackProxy) RegisterLocalComputeCallback(callback compute.Callback)
 }
 
 func (p *CallbackProxy) OnRunComplete(ctx context.Context, result compute.RunResult) {
-	proxyCallbackRequest(ctx, p, result.RoutingMetadata, OnRunComplete, result, func(ctx2 context.Context) {
+	proxyCallbackRequest(ctx, p, result.RoutingMetadata, OnRunComplete, &result, func(ctx2 context.Context) {
 		p.localCallback.OnRunComplete(ctx2, result)
 	})
 }
 
 func (p *CallbackProxy) OnPublishComplete(ctx context.Context, result compute.PublishResult) {
-	proxyCallbackRequest(ctx, p, result.RoutingMetadata, OnPublishComplete, result, func(ctx2 context.Context) {
+	proxyCallbackRequest(ctx, p, result.RoutingMetadata, OnPublishComplete, &result, func(ctx2 context.Context) {
 		p.localCallback.OnPublishComplete(ctx2, result)
 	})
 }
 
 func (p *CallbackProxy) OnCancelComplete(ctx context.Context, result compute.CancelResult) {
-	proxyCallbackRequest(ctx, p, result.RoutingMetadata, OnCancelComplete, result, func(ctx2 context.Context) {
+	proxyCallbackRequest(ctx, p, result.RoutingMetadata, OnCancelComplete, &result, func(ctx2 context.Context) {
 		p.localCallback.OnCancelComplete(ctx2, result)
 	})
 }
 
 func (p *CallbackProxy) OnComputeFailure(ctx context.Context, result compute.ComputeError) {
-	proxyCallbackRequest(ctx, p, result.RoutingMetadata, OnComputeFailure, result, func(ctx2 context.Context) {
+	proxyCallbackRequest(ctx, p, result.RoutingMetadata, OnComputeFailure, &result, func(ctx2 context.Context) {
 		p.localCallback.OnComputeFailure(ctx2, result)
 	})
 }
@@ -76,9 +78,13 @@ func proxyCallbackRequest(
 		} else {
 			// TODO: validate which context to user here, and whether running in a goroutine is ok
 			ctx2 := logger.ContextWithNodeIDLogger(context.Background(), p.host.ID().String())
-			go selfDialFunc(ctx2)
+			go selfDialFunc(system.ContextWithTraceOf(ctx2, ctx))
 		}
 	} else {
+		var span oteltrace.Span
+		ctx, span = system.GetTracer().Start(ctx, string(protocolID), oteltrace.WithSpanKind(oteltrace.SpanKindClient))
+		defer span.End()
+
 		// decode the destination peer ID string value
 		targetPeerID := resultInfo.TargetPeerID
 		peerID, err := peer.Decode(targetPeerID)
@@ -87,7 +93,8 @@ func proxyCallbackRequest(
 			return
 		}
 
-		// deserialize the request object
+		// carry the trace over to the destination peer, and deserialize the request object
+		InjectTraceContext(ctx, request)
 		data, err := json.Marshal(request)
 		if err != nil {
 			log.Ctx(ctx).Error().Err(err).Msgf("%s: failed to marshal request", reflect.TypeOf(request))
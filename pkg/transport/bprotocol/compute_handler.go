@@ -7,9 +7,11 @@ import (
 
 	"github.com/filecoin-project/bacalhau/pkg/compute"
 	"github.com/filecoin-project/bacalhau/pkg/logger"
+	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/rs/zerolog/log"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 type ComputeHandlerParams struct {
@@ -89,6 +91,9 @@ func handleStream[Request any, Response any](
 		return
 	}
 
+	ctx, span := startHandlerSpan(ctx, stream, request)
+	defer span.End()
+
 	response, err := f(ctx, *request)
 	if err != nil {
 		log.Ctx(ctx).Error().Msgf("error delegating %s: %s", reflect.TypeOf(request), err)
@@ -104,3 +109,14 @@ func handleStream[Request any, Response any](
 	}
 	stream.Close()
 }
+
+// startHandlerSpan continues the trace the request was sent in, with this node in its baggage, and starts the span
+// of handling the request.
+func startHandlerSpan(ctx context.Context, stream network.Stream, request interface{}) (context.Context, oteltrace.Span) {
+	ctx = ExtractTraceContext(ctx, request)
+	ctx = system.AddNodeIDToBaggage(ctx, stream.Conn().LocalPeer().String())
+	ctx, span := system.GetTracer().Start(ctx, string(stream.Protocol()), oteltrace.WithSpanKind(oteltrace.SpanKindServer))
+	system.AddJobIDFromBaggageToSpan(ctx, span)
+	system.AddNodeIDFromBaggageToSpan(ctx, span)
+	return ctx, span
+}
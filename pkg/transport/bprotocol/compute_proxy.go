@@ -7,9 +7,11 @@ import (
 	"reflect"
 
 	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 type ComputeProxyParams struct {
@@ -39,6 +41,7 @@ func (p *ComputeProxy) RegisterLocalComputeEndpoint(endpoint compute.Endpoint) {
 }
 
 func (p *ComputeProxy) AskForBid(ctx context.Context, request compute.AskForBidRequest) (compute.AskForBidResponse, error) {
+	ctx = system.AddJobIDToBaggage(ctx, request.Job.Metadata.ID)
 	if request.TargetPeerID == p.host.ID().String() {
 		if p.localEndpoint == nil {
 			return compute.AskForBidResponse{}, fmt.Errorf("unable to dial to self, unless a local compute endpoint is provided")
@@ -111,6 +114,9 @@ func proxyRequest[Request any, Response any](
 	destPeerID string,
 	protocolID protocol.ID,
 	request Request) (Response, error) {
+	ctx, span := system.GetTracer().Start(ctx, string(protocolID), oteltrace.WithSpanKind(oteltrace.SpanKindClient))
+	defer span.End()
+
 	// response object
 	response := new(Response)
 
@@ -120,7 +126,8 @@ func proxyRequest[Request any, Response any](
 		return *response, fmt.Errorf("%s: failed to decode peer ID %s: %w", reflect.TypeOf(request), destPeerID, err)
 	}
 
-	// deserialize the request object
+	// carry the trace over to the destination peer, and deserialize the request object
+	InjectTraceContext(ctx, &request)
 	data, err := json.Marshal(request)
 	if err != nil {
 		return *response, fmt.Errorf("%s: failed to marshal request: %w", reflect.TypeOf(request), err)
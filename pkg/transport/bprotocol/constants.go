@@ -8,6 +8,7 @@ const (
 	ResultAcceptedProtocolID = "/bacalhau/compute/result_accepted/1.0.0"
 	ResultRejectedProtocolID = "/bacalhau/compute/result_rejected/1.0.0"
 	CancelProtocolID         = "/bacalhau/compute/cancel/1.0.0"
+	FetchResultsProtocolID   = "/bacalhau/compute/fetch_results/1.0.0"
 
 	CallbackServiceName = "bacalhau.callback"
 	OnRunComplete       = "/bacalhau/callback/on_run_complete/1.0.0"
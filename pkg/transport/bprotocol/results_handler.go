@@ -0,0 +1,89 @@
+package bprotocol
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/logger"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/rs/zerolog/log"
+)
+
+type ResultsHandlerParams struct {
+	Host            host.Host
+	ResultsProvider compute.ResultsProvider
+}
+
+// ResultsHandler serves the results a compute node kept to requesters that fetch them over libp2p. The response is
+// followed by the tar archive of the results on the same stream. Requests must come from the peer they name as their
+// source, which the results provider checks is the requester of the job.
+type ResultsHandler struct {
+	host            host.Host
+	resultsProvider compute.ResultsProvider
+}
+
+func NewResultsHandler(params ResultsHandlerParams) *ResultsHandler {
+	handler := &ResultsHandler{
+		host:            params.Host,
+		resultsProvider: params.ResultsProvider,
+	}
+
+	handler.host.SetStreamHandler(FetchResultsProtocolID, handler.onFetchResults)
+	return handler
+}
+
+//nolint:errcheck
+func (h *ResultsHandler) onFetchResults(stream network.Stream) {
+	ctx := logger.ContextWithNodeIDLogger(context.Background(), h.host.ID().String())
+	if err := stream.Scope().SetService(ComputeServiceName); err != nil {
+		log.Ctx(ctx).Debug().Msgf("error attaching stream to compute service: %s", err)
+		stream.Reset()
+		return
+	}
+
+	var request compute.FetchResultsRequest
+	err := json.NewDecoder(stream).Decode(&request)
+	if err != nil {
+		log.Ctx(ctx).Error().Msgf("error decoding fetch results request: %s", err)
+		stream.Reset()
+		return
+	}
+
+	ctx, span := startHandlerSpan(ctx, stream, &request)
+	defer span.End()
+
+	var response compute.FetchResultsResponse
+	var results io.ReadCloser
+	if remotePeer := stream.Conn().RemotePeer().String(); request.SourcePeerID != remotePeer {
+		// the results provider trusts the source to be the peer that fetches the results
+		response.Err = fmt.Sprintf("request from peer %s claims to come from %s", remotePeer, request.SourcePeerID)
+	} else if results, err = h.resultsProvider.FetchResults(ctx, request); err != nil {
+		response.Err = err.Error()
+	} else {
+		defer results.Close()
+	}
+
+	// the response is written without the newline json.Encoder ends it with, as the archive follows it directly
+	responseData, err := json.Marshal(response)
+	if err == nil {
+		_, err = stream.Write(responseData)
+	}
+	if err != nil {
+		log.Ctx(ctx).Error().Msgf("error encoding fetch results response: %s", err)
+		stream.Reset()
+		return
+	}
+	if results != nil {
+		_, err = io.Copy(stream, results)
+		if err != nil {
+			log.Ctx(ctx).Error().Msgf("error streaming results of job %s: %s", request.JobID, err)
+			stream.Reset()
+			return
+		}
+	}
+	stream.Close()
+}
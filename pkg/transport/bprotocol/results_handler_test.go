@@ -0,0 +1,55 @@
+//go:build unit || !integration
+
+package bprotocol
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// sourceResultsProvider serves the source of the request as the results.
+type sourceResultsProvider struct{}
+
+func (sourceResultsProvider) FetchResults(_ context.Context, request compute.FetchResultsRequest) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(request.SourcePeerID)), nil
+}
+
+func TestFetchResultsFromClaimedSource(t *testing.T) {
+	mn := mocknet.New()
+	defer mn.Close()
+	computeHost, err := mn.GenPeer()
+	require.NoError(t, err)
+	requesterHost, err := mn.GenPeer()
+	require.NoError(t, err)
+	require.NoError(t, mn.LinkAll())
+	NewResultsHandler(ResultsHandlerParams{Host: computeHost, ResultsProvider: sourceResultsProvider{}})
+
+	// the proxy fetches as the node it runs on
+	proxy := NewResultsProxy(ResultsProxyParams{Host: requesterHost})
+	results, err := proxy.FetchResults(context.Background(), compute.FetchResultsRequest{
+		RoutingMetadata: compute.RoutingMetadata{SourcePeerID: "someone-else", TargetPeerID: computeHost.ID().String()},
+	})
+	require.NoError(t, err)
+	defer results.Close()
+	source, err := io.ReadAll(results)
+	require.NoError(t, err)
+	require.Equal(t, requesterHost.ID().String(), string(source))
+
+	// and requests claiming to come from another node are rejected
+	stream, err := requesterHost.NewStream(context.Background(), computeHost.ID(), FetchResultsProtocolID)
+	require.NoError(t, err)
+	defer stream.Close()
+	require.NoError(t, json.NewEncoder(stream).Encode(compute.FetchResultsRequest{
+		RoutingMetadata: compute.RoutingMetadata{SourcePeerID: "someone-else"},
+	}))
+	var response compute.FetchResultsResponse
+	require.NoError(t, json.NewDecoder(stream).Decode(&response))
+	require.Contains(t, response.Err, "claims to come from someone-else")
+}
@@ -0,0 +1,97 @@
+package bprotocol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+type ResultsProxyParams struct {
+	Host                 host.Host
+	LocalResultsProvider compute.ResultsProvider // optional in case this host is also a compute node keeping results
+}
+
+// ResultsProxy fetches the results that compute nodes kept from the remote compute nodes, or from the local compute
+// node if the target peer ID is the same as the local host, and a LocalResultsProvider is provided.
+type ResultsProxy struct {
+	host                 host.Host
+	localResultsProvider compute.ResultsProvider
+}
+
+func NewResultsProxy(params ResultsProxyParams) *ResultsProxy {
+	return &ResultsProxy{
+		host:                 params.Host,
+		localResultsProvider: params.LocalResultsProvider,
+	}
+}
+
+func (p *ResultsProxy) RegisterLocalResultsProvider(provider compute.ResultsProvider) {
+	p.localResultsProvider = provider
+}
+
+func (p *ResultsProxy) FetchResults(ctx context.Context, request compute.FetchResultsRequest) (io.ReadCloser, error) {
+	// compute nodes only serve results to the requester of the job
+	request.SourcePeerID = p.host.ID().String()
+	if request.TargetPeerID == p.host.ID().String() {
+		if p.localResultsProvider == nil {
+			return nil, fmt.Errorf("unable to dial to self, unless a local results provider is provided")
+		}
+		return p.localResultsProvider.FetchResults(ctx, request)
+	}
+
+	ctx, span := system.GetTracer().Start(ctx, string(FetchResultsProtocolID), oteltrace.WithSpanKind(oteltrace.SpanKindClient))
+	defer span.End()
+	ctx = system.AddJobIDToBaggage(ctx, request.JobID)
+
+	peerID, err := peer.Decode(request.TargetPeerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode peer ID %s: %w", request.TargetPeerID, err)
+	}
+	stream, err := p.host.NewStream(ctx, peerID, FetchResultsProtocolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stream to peer %s: %w", request.TargetPeerID, err)
+	}
+
+	InjectTraceContext(ctx, &request)
+	err = json.NewEncoder(stream).Encode(request)
+	if err != nil {
+		_ = stream.Reset()
+		return nil, fmt.Errorf("failed to write request to peer %s: %w", request.TargetPeerID, err)
+	}
+
+	// the archive follows the response on the stream, including whatever the decoder read past the response
+	decoder := json.NewDecoder(stream)
+	var response compute.FetchResultsResponse
+	err = decoder.Decode(&response)
+	if err != nil {
+		_ = stream.Reset()
+		return nil, fmt.Errorf("failed to decode response from peer %s: %w", request.TargetPeerID, err)
+	}
+	if response.Err != "" {
+		_ = stream.Close()
+		return nil, errors.New(response.Err)
+	}
+	return resultsStream{Reader: io.MultiReader(decoder.Buffered(), stream), stream: stream}, nil
+}
+
+// resultsStream reads the archive of results from a stream, and closes the stream once done.
+type resultsStream struct {
+	io.Reader
+	stream network.Stream
+}
+
+func (r resultsStream) Close() error {
+	return r.stream.Close()
+}
+
+// Compile-time interface check:
+var _ compute.ResultsProvider = (*ResultsProxy)(nil)
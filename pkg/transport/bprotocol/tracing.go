@@ -0,0 +1,39 @@
+package bprotocol
+
+import (
+	"context"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// routedMessage is implemented by pointers to the requests and callback results sent over bprotocol, as they all
+// embed compute.RoutingMetadata.
+type routedMessage interface {
+	Routing() *compute.RoutingMetadata
+}
+
+// InjectTraceContext stores the trace of ctx and its baggage in the routing metadata of message, which must be a
+// pointer to a request or callback result, so that the node receiving it can continue the trace.
+func InjectTraceContext(ctx context.Context, message interface{}) {
+	routed, ok := message.(routedMessage)
+	if !ok {
+		return
+	}
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) > 0 {
+		routed.Routing().TraceContext = carrier
+	}
+}
+
+// ExtractTraceContext returns ctx continuing the trace, and carrying the baggage, that the sender of message stored
+// in its routing metadata.
+func ExtractTraceContext(ctx context.Context, message interface{}) context.Context {
+	routed, ok := message.(routedMessage)
+	if !ok || len(routed.Routing().TraceContext) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(routed.Routing().TraceContext))
+}
@@ -0,0 +1,51 @@
+//go:build unit || !integration
+
+package bprotocol
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceContextCrossesTheWire(t *testing.T) {
+	ctx := system.AddJobIDToBaggage(context.Background(), "job-id")
+	ctx, span := system.GetTracer().Start(ctx, "requester")
+	defer span.End()
+
+	request := compute.BidAcceptedRequest{ExecutionID: "execution-id"}
+	InjectTraceContext(ctx, &request)
+	require.NotEmpty(t, request.TraceContext)
+
+	data, err := json.Marshal(request)
+	require.NoError(t, err)
+	received := new(compute.BidAcceptedRequest)
+	require.NoError(t, json.Unmarshal(data, received))
+
+	remoteCtx := ExtractTraceContext(context.Background(), received)
+	remoteSpan := oteltrace.SpanContextFromContext(remoteCtx)
+	require.True(t, remoteSpan.IsRemote())
+	require.Equal(t, span.SpanContext().TraceID(), remoteSpan.TraceID())
+	require.Equal(t, span.SpanContext().SpanID(), remoteSpan.SpanID())
+	require.Equal(t, "job-id", baggage.FromContext(remoteCtx).Member(model.TracerAttributeNameJobID).Value())
+}
+
+func TestNoTraceContext(t *testing.T) {
+	request := compute.RunResult{}
+	InjectTraceContext(context.Background(), &request)
+	require.Empty(t, request.TraceContext)
+
+	data, err := json.Marshal(request)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "TraceContext")
+
+	ctx := ExtractTraceContext(context.Background(), &request)
+	require.False(t, oteltrace.SpanContextFromContext(ctx).IsValid())
+}
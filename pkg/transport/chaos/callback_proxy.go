@@ -0,0 +1,85 @@
+package chaos
+
+import (
+	"context"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/logger"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/rs/zerolog/log"
+)
+
+type CallbackProxyParams struct {
+	Callback compute.Callback
+	Injector *Injector
+}
+
+// CallbackProxy injects faults into the callbacks a compute node sends to requester nodes through the wrapped
+// callback. Lost callbacks are never delivered, delayed callbacks are delivered in the background so that later
+// callbacks can overtake them, and duplicated callbacks are delivered twice.
+type CallbackProxy struct {
+	callback compute.Callback
+	injector *Injector
+}
+
+func NewCallbackProxy(params CallbackProxyParams) *CallbackProxy {
+	return &CallbackProxy{
+		callback: params.Callback,
+		injector: params.Injector,
+	}
+}
+
+func (p *CallbackProxy) OnRunComplete(ctx context.Context, result compute.RunResult) {
+	injectCallback(ctx, p.injector, result.RoutingMetadata, result, p.callback.OnRunComplete)
+}
+
+func (p *CallbackProxy) OnPublishComplete(ctx context.Context, result compute.PublishResult) {
+	injectCallback(ctx, p.injector, result.RoutingMetadata, result, p.callback.OnPublishComplete)
+}
+
+func (p *CallbackProxy) OnCancelComplete(ctx context.Context, result compute.CancelResult) {
+	injectCallback(ctx, p.injector, result.RoutingMetadata, result, p.callback.OnCancelComplete)
+}
+
+func (p *CallbackProxy) OnComputeFailure(ctx context.Context, result compute.ComputeError) {
+	injectCallback(ctx, p.injector, result.RoutingMetadata, result, p.callback.OnComputeFailure)
+}
+
+func injectCallback[Result any](
+	ctx context.Context,
+	injector *Injector,
+	routing compute.RoutingMetadata,
+	result Result,
+	deliver func(context.Context, Result)) {
+	fault := injector.next(routing.TargetPeerID)
+	if fault.lost {
+		log.Ctx(ctx).Debug().Msgf("chaos: dropping %T to %s", result, routing.TargetPeerID)
+		return
+	}
+
+	deliveries := 1
+	if fault.duplicated {
+		log.Ctx(ctx).Debug().Msgf("chaos: duplicating %T to %s", result, routing.TargetPeerID)
+		deliveries = 2
+	}
+	if fault.delay <= 0 {
+		for i := 0; i < deliveries; i++ {
+			deliver(ctx, result)
+		}
+		return
+	}
+
+	// the caller doesn't wait for callbacks to be delivered, so neither do delayed callbacks
+	ctx2 := system.ContextWithTraceOf(logger.ContextWithNodeIDLogger(context.Background(), injector.nodeID), ctx)
+	go func() {
+		if err := sleep(ctx2, fault.delay); err != nil {
+			return
+		}
+		for i := 0; i < deliveries; i++ {
+			deliver(ctx2, result)
+		}
+	}()
+}
+
+// Compile-time interface check:
+var _ compute.Callback = (*CallbackProxy)(nil)
@@ -0,0 +1,223 @@
+//go:build unit || !integration
+
+package chaos
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	requesterID = "requester"
+	computeID   = "compute-id"
+	computeName = "compute-0"
+)
+
+type ChaosSuite struct {
+	suite.Suite
+	endpoint *countingEndpoint
+	callback *countingCallback
+}
+
+func TestChaosSuite(t *testing.T) {
+	suite.Run(t, new(ChaosSuite))
+}
+
+func (s *ChaosSuite) SetupTest() {
+	s.endpoint = &countingEndpoint{}
+	s.callback = &countingCallback{runResults: make(chan compute.RunResult, 10)}
+}
+
+func (s *ChaosSuite) computeProxy(config Config) *ComputeProxy {
+	return NewComputeProxy(ComputeProxyParams{Endpoint: s.endpoint, Injector: newInjector(requesterID, config)})
+}
+
+func (s *ChaosSuite) callbackProxy(config Config) *CallbackProxy {
+	return NewCallbackProxy(CallbackProxyParams{Callback: s.callback, Injector: newInjector(computeID, config)})
+}
+
+func newInjector(nodeID string, config Config) *Injector {
+	return NewInjector(InjectorParams{
+		NodeID: nodeID,
+		Config: config,
+		PeerName: func(peerID string) string {
+			if peerID == computeID {
+				return computeName
+			}
+			return ""
+		},
+	})
+}
+
+func toCompute() compute.RoutingMetadata {
+	return compute.RoutingMetadata{SourcePeerID: requesterID, TargetPeerID: computeID}
+}
+
+func toRequester() compute.RoutingMetadata {
+	return compute.RoutingMetadata{SourcePeerID: computeID, TargetPeerID: requesterID}
+}
+
+func (s *ChaosSuite) TestNoFaults() {
+	_, err := s.computeProxy(Config{}).AskForBid(context.Background(), compute.AskForBidRequest{RoutingMetadata: toCompute()})
+	s.NoError(err)
+	s.Equal(int32(1), s.endpoint.calls.Load())
+}
+
+func (s *ChaosSuite) TestLostRequest() {
+	proxy := s.computeProxy(Config{Rules: []Rule{{LossProbability: 1}}})
+	_, err := proxy.BidAccepted(context.Background(), compute.BidAcceptedRequest{RoutingMetadata: toCompute()})
+	s.ErrorContains(err, "lost")
+	s.Equal(int32(0), s.endpoint.calls.Load())
+}
+
+func (s *ChaosSuite) TestSelfRequestsAreNotFaulted() {
+	proxy := s.computeProxy(Config{Rules: []Rule{{LossProbability: 1}}})
+	_, err := proxy.BidAccepted(context.Background(), compute.BidAcceptedRequest{
+		RoutingMetadata: compute.RoutingMetadata{SourcePeerID: requesterID, TargetPeerID: requesterID},
+	})
+	s.NoError(err)
+}
+
+func (s *ChaosSuite) TestDuplicatedRequest() {
+	proxy := s.computeProxy(Config{Rules: []Rule{{DuplicateProbability: 1}}})
+	_, err := proxy.ResultAccepted(context.Background(), compute.ResultAcceptedRequest{RoutingMetadata: toCompute()})
+	s.NoError(err)
+	s.Equal(int32(2), s.endpoint.calls.Load())
+}
+
+func (s *ChaosSuite) TestRulesMatchPeers() {
+	proxy := s.computeProxy(Config{Rules: []Rule{
+		{Peers: []string{"other"}, LossProbability: 1},
+		{Peers: []string{computeName}, DuplicateProbability: 1},
+		{LossProbability: 1},
+	}})
+	_, err := proxy.BidRejected(context.Background(), compute.BidRejectedRequest{RoutingMetadata: toCompute()})
+	s.NoError(err)
+	s.Equal(int32(2), s.endpoint.calls.Load())
+}
+
+func (s *ChaosSuite) TestPartition() {
+	proxy := s.computeProxy(Config{Partitions: []Partition{
+		{Peers: []string{computeName}, End: model.Duration(100 * time.Millisecond)},
+	}})
+	_, err := proxy.CancelExecution(context.Background(), compute.CancelExecutionRequest{RoutingMetadata: toCompute()})
+	s.ErrorContains(err, "lost")
+
+	time.Sleep(100 * time.Millisecond)
+	_, err = proxy.CancelExecution(context.Background(), compute.CancelExecutionRequest{RoutingMetadata: toCompute()})
+	s.NoError(err)
+}
+
+func (s *ChaosSuite) TestLatency() {
+	proxy := s.computeProxy(Config{Rules: []Rule{{Latency: model.Duration(50 * time.Millisecond)}}})
+	start := time.Now()
+	_, err := proxy.AskForBid(context.Background(), compute.AskForBidRequest{RoutingMetadata: toCompute()})
+	s.NoError(err)
+	s.GreaterOrEqual(time.Since(start), 50*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = proxy.AskForBid(ctx, compute.AskForBidRequest{RoutingMetadata: toCompute()})
+	s.ErrorIs(err, context.Canceled)
+}
+
+func (s *ChaosSuite) TestCallbacks() {
+	s.callbackProxy(Config{Rules: []Rule{{LossProbability: 1}}}).OnRunComplete(
+		context.Background(), compute.RunResult{RoutingMetadata: toRequester()})
+	s.Empty(s.callback.runResults)
+
+	s.callbackProxy(Config{Rules: []Rule{{DuplicateProbability: 1}}}).OnRunComplete(
+		context.Background(), compute.RunResult{RoutingMetadata: toRequester()})
+	s.Len(s.callback.runResults, 2)
+}
+
+func (s *ChaosSuite) TestDelayedCallbacksAreReordered() {
+	proxy := s.callbackProxy(Config{Rules: []Rule{{Peers: []string{"slow"}, Latency: model.Duration(50 * time.Millisecond)}}})
+	proxy.OnRunComplete(context.Background(), compute.RunResult{
+		RoutingMetadata:   compute.RoutingMetadata{SourcePeerID: computeID, TargetPeerID: "slow"},
+		ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: "first"},
+	})
+	proxy.OnRunComplete(context.Background(), compute.RunResult{
+		RoutingMetadata:   toRequester(),
+		ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: "second"},
+	})
+
+	for _, expected := range []string{"second", "first"} {
+		select {
+		case result := <-s.callback.runResults:
+			s.Equal(expected, result.ExecutionID)
+		case <-time.After(5 * time.Second):
+			s.FailNow("callback not delivered")
+		}
+	}
+}
+
+func TestSeedIsRepeatable(t *testing.T) {
+	config := Config{Seed: 42, Rules: []Rule{{LossProbability: 0.5, Jitter: model.Duration(time.Second)}}}
+	first, second := newInjector(requesterID, config), newInjector(requesterID, config)
+	for i := 0; i < 20; i++ {
+		require.Equal(t, first.next(computeID), second.next(computeID))
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	require.NoError(t, Config{Rules: []Rule{{LossProbability: 1}}, Partitions: []Partition{{End: model.Duration(time.Second)}}}.Validate())
+	require.Error(t, Config{Rules: []Rule{{LossProbability: 1.5}}}.Validate())
+	require.Error(t, Config{Rules: []Rule{{DuplicateProbability: -1}}}.Validate())
+	require.Error(t, Config{Rules: []Rule{{Latency: model.Duration(-time.Second)}}}.Validate())
+	require.Error(t, Config{Partitions: []Partition{{Start: model.Duration(time.Second), End: model.Duration(time.Second)}}}.Validate())
+}
+
+// countingEndpoint counts the requests it receives.
+type countingEndpoint struct {
+	calls atomic.Int32
+}
+
+func (e *countingEndpoint) AskForBid(context.Context, compute.AskForBidRequest) (compute.AskForBidResponse, error) {
+	e.calls.Add(1)
+	return compute.AskForBidResponse{}, nil
+}
+
+func (e *countingEndpoint) BidAccepted(context.Context, compute.BidAcceptedRequest) (compute.BidAcceptedResponse, error) {
+	e.calls.Add(1)
+	return compute.BidAcceptedResponse{}, nil
+}
+
+func (e *countingEndpoint) BidRejected(context.Context, compute.BidRejectedRequest) (compute.BidRejectedResponse, error) {
+	e.calls.Add(1)
+	return compute.BidRejectedResponse{}, nil
+}
+
+func (e *countingEndpoint) ResultAccepted(context.Context, compute.ResultAcceptedRequest) (compute.ResultAcceptedResponse, error) {
+	e.calls.Add(1)
+	return compute.ResultAcceptedResponse{}, nil
+}
+
+func (e *countingEndpoint) ResultRejected(context.Context, compute.ResultRejectedRequest) (compute.ResultRejectedResponse, error) {
+	e.calls.Add(1)
+	return compute.ResultRejectedResponse{}, nil
+}
+
+func (e *countingEndpoint) CancelExecution(context.Context, compute.CancelExecutionRequest) (compute.CancelExecutionResponse, error) {
+	e.calls.Add(1)
+	return compute.CancelExecutionResponse{}, nil
+}
+
+type countingCallback struct {
+	runResults chan compute.RunResult
+}
+
+func (c *countingCallback) OnRunComplete(_ context.Context, result compute.RunResult) {
+	c.runResults <- result
+}
+
+func (c *countingCallback) OnPublishComplete(context.Context, compute.PublishResult) {}
+func (c *countingCallback) OnCancelComplete(context.Context, compute.CancelResult)   {}
+func (c *countingCallback) OnComputeFailure(context.Context, compute.ComputeError)   {}
@@ -0,0 +1,82 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/rs/zerolog/log"
+)
+
+type ComputeProxyParams struct {
+	Endpoint compute.Endpoint
+	Injector *Injector
+}
+
+// ComputeProxy injects faults into the requests a requester node sends to compute nodes through the wrapped
+// endpoint. Lost requests fail as if the compute node couldn't be reached, and duplicated requests are sent again
+// after the first response.
+type ComputeProxy struct {
+	endpoint compute.Endpoint
+	injector *Injector
+}
+
+func NewComputeProxy(params ComputeProxyParams) *ComputeProxy {
+	return &ComputeProxy{
+		endpoint: params.Endpoint,
+		injector: params.Injector,
+	}
+}
+
+func (p *ComputeProxy) AskForBid(ctx context.Context, request compute.AskForBidRequest) (compute.AskForBidResponse, error) {
+	return injectRequest(ctx, p.injector, request.RoutingMetadata, request, p.endpoint.AskForBid)
+}
+
+func (p *ComputeProxy) BidAccepted(ctx context.Context, request compute.BidAcceptedRequest) (compute.BidAcceptedResponse, error) {
+	return injectRequest(ctx, p.injector, request.RoutingMetadata, request, p.endpoint.BidAccepted)
+}
+
+func (p *ComputeProxy) BidRejected(ctx context.Context, request compute.BidRejectedRequest) (compute.BidRejectedResponse, error) {
+	return injectRequest(ctx, p.injector, request.RoutingMetadata, request, p.endpoint.BidRejected)
+}
+
+func (p *ComputeProxy) ResultAccepted(ctx context.Context, request compute.ResultAcceptedRequest) (compute.ResultAcceptedResponse, error) {
+	return injectRequest(ctx, p.injector, request.RoutingMetadata, request, p.endpoint.ResultAccepted)
+}
+
+func (p *ComputeProxy) ResultRejected(ctx context.Context, request compute.ResultRejectedRequest) (compute.ResultRejectedResponse, error) {
+	return injectRequest(ctx, p.injector, request.RoutingMetadata, request, p.endpoint.ResultRejected)
+}
+
+func (p *ComputeProxy) CancelExecution(
+	ctx context.Context, request compute.CancelExecutionRequest) (compute.CancelExecutionResponse, error) {
+	return injectRequest(ctx, p.injector, request.RoutingMetadata, request, p.endpoint.CancelExecution)
+}
+
+func injectRequest[Request any, Response any](
+	ctx context.Context,
+	injector *Injector,
+	routing compute.RoutingMetadata,
+	request Request,
+	send func(context.Context, Request) (Response, error)) (Response, error) {
+	fault := injector.next(routing.TargetPeerID)
+	if fault.lost {
+		log.Ctx(ctx).Debug().Msgf("chaos: dropping %T to %s", request, routing.TargetPeerID)
+		return *new(Response), fmt.Errorf("chaos: %T to %s was lost", request, routing.TargetPeerID)
+	}
+	if err := sleep(ctx, fault.delay); err != nil {
+		return *new(Response), err
+	}
+
+	response, err := send(ctx, request)
+	if fault.duplicated {
+		log.Ctx(ctx).Debug().Msgf("chaos: duplicating %T to %s", request, routing.TargetPeerID)
+		if _, duplicateErr := send(ctx, request); duplicateErr != nil {
+			log.Ctx(ctx).Debug().Err(duplicateErr).Msgf("chaos: duplicate %T to %s failed", request, routing.TargetPeerID)
+		}
+	}
+	return response, err
+}
+
+// Compile-time interface check:
+var _ compute.Endpoint = (*ComputeProxy)(nil)
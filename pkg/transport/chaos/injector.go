@@ -0,0 +1,161 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"golang.org/x/exp/slices"
+)
+
+// Config describes the faults injected into the messages a node exchanges with other nodes.
+type Config struct {
+	// Seed makes the injected faults repeatable. A random seed is used if zero.
+	Seed int64 `json:"Seed,omitempty"`
+	// Rules are matched in order against the peer a message is exchanged with, and the first matching rule decides
+	// the faults injected into the message.
+	Rules []Rule `json:"Rules,omitempty"`
+	// Partitions cut the node off from peers for a period of time.
+	Partitions []Partition `json:"Partitions,omitempty"`
+}
+
+// Rule injects faults into the messages exchanged with the listed peers, or with all peers if none are listed.
+// Peers are listed by node ID or by the name label of devstack nodes.
+type Rule struct {
+	Peers []string `json:"Peers,omitempty"`
+	// LossProbability is the probability of a message being lost.
+	LossProbability float64 `json:"LossProbability,omitempty"`
+	// DuplicateProbability is the probability of a message being delivered twice.
+	DuplicateProbability float64 `json:"DuplicateProbability,omitempty"`
+	// Latency delays every message.
+	Latency model.Duration `json:"Latency,omitempty"`
+	// Jitter is a random delay of up to this long added to Latency, which reorders messages sent close together.
+	Jitter model.Duration `json:"Jitter,omitempty"`
+}
+
+// Partition cuts the node off from the listed peers, or from all peers if none are listed, from Start until End
+// after the node started. The partition never heals if End is zero.
+type Partition struct {
+	Peers []string       `json:"Peers,omitempty"`
+	Start model.Duration `json:"Start,omitempty"`
+	End   model.Duration `json:"End,omitempty"`
+}
+
+// Validate checks that the probabilities and periods of the config are possible.
+func (c Config) Validate() error {
+	for i, rule := range c.Rules {
+		if rule.LossProbability < 0 || rule.LossProbability > 1 {
+			return fmt.Errorf("rule %d: LossProbability must be between 0 and 1", i)
+		}
+		if rule.DuplicateProbability < 0 || rule.DuplicateProbability > 1 {
+			return fmt.Errorf("rule %d: DuplicateProbability must be between 0 and 1", i)
+		}
+		if rule.Latency < 0 || rule.Jitter < 0 {
+			return fmt.Errorf("rule %d: Latency and Jitter must not be negative", i)
+		}
+	}
+	for i, partition := range c.Partitions {
+		if partition.Start < 0 || partition.End < 0 {
+			return fmt.Errorf("partition %d: Start and End must not be negative", i)
+		}
+		if partition.End != 0 && partition.End <= partition.Start {
+			return fmt.Errorf("partition %d: End must be after Start", i)
+		}
+	}
+	return nil
+}
+
+type InjectorParams struct {
+	// NodeID is the node the faults are injected into. Messages the node sends to itself are never faulted.
+	NodeID string
+	Config Config
+	// PeerName is optional and returns the name peers can be listed by in rules and partitions
+	PeerName func(peerID string) string
+}
+
+// Injector decides the faults injected into each message a node exchanges with another node.
+type Injector struct {
+	nodeID   string
+	config   Config
+	peerName func(peerID string) string
+	started  time.Time
+	mu       sync.Mutex
+	random   *rand.Rand
+}
+
+func NewInjector(params InjectorParams) *Injector {
+	seed := params.Config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Injector{
+		nodeID:   params.NodeID,
+		config:   params.Config,
+		peerName: params.PeerName,
+		started:  time.Now(),
+		random:   rand.New(rand.NewSource(seed)), //nolint:gosec // faults don't need a secure source
+	}
+}
+
+// fault is what happens to a single message.
+type fault struct {
+	lost       bool
+	duplicated bool
+	delay      time.Duration
+}
+
+func (i *Injector) next(peerID string) fault {
+	if peerID == i.nodeID {
+		return fault{}
+	}
+	peerName := ""
+	if i.peerName != nil {
+		peerName = i.peerName(peerID)
+	}
+	matches := func(peers []string) bool {
+		return len(peers) == 0 || slices.Contains(peers, peerID) || (peerName != "" && slices.Contains(peers, peerName))
+	}
+
+	elapsed := model.Duration(time.Since(i.started))
+	for _, partition := range i.config.Partitions {
+		if matches(partition.Peers) && elapsed >= partition.Start && (partition.End == 0 || elapsed < partition.End) {
+			return fault{lost: true}
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, rule := range i.config.Rules {
+		if !matches(rule.Peers) {
+			continue
+		}
+		result := fault{
+			lost:       i.random.Float64() < rule.LossProbability,
+			duplicated: i.random.Float64() < rule.DuplicateProbability,
+			delay:      time.Duration(rule.Latency),
+		}
+		if rule.Jitter > 0 {
+			result.delay += time.Duration(i.random.Int63n(int64(rule.Jitter)))
+		}
+		return result
+	}
+	return fault{}
+}
+
+// sleep waits for the delay of a message, or until the context is done.
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,32 @@
+package httpprotocol
+
+import (
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/transport/bprotocol"
+)
+
+type CallbackHandlerParams struct {
+	Server   *Server
+	Callback compute.Callback
+}
+
+// CallbackHandler registers handlers for callback events with the transport server, and delegates the handling of
+// the events to the provided callback.
+type CallbackHandler struct {
+	server   *Server
+	callback compute.Callback
+}
+
+func NewCallbackHandler(params CallbackHandlerParams) *CallbackHandler {
+	handler := &CallbackHandler{
+		server:   params.Server,
+		callback: params.Callback,
+	}
+
+	nodeID := handler.server.nodeID
+	handler.server.handle(bprotocol.OnRunComplete, handleCallback[compute.RunResult](nodeID, handler.callback.OnRunComplete))
+	handler.server.handle(bprotocol.OnPublishComplete, handleCallback[compute.PublishResult](nodeID, handler.callback.OnPublishComplete))
+	handler.server.handle(bprotocol.OnCancelComplete, handleCallback[compute.CancelResult](nodeID, handler.callback.OnCancelComplete))
+	handler.server.handle(bprotocol.OnComputeFailure, handleCallback[compute.ComputeError](nodeID, handler.callback.OnComputeFailure))
+	return handler
+}
@@ -0,0 +1,97 @@
+package httpprotocol
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/logger"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/transport/bprotocol"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/rs/zerolog/log"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+type CallbackProxyParams struct {
+	NodeID        string
+	Client        *Client
+	RequesterURL  string
+	LocalCallback compute.Callback
+}
+
+// CallbackProxy is a proxy for a compute.Callback that sends compute callbacks over HTTP to the requester node the
+// compute node registered with, or locally if the node is the requester and a LocalCallback is provided.
+type CallbackProxy struct {
+	nodeID        string
+	client        *Client
+	requesterURL  string
+	localCallback compute.Callback
+}
+
+func NewCallbackProxy(params CallbackProxyParams) *CallbackProxy {
+	return &CallbackProxy{
+		nodeID:        params.NodeID,
+		client:        params.Client,
+		requesterURL:  params.RequesterURL,
+		localCallback: params.LocalCallback,
+	}
+}
+
+func (p *CallbackProxy) RegisterLocalComputeCallback(callback compute.Callback) {
+	p.localCallback = callback
+}
+
+func (p *CallbackProxy) OnRunComplete(ctx context.Context, result compute.RunResult) {
+	p.proxyCallback(ctx, result.RoutingMetadata, bprotocol.OnRunComplete, &result, func(ctx2 context.Context) {
+		p.localCallback.OnRunComplete(ctx2, result)
+	})
+}
+
+func (p *CallbackProxy) OnPublishComplete(ctx context.Context, result compute.PublishResult) {
+	p.proxyCallback(ctx, result.RoutingMetadata, bprotocol.OnPublishComplete, &result, func(ctx2 context.Context) {
+		p.localCallback.OnPublishComplete(ctx2, result)
+	})
+}
+
+func (p *CallbackProxy) OnCancelComplete(ctx context.Context, result compute.CancelResult) {
+	p.proxyCallback(ctx, result.RoutingMetadata, bprotocol.OnCancelComplete, &result, func(ctx2 context.Context) {
+		p.localCallback.OnCancelComplete(ctx2, result)
+	})
+}
+
+func (p *CallbackProxy) OnComputeFailure(ctx context.Context, result compute.ComputeError) {
+	p.proxyCallback(ctx, result.RoutingMetadata, bprotocol.OnComputeFailure, &result, func(ctx2 context.Context) {
+		p.localCallback.OnComputeFailure(ctx2, result)
+	})
+}
+
+func (p *CallbackProxy) proxyCallback(
+	ctx context.Context,
+	routing compute.RoutingMetadata,
+	path protocol.ID,
+	request interface{},
+	selfDialFunc func(ctx2 context.Context)) {
+	if routing.TargetPeerID == p.nodeID {
+		if p.localCallback == nil {
+			log.Ctx(ctx).Error().Msgf("unable to call self, unless a local compute callback is provided")
+		} else {
+			ctx2 := logger.ContextWithNodeIDLogger(context.Background(), p.nodeID)
+			go selfDialFunc(system.ContextWithTraceOf(ctx2, ctx))
+		}
+		return
+	}
+
+	ctx, span := system.GetTracer().Start(ctx, string(path), oteltrace.WithSpanKind(oteltrace.SpanKindClient))
+	defer span.End()
+
+	body, err := p.client.post(ctx, p.requesterURL, path, request)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msgf("%s: failed to send callback to requester %s", reflect.TypeOf(request), routing.TargetPeerID)
+		return
+	}
+	_ = body.Close()
+}
+
+// Compile-time interface check:
+var _ compute.Callback = (*CallbackProxy)(nil)
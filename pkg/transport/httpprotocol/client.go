@@ -0,0 +1,101 @@
+package httpprotocol
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/transport/bprotocol"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// clientTimeout bounds requests between nodes, apart from fetching results which streams for as long as it takes.
+const clientTimeout = 2 * time.Minute
+
+// maxErrorLength bounds how much of an error response is kept in the error returned to the caller.
+const maxErrorLength = 1024
+
+type ClientParams struct {
+	TLSConfig *tls.Config // optional, to dial other nodes over HTTP/2 with mutual TLS
+}
+
+// Client sends requests to the transport servers of other nodes.
+type Client struct {
+	client *http.Client
+}
+
+func NewClient(params ClientParams) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if params.TLSConfig != nil {
+		transport.TLSClientConfig = params.TLSConfig
+		transport.ForceAttemptHTTP2 = true
+	}
+	return &Client{
+		client: &http.Client{Transport: transport},
+	}
+}
+
+// post sends the request to the path of the node at baseURL, carrying the trace of ctx over, and returns the body of
+// the response, which the caller must close.
+func (c *Client) post(ctx context.Context, baseURL string, path protocol.ID, request interface{}) (io.ReadCloser, error) {
+	bprotocol.InjectTraceContext(ctx, request)
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to marshal request: %w", reflect.TypeOf(request), err)
+	}
+
+	url := strings.TrimSuffix(baseURL, "/") + string(path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to send request to %s: %w", reflect.TypeOf(request), url, err)
+	}
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		defer res.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorLength))
+		return nil, fmt.Errorf("%s: %s responded with %s: %s",
+			reflect.TypeOf(request), url, res.Status, strings.TrimSpace(string(message)))
+	}
+	return res.Body, nil
+}
+
+// proxyRequest sends the request to the node at baseURL, and decodes its response.
+func proxyRequest[Request any, Response any](
+	ctx context.Context,
+	client *Client,
+	baseURL string,
+	path protocol.ID,
+	request Request) (Response, error) {
+	ctx, span := system.GetTracer().Start(ctx, string(path), oteltrace.WithSpanKind(oteltrace.SpanKindClient))
+	defer span.End()
+
+	response := new(Response)
+	ctx, cancel := context.WithTimeout(ctx, clientTimeout)
+	defer cancel()
+
+	body, err := client.post(ctx, baseURL, path, &request)
+	if err != nil {
+		return *response, err
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(response)
+	if err != nil {
+		return *response, fmt.Errorf("%s: failed to decode response from %s: %w", reflect.TypeOf(request), baseURL, err)
+	}
+	return *response, nil
+}
@@ -0,0 +1,40 @@
+package httpprotocol
+
+import (
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/transport/bprotocol"
+)
+
+type ComputeHandlerParams struct {
+	Server          *Server
+	ComputeEndpoint compute.Endpoint
+}
+
+// ComputeHandler registers handlers for compute requests with the transport server, and delegates the requests to
+// the compute endpoint.
+type ComputeHandler struct {
+	server          *Server
+	computeEndpoint compute.Endpoint
+}
+
+func NewComputeHandler(params ComputeHandlerParams) *ComputeHandler {
+	handler := &ComputeHandler{
+		server:          params.Server,
+		computeEndpoint: params.ComputeEndpoint,
+	}
+
+	nodeID := handler.server.nodeID
+	handler.server.handle(bprotocol.AskForBidProtocolID,
+		handleRequest[compute.AskForBidRequest, compute.AskForBidResponse](nodeID, handler.computeEndpoint.AskForBid))
+	handler.server.handle(bprotocol.BidAcceptedProtocolID,
+		handleRequest[compute.BidAcceptedRequest, compute.BidAcceptedResponse](nodeID, handler.computeEndpoint.BidAccepted))
+	handler.server.handle(bprotocol.BidRejectedProtocolID,
+		handleRequest[compute.BidRejectedRequest, compute.BidRejectedResponse](nodeID, handler.computeEndpoint.BidRejected))
+	handler.server.handle(bprotocol.ResultAcceptedProtocolID,
+		handleRequest[compute.ResultAcceptedRequest, compute.ResultAcceptedResponse](nodeID, handler.computeEndpoint.ResultAccepted))
+	handler.server.handle(bprotocol.ResultRejectedProtocolID,
+		handleRequest[compute.ResultRejectedRequest, compute.ResultRejectedResponse](nodeID, handler.computeEndpoint.ResultRejected))
+	handler.server.handle(bprotocol.CancelProtocolID,
+		handleRequest[compute.CancelExecutionRequest, compute.CancelExecutionResponse](nodeID, handler.computeEndpoint.CancelExecution))
+	return handler
+}
@@ -0,0 +1,130 @@
+package httpprotocol
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/transport/bprotocol"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+type ComputeProxyParams struct {
+	NodeID        string
+	Client        *Client
+	Registry      *Registry
+	LocalEndpoint compute.Endpoint // optional in case this node is also a compute node and to allow local calls
+}
+
+// ComputeProxy is a proxy to a compute node endpoint that forwards requests over HTTP to the compute nodes registered
+// in the Registry, or to a local compute node if the target is this node and a LocalEndpoint is provided.
+type ComputeProxy struct {
+	nodeID        string
+	client        *Client
+	registry      *Registry
+	localEndpoint compute.Endpoint
+}
+
+func NewComputeProxy(params ComputeProxyParams) *ComputeProxy {
+	return &ComputeProxy{
+		nodeID:        params.NodeID,
+		client:        params.Client,
+		registry:      params.Registry,
+		localEndpoint: params.LocalEndpoint,
+	}
+}
+
+func (p *ComputeProxy) RegisterLocalComputeEndpoint(endpoint compute.Endpoint) {
+	p.localEndpoint = endpoint
+}
+
+func (p *ComputeProxy) AskForBid(ctx context.Context, request compute.AskForBidRequest) (compute.AskForBidResponse, error) {
+	if p.isLocal(request.RoutingMetadata) {
+		if p.localEndpoint == nil {
+			return compute.AskForBidResponse{}, errNoLocalEndpoint
+		}
+		return p.localEndpoint.AskForBid(ctx, request)
+	}
+	ctx = system.AddJobIDToBaggage(ctx, request.Job.Metadata.ID)
+	return proxyComputeRequest[compute.AskForBidRequest, compute.AskForBidResponse](
+		ctx, p, request.RoutingMetadata, bprotocol.AskForBidProtocolID, request)
+}
+
+func (p *ComputeProxy) BidAccepted(ctx context.Context, request compute.BidAcceptedRequest) (compute.BidAcceptedResponse, error) {
+	if p.isLocal(request.RoutingMetadata) {
+		if p.localEndpoint == nil {
+			return compute.BidAcceptedResponse{}, errNoLocalEndpoint
+		}
+		return p.localEndpoint.BidAccepted(ctx, request)
+	}
+	return proxyComputeRequest[compute.BidAcceptedRequest, compute.BidAcceptedResponse](
+		ctx, p, request.RoutingMetadata, bprotocol.BidAcceptedProtocolID, request)
+}
+
+func (p *ComputeProxy) BidRejected(ctx context.Context, request compute.BidRejectedRequest) (compute.BidRejectedResponse, error) {
+	if p.isLocal(request.RoutingMetadata) {
+		if p.localEndpoint == nil {
+			return compute.BidRejectedResponse{}, errNoLocalEndpoint
+		}
+		return p.localEndpoint.BidRejected(ctx, request)
+	}
+	return proxyComputeRequest[compute.BidRejectedRequest, compute.BidRejectedResponse](
+		ctx, p, request.RoutingMetadata, bprotocol.BidRejectedProtocolID, request)
+}
+
+func (p *ComputeProxy) ResultAccepted(ctx context.Context, request compute.ResultAcceptedRequest) (compute.ResultAcceptedResponse, error) {
+	if p.isLocal(request.RoutingMetadata) {
+		if p.localEndpoint == nil {
+			return compute.ResultAcceptedResponse{}, errNoLocalEndpoint
+		}
+		return p.localEndpoint.ResultAccepted(ctx, request)
+	}
+	return proxyComputeRequest[compute.ResultAcceptedRequest, compute.ResultAcceptedResponse](
+		ctx, p, request.RoutingMetadata, bprotocol.ResultAcceptedProtocolID, request)
+}
+
+func (p *ComputeProxy) ResultRejected(ctx context.Context, request compute.ResultRejectedRequest) (compute.ResultRejectedResponse, error) {
+	if p.isLocal(request.RoutingMetadata) {
+		if p.localEndpoint == nil {
+			return compute.ResultRejectedResponse{}, errNoLocalEndpoint
+		}
+		return p.localEndpoint.ResultRejected(ctx, request)
+	}
+	return proxyComputeRequest[compute.ResultRejectedRequest, compute.ResultRejectedResponse](
+		ctx, p, request.RoutingMetadata, bprotocol.ResultRejectedProtocolID, request)
+}
+
+func (p *ComputeProxy) CancelExecution(
+	ctx context.Context, request compute.CancelExecutionRequest) (compute.CancelExecutionResponse, error) {
+	if p.isLocal(request.RoutingMetadata) {
+		if p.localEndpoint == nil {
+			return compute.CancelExecutionResponse{}, errNoLocalEndpoint
+		}
+		return p.localEndpoint.CancelExecution(ctx, request)
+	}
+	return proxyComputeRequest[compute.CancelExecutionRequest, compute.CancelExecutionResponse](
+		ctx, p, request.RoutingMetadata, bprotocol.CancelProtocolID, request)
+}
+
+func (p *ComputeProxy) isLocal(routing compute.RoutingMetadata) bool {
+	return routing.TargetPeerID == p.nodeID
+}
+
+var errNoLocalEndpoint = fmt.Errorf("unable to call self, unless a local compute endpoint is provided")
+
+func proxyComputeRequest[Request any, Response any](
+	ctx context.Context,
+	p *ComputeProxy,
+	routing compute.RoutingMetadata,
+	path protocol.ID,
+	request Request) (Response, error) {
+	address, ok := p.registry.Address(routing.TargetPeerID)
+	if !ok {
+		return *new(Response), fmt.Errorf("compute node %s has not registered its address", routing.TargetPeerID)
+	}
+	return proxyRequest[Request, Response](ctx, p.client, address, path, request)
+}
+
+// Compile-time interface check:
+var _ compute.Endpoint = (*ComputeProxy)(nil)
@@ -0,0 +1,59 @@
+package httpprotocol
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// Config of the HTTP transport of a node.
+type Config struct {
+	// ListenAddress is the host:port the transport server of the node listens on.
+	ListenAddress string
+	// Address is the URL other nodes reach the transport server of the node at, which compute nodes register with
+	// their requester node.
+	Address string
+	// RequesterURL is the URL of the transport server of the requester node that compute nodes register with and
+	// send their callbacks to.
+	RequesterURL string
+	// TLSCertFile and TLSKeyFile hold the certificate the node presents to the nodes it talks to, and TLSCAFile the
+	// certificate authority that signed the certificates of all nodes. Connections are only mutually authenticated
+	// with TLS, and use HTTP/2, when all three are set. Otherwise plain HTTP is used, which is only fit for trusted
+	// networks.
+	TLSCertFile string
+	TLSKeyFile  string
+	TLSCAFile   string
+}
+
+// TLS returns the TLS configuration both for serving and for dialing other nodes, or nil if the transport uses plain
+// HTTP.
+func (c Config) TLS() (*tls.Config, error) {
+	if c.TLSCertFile == "" && c.TLSKeyFile == "" && c.TLSCAFile == "" {
+		return nil, nil
+	}
+	if c.TLSCertFile == "" || c.TLSKeyFile == "" || c.TLSCAFile == "" {
+		return nil, fmt.Errorf("mutual TLS needs a certificate, a key and a certificate authority")
+	}
+
+	certificate, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the transport certificate: %w", err)
+	}
+	caPEM, err := os.ReadFile(c.TLSCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the transport certificate authority: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", c.TLSCAFile)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,238 @@
+package httpprotocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/pubsub"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/rs/zerolog/log"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const RegisterProtocolID protocol.ID = "/bacalhau/requester/register/1.0.0"
+
+// DefaultRegistrationTTL is how long a registration is kept without being refreshed. Compute nodes register again
+// each time they publish their node info, which is every 30 seconds by default.
+const DefaultRegistrationTTL = 2 * time.Minute
+
+// registrationClockSkew is how far in the future the timestamp of a registration may be, as clocks of nodes drift.
+const registrationClockSkew = 30 * time.Second
+
+// Registration of a compute node with a requester node.
+type Registration struct {
+	NodeInfo model.NodeInfo
+	// Address is the URL of the transport server of the compute node
+	Address string
+	// Timestamp is when the registration was made, so that it can't be replayed once expired
+	Timestamp time.Time
+	// PublicKey is the marshalled libp2p public key of the compute node, which its ID is derived from
+	PublicKey []byte
+	// Signature of the ID, address and timestamp by the libp2p private key of the compute node
+	Signature []byte
+}
+
+// signedData returns the data the signature of the registration is over.
+func (r Registration) signedData() []byte {
+	return []byte(fmt.Sprintf("%s\n%s\n%d", r.NodeInfo.PeerInfo.ID, r.Address, r.Timestamp.UnixNano()))
+}
+
+// verify checks that the registration was signed by the node it registers, and hasn't expired. It returns the public
+// key of the node.
+func (r Registration) verify(now time.Time, ttl time.Duration) (crypto.PubKey, error) {
+	publicKey, err := crypto.UnmarshalPublicKey(r.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	if !r.NodeInfo.PeerInfo.ID.MatchesPublicKey(publicKey) {
+		return nil, fmt.Errorf("public key does not match node ID")
+	}
+	ok, err := publicKey.Verify(r.signedData(), r.Signature)
+	if err != nil || !ok {
+		return nil, fmt.Errorf("invalid signature")
+	}
+	if r.Timestamp.Before(now.Add(-ttl)) || r.Timestamp.After(now.Add(registrationClockSkew)) {
+		return nil, fmt.Errorf("registration made at %s has expired", r.Timestamp)
+	}
+	return publicKey, nil
+}
+
+type RegistryParams struct {
+	Server       *Server // optional, for requester nodes to accept registrations
+	Client       *Client
+	PrivateKey   crypto.PrivKey // the libp2p key of this node, to sign its registrations
+	Address      string         // URL of the transport server of this node
+	RequesterURL string         // optional, for compute nodes to register with their requester node
+	// Peerstore is optional, for requester nodes to learn the public keys of the nodes that register, which RSA keys
+	// are too large to be derived from the ID of. Registry tokens of jobs are sealed with them.
+	Peerstore peerstore.Peerstore
+	// RegistrationTTL is how long registrations are kept without being refreshed. DefaultRegistrationTTL if zero.
+	RegistrationTTL time.Duration
+}
+
+// registeredNode is the address a node registered at, until the registration expires.
+type registeredNode struct {
+	address   string
+	timestamp time.Time
+	expiresAt time.Time
+}
+
+// Registry discovers nodes through registrations with requester nodes, instead of the libp2p topic of node info.
+// As a pubsub.PubSub of node info, compute nodes publish their node info by registering it with their requester node
+// along with the address of their transport server, and requester nodes deliver the node info of the registrations
+// they receive to their subscriber. Requester nodes look up the addresses of the compute nodes to send requests to
+// in the registry. Registrations are signed with the libp2p key of the compute node, so that a node can't register
+// the address of another, and expire unless they are refreshed.
+type Registry struct {
+	client          *Client
+	privateKey      crypto.PrivKey
+	address         string
+	requesterURL    string
+	peerstore       peerstore.Peerstore
+	registrationTTL time.Duration
+	// local is set when the node is its own requester node, and so registers without going through the server
+	local bool
+
+	subscriber     pubsub.Subscriber[model.NodeInfo]
+	subscriberOnce sync.Once
+	nodes          map[string]registeredNode
+	mu             sync.RWMutex
+}
+
+func NewRegistry(params RegistryParams) *Registry {
+	registry := &Registry{
+		client:          params.Client,
+		privateKey:      params.PrivateKey,
+		address:         params.Address,
+		requesterURL:    params.RequesterURL,
+		peerstore:       params.Peerstore,
+		registrationTTL: params.RegistrationTTL,
+		nodes:           make(map[string]registeredNode),
+	}
+	if registry.registrationTTL == 0 {
+		registry.registrationTTL = DefaultRegistrationTTL
+	}
+	if params.Server != nil {
+		registry.local = params.RequesterURL == params.Address
+		params.Server.handle(RegisterProtocolID, handleRequest(params.Server.nodeID, registry.register))
+	}
+	return registry
+}
+
+// Address returns the URL of the transport server of a registered node, unless its registration has expired.
+func (r *Registry) Address(nodeID string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	node, ok := r.nodes[nodeID]
+	if !ok || time.Now().After(node.expiresAt) {
+		return "", false
+	}
+	return node.address, true
+}
+
+// Publish registers the node info with the requester node.
+func (r *Registry) Publish(ctx context.Context, nodeInfo model.NodeInfo) error {
+	if r.requesterURL == "" {
+		return errors.New("no requester node to register with")
+	}
+	if r.privateKey == nil {
+		return errors.New("no private key to sign the registration with")
+	}
+	publicKey, err := crypto.MarshalPublicKey(r.privateKey.GetPublic())
+	if err != nil {
+		return err
+	}
+	registration := Registration{
+		NodeInfo:  nodeInfo,
+		Address:   r.address,
+		Timestamp: time.Now(),
+		PublicKey: publicKey,
+	}
+	registration.Signature, err = r.privateKey.Sign(registration.signedData())
+	if err != nil {
+		return fmt.Errorf("failed to sign registration: %w", err)
+	}
+	if r.local {
+		_, err := r.register(ctx, registration)
+		return err
+	}
+
+	ctx, span := system.GetTracer().Start(ctx, string(RegisterProtocolID), oteltrace.WithSpanKind(oteltrace.SpanKindClient))
+	defer span.End()
+
+	body, err := r.client.post(ctx, r.requesterURL, RegisterProtocolID, &registration)
+	if err != nil {
+		return err
+	}
+	return body.Close()
+}
+
+func (r *Registry) Subscribe(ctx context.Context, subscriber pubsub.Subscriber[model.NodeInfo]) error {
+	var firstSubscriber bool
+	r.subscriberOnce.Do(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.subscriber = subscriber
+		firstSubscriber = true
+	})
+	if !firstSubscriber {
+		return errors.New("only a single subscriber is allowed. Use ChainedSubscriber to chain multiple subscribers")
+	}
+	return nil
+}
+
+func (r *Registry) Close(ctx context.Context) error {
+	return nil
+}
+
+func (r *Registry) register(ctx context.Context, registration Registration) (struct{}, error) {
+	nodeID := registration.NodeInfo.PeerInfo.ID.String()
+	if registration.Address == "" {
+		return struct{}{}, fmt.Errorf("registration of node %s has no address", nodeID)
+	}
+	now := time.Now()
+	publicKey, err := registration.verify(now, r.registrationTTL)
+	if err != nil {
+		return struct{}{}, fmt.Errorf("rejected registration of node %s: %w", nodeID, err)
+	}
+
+	r.mu.Lock()
+	if previous, ok := r.nodes[nodeID]; ok && !registration.Timestamp.After(previous.timestamp) {
+		r.mu.Unlock()
+		return struct{}{}, fmt.Errorf("rejected registration of node %s: a later registration was already made", nodeID)
+	}
+	for id, node := range r.nodes {
+		if now.After(node.expiresAt) {
+			delete(r.nodes, id)
+		}
+	}
+	r.nodes[nodeID] = registeredNode{
+		address:   registration.Address,
+		timestamp: registration.Timestamp,
+		expiresAt: now.Add(r.registrationTTL),
+	}
+	subscriber := r.subscriber
+	r.mu.Unlock()
+	log.Ctx(ctx).Trace().Msgf("node %s registered at %s", nodeID, registration.Address)
+
+	if r.peerstore != nil {
+		if err = r.peerstore.AddPubKey(registration.NodeInfo.PeerInfo.ID, publicKey); err != nil {
+			return struct{}{}, fmt.Errorf("failed to store public key of node %s: %w", nodeID, err)
+		}
+	}
+
+	if subscriber == nil {
+		return struct{}{}, nil
+	}
+	return struct{}{}, subscriber.Handle(ctx, registration.NodeInfo)
+}
+
+// Compile-time interface check:
+var _ pubsub.PubSub[model.NodeInfo] = (*Registry)(nil)
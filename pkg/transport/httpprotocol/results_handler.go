@@ -0,0 +1,53 @@
+package httpprotocol
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/transport/bprotocol"
+	"github.com/rs/zerolog/log"
+)
+
+type ResultsHandlerParams struct {
+	Server          *Server
+	ResultsProvider compute.ResultsProvider
+}
+
+// ResultsHandler serves the results a compute node kept to requesters that fetch them over HTTP, as a tar archive.
+type ResultsHandler struct {
+	server          *Server
+	resultsProvider compute.ResultsProvider
+}
+
+func NewResultsHandler(params ResultsHandlerParams) *ResultsHandler {
+	handler := &ResultsHandler{
+		server:          params.Server,
+		resultsProvider: params.ResultsProvider,
+	}
+
+	handler.server.handle(bprotocol.FetchResultsProtocolID, handler.onFetchResults)
+	return handler
+}
+
+func (h *ResultsHandler) onFetchResults(res http.ResponseWriter, req *http.Request) {
+	var request compute.FetchResultsRequest
+	if !decodeRequest(res, req, &request) {
+		return
+	}
+	ctx, span := startHandlerSpan(bprotocol.ExtractTraceContext(req.Context(), &request), h.server.nodeID, req.URL.Path)
+	defer span.End()
+
+	results, err := h.resultsProvider.FetchResults(ctx, request)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusNotFound)
+		return
+	}
+	defer results.Close()
+
+	res.Header().Set("Content-Type", "application/x-tar")
+	_, err = io.Copy(res, results)
+	if err != nil {
+		log.Ctx(ctx).Error().Msgf("error streaming results of job %s: %s", request.JobID, err)
+	}
+}
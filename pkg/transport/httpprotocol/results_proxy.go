@@ -0,0 +1,66 @@
+package httpprotocol
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/transport/bprotocol"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+type ResultsProxyParams struct {
+	NodeID               string
+	Client               *Client
+	Registry             *Registry
+	LocalResultsProvider compute.ResultsProvider // optional in case this node is also a compute node keeping results
+}
+
+// ResultsProxy fetches the results that compute nodes kept over HTTP from the compute nodes registered in the
+// Registry, or locally if the target is this node and a LocalResultsProvider is provided.
+type ResultsProxy struct {
+	nodeID               string
+	client               *Client
+	registry             *Registry
+	localResultsProvider compute.ResultsProvider
+}
+
+func NewResultsProxy(params ResultsProxyParams) *ResultsProxy {
+	return &ResultsProxy{
+		nodeID:               params.NodeID,
+		client:               params.Client,
+		registry:             params.Registry,
+		localResultsProvider: params.LocalResultsProvider,
+	}
+}
+
+func (p *ResultsProxy) RegisterLocalResultsProvider(provider compute.ResultsProvider) {
+	p.localResultsProvider = provider
+}
+
+func (p *ResultsProxy) FetchResults(ctx context.Context, request compute.FetchResultsRequest) (io.ReadCloser, error) {
+	// compute nodes only serve results to the requester of the job
+	request.SourcePeerID = p.nodeID
+	if request.TargetPeerID == p.nodeID {
+		if p.localResultsProvider == nil {
+			return nil, fmt.Errorf("unable to call self, unless a local results provider is provided")
+		}
+		return p.localResultsProvider.FetchResults(ctx, request)
+	}
+
+	ctx, span := system.GetTracer().Start(ctx, string(bprotocol.FetchResultsProtocolID),
+		oteltrace.WithSpanKind(oteltrace.SpanKindClient))
+	defer span.End()
+	ctx = system.AddJobIDToBaggage(ctx, request.JobID)
+
+	address, ok := p.registry.Address(request.TargetPeerID)
+	if !ok {
+		return nil, fmt.Errorf("compute node %s has not registered its address", request.TargetPeerID)
+	}
+	return p.client.post(ctx, address, bprotocol.FetchResultsProtocolID, &request)
+}
+
+// Compile-time interface check:
+var _ compute.ResultsProvider = (*ResultsProxy)(nil)
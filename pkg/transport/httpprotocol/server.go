@@ -0,0 +1,153 @@
+package httpprotocol
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/logger"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/transport/bprotocol"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/rs/zerolog/log"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const readHeaderTimeout = 10 * time.Second
+
+type ServerParams struct {
+	NodeID        string
+	ListenAddress string
+	TLSConfig     *tls.Config // optional, to serve HTTP/2 with mutual TLS
+}
+
+// Server serves the handlers of the HTTP transport of a node.
+type Server struct {
+	nodeID        string
+	listenAddress string
+	tlsConfig     *tls.Config
+	mux           *http.ServeMux
+}
+
+func NewServer(params ServerParams) *Server {
+	return &Server{
+		nodeID:        params.NodeID,
+		listenAddress: params.ListenAddress,
+		tlsConfig:     params.TLSConfig,
+		mux:           http.NewServeMux(),
+	}
+}
+
+// Handler returns the handler of all the registered transport handlers.
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+func (s *Server) handle(path protocol.ID, handler http.HandlerFunc) {
+	s.mux.Handle(string(path), handler)
+}
+
+// ListenAndServe serves the transport handlers until the node is cleaned up.
+func (s *Server) ListenAndServe(ctx context.Context, cm *system.CleanupManager) error {
+	srv := http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+		TLSConfig:         s.tlsConfig,
+		BaseContext: func(_ net.Listener) context.Context {
+			return logger.ContextWithNodeIDLogger(context.Background(), s.nodeID)
+		},
+	}
+
+	listener, err := net.Listen("tcp", s.listenAddress)
+	if err != nil {
+		return err
+	}
+	log.Ctx(ctx).Debug().Msgf("Transport server listening for host %s on %s...", s.nodeID, listener.Addr().String())
+
+	cm.RegisterCallback(func() error {
+		return srv.Shutdown(context.Background())
+	})
+
+	if s.tlsConfig != nil {
+		// the certificates are already in the TLS config
+		err = srv.ServeTLS(listener, "", "")
+	} else {
+		err = srv.Serve(listener)
+	}
+	if err == http.ErrServerClosed {
+		return nil // expected error if the server is shut down
+	}
+	return err
+}
+
+// handleRequest decodes the request, continues its trace and delegates it to f, responding with what f returns.
+func handleRequest[Request any, Response any](
+	nodeID string,
+	f func(ctx context.Context, r Request) (Response, error)) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		request := new(Request)
+		if !decodeRequest(res, req, request) {
+			return
+		}
+		ctx, span := startHandlerSpan(bprotocol.ExtractTraceContext(req.Context(), request), nodeID, req.URL.Path)
+		defer span.End()
+
+		response, err := f(ctx, *request)
+		if err != nil {
+			log.Ctx(ctx).Error().Msgf("error delegating %s: %s", reflect.TypeOf(request), err)
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(res).Encode(response)
+		if err != nil {
+			log.Ctx(ctx).Error().Msgf("error encoding %s: %s", reflect.TypeOf(response), err)
+		}
+	}
+}
+
+// handleCallback decodes the callback, and delegates it to f in the background, continuing its trace.
+func handleCallback[Request any](nodeID string, f func(ctx context.Context, r Request)) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		request := new(Request)
+		if !decodeRequest(res, req, request) {
+			return
+		}
+		// the callback outlives the request, so it only keeps its trace
+		ctx := logger.ContextWithNodeIDLogger(context.Background(), nodeID)
+		ctx = system.ContextWithTraceOf(ctx, bprotocol.ExtractTraceContext(req.Context(), request))
+		ctx, span := startHandlerSpan(ctx, nodeID, req.URL.Path)
+		go func() {
+			defer span.End()
+			f(ctx, *request)
+		}()
+		res.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func decodeRequest(res http.ResponseWriter, req *http.Request, request interface{}) bool {
+	if req.Method != http.MethodPost {
+		http.Error(res, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	err := json.NewDecoder(req.Body).Decode(request)
+	if err != nil {
+		log.Ctx(req.Context()).Error().Msgf("error decoding %s: %s", reflect.TypeOf(request), err)
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// startHandlerSpan starts the span of handling a request, with this node in the baggage of its trace.
+func startHandlerSpan(ctx context.Context, nodeID string, path string) (context.Context, oteltrace.Span) {
+	ctx = system.AddNodeIDToBaggage(ctx, nodeID)
+	ctx, span := system.GetTracer().Start(ctx, path, oteltrace.WithSpanKind(oteltrace.SpanKindServer))
+	system.AddJobIDFromBaggageToSpan(ctx, span)
+	system.AddNodeIDFromBaggageToSpan(ctx, span)
+	return ctx, span
+}
@@ -0,0 +1,15 @@
+// Package httpprotocol carries the compute–requester protocol between nodes over HTTP, as an alternative to the
+// libp2p streams of bprotocol for deployments where libp2p connectivity is hard to come by. Requests and callbacks
+// are posted as JSON to the paths of the bprotocol protocol IDs, and compute nodes register with their requester
+// node instead of publishing their node info to a libp2p topic. With TLS configured, connections use HTTP/2 and both
+// ends authenticate each other with certificates signed by the same certificate authority.
+package httpprotocol
+
+// Transport bundles the parts of the HTTP transport that the compute and requester sides of a node share.
+type Transport struct {
+	Server   *Server
+	Client   *Client
+	Registry *Registry
+	// RequesterURL is the URL of the requester node that compute nodes send their callbacks to
+	RequesterURL string
+}
@@ -0,0 +1,515 @@
+//go:build unit || !integration
+
+package httpprotocol
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/bacalhau/pkg/compute"
+	"github.com/filecoin-project/bacalhau/pkg/eventhandler"
+	"github.com/filecoin-project/bacalhau/pkg/localdb/inmemory"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/pubsub"
+	"github.com/filecoin-project/bacalhau/pkg/requester"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/transport/bprotocol"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
+	"github.com/filecoin-project/bacalhau/pkg/verifier/noop"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const requesterNodeID = "QmXaXu9N5GNetatsvwnTfQqNtSeKAD6uCmarbh3LMRYAcF"
+
+var (
+	computeKey, computeNodeID = newTestKey(1)
+	otherKey, _               = newTestKey(2)
+)
+
+// newTestKey returns a libp2p key derived from the seed, and the ID of the node with that key.
+func newTestKey(seed byte) (crypto.PrivKey, string) {
+	key, _, err := crypto.GenerateEd25519Key(bytes.NewReader(bytes.Repeat([]byte{seed}, 32))) //nolint:gomnd
+	if err != nil {
+		panic(err)
+	}
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		panic(err)
+	}
+	return key, id.String()
+}
+
+type TransportSuite struct {
+	suite.Suite
+	endpoint          *testEndpoint
+	callbacks         chan compute.RunResult
+	registrations     chan model.NodeInfo
+	computeServer     *httptest.Server
+	requesterServer   *httptest.Server
+	computeRegistry   *Registry
+	requesterRegistry *Registry
+	client            *Client
+}
+
+func (s *TransportSuite) SetupTest() {
+	s.endpoint = &testEndpoint{}
+	s.callbacks = make(chan compute.RunResult, 1)
+	s.registrations = make(chan model.NodeInfo, 1)
+	s.client = NewClient(ClientParams{})
+
+	computeServer := NewServer(ServerParams{NodeID: computeNodeID})
+	NewComputeHandler(ComputeHandlerParams{Server: computeServer, ComputeEndpoint: s.endpoint})
+	s.computeServer = httptest.NewServer(computeServer.Handler())
+	s.T().Cleanup(s.computeServer.Close)
+
+	requesterServer := NewServer(ServerParams{NodeID: requesterNodeID})
+	NewCallbackHandler(CallbackHandlerParams{Server: requesterServer, Callback: &testCallback{runResults: s.callbacks}})
+	s.requesterServer = httptest.NewServer(requesterServer.Handler())
+	s.T().Cleanup(s.requesterServer.Close)
+
+	s.requesterRegistry = NewRegistry(RegistryParams{
+		Server:       requesterServer,
+		Client:       s.client,
+		Address:      s.requesterServer.URL,
+		RequesterURL: s.requesterServer.URL,
+	})
+	s.Require().NoError(s.requesterRegistry.Subscribe(context.Background(),
+		pubsub.SubscriberFunc[model.NodeInfo](func(ctx context.Context, nodeInfo model.NodeInfo) error {
+			s.registrations <- nodeInfo
+			return nil
+		})))
+	s.computeRegistry = NewRegistry(RegistryParams{
+		Client:       s.client,
+		PrivateKey:   computeKey,
+		Address:      s.computeServer.URL,
+		RequesterURL: s.requesterServer.URL,
+	})
+}
+
+func TestTransportSuite(t *testing.T) {
+	suite.Run(t, new(TransportSuite))
+}
+
+func (s *TransportSuite) register() {
+	computePeerID, err := peer.Decode(computeNodeID)
+	s.Require().NoError(err)
+	nodeInfo := model.NodeInfo{PeerInfo: peer.AddrInfo{ID: computePeerID}, NodeType: model.NodeTypeCompute}
+	s.Require().NoError(s.computeRegistry.Publish(context.Background(), nodeInfo))
+	s.Equal(nodeInfo.PeerInfo.ID, (<-s.registrations).PeerInfo.ID)
+
+	address, ok := s.requesterRegistry.Address(computeNodeID)
+	s.True(ok)
+	s.Equal(s.computeServer.URL, address)
+}
+
+func (s *TransportSuite) TestForgedRegistrations() {
+	computePeerID, err := peer.Decode(computeNodeID)
+	s.Require().NoError(err)
+	nodeInfo := model.NodeInfo{PeerInfo: peer.AddrInfo{ID: computePeerID}, NodeType: model.NodeTypeCompute}
+
+	// another node can't register the address of the compute node
+	forger := NewRegistry(RegistryParams{
+		Client:       s.client,
+		PrivateKey:   otherKey,
+		Address:      "http://attacker.example.com",
+		RequesterURL: s.requesterServer.URL,
+	})
+	s.ErrorContains(forger.Publish(context.Background(), nodeInfo), "public key does not match node ID")
+
+	registration := s.signedRegistration(nodeInfo, time.Now())
+	registration.Address = "http://attacker.example.com"
+	_, err = s.requesterRegistry.register(context.Background(), registration)
+	s.ErrorContains(err, "invalid signature")
+
+	_, err = s.requesterRegistry.register(context.Background(), s.signedRegistration(nodeInfo, time.Now().Add(-time.Hour)))
+	s.ErrorContains(err, "expired")
+
+	// and registrations can't be replayed
+	registration = s.signedRegistration(nodeInfo, time.Now())
+	_, err = s.requesterRegistry.register(context.Background(), registration)
+	s.NoError(err)
+	<-s.registrations
+	_, err = s.requesterRegistry.register(context.Background(), registration)
+	s.ErrorContains(err, "a later registration was already made")
+
+	_, ok := s.requesterRegistry.Address(computeNodeID)
+	s.True(ok)
+}
+
+func (s *TransportSuite) TestRegistrationsExpire() {
+	registry := NewRegistry(RegistryParams{
+		Server:          NewServer(ServerParams{NodeID: requesterNodeID}),
+		RegistrationTTL: 50 * time.Millisecond,
+	})
+	computePeerID, err := peer.Decode(computeNodeID)
+	s.Require().NoError(err)
+	_, err = registry.register(context.Background(),
+		s.signedRegistration(model.NodeInfo{PeerInfo: peer.AddrInfo{ID: computePeerID}}, time.Now()))
+	s.Require().NoError(err)
+
+	_, ok := registry.Address(computeNodeID)
+	s.True(ok)
+	s.Eventually(func() bool {
+		_, ok = registry.Address(computeNodeID)
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func (s *TransportSuite) signedRegistration(nodeInfo model.NodeInfo, timestamp time.Time) Registration {
+	publicKey, err := crypto.MarshalPublicKey(computeKey.GetPublic())
+	s.Require().NoError(err)
+	registration := Registration{
+		NodeInfo:  nodeInfo,
+		Address:   s.computeServer.URL,
+		Timestamp: timestamp,
+		PublicKey: publicKey,
+	}
+	registration.Signature, err = computeKey.Sign(registration.signedData())
+	s.Require().NoError(err)
+	return registration
+}
+
+func (s *TransportSuite) TestComputeRequests() {
+	s.register()
+	proxy := NewComputeProxy(ComputeProxyParams{
+		NodeID:   requesterNodeID,
+		Client:   s.client,
+		Registry: s.requesterRegistry,
+	})
+
+	response, err := proxy.BidAccepted(context.Background(), compute.BidAcceptedRequest{
+		RoutingMetadata: compute.RoutingMetadata{SourcePeerID: requesterNodeID, TargetPeerID: computeNodeID},
+		ExecutionID:     "execution-id",
+	})
+	s.NoError(err)
+	s.Equal("execution-id", response.ExecutionID)
+
+	_, err = proxy.CancelExecution(context.Background(), compute.CancelExecutionRequest{
+		RoutingMetadata: compute.RoutingMetadata{SourcePeerID: requesterNodeID, TargetPeerID: computeNodeID},
+		ExecutionID:     "unknown",
+	})
+	s.ErrorContains(err, "no execution unknown")
+}
+
+func (s *TransportSuite) TestUnregisteredComputeNode() {
+	proxy := NewComputeProxy(ComputeProxyParams{
+		NodeID:   requesterNodeID,
+		Client:   s.client,
+		Registry: s.requesterRegistry,
+	})
+	_, err := proxy.BidAccepted(context.Background(), compute.BidAcceptedRequest{
+		RoutingMetadata: compute.RoutingMetadata{SourcePeerID: requesterNodeID, TargetPeerID: computeNodeID},
+	})
+	s.ErrorContains(err, "has not registered")
+}
+
+func (s *TransportSuite) TestCallbacks() {
+	proxy := NewCallbackProxy(CallbackProxyParams{
+		NodeID:       computeNodeID,
+		Client:       s.client,
+		RequesterURL: s.requesterServer.URL,
+	})
+	proxy.OnRunComplete(context.Background(), compute.RunResult{
+		RoutingMetadata:   compute.RoutingMetadata{SourcePeerID: computeNodeID, TargetPeerID: requesterNodeID},
+		ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: "execution-id"},
+	})
+
+	select {
+	case result := <-s.callbacks:
+		s.Equal("execution-id", result.ExecutionID)
+	case <-time.After(5 * time.Second):
+		s.Fail("callback not received")
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newCertificate(t, dir, "ca", nil, nil)
+	newCertificate(t, dir, "node", ca, caKey)
+	otherCA, otherCAKey := newCertificate(t, dir, "other-ca", nil, nil)
+	newCertificate(t, dir, "stranger", otherCA, otherCAKey)
+
+	nodeTLS, err := Config{
+		TLSCertFile: filepath.Join(dir, "node.pem"),
+		TLSKeyFile:  filepath.Join(dir, "node-key.pem"),
+		TLSCAFile:   filepath.Join(dir, "ca.pem"),
+	}.TLS()
+	require.NoError(t, err)
+	strangerTLS, err := Config{
+		TLSCertFile: filepath.Join(dir, "stranger.pem"),
+		TLSKeyFile:  filepath.Join(dir, "stranger-key.pem"),
+		TLSCAFile:   filepath.Join(dir, "ca.pem"),
+	}.TLS()
+	require.NoError(t, err)
+
+	server := NewServer(ServerParams{NodeID: computeNodeID, TLSConfig: nodeTLS})
+	NewComputeHandler(ComputeHandlerParams{Server: server, ComputeEndpoint: &testEndpoint{}})
+	httpServer := httptest.NewUnstartedServer(server.Handler())
+	httpServer.TLS = nodeTLS
+	httpServer.EnableHTTP2 = true
+	httpServer.StartTLS()
+	t.Cleanup(httpServer.Close)
+
+	request := compute.BidAcceptedRequest{ExecutionID: "execution-id"}
+	response, err := proxyRequest[compute.BidAcceptedRequest, compute.BidAcceptedResponse](
+		context.Background(), NewClient(ClientParams{TLSConfig: nodeTLS}), httpServer.URL, bprotocol.BidAcceptedProtocolID, request)
+	require.NoError(t, err)
+	require.Equal(t, "execution-id", response.ExecutionID)
+
+	_, err = proxyRequest[compute.BidAcceptedRequest, compute.BidAcceptedResponse](
+		context.Background(), NewClient(ClientParams{TLSConfig: strangerTLS}), httpServer.URL, bprotocol.BidAcceptedProtocolID, request)
+	require.Error(t, err)
+
+	_, err = proxyRequest[compute.BidAcceptedRequest, compute.BidAcceptedResponse](
+		context.Background(), NewClient(ClientParams{}), httpServer.URL, bprotocol.BidAcceptedProtocolID, request)
+	require.Error(t, err)
+
+	_, err = Config{TLSCertFile: filepath.Join(dir, "node.pem")}.TLS()
+	require.Error(t, err)
+}
+
+// TestRegistryToken runs a job with a registry token on a node registered over HTTP. Registry tokens are sealed with
+// the RSA key of the node, which can't be derived from its ID, and isn't known through libp2p as the node is never
+// connected to.
+func TestRegistryToken(t *testing.T) {
+	require.NoError(t, system.InitConfigForTesting(t))
+	ctx := context.Background()
+	client := NewClient(ClientParams{})
+
+	nodeKey, _, err := crypto.GenerateKeyPair(crypto.RSA, 2048) //nolint:gomnd
+	require.NoError(t, err)
+	nodeID, err := peer.IDFromPrivateKey(nodeKey)
+	require.NoError(t, err)
+	endpoint := &biddingEndpoint{accepted: make(chan compute.BidAcceptedRequest, 1)}
+	computeServer := NewServer(ServerParams{NodeID: nodeID.String()})
+	NewComputeHandler(ComputeHandlerParams{Server: computeServer, ComputeEndpoint: endpoint})
+	computeHTTPServer := httptest.NewServer(computeServer.Handler())
+	t.Cleanup(computeHTTPServer.Close)
+
+	mn := mocknet.New()
+	t.Cleanup(func() { _ = mn.Close() })
+	host, err := mn.GenPeer()
+	require.NoError(t, err)
+	requesterServer := NewServer(ServerParams{NodeID: host.ID().String()})
+	requesterHTTPServer := httptest.NewServer(requesterServer.Handler())
+	t.Cleanup(requesterHTTPServer.Close)
+	requesterRegistry := NewRegistry(RegistryParams{
+		Server:       requesterServer,
+		Client:       client,
+		Address:      requesterHTTPServer.URL,
+		RequesterURL: requesterHTTPServer.URL,
+		Peerstore:    host.Peerstore(),
+	})
+	nodes := &registeredNodes{}
+	require.NoError(t, requesterRegistry.Subscribe(ctx, nodes))
+
+	computeRegistry := NewRegistry(RegistryParams{
+		Client:       client,
+		PrivateKey:   nodeKey,
+		Address:      computeHTTPServer.URL,
+		RequesterURL: requesterHTTPServer.URL,
+	})
+	require.NoError(t, computeRegistry.Publish(ctx, model.NodeInfo{
+		PeerInfo: peer.AddrInfo{ID: nodeID},
+		NodeType: model.NodeTypeCompute,
+		Versions: model.NodeVersions{ProtocolVersion: model.ProtocolVersion, APIVersions: model.SupportedAPIVersions()},
+	}))
+
+	jobStore, err := inmemory.NewInMemoryDatastore()
+	require.NoError(t, err)
+	cm := system.NewCleanupManager()
+	t.Cleanup(cm.Cleanup)
+	noopVerifier, err := noop.NewNoopVerifier(ctx, cm, nil)
+	require.NoError(t, err)
+	scheduler := requester.NewScheduler(ctx, cm, requester.SchedulerParams{
+		ID:             host.ID().String(),
+		Host:           host,
+		JobStore:       jobStore,
+		NodeDiscoverer: nodes,
+		NodeRanker:     nodes,
+		ComputeEndpoint: NewComputeProxy(ComputeProxyParams{
+			NodeID:   host.ID().String(),
+			Client:   client,
+			Registry: requesterRegistry,
+		}),
+		Verifiers: noop.NewNoopVerifierProvider(noopVerifier),
+		EventEmitter: requester.NewEventEmitter(requester.EventEmitterParams{
+			EventConsumer: eventhandler.JobEventHandlerFunc(func(context.Context, model.JobEvent) error { return nil }),
+		}),
+		Encrypter: verifier.NewEncrypter(nodeKey).Seal,
+		Decrypter: func(_ context.Context, data []byte) ([]byte, error) {
+			return bytes.TrimPrefix(data, []byte("sealed:")), nil
+		},
+		JobNegotiationTimeout:              time.Minute,
+		StateManagerBackgroundTaskInterval: time.Hour,
+	})
+
+	require.NoError(t, scheduler.StartJob(ctx, requester.StartJobRequest{Job: model.Job{
+		APIVersion: model.APIVersionModel().String(),
+		Metadata:   model.Metadata{ID: "job-id", CreatedAt: time.Now()},
+		Spec: model.Spec{
+			Engine:        model.EngineDocker,
+			Verifier:      model.VerifierNoop,
+			Docker:        model.JobSpecDocker{Image: "registry.example.com/image", RegistryAuth: []byte("sealed:user:password")},
+			Deal:          model.Deal{Concurrency: 1},
+			Timeout:       time.Minute.Seconds(),
+			ExecutionPlan: model.JobExecutionPlan{TotalShards: 1},
+		},
+	}}))
+
+	select {
+	case request := <-endpoint.accepted:
+		token, err := verifier.NewEncrypter(nodeKey).Unseal(ctx, request.RegistryAuth)
+		require.NoError(t, err)
+		require.Equal(t, "user:password", string(token))
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "bid with the registry token was not accepted")
+	}
+}
+
+// newCertificate writes a certificate and its key to <dir>/<name>.pem and <dir>/<name>-key.pem, signed by parent, or
+// self-signed as a certificate authority if parent is nil.
+func newCertificate(
+	t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+"-key.pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certificate, key
+}
+
+// testEndpoint responds to requests with the execution they name, and fails to cancel executions.
+type testEndpoint struct{}
+
+func (e *testEndpoint) AskForBid(context.Context, compute.AskForBidRequest) (compute.AskForBidResponse, error) {
+	return compute.AskForBidResponse{}, nil
+}
+
+func (e *testEndpoint) BidAccepted(_ context.Context, request compute.BidAcceptedRequest) (compute.BidAcceptedResponse, error) {
+	return compute.BidAcceptedResponse{ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: request.ExecutionID}}, nil
+}
+
+func (e *testEndpoint) BidRejected(context.Context, compute.BidRejectedRequest) (compute.BidRejectedResponse, error) {
+	return compute.BidRejectedResponse{}, nil
+}
+
+func (e *testEndpoint) ResultAccepted(context.Context, compute.ResultAcceptedRequest) (compute.ResultAcceptedResponse, error) {
+	return compute.ResultAcceptedResponse{}, nil
+}
+
+func (e *testEndpoint) ResultRejected(context.Context, compute.ResultRejectedRequest) (compute.ResultRejectedResponse, error) {
+	return compute.ResultRejectedResponse{}, nil
+}
+
+func (e *testEndpoint) CancelExecution(
+	_ context.Context, request compute.CancelExecutionRequest) (compute.CancelExecutionResponse, error) {
+	return compute.CancelExecutionResponse{}, fmt.Errorf("no execution %s", request.ExecutionID)
+}
+
+// biddingEndpoint bids on every shard it is asked to, and records the bids that are accepted.
+type biddingEndpoint struct {
+	testEndpoint
+	accepted chan compute.BidAcceptedRequest
+}
+
+func (e *biddingEndpoint) AskForBid(_ context.Context, request compute.AskForBidRequest) (compute.AskForBidResponse, error) {
+	var response compute.AskForBidResponse
+	for _, shardIndex := range request.ShardIndexes {
+		response.ShardResponse = append(response.ShardResponse, compute.AskForBidShardResponse{
+			ExecutionMetadata: compute.ExecutionMetadata{
+				ExecutionID: fmt.Sprintf("execution-%d", shardIndex),
+				JobID:       request.Job.Metadata.ID,
+				ShardIndex:  shardIndex,
+			},
+			Accepted: true,
+		})
+	}
+	return response, nil
+}
+
+func (e *biddingEndpoint) BidAccepted(_ context.Context, request compute.BidAcceptedRequest) (compute.BidAcceptedResponse, error) {
+	e.accepted <- request
+	return compute.BidAcceptedResponse{ExecutionMetadata: compute.ExecutionMetadata{ExecutionID: request.ExecutionID}}, nil
+}
+
+// registeredNodes discovers and ranks equally the nodes that registered.
+type registeredNodes struct {
+	mu    sync.Mutex
+	nodes []model.NodeInfo
+}
+
+func (n *registeredNodes) Handle(_ context.Context, nodeInfo model.NodeInfo) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.nodes = append(n.nodes, nodeInfo)
+	return nil
+}
+
+func (n *registeredNodes) FindNodes(context.Context, model.Job) ([]model.NodeInfo, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]model.NodeInfo{}, n.nodes...), nil
+}
+
+func (n *registeredNodes) RankNodes(_ context.Context, _ model.Job, nodes []model.NodeInfo) ([]requester.NodeRank, error) {
+	ranks := make([]requester.NodeRank, 0, len(nodes))
+	for _, node := range nodes {
+		ranks = append(ranks, requester.NodeRank{NodeInfo: node, Rank: 1})
+	}
+	return ranks, nil
+}
+
+type testCallback struct {
+	runResults chan compute.RunResult
+}
+
+func (c *testCallback) OnRunComplete(_ context.Context, result compute.RunResult) {
+	c.runResults <- result
+}
+
+func (c *testCallback) OnPublishComplete(context.Context, compute.PublishResult) {}
+func (c *testCallback) OnCancelComplete(context.Context, compute.CancelResult)   {}
+func (c *testCallback) OnComputeFailure(context.Context, compute.ComputeError)   {}
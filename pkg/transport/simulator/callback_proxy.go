@@ -7,6 +7,7 @@ import (
 
 	"github.com/filecoin-project/bacalhau/pkg/compute"
 	"github.com/filecoin-project/bacalhau/pkg/logger"
+	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/filecoin-project/bacalhau/pkg/transport/bprotocol"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -45,25 +46,25 @@ func (p *CallbackProxy) RegisterLocalComputeCallback(callback compute.Callback)
 }
 
 func (p *CallbackProxy) OnRunComplete(ctx context.Context, result compute.RunResult) {
-	proxyCallbackRequest(ctx, p, result.RoutingMetadata, bprotocol.OnRunComplete, result, func(ctx2 context.Context) {
+	proxyCallbackRequest(ctx, p, result.RoutingMetadata, bprotocol.OnRunComplete, &result, func(ctx2 context.Context) {
 		p.localCallback.OnRunComplete(ctx2, result)
 	})
 }
 
 func (p *CallbackProxy) OnPublishComplete(ctx context.Context, result compute.PublishResult) {
-	proxyCallbackRequest(ctx, p, result.RoutingMetadata, bprotocol.OnPublishComplete, result, func(ctx2 context.Context) {
+	proxyCallbackRequest(ctx, p, result.RoutingMetadata, bprotocol.OnPublishComplete, &result, func(ctx2 context.Context) {
 		p.localCallback.OnPublishComplete(ctx2, result)
 	})
 }
 
 func (p *CallbackProxy) OnCancelComplete(ctx context.Context, result compute.CancelResult) {
-	proxyCallbackRequest(ctx, p, result.RoutingMetadata, bprotocol.OnCancelComplete, result, func(ctx2 context.Context) {
+	proxyCallbackRequest(ctx, p, result.RoutingMetadata, bprotocol.OnCancelComplete, &result, func(ctx2 context.Context) {
 		p.localCallback.OnCancelComplete(ctx2, result)
 	})
 }
 
 func (p *CallbackProxy) OnComputeFailure(ctx context.Context, result compute.ComputeError) {
-	proxyCallbackRequest(ctx, p, result.RoutingMetadata, bprotocol.OnComputeFailure, result, func(ctx2 context.Context) {
+	proxyCallbackRequest(ctx, p, result.RoutingMetadata, bprotocol.OnComputeFailure, &result, func(ctx2 context.Context) {
 		p.localCallback.OnComputeFailure(ctx2, result)
 	})
 }
@@ -81,7 +82,7 @@ func proxyCallbackRequest(
 		} else {
 			// TODO: validate which context to user here, and whether running in a goroutine is ok
 			ctx2 := logger.ContextWithNodeIDLogger(context.Background(), p.host.ID().String())
-			go selfDialFunc(ctx2)
+			go selfDialFunc(system.ContextWithTraceOf(ctx2, ctx))
 		}
 	} else {
 		// decode the destination peer ID string value
@@ -93,7 +94,8 @@ func proxyCallbackRequest(
 			return
 		}
 
-		// deserialize the request object
+		// carry the trace over to the destination peer, and deserialize the request object
+		bprotocol.InjectTraceContext(ctx, request)
 		data, err := json.Marshal(request)
 		if err != nil {
 			log.Ctx(ctx).Error().Err(err).Msgf("%s: failed to marshal request", reflect.TypeOf(request))
@@ -127,7 +127,8 @@ func proxyRequest[Request any, Response any](
 		return *response, fmt.Errorf("%s: failed to decode peer ID %s: %w", reflect.TypeOf(request), destPeerID, err)
 	}
 
-	// deserialize the request object
+	// carry the trace over to the destination peer, and deserialize the request object
+	bprotocol.InjectTraceContext(ctx, &request)
 	data, err := json.Marshal(request)
 	if err != nil {
 		return *response, fmt.Errorf("%s: failed to marshal request: %w", reflect.TypeOf(request), err)
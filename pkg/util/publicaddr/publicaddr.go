@@ -0,0 +1,65 @@
+// Package publicaddr checks that hosts submitted by users are on the public
+// internet, so that a node can't be made to send requests to itself, to its
+// private network or to cloud metadata services.
+package publicaddr
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+var hostnameRegex = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9_.-]*[A-Za-z0-9])?$`)
+
+// IsPublicIP returns false for loopback, private, link-local, multicast and
+// unspecified addresses.
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified())
+}
+
+// CheckHostname returns an error if the host is not a well formed hostname or
+// IP address, or names the local machine or a private network without needing
+// to be resolved.
+func CheckHostname(host string) error {
+	host = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSuffix(host, "]"), "["), ".")
+	if ip := net.ParseIP(host); ip != nil {
+		if !IsPublicIP(ip) {
+			return fmt.Errorf("host %s is not a public address", host)
+		}
+		return nil
+	}
+	if !hostnameRegex.MatchString(host) {
+		return fmt.Errorf("invalid host %q", host)
+	}
+	lower := strings.ToLower(host)
+	if lower == "localhost" || strings.HasSuffix(lower, ".localhost") || !strings.Contains(lower, ".") {
+		return fmt.Errorf("host %s is not a public address", host)
+	}
+	return nil
+}
+
+// CheckHost checks the hostname, and that every address it resolves to is
+// public.
+func CheckHost(ctx context.Context, host string) error {
+	if err := CheckHostname(host); err != nil {
+		return err
+	}
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip", strings.Trim(host, "[]"))
+	if err != nil {
+		return fmt.Errorf("failed to resolve host %s: %w", host, err)
+	}
+	for _, ip := range ips {
+		if !IsPublicIP(ip) {
+			return fmt.Errorf("host %s resolves to %s, which is not a public address", host, ip)
+		}
+	}
+	return nil
+}
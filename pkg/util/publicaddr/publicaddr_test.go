@@ -0,0 +1,38 @@
+//go:build unit || !integration
+
+package publicaddr
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	for _, public := range []string{"8.8.8.8", "140.82.112.3", "2606:4700:4700::1111"} {
+		require.True(t, IsPublicIP(net.ParseIP(public)), public)
+	}
+	for _, private := range []string{
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "0.0.0.0", "::1", "fe80::1", "fd00::1",
+	} {
+		require.False(t, IsPublicIP(net.ParseIP(private)), private)
+	}
+}
+
+func TestCheckHostname(t *testing.T) {
+	for _, valid := range []string{"github.com", "example.com.", "140.82.112.3", "[2606:4700:4700::1111]"} {
+		require.NoError(t, CheckHostname(valid), valid)
+	}
+	for _, invalid := range []string{
+		"", "localhost", "LOCALHOST", "foo.localhost", "intranet", "127.0.0.1", "[::1]", "169.254.169.254",
+		"-oProxyCommand=x", "exa mple.com",
+	} {
+		require.Error(t, CheckHostname(invalid), invalid)
+	}
+}
+
+func TestCheckHostResolves(t *testing.T) {
+	require.Error(t, CheckHost(context.Background(), "127.0.0.1"))
+}
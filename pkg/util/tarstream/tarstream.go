@@ -0,0 +1,137 @@
+// Package tarstream streams directories as uncompressed tar archives, without
+// the size limits of targzip, for moving results between nodes.
+package tarstream
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/filecoin-project/bacalhau/pkg/util/closer"
+)
+
+const dirPerm fs.FileMode = 0755
+
+// Write archives the files of dir to w, with paths relative to dir.
+func Write(ctx context.Context, dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		relativePath, err := filepath.Rel(dir, path)
+		if err != nil || relativePath == "." {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			// links and devices are not part of results
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relativePath)
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer closer.CloseWithLogOnError(path, file)
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// Reader returns the archive of dir as a stream, which is written as it is
+// read. Closing the reader stops writing the archive.
+func Reader(ctx context.Context, dir string) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(Write(ctx, dir, writer))
+	}()
+	return reader
+}
+
+// Extract writes the files of an archive into dst, which is created if it
+// doesn't exist.
+func Extract(r io.Reader, dst string) error {
+	err := os.MkdirAll(dst, dirPerm)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := targetPath(dst, header.Name)
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, dirPerm)
+		case tar.TypeReg:
+			err = extractFile(tr, target, header.FileInfo().Mode().Perm())
+		default:
+			err = fmt.Errorf("unsupported file %q in archive", header.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractFile(r io.Reader, target string, perm fs.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(target), dirPerm)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	defer closer.CloseWithLogOnError(target, file)
+	_, err = io.Copy(file, r)
+	return err
+}
+
+// targetPath returns where a file of an archive is extracted to, refusing
+// paths that would escape dst.
+func targetPath(dst, name string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive contains invalid path %q", name)
+	}
+	return filepath.Join(dst, cleaned), nil
+}
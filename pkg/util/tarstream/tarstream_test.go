@@ -0,0 +1,57 @@
+//go:build unit || !integration
+
+package tarstream
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "stdout"), []byte("hello"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "outputs", "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "outputs", "nested", "data.csv"), []byte("1,2,3"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "empty"), 0755))
+
+	reader := Reader(context.Background(), src)
+	defer reader.Close()
+
+	dst := filepath.Join(t.TempDir(), "results")
+	require.NoError(t, Extract(reader, dst))
+
+	contents, err := os.ReadFile(filepath.Join(dst, "stdout"))
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(contents))
+	contents, err = os.ReadFile(filepath.Join(dst, "outputs", "nested", "data.csv"))
+	require.NoError(t, err)
+	require.Equal(t, "1,2,3", string(contents))
+	require.DirExists(t, filepath.Join(dst, "empty"))
+}
+
+func TestExtractRejectsPathTraversal(t *testing.T) {
+	for _, name := range []string{"../escaped", "/etc/passwd", "outputs/../../escaped"} {
+		t.Run(name, func(t *testing.T) {
+			var archive bytes.Buffer
+			tw := tar.NewWriter(&archive)
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: 1, Typeflag: tar.TypeReg}))
+			_, err := tw.Write([]byte("x"))
+			require.NoError(t, err)
+			require.NoError(t, tw.Close())
+
+			require.Error(t, Extract(&archive, t.TempDir()))
+		})
+	}
+}
+
+func TestReaderMissingDir(t *testing.T) {
+	reader := Reader(context.Background(), filepath.Join(t.TempDir(), "missing"))
+	defer reader.Close()
+	require.Error(t, Extract(reader, t.TempDir()))
+}
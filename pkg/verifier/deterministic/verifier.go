@@ -3,13 +3,16 @@ package deterministic
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/filecoin-project/bacalhau/pkg/job"
 	"github.com/filecoin-project/bacalhau/pkg/model"
 	"github.com/filecoin-project/bacalhau/pkg/system"
 	"github.com/filecoin-project/bacalhau/pkg/verifier"
+	"github.com/filecoin-project/bacalhau/pkg/verifier/manifest"
 	"github.com/filecoin-project/bacalhau/pkg/verifier/results"
-	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/exp/maps"
 )
 
 type DeterministicVerifier struct {
@@ -17,6 +20,8 @@ type DeterministicVerifier struct {
 	results       *results.Results
 	encrypter     verifier.EncrypterFunction
 	decrypter     verifier.DecrypterFunction
+	// legacyDecrypter decrypts the dirhash proposals of nodes that do not propose a manifest yet
+	legacyDecrypter verifier.DecrypterFunction
 }
 
 func NewDeterministicVerifier(
@@ -24,6 +29,7 @@ func NewDeterministicVerifier(
 	resolver *job.StateResolver,
 	encrypter verifier.EncrypterFunction,
 	decrypter verifier.DecrypterFunction,
+	legacyDecrypter verifier.DecrypterFunction,
 ) (*DeterministicVerifier, error) {
 	results, err := results.NewResults()
 	if err != nil {
@@ -37,10 +43,11 @@ func NewDeterministicVerifier(
 		return nil
 	})
 	return &DeterministicVerifier{
-		stateResolver: resolver,
-		results:       results,
-		encrypter:     encrypter,
-		decrypter:     decrypter,
+		stateResolver:   resolver,
+		results:         results,
+		encrypter:       encrypter,
+		decrypter:       decrypter,
+		legacyDecrypter: legacyDecrypter,
 	}, nil
 }
 
@@ -63,15 +70,11 @@ func (deterministicVerifier *DeterministicVerifier) GetShardProposal(
 	if len(shard.Job.Status.Requester.RequesterPublicKey) == 0 {
 		return nil, fmt.Errorf("no RequesterPublicKey found in the job")
 	}
-	dirHash, err := dirhash.HashDir(shardResultPath, "results", dirhash.Hash1)
+	m, err := manifest.Build(shardResultPath)
 	if err != nil {
 		return nil, err
 	}
-	encryptedHash, err := deterministicVerifier.encrypter(ctx, []byte(dirHash), shard.Job.Status.Requester.RequesterPublicKey)
-	if err != nil {
-		return nil, err
-	}
-	return encryptedHash, nil
+	return manifest.Encrypt(ctx, m, deterministicVerifier.encrypter, shard.Job.Status.Requester.RequesterPublicKey)
 }
 
 // each shard must have >= concurrency states
@@ -88,17 +91,40 @@ func (deterministicVerifier *DeterministicVerifier) IsExecutionComplete(
 	})
 }
 
+// legacyHashPrefix starts the dirhash of the results that nodes proposed before they proposed a manifest.
+const legacyHashPrefix = "h1:"
+
+// hashedResult is a verifier result with the manifest of the result files it was hashed from.
+type hashedResult struct {
+	*verifier.VerifierResult
+	manifest manifest.Manifest
+	// legacy is true if the node proposed a dirhash rather than a manifest, so there are no files to diff
+	legacy bool
+}
+
+// legacyHash returns the dirhash proposed by a node that does not propose a manifest yet.
+func (deterministicVerifier *DeterministicVerifier) legacyHash(ctx context.Context, proposal []byte) (string, bool) {
+	if len(proposal) == 0 || deterministicVerifier.legacyDecrypter == nil {
+		return "", false
+	}
+	data, err := deterministicVerifier.legacyDecrypter(ctx, proposal)
+	if err != nil || !strings.HasPrefix(string(data), legacyHashPrefix) {
+		return "", false
+	}
+	return string(data), true
+}
+
 func (deterministicVerifier *DeterministicVerifier) getHashGroups(
 	ctx context.Context,
 	shard model.JobShard,
 	shardStates []model.JobShardState,
-) map[string][]*verifier.VerifierResult {
+) map[string][]hashedResult {
 	// group the verifier results by their reported hash
 	// then pick the largest group and verify all of those
 	// caveats:
 	//  * if there is only 1 group - there must be > 1 result
 	//  * there cannot be a draw between the top 2 groups
-	hashGroups := map[string][]*verifier.VerifierResult{}
+	hashGroups := map[string][]hashedResult{}
 
 	for _, shardState := range shardStates { //nolint:gocritic
 		// we've already called IsExecutionComplete so will assume any shard state
@@ -108,31 +134,33 @@ func (deterministicVerifier *DeterministicVerifier) getHashGroups(
 		}
 
 		hash := ""
-
-		if len(shardState.VerificationProposal) > 0 {
-			decryptedHash, err := deterministicVerifier.decrypter(ctx, shardState.VerificationProposal)
-
-			// if there is an error decrypting let's not fail the verification job
-			// but just leave the proposed hash at empty string (which won't pass actual verification)
-			// this means we can "complete" the verification process by deciding that anyone
-			// who couldn't submit a correctly encrypted hash will result in an empty hash
-			// rather than a decryption error
-			if err == nil {
-				hash = string(decryptedHash)
-			}
-		}
-
-		existingArray, ok := hashGroups[hash]
-		if !ok {
-			existingArray = []*verifier.VerifierResult{}
-		}
-		hashGroups[hash] = append(existingArray, &verifier.VerifierResult{
+		result := hashedResult{VerifierResult: &verifier.VerifierResult{
 			JobID:       shard.Job.Metadata.ID,
 			NodeID:      shardState.NodeID,
 			ExecutionID: shardState.ExecutionID,
 			ShardIndex:  shardState.ShardIndex,
 			Verified:    false,
-		})
+		}}
+
+		// if there is an error decrypting let's not fail the verification job
+		// but just leave the proposed hash at empty string (which won't pass actual verification)
+		// this means we can "complete" the verification process by deciding that anyone
+		// who couldn't submit a correctly encrypted manifest will result in an empty hash
+		// rather than a decryption error
+		m, err := manifest.Decrypt(ctx, shardState.VerificationProposal, deterministicVerifier.decrypter)
+		if err == nil {
+			// files that are known to differ between runs are not part of the hash
+			result.manifest = m.Without(shard.Job.Spec.Verification.IgnorePaths)
+			hash = result.manifest.Hash()
+		} else if legacyHash, ok := deterministicVerifier.legacyHash(ctx, shardState.VerificationProposal); ok {
+			// the dirhash covers every file, so it only matches other nodes proposing the same legacy format
+			hash = legacyHash
+			result.legacy = true
+		} else {
+			result.Reason = err.Error()
+		}
+
+		hashGroups[hash] = append(hashGroups[hash], result)
 	}
 
 	return hashGroups
@@ -151,7 +179,11 @@ func (deterministicVerifier *DeterministicVerifier) verifyShard(
 	groupSizeCounts := map[int]int{}
 	hashGroups := deterministicVerifier.getHashGroups(ctx, shard, shardStates)
 
-	for hash, group := range hashGroups {
+	// go through the groups in a fixed order, so a draw is always settled the same way for diagnostics
+	hashes := maps.Keys(hashGroups)
+	sort.Strings(hashes)
+	for _, hash := range hashes {
+		group := hashGroups[hash]
 		if len(group) > largestGroupSize {
 			largestGroupSize = len(group)
 			largestGroupHash = hash
@@ -186,11 +218,35 @@ func (deterministicVerifier *DeterministicVerifier) verifyShard(
 		}
 	}
 
+	// compare everyone else's files to those of the largest group, so mismatches can be diagnosed
+	if largestGroupHash != "" && !hashGroups[largestGroupHash][0].legacy {
+		reference := hashGroups[largestGroupHash][0]
+		for hash, group := range hashGroups {
+			if hash == "" || hash == largestGroupHash {
+				continue
+			}
+			for _, result := range group {
+				if result.legacy {
+					continue
+				}
+				diff := result.manifest.Diff(reference.manifest)
+				diff.ComparedTo = reference.NodeID
+				result.Diff = &diff
+			}
+		}
+	}
+
 	allResults := []verifier.VerifierResult{}
 
-	for _, verificationResultList := range hashGroups {
-		for _, verificationResult := range verificationResultList {
-			allResults = append(allResults, *verificationResult)
+	for _, hash := range hashes {
+		for _, verificationResult := range hashGroups[hash] {
+			if !verificationResult.Verified && verificationResult.Reason == "" {
+				verificationResult.Reason = "results differ from the largest group of matching results"
+				if isVoidResult {
+					verificationResult.Reason = "not enough results match"
+				}
+			}
+			allResults = append(allResults, *verificationResult.VerifierResult)
 		}
 	}
 
@@ -0,0 +1,187 @@
+//go:build unit || !integration
+
+package deterministic
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
+	"github.com/filecoin-project/bacalhau/pkg/verifier/manifest"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+type DeterministicVerifierSuite struct {
+	suite.Suite
+	ctx       context.Context
+	verifier  *DeterministicVerifier
+	encrypter verifier.Encrypter
+	publicKey []byte
+	shard     model.JobShard
+}
+
+func TestDeterministicVerifierSuite(t *testing.T) {
+	suite.Run(t, new(DeterministicVerifierSuite))
+}
+
+func (s *DeterministicVerifierSuite) SetupTest() {
+	s.ctx = context.Background()
+	privateKey, publicKey, err := crypto.GenerateKeyPair(crypto.RSA, 2048)
+	s.Require().NoError(err)
+	s.publicKey, err = crypto.MarshalPublicKey(publicKey)
+	s.Require().NoError(err)
+	encrypter := verifier.NewEncrypter(privateKey)
+	s.encrypter = encrypter
+	s.verifier = &DeterministicVerifier{
+		encrypter:       encrypter.Seal,
+		decrypter:       encrypter.Unseal,
+		legacyDecrypter: encrypter.Decrypt,
+	}
+	s.shard = model.JobShard{Job: &model.Job{Metadata: model.Metadata{ID: "job"}}}
+	s.shard.Job.Status.Requester.RequesterPublicKey = s.publicKey
+}
+
+func (s *DeterministicVerifierSuite) writeResults(files map[string]string) string {
+	dir := s.T().TempDir()
+	for name, content := range files {
+		s.Require().NoError(os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+// propose returns the shard state of a node that proposed a manifest of the files.
+func (s *DeterministicVerifierSuite) propose(nodeID string, files map[string]string) model.JobShardState {
+	proposal, err := s.verifier.GetShardProposal(s.ctx, s.shard, s.writeResults(files))
+	s.Require().NoError(err)
+	return model.JobShardState{NodeID: nodeID, State: model.JobStateVerifying, VerificationProposal: proposal}
+}
+
+// proposeLegacy returns the shard state of a node that proposed a dirhash of the files.
+func (s *DeterministicVerifierSuite) proposeLegacy(nodeID string, files map[string]string) model.JobShardState {
+	hash, err := dirhash.HashDir(s.writeResults(files), "results", dirhash.Hash1)
+	s.Require().NoError(err)
+	proposal, err := s.encrypter.Encrypt(s.ctx, []byte(hash), s.publicKey)
+	s.Require().NoError(err)
+	return model.JobShardState{NodeID: nodeID, State: model.JobStateVerifying, VerificationProposal: proposal}
+}
+
+func (s *DeterministicVerifierSuite) verify(states ...model.JobShardState) map[string]verifier.VerifierResult {
+	results, err := s.verifier.verifyShard(s.ctx, s.shard, states)
+	s.Require().NoError(err)
+	s.Require().Len(results, len(states))
+	byNode := map[string]verifier.VerifierResult{}
+	for _, result := range results {
+		byNode[result.NodeID] = result
+	}
+	return byNode
+}
+
+func (s *DeterministicVerifierSuite) TestMismatchDiff() {
+	expected := map[string]string{"stdout": "hello", "a.txt": "a"}
+	results := s.verify(
+		s.propose("node-a", expected),
+		s.propose("node-b", expected),
+		s.propose("node-c", map[string]string{"stdout": "goodbye", "b.txt": "b"}),
+	)
+
+	for _, nodeID := range []string{"node-a", "node-b"} {
+		s.True(results[nodeID].Verified, nodeID)
+		s.Empty(results[nodeID].Reason, nodeID)
+		s.Nil(results[nodeID].Diff, nodeID)
+	}
+
+	mismatch := results["node-c"]
+	s.False(mismatch.Verified)
+	s.Equal("results differ from the largest group of matching results", mismatch.Reason)
+	s.Require().NotNil(mismatch.Diff)
+	s.Contains([]string{"node-a", "node-b"}, mismatch.Diff.ComparedTo)
+	s.Equal([]string{"a.txt"}, mismatch.Diff.Missing)
+	s.Equal([]string{"b.txt"}, mismatch.Diff.Extra)
+	s.Equal([]string{"stdout"}, mismatch.Diff.Differing)
+}
+
+func (s *DeterministicVerifierSuite) TestIgnorePaths() {
+	s.shard.Job.Spec.Verification.IgnorePaths = []string{"*.log"}
+	results := s.verify(
+		s.propose("node-a", map[string]string{"stdout": "hello", "run.log": "10:00"}),
+		s.propose("node-b", map[string]string{"stdout": "hello", "run.log": "10:01"}),
+	)
+	s.True(results["node-a"].Verified)
+	s.True(results["node-b"].Verified)
+}
+
+func (s *DeterministicVerifierSuite) TestDraw() {
+	results := s.verify(
+		s.propose("node-a", map[string]string{"stdout": "a"}),
+		s.propose("node-b", map[string]string{"stdout": "b"}),
+	)
+	for _, result := range results {
+		s.False(result.Verified)
+		s.Equal("not enough results match", result.Reason)
+	}
+}
+
+func (s *DeterministicVerifierSuite) TestUndecryptableProposal() {
+	expected := map[string]string{"stdout": "hello"}
+	invalid := s.propose("node-c", expected)
+	invalid.VerificationProposal = []byte("not encrypted")
+	results := s.verify(s.propose("node-a", expected), s.propose("node-b", expected), invalid)
+
+	s.True(results["node-a"].Verified)
+	s.False(results["node-c"].Verified)
+	s.Contains(results["node-c"].Reason, "failed to decrypt results manifest")
+	s.Nil(results["node-c"].Diff)
+}
+
+func (s *DeterministicVerifierSuite) TestLegacyProposals() {
+	expected := map[string]string{"stdout": "hello"}
+	results := s.verify(
+		s.proposeLegacy("node-a", expected),
+		s.proposeLegacy("node-b", expected),
+		s.proposeLegacy("node-c", map[string]string{"stdout": "goodbye"}),
+		s.propose("node-d", expected),
+	)
+
+	s.True(results["node-a"].Verified)
+	s.True(results["node-b"].Verified)
+	for _, nodeID := range []string{"node-c", "node-d"} {
+		s.False(results[nodeID].Verified, nodeID)
+		s.Equal("results differ from the largest group of matching results", results[nodeID].Reason, nodeID)
+		s.Nil(results[nodeID].Diff, nodeID)
+	}
+}
+
+func TestLegacyHash(t *testing.T) {
+	ctx := context.Background()
+	privateKey, publicKey, err := crypto.GenerateKeyPair(crypto.RSA, 2048)
+	require.NoError(t, err)
+	publicKeyBytes, err := crypto.MarshalPublicKey(publicKey)
+	require.NoError(t, err)
+	encrypter := verifier.NewEncrypter(privateKey)
+	v := &DeterministicVerifier{legacyDecrypter: encrypter.Decrypt}
+
+	proposal, err := encrypter.Encrypt(ctx, []byte("h1:abc="), publicKeyBytes)
+	require.NoError(t, err)
+	hash, ok := v.legacyHash(ctx, proposal)
+	require.True(t, ok)
+	require.Equal(t, "h1:abc=", hash)
+
+	proposal, err = manifest.Encrypt(ctx, manifest.Manifest{}, encrypter.Seal, publicKeyBytes)
+	require.NoError(t, err)
+	_, ok = v.legacyHash(ctx, proposal)
+	require.False(t, ok)
+
+	proposal, err = encrypter.Encrypt(ctx, []byte("{}"), publicKeyBytes)
+	require.NoError(t, err)
+	_, ok = v.legacyHash(ctx, proposal)
+	require.False(t, ok)
+
+	_, ok = v.legacyHash(ctx, nil)
+	require.False(t, ok)
+}
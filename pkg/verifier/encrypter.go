@@ -2,10 +2,13 @@ package verifier
 
 import (
 	"context"
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha512"
 	"crypto/x509"
+	"encoding/binary"
 	"fmt"
 
 	"github.com/filecoin-project/bacalhau/pkg/system"
@@ -72,3 +75,68 @@ func (e Encrypter) Decrypt(ctx context.Context, data []byte) ([]byte, error) {
 		nil,
 	)
 }
+
+// sealedKeySize is the size of the random AES-256 key used by Seal
+const sealedKeySize = 32
+
+// Seal encrypts data of any length for the holder of the private key matching
+// libp2pKeyBytes. Encrypt can only handle data smaller than the RSA key, so a
+// random key is encrypted with it and used to encrypt the data with AES-GCM.
+func (e Encrypter) Seal(ctx context.Context, data, libp2pKeyBytes []byte) ([]byte, error) {
+	key := make([]byte, sealedKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	encryptedKey, err := e.Encrypt(ctx, key, libp2pKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	// <key length><encrypted key><nonce><encrypted data>
+	sealed := binary.BigEndian.AppendUint16(nil, uint16(len(encryptedKey)))
+	sealed = append(sealed, encryptedKey...)
+	sealed = append(sealed, nonce...)
+	return gcm.Seal(sealed, nonce, data, nil), nil
+}
+
+// Unseal decrypts data encrypted for this node by Seal.
+func (e Encrypter) Unseal(ctx context.Context, data []byte) ([]byte, error) {
+	const lengthSize = 2
+	if len(data) < lengthSize {
+		return nil, fmt.Errorf("sealed data is too short")
+	}
+	keyLength := int(binary.BigEndian.Uint16(data))
+	data = data[lengthSize:]
+	if len(data) < keyLength {
+		return nil, fmt.Errorf("sealed data is too short")
+	}
+	key, err := e.Decrypt(ctx, data[:keyLength])
+	if err != nil {
+		return nil, err
+	}
+	data = data[keyLength:]
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("sealed data is too short")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
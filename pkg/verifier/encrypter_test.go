@@ -0,0 +1,72 @@
+//go:build unit || !integration
+
+package verifier
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEncrypter(t *testing.T) (Encrypter, []byte) {
+	privateKey, publicKey, err := crypto.GenerateKeyPair(crypto.RSA, 2048)
+	require.NoError(t, err)
+	publicKeyBytes, err := crypto.MarshalPublicKey(publicKey)
+	require.NoError(t, err)
+	return NewEncrypter(privateKey), publicKeyBytes
+}
+
+func TestSealRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	encrypter, publicKey := newTestEncrypter(t)
+
+	// larger than what RSA can encrypt in one go
+	data := bytes.Repeat([]byte("registry-token:"), 100)
+	sealed, err := encrypter.Seal(ctx, data, publicKey)
+	require.NoError(t, err)
+	require.NotContains(t, string(sealed), "registry-token")
+
+	unsealed, err := encrypter.Unseal(ctx, sealed)
+	require.NoError(t, err)
+	require.Equal(t, data, unsealed)
+}
+
+func TestUnsealForAnotherNode(t *testing.T) {
+	ctx := context.Background()
+	encrypter, publicKey := newTestEncrypter(t)
+	other, _ := newTestEncrypter(t)
+
+	sealed, err := encrypter.Seal(ctx, []byte("token"), publicKey)
+	require.NoError(t, err)
+	_, err = other.Unseal(ctx, sealed)
+	require.Error(t, err)
+}
+
+func TestUnsealTampered(t *testing.T) {
+	ctx := context.Background()
+	encrypter, publicKey := newTestEncrypter(t)
+	sealed, err := encrypter.Seal(ctx, []byte("token"), publicKey)
+	require.NoError(t, err)
+
+	for name, tampered := range map[string][]byte{
+		"empty":          nil,
+		"key length":     append([]byte{0xff, 0xff}, sealed[2:]...),
+		"encrypted key":  flipByte(sealed, 10),
+		"encrypted data": flipByte(sealed, len(sealed)-1),
+		"truncated":      sealed[:len(sealed)-20],
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := encrypter.Unseal(ctx, tampered)
+			require.Error(t, err)
+		})
+	}
+}
+
+func flipByte(data []byte, index int) []byte {
+	flipped := append([]byte(nil), data...)
+	flipped[index] ^= 0xff
+	return flipped
+}
@@ -0,0 +1,177 @@
+package manifest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	doublestar "github.com/bmatcuk/doublestar/v4"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
+)
+
+// File describes one file of a shard's results.
+type File struct {
+	// path relative to the results directory, using forward slashes
+	Path string `json:"Path"`
+	Size int64  `json:"Size"`
+	// hex encoded sha256 of the file's content
+	Hash string `json:"Hash"`
+}
+
+// Manifest lists the files of a shard's results, sorted by path.
+type Manifest struct {
+	Files []File `json:"Files"`
+}
+
+// Build hashes every file in a results directory.
+func Build(dir string) (Manifest, error) {
+	var m Manifest
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relativePath, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		file, err := hashFile(filePath)
+		if err != nil {
+			return err
+		}
+		file.Path = filepath.ToSlash(relativePath)
+		m.Files = append(m.Files, file)
+		return nil
+	})
+	if err != nil {
+		return Manifest{}, fmt.Errorf("error building results manifest: %w", err)
+	}
+	sort.Slice(m.Files, func(i, j int) bool {
+		return m.Files[i].Path < m.Files[j].Path
+	})
+	return m, nil
+}
+
+func hashFile(filePath string) (File, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return File{}, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return File{}, err
+	}
+	return File{Size: size, Hash: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// Without returns the manifest without the files matching any of the glob
+// patterns, which may use ** to match any number of directories. Patterns
+// without a slash are matched against file names in any directory, the others
+// against the whole path.
+func (m Manifest) Without(patterns []string) Manifest {
+	if len(patterns) == 0 {
+		return m
+	}
+	var filtered Manifest
+	for _, file := range m.Files {
+		if !Matches(file.Path, patterns) {
+			filtered.Files = append(filtered.Files, file)
+		}
+	}
+	return filtered
+}
+
+// Matches returns true if the path matches any of the glob patterns.
+func Matches(filePath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		name := filePath
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(filePath)
+		}
+		if ok, _ := doublestar.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePatterns checks that glob patterns are well formed.
+func ValidatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if !doublestar.ValidatePattern(pattern) {
+			return fmt.Errorf("invalid path pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// Diff compares a manifest to another one.
+func (m Manifest) Diff(other Manifest) model.ResultsDiff {
+	var d model.ResultsDiff
+	i, j := 0, 0
+	for i < len(m.Files) || j < len(other.Files) {
+		switch {
+		case j == len(other.Files) || (i < len(m.Files) && m.Files[i].Path < other.Files[j].Path):
+			d.Extra = append(d.Extra, m.Files[i].Path)
+			i++
+		case i == len(m.Files) || other.Files[j].Path < m.Files[i].Path:
+			d.Missing = append(d.Missing, other.Files[j].Path)
+			j++
+		default:
+			if m.Files[i].Hash != other.Files[j].Hash || m.Files[i].Size != other.Files[j].Size {
+				d.Differing = append(d.Differing, m.Files[i].Path)
+			}
+			i++
+			j++
+		}
+	}
+	return d
+}
+
+// Hash returns a hash of the whole manifest, which is the same for manifests
+// that list the same files with the same contents.
+func (m Manifest) Hash() string {
+	hash := sha256.New()
+	for _, file := range m.Files {
+		fmt.Fprintf(hash, "%s\x00%d\x00%s\n", file.Path, file.Size, file.Hash)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Encrypt encodes a manifest as a verification proposal that only the
+// holder of the private key matching publicKey can read.
+func Encrypt(ctx context.Context, m Manifest, encrypter verifier.EncrypterFunction, publicKey []byte) ([]byte, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return encrypter(ctx, data, publicKey)
+}
+
+// Decrypt decodes a manifest from a verification proposal.
+func Decrypt(ctx context.Context, proposal []byte, decrypter verifier.DecrypterFunction) (Manifest, error) {
+	if len(proposal) == 0 {
+		return Manifest{}, fmt.Errorf("no results manifest was proposed")
+	}
+	data, err := decrypter(ctx, proposal)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to decrypt results manifest: %w", err)
+	}
+	var m Manifest
+	if err = json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, fmt.Errorf("failed to decode results manifest: %w", err)
+	}
+	return m, nil
+}
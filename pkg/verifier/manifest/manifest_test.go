@@ -0,0 +1,72 @@
+//go:build unit || !integration
+
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0755))
+		require.NoError(t, os.WriteFile(filePath, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestBuild(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"stdout":          "hello",
+		"outputs/b.txt":   "b",
+		"outputs/a/c.txt": "",
+	})
+
+	m, err := Build(dir)
+	require.NoError(t, err)
+	require.Len(t, m.Files, 3)
+	require.Equal(t, "outputs/a/c.txt", m.Files[0].Path)
+	require.Equal(t, "outputs/b.txt", m.Files[1].Path)
+	require.Equal(t, File{
+		Path: "stdout",
+		Size: 5,
+		Hash: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+	}, m.Files[2])
+}
+
+func TestDiff(t *testing.T) {
+	base, err := Build(writeFiles(t, map[string]string{"a": "1", "b": "2", "log/run.log": "10:00"}))
+	require.NoError(t, err)
+	other, err := Build(writeFiles(t, map[string]string{"b": "3", "c": "4", "log/run.log": "10:01"}))
+	require.NoError(t, err)
+
+	require.True(t, base.Diff(base).IsEmpty())
+	require.Equal(t, base.Hash(), base.Hash())
+	require.NotEqual(t, base.Hash(), other.Hash())
+	require.Equal(t, Manifest{Files: base.Files[:2]}.Hash(), base.Without([]string{"*.log"}).Hash())
+
+	d := other.Diff(base)
+	require.Equal(t, []string{"a"}, d.Missing)
+	require.Equal(t, []string{"c"}, d.Extra)
+	require.Equal(t, []string{"b", "log/run.log"}, d.Differing)
+	require.Equal(t, 4, d.Count())
+
+	d = other.Without([]string{"*.log"}).Diff(base.Without([]string{"*.log"}))
+	require.Equal(t, []string{"b"}, d.Differing)
+}
+
+func TestMatches(t *testing.T) {
+	require.True(t, Matches("outputs/run.log", []string{"*.log"}))
+	require.True(t, Matches("outputs/run.log", []string{"outputs/*"}))
+	require.False(t, Matches("outputs/run.log", []string{"*/other/*"}))
+	require.True(t, Matches("outputs/logs/today/run.txt", []string{"outputs/**/today/*"}))
+	require.False(t, Matches("outputs/run.txt", nil))
+
+	require.NoError(t, ValidatePatterns([]string{"*.log", "a/[bc]"}))
+	require.Error(t, ValidatePatterns([]string{"[a"}))
+}
@@ -0,0 +1,262 @@
+package optimistic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/filecoin-project/bacalhau/pkg/job"
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/system"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
+	"github.com/filecoin-project/bacalhau/pkg/verifier/manifest"
+	"github.com/filecoin-project/bacalhau/pkg/verifier/results"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// sampled shards run on a second node to check the first result
+	sampledConcurrency = 2
+	// when the two results of a sampled shard differ, a third node breaks the tie
+	escalatedConcurrency = 3
+)
+
+// OptimisticVerifier trusts the result of a single execution for most shards.
+// A random sample of the shards also runs on a second node, and the two
+// results are compared file by file. If they differ the shard runs on a third
+// node, the results the majority agrees on are accepted and the others are
+// rejected.
+type OptimisticVerifier struct {
+	stateResolver *job.StateResolver
+	results       *results.Results
+	encrypter     verifier.EncrypterFunction
+	decrypter     verifier.DecrypterFunction
+}
+
+func NewOptimisticVerifier(
+	_ context.Context, cm *system.CleanupManager,
+	resolver *job.StateResolver,
+	encrypter verifier.EncrypterFunction,
+	decrypter verifier.DecrypterFunction,
+) (*OptimisticVerifier, error) {
+	results, err := results.NewResults()
+	if err != nil {
+		return nil, err
+	}
+
+	cm.RegisterCallback(func() error {
+		if err := results.Close(); err != nil {
+			return fmt.Errorf("unable to remove results folder: %w", err)
+		}
+		return nil
+	})
+	return &OptimisticVerifier{
+		stateResolver: resolver,
+		results:       results,
+		encrypter:     encrypter,
+		decrypter:     decrypter,
+	}, nil
+}
+
+func (optimisticVerifier *OptimisticVerifier) IsInstalled(context.Context) (bool, error) {
+	return true, nil
+}
+
+func (optimisticVerifier *OptimisticVerifier) GetShardResultPath(
+	_ context.Context,
+	shard model.JobShard,
+) (string, error) {
+	return optimisticVerifier.results.EnsureShardResultsDir(shard.Job.Metadata.ID, shard.Index)
+}
+
+// GetShardProposal encrypts the manifest of the shard's result files for the
+// requester.
+func (optimisticVerifier *OptimisticVerifier) GetShardProposal(
+	ctx context.Context,
+	shard model.JobShard,
+	shardResultPath string,
+) ([]byte, error) {
+	if len(shard.Job.Status.Requester.RequesterPublicKey) == 0 {
+		return nil, fmt.Errorf("no RequesterPublicKey found in the job")
+	}
+	m, err := manifest.Build(shardResultPath)
+	if err != nil {
+		return nil, err
+	}
+	return manifest.Encrypt(ctx, m, optimisticVerifier.encrypter, shard.Job.Status.Requester.RequesterPublicKey)
+}
+
+// GetShardConcurrency returns 2 for the sampled shards, and 1 for the others.
+func (optimisticVerifier *OptimisticVerifier) GetShardConcurrency(shard model.JobShard) int {
+	if IsSampled(shard) {
+		return sampledConcurrency
+	}
+	return 1
+}
+
+// IsSampled returns true if the shard is in the job's sample of shards that
+// are checked on a second node. The sample is random, but always the same for
+// a given job.
+func IsSampled(shard model.JobShard) bool {
+	rate := shard.Job.Spec.Verification.SampleRate
+	if rate <= 0 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(shard.Job.Metadata.ID + "/" + strconv.Itoa(shard.Index)))
+	return float64(binary.BigEndian.Uint64(sum[:]))/math.MaxUint64 < rate
+}
+
+func (optimisticVerifier *OptimisticVerifier) IsExecutionComplete(
+	ctx context.Context,
+	shard model.JobShard,
+) (bool, error) {
+	return optimisticVerifier.stateResolver.CheckShardStates(ctx, shard, func(
+		shardStates []model.JobShardState,
+		_ int,
+	) (bool, error) {
+		return optimisticVerifier.results.CheckShardStates(shardStates, optimisticVerifier.GetShardConcurrency(shard))
+	})
+}
+
+func (optimisticVerifier *OptimisticVerifier) VerifyShard(
+	ctx context.Context,
+	shard model.JobShard,
+) ([]verifier.VerifierResult, error) {
+	ctx, span := system.GetTracer().Start(ctx, "pkg/verifier/optimistic.VerifyShard")
+	defer span.End()
+
+	jobState, err := optimisticVerifier.stateResolver.GetJobState(ctx, shard.Job.Metadata.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	shardStates := job.GetStatesForShardIndex(jobState, shard.Index)
+	if len(shardStates) == 0 {
+		return nil, fmt.Errorf("job (%s) has no shard state for shard index %d", shard.Job.Metadata.ID, shard.Index)
+	}
+
+	var proposals []proposal
+	for _, shardState := range shardStates { //nolint:gocritic
+		// we've already called IsExecutionComplete so will assume any shard state
+		// that is not JobStateVerifying we can safely ignore
+		if shardState.State != model.JobStateVerifying {
+			continue
+		}
+		p := proposal{result: verifier.VerifierResult{
+			JobID:       shard.Job.Metadata.ID,
+			NodeID:      shardState.NodeID,
+			ExecutionID: shardState.ExecutionID,
+			ShardIndex:  shardState.ShardIndex,
+		}}
+		p.manifest, p.err = manifest.Decrypt(ctx, shardState.VerificationProposal, optimisticVerifier.decrypter)
+		proposals = append(proposals, p)
+	}
+
+	shardResults, err := verifyProposals(shard.Job.Spec.Verification, proposals)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range shardResults {
+		if !result.Verified {
+			log.Ctx(ctx).Warn().Msgf("rejecting results of node %s for shard %s: %s", result.NodeID, shard, result.Reason)
+		}
+	}
+	return shardResults, nil
+}
+
+type proposal struct {
+	result   verifier.VerifierResult
+	manifest manifest.Manifest
+	// set if the node didn't propose a readable manifest
+	err error
+}
+
+// verifyProposals decides which of a shard's results to accept. A single
+// result is accepted as is. Two results are accepted if they agree, otherwise
+// more executions are needed. With three or more results, the ones the
+// majority agrees on are accepted.
+func verifyProposals(config model.VerificationConfig, proposals []proposal) ([]verifier.VerifierResult, error) {
+	// group the results that agree with each other, comparing them to the first result of each group
+	var groups [][]int
+	var results []verifier.VerifierResult
+	for i, p := range proposals {
+		results = append(results, p.result)
+		if p.err != nil {
+			results[i].Reason = p.err.Error()
+			continue
+		}
+		found := false
+		for g, group := range groups {
+			if agree(config, proposals[group[0]].manifest, p.manifest) {
+				groups[g] = append(group, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			groups = append(groups, []int{i})
+		}
+	}
+
+	var largest []int
+	for _, group := range groups {
+		if len(group) > len(largest) {
+			largest = group
+		}
+	}
+
+	if len(largest) == 0 {
+		// no readable results
+		return results, nil
+	}
+	if len(largest) < len(proposals) && len(proposals) < escalatedConcurrency {
+		return nil, &verifier.MoreExecutionsNeeded{Executions: escalatedConcurrency}
+	}
+
+	// compare every result to one of the largest group, so mismatches can be diagnosed
+	reference := proposals[largest[0]]
+	for i := range results {
+		if proposals[i].err == nil && i != largest[0] {
+			results[i].Diff = diff(config, proposals[i].manifest, reference)
+		}
+	}
+
+	reason := "results differ from the majority"
+	if len(largest)*2 > len(proposals) {
+		for _, i := range largest {
+			results[i].Verified = true
+		}
+	} else {
+		reason = "no majority of the results agree"
+	}
+	for i := range results {
+		if !results[i].Verified && results[i].Reason == "" {
+			results[i].Reason = reason
+		}
+	}
+	return results, nil
+}
+
+// diff returns how a manifest differs from the reference one, or nil if it doesn't.
+func diff(config model.VerificationConfig, m manifest.Manifest, reference proposal) *model.ResultsDiff {
+	d := m.Without(config.IgnorePaths).Diff(reference.manifest.Without(config.IgnorePaths))
+	if d.IsEmpty() {
+		return nil
+	}
+	d.ComparedTo = reference.result.NodeID
+	return &d
+}
+
+// agree returns true if two manifests differ in no more files than allowed,
+// ignoring the files that are not compared.
+func agree(config model.VerificationConfig, a, b manifest.Manifest) bool {
+	diff := a.Without(config.IgnorePaths).Diff(b.Without(config.IgnorePaths))
+	return diff.Count() <= config.MaxDifferingFiles
+}
+
+// Compile-time check that OptimisticVerifier implements the correct interfaces:
+var _ verifier.Verifier = (*OptimisticVerifier)(nil)
+var _ verifier.ShardConcurrencyProvider = (*OptimisticVerifier)(nil)
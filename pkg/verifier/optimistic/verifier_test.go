@@ -0,0 +1,125 @@
+//go:build unit || !integration
+
+package optimistic
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/filecoin-project/bacalhau/pkg/model"
+	"github.com/filecoin-project/bacalhau/pkg/verifier"
+	"github.com/filecoin-project/bacalhau/pkg/verifier/manifest"
+	"github.com/stretchr/testify/require"
+)
+
+func newProposal(nodeID string, files ...string) proposal {
+	m := manifest.Manifest{}
+	for _, file := range files {
+		m.Files = append(m.Files, manifest.File{Path: file, Size: 1, Hash: file + "-hash"})
+	}
+	return proposal{result: verifier.VerifierResult{NodeID: nodeID}, manifest: m}
+}
+
+func verified(results []verifier.VerifierResult) map[string]bool {
+	v := make(map[string]bool, len(results))
+	for _, result := range results {
+		v[result.NodeID] = result.Verified
+	}
+	return v
+}
+
+func TestVerifyProposals(t *testing.T) {
+	config := model.VerificationConfig{}
+
+	t.Run("single", func(t *testing.T) {
+		results, err := verifyProposals(config, []proposal{newProposal("a", "x")})
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"a": true}, verified(results))
+	})
+
+	t.Run("single-unreadable", func(t *testing.T) {
+		results, err := verifyProposals(config, []proposal{{
+			result: verifier.VerifierResult{NodeID: "a"},
+			err:    fmt.Errorf("failed to decrypt"),
+		}})
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"a": false}, verified(results))
+		require.Equal(t, "failed to decrypt", results[0].Reason)
+	})
+
+	t.Run("sample-agrees", func(t *testing.T) {
+		results, err := verifyProposals(config, []proposal{newProposal("a", "x"), newProposal("b", "x")})
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"a": true, "b": true}, verified(results))
+	})
+
+	t.Run("sample-differs", func(t *testing.T) {
+		_, err := verifyProposals(config, []proposal{newProposal("a", "x"), newProposal("b", "y")})
+		var moreExecutions *verifier.MoreExecutionsNeeded
+		require.True(t, errors.As(err, &moreExecutions))
+		require.Equal(t, 3, moreExecutions.Executions)
+	})
+
+	t.Run("majority", func(t *testing.T) {
+		results, err := verifyProposals(config, []proposal{
+			newProposal("a", "x"), newProposal("b", "y"), newProposal("c", "x"),
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"a": true, "b": false, "c": true}, verified(results))
+		require.Equal(t, "results differ from the majority", results[1].Reason)
+		require.Nil(t, results[2].Diff)
+		require.Equal(t, &model.ResultsDiff{ComparedTo: "a", Missing: []string{"x"}, Extra: []string{"y"}}, results[1].Diff)
+	})
+
+	t.Run("no-majority", func(t *testing.T) {
+		results, err := verifyProposals(config, []proposal{
+			newProposal("a", "x"), newProposal("b", "y"), newProposal("c", "z"),
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"a": false, "b": false, "c": false}, verified(results))
+	})
+
+	t.Run("ignored-paths", func(t *testing.T) {
+		a := newProposal("a", "x")
+		b := newProposal("b", "x", "run.log")
+		_, err := verifyProposals(config, []proposal{a, b})
+		require.Error(t, err)
+
+		results, err := verifyProposals(model.VerificationConfig{IgnorePaths: []string{"*.log"}}, []proposal{a, b})
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"a": true, "b": true}, verified(results))
+	})
+
+	t.Run("max-differing-files", func(t *testing.T) {
+		results, err := verifyProposals(model.VerificationConfig{MaxDifferingFiles: 1}, []proposal{
+			newProposal("a", "x"), newProposal("b", "x", "y"),
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"a": true, "b": true}, verified(results))
+	})
+}
+
+func TestIsSampled(t *testing.T) {
+	newShard := func(rate float64, index int) model.JobShard {
+		return model.JobShard{
+			Job: &model.Job{
+				Metadata: model.Metadata{ID: "job-id"},
+				Spec:     model.Spec{Verification: model.VerificationConfig{SampleRate: rate}},
+			},
+			Index: index,
+		}
+	}
+
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		require.False(t, IsSampled(newShard(0, i)))
+		require.True(t, IsSampled(newShard(1, i)))
+		if IsSampled(newShard(0.25, i)) {
+			sampled++
+		}
+		// the sample doesn't change
+		require.Equal(t, IsSampled(newShard(0.25, i)), IsSampled(newShard(0.25, i)))
+	}
+	require.InDelta(t, 250, sampled, 50)
+}
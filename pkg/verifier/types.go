@@ -2,6 +2,7 @@ package verifier
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/filecoin-project/bacalhau/pkg/model"
 )
@@ -15,6 +16,29 @@ type VerifierResult struct {
 	ExecutionID string
 	ShardIndex  int
 	Verified    bool
+	// optional explanation of why the result was rejected
+	Reason string
+	// how the result files differ from the ones they were compared to, if they do
+	Diff *model.ResultsDiff
+}
+
+// ShardConcurrencyProvider is implemented by verifiers that decide how many
+// times each shard runs, instead of running every shard Deal.Concurrency
+// times.
+type ShardConcurrencyProvider interface {
+	GetShardConcurrency(shard model.JobShard) int
+}
+
+// MoreExecutionsNeeded is returned by VerifyShard when the results can't be
+// verified either way, and the shard should run on more nodes before it is
+// verified again.
+type MoreExecutionsNeeded struct {
+	// how many executions of the shard are needed in total
+	Executions int
+}
+
+func (e *MoreExecutionsNeeded) Error() string {
+	return fmt.Sprintf("results are inconclusive, %d executions are needed", e.Executions)
 }
 
 // Returns a verifier that can be used to verify a job.
@@ -9,6 +9,7 @@ import (
 	"github.com/filecoin-project/bacalhau/pkg/verifier"
 	"github.com/filecoin-project/bacalhau/pkg/verifier/deterministic"
 	"github.com/filecoin-project/bacalhau/pkg/verifier/noop"
+	"github.com/filecoin-project/bacalhau/pkg/verifier/optimistic"
 )
 
 func NewStandardVerifiers(
@@ -17,6 +18,7 @@ func NewStandardVerifiers(
 	resolver *job.StateResolver,
 	encrypter verifier.EncrypterFunction,
 	decrypter verifier.DecrypterFunction,
+	legacyDecrypter verifier.DecrypterFunction,
 ) (verifier.VerifierProvider, error) {
 	noopVerifier, err := noop.NewNoopVerifier(
 		ctx,
@@ -33,6 +35,18 @@ func NewStandardVerifiers(
 		resolver,
 		encrypter,
 		decrypter,
+		legacyDecrypter,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	optimisticVerifier, err := optimistic.NewOptimisticVerifier(
+		ctx,
+		cm,
+		resolver,
+		encrypter,
+		decrypter,
 	)
 	if err != nil {
 		return nil, err
@@ -41,6 +55,7 @@ func NewStandardVerifiers(
 	return verifier.NewMappedVerifierProvider(map[model.Verifier]verifier.Verifier{
 		model.VerifierNoop:          noopVerifier,
 		model.VerifierDeterministic: deterministicVerifier,
+		model.VerifierOptimistic:    optimisticVerifier,
 	}), nil
 }
 